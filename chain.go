@@ -0,0 +1,92 @@
+package main
+
+import (
+	"net/http"
+	"slices"
+
+	"shawty/internal/config"
+	"shawty/internal/handler"
+	"shawty/internal/logger"
+	"shawty/internal/metrics"
+	"shawty/internal/middleware"
+
+	"go.opentelemetry.io/contrib/instrumentation/net/http/otelhttp"
+)
+
+// apiPaths returns the versioned form of each API path, plus the unversioned legacy path
+// while legacy routes are enabled, so that middleware covers every route that serves it.
+func apiPaths(cfg config.AppConfig, paths ...string) []string {
+	prefix := handler.APIPrefix("v" + apiVersion)
+	result := make([]string, 0, 2*len(paths))
+	for _, p := range paths {
+		result = append(result, prefix+p)
+		if cfg.LegacyRoutesEnabled {
+			result = append(result, p)
+		}
+	}
+	return result
+}
+
+// newRootHandler wraps the routes of mux in the middleware chain every request goes through.
+// Responses to requests with an idempotency key are kept in idempotencyCache for replay.
+func newRootHandler(cfg config.AppConfig, mux *http.ServeMux, idempotencyCache middleware.IdempotencyCache) http.Handler {
+	// Only link creation is rate limited; redirects stay unthrottled.
+	rateLimit := middleware.ForPaths(
+		middleware.NewRateLimiter(cfg.RateLimit.RequestsPerMinute, cfg.RateLimit.Burst),
+		apiPaths(cfg, "/shorten", "/shorten/bulk")...,
+	)
+
+	// Link creation and admin endpoints require an API key. The admin token doubles as a key
+	// so that admin requests get through to the handler's own admin check.
+	apiKeys := make(map[string]string, len(cfg.APIKeys)+1)
+	for key, owner := range cfg.APIKeys {
+		apiKeys[key] = owner
+	}
+	if cfg.AdminToken != "" {
+		apiKeys[cfg.AdminToken] = handler.AdminOwner
+	}
+	requireAPIKey := middleware.ForPaths(middleware.NewAPIKeyMiddleware(apiKeys), apiPaths(cfg, "/shorten", "/shorten/bulk", "/shorten/token", "/admin/")...)
+
+	// Bodies are capped before anything reads them. Bulk requests carry up to 100 URLs and imports
+	// apply their own, larger limit.
+	bulkPaths := apiPaths(cfg, "/shorten/bulk")
+	limitBulkBody := middleware.ForPaths(middleware.NewMaxBodyMiddleware(cfg.MaxBulkBodyBytes), bulkPaths...)
+	limitBody := middleware.ExceptPaths(middleware.NewMaxBodyMiddleware(cfg.MaxRequestBodyBytes), append(bulkPaths, apiPaths(cfg, "/admin/import")...)...)
+
+	// Redirects must be quick and imports may take a while. Exports stream the whole database and
+	// click streams stay open, so they are not cut short.
+	redirectPaths := apiPaths(cfg, "/r/")
+	importPaths := apiPaths(cfg, "/admin/import")
+	redirectTimeout := middleware.ForPaths(middleware.NewTimeoutMiddleware(cfg.RedirectTimeout), redirectPaths...)
+	importTimeout := middleware.ForPaths(middleware.NewTimeoutMiddleware(cfg.ImportTimeout), importPaths...)
+	requestTimeout := middleware.ExceptPaths(middleware.NewTimeoutMiddleware(cfg.RequestTimeout), slices.Concat(redirectPaths, importPaths, apiPaths(cfg, "/admin/export", "/admin/events/"))...)
+
+	// Middleware is applied innermost first, so the audit actor is recorded closest to the routes,
+	// after the API key check has resolved the key's owner, and tracing wraps everything.
+	// Idempotent responses are replayed after the key check, so a replay still needs a valid key.
+	// Rate limiting runs before the key check so that key guessing is throttled too.
+	// Body limits are in place before the idempotency middleware reads the body.
+	// Panics are recovered right around the routes, so the request logger still sees the 500.
+	// Timeouts only cover the routes themselves.
+	var root http.Handler = mux
+	root = middleware.NewRecoveryMiddleware(logger.Logger)(root)
+	root = redirectTimeout(root)
+	root = importTimeout(root)
+	root = requestTimeout(root)
+	root = middleware.NewAuditActorMiddleware()(root)
+	root = middleware.NewIdempotencyMiddleware(idempotencyCache, middleware.IdempotencyTTL)(root)
+	root = requireAPIKey(root)
+	root = rateLimit(root)
+	root = limitBulkBody(root)
+	root = limitBody(root)
+	root = middleware.NewGzipMiddleware()(root)
+	root = metrics.PrometheusMiddleware(mux)(root)
+	root = middleware.NewCORSMiddleware(cfg.CORSAllowedOrigins)(root)
+	root = middleware.NewSecureHeadersMiddleware(cfg.Security.HSTS, cfg.Security.ExpectCTMaxAge, cfg.Security.PermissionsPolicy)(root)
+	root = middleware.NewAPIVersionMiddleware(apiVersion)(root)
+	root = middleware.NewRequestLogger(logger.Logger)(root)
+	root = middleware.NewRealIPMiddleware(cfg.TrustedProxies)(root)
+	root = middleware.NewRequestIDMiddleware()(root)
+	root = otelhttp.NewHandler(root, "shawty")
+	return root
+}
@@ -0,0 +1,222 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"shawty/internal/cache"
+	"shawty/internal/config"
+	"shawty/internal/handler"
+	"shawty/internal/middleware"
+	"shawty/internal/service"
+	"shawty/internal/store"
+)
+
+// newTestServer serves the routes of a service on an in-memory store through the full
+// middleware chain.
+func newTestServer(t *testing.T, cfg config.AppConfig) *httptest.Server {
+	t.Helper()
+	urlHandler, err := handler.NewURLHandler(service.NewUrlService(store.NewMemoryUrlStore()), cfg)
+	if err != nil {
+		t.Fatalf("NewURLHandler: %v", err)
+	}
+	mux := http.NewServeMux()
+	urlHandler.RegisterRoutes(mux, "v"+apiVersion)
+	server := httptest.NewServer(newRootHandler(cfg, mux, cache.NewByteCache(100)))
+	t.Cleanup(server.Close)
+	return server
+}
+
+// testAppConfig returns the settings the server runs with by default, with the dashboard enabled
+// and one API key, dash-key.
+func testAppConfig() config.AppConfig {
+	return config.AppConfig{
+		DashboardEnabled:    true,
+		APIKeys:             map[string]string{"dash-key": "alice"},
+		RateLimit:           config.RateLimitConfig{RequestsPerMinute: 600, Burst: 10},
+		MaxRequestBodyBytes: 1 << 20,
+		MaxBulkBodyBytes:    1 << 20,
+		RequestTimeout:      5 * time.Second,
+		RedirectTimeout:     5 * time.Second,
+		ImportTimeout:       5 * time.Second,
+	}
+}
+
+// get fetches target with client and returns the response with its body read.
+func get(t *testing.T, client *http.Client, target string) (*http.Response, string) {
+	t.Helper()
+	resp, err := client.Get(target)
+	if err != nil {
+		t.Fatalf("GET %s: %v", target, err)
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatalf("reading %s: %v", target, err)
+	}
+	return resp, string(body)
+}
+
+// TestDashboardShortensThroughTheMiddlewareChain makes the requests app.js makes, with the
+// API key the dashboard asks for.
+func TestDashboardShortensThroughTheMiddlewareChain(t *testing.T) {
+	server := newTestServer(t, testAppConfig())
+	client := server.Client()
+
+	if resp, page := get(t, client, server.URL+"/dashboard/"); resp.StatusCode != http.StatusOK || !strings.Contains(page, `id="api-key-input"`) {
+		t.Fatalf("dashboard page: status = %d; want 200 with an API key field", resp.StatusCode)
+	}
+	if _, script := get(t, client, server.URL+"/dashboard/app.js"); !strings.Contains(script, `"Authorization": "Bearer " + apiKey`) {
+		t.Fatal("app.js does not send the API key with its shorten requests")
+	}
+	resp, body := get(t, client, server.URL+"/dashboard/config.json")
+	var dashboardCfg handler.DashboardConfigResponse
+	if err := json.Unmarshal([]byte(body), &dashboardCfg); err != nil || resp.StatusCode != http.StatusOK {
+		t.Fatalf("config.json: status = %d, body %s: %v", resp.StatusCode, body, err)
+	}
+	if want := server.URL + "/api/v1"; dashboardCfg.BaseURL != want {
+		t.Fatalf("base_url = %q, want %q", dashboardCfg.BaseURL, want)
+	}
+
+	shorten := func(apiKey string) (*http.Response, []byte) {
+		t.Helper()
+		req, err := http.NewRequest(http.MethodPost, dashboardCfg.BaseURL+"/shorten", strings.NewReader(`{"url": "https://example.com/from-the-dashboard"}`))
+		if err != nil {
+			t.Fatalf("building request: %v", err)
+		}
+		req.Header.Set("Content-Type", "application/json")
+		if apiKey != "" {
+			req.Header.Set("Authorization", "Bearer "+apiKey)
+		}
+		resp, err := client.Do(req)
+		if err != nil {
+			t.Fatalf("POST /shorten: %v", err)
+		}
+		defer resp.Body.Close()
+		body, _ := io.ReadAll(resp.Body)
+		return resp, body
+	}
+
+	// Without a key, or with a wrong one, the error body is JSON the dashboard can show.
+	for _, apiKey := range []string{"", "wrong-key"} {
+		resp, body := shorten(apiKey)
+		var errBody handler.ErrorResponse
+		if err := json.Unmarshal(body, &errBody); err != nil {
+			t.Fatalf("error body %q is not JSON: %v", body, err)
+		}
+		if resp.StatusCode != http.StatusUnauthorized || errBody.Code != "UNAUTHORIZED" || errBody.Error == "" {
+			t.Errorf("key %q: status = %d, body %s; want 401 with code UNAUTHORIZED and a message", apiKey, resp.StatusCode, body)
+		}
+	}
+
+	resp, createdBody := shorten("dash-key")
+	if resp.StatusCode != http.StatusCreated {
+		t.Fatalf("status = %d, body %s; want 201", resp.StatusCode, createdBody)
+	}
+	var created handler.ShortenURLResponse
+	if err := json.Unmarshal(createdBody, &created); err != nil {
+		t.Fatalf("decoding response: %v", err)
+	}
+	if !strings.HasPrefix(created.ShortURL, dashboardCfg.BaseURL+"/r/") {
+		t.Fatalf("short_url = %q, want a link under %s/r/", created.ShortURL, dashboardCfg.BaseURL)
+	}
+
+	// The link the dashboard lists redirects without a key.
+	noRedirects := *client
+	noRedirects.CheckRedirect = func(*http.Request, []*http.Request) error { return http.ErrUseLastResponse }
+	if resp, _ := get(t, &noRedirects, created.ShortURL); resp.StatusCode != http.StatusFound || resp.Header.Get("Location") != "https://example.com/from-the-dashboard" {
+		t.Errorf("short link: status = %d, Location = %q; want a redirect to the original URL", resp.StatusCode, resp.Header.Get("Location"))
+	}
+}
+
+func TestAPIPaths(t *testing.T) {
+	if got := apiPaths(config.AppConfig{}, "/shorten", "/r/"); !slices.Equal(got, []string{"/api/v1/shorten", "/api/v1/r/"}) {
+		t.Errorf("apiPaths without legacy routes = %q, want only the versioned paths", got)
+	}
+	got := apiPaths(config.AppConfig{LegacyRoutesEnabled: true}, "/shorten", "/r/")
+	if want := []string{"/api/v1/shorten", "/shorten", "/api/v1/r/", "/r/"}; !slices.Equal(got, want) {
+		t.Errorf("apiPaths with legacy routes = %q, want %q", got, want)
+	}
+}
+
+func TestVersionedAndLegacyRoutes(t *testing.T) {
+	for _, legacy := range []bool{true, false} {
+		t.Run(fmt.Sprintf("legacy routes enabled %v", legacy), func(t *testing.T) {
+			cfg := testAppConfig()
+			cfg.LegacyRoutesEnabled = legacy
+			server := newTestServer(t, cfg)
+			client := *server.Client()
+			client.CheckRedirect = func(*http.Request, []*http.Request) error { return http.ErrUseLastResponse }
+
+			// do sends a request and checks that the response reports the API version, whatever its status.
+			do := func(method, target, apiKey string) (int, []byte) {
+				t.Helper()
+				req, err := http.NewRequest(method, server.URL+target, strings.NewReader(`{"url": "https://example.com/versioned"}`))
+				if err != nil {
+					t.Fatalf("building request: %v", err)
+				}
+				if apiKey != "" {
+					req.Header.Set("Authorization", "Bearer "+apiKey)
+				}
+				resp, err := client.Do(req)
+				if err != nil {
+					t.Fatalf("%s %s: %v", method, target, err)
+				}
+				defer resp.Body.Close()
+				body, _ := io.ReadAll(resp.Body)
+				if got := resp.Header.Get(middleware.APIVersionHeader); got != apiVersion {
+					t.Errorf("%s %s: %s = %q, want %q", method, target, middleware.APIVersionHeader, got, apiVersion)
+				}
+				return resp.StatusCode, body
+			}
+
+			status, body := do(http.MethodPost, "/api/v1/shorten", "dash-key")
+			if status != http.StatusCreated {
+				t.Fatalf("POST /api/v1/shorten: status = %d, want 201; body %s", status, body)
+			}
+			var created handler.ShortenURLResponse
+			if err := json.Unmarshal(body, &created); err != nil {
+				t.Fatalf("decoding response: %v", err)
+			}
+			// Short links handed out always use the versioned path.
+			shortID, ok := strings.CutPrefix(created.ShortURL, server.URL+"/api/v1/r/")
+			if !ok {
+				t.Fatalf("short_url = %q, want a link under /api/v1/r/", created.ShortURL)
+			}
+
+			legacyStatus := func(want int) int {
+				if legacy {
+					return want
+				}
+				return http.StatusNotFound
+			}
+			tests := []struct {
+				method, target, apiKey string
+				want                   int
+			}{
+				{method: http.MethodGet, target: "/api/v1/r/" + shortID, want: http.StatusFound},
+				{method: http.MethodGet, target: "/r/" + shortID, want: legacyStatus(http.StatusFound)},
+				{method: http.MethodGet, target: "/api/v1/r/" + shortID + "/info", want: http.StatusOK},
+				{method: http.MethodGet, target: "/r/" + shortID + "/info", want: legacyStatus(http.StatusOK)},
+				{method: http.MethodPost, target: "/shorten", apiKey: "dash-key", want: legacyStatus(http.StatusCreated)},
+				// The legacy paths need the API key as much as the versioned ones.
+				{method: http.MethodPost, target: "/api/v1/shorten", want: http.StatusUnauthorized},
+				{method: http.MethodPost, target: "/shorten", want: legacyStatus(http.StatusUnauthorized)},
+				{method: http.MethodGet, target: "/admin/urls", want: legacyStatus(http.StatusUnauthorized)},
+				{method: http.MethodGet, target: "/admin/urls", apiKey: "dash-key", want: legacyStatus(http.StatusOK)},
+			}
+			for _, tt := range tests {
+				if status, body := do(tt.method, tt.target, tt.apiKey); status != tt.want {
+					t.Errorf("%s %s: status = %d, want %d; body %s", tt.method, tt.target, status, tt.want, body)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,215 @@
+// Command cli shortens and manages links on a shawty server from the terminal.
+//
+// Usage:
+//
+//	shawty-cli [flags] shorten <url>
+//	shawty-cli [flags] info <shortID>
+//	shawty-cli [flags] stats <shortID>
+//	shawty-cli [flags] delete <shortID>
+//
+// The API key is taken from -key or SHAWTY_API_KEY; delete needs the server's master admin token.
+// The CLI only uses the standard library and talks to the server over its public HTTP API, so it
+// builds on its own: go build -ldflags "-X main.version=1.2.3" -o shawty-cli ./cmd/cli
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+)
+
+// version is set at build time with -ldflags "-X main.version=<version>".
+var version = "dev"
+
+// apiPrefix is the path prefix of the server's versioned API.
+const apiPrefix = "/api/v1"
+
+// client calls the API of one server.
+type client struct {
+	server string
+	key    string
+	http   *http.Client
+}
+
+func main() {
+	flags := flag.NewFlagSet("shawty-cli", flag.ContinueOnError)
+	flags.Usage = func() {
+		fmt.Fprintf(flags.Output(), "Usage: shawty-cli [flags] shorten <url> | info <shortID> | stats <shortID> | delete <shortID>\n\nFlags:\n")
+		flags.PrintDefaults()
+	}
+	server := flags.String("server", envOr("SHAWTY_SERVER", "http://localhost:8080"), "base URL of the shawty server (or SHAWTY_SERVER)")
+	key := flags.String("key", os.Getenv("SHAWTY_API_KEY"), "API key sent as a bearer token (or SHAWTY_API_KEY)")
+	asJSON := flags.Bool("json", false, "print the server's response as indented JSON")
+	showVersion := flags.Bool("version", false, "print the version and exit")
+	timeout := flags.Duration("timeout", 10*time.Second, "timeout of each request")
+	if err := flags.Parse(os.Args[1:]); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return
+		}
+		os.Exit(2)
+	}
+	if *showVersion {
+		fmt.Println("shawty-cli", version)
+		return
+	}
+	if flags.NArg() != 2 {
+		flags.Usage()
+		os.Exit(2)
+	}
+
+	c := &client{server: strings.TrimRight(*server, "/"), key: *key, http: &http.Client{Timeout: *timeout}}
+	if err := run(c, flags.Arg(0), flags.Arg(1), *asJSON, os.Stdout); err != nil {
+		fmt.Fprintln(os.Stderr, "shawty-cli:", err)
+		os.Exit(1)
+	}
+}
+
+// run executes one command and writes its result to out.
+func run(c *client, command, arg string, asJSON bool, out io.Writer) error {
+	var (
+		body []byte
+		err  error
+	)
+	switch command {
+	case "shorten":
+		payload, _ := json.Marshal(map[string]string{"url": arg})
+		body, err = c.do(http.MethodPost, apiPrefix+"/shorten", payload)
+	case "info":
+		body, err = c.do(http.MethodGet, apiPrefix+"/r/"+url.PathEscape(arg)+"/info", nil)
+	case "stats":
+		body, err = c.do(http.MethodGet, apiPrefix+"/stats/"+url.PathEscape(arg), nil)
+	case "delete":
+		if _, err := c.do(http.MethodDelete, apiPrefix+"/r/"+url.PathEscape(arg), nil); err != nil {
+			return err
+		}
+		fmt.Fprintf(out, "Deleted %s\n", arg)
+		return nil
+	default:
+		return fmt.Errorf("unknown command %q; use shorten, info, stats or delete", command)
+	}
+	if err != nil {
+		return err
+	}
+
+	if asJSON {
+		var indented bytes.Buffer
+		if err := json.Indent(&indented, bytes.TrimSpace(body), "", "  "); err != nil {
+			return fmt.Errorf("server sent invalid JSON: %w", err)
+		}
+		indented.WriteByte('\n')
+		_, err := indented.WriteTo(out)
+		return err
+	}
+	return printText(out, command, body)
+}
+
+// do sends a request to the server and returns the response body. Responses other than 2xx
+// become errors carrying the server's message.
+func (c *client) do(method, path string, payload []byte) ([]byte, error) {
+	req, err := http.NewRequest(method, c.server+path, bytes.NewReader(payload))
+	if err != nil {
+		return nil, fmt.Errorf("invalid server URL: %w", err)
+	}
+	if payload != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if c.key != "" {
+		req.Header.Set("Authorization", "Bearer "+c.key)
+	}
+	req.Header.Set("User-Agent", "shawty-cli/"+version)
+
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read response: %w", err)
+	}
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return nil, fmt.Errorf("server answered %s: %s", resp.Status, strings.TrimSpace(string(body)))
+	}
+	return body, nil
+}
+
+// printText writes the response of command in a human-readable form.
+func printText(out io.Writer, command string, body []byte) error {
+	var fields map[string]any
+	if err := json.Unmarshal(body, &fields); err != nil {
+		return fmt.Errorf("server sent invalid JSON: %w", err)
+	}
+	var rows [][2]string
+	switch command {
+	case "shorten":
+		fmt.Fprintln(out, text(fields["short_url"]))
+		return nil
+	case "info":
+		rows = [][2]string{
+			{"Short ID", text(fields["id"])},
+			{"Destination", text(fields["original_url"])},
+			{"Created", text(fields["creation_date"])},
+			{"Expires", text(fields["expires_at"])},
+			{"Clicks", text(fields["click_count"])},
+			{"Tags", text(fields["tags"])},
+			{"Title", text(fields["page_title"])},
+			{"Expired", text(fields["is_expired"])},
+			{"Password", text(fields["password_protected"])},
+		}
+	case "stats":
+		rows = [][2]string{
+			{"Short URL", text(fields["short_url"])},
+			{"Destination", text(fields["original_url"])},
+			{"Clicks", text(fields["click_count"])},
+			{"Mobile clicks", text(fields["mobile_click_count"])},
+			{"Last visit", text(fields["last_accessed_at"])},
+			{"Created", text(fields["creation_date"])},
+		}
+	}
+	for _, row := range rows {
+		fmt.Fprintf(out, "%-14s %s\n", row[0]+":", row[1])
+	}
+	return nil
+}
+
+// text formats a decoded JSON value for display; missing and null values print as "-".
+func text(v any) string {
+	switch v := v.(type) {
+	case nil:
+		return "-"
+	case string:
+		if v == "" {
+			return "-"
+		}
+		return v
+	case float64:
+		return fmt.Sprintf("%.0f", v)
+	case []any:
+		parts := make([]string, len(v))
+		for i, item := range v {
+			parts[i] = text(item)
+		}
+		if len(parts) == 0 {
+			return "-"
+		}
+		return strings.Join(parts, ", ")
+	default:
+		return fmt.Sprint(v)
+	}
+}
+
+// envOr returns the environment variable key, or def when it is unset.
+func envOr(key, def string) string {
+	if v := os.Getenv(key); v != "" {
+		return v
+	}
+	return def
+}
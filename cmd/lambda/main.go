@@ -0,0 +1,177 @@
+// Command lambda runs shawty as an AWS Lambda function behind an API Gateway REST API with
+// Lambda proxy integration. Requests are translated to net/http by httpadapter and served by the
+// same routes as the standalone server.
+//
+// The function is configured with the server's environment variables. It uses MongoDB, so MONGO_URI
+// is required, unless STORE_BACKEND=atlas selects the Atlas Data API, which needs ATLAS_APP_ID and
+// ATLAS_API_KEY instead and holds no connections between invocations, or STORE_BACKEND=dynamo
+// selects a DynamoDB table named by DYNAMO_TABLE_NAME in AWS_REGION; the audit log and tenant
+// quotas are only available with MongoDB. MONGO_DB_NAME, MONGO_COLLECTION_NAME, API_KEYS,
+// MASTER_ADMIN_TOKEN and BASE_URL are read as usual. BASE_URL should be set, since requests arrive with the API Gateway
+// host name. PORT and the TLS settings are ignored: API Gateway terminates HTTPS. Indexes are only
+// created at cold start with AUTO_MIGRATE=true; otherwise run cmd/migrate up before deploying.
+//
+// The execution role needs no permissions beyond logging, as in the AWSLambdaBasicExecutionRole
+// managed policy (logs:CreateLogGroup, logs:CreateLogStream, logs:PutLogEvents), and with DynamoDB
+// the item actions on its table and index: dynamodb:GetItem, PutItem, UpdateItem, DeleteItem,
+// BatchGetItem, Query, Scan and DescribeTable, plus CreateTable and UpdateTable with AUTO_MIGRATE. A function that
+// reaches MongoDB through a VPC also needs ec2:CreateNetworkInterface, ec2:DescribeNetworkInterfaces
+// and ec2:DeleteNetworkInterface, as in AWSLambdaVPCAccessExecutionRole. MongoDB itself is accessed
+// with the credentials in MONGO_URI, unless that URI uses MONGODB-AWS authentication, in which case
+// the execution role must be mapped to a database user.
+//
+// Lambda freezes the function between invocations, so work that outlives a response cannot be
+// relied on: the event bus is not set up, and first-click webhooks and page metadata are not sent
+// or stored.
+package main
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+	"slices"
+	"testing"
+	"time"
+
+	"shawty/internal/cache"
+	"shawty/internal/cdn"
+	"shawty/internal/config"
+	"shawty/internal/docs"
+	"shawty/internal/handler"
+	"shawty/internal/logger"
+	"shawty/internal/middleware"
+	"shawty/internal/service"
+	"shawty/internal/store"
+
+	"github.com/aws/aws-lambda-go/lambda"
+	"github.com/awslabs/aws-lambda-go-api-proxy/httpadapter"
+)
+
+// apiVersion is the version of the HTTP API, served under /api/v{apiVersion}.
+const apiVersion = "1"
+
+// adapter serves the API Gateway events. It is built once per execution environment, in init, so
+// that warm invocations reuse the MongoDB connection pool.
+var adapter *httpadapter.HandlerAdapter
+
+func init() {
+	// Tests build their handler with newRootHandler on a store of their own.
+	if testing.Testing() {
+		return
+	}
+	cfg := config.LoadConfig()
+
+	opts := []service.Option{
+		service.WithHashAlgo(cfg.HashAlgo),
+		service.WithShortIDLength(cfg.ShortIDLength),
+		service.WithMaxCollisionRetries(cfg.MaxCollisionRetries),
+		service.WithBulkConcurrency(cfg.BulkConcurrency),
+	}
+	var urlStore interface {
+		store.UrlStoreInterface
+		store.HealthChecker
+	}
+	switch cfg.StoreBackend {
+	case config.StoreBackendAtlas:
+		httpClient := &http.Client{Timeout: cfg.Atlas.Timeout}
+		urlStore = store.NewAtlasDataAPIStore(httpClient, cfg.Atlas.URL, cfg.Atlas.APIKey, cfg.Atlas.DataSource, cfg.DB.DBName, cfg.DB.CollectionName)
+	case config.StoreBackendDynamo:
+		client, err := config.ConnectDynamo(cfg.Dynamo)
+		if err != nil {
+			logger.Fatal("Failed to create the DynamoDB client", slog.Any("error", err))
+		}
+		urlStore = store.NewDynamoUrlStore(client, cfg.Dynamo.TableName)
+	default:
+		dbClient, err := config.ConnectDB(cfg.DB)
+		if err != nil {
+			logger.Fatal("Failed to connect to database", slog.Any("error", err))
+		}
+		mongoStore := store.NewMongoUrlStore(dbClient, cfg.DB.DBName, cfg.DB.CollectionName)
+		opts = append(opts, service.WithAuditStore(mongoStore), service.WithQuotaStore(mongoStore), service.WithDomainStatsStore(mongoStore), service.WithOwnershipStore(mongoStore))
+		urlStore = mongoStore
+	}
+	if cfg.Cloudflare.Enabled() {
+		purger := cdn.NewCloudflarePurger(&http.Client{Timeout: cdn.CloudflareTimeout}, cdn.CloudflareAPIURL, cfg.Cloudflare.ZoneID, cfg.Cloudflare.APIToken)
+		opts = append(opts, service.WithCDNPurger(purger))
+	}
+	// As in the standalone server, MongoDB indexes are left to cmd/migrate unless AUTO_MIGRATE is set.
+	if cfg.AutoMigrate {
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+		if err := urlStore.EnsureIndexes(ctx); err != nil {
+			logger.Fatal("Failed to ensure database indexes", slog.Any("error", err))
+		}
+	}
+
+	adapter = httpadapter.New(newRootHandler(cfg, urlStore, opts))
+}
+
+// newRootHandler serves the routes of the standalone server on urlStore, wrapped in the middleware
+// that works without a long-running process.
+func newRootHandler(cfg config.AppConfig, urlStore interface {
+	store.UrlStoreInterface
+	store.HealthChecker
+}, opts []service.Option) http.Handler {
+	urlSvc := service.NewUrlService(urlStore, opts...)
+
+	mux := http.NewServeMux()
+	urlHandler, err := handler.NewURLHandler(urlSvc, cfg)
+	if err != nil {
+		logger.Fatal("Failed to create the URL handler", slog.Any("error", err))
+	}
+	urlHandler.RegisterRoutes(mux, "v"+apiVersion)
+	handler.NewHealthHandler(urlStore, nil).RegisterRoutes(mux, cfg.LivenessPath, cfg.ReadinessPath)
+	apiDocs, err := docs.NewHandler()
+	if err != nil {
+		logger.Fatal("Failed to build the OpenAPI document", slog.Any("error", err))
+	}
+	apiDocs.RegisterRoutes(mux)
+
+	// The same keys as the server guard link creation and admin endpoints. Rate limiting is left to
+	// API Gateway usage plans, since every execution environment would keep its own counters.
+	apiKeys := make(map[string]string, len(cfg.APIKeys)+1)
+	for key, owner := range cfg.APIKeys {
+		apiKeys[key] = owner
+	}
+	if cfg.AdminToken != "" {
+		apiKeys[cfg.AdminToken] = handler.AdminOwner
+	}
+	prefix := handler.APIPrefix("v" + apiVersion)
+	protected := []string{prefix + "/shorten", prefix + "/shorten/bulk", prefix + "/shorten/token", prefix + "/admin/"}
+	if cfg.LegacyRoutesEnabled {
+		protected = append(protected, "/shorten", "/shorten/bulk", "/shorten/token", "/admin/")
+	}
+
+	bulkPaths := []string{prefix + "/shorten/bulk"}
+	importPaths := []string{prefix + "/admin/import"}
+	redirectPaths := []string{prefix + "/r/"}
+	exportPaths := []string{prefix + "/admin/export"}
+	if cfg.LegacyRoutesEnabled {
+		bulkPaths = append(bulkPaths, "/shorten/bulk")
+		importPaths = append(importPaths, "/admin/import")
+		redirectPaths = append(redirectPaths, "/r/")
+		exportPaths = append(exportPaths, "/admin/export")
+	}
+
+	var root http.Handler = mux
+	root = middleware.NewRecoveryMiddleware(logger.Logger)(root)
+	root = middleware.ForPaths(middleware.NewTimeoutMiddleware(cfg.RedirectTimeout), redirectPaths...)(root)
+	root = middleware.ForPaths(middleware.NewTimeoutMiddleware(cfg.ImportTimeout), importPaths...)(root)
+	root = middleware.ExceptPaths(middleware.NewTimeoutMiddleware(cfg.RequestTimeout), slices.Concat(redirectPaths, importPaths, exportPaths)...)(root)
+	root = middleware.NewAuditActorMiddleware()(root)
+	// Without Redis, a retried request is only recognised by the execution environment that served it.
+	root = middleware.NewIdempotencyMiddleware(cache.NewByteCache(cfg.CacheSize), middleware.IdempotencyTTL)(root)
+	root = middleware.ForPaths(middleware.NewAPIKeyMiddleware(apiKeys), protected...)(root)
+	root = middleware.ForPaths(middleware.NewMaxBodyMiddleware(cfg.MaxBulkBodyBytes), bulkPaths...)(root)
+	root = middleware.ExceptPaths(middleware.NewMaxBodyMiddleware(cfg.MaxRequestBodyBytes), append(bulkPaths, importPaths...)...)(root)
+	root = middleware.NewSecureHeadersMiddleware(cfg.Security.HSTS, cfg.Security.ExpectCTMaxAge, cfg.Security.PermissionsPolicy)(root)
+	root = middleware.NewAPIVersionMiddleware(apiVersion)(root)
+	root = middleware.NewRequestLogger(logger.Logger)(root)
+	root = middleware.NewRealIPMiddleware(cfg.TrustedProxies)(root)
+	root = middleware.NewRequestIDMiddleware()(root)
+	return root
+}
+
+func main() {
+	lambda.Start(adapter.ProxyWithContext)
+}
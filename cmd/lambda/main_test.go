@@ -0,0 +1,89 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"shawty/internal/config"
+	"shawty/internal/handler"
+	"shawty/internal/store"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/awslabs/aws-lambda-go-api-proxy/httpadapter"
+)
+
+func TestAdapterServesAPIGatewayEvents(t *testing.T) {
+	cfg := config.AppConfig{BaseURL: "https://sho.rt", APIKeys: map[string]string{"test-key": "tests"}, LivenessPath: "/livez", ReadinessPath: "/readyz"}
+	proxy := httpadapter.New(newRootHandler(cfg, store.NewMemoryUrlStore(), nil))
+	ctx := context.Background()
+
+	unauthorized, err := proxy.ProxyWithContext(ctx, events.APIGatewayProxyRequest{
+		HTTPMethod: http.MethodPost,
+		Path:       "/api/v1/shorten",
+		Body:       `{"url": "https://93.184.216.34/page"}`,
+	})
+	if err != nil {
+		t.Fatalf("proxying shorten without a key: %v", err)
+	}
+	if unauthorized.StatusCode != http.StatusUnauthorized {
+		t.Errorf("shorten without a key: status = %d, want 401", unauthorized.StatusCode)
+	}
+
+	created, err := proxy.ProxyWithContext(ctx, events.APIGatewayProxyRequest{
+		HTTPMethod: http.MethodPost,
+		Path:       "/api/v1/shorten",
+		Headers:    map[string]string{"Authorization": "Bearer test-key", "Content-Type": "application/json"},
+		Body:       `{"url": "https://93.184.216.34/page"}`,
+	})
+	if err != nil {
+		t.Fatalf("proxying shorten: %v", err)
+	}
+	if created.StatusCode != http.StatusCreated {
+		t.Fatalf("shorten: status = %d, want 201; body %s", created.StatusCode, created.Body)
+	}
+	var resp handler.ShortenURLResponse
+	if err := json.Unmarshal([]byte(created.Body), &resp); err != nil {
+		t.Fatalf("decoding shorten response: %v", err)
+	}
+	if !strings.HasPrefix(resp.ShortURL, "https://sho.rt/") {
+		t.Errorf("short URL = %q, want it on BASE_URL", resp.ShortURL)
+	}
+	shortID := resp.ShortURL[strings.LastIndex(resp.ShortURL, "/")+1:]
+
+	redirect, err := proxy.ProxyWithContext(ctx, events.APIGatewayProxyRequest{HTTPMethod: http.MethodGet, Path: "/api/v1/r/" + shortID})
+	if err != nil {
+		t.Fatalf("proxying redirect: %v", err)
+	}
+	location := redirect.Headers["Location"]
+	if location == "" && len(redirect.MultiValueHeaders["Location"]) > 0 {
+		location = redirect.MultiValueHeaders["Location"][0]
+	}
+	if redirect.StatusCode != http.StatusFound || location != "https://93.184.216.34/page" {
+		t.Errorf("redirect: status = %d, Location = %q; want 302 to the original URL", redirect.StatusCode, location)
+	}
+}
+
+// unreadyStore is a memory store whose readiness check fails, like a database that cannot be reached.
+type unreadyStore struct {
+	*store.MemoryUrlStore
+}
+
+func (unreadyStore) Ready(ctx context.Context) error { return errors.New("database unreachable") }
+
+func TestProbesNeedNoKeyAndLivenessIgnoresTheStore(t *testing.T) {
+	cfg := config.AppConfig{APIKeys: map[string]string{"test-key": "tests"}, AdminToken: "admin", LivenessPath: "/livez", ReadinessPath: "/readyz"}
+	root := newRootHandler(cfg, unreadyStore{store.NewMemoryUrlStore()}, nil)
+
+	for path, want := range map[string]int{"/livez": http.StatusOK, "/readyz": http.StatusServiceUnavailable} {
+		rec := httptest.NewRecorder()
+		root.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, path, nil))
+		if rec.Code != want {
+			t.Errorf("GET %s without a key: status = %d, want %d; body %s", path, rec.Code, want, rec.Body)
+		}
+	}
+}
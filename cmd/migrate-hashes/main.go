@@ -0,0 +1,80 @@
+// Command migrate-hashes moves the links of a MongoDB database from MD5 to SHA-256 short IDs
+// without downtime. Every link whose ID was derived with MD5 gets an alias under the ID SHA-256
+// derives from the same URL and is marked hash_algo "migrated"; the MD5 ID keeps resolving.
+//
+// Usage:
+//
+//	migrate-hashes [-batch-size 100] [-delay 1s]
+//
+// The database is configured with the server's environment variables: MONGO_URI, MONGO_DB_NAME,
+// MONGO_COLLECTION_NAME, SHORT_ID_LENGTH and MAX_COLLISION_RETRIES. Set HASH_ALGO=sha256 on the
+// servers before running it, so that no new MD5 links are created meanwhile. The command can be
+// stopped and started again at any time.
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"log/slog"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"shawty/internal/config"
+	"shawty/internal/service"
+	"shawty/internal/store"
+)
+
+func main() {
+	flags := flag.NewFlagSet("migrate-hashes", flag.ContinueOnError)
+	flags.Usage = func() {
+		fmt.Fprintf(flags.Output(), "Usage: migrate-hashes [flags]\n\nFlags:\n")
+		flags.PrintDefaults()
+	}
+	batchSize := flags.Int("batch-size", 100, "number of links read and migrated at a time")
+	delay := flags.Duration("delay", time.Second, "pause between batches, to keep the load on MongoDB down")
+	if err := flags.Parse(os.Args[1:]); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return
+		}
+		os.Exit(2)
+	}
+	if flags.NArg() != 0 || *batchSize < 1 || *delay < 0 {
+		flags.Usage()
+		os.Exit(2)
+	}
+
+	cfg := config.LoadConfig()
+	if cfg.StoreBackend != config.StoreBackendMongo {
+		fmt.Fprintf(os.Stderr, "migrate-hashes: STORE_BACKEND=%s is not supported; only mongo is\n", cfg.StoreBackend)
+		os.Exit(1)
+	}
+	dbClient, err := config.ConnectDB(cfg.DB)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "migrate-hashes:", err)
+		os.Exit(1)
+	}
+
+	mongoStore := store.NewMongoUrlStore(dbClient, cfg.DB.DBName, cfg.DB.CollectionName)
+	urlSvc := service.NewUrlService(mongoStore,
+		service.WithShortIDLength(cfg.ShortIDLength),
+		service.WithMaxCollisionRetries(cfg.MaxCollisionRetries),
+		service.WithHashAlgoStore(mongoStore),
+	)
+
+	// An interrupted run stops after the link it is migrating and can be resumed later.
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	result, err := urlSvc.MigrateHashes(ctx, *batchSize, *delay)
+	stop()
+	if disconnectErr := dbClient.Disconnect(context.Background()); disconnectErr != nil {
+		slog.Error("Failed to disconnect MongoDB client", slog.Any("error", disconnectErr))
+	}
+	fmt.Printf("Migrated %d links, skipped %d\n", result.Migrated, result.Skipped)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "migrate-hashes:", err)
+		os.Exit(1)
+	}
+}
@@ -0,0 +1,113 @@
+// Command migrate creates and reverses the MongoDB indexes and collections shawty relies on, so
+// that they are set up once per deploy rather than by every server replica as it starts.
+//
+// Usage:
+//
+//	migrate [flags] up      apply every pending migration
+//	migrate [flags] status  list the migrations and when they were applied
+//	migrate [flags] down    reverse the last applied migration
+//
+// The database is configured with the server's environment variables: MONGO_URI, MONGO_DB_NAME
+// and MONGO_COLLECTION_NAME. Applied migrations are recorded in the _migrations collection, so up
+// can be run on every deploy. Run it before starting servers without AUTO_MIGRATE=true.
+// The PostgreSQL and SQLite backends create their schema at startup and have no migrations.
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"time"
+
+	"shawty/internal/config"
+	"shawty/internal/store"
+)
+
+func main() {
+	flags := flag.NewFlagSet("migrate", flag.ContinueOnError)
+	flags.Usage = func() {
+		fmt.Fprintf(flags.Output(), "Usage: migrate [flags] up | status | down\n\nFlags:\n")
+		flags.PrintDefaults()
+	}
+	clickRetention := flags.Duration("click-retention", 90*24*time.Hour, "how long click events are kept by the clicks TTL index; 0 keeps them forever")
+	timeout := flags.Duration("timeout", 5*time.Minute, "timeout of the whole command; index builds on large collections take a while")
+	if err := flags.Parse(os.Args[1:]); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return
+		}
+		os.Exit(2)
+	}
+	if flags.NArg() != 1 {
+		flags.Usage()
+		os.Exit(2)
+	}
+
+	cfg := config.LoadConfig()
+	if cfg.StoreBackend != config.StoreBackendMongo {
+		fmt.Fprintf(os.Stderr, "migrate: STORE_BACKEND=%s has no migrations; only mongo does\n", cfg.StoreBackend)
+		os.Exit(1)
+	}
+	dbClient, err := config.ConnectDB(cfg.DB)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "migrate:", err)
+		os.Exit(1)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), *timeout)
+	migrator := store.NewMongoMigrator(dbClient.Database(cfg.DB.DBName), cfg.DB.CollectionName, *clickRetention)
+	err = run(ctx, migrator, flags.Arg(0), os.Stdout)
+	cancel()
+	if disconnectErr := dbClient.Disconnect(context.Background()); disconnectErr != nil {
+		slog.Error("Failed to disconnect MongoDB client", slog.Any("error", disconnectErr))
+	}
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "migrate:", err)
+		os.Exit(1)
+	}
+}
+
+// run executes one subcommand and writes its result to out.
+func run(ctx context.Context, migrator *store.MongoMigrator, command string, out io.Writer) error {
+	switch command {
+	case "up":
+		applied, err := migrator.Up(ctx)
+		for _, name := range applied {
+			fmt.Fprintf(out, "Applied %s\n", name)
+		}
+		if err != nil {
+			return err
+		}
+		if len(applied) == 0 {
+			fmt.Fprintln(out, "No pending migrations")
+		}
+	case "status":
+		statuses, err := migrator.Status(ctx)
+		if err != nil {
+			return err
+		}
+		for _, status := range statuses {
+			if status.AppliedAt == nil {
+				fmt.Fprintf(out, "%-28s pending\n", status.Name)
+			} else {
+				fmt.Fprintf(out, "%-28s applied %s\n", status.Name, status.AppliedAt.Format(time.RFC3339))
+			}
+		}
+	case "down":
+		name, err := migrator.Down(ctx)
+		if errors.Is(err, store.ErrNoAppliedMigrations) {
+			fmt.Fprintln(out, "No applied migrations to reverse")
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(out, "Reversed %s\n", name)
+	default:
+		return fmt.Errorf("unknown command %q; use up, status or down", command)
+	}
+	return nil
+}
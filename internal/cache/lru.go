@@ -0,0 +1,100 @@
+// Package cache provides the in-process caches used when no shared cache is configured.
+package cache
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"shawty/internal/domain"
+	"shawty/internal/metrics"
+
+	"github.com/hashicorp/golang-lru/v2/expirable"
+)
+
+// DefaultSize is the number of entries a URLCache holds when no size is configured.
+const DefaultSize = 1000
+
+// URLCache is a fixed-size, least-recently-used cache of URL entries keyed by short ID.
+// Entries also expire after a TTL, so that changes made by other instances are picked up.
+// It is safe for concurrent use.
+type URLCache struct {
+	lru *expirable.LRU[string, domain.URL]
+}
+
+// NewURLCache creates a URLCache holding at most size entries for at most ttl each.
+// A size below 1 uses DefaultSize; a ttl of zero or less keeps entries until they are evicted.
+func NewURLCache(size int, ttl time.Duration) *URLCache {
+	if size < 1 {
+		size = DefaultSize
+	}
+	if ttl < 0 {
+		ttl = 0
+	}
+	return &URLCache{lru: expirable.NewLRU[string, domain.URL](size, nil, ttl)}
+}
+
+// Get returns the cached entry for shortID and records a cache hit or miss.
+func (c *URLCache) Get(shortID string) (domain.URL, bool) {
+	urlEntry, ok := c.lru.Get(shortID)
+	if ok {
+		metrics.CacheHits.Inc()
+	} else {
+		metrics.CacheMisses.Inc()
+	}
+	return urlEntry, ok
+}
+
+// Add caches urlEntry under its short ID, evicting the least recently used entry if the cache is full.
+func (c *URLCache) Add(urlEntry domain.URL) {
+	c.lru.Add(urlEntry.ID, urlEntry)
+}
+
+// Remove evicts the entry for shortID, if any.
+func (c *URLCache) Remove(shortID string) {
+	c.lru.Remove(shortID)
+}
+
+// Len returns the number of cached entries.
+func (c *URLCache) Len() int {
+	return c.lru.Len()
+}
+
+// ByteCache is a fixed-size, least-recently-used cache of byte values with a TTL per entry.
+// It satisfies the same Get/Set contract as store.RedisCache, for deployments without Redis.
+// It is safe for concurrent use.
+type ByteCache struct {
+	lru *expirable.LRU[string, byteEntry]
+}
+
+// byteEntry is a cached value and the time it expires.
+type byteEntry struct {
+	value     []byte
+	expiresAt time.Time
+}
+
+// ErrCacheMiss is returned by ByteCache.Get for keys that are missing or have expired.
+var ErrCacheMiss = errors.New("cache miss")
+
+// NewByteCache creates a ByteCache holding at most size entries. A size below 1 uses DefaultSize.
+func NewByteCache(size int) *ByteCache {
+	if size < 1 {
+		size = DefaultSize
+	}
+	return &ByteCache{lru: expirable.NewLRU[string, byteEntry](size, nil, 0)}
+}
+
+// Get returns the value cached under key, or ErrCacheMiss.
+func (c *ByteCache) Get(_ context.Context, key string) ([]byte, error) {
+	entry, ok := c.lru.Get(key)
+	if !ok || !time.Now().Before(entry.expiresAt) {
+		return nil, ErrCacheMiss
+	}
+	return entry.value, nil
+}
+
+// Set caches value under key for ttl, evicting the least recently used entry if the cache is full.
+func (c *ByteCache) Set(_ context.Context, key string, value []byte, ttl time.Duration) error {
+	c.lru.Add(key, byteEntry{value: value, expiresAt: time.Now().Add(ttl)})
+	return nil
+}
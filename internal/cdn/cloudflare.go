@@ -0,0 +1,88 @@
+package cdn
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// CloudflareAPIURL is the base URL of the Cloudflare API.
+const CloudflareAPIURL = "https://api.cloudflare.com/client/v4"
+
+// CloudflareTimeout is how long a purge request may take.
+const CloudflareTimeout = 5 * time.Second
+
+// CloudflarePurger purges short links from a Cloudflare zone by cache tag. Cloudflare tags responses
+// from their Cache-Tag header, so redirects send the surrogate keys in both Cache-Tag and
+// Surrogate-Key. Purging by tag needs an API token with the Cache Purge permission for the zone.
+type CloudflarePurger struct {
+	client   *http.Client
+	baseURL  string
+	zoneID   string
+	apiToken string
+}
+
+// NewCloudflarePurger returns a purger for the zone zoneID that authenticates with apiToken. baseURL
+// is the API to call, normally CloudflareAPIURL.
+func NewCloudflarePurger(client *http.Client, baseURL, zoneID, apiToken string) *CloudflarePurger {
+	return &CloudflarePurger{
+		client:   client,
+		baseURL:  strings.TrimSuffix(baseURL, "/"),
+		zoneID:   zoneID,
+		apiToken: apiToken,
+	}
+}
+
+// cloudflarePurgeRequest is the body of a purge by tag.
+type cloudflarePurgeRequest struct {
+	Tags []string `json:"tags"`
+}
+
+// cloudflareResponse is the envelope of Cloudflare API responses.
+type cloudflareResponse struct {
+	Success bool `json:"success"`
+	Errors  []struct {
+		Code    int    `json:"code"`
+		Message string `json:"message"`
+	} `json:"errors"`
+}
+
+// PurgeShortURL calls the Cache Purge API with the tag SurrogateKey(shortID).
+func (p *CloudflarePurger) PurgeShortURL(ctx context.Context, shortID string) error {
+	body, err := json.Marshal(cloudflarePurgeRequest{Tags: []string{SurrogateKey(shortID)}})
+	if err != nil {
+		return fmt.Errorf("failed to encode Cloudflare purge request: %w", err)
+	}
+	endpoint := p.baseURL + "/zones/" + url.PathEscape(p.zoneID) + "/purge_cache"
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("failed to build Cloudflare purge request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer "+p.apiToken)
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to call Cloudflare: %w", err)
+	}
+	defer resp.Body.Close()
+
+	var result cloudflareResponse
+	// Errors are described in the body, so it is read whatever the status.
+	if err := json.NewDecoder(io.LimitReader(resp.Body, 64<<10)).Decode(&result); err != nil && resp.StatusCode < 300 {
+		return fmt.Errorf("failed to decode Cloudflare response: %w", err)
+	}
+	if resp.StatusCode >= 300 || !result.Success {
+		if len(result.Errors) > 0 {
+			return fmt.Errorf("cloudflare purge of '%s' failed with status %d: %s (code %d)", shortID, resp.StatusCode, result.Errors[0].Message, result.Errors[0].Code)
+		}
+		return fmt.Errorf("cloudflare purge of '%s' failed with status %d", shortID, resp.StatusCode)
+	}
+	return nil
+}
@@ -0,0 +1,77 @@
+package cdn
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"testing"
+)
+
+// newCloudflareAPI starts a fake Cloudflare API whose purge endpoint answers with status and body,
+// and returns it with a channel receiving the tags of each purge request.
+func newCloudflareAPI(t *testing.T, status int, body string) (*httptest.Server, <-chan []string) {
+	t.Helper()
+	purged := make(chan []string, 1)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// The zone ID is escaped as one path segment.
+		if r.Method != http.MethodPost || r.URL.EscapedPath() != "/client/v4/zones/zone%2F1/purge_cache" {
+			t.Errorf("got %s %s, want POST /client/v4/zones/zone%%2F1/purge_cache", r.Method, r.URL.EscapedPath())
+		}
+		if got := r.Header.Get("Authorization"); got != "Bearer cf-token" {
+			t.Errorf("Authorization = %q, want the API token as a bearer token", got)
+		}
+		if got := r.Header.Get("Content-Type"); got != "application/json" {
+			t.Errorf("Content-Type = %q, want application/json", got)
+		}
+		var req cloudflarePurgeRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			t.Errorf("decoding purge request: %v", err)
+		}
+		purged <- req.Tags
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(status)
+		w.Write([]byte(body))
+	}))
+	t.Cleanup(server.Close)
+	return server, purged
+}
+
+func TestCloudflarePurgerPurgesByTag(t *testing.T) {
+	server, purged := newCloudflareAPI(t, http.StatusOK, `{"success": true, "errors": [], "result": {"id": "zone/1"}}`)
+	purger := NewCloudflarePurger(server.Client(), server.URL+"/client/v4/", "zone/1", "cf-token")
+
+	if err := purger.PurgeShortURL(context.Background(), "abc123"); err != nil {
+		t.Fatalf("PurgeShortURL: %v", err)
+	}
+	if tags := <-purged; !slices.Equal(tags, []string{"shawty-abc123"}) {
+		t.Errorf("purged tags %v, want [shawty-abc123]", tags)
+	}
+}
+
+func TestCloudflarePurgerReportsFailures(t *testing.T) {
+	tests := []struct {
+		name    string
+		status  int
+		body    string
+		wantErr string
+	}{
+		{name: "API error", status: http.StatusForbidden, body: `{"success": false, "errors": [{"code": 10000, "message": "Authentication error"}]}`, wantErr: "Authentication error (code 10000)"},
+		{name: "unsuccessful with status 200", status: http.StatusOK, body: `{"success": false, "errors": []}`, wantErr: "failed with status 200"},
+		{name: "server error without a JSON body", status: http.StatusBadGateway, body: "bad gateway", wantErr: "failed with status 502"},
+		{name: "undecodable success", status: http.StatusOK, body: "not json", wantErr: "failed to decode"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server, _ := newCloudflareAPI(t, tt.status, tt.body)
+			purger := NewCloudflarePurger(server.Client(), server.URL+"/client/v4", "zone/1", "cf-token")
+
+			err := purger.PurgeShortURL(context.Background(), "abc123")
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("PurgeShortURL error = %v, want one containing %q", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,17 @@
+// Package cdn removes short links from the caches of content delivery networks in front of the API.
+package cdn
+
+import "context"
+
+// Purger removes the cached redirects of a short link from a CDN. Implementations must be safe
+// for concurrent use.
+type Purger interface {
+	// PurgeShortURL purges every cached response tagged with SurrogateKey(shortID).
+	PurgeShortURL(ctx context.Context, shortID string) error
+}
+
+// SurrogateKey is the cache tag of the redirects of shortID. Redirects of an alias carry the key of
+// its primary link too, so purging the primary purges its aliases.
+func SurrogateKey(shortID string) string {
+	return "shawty-" + shortID
+}
@@ -0,0 +1,222 @@
+// Package circuitbreaker stops calling a failing URL store for a while, so that requests fail
+// fast during a database outage instead of each waiting for the connection timeout.
+package circuitbreaker
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"time"
+
+	"shawty/internal/domain"
+	"shawty/internal/metrics"
+	"shawty/internal/store"
+
+	"github.com/sony/gobreaker"
+)
+
+// Breaker settings. The circuit opens after ConsecutiveFailures failed store calls in a row and,
+// after OpenTimeout, lets one trial call through (half-open); the circuit closes again if it succeeds.
+const (
+	ConsecutiveFailures = 5
+	OpenTimeout         = 30 * time.Second
+)
+
+// ErrCircuitOpen is returned without calling the store while the circuit is open.
+var ErrCircuitOpen = errors.New("store circuit breaker is open")
+
+// BreakerStore decorates a UrlStoreInterface with a circuit breaker. Only errors that suggest the
+// database is unhealthy count as failures: lookups of unknown or deleted entries or tenants, duplicate keys,
+// cancelled requests and errors returned by ForEach callbacks do not.
+type BreakerStore struct {
+	inner store.UrlStoreInterface
+	cb    *gobreaker.CircuitBreaker
+}
+
+// NewBreakerStore wraps inner with a circuit breaker named name, which labels its log lines and metrics.
+func NewBreakerStore(inner store.UrlStoreInterface, name string) store.UrlStoreInterface {
+	metrics.CircuitBreakerState.WithLabelValues(name).Set(float64(gobreaker.StateClosed))
+	return &BreakerStore{
+		inner: inner,
+		cb: gobreaker.NewCircuitBreaker(gobreaker.Settings{
+			Name:    name,
+			Timeout: OpenTimeout,
+			ReadyToTrip: func(counts gobreaker.Counts) bool {
+				return counts.ConsecutiveFailures >= ConsecutiveFailures
+			},
+			IsSuccessful:  isSuccessful,
+			OnStateChange: onStateChange,
+		}),
+	}
+}
+
+// callbackError marks an error returned by a ForEach callback rather than by the store.
+type callbackError struct{ err error }
+
+func (e callbackError) Error() string { return e.err.Error() }
+func (e callbackError) Unwrap() error { return e.err }
+
+// isSuccessful reports whether err leaves the store's health unquestioned.
+func isSuccessful(err error) bool {
+	var cbErr callbackError
+	return err == nil ||
+		errors.Is(err, store.ErrURLNotFound) ||
+		errors.Is(err, store.ErrURLDeleted) ||
+		errors.Is(err, store.ErrDuplicateShortID) ||
+		errors.Is(err, store.ErrClickLimitReached) ||
+		errors.Is(err, store.ErrInvalidCursor) ||
+		errors.Is(err, store.ErrTenantNotFound) ||
+		errors.Is(err, context.Canceled) ||
+		errors.As(err, &cbErr)
+}
+
+// onStateChange logs and counts transitions of the circuit.
+func onStateChange(name string, from, to gobreaker.State) {
+	metrics.CircuitBreakerTransitions.WithLabelValues(name, from.String(), to.String()).Inc()
+	metrics.CircuitBreakerState.WithLabelValues(name).Set(float64(to))
+	if to == gobreaker.StateOpen {
+		slog.Error("Circuit breaker opened, store calls fail fast", slog.String("breaker", name), slog.Duration("open_for", OpenTimeout))
+		return
+	}
+	slog.Info("Circuit breaker changed state", slog.String("breaker", name), slog.String("from", from.String()), slog.String("to", to.String()))
+}
+
+// call runs fn through the breaker, translating the breaker's rejections to ErrCircuitOpen.
+func call[T any](s *BreakerStore, fn func() (T, error)) (T, error) {
+	var result T
+	_, err := s.cb.Execute(func() (interface{}, error) {
+		var err error
+		result, err = fn()
+		return nil, err
+	})
+	if errors.Is(err, gobreaker.ErrOpenState) || errors.Is(err, gobreaker.ErrTooManyRequests) {
+		return result, ErrCircuitOpen
+	}
+	return result, err
+}
+
+// exec is call for store methods that only return an error.
+func exec(s *BreakerStore, fn func() error) error {
+	_, err := call(s, func() (struct{}, error) { return struct{}{}, fn() })
+	return err
+}
+
+// EnsureIndexes runs at startup, before any traffic, and is not guarded.
+func (s *BreakerStore) EnsureIndexes(ctx context.Context) error {
+	return s.inner.EnsureIndexes(ctx)
+}
+
+// Save runs the inner store's Save through the breaker.
+func (s *BreakerStore) Save(ctx context.Context, urlEntry domain.URL) error {
+	return exec(s, func() error { return s.inner.Save(ctx, urlEntry) })
+}
+
+// GetByShortID runs the inner store's GetByShortID through the breaker.
+func (s *BreakerStore) GetByShortID(ctx context.Context, shortID, tenantID string) (domain.URL, error) {
+	return call(s, func() (domain.URL, error) { return s.inner.GetByShortID(ctx, shortID, tenantID) })
+}
+
+// GetByOriginalURL runs the inner store's GetByOriginalURL through the breaker.
+func (s *BreakerStore) GetByOriginalURL(ctx context.Context, originalURL string) (domain.URL, error) {
+	return call(s, func() (domain.URL, error) { return s.inner.GetByOriginalURL(ctx, originalURL) })
+}
+
+// EstimatedCount runs the inner store's EstimatedCount through the breaker.
+func (s *BreakerStore) EstimatedCount(ctx context.Context) (int64, error) {
+	return call(s, func() (int64, error) { return s.inner.EstimatedCount(ctx) })
+}
+
+// IncrementClickCount runs the inner store's IncrementClickCount through the breaker.
+func (s *BreakerStore) IncrementClickCount(ctx context.Context, shortID string, mobile bool) (int64, error) {
+	return call(s, func() (int64, error) { return s.inner.IncrementClickCount(ctx, shortID, mobile) })
+}
+
+// IncrementConversionCount runs the inner store's IncrementConversionCount through the breaker.
+func (s *BreakerStore) IncrementConversionCount(ctx context.Context, shortID string) error {
+	return exec(s, func() error { return s.inner.IncrementConversionCount(ctx, shortID) })
+}
+
+// List runs the inner store's List through the breaker.
+func (s *BreakerStore) List(ctx context.Context, tenantID, cursor string, limit int64, includeDeleted bool) ([]domain.URL, string, error) {
+	var next string
+	entries, err := call(s, func() ([]domain.URL, error) {
+		entries, nextCursor, err := s.inner.List(ctx, tenantID, cursor, limit, includeDeleted)
+		next = nextCursor
+		return entries, err
+	})
+	return entries, next, err
+}
+
+// ForEach runs the inner store's ForEach through the breaker. Errors returned by fn are passed
+// back unchanged and do not count as store failures.
+func (s *BreakerStore) ForEach(ctx context.Context, fn func(domain.URL) error) error {
+	err := exec(s, func() error {
+		return s.inner.ForEach(ctx, func(u domain.URL) error {
+			if err := fn(u); err != nil {
+				return callbackError{err: err}
+			}
+			return nil
+		})
+	})
+	var cbErr callbackError
+	if errors.As(err, &cbErr) {
+		return cbErr.err
+	}
+	return err
+}
+
+// Delete runs the inner store's Delete through the breaker.
+func (s *BreakerStore) Delete(ctx context.Context, shortID string) error {
+	return exec(s, func() error { return s.inner.Delete(ctx, shortID) })
+}
+
+// Undelete runs the inner store's Undelete through the breaker.
+func (s *BreakerStore) Undelete(ctx context.Context, shortID string) error {
+	return exec(s, func() error { return s.inner.Undelete(ctx, shortID) })
+}
+
+// ListByTag runs the inner store's ListByTag through the breaker.
+func (s *BreakerStore) ListByTag(ctx context.Context, tag string, limit, offset int64) ([]domain.URL, int64, error) {
+	var total int64
+	entries, err := call(s, func() ([]domain.URL, error) {
+		entries, count, err := s.inner.ListByTag(ctx, tag, limit, offset)
+		total = count
+		return entries, err
+	})
+	return entries, total, err
+}
+
+// UpdateTags runs the inner store's UpdateTags through the breaker.
+func (s *BreakerStore) UpdateTags(ctx context.Context, shortID string, tags []string) error {
+	return exec(s, func() error { return s.inner.UpdateTags(ctx, shortID, tags) })
+}
+
+// Search runs the inner store's Search through the breaker.
+func (s *BreakerStore) Search(ctx context.Context, query string, limit int64) ([]domain.URL, error) {
+	return call(s, func() ([]domain.URL, error) { return s.inner.Search(ctx, query, limit) })
+}
+
+// ListAliases runs the inner store's ListAliases through the breaker.
+func (s *BreakerStore) ListAliases(ctx context.Context, primaryID string) ([]string, error) {
+	return call(s, func() ([]string, error) { return s.inner.ListAliases(ctx, primaryID) })
+}
+
+// UpdateMetadata runs the inner store's UpdateMetadata through the breaker.
+func (s *BreakerStore) UpdateMetadata(ctx context.Context, shortID, title, description string) error {
+	return exec(s, func() error { return s.inner.UpdateMetadata(ctx, shortID, title, description) })
+}
+
+// Update runs the inner store's Update through the breaker.
+func (s *BreakerStore) Update(ctx context.Context, shortID, newOriginalURL string) error {
+	return exec(s, func() error { return s.inner.Update(ctx, shortID, newOriginalURL) })
+}
+
+// TransferOwnership runs the inner store's TransferOwnership through the breaker, or returns
+// store.ErrOwnershipUnsupported if the inner store cannot transfer links.
+func (s *BreakerStore) TransferOwnership(ctx context.Context, shortID, newOwnerID string) (string, error) {
+	ownershipStore, ok := s.inner.(store.OwnershipStoreInterface)
+	if !ok {
+		return "", store.ErrOwnershipUnsupported
+	}
+	return call(s, func() (string, error) { return ownershipStore.TransferOwnership(ctx, shortID, newOwnerID) })
+}
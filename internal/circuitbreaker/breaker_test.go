@@ -0,0 +1,123 @@
+package circuitbreaker
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"shawty/internal/domain"
+	"shawty/internal/metrics"
+	"shawty/internal/store"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+// errDatabaseDown stands in for the errors of an unreachable database.
+var errDatabaseDown = errors.New("server selection timeout")
+
+// flakyStore is a MemoryUrlStore whose Save and GetByShortID fail with err while it is set.
+// It counts the calls that reach it.
+type flakyStore struct {
+	*store.MemoryUrlStore
+	err   error
+	calls int
+}
+
+func (s *flakyStore) Save(ctx context.Context, urlEntry domain.URL) error {
+	s.calls++
+	if s.err != nil {
+		return s.err
+	}
+	return s.MemoryUrlStore.Save(ctx, urlEntry)
+}
+
+func (s *flakyStore) GetByShortID(ctx context.Context, shortID, tenantID string) (domain.URL, error) {
+	s.calls++
+	if s.err != nil {
+		return domain.URL{}, s.err
+	}
+	return s.MemoryUrlStore.GetByShortID(ctx, shortID, tenantID)
+}
+
+func TestBreakerOpensAfterConsecutiveFailures(t *testing.T) {
+	ctx := context.Background()
+	inner := &flakyStore{MemoryUrlStore: store.NewMemoryUrlStore(), err: errDatabaseDown}
+	name := t.Name()
+	breaker := NewBreakerStore(inner, name)
+
+	for i := range ConsecutiveFailures {
+		if _, err := breaker.GetByShortID(ctx, "abc123", store.AnyTenant); !errors.Is(err, errDatabaseDown) {
+			t.Fatalf("call %d: error = %v, want the store's error", i+1, err)
+		}
+	}
+	if got := testutil.ToFloat64(metrics.CircuitBreakerTransitions.WithLabelValues(name, "closed", "open")); got != 1 {
+		t.Errorf("closed to open transitions = %v, want 1", got)
+	}
+	if got := testutil.ToFloat64(metrics.CircuitBreakerState.WithLabelValues(name)); got != 2 {
+		t.Errorf("state gauge = %v, want 2 (open)", got)
+	}
+
+	// The store has recovered, but the open circuit keeps calls away from it.
+	inner.err = nil
+	start := time.Now()
+	if _, err := breaker.GetByShortID(ctx, "abc123", store.AnyTenant); !errors.Is(err, ErrCircuitOpen) {
+		t.Errorf("GetByShortID error = %v, want ErrCircuitOpen", err)
+	}
+	if err := breaker.Save(ctx, domain.URL{ID: "abc123"}); !errors.Is(err, ErrCircuitOpen) {
+		t.Errorf("Save error = %v, want ErrCircuitOpen", err)
+	}
+	if elapsed := time.Since(start); elapsed > 10*time.Millisecond {
+		t.Errorf("rejected calls took %s, want them to return immediately", elapsed)
+	}
+	if inner.calls != ConsecutiveFailures {
+		t.Errorf("inner store was called %d times, want %d", inner.calls, ConsecutiveFailures)
+	}
+}
+
+func TestBreakerIgnoresExpectedErrors(t *testing.T) {
+	ctx := context.Background()
+	inner := &flakyStore{MemoryUrlStore: store.NewMemoryUrlStore()}
+	breaker := NewBreakerStore(inner, t.Name())
+
+	// Unknown IDs and duplicates are answers, not failures, however many there are.
+	for range 2 * ConsecutiveFailures {
+		if _, err := breaker.GetByShortID(ctx, "missing", store.AnyTenant); !errors.Is(err, store.ErrURLNotFound) {
+			t.Fatalf("GetByShortID error = %v, want ErrURLNotFound", err)
+		}
+		if _, err := breaker.IncrementClickCount(ctx, "missing", false); !errors.Is(err, store.ErrURLNotFound) {
+			t.Fatalf("IncrementClickCount error = %v, want ErrURLNotFound", err)
+		}
+	}
+	entry := domain.URL{ID: "abc123", ShortUrl: "abc123", OriginalUrl: "https://example.com"}
+	if err := breaker.Save(ctx, entry); err != nil {
+		t.Fatalf("Save: %v", err)
+	}
+	for range 2 * ConsecutiveFailures {
+		if err := breaker.Save(ctx, entry); !errors.Is(err, store.ErrDuplicateShortID) {
+			t.Fatalf("Save error = %v, want ErrDuplicateShortID", err)
+		}
+	}
+	if _, err := breaker.GetByShortID(ctx, "abc123", store.AnyTenant); err != nil {
+		t.Errorf("GetByShortID after expected errors: %v", err)
+	}
+}
+
+func TestBreakerResetsOnSuccess(t *testing.T) {
+	ctx := context.Background()
+	inner := &flakyStore{MemoryUrlStore: store.NewMemoryUrlStore()}
+	breaker := NewBreakerStore(inner, t.Name())
+
+	// Failures interrupted by a success are not consecutive.
+	for round := range 3 {
+		inner.err = fmt.Errorf("round %d: %w", round, errDatabaseDown)
+		for range ConsecutiveFailures - 1 {
+			breaker.GetByShortID(ctx, "abc123", store.AnyTenant)
+		}
+		inner.err = nil
+		if _, err := breaker.GetByShortID(ctx, "abc123", store.AnyTenant); !errors.Is(err, store.ErrURLNotFound) {
+			t.Fatalf("round %d: GetByShortID error = %v, want ErrURLNotFound from the store", round, err)
+		}
+	}
+}
@@ -0,0 +1,111 @@
+// Package cleanup periodically removes expired short URLs from the store.
+package cleanup
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"sync"
+	"time"
+
+	"shawty/internal/metrics"
+	"shawty/internal/store"
+)
+
+// Cleaner deletes expired URLs every interval until it is stopped.
+type Cleaner struct {
+	store    store.ExpiredURLStoreInterface
+	interval time.Duration
+
+	mu     sync.Mutex
+	cancel context.CancelFunc
+	done   chan struct{}
+	err    error // Why the background run stopped on its own
+}
+
+// NewCleaner creates a Cleaner that cleans s every interval. Call Start to begin.
+func NewCleaner(s store.ExpiredURLStoreInterface, interval time.Duration) *Cleaner {
+	return &Cleaner{store: s, interval: interval}
+}
+
+// Start runs the cleaner in the background until ctx is done or Stop is called.
+// The first run happens one interval after Start. Starting a running cleaner does nothing.
+func (c *Cleaner) Start(ctx context.Context) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.cancel != nil {
+		return
+	}
+	ctx, c.cancel = context.WithCancel(ctx)
+	c.done = make(chan struct{})
+
+	go func() {
+		defer close(c.done)
+		ticker := time.NewTicker(c.interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				if !c.runRecovered(ctx) {
+					return
+				}
+			}
+		}
+	}()
+	slog.InfoContext(ctx, "Expired URL cleanup started", slog.Duration("interval", c.interval))
+}
+
+// Stop ends the background run and waits for a cleanup in progress to finish.
+// Stopping a cleaner that was never started does nothing.
+func (c *Cleaner) Stop() {
+	c.mu.Lock()
+	cancel, done := c.cancel, c.done
+	c.cancel = nil
+	c.mu.Unlock()
+	if cancel == nil {
+		return
+	}
+	cancel()
+	<-done
+}
+
+// Err returns why the background run stopped on its own, which only happens when a cleanup panics.
+// It is nil while the cleaner runs and after a regular Stop.
+func (c *Cleaner) Err() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.err
+}
+
+// runRecovered calls RunOnce and reports whether it returned. A panic is logged and kept for Err
+// instead of crashing the server, and ends the background run.
+func (c *Cleaner) runRecovered(ctx context.Context) (ok bool) {
+	defer func() {
+		if rec := recover(); rec != nil {
+			slog.ErrorContext(ctx, "Expired URL cleanup panicked, stopping it", slog.Any("panic", rec))
+			c.mu.Lock()
+			c.err = fmt.Errorf("expired URL cleanup panicked: %v", rec)
+			c.mu.Unlock()
+			ok = false
+		}
+	}()
+	c.RunOnce(ctx)
+	return true
+}
+
+// RunOnce deletes the URLs that have expired by now and returns how many were deleted.
+// Failures are logged and reported as zero deletions, so that the next run simply tries again.
+func (c *Cleaner) RunOnce(ctx context.Context) int64 {
+	deleted, err := c.store.DeleteExpired(ctx, time.Now().UTC())
+	if err != nil {
+		if ctx.Err() == nil {
+			slog.ErrorContext(ctx, "Failed to clean up expired URLs", slog.Any("error", err))
+		}
+		return 0
+	}
+	metrics.ExpiredURLsCleaned.Add(float64(deleted))
+	slog.InfoContext(ctx, "Cleaned up expired URLs", slog.Int64("deleted", deleted))
+	return deleted
+}
@@ -0,0 +1,164 @@
+package cleanup
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"sync"
+	"testing"
+	"time"
+
+	"shawty/internal/domain"
+	"shawty/internal/metrics"
+	"shawty/internal/store"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// fakeExpiredStore returns deleted from each DeleteExpired call, or err, or panics with panicWith.
+type fakeExpiredStore struct {
+	mu        sync.Mutex
+	deleted   int64
+	err       error
+	panicWith any
+	calls     int
+}
+
+func (s *fakeExpiredStore) DeleteExpired(ctx context.Context, now time.Time) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.calls++
+	if s.panicWith != nil {
+		panic(s.panicWith)
+	}
+	return s.deleted, s.err
+}
+
+func (s *fakeExpiredStore) callCount() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.calls
+}
+
+func TestRunOnceCountsDeletedURLs(t *testing.T) {
+	before := testutil.ToFloat64(metrics.ExpiredURLsCleaned)
+	cleaner := NewCleaner(&fakeExpiredStore{deleted: 3}, time.Hour)
+	if got := cleaner.RunOnce(context.Background()); got != 3 {
+		t.Errorf("RunOnce = %d, want 3", got)
+	}
+	if got := testutil.ToFloat64(metrics.ExpiredURLsCleaned) - before; got != 3 {
+		t.Errorf("cleaned counter grew by %v, want 3", got)
+	}
+
+	failing := NewCleaner(&fakeExpiredStore{err: errors.New("connection refused")}, time.Hour)
+	if got := failing.RunOnce(context.Background()); got != 0 {
+		t.Errorf("failing RunOnce = %d, want 0", got)
+	}
+}
+
+func TestStartRunsEveryIntervalUntilStopped(t *testing.T) {
+	fake := &fakeExpiredStore{}
+	cleaner := NewCleaner(fake, 5*time.Millisecond)
+	cleaner.Start(context.Background())
+	cleaner.Start(context.Background()) // A second Start does not add a second loop.
+
+	deadline := time.Now().Add(2 * time.Second)
+	for fake.callCount() < 3 && time.Now().Before(deadline) {
+		time.Sleep(time.Millisecond)
+	}
+	cleaner.Stop()
+	stopped := fake.callCount()
+	if stopped < 3 {
+		t.Fatalf("cleaner ran %d times, want at least 3", stopped)
+	}
+	time.Sleep(20 * time.Millisecond)
+	if got := fake.callCount(); got != stopped {
+		t.Errorf("cleaner ran %d more times after Stop", got-stopped)
+	}
+	cleaner.Stop()
+	if err := cleaner.Err(); err != nil {
+		t.Errorf("Err = %v, want nil after a regular Stop", err)
+	}
+}
+
+func TestStartStopsWithContext(t *testing.T) {
+	cleaner := NewCleaner(&fakeExpiredStore{}, time.Millisecond)
+	ctx, cancel := context.WithCancel(context.Background())
+	cleaner.Start(ctx)
+	cancel()
+
+	done := make(chan struct{})
+	go func() {
+		cleaner.Stop()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("Stop did not return after the context was cancelled")
+	}
+}
+
+func TestPanickingCleanupStopsTheCleaner(t *testing.T) {
+	fake := &fakeExpiredStore{panicWith: "store bug"}
+	cleaner := NewCleaner(fake, time.Millisecond)
+	cleaner.Start(context.Background())
+	defer cleaner.Stop()
+
+	deadline := time.Now().Add(2 * time.Second)
+	for cleaner.Err() == nil && time.Now().Before(deadline) {
+		time.Sleep(time.Millisecond)
+	}
+	if cleaner.Err() == nil {
+		t.Fatal("Err = nil after a cleanup panicked")
+	}
+	time.Sleep(10 * time.Millisecond)
+	if got := fake.callCount(); got != 1 {
+		t.Errorf("cleaner ran %d times, want it to stop after the panic", got)
+	}
+}
+
+// TestRunOnceRemovesExpiredMongoURLs needs a MongoDB server at SHAWTY_TEST_MONGO_URI and is skipped otherwise.
+func TestRunOnceRemovesExpiredMongoURLs(t *testing.T) {
+	uri := os.Getenv("SHAWTY_TEST_MONGO_URI")
+	if uri == "" {
+		t.Skip("SHAWTY_TEST_MONGO_URI is not set")
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	client, err := mongo.Connect(ctx, options.Client().ApplyURI(uri))
+	if err != nil {
+		t.Fatalf("connecting to MongoDB: %v", err)
+	}
+	dbName := fmt.Sprintf("shawty_test_%d", time.Now().UnixNano())
+	defer func() {
+		client.Database(dbName).Drop(context.Background())
+		client.Disconnect(context.Background())
+	}()
+	mongoStore := store.NewMongoUrlStore(client, dbName, "urls")
+
+	now := time.Now().UTC()
+	past, future := now.Add(-time.Minute), now.Add(time.Hour)
+	entries := map[string]*time.Time{"expird": &past, "future": &future, "noexpy": nil}
+	for id, expiresAt := range entries {
+		entry := domain.URL{ID: id, ShortUrl: id, OriginalUrl: "https://example.com/" + id, CreationDate: now, ExpiresAt: expiresAt}
+		if err := mongoStore.Save(ctx, entry); err != nil {
+			t.Fatalf("Save(%s): %v", id, err)
+		}
+	}
+
+	if got := NewCleaner(mongoStore, time.Hour).RunOnce(ctx); got != 1 {
+		t.Errorf("RunOnce deleted %d URLs, want 1", got)
+	}
+	if _, err := mongoStore.GetByShortID(ctx, "expird", store.AnyTenant); err == nil {
+		t.Error("the expired URL is still stored")
+	}
+	for _, id := range []string{"future", "noexpy"} {
+		if _, err := mongoStore.GetByShortID(ctx, id, store.AnyTenant); err != nil {
+			t.Errorf("GetByShortID(%s) after cleanup: %v", id, err)
+		}
+	}
+}
@@ -0,0 +1,52 @@
+package config
+
+import (
+	"log/slog"
+	"os"
+	"time"
+
+	"shawty/internal/logger"
+)
+
+// AtlasConfig holds the settings for the MongoDB Atlas Data API store used when STORE_BACKEND=atlas.
+type AtlasConfig struct {
+	URL        string // Data API endpoint, e.g. https://data.mongodb-api.com/app/<app-id>/endpoint/data/v1
+	APIKey     string
+	DataSource string
+	Timeout    time.Duration // Timeout of each Data API request
+}
+
+// loadAtlasConfig loads the Atlas Data API configuration from environment variables. The endpoint
+// is built from ATLAS_APP_ID unless ATLAS_DATA_API_URL names one, e.g. a regional deployment.
+// ATLAS_DATA_API_KEY is still accepted in place of ATLAS_API_KEY.
+func loadAtlasConfig() AtlasConfig {
+	apiURL := os.Getenv("ATLAS_DATA_API_URL")
+	if apiURL == "" {
+		appID := os.Getenv("ATLAS_APP_ID")
+		if appID == "" {
+			logger.Fatal("ATLAS_APP_ID environment variable is required when STORE_BACKEND=atlas")
+		}
+		apiURL = "https://data.mongodb-api.com/app/" + appID + "/endpoint/data/v1"
+	}
+	apiKey := os.Getenv("ATLAS_API_KEY")
+	if apiKey == "" {
+		apiKey = os.Getenv("ATLAS_DATA_API_KEY")
+	}
+	if apiKey == "" {
+		logger.Fatal("ATLAS_API_KEY environment variable is required when STORE_BACKEND=atlas")
+	}
+	dataSource := os.Getenv("ATLAS_DATA_SOURCE")
+	if dataSource == "" {
+		dataSource = "Cluster0" // Default cluster name in new Atlas projects
+		slog.Info("ATLAS_DATA_SOURCE not set, using default", slog.String("value", dataSource))
+	}
+
+	slog.Info("Using the Atlas Data API", slog.String("url", MaskURI(apiURL)), slog.String("api_key", MaskAPIKey(apiKey)))
+
+	return AtlasConfig{
+		URL:        apiURL,
+		APIKey:     apiKey,
+		DataSource: dataSource,
+		Timeout:    time.Duration(getEnvInt("ATLAS_TIMEOUT_SECONDS", 10)) * time.Second,
+	}
+}
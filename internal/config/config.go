@@ -2,67 +2,513 @@ package config
 
 import (
 	"context"
+	"database/sql"
 	"fmt"
-	"log"
+	"log/slog"
+	"net"
+	"net/url"
 	"os"
+	"path"
+	"slices"
+	"strconv"
+	"strings"
 	"time"
 
+	"shawty/internal/domain"
+	"shawty/internal/logger"
+	"shawty/internal/middleware"
+
+	_ "github.com/jackc/pgx/v5/stdlib" // Registers the "pgx" database/sql driver
 	"github.com/joho/godotenv"
 	"go.mongodb.org/mongo-driver/mongo"
 	"go.mongodb.org/mongo-driver/mongo/options"
+	_ "modernc.org/sqlite" // Registers the pure-Go "sqlite" database/sql driver
 )
 
 // DBConfig holds database configuration.
 type DBConfig struct {
-	URI            string
-	DBName         string
-	CollectionName string
+	URI             string
+	DBName          string
+	CollectionName  string
+	ConnectTimeout  time.Duration
+	PingTimeout     time.Duration
+	MaxPoolSize     uint64        // Most connections kept per server; 0 means unlimited
+	MinPoolSize     uint64        // Connections kept open per server even when idle
+	MaxConnIdleTime time.Duration // How long an idle connection is kept before it is closed
+}
+
+// Supported values for STORE_BACKEND.
+const (
+	StoreBackendMongo    = "mongo"
+	StoreBackendPostgres = "postgres"
+	StoreBackendSQLite   = "sqlite"
+	StoreBackendAtlas    = "atlas"
+	StoreBackendDynamo   = "dynamo"
+)
+
+// PostgresConfig holds the PostgreSQL settings used when STORE_BACKEND=postgres.
+type PostgresConfig struct {
+	DSN            string
 	ConnectTimeout time.Duration
-	PingTimeout    time.Duration
 }
 
-// LoadConfig loads database configuration from environment variables.
+// SQLiteConfig holds the SQLite settings used when STORE_BACKEND=sqlite.
+type SQLiteConfig struct {
+	Path string // Database file; it is created if it does not exist
+}
+
+// RedisConfig holds the optional Redis cache configuration.
+// Caching is disabled when Addr is empty.
+type RedisConfig struct {
+	Addr     string
+	Password string
+	CacheTTL time.Duration
+}
+
+// TLSConfig holds the optional HTTPS settings. HTTPS is served with the certificate in CertFile and
+// KeyFile, or with certificates obtained from Let's Encrypt for AutoDomain; it is disabled otherwise.
+type TLSConfig struct {
+	CertFile    string
+	KeyFile     string
+	Port        string // Port of the HTTPS listener; PORT then only redirects to it
+	AutoDomain  string // Domain to obtain Let's Encrypt certificates for; takes precedence over CertFile and KeyFile
+	AutocertDir string // Directory where Let's Encrypt certificates and the account key are cached
+}
+
+// Enabled reports whether HTTPS is configured.
+func (c TLSConfig) Enabled() bool {
+	return c.AutoDomain != "" || (c.CertFile != "" && c.KeyFile != "")
+}
+
+// CloudflareConfig holds the optional Cloudflare zone that permanent redirects are purged from when
+// a link is updated or deleted. Purging is disabled when ZoneID is empty.
+type CloudflareConfig struct {
+	ZoneID   string
+	APIToken string // Needs the Cache Purge permission for the zone
+}
+
+// Enabled reports whether CDN purging is configured.
+func (c CloudflareConfig) Enabled() bool {
+	return c.ZoneID != ""
+}
+
+// AppConfig holds application configuration, including the database settings.
+type AppConfig struct {
+	LogLevel            string
+	LogFormat           string
+	Host                string // Interface the server listens on, e.g. 127.0.0.1 behind a reverse proxy; all interfaces when empty
+	Port                string // Port of the HTTP listener; with TLS it only redirects to TLS.Port
+	StoreBackend        string
+	DB                  DBConfig
+	Postgres            PostgresConfig
+	SQLite              SQLiteConfig
+	Atlas               AtlasConfig
+	Dynamo              DynamoConfig
+	Redis               RedisConfig
+	TLS                 TLSConfig
+	Cloudflare          CloudflareConfig
+	StoreMaxRetries     int           // Retries of a short ID lookup that failed with a transient error; 0 disables retrying
+	StoreRetryBase      time.Duration // Delay before the first retry; it doubles with every further retry
+	CacheSize           int           // Capacity of the in-process URL cache used when Redis is not configured; 0 disables it
+	DashboardEnabled    bool
+	AnalyticsEnabled    bool
+	GeoIPDBPath         string // MaxMind country database used to locate clicks; countries are recorded as XX when empty
+	HashAlgo            string
+	ShortIDLength       int
+	MaxCollisionRetries int
+	BulkConcurrency     int
+	AdminToken          string            // Master bearer token for /admin endpoints, which sees every tenant's links; admin endpoints reject every request when empty
+	APIKeys             map[string]string // API key -> owner name; required for /shorten, /shorten/bulk and /admin
+	RateLimit           RateLimitConfig
+	Security            SecurityConfig
+	OTelEndpoint        string        // OTLP/HTTP collector URL; tracing is disabled when empty
+	MetricsToken        string        // Bearer token for /metrics; the endpoint is not registered when empty
+	BlacklistFile       string        // Newline-separated list of blocked hosts; the blacklist is in-memory only when empty
+	CORSAllowedOrigins  []string      // Origins allowed to call the API from a browser; cross-origin requests are denied when empty
+	ShutdownTimeout     time.Duration // How long in-flight requests may take to finish after SIGINT/SIGTERM
+	CleanupInterval     time.Duration // How often expired URLs are deleted; 0 disables the cleanup job
+	BaseURL             string        // Public base URL of short links, e.g. https://shawty.example.com; detected per request when empty
+	DomainAliases       []string      // Further hosts serving the same links, e.g. go.example.com; requests on them get short links on their own host
+	RedirectType        string        // Default redirect type of links created without one: permanent (301) or temporary (302)
+	LegacyRoutesEnabled bool          // Also serve the API at its unversioned paths, e.g. /shorten next to /api/v1/shorten
+	BundleTemplatePath  string        // html/template file for bundle pages; the built-in template is used when empty
+	MaxRequestBodyBytes int64         // Largest request body accepted by the API, except bulk shortening and imports; 0 disables the limit
+	MaxBulkBodyBytes    int64         // Largest request body accepted by /shorten/bulk; 0 disables the limit
+	RequestTimeout      time.Duration // How long a request may take before it is answered 503, except redirects, imports and exports; 0 disables the timeout
+	RedirectTimeout     time.Duration // RequestTimeout of the routes under /r/, which serve redirects
+	ImportTimeout       time.Duration // RequestTimeout of /admin/import
+	TrustedProxies      []string      // CIDR ranges of reverse proxies whose X-Forwarded-For and X-Real-IP headers are believed; ignored when empty
+	TokenHMACSecret     string        // Key of the HMAC-SHA256 tokens issued by /shorten/token; the endpoint answers 501 when empty
+	LivenessPath        string        // Path of the liveness probe, /livez by default
+	ReadinessPath       string        // Path of the readiness probe, /readyz by default
+	RequireToken        bool          // Reject /shorten requests without a valid token for their URL, and bulk shortening altogether
+	AutoMigrate         bool          // Create the MongoDB indexes at startup instead of leaving them to cmd/migrate
+	SitemapURL          string        // Public URL of the sitemap, announced in robots.txt when set
+}
+
+// SecurityConfig holds the security headers added to every response.
+type SecurityConfig struct {
+	HSTS              middleware.HSTSConfig
+	ExpectCTMaxAge    int    // max-age of the Expect-CT header in seconds; 0 leaves the header out
+	PermissionsPolicy string // Value of the Permissions-Policy header; left out when empty
+}
+
+// RateLimitConfig holds the per-IP rate limit applied to the shorten endpoints.
+type RateLimitConfig struct {
+	RequestsPerMinute int
+	Burst             int
+}
+
+// LoadConfig loads application and database configuration from environment variables.
 // Defaults are provided for some values.
-func LoadConfig() DBConfig {
-	err := godotenv.Load(".env")
-	if err != nil {
-		log.Printf("Info: .env file not found or error loading: %s. Using environment variables directly.", err)
+func LoadConfig() AppConfig {
+	envErr := godotenv.Load(".env")
+
+	// The logger is configured first so that everything below is logged in the chosen format.
+	logLevel := os.Getenv("LOG_LEVEL")
+	logFormat := os.Getenv("LOG_FORMAT")
+	if err := logger.Configure(logLevel, logFormat); err != nil {
+		logger.Fatal("Invalid logging configuration", slog.Any("error", err))
+	}
+	if envErr != nil {
+		slog.Info(".env file not found or error loading, using environment variables directly", slog.Any("error", envErr))
 	}
 
 	mongoURI := os.Getenv("MONGO_URI")
-	if mongoURI == "" {
-		log.Fatal("MONGO_URI environment variable is required")
-	}
 	dbName := os.Getenv("MONGO_DB_NAME")
 	if dbName == "" {
 		dbName = "shawtydb"
-		log.Printf("MONGO_DB_NAME not set, using default: %s", dbName)
+		slog.Info("MONGO_DB_NAME not set, using default", slog.String("value", dbName))
 	}
 	collectionName := os.Getenv("MONGO_COLLECTION_NAME")
 	if collectionName == "" {
 		collectionName = "urls" // Default collection name
-		log.Printf("MONGO_COLLECTION_NAME not set, using default: %s", collectionName)
+		slog.Info("MONGO_COLLECTION_NAME not set, using default", slog.String("value", collectionName))
+	}
+
+	// The length is part of every generated ID. Changing it on an existing database orphans
+	// previously shortened URLs: resubmitting them creates a new entry with a differently sized ID.
+	shortIDLength := getEnvInt("SHORT_ID_LENGTH", 8)
+	if shortIDLength < 4 || shortIDLength > 32 {
+		logger.Fatal("Invalid SHORT_ID_LENGTH: must be between 4 and 32 (note that changing it for an existing database orphans previously shortened URLs)", slog.Int("value", shortIDLength))
+	}
+
+	storeBackend := strings.ToLower(os.Getenv("STORE_BACKEND"))
+	switch storeBackend {
+	case "":
+		storeBackend = StoreBackendMongo
+		slog.Info("STORE_BACKEND not set, using default", slog.String("value", storeBackend))
+	case StoreBackendMongo, StoreBackendPostgres, StoreBackendSQLite, StoreBackendAtlas, StoreBackendDynamo:
+	default:
+		logger.Fatal("Invalid STORE_BACKEND: must be mongo, postgres, sqlite, atlas or dynamo", slog.String("value", storeBackend))
+	}
+	var atlasCfg AtlasConfig
+	if storeBackend == StoreBackendAtlas {
+		atlasCfg = loadAtlasConfig()
+	}
+	var dynamoCfg DynamoConfig
+	if storeBackend == StoreBackendDynamo {
+		dynamoCfg = loadDynamoConfig()
+	}
+	// The driver's default pool of 100 connections is more than a small deployment needs.
+	maxPoolSize := getEnvInt("MONGO_MAX_POOL_SIZE", 10)
+	minPoolSize := getEnvInt("MONGO_MIN_POOL_SIZE", 2)
+	if maxPoolSize < 0 || minPoolSize < 0 || (maxPoolSize > 0 && minPoolSize > maxPoolSize) {
+		logger.Fatal("Invalid MongoDB pool size: MONGO_MIN_POOL_SIZE must be between 0 and MONGO_MAX_POOL_SIZE",
+			slog.Int("min", minPoolSize), slog.Int("max", maxPoolSize))
+	}
+
+	tlsCfg := TLSConfig{
+		CertFile:    os.Getenv("TLS_CERT_FILE"),
+		KeyFile:     os.Getenv("TLS_KEY_FILE"),
+		Port:        os.Getenv("TLS_PORT"),
+		AutoDomain:  os.Getenv("AUTO_TLS_DOMAIN"),
+		AutocertDir: os.Getenv("AUTOCERT_DIR"),
+	}
+	if (tlsCfg.CertFile == "") != (tlsCfg.KeyFile == "") {
+		logger.Fatal("TLS_CERT_FILE and TLS_KEY_FILE must be set together")
+	}
+	if tlsCfg.Port == "" {
+		tlsCfg.Port = "8443"
+	}
+	if !validPort(tlsCfg.Port) {
+		logger.Fatal("Invalid TLS_PORT: must be a port number between 1 and 65535", slog.String("value", tlsCfg.Port))
+	}
+
+	cloudflareCfg := CloudflareConfig{
+		ZoneID:   os.Getenv("CF_ZONE_ID"),
+		APIToken: os.Getenv("CF_API_TOKEN"),
+	}
+	if (cloudflareCfg.ZoneID == "") != (cloudflareCfg.APIToken == "") {
+		logger.Fatal("CF_ZONE_ID and CF_API_TOKEN must be set together")
+	}
+
+	// An empty HOST listens on all interfaces, IPv4 and IPv6 alike.
+	host := os.Getenv("HOST")
+	port := os.Getenv("PORT")
+	if port == "" {
+		port = "8080"
+		slog.Info("PORT not set, using default", slog.String("value", port))
+	}
+	if !validPort(port) {
+		logger.Fatal("Invalid PORT: must be a port number between 1 and 65535", slog.String("value", port))
+	}
+	if tlsCfg.AutocertDir == "" {
+		tlsCfg.AutocertDir = "autocert"
+	}
+
+	sqlitePath := os.Getenv("DATABASE_PATH")
+	if sqlitePath == "" {
+		sqlitePath = "shawty.db"
+	}
+
+	// Changing the algorithm changes the ID generated for a given URL. Existing short links
+	// keep resolving, and re-submitted URLs are matched by their original URL. sha256 produces
+	// Base62 IDs; md5 keeps the legacy hex IDs.
+	hashAlgo := strings.ToLower(os.Getenv("HASH_ALGO"))
+	switch hashAlgo {
+	case "":
+		hashAlgo = "sha256"
+		slog.Info("HASH_ALGO not set, using default", slog.String("value", hashAlgo))
+	case "sha256", "md5":
+	default:
+		logger.Fatal("Invalid HASH_ALGO: must be sha256 or md5", slog.String("value", hashAlgo))
+	}
+
+	// MASTER_ADMIN_TOKEN replaced ADMIN_TOKEN when links were split into tenants; the old name is still read.
+	adminToken := os.Getenv("MASTER_ADMIN_TOKEN")
+	if adminToken == "" {
+		adminToken = os.Getenv("ADMIN_TOKEN")
+	}
+	if adminToken != "" {
+		slog.Info("Master admin token configured", slog.String("token", MaskAPIKey(adminToken)))
+	}
+
+	// With REQUIRE_TOKEN, only URLs the token endpoint was asked about can be shortened, which
+	// needs the key the tokens are signed with.
+	tokenSecret := os.Getenv("TOKEN_HMAC_SECRET")
+	requireToken := getEnvBool("REQUIRE_TOKEN", false)
+	if requireToken && tokenSecret == "" {
+		logger.Fatal("REQUIRE_TOKEN=true requires TOKEN_HMAC_SECRET")
+	}
+
+	// Permanent redirects are cached by browsers, so later visits are neither counted nor affected
+	// by destination changes. Temporary is the safer default for a shortener.
+	redirectType := strings.ToLower(os.Getenv("REDIRECT_TYPE"))
+	switch redirectType {
+	case "":
+		redirectType = domain.RedirectTemporary
+		slog.Info("REDIRECT_TYPE not set, using default", slog.String("value", redirectType))
+	case domain.RedirectPermanent, domain.RedirectTemporary:
+	default:
+		logger.Fatal("Invalid REDIRECT_TYPE: must be permanent or temporary", slog.String("value", redirectType))
 	}
 
-	return DBConfig{
-		URI:            mongoURI,
-		DBName:         dbName,
-		CollectionName: collectionName,
-		ConnectTimeout: 10 * time.Second,
-		PingTimeout:    5 * time.Second,
+	// Behind a reverse proxy the Host header is internal, so the public address must be configured.
+	baseURL := strings.TrimSuffix(os.Getenv("BASE_URL"), "/")
+	if baseURL != "" {
+		parsed, err := url.Parse(baseURL)
+		if err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Host == "" {
+			logger.Fatal("Invalid BASE_URL: must be an absolute http(s) URL such as https://shawty.example.com", slog.String("value", baseURL))
+		}
+	}
+
+	// DOMAIN_ALIASES is a comma-separated list of hosts, compared with the Host header as sent, port included.
+	domainAliases := getEnvList("DOMAIN_ALIASES")
+	for i, alias := range domainAliases {
+		alias = strings.ToLower(alias)
+		if parsed, err := url.Parse("//" + alias); err != nil || parsed.Host != alias || parsed.User != nil {
+			logger.Fatal("Invalid DOMAIN_ALIASES entry: must be a host such as go.example.com, without scheme or path", slog.String("value", alias))
+		}
+		domainAliases[i] = alias
+	}
+	if len(domainAliases) > 0 && baseURL == "" {
+		logger.Fatal("DOMAIN_ALIASES requires BASE_URL; without it every request gets short links on its own host")
+	}
+
+	// Some platforms probe fixed paths, so the probes can be moved. They are registered on the
+	// router as exact paths, which rules out wildcards and anything path.Clean would rewrite.
+	livenessPath := os.Getenv("LIVENESS_PATH")
+	if livenessPath == "" {
+		livenessPath = "/livez"
+	}
+	readinessPath := os.Getenv("READINESS_PATH")
+	if readinessPath == "" {
+		readinessPath = "/readyz"
+	}
+	for _, probePath := range []string{livenessPath, readinessPath} {
+		if !strings.HasPrefix(probePath, "/") || path.Clean(probePath) != probePath || strings.ContainsAny(probePath, "{} \t") {
+			logger.Fatal("Invalid LIVENESS_PATH or READINESS_PATH: must be an absolute path such as /livez", slog.String("value", probePath))
+		}
+	}
+	if livenessPath == readinessPath {
+		logger.Fatal("LIVENESS_PATH and READINESS_PATH must differ", slog.String("value", livenessPath))
+	}
+
+	// API_KEYS is a comma-separated list of key:owner pairs.
+	apiKeys := make(map[string]string)
+	for _, entry := range getEnvList("API_KEYS") {
+		key, owner, ok := strings.Cut(entry, ":")
+		key, owner = strings.TrimSpace(key), strings.TrimSpace(owner)
+		if !ok || key == "" || owner == "" {
+			logger.Fatal("Invalid API_KEYS entry: must be key:owner", slog.String("key", MaskAPIKey(key)))
+		}
+		apiKeys[key] = owner
+	}
+	if len(apiKeys) == 0 {
+		slog.Warn("API_KEYS not set, only the admin token can create short URLs")
+	} else {
+		masked := make([]string, 0, len(apiKeys))
+		for key, owner := range apiKeys {
+			masked = append(masked, MaskAPIKey(key)+":"+owner)
+		}
+		slices.Sort(masked)
+		slog.Info("Loaded API keys", slog.Int("count", len(apiKeys)), slog.Any("keys", masked))
+	}
+
+	// Setting PERMISSIONS_POLICY to an empty value leaves the header out.
+	permissionsPolicy, ok := os.LookupEnv("PERMISSIONS_POLICY")
+	if !ok {
+		permissionsPolicy = "geolocation=(), microphone=(), camera=()"
+	}
+
+	return AppConfig{
+		LogLevel:     logLevel,
+		LogFormat:    logFormat,
+		Host:         host,
+		Port:         port,
+		StoreBackend: storeBackend,
+		DB: DBConfig{
+			URI:             mongoURI,
+			DBName:          dbName,
+			CollectionName:  collectionName,
+			ConnectTimeout:  10 * time.Second,
+			PingTimeout:     5 * time.Second,
+			MaxPoolSize:     uint64(maxPoolSize),
+			MinPoolSize:     uint64(minPoolSize),
+			MaxConnIdleTime: time.Duration(getEnvInt("MONGO_MAX_CONN_IDLE_TIME_SECONDS", 60)) * time.Second,
+		},
+		Postgres: PostgresConfig{
+			DSN:            os.Getenv("DATABASE_URL"),
+			ConnectTimeout: 10 * time.Second,
+		},
+		SQLite: SQLiteConfig{
+			Path: sqlitePath,
+		},
+		Atlas:      atlasCfg,
+		Dynamo:     dynamoCfg,
+		TLS:        tlsCfg,
+		Cloudflare: cloudflareCfg,
+		Redis: RedisConfig{
+			Addr:     os.Getenv("REDIS_ADDR"),
+			Password: os.Getenv("REDIS_PASSWORD"),
+			CacheTTL: time.Duration(getEnvInt("CACHE_TTL_SECONDS", 300)) * time.Second,
+		},
+		CacheSize:           getEnvInt("CACHE_SIZE", 1000),
+		StoreMaxRetries:     getEnvInt("STORE_MAX_RETRIES", 3),
+		StoreRetryBase:      time.Duration(getEnvInt("STORE_RETRY_BASE_MS", 50)) * time.Millisecond,
+		DashboardEnabled:    getEnvBool("DASHBOARD_ENABLED", true),
+		AnalyticsEnabled:    getEnvBool("ANALYTICS_ENABLED", false),
+		GeoIPDBPath:         os.Getenv("GEOIP_DB_PATH"),
+		HashAlgo:            hashAlgo,
+		ShortIDLength:       shortIDLength,
+		MaxCollisionRetries: getEnvInt("MAX_COLLISION_RETRIES", 5),
+		BulkConcurrency:     getEnvInt("BULK_CONCURRENCY", 10),
+		AdminToken:          adminToken,
+		APIKeys:             apiKeys,
+		OTelEndpoint:        os.Getenv("OTEL_EXPORTER_OTLP_ENDPOINT"),
+		MetricsToken:        os.Getenv("METRICS_TOKEN"),
+		BlacklistFile:       os.Getenv("BLACKLIST_FILE"),
+		CORSAllowedOrigins:  getEnvList("CORS_ALLOWED_ORIGINS"),
+		ShutdownTimeout:     time.Duration(getEnvInt("SERVER_SHUTDOWN_TIMEOUT_SECONDS", 15)) * time.Second,
+		CleanupInterval:     time.Duration(getEnvInt("CLEANUP_INTERVAL_SECONDS", 300)) * time.Second,
+		BaseURL:             baseURL,
+		DomainAliases:       domainAliases,
+		LivenessPath:        livenessPath,
+		ReadinessPath:       readinessPath,
+		RedirectType:        redirectType,
+		LegacyRoutesEnabled: getEnvBool("LEGACY_ROUTES_ENABLED", true),
+		BundleTemplatePath:  os.Getenv("BUNDLE_TEMPLATE_PATH"),
+		MaxRequestBodyBytes: int64(getEnvInt("MAX_REQUEST_BODY_BYTES", 4<<10)),
+		MaxBulkBodyBytes:    int64(getEnvInt("MAX_BULK_REQUEST_BODY_BYTES", 1<<20)),
+		RequestTimeout:      time.Duration(getEnvInt("REQUEST_TIMEOUT_MS", 5000)) * time.Millisecond,
+		RedirectTimeout:     time.Duration(getEnvInt("REDIRECT_TIMEOUT_MS", 500)) * time.Millisecond,
+		ImportTimeout:       time.Duration(getEnvInt("IMPORT_TIMEOUT_MS", 10000)) * time.Millisecond,
+		TrustedProxies:      getEnvList("TRUSTED_PROXIES"),
+		TokenHMACSecret:     tokenSecret,
+		RequireToken:        requireToken,
+		AutoMigrate:         getEnvBool("AUTO_MIGRATE", false),
+		SitemapURL:          os.Getenv("SITEMAP_URL"),
+		Security: SecurityConfig{
+			HSTS: middleware.HSTSConfig{
+				MaxAge:            getEnvInt("HSTS_MAX_AGE", 365*24*60*60),
+				IncludeSubDomains: getEnvBool("HSTS_INCLUDE_SUBDOMAINS", false),
+				Preload:           getEnvBool("HSTS_PRELOAD", false),
+			},
+			ExpectCTMaxAge:    getEnvInt("EXPECT_CT_MAX_AGE", 86400),
+			PermissionsPolicy: permissionsPolicy,
+		},
+		RateLimit: RateLimitConfig{
+			RequestsPerMinute: getEnvInt("RATE_LIMIT_PER_MINUTE", 60),
+			Burst:             getEnvInt("RATE_LIMIT_BURST", 10),
+		},
+	}
+}
+
+// Listen returns the address the HTTP listener binds to, HOST:PORT, e.g. "127.0.0.1:8080"
+// or ":8080" on all interfaces.
+func (c AppConfig) Listen() string {
+	return net.JoinHostPort(c.Host, c.Port)
+}
+
+// validPort reports whether port is a TCP port number between 1 and 65535.
+func validPort(port string) bool {
+	n, err := strconv.Atoi(port)
+	return err == nil && n >= 1 && n <= 65535
+}
+
+// getEnvBool reads a boolean environment variable, falling back to def when it is unset or invalid.
+func getEnvBool(key string, def bool) bool {
+	raw := os.Getenv(key)
+	if raw == "" {
+		return def
+	}
+	val, err := strconv.ParseBool(raw)
+	if err != nil {
+		slog.Warn("Invalid boolean environment variable, using default", slog.String("key", key), slog.String("value", raw), slog.Bool("default", def))
+		return def
 	}
+	return val
+}
+
+// getEnvList reads a comma-separated environment variable, dropping empty entries and surrounding whitespace.
+// It returns nil when the variable is unset.
+func getEnvList(key string) []string {
+	var list []string
+	for _, item := range strings.Split(os.Getenv(key), ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			list = append(list, item)
+		}
+	}
+	return list
 }
 
 // ConnectDB establishes a connection to MongoDB using the provided configuration.
 func ConnectDB(cfg DBConfig) (*mongo.Client, error) {
-	clientOptions := options.Client().ApplyURI(cfg.URI)
+	if cfg.URI == "" {
+		return nil, fmt.Errorf("MONGO_URI environment variable is required")
+	}
+	clientOptions := mongoClientOptions(cfg)
+	slog.Info("MongoDB connection pool", slog.Uint64("max_pool_size", cfg.MaxPoolSize), slog.Uint64("min_pool_size", cfg.MinPoolSize),
+		slog.Duration("max_conn_idle_time", cfg.MaxConnIdleTime))
 
 	ctx, cancel := context.WithTimeout(context.Background(), cfg.ConnectTimeout)
 	defer cancel()
 
 	client, err := mongo.Connect(ctx, clientOptions)
 	if err != nil {
-		return nil, fmt.Errorf("failed to connect to MongoDB: %w", err)
+		slog.Error("MongoDB connection failed", slog.String("uri", MaskURI(cfg.URI)), slog.Any("error", err))
+		return nil, fmt.Errorf("failed to connect to MongoDB at %s: %w", MaskURI(cfg.URI), err)
 	}
 
 	ctxPing, cancelPing := context.WithTimeout(context.Background(), cfg.PingTimeout)
@@ -71,11 +517,75 @@ func ConnectDB(cfg DBConfig) (*mongo.Client, error) {
 	if err != nil {
 		// Disconnect if ping fails to clean up resources
 		if disconnectErr := client.Disconnect(context.Background()); disconnectErr != nil {
-			log.Printf("Failed to disconnect client after ping failure: %v", disconnectErr)
+			slog.Error("Failed to disconnect MongoDB client after ping failure", slog.Any("error", disconnectErr))
 		}
-		return nil, fmt.Errorf("failed to ping MongoDB: %w", err)
+		slog.Error("MongoDB ping failed", slog.String("uri", MaskURI(cfg.URI)), slog.Any("error", err))
+		return nil, fmt.Errorf("failed to ping MongoDB at %s: %w", MaskURI(cfg.URI), err)
 	}
 
-	fmt.Println("Successfully connected to MongoDB!")
+	slog.Info("Connected to MongoDB", slog.String("uri", MaskURI(cfg.URI)), slog.String("database", cfg.DBName))
 	return client, nil
 }
+
+// mongoClientOptions builds the MongoDB client options of cfg: its URI and connection pool settings.
+// Pool settings given in the URI are overridden.
+func mongoClientOptions(cfg DBConfig) *options.ClientOptions {
+	return options.Client().ApplyURI(cfg.URI).
+		SetMaxPoolSize(cfg.MaxPoolSize).
+		SetMinPoolSize(cfg.MinPoolSize).
+		SetMaxConnIdleTime(cfg.MaxConnIdleTime)
+}
+
+// ConnectPostgres opens a PostgreSQL connection pool with the pgx driver and verifies it with a ping.
+func ConnectPostgres(cfg PostgresConfig) (*sql.DB, error) {
+	if cfg.DSN == "" {
+		return nil, fmt.Errorf("DATABASE_URL environment variable is required when STORE_BACKEND=postgres")
+	}
+	db, err := sql.Open("pgx", cfg.DSN)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open PostgreSQL connection: %w", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), cfg.ConnectTimeout)
+	defer cancel()
+	if err := db.PingContext(ctx); err != nil {
+		slog.Error("PostgreSQL ping failed", slog.String("url", MaskURI(cfg.DSN)), slog.Any("error", err))
+		db.Close()
+		return nil, fmt.Errorf("failed to ping PostgreSQL at %s: %w", MaskURI(cfg.DSN), err)
+	}
+
+	slog.Info("Connected to PostgreSQL", slog.String("url", MaskURI(cfg.DSN)))
+	return db, nil
+}
+
+// ConnectSQLite opens the SQLite database file, creating it if needed, and verifies it with a ping.
+// The database uses write-ahead logging so that redirects can read while links are being written,
+// and waits for locks instead of failing with "database is locked".
+func ConnectSQLite(cfg SQLiteConfig) (*sql.DB, error) {
+	dsn := (&url.URL{Scheme: "file", Opaque: cfg.Path, RawQuery: "_pragma=journal_mode(WAL)&_pragma=busy_timeout(5000)"}).String()
+	db, err := sql.Open("sqlite", dsn)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open SQLite database: %w", err)
+	}
+	if err := db.Ping(); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("failed to open SQLite database '%s': %w", cfg.Path, err)
+	}
+
+	slog.Info("Opened SQLite database", slog.String("path", cfg.Path))
+	return db, nil
+}
+
+// getEnvInt reads an integer environment variable, falling back to def when it is unset or invalid.
+func getEnvInt(key string, def int) int {
+	raw := os.Getenv(key)
+	if raw == "" {
+		return def
+	}
+	val, err := strconv.Atoi(raw)
+	if err != nil {
+		slog.Warn("Invalid integer environment variable, using default", slog.String("key", key), slog.String("value", raw), slog.Int("default", def))
+		return def
+	}
+	return val
+}
@@ -0,0 +1,136 @@
+package config
+
+import (
+	"errors"
+	"os"
+	"os/exec"
+	"strings"
+	"testing"
+	"time"
+
+	"shawty/internal/middleware"
+)
+
+func TestMongoClientOptionsApplyPoolSettings(t *testing.T) {
+	cfg := DBConfig{
+		// Pool settings in the URI are overridden by the configured ones.
+		URI:             "mongodb://localhost:27017/?maxPoolSize=100&minPoolSize=50",
+		MaxPoolSize:     25,
+		MinPoolSize:     3,
+		MaxConnIdleTime: 90 * time.Second,
+	}
+	opts := mongoClientOptions(cfg)
+	if err := opts.Validate(); err != nil {
+		t.Fatalf("options are invalid: %v", err)
+	}
+	if opts.MaxPoolSize == nil || *opts.MaxPoolSize != 25 {
+		t.Errorf("MaxPoolSize = %v, want 25", opts.MaxPoolSize)
+	}
+	if opts.MinPoolSize == nil || *opts.MinPoolSize != 3 {
+		t.Errorf("MinPoolSize = %v, want 3", opts.MinPoolSize)
+	}
+	if opts.MaxConnIdleTime == nil || *opts.MaxConnIdleTime != 90*time.Second {
+		t.Errorf("MaxConnIdleTime = %v, want 90s", opts.MaxConnIdleTime)
+	}
+	if len(opts.Hosts) != 1 || opts.Hosts[0] != "localhost:27017" {
+		t.Errorf("Hosts = %v, want the URI's host", opts.Hosts)
+	}
+}
+
+func TestLoadConfigSecurityHeaders(t *testing.T) {
+	t.Chdir(t.TempDir()) // no .env file
+	tests := []struct {
+		name string
+		env  map[string]string
+		want SecurityConfig
+	}{
+		{
+			name: "defaults",
+			want: SecurityConfig{
+				HSTS:              middleware.HSTSConfig{MaxAge: 31536000},
+				ExpectCTMaxAge:    86400,
+				PermissionsPolicy: "geolocation=(), microphone=(), camera=()",
+			},
+		},
+		{
+			name: "preload",
+			env:  map[string]string{"HSTS_MAX_AGE": "63072000", "HSTS_INCLUDE_SUBDOMAINS": "true", "HSTS_PRELOAD": "true"},
+			want: SecurityConfig{
+				HSTS:              middleware.HSTSConfig{MaxAge: 63072000, IncludeSubDomains: true, Preload: true},
+				ExpectCTMaxAge:    86400,
+				PermissionsPolicy: "geolocation=(), microphone=(), camera=()",
+			},
+		},
+		{
+			name: "headers turned off",
+			env:  map[string]string{"HSTS_MAX_AGE": "0", "EXPECT_CT_MAX_AGE": "0", "PERMISSIONS_POLICY": ""},
+			want: SecurityConfig{},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for _, name := range []string{"HSTS_MAX_AGE", "HSTS_INCLUDE_SUBDOMAINS", "HSTS_PRELOAD", "EXPECT_CT_MAX_AGE"} {
+				t.Setenv(name, "")
+			}
+			for name, value := range tt.env {
+				t.Setenv(name, value)
+			}
+			if _, set := tt.env["PERMISSIONS_POLICY"]; !set {
+				unsetenv(t, "PERMISSIONS_POLICY")
+			}
+			if got := LoadConfig().Security; got != tt.want {
+				t.Errorf("Security = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+// unsetenv unsets the environment variable name for the rest of the test.
+func unsetenv(t *testing.T, name string) {
+	t.Helper()
+	t.Setenv(name, "") // restores the previous value on cleanup
+	os.Unsetenv(name)
+}
+
+func TestLoadConfigPort(t *testing.T) {
+	t.Chdir(t.TempDir()) // no .env file
+	tests := []struct {
+		host, port string
+		wantPort   string
+		wantListen string
+	}{
+		{port: "9090", wantPort: "9090", wantListen: ":9090"},
+		{host: "127.0.0.1", port: "9090", wantPort: "9090", wantListen: "127.0.0.1:9090"},
+		{host: "::1", port: "", wantPort: "8080", wantListen: "[::1]:8080"},
+	}
+	for _, tt := range tests {
+		t.Setenv("HOST", tt.host)
+		t.Setenv("PORT", tt.port)
+		cfg := LoadConfig()
+		if cfg.Port != tt.wantPort || cfg.Listen() != tt.wantListen {
+			t.Errorf("HOST=%q PORT=%q: Port = %q, Listen() = %q; want %q and %q", tt.host, tt.port, cfg.Port, cfg.Listen(), tt.wantPort, tt.wantListen)
+		}
+	}
+}
+
+func TestLoadConfigRejectsInvalidPort(t *testing.T) {
+	// LoadConfig exits the process on invalid settings, so it runs in a child test process.
+	if os.Getenv("SHAWTY_TEST_LOAD_CONFIG") == "1" {
+		LoadConfig()
+		return
+	}
+	for _, port := range []string{"99999", "0", "http"} {
+		cmd := exec.Command(os.Args[0], "-test.run=^TestLoadConfigRejectsInvalidPort$")
+		cmd.Dir = t.TempDir()
+		cmd.Env = append(os.Environ(), "SHAWTY_TEST_LOAD_CONFIG=1", "PORT="+port)
+		out, err := cmd.CombinedOutput()
+		var exitErr *exec.ExitError
+		if !errors.As(err, &exitErr) || exitErr.ExitCode() != 1 {
+			t.Errorf("PORT=%s: LoadConfig error = %v, want exit status 1; output:\n%s", port, err, out)
+			continue
+		}
+		if !strings.Contains(string(out), "Invalid PORT") {
+			t.Errorf("PORT=%s: output does not report the invalid port:\n%s", port, out)
+		}
+	}
+}
@@ -0,0 +1,58 @@
+package config
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"os"
+
+	"shawty/internal/logger"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	awsconfig "github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+)
+
+// DynamoConfig holds the settings for the DynamoDB store used when STORE_BACKEND=dynamo.
+// Credentials come from the default AWS chain: environment variables, shared config files, or
+// the role of the instance or function.
+type DynamoConfig struct {
+	TableName string
+	Region    string
+	Endpoint  string // Overrides the regional endpoint, e.g. http://localhost:8000 for DynamoDB Local
+}
+
+// loadDynamoConfig loads the DynamoDB configuration from environment variables.
+func loadDynamoConfig() DynamoConfig {
+	tableName := os.Getenv("DYNAMO_TABLE_NAME")
+	if tableName == "" {
+		logger.Fatal("DYNAMO_TABLE_NAME environment variable is required when STORE_BACKEND=dynamo")
+	}
+	region := os.Getenv("AWS_REGION")
+	if region == "" {
+		logger.Fatal("AWS_REGION environment variable is required when STORE_BACKEND=dynamo")
+	}
+	endpoint := os.Getenv("DYNAMO_ENDPOINT")
+
+	slog.Info("Using DynamoDB", slog.String("table", tableName), slog.String("region", region), slog.String("endpoint", endpoint))
+
+	return DynamoConfig{
+		TableName: tableName,
+		Region:    region,
+		Endpoint:  endpoint,
+	}
+}
+
+// ConnectDynamo returns a DynamoDB client for the configured region. No connection is opened
+// until the first request, so the table is only checked by EnsureIndexes and the readiness probe.
+func ConnectDynamo(cfg DynamoConfig) (*dynamodb.Client, error) {
+	awsCfg, err := awsconfig.LoadDefaultConfig(context.Background(), awsconfig.WithRegion(cfg.Region))
+	if err != nil {
+		return nil, fmt.Errorf("failed to load AWS configuration: %w", err)
+	}
+	return dynamodb.NewFromConfig(awsCfg, func(o *dynamodb.Options) {
+		if cfg.Endpoint != "" {
+			o.BaseEndpoint = aws.String(cfg.Endpoint)
+		}
+	}), nil
+}
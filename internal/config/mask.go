@@ -0,0 +1,56 @@
+package config
+
+import (
+	"net/url"
+	"strings"
+)
+
+// maskedCredential replaces secrets in logged values.
+const maskedCredential = "***"
+
+// MaskURI returns uri with its user info replaced by ***:*** and the values of password-, secret-
+// and token-like query parameters masked, so that connection strings can be logged. A value that
+// cannot be parsed as an absolute URI, such as a key=value PostgreSQL DSN or a URI with an
+// unescaped '@' in its password, is masked entirely rather than risk logging a secret.
+func MaskURI(uri string) string {
+	parsed, err := url.Parse(uri)
+	if err != nil || parsed.Scheme == "" || parsed.Host == "" || strings.Contains(parsed.Path+parsed.RawQuery+parsed.Fragment, "@") {
+		return maskedCredential
+	}
+	// The masks are spliced in by hand, since url.URL would percent-encode them.
+	params := strings.Split(parsed.RawQuery, "&")
+	for i, param := range params {
+		if key, _, ok := strings.Cut(param, "="); ok && isSecretParameter(key) {
+			params[i] = key + "=" + maskedCredential
+		}
+	}
+	parsed.RawQuery = strings.Join(params, "&")
+	hasUser := parsed.User != nil
+	parsed.User = nil
+	masked := parsed.String()
+	if hasUser {
+		masked = strings.Replace(masked, "://", "://"+maskedCredential+":"+maskedCredential+"@", 1)
+	}
+	return masked
+}
+
+// isSecretParameter reports whether a connection string option may hold a credential, e.g.
+// tlsCertificateKeyFilePassword or authMechanismProperties=AWS_SESSION_TOKEN:...
+func isSecretParameter(key string) bool {
+	key = strings.ToLower(key)
+	for _, marker := range []string{"password", "secret", "token", "authmechanismproperties"} {
+		if strings.Contains(key, marker) {
+			return true
+		}
+	}
+	return false
+}
+
+// MaskAPIKey returns the first four characters of key followed by ****, enough to tell keys apart
+// in logs. Keys of four characters or fewer are masked entirely.
+func MaskAPIKey(key string) string {
+	if len(key) <= 4 {
+		return "****"
+	}
+	return key[:4] + "****"
+}
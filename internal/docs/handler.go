@@ -0,0 +1,72 @@
+package docs
+
+import (
+	"encoding/json"
+	"log/slog"
+	"net/http"
+)
+
+// swaggerUIPage renders the spec served at /openapi.json with Swagger UI loaded from a CDN.
+const swaggerUIPage = `<!DOCTYPE html>
+<html lang="en">
+<head>
+  <meta charset="utf-8">
+  <title>Shawty API documentation</title>
+  <link rel="stylesheet" href="https://unpkg.com/swagger-ui-dist@5/swagger-ui.css">
+</head>
+<body>
+  <div id="swagger-ui"></div>
+  <script src="https://unpkg.com/swagger-ui-dist@5/swagger-ui-bundle.js" crossorigin></script>
+  <script>
+    window.onload = () => {
+      window.ui = SwaggerUIBundle({ url: "/openapi.json", dom_id: "#swagger-ui" });
+    };
+  </script>
+</body>
+</html>
+`
+
+// Handler serves the OpenAPI document and the Swagger UI page.
+// The document is built and encoded once, since it does not change at runtime.
+type Handler struct {
+	spec []byte
+}
+
+// NewHandler builds the OpenAPI document and returns a Handler serving it.
+func NewHandler() (*Handler, error) {
+	spec, err := json.Marshal(NewSpec())
+	if err != nil {
+		return nil, err
+	}
+	return &Handler{spec: spec}, nil
+}
+
+// RegisterRoutes sets up the routes for the documentation handler.
+func (h *Handler) RegisterRoutes(mux *http.ServeMux) {
+	mux.HandleFunc("/openapi.json", h.specHandler)
+	mux.HandleFunc("/docs", h.uiHandler)
+}
+
+// specHandler returns the OpenAPI document as JSON.
+func (h *Handler) specHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		http.Error(w, "Only GET method is allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	if _, err := w.Write(h.spec); err != nil {
+		slog.ErrorContext(r.Context(), "Error writing OpenAPI document", slog.Any("error", err))
+	}
+}
+
+// uiHandler returns the Swagger UI page.
+func (h *Handler) uiHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		http.Error(w, "Only GET method is allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	if _, err := w.Write([]byte(swaggerUIPage)); err != nil {
+		slog.ErrorContext(r.Context(), "Error writing API documentation page", slog.Any("error", err))
+	}
+}
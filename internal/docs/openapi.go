@@ -0,0 +1,722 @@
+// Package docs describes the HTTP API as an OpenAPI 3.0 document and serves it,
+// together with a Swagger UI page, for API consumers.
+package docs
+
+import (
+	"net/http"
+
+	"github.com/getkin/kin-openapi/openapi3"
+)
+
+// Version is the API version reported in the OpenAPI document.
+const Version = "1.0.0"
+
+// basePath is the prefix the API paths below are served under; see handler.RegisterRoutes.
+const basePath = "/api/v1"
+
+// schemaRef returns a reference to a schema under components/schemas.
+func schemaRef(name string) *openapi3.SchemaRef {
+	return openapi3.NewSchemaRef("#/components/schemas/"+name, nil)
+}
+
+// newOperation creates an operation with a summary, a tag and an empty response set.
+func newOperation(id, summary, tag string) *openapi3.Operation {
+	op := openapi3.NewOperation()
+	op.OperationID = id
+	op.Summary = summary
+	op.Tags = []string{tag}
+	op.Responses = openapi3.NewResponsesWithCapacity(4)
+	return op
+}
+
+// arrayOf returns an array schema whose items are the named component schema.
+func arrayOf(name string) *openapi3.Schema {
+	schema := openapi3.NewArraySchema()
+	schema.Items = schemaRef(name)
+	return schema
+}
+
+// textResponse describes a plain-text response.
+func textResponse(description string) *openapi3.Response {
+	return openapi3.NewResponse().
+		WithDescription(description).
+		WithContent(openapi3.NewContentWithSchema(openapi3.NewStringSchema(), []string{"text/plain"}))
+}
+
+// errorResponse describes an error response, whose body is an ErrorResponse.
+func errorResponse(description string) *openapi3.Response {
+	return jsonResponse(description, "ErrorResponse")
+}
+
+// jsonResponse describes a JSON response whose body is the named component schema.
+func jsonResponse(description, schema string) *openapi3.Response {
+	return openapi3.NewResponse().WithDescription(description).WithJSONSchemaRef(schemaRef(schema))
+}
+
+// bearerAuth requires the Authorization: Bearer header checked by the API key and admin middleware.
+var bearerAuth = openapi3.NewSecurityRequirements().With(openapi3.NewSecurityRequirement().Authenticate("bearerAuth"))
+
+// shortIDParameter is the {shortID} path segment shared by the per-link endpoints.
+var shortIDParameter = openapi3.NewPathParameter("shortID").
+	WithDescription("The short code of the link").
+	WithSchema(openapi3.NewStringSchema())
+
+// dateRangeParameters are the from and to query parameters of the click analytics endpoints.
+var dateRangeParameters = []*openapi3.Parameter{
+	openapi3.NewQueryParameter("from").
+		WithDescription("Start of the range as YYYY-MM-DD or an RFC 3339 timestamp; defaults to the first click").
+		WithSchema(openapi3.NewStringSchema()),
+	openapi3.NewQueryParameter("to").
+		WithDescription("End of the range as YYYY-MM-DD (inclusive of that day) or an RFC 3339 timestamp; defaults to the end of today (UTC)").
+		WithSchema(openapi3.NewStringSchema()),
+}
+
+// schemas returns the component schemas. They mirror the request and response structs in
+// internal/handler and internal/domain and must be updated together with them.
+func schemas() openapi3.Schemas {
+	utm := openapi3.NewObjectSchema().
+		WithProperty("source", openapi3.NewStringSchema()).
+		WithProperty("medium", openapi3.NewStringSchema()).
+		WithProperty("campaign", openapi3.NewStringSchema()).
+		WithProperty("term", openapi3.NewStringSchema()).
+		WithProperty("content", openapi3.NewStringSchema())
+	utm.Description = "Campaign parameters added to the destination's query string on redirect"
+
+	redirectType := openapi3.NewStringSchema().WithEnum("permanent", "temporary")
+	redirectType.Description = "permanent (301) or temporary (302); defaults to the server's REDIRECT_TYPE"
+
+	variant := openapi3.NewObjectSchema().
+		WithProperty("name", openapi3.NewStringSchema().WithMaxLength(200)).
+		WithProperty("url", openapi3.NewStringSchema().WithFormat("uri")).
+		WithProperty("weight", openapi3.NewIntegerSchema().WithMin(0).WithMax(100))
+	variant.Required = []string{"url"}
+	variant.Description = "An A/B test destination. weight (1-100) is the percentage of visitors sent to url; the link's own URL receives what the weights leave. " +
+		"Names are 1-50 letters, digits, '_' or '-'; unnamed variants are called variant-1, variant-2 and so on. " +
+		"In a bundle, each variant is one entry of the page: name is its label, defaulting to url, and weight is left out"
+
+	variants := openapi3.NewArraySchema().WithItems(variant).WithMaxItems(50)
+	variants.Description = "At most 10 A/B test variants, or 1-50 bundle links"
+
+	bundle := openapi3.NewBoolSchema()
+	bundle.Description = "Show an HTML page listing the variants instead of redirecting; url may then be left out and defaults to the first link"
+
+	customHeaders := openapi3.NewObjectSchema().
+		WithAdditionalProperties(openapi3.NewStringSchema().WithMaxLength(256)).
+		WithMaxProperties(10)
+	customHeaders.Description = "Response headers set on the link's redirects, e.g. {\"Cache-Control\": \"no-store\"}. " +
+		"Names are at most 64 characters and stored in canonical form. Headers links may not set, such as Set-Cookie, " +
+		"Content-Security-Policy, Location and Access-Control-*, are dropped without an error"
+
+	destination := openapi3.NewStringSchema().WithFormat("uri")
+	destination.Description = "The URL to shorten; required unless bundle is set"
+
+	shortenToken := openapi3.NewStringSchema()
+	shortenToken.Description = "Token from /shorten/token for url; required when the server runs with REQUIRE_TOKEN=true"
+
+	shortenRequest := openapi3.NewObjectSchema().
+		WithProperty("url", destination).
+		WithProperty("custom_code", openapi3.NewStringSchema()).
+		WithProperty("expires_in_seconds", openapi3.NewInt64Schema().WithMin(1)).
+		WithProperty("redirect_type", redirectType).
+		WithProperty("password", openapi3.NewStringSchema()).
+		WithProperty("max_clicks", openapi3.NewInt64Schema().WithMin(1)).
+		WithPropertyRef("utm", schemaRef("UTMParams")).
+		WithProperty("tags", openapi3.NewArraySchema().WithItems(openapi3.NewStringSchema()).WithMaxItems(10)).
+		WithProperty("webhook_url", openapi3.NewStringSchema().WithFormat("uri")).
+		WithProperty("mobile_url", openapi3.NewStringSchema().WithFormat("uri")).
+		WithProperty("variants", variants).
+		WithProperty("active_from", openapi3.NewDateTimeSchema()).
+		WithProperty("active_until", openapi3.NewDateTimeSchema()).
+		WithProperty("bundle", bundle).
+		WithProperty("bundle_title", openapi3.NewStringSchema().WithMaxLength(200)).
+		WithProperty("custom_headers", customHeaders).
+		WithProperty("token", shortenToken)
+
+	shortenResponse := openapi3.NewObjectSchema().
+		WithProperty("short_url", openapi3.NewStringSchema().WithFormat("uri")).
+		WithProperty("original_url", openapi3.NewStringSchema().WithFormat("uri")).
+		WithProperty("creation_date", openapi3.NewDateTimeSchema()).
+		WithProperty("expires_at", openapi3.NewDateTimeSchema()).
+		WithProperty("active_from", openapi3.NewDateTimeSchema()).
+		WithProperty("active_until", openapi3.NewDateTimeSchema()).
+		WithProperty("redirect_type", openapi3.NewStringSchema().WithEnum("permanent", "temporary")).
+		WithProperty("max_clicks", openapi3.NewInt64Schema()).
+		WithPropertyRef("utm", schemaRef("UTMParams")).
+		WithProperty("tags", openapi3.NewArraySchema().WithItems(openapi3.NewStringSchema())).
+		WithProperty("webhook_url", openapi3.NewStringSchema().WithFormat("uri")).
+		WithProperty("mobile_url", openapi3.NewStringSchema().WithFormat("uri")).
+		WithProperty("variants", variants).
+		WithProperty("bundle", openapi3.NewBoolSchema()).
+		WithProperty("bundle_title", openapi3.NewStringSchema()).
+		WithProperty("custom_headers", customHeaders).
+		WithProperty("page_title", openapi3.NewStringSchema()).
+		WithProperty("page_description", openapi3.NewStringSchema())
+	shortenResponse.Required = []string{"short_url", "original_url", "creation_date"}
+
+	bulkRequest := openapi3.NewObjectSchema().
+		WithProperty("urls", openapi3.NewArraySchema().WithItems(openapi3.NewStringSchema()))
+	bulkRequest.Required = []string{"urls"}
+
+	bulkResult := openapi3.NewObjectSchema().
+		WithProperty("original_url", openapi3.NewStringSchema()).
+		WithProperty("short_url", openapi3.NewStringSchema().WithFormat("uri")).
+		WithProperty("creation_date", openapi3.NewDateTimeSchema()).
+		WithProperty("error", openapi3.NewStringSchema())
+	bulkResult.Required = []string{"original_url"}
+
+	url := openapi3.NewObjectSchema().
+		WithProperty("id", openapi3.NewStringSchema()).
+		WithProperty("original_url", openapi3.NewStringSchema().WithFormat("uri")).
+		WithProperty("submitted_url", openapi3.NewStringSchema()).
+		WithProperty("short_url", openapi3.NewStringSchema()).
+		WithProperty("creation_date", openapi3.NewDateTimeSchema()).
+		WithProperty("expires_at", openapi3.NewDateTimeSchema()).
+		WithProperty("click_count", openapi3.NewInt64Schema()).
+		WithProperty("last_accessed_at", openapi3.NewDateTimeSchema()).
+		WithProperty("updated_at", openapi3.NewDateTimeSchema()).
+		WithProperty("deleted_at", openapi3.NewDateTimeSchema()).
+		WithProperty("redirect_type", openapi3.NewStringSchema().WithEnum("permanent", "temporary")).
+		WithProperty("max_clicks", openapi3.NewInt64Schema()).
+		WithPropertyRef("utm", schemaRef("UTMParams")).
+		WithProperty("tags", openapi3.NewArraySchema().WithItems(openapi3.NewStringSchema())).
+		WithProperty("webhook_url", openapi3.NewStringSchema().WithFormat("uri")).
+		WithProperty("mobile_url", openapi3.NewStringSchema().WithFormat("uri")).
+		WithProperty("mobile_click_count", openapi3.NewInt64Schema()).
+		WithProperty("conversion_count", openapi3.NewInt64Schema()).
+		WithProperty("page_title", openapi3.NewStringSchema()).
+		WithProperty("page_description", openapi3.NewStringSchema()).
+		WithProperty("tenant_id", openapi3.NewStringSchema()).
+		WithProperty("alias_of", openapi3.NewStringSchema()).
+		WithProperty("variants", variants).
+		WithProperty("active_from", openapi3.NewDateTimeSchema()).
+		WithProperty("active_until", openapi3.NewDateTimeSchema()).
+		WithProperty("bundle", openapi3.NewBoolSchema()).
+		WithProperty("bundle_title", openapi3.NewStringSchema()).
+		WithProperty("custom_headers", customHeaders).
+		WithProperty("domain", openapi3.NewStringSchema())
+
+	info := openapi3.NewObjectSchema().
+		WithProperty("is_expired", openapi3.NewBoolSchema()).
+		WithProperty("is_active", openapi3.NewBoolSchema()).
+		WithProperty("clicks_remaining", openapi3.NewInt64Schema().WithNullable()).
+		WithProperty("password_protected", openapi3.NewBoolSchema()).
+		WithProperty("aliases", openapi3.NewArraySchema().WithItems(openapi3.NewStringSchema()))
+	urlInfo := &openapi3.Schema{AllOf: openapi3.SchemaRefs{schemaRef("URL"), info.NewRef()}}
+
+	updateRequest := openapi3.NewObjectSchema().
+		WithProperty("url", openapi3.NewStringSchema().WithFormat("uri")).
+		WithProperty("tags", openapi3.NewArraySchema().WithItems(openapi3.NewStringSchema()).WithMaxItems(10))
+	updateRequest.Description = "Set url, tags or both; an empty tags list removes all tags"
+
+	aliasRequest := openapi3.NewObjectSchema().
+		WithProperty("alias", openapi3.NewStringSchema().WithPattern(`^[a-zA-Z0-9_-]{3,50}$`))
+	aliasRequest.Required = []string{"alias"}
+
+	stats := openapi3.NewObjectSchema().
+		WithProperty("short_url", openapi3.NewStringSchema().WithFormat("uri")).
+		WithProperty("original_url", openapi3.NewStringSchema()).
+		WithProperty("click_count", openapi3.NewInt64Schema()).
+		WithProperty("mobile_click_count", openapi3.NewInt64Schema()).
+		WithProperty("last_accessed_at", openapi3.NewDateTimeSchema().WithNullable()).
+		WithProperty("creation_date", openapi3.NewDateTimeSchema())
+
+	listURLs := openapi3.NewObjectSchema().
+		WithProperty("data", arrayOf("URL")).
+		WithProperty("limit", openapi3.NewInt64Schema()).
+		WithProperty("next_cursor", openapi3.NewStringSchema()).
+		WithProperty("total", openapi3.NewInt64Schema())
+
+	importError := openapi3.NewObjectSchema().
+		WithProperty("line", openapi3.NewIntegerSchema()).
+		WithProperty("error", openapi3.NewStringSchema())
+	importResponse := openapi3.NewObjectSchema().
+		WithProperty("processed", openapi3.NewIntegerSchema()).
+		WithProperty("created", openapi3.NewIntegerSchema()).
+		WithProperty("skipped_duplicates", openapi3.NewIntegerSchema()).
+		WithProperty("errors", openapi3.NewArraySchema().WithItems(importError))
+
+	quota := openapi3.NewObjectSchema().
+		WithProperty("used", openapi3.NewInt64Schema()).
+		WithProperty("limit", openapi3.NewInt64Schema().WithMin(0))
+	quota.Description = "Links created by a tenant, deleted ones included, and the most it may create; a limit of 0 means unlimited"
+
+	updateQuota := openapi3.NewObjectSchema().
+		WithProperty("limit", openapi3.NewInt64Schema().WithMin(0))
+	updateQuota.Required = []string{"limit"}
+
+	transferRequest := openapi3.NewObjectSchema().
+		WithProperty("new_owner", openapi3.NewStringSchema().WithMinLength(1))
+	transferRequest.Required = []string{"new_owner"}
+	transferRequest.Description = "Owner name of the API key of the tenant that receives the link"
+
+	refererCount := openapi3.NewObjectSchema().
+		WithProperty("referer", openapi3.NewStringSchema()).
+		WithProperty("count", openapi3.NewInt64Schema())
+	refererCount.Description = "Clicks from one referring domain; clicks without a Referer header are counted as (direct)"
+
+	clickBucket := openapi3.NewObjectSchema().
+		WithProperty("date", openapi3.NewStringSchema()).
+		WithProperty("count", openapi3.NewInt64Schema())
+	clickBucket.Description = "Clicks in one period, identified by its start in UTC: 2024-01-15T13:00:00Z (hour), 2024-01-15 (day, week) or 2024-01 (month)"
+
+	countryCount := openapi3.NewObjectSchema().
+		WithProperty("country", openapi3.NewStringSchema().WithMinLength(2).WithMaxLength(2)).
+		WithProperty("count", openapi3.NewInt64Schema())
+	countryCount.Description = "Clicks from one country as an ISO 3166-1 alpha-2 code; XX when the location is unknown"
+
+	variantStats := openapi3.NewObjectSchema().
+		WithProperty("name", openapi3.NewStringSchema()).
+		WithProperty("url", openapi3.NewStringSchema().WithFormat("uri")).
+		WithProperty("weight", openapi3.NewIntegerSchema()).
+		WithProperty("clicks", openapi3.NewInt64Schema()).
+		WithProperty("click_share", openapi3.NewFloat64Schema().WithMin(0).WithMax(1))
+	variantStats.Description = "Clicks sent to one destination of an A/B-tested link; the entry named original covers the link's own URL"
+
+	domainStats := openapi3.NewObjectSchema().
+		WithProperty("domain", openapi3.NewStringSchema()).
+		WithProperty("links", openapi3.NewInt64Schema()).
+		WithProperty("clicks", openapi3.NewInt64Schema())
+	domainStats.Description = "Links created on one short domain and their clicks; links from before domains were recorded have an empty domain"
+
+	clickEvent := openapi3.NewObjectSchema().
+		WithProperty("short_id", openapi3.NewStringSchema()).
+		WithProperty("click_count", openapi3.NewInt64Schema()).
+		WithProperty("at", openapi3.NewDateTimeSchema())
+	clickEvent.Description = "The data of a click event: the link clicked, its click count after the click, and when it happened. Clicks on aliases are reported for the primary link"
+
+	conversionStats := openapi3.NewObjectSchema().
+		WithProperty("clicks", openapi3.NewInt64Schema()).
+		WithProperty("conversions", openapi3.NewInt64Schema()).
+		WithProperty("rate", openapi3.NewFloat64Schema().WithMin(0))
+	conversionStats.Description = "Conversions reported for a link against its clicks; rate is conversions / clicks, or 0 without clicks. " +
+		"Conversions reported without a session_id are not matched to clicks, so rate may exceed 1"
+
+	health := openapi3.NewObjectSchema().
+		WithProperty("status", openapi3.NewStringSchema().WithEnum("ok", "degraded")).
+		WithProperty("mongo", openapi3.NewStringSchema().WithEnum("up", "down")).
+		WithProperty("approximate_total_urls", openapi3.NewInt64Schema()).
+		WithProperty("error", openapi3.NewStringSchema())
+
+	liveness := openapi3.NewObjectSchema().
+		WithProperty("status", openapi3.NewStringSchema().WithEnum("alive"))
+
+	errorBody := openapi3.NewObjectSchema().
+		WithProperty("error", openapi3.NewStringSchema()).
+		WithProperty("code", openapi3.NewStringSchema().WithEnum(
+			"NOT_FOUND", "VALIDATION_ERROR", "HASH_COLLISION", "RATE_LIMITED", "UNAUTHORIZED", "FORBIDDEN",
+			"INTERNAL_ERROR", "METHOD_NOT_ALLOWED", "GONE", "CONFLICT", "PAYLOAD_TOO_LARGE", "QUOTA_EXCEEDED",
+			"SERVICE_UNAVAILABLE", "NOT_IMPLEMENTED"))
+	errorBody.Required = []string{"error", "code"}
+	errorBody.Description = "Body of every error response; clients should branch on code, not on the message"
+
+	return openapi3.Schemas{
+		"ErrorResponse":      errorBody.NewRef(),
+		"UTMParams":          utm.NewRef(),
+		"ShortenURLRequest":  shortenRequest.NewRef(),
+		"ShortenURLResponse": shortenResponse.NewRef(),
+		"ShortenTokenResponse": openapi3.NewObjectSchema().
+			WithProperty("token", openapi3.NewStringSchema()).NewRef(),
+		"BulkShortenRequest": bulkRequest.NewRef(),
+		"BulkShortenResult":  bulkResult.NewRef(),
+		"URL":                url.NewRef(),
+		"URLInfoResponse":    urlInfo.NewRef(),
+		"UpdateURLRequest":   updateRequest.NewRef(),
+		"CreateAliasRequest": aliasRequest.NewRef(),
+		"URLStatsResponse":   stats.NewRef(),
+		"ListURLsResponse":   listURLs.NewRef(),
+		"ImportResponse":     importResponse.NewRef(),
+		"QuotaResponse":      quota.NewRef(),
+		"UpdateQuotaRequest": updateQuota.NewRef(),
+		"TransferRequest":    transferRequest.NewRef(),
+		"RefererCount":       refererCount.NewRef(),
+		"CountryCount":       countryCount.NewRef(),
+		"VariantStats":       variantStats.NewRef(),
+		"ConversionStats":    conversionStats.NewRef(),
+		"DomainStats":        domainStats.NewRef(),
+		"ClickEvent":         clickEvent.NewRef(),
+		"ClickBucket":        clickBucket.NewRef(),
+		"HealthResponse":     health.NewRef(),
+		"LivenessResponse":   liveness.NewRef(),
+	}
+}
+
+// NewSpec builds the OpenAPI document describing the public HTTP API.
+func NewSpec() *openapi3.T {
+	idempotencyKey := openapi3.NewHeaderParameter("Idempotency-Key").
+		WithDescription("Client-chosen key, e.g. a UUID. The first successful response for a key is replayed for 24 hours to retries with the same key, marked by an Idempotent-Replayed: true header").
+		WithSchema(openapi3.NewStringSchema().WithMaxLength(255))
+
+	shorten := newOperation("shortenURL", "Create a short URL", "links")
+	shorten.Security = bearerAuth
+	shorten.AddParameter(idempotencyKey)
+	shorten.RequestBody = &openapi3.RequestBodyRef{Value: openapi3.NewRequestBody().
+		WithRequired(true).
+		WithJSONSchemaRef(schemaRef("ShortenURLRequest"))}
+	shorten.AddResponse(http.StatusCreated, jsonResponse("The short URL was created", "ShortenURLResponse"))
+	shorten.AddResponse(http.StatusOK, jsonResponse("The URL was already shortened; the existing short URL is returned", "ShortenURLResponse"))
+	shorten.AddResponse(http.StatusBadRequest, errorResponse("The request body or destination URL is invalid"))
+	shorten.AddResponse(http.StatusUnauthorized, errorResponse("Missing or invalid API key, or a missing token while tokens are required"))
+	shorten.AddResponse(http.StatusForbidden, errorResponse("The token does not match the URL"))
+	shorten.AddResponse(http.StatusConflict, errorResponse("The custom code is already taken"))
+	shorten.AddResponse(http.StatusRequestEntityTooLarge, errorResponse("The request body is larger than MAX_REQUEST_BODY_BYTES (4 KB by default)"))
+	shorten.AddResponse(http.StatusUnprocessableEntity, errorResponse("The custom code is reserved, the destination is not allowed, or the Idempotency-Key was used for a different request"))
+	shorten.AddResponse(http.StatusTooManyRequests, errorResponse("Rate limit exceeded, or the API key's URL quota is used up"))
+	shorten.AddResponse(http.StatusServiceUnavailable, errorResponse("The database is unavailable; try again shortly"))
+
+	bulk := newOperation("bulkShortenURL", "Create several short URLs at once", "links")
+	bulk.Security = bearerAuth
+	bulk.AddParameter(idempotencyKey)
+	bulk.RequestBody = &openapi3.RequestBodyRef{Value: openapi3.NewRequestBody().
+		WithRequired(true).
+		WithJSONSchemaRef(schemaRef("BulkShortenRequest"))}
+	bulk.AddResponse(http.StatusOK, openapi3.NewResponse().
+		WithDescription("One result per submitted URL, in order; failed entries carry an error").
+		WithJSONSchema(arrayOf("BulkShortenResult")))
+	bulk.AddResponse(http.StatusBadRequest, errorResponse("The request body is invalid or has too many URLs"))
+	bulk.AddResponse(http.StatusUnauthorized, errorResponse("Missing or invalid API key"))
+	bulk.AddResponse(http.StatusForbidden, errorResponse("Bulk shortening is disabled because the server requires tokens"))
+	bulk.AddResponse(http.StatusRequestEntityTooLarge, errorResponse("The request body is larger than MAX_BULK_REQUEST_BODY_BYTES (1 MB by default)"))
+	bulk.AddResponse(http.StatusUnprocessableEntity, errorResponse("The Idempotency-Key was used for a different request"))
+
+	shortenToken := newOperation("shortenToken", "Issue the token that approves a URL for shortening", "links")
+	shortenToken.Description = "Returns the HMAC-SHA256 of the normalized URL under TOKEN_HMAC_SECRET. With REQUIRE_TOKEN=true, /shorten only accepts a URL together with its token. Needs the master admin token."
+	shortenToken.Security = bearerAuth
+	shortenToken.AddParameter(openapi3.NewQueryParameter("url").
+		WithDescription("The URL to approve").
+		WithRequired(true).
+		WithSchema(openapi3.NewStringSchema().WithFormat("uri")))
+	shortenToken.AddResponse(http.StatusOK, jsonResponse("The token for the URL", "ShortenTokenResponse"))
+	shortenToken.AddResponse(http.StatusBadRequest, errorResponse("The url parameter is missing or invalid"))
+	shortenToken.AddResponse(http.StatusUnauthorized, errorResponse("Missing or invalid admin token"))
+	shortenToken.AddResponse(http.StatusNotImplemented, errorResponse("TOKEN_HMAC_SECRET is not set"))
+
+	redirect := newOperation("redirect", "Redirect to the destination of a short URL", "links")
+	redirect.AddParameter(shortIDParameter)
+	redirect.AddParameter(openapi3.NewHeaderParameter("X-Short-URL-Password").
+		WithDescription("Password of a protected link").
+		WithSchema(openapi3.NewStringSchema()))
+	redirect.AddParameter(openapi3.NewQueryParameter("session_id").
+		WithDescription("UUID identifying the visitor's session, kept with the click so that /track/convert can match conversions to it").
+		WithSchema(openapi3.NewUUIDSchema()))
+	redirect.AddResponse(http.StatusMovedPermanently, textResponse("Permanent redirect to the destination, cacheable by CDNs for a day and tagged with Surrogate-Key: shawty-{shortID}, which updates and deletes purge"))
+	redirect.AddResponse(http.StatusFound, textResponse("Temporary redirect to the destination, sent with Cache-Control: no-store"))
+	redirect.AddResponse(http.StatusOK, openapi3.NewResponse().
+		WithDescription("The link is a bundle: an HTML page listing its links").
+		WithContent(openapi3.NewContentWithSchema(openapi3.NewStringSchema(), []string{"text/html"})))
+	redirect.AddResponse(http.StatusUnauthorized, errorResponse("The link is password protected and the password is missing or wrong"))
+	redirect.AddResponse(http.StatusNotFound, errorResponse("No link has this short code"))
+	redirect.AddResponse(http.StatusGone, errorResponse("The link has been deleted, has expired or used up its clicks"))
+	redirect.AddResponse(http.StatusServiceUnavailable, errorResponse("The database is unavailable; the Retry-After header says when to try again"))
+
+	deleteURL := newOperation("deleteURL", "Delete a short URL", "admin")
+	deleteURL.Security = bearerAuth
+	deleteURL.AddParameter(shortIDParameter)
+	deleteURL.AddResponse(http.StatusNoContent, openapi3.NewResponse().WithDescription("The link was deleted"))
+	deleteURL.AddResponse(http.StatusUnauthorized, errorResponse("Missing or invalid admin token"))
+	deleteURL.AddResponse(http.StatusNotFound, errorResponse("No link has this short code"))
+
+	updateURL := newOperation("updateURL", "Change the destination of a short URL", "admin")
+	updateURL.Security = bearerAuth
+	updateURL.AddParameter(shortIDParameter)
+	updateURL.RequestBody = &openapi3.RequestBodyRef{Value: openapi3.NewRequestBody().
+		WithRequired(true).
+		WithJSONSchemaRef(schemaRef("UpdateURLRequest"))}
+	updateURL.AddResponse(http.StatusOK, jsonResponse("The link was updated", "ShortenURLResponse"))
+	updateURL.AddResponse(http.StatusBadRequest, errorResponse("The body sets neither url nor tags, or the tags are invalid"))
+	updateURL.AddResponse(http.StatusUnauthorized, errorResponse("Missing or invalid admin token"))
+	updateURL.AddResponse(http.StatusNotFound, errorResponse("No link has this short code"))
+
+	createAlias := newOperation("createAlias", "Add another short code for a link", "admin")
+	createAlias.Security = bearerAuth
+	createAlias.AddParameter(shortIDParameter)
+	createAlias.RequestBody = &openapi3.RequestBodyRef{Value: openapi3.NewRequestBody().
+		WithRequired(true).
+		WithJSONSchemaRef(schemaRef("CreateAliasRequest"))}
+	createAlias.AddResponse(http.StatusCreated, jsonResponse("The alias was created; it redirects to the link's destination and its clicks count towards the link", "ShortenURLResponse"))
+	createAlias.AddResponse(http.StatusBadRequest, errorResponse("The alias is missing or not a valid short code"))
+	createAlias.AddResponse(http.StatusUnauthorized, errorResponse("Missing or invalid admin token"))
+	createAlias.AddResponse(http.StatusNotFound, errorResponse("No link has this short code"))
+	createAlias.AddResponse(http.StatusConflict, errorResponse("The alias is already in use"))
+
+	info := newOperation("getURLInfo", "Inspect a short URL without following it", "links")
+	info.AddParameter(shortIDParameter)
+	info.AddParameter(openapi3.NewHeaderParameter("If-None-Match").
+		WithDescription("ETag of a previously fetched response; answered with 304 while the details are unchanged").
+		WithSchema(openapi3.NewStringSchema()))
+	info.AddResponse(http.StatusOK, jsonResponse("The link's details; the destination is omitted for password-protected links. The ETag header is the quoted hex MD5 of the body and Last-Modified is when the link was last created, changed or clicked", "URLInfoResponse"))
+	info.AddResponse(http.StatusNotModified, openapi3.NewResponse().WithDescription("The details still match the If-None-Match ETag; the body is empty"))
+	info.AddResponse(http.StatusNotFound, errorResponse("No link has this short code"))
+	info.AddResponse(http.StatusGone, errorResponse("The link has been deleted"))
+
+	stats := newOperation("getURLStats", "Get click statistics for a short URL", "stats")
+	stats.AddParameter(shortIDParameter)
+	stats.AddResponse(http.StatusOK, jsonResponse("Click statistics for the link", "URLStatsResponse"))
+	stats.AddResponse(http.StatusNotFound, errorResponse("No link has this short code"))
+	stats.AddResponse(http.StatusGone, errorResponse("The link has been deleted"))
+
+	refererStats := newOperation("getURLRefererStats", "Count the clicks of a short URL per referring domain", "stats")
+	refererStats.AddParameter(shortIDParameter)
+	for _, p := range dateRangeParameters {
+		refererStats.AddParameter(p)
+	}
+	refererStats.AddResponse(http.StatusOK, openapi3.NewResponse().
+		WithDescription("Click counts per referer, most clicks first").
+		WithJSONSchema(arrayOf("RefererCount")))
+	refererStats.AddResponse(http.StatusBadRequest, errorResponse("from or to is malformed, or from is after to"))
+	refererStats.AddResponse(http.StatusNotImplemented, errorResponse("Click analytics are not enabled on this server"))
+
+	countryStats := newOperation("getURLCountryStats", "Count the clicks of a short URL per country", "stats")
+	countryStats.AddParameter(shortIDParameter)
+	for _, p := range dateRangeParameters {
+		countryStats.AddParameter(p)
+	}
+	countryStats.AddResponse(http.StatusOK, openapi3.NewResponse().
+		WithDescription("Click counts per country, most clicks first").
+		WithJSONSchema(arrayOf("CountryCount")))
+	countryStats.AddResponse(http.StatusBadRequest, errorResponse("from or to is malformed, or from is after to"))
+	countryStats.AddResponse(http.StatusNotImplemented, errorResponse("Click analytics are not enabled on this server"))
+
+	variantStats := newOperation("getURLVariantStats", "Count the clicks of an A/B-tested short URL per variant", "stats")
+	variantStats.AddParameter(shortIDParameter)
+	for _, p := range dateRangeParameters {
+		variantStats.AddParameter(p)
+	}
+	variantStats.AddResponse(http.StatusOK, openapi3.NewResponse().
+		WithDescription("Clicks per variant in the link's order; empty for links without variants").
+		WithJSONSchema(arrayOf("VariantStats")))
+	variantStats.AddResponse(http.StatusBadRequest, errorResponse("from or to is malformed, or from is after to"))
+	variantStats.AddResponse(http.StatusNotFound, errorResponse("No link has this short code"))
+	variantStats.AddResponse(http.StatusNotImplemented, errorResponse("Click analytics are not enabled on this server"))
+
+	conversionRate := newOperation("getURLConversionRate", "Compare the conversions of a short URL with its clicks", "stats")
+	conversionRate.AddParameter(shortIDParameter)
+	conversionRate.AddResponse(http.StatusOK, jsonResponse("Clicks, conversions and their ratio", "ConversionStats"))
+	conversionRate.AddResponse(http.StatusNotFound, errorResponse("No link has this short code"))
+	conversionRate.AddResponse(http.StatusGone, errorResponse("The link has been deleted"))
+
+	sessionIDParameter := openapi3.NewQueryParameter("session_id").
+		WithDescription("UUID the visitor's click carried in its own session_id query parameter. The conversion then only counts if a click of that session has not been converted yet; needs click analytics").
+		WithSchema(openapi3.NewUUIDSchema())
+	trackConversion := newOperation("trackConversion", "Count a conversion from a beacon", "stats")
+	trackConversion.Description = "Called by the page a link's visitors end up on, e.g. with navigator.sendBeacon. A conversion that is not counted is answered the same way."
+	trackConversion.AddParameter(shortIDParameter)
+	trackConversion.AddParameter(sessionIDParameter)
+	trackConversion.AddResponse(http.StatusNoContent, openapi3.NewResponse().WithDescription("The conversion was received"))
+	trackConversion.AddResponse(http.StatusBadRequest, errorResponse("session_id is not a UUID"))
+	trackConversion.AddResponse(http.StatusNotFound, errorResponse("No link has this short code"))
+	trackConversion.AddResponse(http.StatusGone, errorResponse("The link has been deleted"))
+	trackConversion.AddResponse(http.StatusNotImplemented, errorResponse("session_id was given, but click analytics are not enabled on this server"))
+
+	trackConversionPixel := newOperation("trackConversionPixel", "Count a conversion from an image tag", "stats")
+	trackConversionPixel.Description = "Like the POST form, for <img> tags; answers with a transparent 1x1 GIF."
+	trackConversionPixel.AddParameter(shortIDParameter)
+	trackConversionPixel.AddParameter(sessionIDParameter)
+	trackConversionPixel.AddResponse(http.StatusOK, openapi3.NewResponse().
+		WithDescription("The conversion was received").
+		WithContent(openapi3.NewContentWithSchema(openapi3.NewStringSchema().WithFormat("binary"), []string{"image/gif"})))
+	trackConversionPixel.AddResponse(http.StatusBadRequest, errorResponse("session_id is not a UUID"))
+	trackConversionPixel.AddResponse(http.StatusNotFound, errorResponse("No link has this short code"))
+	trackConversionPixel.AddResponse(http.StatusGone, errorResponse("The link has been deleted"))
+	trackConversionPixel.AddResponse(http.StatusNotImplemented, errorResponse("session_id was given, but click analytics are not enabled on this server"))
+
+	timeseries := newOperation("getURLClickTimeseries", "Count the clicks of a short URL per hour, day, week or month", "stats")
+	timeseries.AddParameter(shortIDParameter)
+	timeseries.AddParameter(openapi3.NewQueryParameter("granularity").
+		WithDescription("Length of each period; weeks start on Monday").
+		WithSchema(openapi3.NewStringSchema().WithEnum("hour", "day", "week", "month").WithDefault("day")))
+	for _, p := range dateRangeParameters {
+		timeseries.AddParameter(p)
+	}
+	timeseries.AddResponse(http.StatusOK, openapi3.NewResponse().
+		WithDescription("Click counts per period, oldest first; periods without clicks are omitted").
+		WithJSONSchema(arrayOf("ClickBucket")))
+	timeseries.AddResponse(http.StatusBadRequest, errorResponse("granularity, from or to is invalid, or the range is longer than a day (hour) or 366 days (day)"))
+	timeseries.AddResponse(http.StatusNotImplemented, errorResponse("Click analytics are not enabled on this server"))
+
+	listURLs := newOperation("listURLs", "List stored short URLs in short ID order", "admin")
+	listURLs.Description = "The master admin token lists the links of every tenant; any other API key lists only the links its owner created."
+	listURLs.Security = bearerAuth
+	listURLs.AddParameter(openapi3.NewQueryParameter("cursor").
+		WithDescription("The next_cursor of the previous page; omitted for the first page").
+		WithSchema(openapi3.NewStringSchema()))
+	listURLs.AddParameter(openapi3.NewQueryParameter("limit").WithSchema(openapi3.NewInt64Schema().WithMin(1).WithMax(200)))
+	listURLs.AddParameter(openapi3.NewQueryParameter("tag").
+		WithDescription("Only list links carrying this tag; requires the master admin token").
+		WithSchema(openapi3.NewStringSchema()))
+	listURLs.AddParameter(openapi3.NewQueryParameter("include_deleted").
+		WithDescription("Also list deleted links").
+		WithSchema(openapi3.NewBoolSchema()))
+	listURLs.AddResponse(http.StatusOK, jsonResponse("A page of short URLs", "ListURLsResponse"))
+	listURLs.AddResponse(http.StatusBadRequest, errorResponse("cursor is invalid or limit is out of range"))
+	listURLs.AddResponse(http.StatusUnauthorized, errorResponse("Missing or invalid API key"))
+	listURLs.AddResponse(http.StatusForbidden, errorResponse("Filtering by tag requires the master admin token"))
+
+	exportURLs := newOperation("exportURLs", "Download every stored URL for backup", "admin")
+	exportURLs.Security = bearerAuth
+	exportURLs.AddParameter(openapi3.NewQueryParameter("format").
+		WithSchema(openapi3.NewStringSchema().WithEnum("ndjson", "csv").WithDefault("ndjson")))
+	exportURLs.AddResponse(http.StatusOK, openapi3.NewResponse().
+		WithDescription("One URL document per line, or CSV rows of id, original_url, short_url, creation_date and click_count").
+		WithContent(openapi3.Content{
+			"application/x-ndjson": openapi3.NewMediaType().WithSchemaRef(schemaRef("URL")),
+			"text/csv":             openapi3.NewMediaType().WithSchema(openapi3.NewStringSchema()),
+		}))
+	exportURLs.AddResponse(http.StatusBadRequest, errorResponse("format is not ndjson or csv"))
+	exportURLs.AddResponse(http.StatusUnauthorized, errorResponse("Missing or invalid admin token"))
+
+	importFile := openapi3.NewStringSchema().WithFormat("binary")
+	importFile.Description = "A *.ndjson, *.jsonl or *.csv file of at most 10 MB whose records have original_url and optionally custom_code or id"
+	importSchema := openapi3.NewObjectSchema().WithProperty("file", importFile)
+	importSchema.Required = []string{"file"}
+	importURLs := newOperation("importURLs", "Bulk-create short URLs from an uploaded file", "admin")
+	importURLs.Security = bearerAuth
+	importURLs.RequestBody = &openapi3.RequestBodyRef{Value: openapi3.NewRequestBody().
+		WithRequired(true).
+		WithContent(openapi3.NewContentWithFormDataSchema(importSchema))}
+	importURLs.AddResponse(http.StatusOK, jsonResponse("A summary of the import", "ImportResponse"))
+	importURLs.AddResponse(http.StatusBadRequest, errorResponse("The form has no file, or the file cannot be read"))
+	importURLs.AddResponse(http.StatusUnauthorized, errorResponse("Missing or invalid admin token"))
+	importURLs.AddResponse(http.StatusRequestEntityTooLarge, errorResponse("The file is larger than 10 MB"))
+
+	searchURLs := newOperation("searchURLs", "Find short URLs whose destination contains a keyword", "admin")
+	searchURLs.Security = bearerAuth
+	searchURLs.AddParameter(openapi3.NewQueryParameter("q").
+		WithDescription("Matched literally and ignoring case, e.g. a hostname").
+		WithRequired(true).
+		WithSchema(openapi3.NewStringSchema()))
+	searchURLs.AddParameter(openapi3.NewQueryParameter("limit").
+		WithDescription("Values above 100 are capped").
+		WithSchema(openapi3.NewInt64Schema().WithMin(1).WithDefault(20)))
+	searchURLs.AddResponse(http.StatusOK, jsonResponse("The matching short URLs", "ListURLsResponse"))
+	searchURLs.AddResponse(http.StatusBadRequest, errorResponse("q is missing or limit is not a positive integer"))
+	searchURLs.AddResponse(http.StatusUnauthorized, errorResponse("Missing or invalid admin token"))
+
+	undelete := newOperation("undeleteURL", "Restore a deleted short URL", "admin")
+	undelete.Security = bearerAuth
+	undelete.AddParameter(shortIDParameter)
+	undelete.AddResponse(http.StatusNoContent, openapi3.NewResponse().WithDescription("The link redirects again"))
+	undelete.AddResponse(http.StatusUnauthorized, errorResponse("Missing or invalid admin token"))
+	undelete.AddResponse(http.StatusNotFound, errorResponse("No deleted link has this short code"))
+
+	ownerIDParameter := openapi3.NewPathParameter("ownerID").
+		WithDescription("Owner name of the tenant's API key").
+		WithSchema(openapi3.NewStringSchema())
+
+	getQuota := newOperation("getTenantQuota", "Show how many links a tenant has created and may create", "admin")
+	getQuota.Security = bearerAuth
+	getQuota.AddParameter(ownerIDParameter)
+	getQuota.AddResponse(http.StatusOK, jsonResponse("The tenant's usage and quota", "QuotaResponse"))
+	getQuota.AddResponse(http.StatusUnauthorized, errorResponse("Missing or invalid admin token"))
+	getQuota.AddResponse(http.StatusNotImplemented, errorResponse("The store keeps no tenant quotas"))
+
+	domainStatsOp := newOperation("getDomainStats", "Count the links created on each short domain and their clicks", "admin")
+	domainStatsOp.Security = bearerAuth
+	domainStatsOp.AddResponse(http.StatusOK, openapi3.NewResponse().
+		WithDescription("Link and click counts per domain, most links first").
+		WithJSONSchema(arrayOf("DomainStats")))
+	domainStatsOp.AddResponse(http.StatusUnauthorized, errorResponse("Missing or invalid admin token"))
+	domainStatsOp.AddResponse(http.StatusNotImplemented, errorResponse("The store cannot group links by domain"))
+
+	transfer := newOperation("transferURL", "Move a short URL to another tenant", "admin")
+	transfer.Security = bearerAuth
+	transfer.AddParameter(shortIDParameter)
+	transfer.RequestBody = &openapi3.RequestBodyRef{Value: openapi3.NewRequestBody().
+		WithRequired(true).
+		WithJSONSchemaRef(schemaRef("TransferRequest"))}
+	transfer.AddResponse(http.StatusNoContent, openapi3.NewResponse().WithDescription("The link is listed for the new tenant only and counts towards its quota; its aliases keep their tenant"))
+	transfer.AddResponse(http.StatusBadRequest, errorResponse("The request body is invalid or new_owner is missing"))
+	transfer.AddResponse(http.StatusUnauthorized, errorResponse("Missing or invalid admin token"))
+	transfer.AddResponse(http.StatusNotFound, errorResponse("No link has this short code"))
+	transfer.AddResponse(http.StatusUnprocessableEntity, errorResponse("new_owner is not a known tenant"))
+	transfer.AddResponse(http.StatusNotImplemented, errorResponse("The store cannot move links between tenants"))
+
+	clickStream := newOperation("streamClicks", "Follow the clicks of a short URL as server-sent events", "admin")
+	clickStream.Security = bearerAuth
+	clickStream.AddParameter(shortIDParameter)
+	clickStream.AddResponse(http.StatusOK, openapi3.NewResponse().
+		WithDescription("An endless stream of events named click whose data is a ClickEvent, with a heartbeat comment every 15 seconds").
+		WithContent(openapi3.Content{
+			"text/event-stream": openapi3.NewMediaType().WithSchemaRef(schemaRef("ClickEvent")),
+		}))
+	clickStream.AddResponse(http.StatusUnauthorized, errorResponse("Missing or invalid admin token"))
+	clickStream.AddResponse(http.StatusNotFound, errorResponse("No link has this short code"))
+	clickStream.AddResponse(http.StatusGone, errorResponse("The link has expired or been deleted"))
+	clickStream.AddResponse(http.StatusNotImplemented, errorResponse("Click streams are not available on this deployment"))
+
+	updateQuota := newOperation("updateTenantQuota", "Change how many links a tenant may create", "admin")
+	updateQuota.Security = bearerAuth
+	updateQuota.AddParameter(ownerIDParameter)
+	updateQuota.RequestBody = &openapi3.RequestBodyRef{Value: openapi3.NewRequestBody().
+		WithRequired(true).
+		WithJSONSchemaRef(schemaRef("UpdateQuotaRequest"))}
+	updateQuota.AddResponse(http.StatusOK, jsonResponse("The tenant's usage and new quota", "QuotaResponse"))
+	updateQuota.AddResponse(http.StatusBadRequest, errorResponse("The request body is invalid or limit is negative"))
+	updateQuota.AddResponse(http.StatusUnauthorized, errorResponse("Missing or invalid admin token"))
+	updateQuota.AddResponse(http.StatusNotImplemented, errorResponse("The store keeps no tenant quotas"))
+
+	// The probe paths can be moved with LIVENESS_PATH and READINESS_PATH; the defaults are documented.
+	livez := newOperation("livez", "Report that the process is running, without checking its dependencies", "health")
+	livez.AddResponse(http.StatusOK, jsonResponse("The process is alive", "LivenessResponse"))
+
+	readyz := newOperation("readyz", "Report whether the database is ready and the background jobs are running", "health")
+	readyz.AddResponse(http.StatusOK, jsonResponse("The service is ready", "HealthResponse"))
+	readyz.AddResponse(http.StatusServiceUnavailable, jsonResponse("The database is not ready or a background job has stopped", "HealthResponse"))
+
+	robots := newOperation("robotsTxt", "Tell crawlers not to index short links", "crawlers")
+	robots.AddResponse(http.StatusOK, textResponse("Disallows /r/ and /api/, followed by a Sitemap line when SITEMAP_URL is set"))
+
+	sitemap := newOperation("sitemap", "List the links that currently redirect", "crawlers")
+	sitemap.AddResponse(http.StatusOK, openapi3.NewResponse().
+		WithDescription("A sitemap of up to 50,000 short links with their creation dates as lastmod").
+		WithContent(openapi3.NewContentWithSchema(openapi3.NewStringSchema(), []string{"application/xml"})))
+	sitemap.AddResponse(http.StatusInternalServerError, errorResponse("The links could not be listed"))
+
+	return &openapi3.T{
+		OpenAPI: "3.0.3",
+		Info: &openapi3.Info{
+			Title:       "Shawty URL Shortener API",
+			Description: "Create, manage and follow short links.",
+			Version:     Version,
+		},
+		Servers: openapi3.Servers{{URL: basePath}},
+		Paths: openapi3.NewPaths(
+			openapi3.WithPath("/shorten", &openapi3.PathItem{Post: shorten}),
+			openapi3.WithPath("/shorten/bulk", &openapi3.PathItem{Post: bulk}),
+			openapi3.WithPath("/shorten/token", &openapi3.PathItem{Get: shortenToken}),
+			openapi3.WithPath("/r/{shortID}", &openapi3.PathItem{Get: redirect, Delete: deleteURL, Patch: updateURL}),
+			openapi3.WithPath("/r/{shortID}/info", &openapi3.PathItem{Get: info}),
+			openapi3.WithPath("/r/{shortID}/aliases", &openapi3.PathItem{Post: createAlias}),
+			openapi3.WithPath("/stats/{shortID}", &openapi3.PathItem{Get: stats}),
+			openapi3.WithPath("/stats/{shortID}/clicks", &openapi3.PathItem{Get: refererStats}),
+			openapi3.WithPath("/stats/{shortID}/countries", &openapi3.PathItem{Get: countryStats}),
+			openapi3.WithPath("/stats/{shortID}/variants", &openapi3.PathItem{Get: variantStats}),
+			openapi3.WithPath("/stats/{shortID}/timeseries", &openapi3.PathItem{Get: timeseries}),
+			openapi3.WithPath("/stats/{shortID}/conversion-rate", &openapi3.PathItem{Get: conversionRate}),
+			openapi3.WithPath("/track/convert/{shortID}", &openapi3.PathItem{Get: trackConversionPixel, Post: trackConversion}),
+			openapi3.WithPath("/admin/urls", &openapi3.PathItem{Get: listURLs}),
+			openapi3.WithPath("/admin/urls/search", &openapi3.PathItem{Get: searchURLs}),
+			openapi3.WithPath("/admin/export", &openapi3.PathItem{Get: exportURLs}),
+			openapi3.WithPath("/admin/import", &openapi3.PathItem{Post: importURLs}),
+			openapi3.WithPath("/admin/undelete/{shortID}", &openapi3.PathItem{Post: undelete}),
+			openapi3.WithPath("/admin/tenants/{ownerID}/quota", &openapi3.PathItem{Get: getQuota, Patch: updateQuota}),
+			openapi3.WithPath("/admin/domains/stats", &openapi3.PathItem{Get: domainStatsOp}),
+			openapi3.WithPath("/admin/urls/{shortID}/transfer", &openapi3.PathItem{Post: transfer}),
+			openapi3.WithPath("/admin/events/{shortID}", &openapi3.PathItem{Get: clickStream}),
+			// Health checks and the crawler files are served at the root, outside the versioned API.
+			openapi3.WithPath("/livez", &openapi3.PathItem{Get: livez, Servers: openapi3.Servers{{URL: "/"}}}),
+			openapi3.WithPath("/readyz", &openapi3.PathItem{Get: readyz, Servers: openapi3.Servers{{URL: "/"}}}),
+			openapi3.WithPath("/robots.txt", &openapi3.PathItem{Get: robots, Servers: openapi3.Servers{{URL: "/"}}}),
+			openapi3.WithPath("/sitemap.xml", &openapi3.PathItem{Get: sitemap, Servers: openapi3.Servers{{URL: "/"}}}),
+		),
+		Components: &openapi3.Components{
+			Schemas: schemas(),
+			SecuritySchemes: openapi3.SecuritySchemes{
+				"bearerAuth": &openapi3.SecuritySchemeRef{Value: &openapi3.SecurityScheme{
+					Type:        "http",
+					Scheme:      "bearer",
+					Description: "An API key from API_KEYS, or MASTER_ADMIN_TOKEN for admin endpoints",
+				}},
+			},
+		},
+	}
+}
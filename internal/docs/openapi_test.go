@@ -0,0 +1,54 @@
+package docs
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"flag"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/getkin/kin-openapi/openapi3"
+)
+
+// update rewrites the golden file from the current spec: go test ./internal/docs -update
+var update = flag.Bool("update", false, "rewrite testdata/openapi.golden.json")
+
+// goldenPath is the committed copy of the OpenAPI document.
+var goldenPath = filepath.Join("testdata", "openapi.golden.json")
+
+func TestSpecMatchesGolden(t *testing.T) {
+	got, err := json.MarshalIndent(NewSpec(), "", "  ")
+	if err != nil {
+		t.Fatalf("encoding spec: %v", err)
+	}
+	got = append(got, '\n')
+
+	if *update {
+		if err := os.WriteFile(goldenPath, got, 0o644); err != nil {
+			t.Fatalf("writing golden file: %v", err)
+		}
+	}
+	want, err := os.ReadFile(goldenPath)
+	if err != nil {
+		t.Fatalf("reading golden file: %v", err)
+	}
+	if !bytes.Equal(got, want) {
+		t.Errorf("the OpenAPI spec differs from %s; review the change and run go test ./internal/docs -update", goldenPath)
+	}
+}
+
+func TestSpecIsValid(t *testing.T) {
+	encoded, err := json.Marshal(NewSpec())
+	if err != nil {
+		t.Fatalf("encoding spec: %v", err)
+	}
+	doc, err := openapi3.NewLoader().LoadFromData(encoded)
+	if err != nil {
+		t.Fatalf("loading spec: %v", err)
+	}
+	if err := doc.Validate(context.Background()); err != nil {
+		t.Errorf("spec is not valid OpenAPI: %v", err)
+	}
+}
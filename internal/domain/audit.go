@@ -0,0 +1,48 @@
+package domain
+
+import (
+	"context"
+	"time"
+)
+
+// Audit event types.
+const (
+	AuditEventCreate   = "create"
+	AuditEventUpdate   = "update"
+	AuditEventDelete   = "delete"
+	AuditEventUndelete = "undelete"
+	AuditEventTransfer = "transfer"
+)
+
+// AuditEvent records one change made to a short URL. Events are never modified or removed,
+// including when the short URL itself is deleted.
+type AuditEvent struct {
+	EventType     string    `json:"event_type" bson:"event_type"`
+	ShortID       string    `json:"short_id" bson:"short_id"`
+	OriginalURL   string    `json:"original_url" bson:"original_url"`
+	ActorKey      string    `json:"actor_key" bson:"actor_key"` // Owner name of the API key that made the change; never the key itself
+	Timestamp     time.Time `json:"timestamp" bson:"timestamp"`
+	IPAddress     string    `json:"ip_address" bson:"ip_address"`
+	PreviousOwner string    `json:"previous_owner,omitempty" bson:"previous_owner,omitempty"` // Tenant a transfer took the short URL from; empty for a link without a tenant
+	NewOwner      string    `json:"new_owner,omitempty" bson:"new_owner,omitempty"`           // Tenant a transfer gave the short URL to; set on transfer events only
+}
+
+// AuditActor identifies who is making the changes of a request.
+type AuditActor struct {
+	Key       string
+	IPAddress string
+}
+
+// auditActorKey is the context key under which WithAuditActor stores the actor.
+type auditActorKey struct{}
+
+// WithAuditActor returns a copy of ctx that attributes changes to actor.
+func WithAuditActor(ctx context.Context, actor AuditActor) context.Context {
+	return context.WithValue(ctx, auditActorKey{}, actor)
+}
+
+// AuditActorFromContext returns the actor stored by WithAuditActor, or the zero AuditActor.
+func AuditActorFromContext(ctx context.Context) AuditActor {
+	actor, _ := ctx.Value(auditActorKey{}).(AuditActor)
+	return actor
+}
@@ -0,0 +1,61 @@
+package domain
+
+import "time"
+
+// User agent classes of a ClickEvent.
+const (
+	UserAgentMobile  = "mobile"
+	UserAgentDesktop = "desktop"
+)
+
+// RefererDirect is the referer domain of clicks that arrived without a Referer header,
+// e.g. from typed URLs, bookmarks or apps.
+const RefererDirect = "(direct)"
+
+// ClickEvent records one redirect of a short URL for analytics. It holds no full URLs or IP
+// addresses: the referer is reduced to its domain and the visitor's location to a country.
+type ClickEvent struct {
+	ShortID        string     `json:"short_id" bson:"short_id"`
+	RefererDomain  string     `json:"referer_domain" bson:"referer_domain"` // RefererDirect when the visitor sent no Referer
+	ClickedAt      time.Time  `json:"clicked_at" bson:"clicked_at"`
+	UserAgentClass string     `json:"user_agent_class" bson:"user_agent_class"`             // UserAgentMobile or UserAgentDesktop
+	CountryCode    string     `json:"country_code,omitempty" bson:"country_code,omitempty"` // ISO 3166-1 alpha-2; XX when unknown
+	Variant        string     `json:"variant,omitempty" bson:"variant,omitempty"`           // Variant served by an A/B-tested link, or VariantOriginal
+	SessionID      string     `json:"session_id,omitempty" bson:"session_id,omitempty"`     // UUID the visitor's session_id query parameter carried, for matching conversions
+	ConvertedAt    *time.Time `json:"converted_at,omitempty" bson:"converted_at,omitempty"` // When a conversion of SessionID was matched to this click
+}
+
+// ConversionStats compares the conversions reported for a short URL with its clicks.
+type ConversionStats struct {
+	Clicks      int64   `json:"clicks"`
+	Conversions int64   `json:"conversions"`
+	Rate        float64 `json:"rate"` // Conversions / Clicks; 0 for links without clicks
+}
+
+// Granularities of a click time series.
+const (
+	GranularityHour  = "hour"
+	GranularityDay   = "day"
+	GranularityWeek  = "week"
+	GranularityMonth = "month"
+)
+
+// ClickBucket is the number of clicks a short URL received in one period of a time series.
+// Date is the start of the period in UTC: 2024-01-15T13:00:00Z for hours, 2024-01-15 for days
+// and weeks (which start on Monday), and 2024-01 for months.
+type ClickBucket struct {
+	Date  string `json:"date" bson:"_id"`
+	Count int64  `json:"count" bson:"count"`
+}
+
+// CountryCount is the number of clicks a short URL received from one country.
+type CountryCount struct {
+	Country string `json:"country" bson:"_id"`
+	Count   int64  `json:"count" bson:"count"`
+}
+
+// RefererCount is the number of clicks a short URL received from one referer domain.
+type RefererCount struct {
+	Referer string `json:"referer" bson:"_id"`
+	Count   int64  `json:"count" bson:"count"`
+}
@@ -0,0 +1,49 @@
+package domain
+
+import (
+	"net/textproto"
+	"strings"
+)
+
+// deniedCustomHeaders are the response headers a link may not set: they would let a link plant
+// cookies on the shortener's domain, weaken its security headers, break the redirect itself, or
+// keep it from being purged from a CDN.
+// Keys are in canonical form.
+var deniedCustomHeaders = map[string]bool{
+	"Set-Cookie":                          true,
+	"Set-Cookie2":                         true,
+	"Content-Security-Policy":             true,
+	"Content-Security-Policy-Report-Only": true,
+	"Strict-Transport-Security":           true,
+	"Expect-Ct":                           true,
+	"Permissions-Policy":                  true,
+	"X-Content-Type-Options":              true,
+	"X-Frame-Options":                     true,
+	"X-Xss-Protection":                    true,
+	"Clear-Site-Data":                     true,
+	"Location":                            true,
+	"Refresh":                             true,
+	"Content-Type":                        true,
+	"Content-Length":                      true,
+	"Content-Encoding":                    true,
+	"Transfer-Encoding":                   true,
+	"Connection":                          true,
+	"Keep-Alive":                          true,
+	"Upgrade":                             true,
+	"Trailer":                             true,
+	"Www-Authenticate":                    true,
+	"Proxy-Authenticate":                  true,
+	"Alt-Svc":                             true,
+	"Link":                                true,
+	"X-Request-Id":                        true,
+	"X-Api-Version":                       true,
+	"Surrogate-Key":                       true,
+	"Cache-Tag":                           true,
+}
+
+// CustomHeaderAllowed reports whether a link may set the response header name on its redirects.
+// Access-Control-* headers are denied along with the fixed list, as CORS is the server's to decide.
+func CustomHeaderAllowed(name string) bool {
+	name = textproto.CanonicalMIMEHeaderKey(name)
+	return !deniedCustomHeaders[name] && !strings.HasPrefix(name, "Access-Control-")
+}
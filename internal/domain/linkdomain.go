@@ -0,0 +1,8 @@
+package domain
+
+// DomainStats counts the short URLs created on one domain and the clicks they received.
+type DomainStats struct {
+	Domain string `json:"domain" bson:"_id"` // Empty for links created before domains were recorded
+	Links  int64  `json:"links" bson:"links"`
+	Clicks int64  `json:"clicks" bson:"clicks"`
+}
@@ -0,0 +1,9 @@
+package domain
+
+// URLMetadata holds the page information shown in link previews.
+// Fields are empty when the destination page does not provide them.
+type URLMetadata struct {
+	Title       string `json:"title"`
+	Description string `json:"description"`
+	Image       string `json:"og_image"`
+}
@@ -0,0 +1,14 @@
+package domain
+
+// Tenant holds the link quota of an API key owner. A tenant's document is created the first time
+// it creates a link or gets a quota.
+type Tenant struct {
+	ID          string `json:"id" bson:"_id"`              // Owner name of the tenant's API key
+	TenantQuota int64  `json:"quota" bson:"quota"`         // Most links the tenant may create; 0 means unlimited
+	URLCount    int64  `json:"url_count" bson:"url_count"` // Links the tenant has created, deleted ones included
+}
+
+// QuotaExceeded reports whether the tenant has created more links than its quota allows.
+func (t Tenant) QuotaExceeded() bool {
+	return t.TenantQuota > 0 && t.URLCount > t.TenantQuota
+}
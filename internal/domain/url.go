@@ -2,10 +2,88 @@ package domain
 
 import "time"
 
+// Redirect types of a short URL. An empty RedirectType means the server's REDIRECT_TYPE default.
+const (
+	RedirectPermanent = "permanent" // HTTP 301; browsers may cache the destination
+	RedirectTemporary = "temporary" // HTTP 302; every visit comes back to the shortener
+)
+
 // URL defines the structure for storing URL information.
 type URL struct {
-	ID           string    `json:"id" bson:"_id"` // Unique identifier, also the short URL
-	OriginalUrl  string    `json:"original_url" bson:"original_url"`
-	ShortUrl     string    `json:"short_url" bson:"short_url"` // Redundant if ID is the short URL, but kept for clarity from original
-	CreationDate time.Time `json:"creation_date" bson:"creation_date"`
+	ID              string            `json:"id" bson:"_id"`                                          // Unique identifier, also the short URL
+	OriginalUrl     string            `json:"original_url" bson:"original_url"`                       // Normalized form, see urlutil.NormalizeURL
+	SubmittedUrl    string            `json:"submitted_url,omitempty" bson:"submitted_url,omitempty"` // Exactly as submitted, kept for auditing
+	ShortUrl        string            `json:"short_url" bson:"short_url"`                             // Redundant if ID is the short URL, but kept for clarity from original
+	CreationDate    time.Time         `json:"creation_date" bson:"creation_date"`
+	ExpiresAt       *time.Time        `json:"expires_at,omitempty" bson:"expires_at,omitempty"` // Nil for links that never expire
+	ClickCount      int64             `json:"click_count" bson:"click_count"`
+	LastAccessedAt  *time.Time        `json:"last_accessed_at,omitempty" bson:"last_accessed_at,omitempty"` // Nil until the first redirect
+	UpdatedAt       *time.Time        `json:"updated_at,omitempty" bson:"updated_at,omitempty"`             // Nil until the destination is changed
+	DeletedAt       *time.Time        `json:"deleted_at,omitempty" bson:"deleted_at,omitempty"`             // Set only by soft-delete builds
+	RedirectType    string            `json:"redirect_type,omitempty" bson:"redirect_type,omitempty"`       // RedirectPermanent, RedirectTemporary, or empty for the server default
+	PasswordHash    string            `json:"-" bson:"password_hash,omitempty"`                             // bcrypt hash; empty for links without a password. Never serialized to JSON
+	MaxClicks       *int64            `json:"max_clicks,omitempty" bson:"max_clicks,omitempty"`             // Nil for links without a click limit; 1 makes a one-time link
+	UTM             *UTMParams        `json:"utm,omitempty" bson:"utm,omitempty"`                           // Campaign parameters added to the destination on redirect
+	Tags            []string          `json:"tags,omitempty" bson:"tags,omitempty"`                         // Lowercase labels for organising links, e.g. "campaign-q4"
+	WebhookURL      string            `json:"webhook_url,omitempty" bson:"webhook_url,omitempty"`           // Receives a first_click event when the link is used for the first time
+	MobileURL       *string           `json:"mobile_url,omitempty" bson:"mobile_url,omitempty"`             // Destination for visitors on mobile devices; nil sends everyone to OriginalUrl
+	MobileClicks    int64             `json:"mobile_click_count" bson:"mobile_click_count"`                 // Part of ClickCount that came from mobile devices
+	ConversionCount int64             `json:"conversion_count" bson:"conversion_count"`                     // Visits reported as converted through /track/convert
+	PageTitle       string            `json:"page_title" bson:"page_title,omitempty"`                       // <title> of the destination, fetched in the background after creation
+	PageDescription string            `json:"page_description" bson:"page_description,omitempty"`           // Meta description of the destination; empty if the page asks for nosnippet
+	TenantID        string            `json:"tenant_id,omitempty" bson:"tenant_id,omitempty"`               // Owner of the API key that created the link; empty for links created before tenants
+	AliasOf         *string           `json:"alias_of,omitempty" bson:"alias_of,omitempty"`                 // Short ID of the primary entry this alias redirects to; aliases carry no destination of their own
+	Variants        []Variant         `json:"variants,omitempty" bson:"variants,omitempty"`                 // A/B test destinations chosen by weight on every redirect; OriginalUrl gets the remaining share
+	ActiveFrom      *time.Time        `json:"active_from,omitempty" bson:"active_from,omitempty"`           // The link answers 404 before this time; nil for links live from creation
+	ActiveUntil     *time.Time        `json:"active_until,omitempty" bson:"active_until,omitempty"`         // The link answers 410 from this time on; nil for links without an end
+	Bundle          bool              `json:"bundle,omitempty" bson:"bundle,omitempty"`                     // The link shows a page listing Variants instead of redirecting; their weights are unused
+	BundleTitle     string            `json:"bundle_title,omitempty" bson:"bundle_title,omitempty"`         // Title of the bundle page; empty uses the short ID
+	CustomHeaders   map[string]string `json:"custom_headers,omitempty" bson:"custom_headers,omitempty"`     // Response headers set on redirects, under canonical names; see CustomHeaderAllowed
+	Domain          string            `json:"domain,omitempty" bson:"domain,omitempty"`                     // Host the link was created on, e.g. go.example.com; empty for links from before it was recorded and imports
+	HashAlgo        string            `json:"hash_algo,omitempty" bson:"hash_algo,omitempty"`               // Hash the ID was derived with: md5, sha256, or migrated once an sha256 alias exists; empty for custom codes and entries from before it was recorded, which used md5
+}
+
+// UTMParams are the campaign parameters added to a destination URL on redirect.
+// Empty fields are not added.
+type UTMParams struct {
+	Source   string `json:"source,omitempty" bson:"source,omitempty"`
+	Medium   string `json:"medium,omitempty" bson:"medium,omitempty"`
+	Campaign string `json:"campaign,omitempty" bson:"campaign,omitempty"`
+	Term     string `json:"term,omitempty" bson:"term,omitempty"`
+	Content  string `json:"content,omitempty" bson:"content,omitempty"`
+}
+
+// IsZero reports whether no parameter is set.
+func (p UTMParams) IsZero() bool {
+	return p == UTMParams{}
+}
+
+// IsExpired reports whether the URL has an expiration time that is not after now.
+func (u URL) IsExpired(now time.Time) bool {
+	return u.ExpiresAt != nil && !now.Before(*u.ExpiresAt)
+}
+
+// IsActive reports whether now lies in the URL's activation window, [ActiveFrom, ActiveUntil).
+// Links without bounds are always active.
+func (u URL) IsActive(now time.Time) bool {
+	return (u.ActiveFrom == nil || !now.Before(*u.ActiveFrom)) && (u.ActiveUntil == nil || now.Before(*u.ActiveUntil))
+}
+
+// ClicksRemaining returns how many more redirects a click-limited URL allows, or nil if it has no limit.
+func (u URL) ClicksRemaining() *int64 {
+	if u.MaxClicks == nil {
+		return nil
+	}
+	remaining := max(*u.MaxClicks-u.ClickCount, 0)
+	return &remaining
+}
+
+// IsPasswordProtected reports whether following the URL requires a password.
+func (u URL) IsPasswordProtected() bool {
+	return u.PasswordHash != ""
+}
+
+// IsDeleted reports whether the URL has been soft-deleted.
+func (u URL) IsDeleted() bool {
+	return u.DeletedAt != nil
 }
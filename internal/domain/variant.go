@@ -0,0 +1,43 @@
+package domain
+
+// VariantWeightTotal is the weight of all of a link's traffic: variant weights are percentages.
+const VariantWeightTotal = 100
+
+// VariantOriginal is the variant recorded for clicks on an A/B-tested link that were sent to its
+// OriginalUrl, which receives whatever share of the traffic its variants' weights leave over.
+const VariantOriginal = "original"
+
+// Variant is one destination of an A/B-tested link, or one entry of a bundle's list of links.
+type Variant struct {
+	Name   string `json:"name" bson:"name"`
+	URL    string `json:"url" bson:"url"`
+	Weight int    `json:"weight,omitempty" bson:"weight"` // Percentage of the link's traffic sent to URL; zero in bundles
+}
+
+// PickVariant returns the variant a visitor who drew roll is sent to, where roll is uniformly
+// random in [0, VariantWeightTotal). It returns false when roll falls into the share of the
+// traffic that the variants leave to OriginalUrl.
+func (u URL) PickVariant(roll int) (Variant, bool) {
+	for _, v := range u.Variants {
+		if roll < v.Weight {
+			return v, true
+		}
+		roll -= v.Weight
+	}
+	return Variant{}, false
+}
+
+// VariantCount is the number of clicks an A/B-tested link sent to one of its variants.
+type VariantCount struct {
+	Variant string `json:"variant" bson:"_id"`
+	Count   int64  `json:"count" bson:"count"`
+}
+
+// VariantStats reports how the clicks of an A/B-tested link were split across its destinations.
+type VariantStats struct {
+	Name       string  `json:"name"`
+	URL        string  `json:"url"`
+	Weight     int     `json:"weight"`
+	Clicks     int64   `json:"clicks"`
+	ClickShare float64 `json:"click_share"` // Fraction of the link's counted clicks, between 0 and 1
+}
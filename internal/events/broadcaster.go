@@ -0,0 +1,126 @@
+package events
+
+import (
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// ClickSubscriberBuffer is how many clicks may wait for a slow subscriber before further clicks
+// are dropped for it.
+const ClickSubscriberBuffer = 16
+
+// ClickEvent is what a ClickBroadcaster delivers about one redirect.
+type ClickEvent struct {
+	ShortID    string    `json:"short_id"`
+	ClickCount int64     `json:"click_count"` // The link's click count after this click
+	At         time.Time `json:"at"`
+}
+
+// ClickBroadcaster fans clicks out to the subscribers of each short ID, such as the server-sent
+// event streams of dashboards. Subscribers that fall behind miss clicks instead of holding up the
+// others. The zero value is ready to use.
+type ClickBroadcaster struct {
+	subscribers sync.Map // Short ID -> *clickSubscribers
+	closed      atomic.Bool
+}
+
+// clickSubscribers are the channels subscribed to one short ID.
+type clickSubscribers struct {
+	mu      sync.Mutex
+	chans   map[chan ClickEvent]struct{}
+	removed bool // The set has left the map and must not be subscribed to
+}
+
+// NewClickBroadcaster returns an empty ClickBroadcaster.
+func NewClickBroadcaster() *ClickBroadcaster {
+	return &ClickBroadcaster{}
+}
+
+// Subscribe returns a channel that receives the clicks broadcast for shortID, and a function that
+// ends the subscription and closes the channel. The function is safe to call more than once.
+// After Close the channel is returned closed.
+func (b *ClickBroadcaster) Subscribe(shortID string) (<-chan ClickEvent, func()) {
+	ch := make(chan ClickEvent, ClickSubscriberBuffer)
+	if b.closed.Load() {
+		close(ch)
+		return ch, func() {}
+	}
+	for {
+		value, _ := b.subscribers.LoadOrStore(shortID, &clickSubscribers{chans: make(map[chan ClickEvent]struct{})})
+		subs := value.(*clickSubscribers)
+		subs.mu.Lock()
+		if subs.removed && !b.closed.Load() {
+			// The last subscriber left between the load and the lock; a fresh set replaces it.
+			subs.mu.Unlock()
+			continue
+		}
+		if subs.removed {
+			// Close ran in the meantime.
+			subs.mu.Unlock()
+			close(ch)
+			return ch, func() {}
+		}
+		subs.chans[ch] = struct{}{}
+		subs.mu.Unlock()
+
+		var once sync.Once
+		return ch, func() { once.Do(func() { b.unsubscribe(shortID, subs, ch) }) }
+	}
+}
+
+// unsubscribe removes ch from subs and closes it, dropping subs from the map once it is empty.
+func (b *ClickBroadcaster) unsubscribe(shortID string, subs *clickSubscribers, ch chan ClickEvent) {
+	subs.mu.Lock()
+	defer subs.mu.Unlock()
+	if _, ok := subs.chans[ch]; !ok {
+		return // Already closed by Close
+	}
+	delete(subs.chans, ch)
+	close(ch)
+	if len(subs.chans) == 0 {
+		subs.removed = true
+		b.subscribers.CompareAndDelete(shortID, subs)
+	}
+}
+
+// Broadcast delivers evt to every subscriber of shortID without waiting for any of them.
+func (b *ClickBroadcaster) Broadcast(shortID string, evt ClickEvent) {
+	value, ok := b.subscribers.Load(shortID)
+	if !ok {
+		return
+	}
+	subs := value.(*clickSubscribers)
+	subs.mu.Lock()
+	defer subs.mu.Unlock()
+	for ch := range subs.chans {
+		select {
+		case ch <- evt:
+		default:
+		}
+	}
+}
+
+// Close ends every subscription, closing its channel, so that the streams reading them finish.
+// Later subscriptions get a closed channel. Servers call it when they shut down, since
+// http.Server.Shutdown waits for open streams.
+func (b *ClickBroadcaster) Close() {
+	b.closed.Store(true)
+	b.subscribers.Range(func(key, value any) bool {
+		subs := value.(*clickSubscribers)
+		subs.mu.Lock()
+		for ch := range subs.chans {
+			delete(subs.chans, ch)
+			close(ch)
+		}
+		subs.removed = true
+		subs.mu.Unlock()
+		b.subscribers.CompareAndDelete(key, subs)
+		return true
+	})
+}
+
+// HandleClick broadcasts a URLClicked event of the bus to the subscribers of its short ID.
+func (b *ClickBroadcaster) HandleClick(e Event) {
+	b.Broadcast(e.ShortID, ClickEvent{ShortID: e.ShortID, ClickCount: e.ClickCount, At: e.At})
+}
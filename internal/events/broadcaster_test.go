@@ -0,0 +1,85 @@
+package events
+
+import (
+	"testing"
+	"time"
+)
+
+// receive returns the next value of ch, or fails if none arrives within 100ms.
+func receive(t *testing.T, ch <-chan ClickEvent) (ClickEvent, bool) {
+	t.Helper()
+	select {
+	case evt, ok := <-ch:
+		return evt, ok
+	case <-time.After(100 * time.Millisecond):
+		t.Fatal("nothing arrived on the channel within 100ms")
+		return ClickEvent{}, false
+	}
+}
+
+func TestClickBroadcasterDeliversOnlyToSubscribersOfTheShortID(t *testing.T) {
+	b := NewClickBroadcaster()
+	first, unsubscribeFirst := b.Subscribe("abc123")
+	defer unsubscribeFirst()
+	second, unsubscribeSecond := b.Subscribe("abc123")
+	defer unsubscribeSecond()
+	other, unsubscribeOther := b.Subscribe("xyz789")
+	defer unsubscribeOther()
+
+	b.Broadcast("abc123", ClickEvent{ShortID: "abc123", ClickCount: 1})
+	b.Broadcast("abc123", ClickEvent{ShortID: "abc123", ClickCount: 2})
+	for _, ch := range []<-chan ClickEvent{first, second} {
+		for want := int64(1); want <= 2; want++ {
+			if evt, _ := receive(t, ch); evt.ClickCount != want {
+				t.Errorf("received click count %d, want %d", evt.ClickCount, want)
+			}
+		}
+	}
+	select {
+	case evt := <-other:
+		t.Errorf("a subscriber of another short ID received %+v", evt)
+	default:
+	}
+}
+
+func TestClickBroadcasterUnsubscribeClosesTheChannel(t *testing.T) {
+	b := NewClickBroadcaster()
+	clicks, unsubscribe := b.Subscribe("abc123")
+	unsubscribe()
+	unsubscribe() // A second call does nothing.
+	if _, ok := receive(t, clicks); ok {
+		t.Fatal("the channel is open after unsubscribing")
+	}
+	// Broadcasting to a short ID without subscribers must not send on the closed channel.
+	b.Broadcast("abc123", ClickEvent{ShortID: "abc123", ClickCount: 1})
+
+	// The short ID can be subscribed to again.
+	again, unsubscribeAgain := b.Subscribe("abc123")
+	defer unsubscribeAgain()
+	b.Broadcast("abc123", ClickEvent{ShortID: "abc123", ClickCount: 2})
+	if evt, ok := receive(t, again); !ok || evt.ClickCount != 2 {
+		t.Errorf("resubscribed channel received %+v (open %t), want click count 2", evt, ok)
+	}
+}
+
+func TestClickBroadcasterCloseEndsEverySubscription(t *testing.T) {
+	b := NewClickBroadcaster()
+	first, unsubscribeFirst := b.Subscribe("abc123")
+	second, unsubscribeSecond := b.Subscribe("xyz789")
+
+	b.Close()
+	for _, ch := range []<-chan ClickEvent{first, second} {
+		if _, ok := receive(t, ch); ok {
+			t.Error("a channel is open after Close")
+		}
+	}
+	// Unsubscribing after Close must not close the channels a second time.
+	unsubscribeFirst()
+	unsubscribeSecond()
+
+	late, unsubscribeLate := b.Subscribe("abc123")
+	defer unsubscribeLate()
+	if _, ok := receive(t, late); ok {
+		t.Error("a subscription made after Close is open")
+	}
+}
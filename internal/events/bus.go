@@ -0,0 +1,145 @@
+// Package events dispatches in-process notifications about short links to subscribed hooks.
+package events
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"sync"
+	"time"
+
+	"shawty/internal/metrics"
+)
+
+// Event types published by the URL service.
+const (
+	URLCreated = "url.created"
+	URLDeleted = "url.deleted"
+	URLClicked = "url.clicked"
+)
+
+// BufferSize is how many published events may wait for dispatch before further events are dropped.
+const BufferSize = 100
+
+// Event is a notification about a short link. Fields that do not apply to the event type are left empty.
+type Event struct {
+	Type        string
+	ShortID     string
+	OriginalURL string          // URLCreated only
+	ClickCount  int64           // URLClicked only: the link's click count after this click
+	IP          string          // Client IP of the request that caused the event
+	At          time.Time       // When the event happened
+	Context     context.Context // Context of the request that caused the event, detached from its cancellation
+}
+
+// Bus delivers published events to their subscribers asynchronously. Events wait in a buffered
+// channel until the dispatcher picks them up; each subscriber then runs in its own goroutine,
+// so a slow hook never holds up the others. The zero value is not usable; create buses with NewBus.
+type Bus struct {
+	mu       sync.RWMutex
+	handlers map[string][]func(Event)
+	closed   bool
+
+	queue    chan Event
+	done     chan struct{}
+	inFlight sync.WaitGroup
+	err      error // Why the dispatcher stopped before the bus was closed; guarded by mu
+}
+
+// NewBus returns a bus and starts its dispatcher. Call Close to stop it.
+func NewBus() *Bus {
+	b := &Bus{
+		handlers: make(map[string][]func(Event)),
+		queue:    make(chan Event, BufferSize),
+		done:     make(chan struct{}),
+	}
+	go b.dispatch()
+	return b
+}
+
+// Subscribe registers handler for events of eventType. Handlers are called for events published after
+// they subscribe, in a goroutine of their own, and must therefore be safe for concurrent use.
+func (b *Bus) Subscribe(eventType string, handler func(Event)) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.handlers[eventType] = append(b.handlers[eventType], handler)
+}
+
+// Publish queues e for dispatch without waiting for its subscribers. When the queue is full, or the
+// bus is closed, the event is dropped and counted in metrics.EventsDropped. Publishing on a nil bus
+// does nothing, so services can run without one.
+func (b *Bus) Publish(e Event) {
+	if b == nil {
+		return
+	}
+	if e.Context == nil {
+		e.Context = context.Background()
+	}
+	e.Context = context.WithoutCancel(e.Context)
+
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	if b.closed {
+		metrics.EventsDropped.WithLabelValues(e.Type).Inc()
+		return
+	}
+	select {
+	case b.queue <- e:
+	default:
+		metrics.EventsDropped.WithLabelValues(e.Type).Inc()
+		slog.WarnContext(e.Context, "Event queue is full, dropping event", slog.String("type", e.Type), slog.String("short_id", e.ShortID))
+	}
+}
+
+// Close stops accepting events, delivers those already queued and waits for every running handler
+// to return. It is safe to call more than once.
+func (b *Bus) Close() {
+	b.mu.Lock()
+	if !b.closed {
+		b.closed = true
+		close(b.queue)
+	}
+	b.mu.Unlock()
+
+	<-b.done
+	b.inFlight.Wait()
+}
+
+// Err returns why the dispatcher stopped before the bus was closed, which only happens when it
+// panics. Events published after that are never delivered. Panicking handlers do not stop it.
+func (b *Bus) Err() error {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	return b.err
+}
+
+// dispatch hands queued events to their subscribers until the queue is closed.
+func (b *Bus) dispatch() {
+	defer close(b.done)
+	defer func() {
+		if rec := recover(); rec != nil {
+			slog.Error("Event dispatcher panicked, further events are dropped", slog.Any("panic", rec))
+			b.mu.Lock()
+			b.err = fmt.Errorf("event dispatcher panicked: %v", rec)
+			b.mu.Unlock()
+		}
+	}()
+	for e := range b.queue {
+		b.mu.RLock()
+		handlers := b.handlers[e.Type]
+		b.mu.RUnlock()
+
+		for _, handler := range handlers {
+			b.inFlight.Add(1)
+			go func() {
+				defer b.inFlight.Done()
+				defer func() {
+					if rec := recover(); rec != nil {
+						slog.ErrorContext(e.Context, "Event handler panicked", slog.String("type", e.Type), slog.String("short_id", e.ShortID), slog.Any("panic", rec))
+					}
+				}()
+				handler(e)
+			}()
+		}
+	}
+}
@@ -0,0 +1,95 @@
+package events
+
+import (
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"shawty/internal/metrics"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+func TestSubscriberReceivesPublishedEvent(t *testing.T) {
+	bus := NewBus()
+	defer bus.Close()
+	received := make(chan Event, 1)
+	bus.Subscribe(URLCreated, func(e Event) { received <- e })
+	bus.Subscribe(URLDeleted, func(e Event) { t.Errorf("URLDeleted subscriber received a %s event", e.Type) })
+
+	bus.Publish(Event{Type: URLCreated, ShortID: "abc123", OriginalURL: "https://example.com"})
+	select {
+	case e := <-received:
+		if e.ShortID != "abc123" || e.OriginalURL != "https://example.com" || e.Context == nil {
+			t.Errorf("received %+v, want the published event with a context", e)
+		}
+	case <-time.After(100 * time.Millisecond):
+		t.Fatal("subscriber did not receive the event within 100ms")
+	}
+}
+
+func TestPublishDropsEventsWhenQueueIsFull(t *testing.T) {
+	// Without a dispatcher nothing drains the queue.
+	bus := &Bus{handlers: make(map[string][]func(Event)), queue: make(chan Event, BufferSize), done: make(chan struct{})}
+	dropped := metrics.EventsDropped.WithLabelValues(URLClicked)
+	before := testutil.ToFloat64(dropped)
+
+	for range BufferSize + 3 {
+		bus.Publish(Event{Type: URLClicked, ShortID: "abc123"})
+	}
+	if got := testutil.ToFloat64(dropped) - before; got != 3 {
+		t.Errorf("dropped %v events, want 3", got)
+	}
+	if len(bus.queue) != BufferSize {
+		t.Errorf("queued %d events, want %d", len(bus.queue), BufferSize)
+	}
+}
+
+func TestCloseDeliversQueuedEventsAndWaitsForHandlers(t *testing.T) {
+	bus := NewBus()
+	var handled atomic.Int32
+	bus.Subscribe(URLClicked, func(Event) {
+		time.Sleep(20 * time.Millisecond)
+		handled.Add(1)
+	})
+	for range 5 {
+		bus.Publish(Event{Type: URLClicked, ShortID: "abc123"})
+	}
+
+	bus.Close()
+	if got := handled.Load(); got != 5 {
+		t.Errorf("%d handlers finished before Close returned, want 5", got)
+	}
+
+	dropped := metrics.EventsDropped.WithLabelValues(URLClicked)
+	before := testutil.ToFloat64(dropped)
+	bus.Publish(Event{Type: URLClicked, ShortID: "abc123"})
+	if got := testutil.ToFloat64(dropped) - before; got != 1 {
+		t.Errorf("publishing after Close dropped %v events, want 1", got)
+	}
+	bus.Close()
+}
+
+func TestPanickingHandlerDoesNotStopDispatch(t *testing.T) {
+	bus := NewBus()
+	defer bus.Close()
+	received := make(chan Event, 1)
+	bus.Subscribe(URLDeleted, func(Event) { panic("handler bug") })
+	bus.Subscribe(URLCreated, func(e Event) { received <- e })
+
+	bus.Publish(Event{Type: URLDeleted, ShortID: "abc123"})
+	bus.Publish(Event{Type: URLCreated, ShortID: "def456"})
+	select {
+	case <-received:
+	case <-time.After(time.Second):
+		t.Fatal("events after a panicking handler were not delivered")
+	}
+	if err := bus.Err(); err != nil {
+		t.Errorf("Err = %v, want nil", err)
+	}
+}
+
+func TestPublishOnNilBusDoesNothing(t *testing.T) {
+	var bus *Bus
+	bus.Publish(Event{Type: URLCreated, ShortID: "abc123"})
+}
@@ -0,0 +1,81 @@
+// Package geoip resolves IP addresses to countries using a local MaxMind (MMDB) database.
+package geoip
+
+import (
+	"fmt"
+	"log/slog"
+	"net"
+	"sync"
+
+	"github.com/oschwald/maxminddb-golang"
+)
+
+// UnknownCountry is the country code of addresses that cannot be resolved, following the
+// user-assigned ISO 3166 code MaxMind and others use for "unknown".
+const UnknownCountry = "XX"
+
+// countryRecord is the part of a GeoIP2/GeoLite2 Country or City record that is read.
+type countryRecord struct {
+	Country struct {
+		ISOCode string `maxminddb:"iso_code"`
+	} `maxminddb:"country"`
+	RegisteredCountry struct {
+		ISOCode string `maxminddb:"iso_code"`
+	} `maxminddb:"registered_country"`
+}
+
+// Resolver looks up the country of IP addresses. A nil *Resolver is valid and resolves every
+// address to UnknownCountry, so callers need not check whether a database was configured.
+type Resolver struct {
+	reader *maxminddb.Reader
+}
+
+// warnUnconfigured logs, once per process, that countries are not being resolved.
+var warnUnconfigured sync.Once
+
+// Open memory-maps the MMDB database at path, e.g. GeoLite2-Country.mmdb.
+func Open(path string) (*Resolver, error) {
+	reader, err := maxminddb.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open GeoIP database '%s': %w", path, err)
+	}
+	return &Resolver{reader: reader}, nil
+}
+
+// Country returns the two-letter ISO 3166-1 code of the country ip is located in, falling back
+// to the country it is registered in. It returns UnknownCountry for unparseable, private or
+// unlisted addresses, and always when r is nil.
+func (r *Resolver) Country(ip string) string {
+	if r == nil {
+		warnUnconfigured.Do(func() {
+			slog.Warn("GEOIP_DB_PATH is not set; clicks are recorded with country " + UnknownCountry)
+		})
+		return UnknownCountry
+	}
+
+	parsed := net.ParseIP(ip)
+	if parsed == nil {
+		return UnknownCountry
+	}
+	var record countryRecord
+	if err := r.reader.Lookup(parsed, &record); err != nil {
+		slog.Debug("GeoIP lookup failed", slog.String("ip", ip), slog.Any("error", err))
+		return UnknownCountry
+	}
+	switch {
+	case record.Country.ISOCode != "":
+		return record.Country.ISOCode
+	case record.RegisteredCountry.ISOCode != "":
+		return record.RegisteredCountry.ISOCode
+	default:
+		return UnknownCountry
+	}
+}
+
+// Close unmaps the database. It is a no-op on a nil Resolver.
+func (r *Resolver) Close() error {
+	if r == nil {
+		return nil
+	}
+	return r.reader.Close()
+}
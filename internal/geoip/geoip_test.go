@@ -0,0 +1,51 @@
+package geoip
+
+import "testing"
+
+// testDatabase is a small MMDB file; testdata/README.md lists the networks it holds.
+const testDatabase = "testdata/country-test.mmdb"
+
+func TestCountry(t *testing.T) {
+	resolver, err := Open(testDatabase)
+	if err != nil {
+		t.Fatalf("Open: %v", err)
+	}
+	defer resolver.Close()
+
+	tests := []struct {
+		ip   string
+		want string
+	}{
+		{ip: "1.2.3.4", want: "US"},
+		{ip: "1.255.255.255", want: "US"},
+		{ip: "2.125.160.216", want: "GB"},
+		{ip: "81.2.69.160", want: "GB"},
+		{ip: "3.1.2.3", want: "JP"}, // Registered country only
+		{ip: "4.4.4.4", want: UnknownCountry},
+		{ip: "10.0.0.1", want: UnknownCountry},
+		{ip: "2001:db8::1", want: UnknownCountry},
+		{ip: "not an ip", want: UnknownCountry},
+		{ip: "", want: UnknownCountry},
+	}
+	for _, tt := range tests {
+		if got := resolver.Country(tt.ip); got != tt.want {
+			t.Errorf("Country(%q) = %q, want %q", tt.ip, got, tt.want)
+		}
+	}
+}
+
+func TestCountryWithoutDatabase(t *testing.T) {
+	var resolver *Resolver
+	if got := resolver.Country("1.2.3.4"); got != UnknownCountry {
+		t.Errorf("Country on a nil Resolver = %q, want %q", got, UnknownCountry)
+	}
+	if err := resolver.Close(); err != nil {
+		t.Errorf("Close on a nil Resolver: %v", err)
+	}
+}
+
+func TestOpenMissingDatabase(t *testing.T) {
+	if _, err := Open("testdata/missing.mmdb"); err == nil {
+		t.Error("Open of a missing file succeeded")
+	}
+}
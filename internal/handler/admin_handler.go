@@ -0,0 +1,409 @@
+package handler
+
+import (
+	"context"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"shawty/internal/domain"
+	"shawty/internal/middleware"
+	"shawty/internal/service"
+	"shawty/internal/store"
+	"shawty/internal/tracing"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// AdminOwner is the owner name under which requests made with the admin token are recorded.
+const AdminOwner = "admin"
+
+// Pagination bounds for the admin URL listing.
+const (
+	defaultListLimit = 50
+	maxListLimit     = 200
+)
+
+// Result bounds for the admin URL search. Larger limits are capped rather than refused.
+const (
+	defaultSearchLimit = 20
+	maxSearchLimit     = 100
+)
+
+// ListURLsResponse defines the JSON response for a page of stored URLs.
+// NextCursor is passed back as ?cursor= to fetch the next page; it is empty on the last page.
+// Total is only reported for listings filtered by tag.
+type ListURLsResponse struct {
+	Data       []domain.URL `json:"data"`
+	Limit      int64        `json:"limit"`
+	NextCursor string       `json:"next_cursor"`
+	Total      *int64       `json:"total,omitempty"`
+}
+
+// offsetCursorPrefix marks the cursors of tag-filtered listings, which page by offset.
+const offsetCursorPrefix = "offset:"
+
+// encodeOffsetCursor returns the opaque cursor of a tag-filtered page starting at offset.
+func encodeOffsetCursor(offset int64) string {
+	return base64.RawURLEncoding.EncodeToString([]byte(offsetCursorPrefix + strconv.FormatInt(offset, 10)))
+}
+
+// decodeOffsetCursor returns the offset a tag-filtered cursor starts at; the empty cursor starts at 0.
+func decodeOffsetCursor(cursor string) (int64, bool) {
+	if cursor == "" {
+		return 0, true
+	}
+	decoded, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return 0, false
+	}
+	raw, ok := strings.CutPrefix(string(decoded), offsetCursorPrefix)
+	if !ok {
+		return 0, false
+	}
+	offset, err := strconv.ParseInt(raw, 10, 64)
+	return offset, err == nil && offset >= 0
+}
+
+// authorizeAdmin checks the request's bearer token against MASTER_ADMIN_TOKEN and writes a 401 if it does not match.
+// It returns true when the request may proceed. Admin endpoints are closed when no token is configured.
+func (h *URLHandler) authorizeAdmin(w http.ResponseWriter, r *http.Request) bool {
+	if !h.isMasterToken(r) {
+		writeAdminUnauthorized(w)
+		return false
+	}
+	return true
+}
+
+// isMasterToken reports whether the request's bearer token is the master admin token,
+// which sees the links of every tenant.
+func (h *URLHandler) isMasterToken(r *http.Request) bool {
+	token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+	return ok && h.cfg.AdminToken != "" && subtle.ConstantTimeCompare([]byte(token), []byte(h.cfg.AdminToken)) == 1
+}
+
+// writeAdminUnauthorized writes the 401 of a request without a valid admin token.
+func writeAdminUnauthorized(w http.ResponseWriter) {
+	w.Header().Set("WWW-Authenticate", `Bearer realm="shawty-admin"`)
+	WriteError(w, http.StatusUnauthorized, ErrCodeUnauthorized, "Missing or invalid admin token")
+}
+
+// withAdminActor attributes the changes of an admin-authorized request to AdminOwner in the audit log,
+// keeping the client IP recorded by the audit actor middleware.
+func withAdminActor(ctx context.Context) context.Context {
+	actor := domain.AuditActorFromContext(ctx)
+	actor.Key = AdminOwner
+	return domain.WithAuditActor(ctx, actor)
+}
+
+// listURLsHandler returns a page of stored URLs in short ID order.
+// It expects a GET request like /api/v1/admin/urls?cursor=<next_cursor>&limit=50 with a bearer token;
+// the first page is requested without a cursor. Deleted URLs are listed too with include_deleted=true.
+// The master admin token lists every tenant's URLs; any other API key lists only the URLs its owner created.
+// With tag=campaign-q4 only (undeleted) URLs carrying that tag are listed, which needs the master token.
+func (h *URLHandler) listURLsHandler(w http.ResponseWriter, r *http.Request) {
+	ctx, span := tracer.Start(r.Context(), "handler.ListURLs")
+	defer span.End()
+	r = r.WithContext(ctx)
+
+	if r.Method != http.MethodGet {
+		WriteError(w, http.StatusMethodNotAllowed, ErrCodeMethodNotAllowed, "Only GET method is allowed")
+		return
+	}
+	tenantID := store.AnyTenant
+	if !h.isMasterToken(r) {
+		// The API key middleware has already resolved the key's owner.
+		tenantID = middleware.OwnerFromContext(r.Context())
+		if tenantID == "" {
+			writeAdminUnauthorized(w)
+			return
+		}
+	}
+
+	query := r.URL.Query()
+	cursor := query.Get("cursor")
+	includeDeleted := false
+	if raw := query.Get("include_deleted"); raw != "" {
+		parsed, err := strconv.ParseBool(raw)
+		if err != nil {
+			WriteError(w, http.StatusBadRequest, ErrCodeValidation, "include_deleted must be true or false")
+			return
+		}
+		includeDeleted = parsed
+	}
+	limit := int64(defaultListLimit)
+	if raw := query.Get("limit"); raw != "" {
+		parsed, err := strconv.ParseInt(raw, 10, 64)
+		if err != nil || parsed < 1 || parsed > maxListLimit {
+			WriteError(w, http.StatusBadRequest, ErrCodeValidation, "limit must be an integer between 1 and 200")
+			return
+		}
+		limit = parsed
+	}
+	if tag := query.Get("tag"); tag != "" {
+		if tenantID != store.AnyTenant {
+			WriteError(w, http.StatusForbidden, ErrCodeForbidden, "Filtering by tag requires the master admin token")
+			return
+		}
+		h.listURLsByTag(w, r, tag, cursor, limit)
+		return
+	}
+
+	urls, nextCursor, err := h.urlService.ListURLs(r.Context(), tenantID, cursor, limit, includeDeleted)
+	if err != nil {
+		if errors.Is(err, store.ErrInvalidCursor) {
+			WriteError(w, http.StatusBadRequest, ErrCodeValidation, "cursor is invalid; pass the next_cursor of a previous page")
+			return
+		}
+		slog.ErrorContext(r.Context(), "Error listing URLs", slog.String("cursor", cursor), slog.Int64("limit", limit), slog.Any("error", err))
+		WriteError(w, http.StatusInternalServerError, ErrCodeInternal, "Failed to list URLs")
+		return
+	}
+
+	response := ListURLsResponse{
+		Data:       urls,
+		Limit:      limit,
+		NextCursor: nextCursor,
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(response); err != nil {
+		slog.ErrorContext(r.Context(), "Error encoding URL list response", slog.Any("error", err))
+	}
+}
+
+// listURLsByTag writes a page of the URLs carrying tag, in the same envelope as listURLsHandler.
+func (h *URLHandler) listURLsByTag(w http.ResponseWriter, r *http.Request, tag, cursor string, limit int64) {
+	offset, ok := decodeOffsetCursor(cursor)
+	if !ok {
+		WriteError(w, http.StatusBadRequest, ErrCodeValidation, "cursor is invalid; pass the next_cursor of a previous page")
+		return
+	}
+
+	urls, total, err := h.urlService.ListURLsByTag(r.Context(), tag, limit, offset)
+	if err != nil {
+		slog.ErrorContext(r.Context(), "Error listing URLs by tag", slog.String("tag", tag), slog.Int64("offset", offset), slog.Any("error", err))
+		WriteError(w, http.StatusInternalServerError, ErrCodeInternal, "Failed to list URLs")
+		return
+	}
+
+	response := ListURLsResponse{
+		Data:  urls,
+		Limit: limit,
+		Total: &total,
+	}
+	if next := offset + int64(len(urls)); next < total {
+		response.NextCursor = encodeOffsetCursor(next)
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(response); err != nil {
+		slog.ErrorContext(r.Context(), "Error encoding URL list response", slog.Any("error", err))
+	}
+}
+
+// searchURLsHandler returns the URLs whose original URL contains a keyword, such as a hostname.
+// It expects a GET request like /api/v1/admin/urls/search?q=example.com&limit=20 with an admin bearer token.
+// Matching ignores case and treats q literally. Results come in the listing envelope of listURLsHandler;
+// search returns a single page, so next_cursor is always empty.
+func (h *URLHandler) searchURLsHandler(w http.ResponseWriter, r *http.Request) {
+	ctx, span := tracer.Start(r.Context(), "handler.SearchURLs")
+	defer span.End()
+	r = r.WithContext(ctx)
+
+	if r.Method != http.MethodGet {
+		WriteError(w, http.StatusMethodNotAllowed, ErrCodeMethodNotAllowed, "Only GET method is allowed")
+		return
+	}
+	if !h.authorizeAdmin(w, r) {
+		return
+	}
+
+	query := r.URL.Query()
+	q := strings.TrimSpace(query.Get("q"))
+	if q == "" {
+		WriteError(w, http.StatusBadRequest, ErrCodeValidation, "q is missing or empty")
+		return
+	}
+	limit := int64(defaultSearchLimit)
+	if raw := query.Get("limit"); raw != "" {
+		parsed, err := strconv.ParseInt(raw, 10, 64)
+		if err != nil || parsed < 1 {
+			WriteError(w, http.StatusBadRequest, ErrCodeValidation, "limit must be a positive integer")
+			return
+		}
+		limit = min(parsed, maxSearchLimit)
+	}
+
+	urls, err := h.urlService.SearchURLs(r.Context(), q, limit)
+	if err != nil {
+		slog.ErrorContext(r.Context(), "Error searching URLs", slog.String("query", q), slog.Any("error", err))
+		WriteError(w, http.StatusInternalServerError, ErrCodeInternal, "Failed to search URLs")
+		return
+	}
+
+	response := ListURLsResponse{
+		Data:  urls,
+		Limit: limit,
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(response); err != nil {
+		slog.ErrorContext(r.Context(), "Error encoding URL search response", slog.Any("error", err))
+	}
+}
+
+// undeleteHandler restores a deleted short URL so that it redirects again.
+// It expects a POST request like /api/v1/admin/undelete/{shortID} with an admin bearer token.
+func (h *URLHandler) undeleteHandler(w http.ResponseWriter, r *http.Request) {
+	shortID := pathParam(r)
+	ctx, span := tracer.Start(r.Context(), "handler.UndeleteURL", trace.WithAttributes(attribute.String(tracing.AttrShortID, shortID)))
+	defer span.End()
+	r = r.WithContext(ctx)
+
+	if r.Method != http.MethodPost {
+		WriteError(w, http.StatusMethodNotAllowed, ErrCodeMethodNotAllowed, "Only POST method is allowed")
+		return
+	}
+	if !h.authorizeAdmin(w, r) {
+		return
+	}
+	if shortID == "" {
+		WriteError(w, http.StatusBadRequest, ErrCodeValidation, "Short URL ID is missing in the path")
+		return
+	}
+	r = r.WithContext(withAdminActor(r.Context()))
+
+	if err := h.urlService.UndeleteShortURL(r.Context(), shortID); err != nil {
+		if errors.Is(err, store.ErrURLNotFound) {
+			WriteError(w, http.StatusNotFound, ErrCodeNotFound, fmt.Sprintf("No deleted short URL '%s'", shortID))
+		} else {
+			slog.ErrorContext(r.Context(), "Error undeleting short URL", slog.String("short_id", shortID), slog.Any("error", err))
+			WriteError(w, http.StatusInternalServerError, ErrCodeInternal, "Failed to undelete URL")
+		}
+		return
+	}
+
+	slog.InfoContext(r.Context(), "Undeleted short URL", slog.String("short_id", shortID))
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// BlacklistRequest defines the expected JSON body for blacklisting a domain.
+type BlacklistRequest struct {
+	Host string `json:"host"`
+}
+
+// blacklistHandler adds a host to the domain blacklist so that it can no longer be shortened.
+// It expects a POST request with a JSON body like: {"host": "evil.com"} and an admin bearer token.
+func (h *URLHandler) blacklistHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		WriteError(w, http.StatusMethodNotAllowed, ErrCodeMethodNotAllowed, "Only POST method is allowed")
+		return
+	}
+	if !h.authorizeAdmin(w, r) {
+		return
+	}
+
+	var req BlacklistRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeBodyError(w, err)
+		return
+	}
+	defer r.Body.Close()
+
+	host := strings.ToLower(strings.TrimSpace(req.Host))
+	if host == "" || strings.ContainsAny(host, "/:@ ") {
+		WriteError(w, http.StatusBadRequest, ErrCodeValidation, "host must be a bare hostname such as evil.com")
+		return
+	}
+
+	if err := h.urlService.BlacklistDomain(r.Context(), host); err != nil {
+		slog.ErrorContext(r.Context(), "Error blacklisting domain", slog.String("host", host), slog.Any("error", err))
+		WriteError(w, http.StatusInternalServerError, ErrCodeInternal, "Failed to blacklist domain")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	if err := json.NewEncoder(w).Encode(BlacklistRequest{Host: host}); err != nil {
+		slog.ErrorContext(r.Context(), "Error encoding blacklist response", slog.Any("error", err))
+	}
+}
+
+// auditHandler returns the most recent audit events (at most service.MaxAuditEvents) for a short ID,
+// newest first. It expects a GET request like /api/v1/admin/audit/{shortID} with an admin bearer token.
+// Deleted short IDs keep their history.
+func (h *URLHandler) auditHandler(w http.ResponseWriter, r *http.Request) {
+	ctx, span := tracer.Start(r.Context(), "handler.ListAuditEvents")
+	defer span.End()
+	r = r.WithContext(ctx)
+
+	if r.Method != http.MethodGet {
+		WriteError(w, http.StatusMethodNotAllowed, ErrCodeMethodNotAllowed, "Only GET method is allowed")
+		return
+	}
+	if !h.authorizeAdmin(w, r) {
+		return
+	}
+
+	shortID := pathParam(r)
+	if shortID == "" {
+		WriteError(w, http.StatusBadRequest, ErrCodeValidation, "Short URL ID is missing in the path")
+		return
+	}
+
+	events, err := h.urlService.ListAuditEvents(r.Context(), shortID)
+	if err != nil {
+		if errors.Is(err, service.ErrAuditUnavailable) {
+			WriteError(w, http.StatusNotImplemented, ErrCodeNotImplemented, "The audit log is not available with this store")
+			return
+		}
+		slog.ErrorContext(r.Context(), "Error listing audit events", slog.String("short_id", shortID), slog.Any("error", err))
+		WriteError(w, http.StatusInternalServerError, ErrCodeInternal, fmt.Sprintf("Failed to list audit events for '%s'", shortID))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(events); err != nil {
+		slog.ErrorContext(r.Context(), "Error encoding audit events", slog.String("short_id", shortID), slog.Any("error", err))
+	}
+}
+
+// domainStatsHandler returns the links created on each domain and the clicks they received, as a
+// list of domain.DomainStats. It serves GET /admin/domains/stats and requires the admin bearer token.
+func (h *URLHandler) domainStatsHandler(w http.ResponseWriter, r *http.Request) {
+	ctx, span := tracer.Start(r.Context(), "handler.DomainStats")
+	defer span.End()
+	r = r.WithContext(ctx)
+
+	if r.Method != http.MethodGet {
+		WriteError(w, http.StatusMethodNotAllowed, ErrCodeMethodNotAllowed, "Only GET method is allowed")
+		return
+	}
+	if !h.authorizeAdmin(w, r) {
+		return
+	}
+
+	stats, err := h.urlService.DomainStats(r.Context())
+	if err != nil {
+		if errors.Is(err, service.ErrDomainStatsUnavailable) {
+			WriteError(w, http.StatusNotImplemented, ErrCodeNotImplemented, "Per-domain stats are not available with this store")
+			return
+		}
+		slog.ErrorContext(r.Context(), "Error counting URLs by domain", slog.Any("error", err))
+		WriteError(w, http.StatusInternalServerError, ErrCodeInternal, "Failed to count URLs by domain")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(stats); err != nil {
+		slog.ErrorContext(r.Context(), "Error encoding domain stats", slog.Any("error", err))
+	}
+}
@@ -0,0 +1,264 @@
+package handler
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+	"testing"
+
+	"shawty/internal/config"
+	"shawty/internal/middleware"
+	"shawty/internal/service"
+	"shawty/internal/store"
+)
+
+const testAdminToken = "admin-secret"
+
+// adminHeader returns the headers of a request authorized with testAdminToken.
+func adminHeader() http.Header {
+	return http.Header{"Authorization": {"Bearer " + testAdminToken}}
+}
+
+func TestListURLsHandlerWalksAllPagesByCursor(t *testing.T) {
+	svc, memStore := newMemoryService()
+	const total = 200
+	saveURLs(t, memStore, total)
+	mux := newTestMux(t, svc, config.AppConfig{AdminToken: testAdminToken})
+
+	seen := make(map[string]bool)
+	var order []string
+	cursor := ""
+	for pages := 0; ; pages++ {
+		if pages > total {
+			t.Fatal("listing did not reach an empty next_cursor")
+		}
+		target := "/api/v1/admin/urls?limit=30&cursor=" + url.QueryEscape(cursor)
+		rec := serve(mux, http.MethodGet, target, "", adminHeader())
+		if rec.Code != http.StatusOK {
+			t.Fatalf("page %d: status = %d, want 200; body %s", pages, rec.Code, rec.Body)
+		}
+		var page ListURLsResponse
+		if err := json.Unmarshal(rec.Body.Bytes(), &page); err != nil {
+			t.Fatalf("page %d: decoding response: %v", pages, err)
+		}
+		for _, entry := range page.Data {
+			if seen[entry.ID] {
+				t.Fatalf("page %d repeats %q", pages, entry.ID)
+			}
+			seen[entry.ID] = true
+			order = append(order, entry.ID)
+		}
+		if page.NextCursor == "" {
+			break
+		}
+		cursor = page.NextCursor
+	}
+
+	if len(order) != total {
+		t.Fatalf("listed %d URLs, want %d", len(order), total)
+	}
+	for i, id := range order {
+		if want := fmt.Sprintf("id%04d", i); id != want {
+			t.Fatalf("entry %d = %q, want %q", i, id, want)
+		}
+	}
+}
+
+func TestDeletedLinkIsGoneUntilUndeleted(t *testing.T) {
+	if !store.SoftDelete {
+		t.Skip("deleted links are removed in harddelete builds")
+	}
+	svc, _ := newMemoryService()
+	shortID := mustShorten(t, svc, "https://example.com/removed", service.CreateOptions{})
+	mux := newTestMux(t, svc, config.AppConfig{AdminToken: testAdminToken})
+
+	if rec := serve(mux, http.MethodDelete, "/api/v1/r/"+shortID, "", adminHeader()); rec.Code != http.StatusNoContent {
+		t.Fatalf("delete: status = %d, want 204; body %s", rec.Code, rec.Body)
+	}
+
+	rec := serve(mux, http.MethodGet, "/api/v1/r/"+shortID, "", nil)
+	if rec.Code != http.StatusGone {
+		t.Fatalf("redirect after delete: status = %d, want 410; body %s", rec.Code, rec.Body)
+	}
+	var body ErrorResponse
+	if err := json.Unmarshal(rec.Body.Bytes(), &body); err != nil {
+		t.Fatalf("decoding error body: %v", err)
+	}
+	if body.Error != "This link has been removed" {
+		t.Errorf("error = %q, want %q", body.Error, "This link has been removed")
+	}
+
+	listed := func(includeDeleted bool) bool {
+		rec := serve(mux, http.MethodGet, fmt.Sprintf("/api/v1/admin/urls?include_deleted=%t", includeDeleted), "", adminHeader())
+		var page ListURLsResponse
+		if err := json.Unmarshal(rec.Body.Bytes(), &page); err != nil {
+			t.Fatalf("decoding listing: %v", err)
+		}
+		return len(page.Data) == 1 && page.Data[0].ID == shortID
+	}
+	if listed(false) {
+		t.Error("the deleted link is listed without include_deleted")
+	}
+	if !listed(true) {
+		t.Error("the deleted link is not listed with include_deleted=true")
+	}
+
+	if rec := serve(mux, http.MethodPost, "/api/v1/admin/undelete/"+shortID, "", adminHeader()); rec.Code != http.StatusNoContent {
+		t.Fatalf("undelete: status = %d, want 204; body %s", rec.Code, rec.Body)
+	}
+	rec = serve(mux, http.MethodGet, "/api/v1/r/"+shortID, "", nil)
+	if rec.Code != http.StatusFound || rec.Header().Get("Location") != "https://example.com/removed" {
+		t.Errorf("redirect after undelete: status = %d, Location = %q; want 302 to the original URL", rec.Code, rec.Header().Get("Location"))
+	}
+	if rec := serve(mux, http.MethodPost, "/api/v1/admin/undelete/"+shortID, "", adminHeader()); rec.Code != http.StatusNotFound {
+		t.Errorf("undeleting a live link: status = %d, want 404", rec.Code)
+	}
+}
+
+func TestListURLsHandlerRejectsInvalidCursor(t *testing.T) {
+	svc, _ := newMemoryService()
+	mux := newTestMux(t, svc, config.AppConfig{AdminToken: testAdminToken})
+
+	rec := serve(mux, http.MethodGet, "/api/v1/admin/urls?cursor=%25%25", "", adminHeader())
+	if rec.Code != http.StatusBadRequest {
+		t.Fatalf("status = %d, want 400; body %s", rec.Code, rec.Body)
+	}
+	if code := errorCode(t, rec); code != ErrCodeValidation {
+		t.Errorf("code = %q, want %q", code, ErrCodeValidation)
+	}
+}
+
+func TestListURLsHandlerFiltersByTag(t *testing.T) {
+	svc, _ := newMemoryService()
+	mux := newTestMux(t, svc, config.AppConfig{AdminToken: testAdminToken})
+	tagged := map[string][]string{
+		"https://example.com/q4-social": {"campaign-q4", "social"},
+		"https://example.com/q4-email":  {"Campaign-Q4", "email"},
+		"https://example.com/social":    {"social"},
+		"https://example.com/untagged":  nil,
+	}
+	ids := make(map[string]string)
+	for originalURL, tags := range tagged {
+		body, _ := json.Marshal(ShortenURLRequest{URL: originalURL, Tags: tags})
+		rec := serve(mux, http.MethodPost, "/api/v1/shorten", string(body), nil)
+		if rec.Code != http.StatusCreated {
+			t.Fatalf("shorten %s: status = %d, want 201; body %s", originalURL, rec.Code, rec.Body)
+		}
+		var resp ShortenURLResponse
+		if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+			t.Fatalf("decoding response: %v", err)
+		}
+		ids[originalURL] = resp.ShortURL[strings.LastIndex(resp.ShortURL, "/")+1:]
+	}
+
+	listTag := func(tag string) []string {
+		t.Helper()
+		rec := serve(mux, http.MethodGet, "/api/v1/admin/urls?tag="+url.QueryEscape(tag), "", adminHeader())
+		if rec.Code != http.StatusOK {
+			t.Fatalf("tag %q: status = %d, want 200; body %s", tag, rec.Code, rec.Body)
+		}
+		var page ListURLsResponse
+		if err := json.Unmarshal(rec.Body.Bytes(), &page); err != nil {
+			t.Fatalf("decoding listing: %v", err)
+		}
+		if page.Total == nil || *page.Total != int64(len(page.Data)) {
+			t.Errorf("tag %q: total = %v, want %d", tag, page.Total, len(page.Data))
+		}
+		var urls []string
+		for _, entry := range page.Data {
+			urls = append(urls, entry.OriginalUrl)
+		}
+		slices.Sort(urls)
+		return urls
+	}
+	tests := []struct {
+		tag  string
+		want []string
+	}{
+		{tag: "campaign-q4", want: []string{"https://example.com/q4-email", "https://example.com/q4-social"}},
+		{tag: "social", want: []string{"https://example.com/q4-social", "https://example.com/social"}},
+		{tag: "email", want: []string{"https://example.com/q4-email"}},
+		{tag: "unused", want: nil},
+	}
+	for _, tt := range tests {
+		if got := listTag(tt.tag); !slices.Equal(got, tt.want) {
+			t.Errorf("tag %q lists %v, want %v", tt.tag, got, tt.want)
+		}
+	}
+
+	// Retagging changes the filter results without touching the destination.
+	shortID := ids["https://example.com/untagged"]
+	rec := serve(mux, http.MethodPatch, "/api/v1/r/"+shortID, `{"tags": ["email"]}`, adminHeader())
+	if rec.Code != http.StatusOK {
+		t.Fatalf("patch tags: status = %d, want 200; body %s", rec.Code, rec.Body)
+	}
+	want := []string{"https://example.com/q4-email", "https://example.com/untagged"}
+	if got := listTag("email"); !slices.Equal(got, want) {
+		t.Errorf("after retagging, tag email lists %v, want %v", got, want)
+	}
+	if rec := serve(mux, http.MethodPatch, "/api/v1/r/"+shortID, `{"tags": ["not a slug"]}`, adminHeader()); rec.Code != http.StatusBadRequest {
+		t.Errorf("invalid tag: status = %d, want 400", rec.Code)
+	}
+}
+
+func TestListURLsHandlerScopesAPIKeysToTheirTenant(t *testing.T) {
+	svc, _ := newMemoryService()
+	keys := map[string]string{"key-a": "tenant-a", "key-b": "tenant-b", testAdminToken: AdminOwner}
+	mux := newTestMux(t, svc, config.AppConfig{AdminToken: testAdminToken})
+	api := middleware.NewAPIKeyMiddleware(keys)(mux)
+	bearer := func(key string) http.Header { return http.Header{"Authorization": {"Bearer " + key}} }
+
+	ids := make(map[string]string)
+	for _, key := range []string{"key-a", "key-b"} {
+		rec := serve(api, http.MethodPost, "/api/v1/shorten", `{"url": "https://example.com/shared"}`, bearer(key))
+		if rec.Code != http.StatusCreated {
+			t.Fatalf("shorten with %s: status = %d, want 201; body %s", key, rec.Code, rec.Body)
+		}
+		var resp ShortenURLResponse
+		if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+			t.Fatalf("decoding response: %v", err)
+		}
+		ids[key] = resp.ShortURL[strings.LastIndex(resp.ShortURL, "/")+1:]
+	}
+	if ids["key-a"] == ids["key-b"] {
+		t.Fatalf("both tenants got short ID %q, want separate IDs", ids["key-a"])
+	}
+
+	list := func(key string) []string {
+		t.Helper()
+		rec := serve(api, http.MethodGet, "/api/v1/admin/urls", "", bearer(key))
+		if rec.Code != http.StatusOK {
+			t.Fatalf("list with %s: status = %d, want 200; body %s", key, rec.Code, rec.Body)
+		}
+		var page ListURLsResponse
+		if err := json.Unmarshal(rec.Body.Bytes(), &page); err != nil {
+			t.Fatalf("decoding listing: %v", err)
+		}
+		var listed []string
+		for _, entry := range page.Data {
+			listed = append(listed, entry.ID)
+		}
+		slices.Sort(listed)
+		return listed
+	}
+	for _, key := range []string{"key-a", "key-b"} {
+		if got := list(key); !slices.Equal(got, []string{ids[key]}) {
+			t.Errorf("%s lists %v, want only %q", key, got, ids[key])
+		}
+	}
+	want := []string{ids["key-a"], ids["key-b"]}
+	slices.Sort(want)
+	if got := list(testAdminToken); !slices.Equal(got, want) {
+		t.Errorf("the master token lists %v, want %v", got, want)
+	}
+
+	// Redirects are public and resolve every tenant's links.
+	for _, id := range ids {
+		if rec := serve(mux, http.MethodGet, "/api/v1/r/"+id, "", nil); rec.Code != http.StatusFound {
+			t.Errorf("redirect %s: status = %d, want 302", id, rec.Code)
+		}
+	}
+}
@@ -0,0 +1,71 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+
+	"shawty/internal/service"
+	"shawty/internal/tracing"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// CreateAliasRequest defines the expected JSON body for adding an alias to a short URL.
+type CreateAliasRequest struct {
+	Alias string `json:"alias"`
+}
+
+// createAliasHandler adds another short code that redirects to an existing link and shares its
+// click count. It requires the admin bearer token.
+// It expects a POST request to /r/{shortID}/aliases with a JSON body like: {"alias": "my-brand"}
+func (h *URLHandler) createAliasHandler(w http.ResponseWriter, r *http.Request, primaryID string) {
+	ctx, span := tracer.Start(r.Context(), "handler.CreateAlias", trace.WithAttributes(attribute.String(tracing.AttrShortID, primaryID)))
+	defer span.End()
+	r = r.WithContext(ctx)
+
+	if r.Method != http.MethodPost {
+		w.Header().Set("Allow", http.MethodPost)
+		WriteError(w, http.StatusMethodNotAllowed, ErrCodeMethodNotAllowed, "Only POST method is allowed")
+		return
+	}
+	if primaryID == "" {
+		WriteError(w, http.StatusBadRequest, ErrCodeValidation, "Short URL ID is missing in the path")
+		return
+	}
+	if !h.authorizeAdmin(w, r) {
+		return
+	}
+	r = r.WithContext(withAdminActor(r.Context()))
+
+	var req CreateAliasRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeBodyError(w, err)
+		return
+	}
+	defer r.Body.Close()
+
+	if req.Alias == "" {
+		WriteError(w, http.StatusBadRequest, ErrCodeValidation, "alias is missing or empty")
+		return
+	}
+
+	alias, err := h.urlService.CreateAlias(r.Context(), primaryID, req.Alias)
+	if err != nil {
+		if errors.Is(err, service.ErrInvalidCustomCode) || errors.Is(err, service.ErrReservedCode) || errors.Is(err, service.ErrCustomCodeTaken) {
+			status, code, message := shortenErrorResponse(err, req.Alias)
+			WriteError(w, status, code, message)
+			return
+		}
+		writeLookupError(w, r, primaryID, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	if err := json.NewEncoder(w).Encode(h.newShortenURLResponse(r, alias)); err != nil {
+		slog.ErrorContext(r.Context(), "Error encoding alias response", slog.String("short_id", req.Alias), slog.Any("error", err))
+	}
+}
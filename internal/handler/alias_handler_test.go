@@ -0,0 +1,89 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+	"slices"
+	"testing"
+	"time"
+
+	"shawty/internal/config"
+	"shawty/internal/service"
+)
+
+func TestAliasRedirectsLikeItsPrimary(t *testing.T) {
+	svc, _ := newMemoryService()
+	primaryID := mustShorten(t, svc, "https://example.com/campaign", service.CreateOptions{})
+	mux := newTestMux(t, svc, config.AppConfig{AdminToken: testAdminToken})
+
+	if rec := serve(mux, http.MethodPost, "/api/v1/r/"+primaryID+"/aliases", `{"alias": "my-brand"}`, nil); rec.Code != http.StatusUnauthorized {
+		t.Errorf("alias without the admin token: status = %d, want 401", rec.Code)
+	}
+	rec := serve(mux, http.MethodPost, "/api/v1/r/"+primaryID+"/aliases", `{"alias": "my-brand"}`, adminHeader())
+	if rec.Code != http.StatusCreated {
+		t.Fatalf("create alias: status = %d, want 201; body %s", rec.Code, rec.Body)
+	}
+	// Naming an alias as the primary attaches the new alias to the real primary.
+	if rec := serve(mux, http.MethodPost, "/api/v1/r/my-brand/aliases", `{"alias": "my-brand-2"}`, adminHeader()); rec.Code != http.StatusCreated {
+		t.Fatalf("alias of an alias: status = %d, want 201; body %s", rec.Code, rec.Body)
+	}
+
+	for _, id := range []string{primaryID, "my-brand", "my-brand-2"} {
+		rec := serve(mux, http.MethodGet, "/api/v1/r/"+id, "", nil)
+		if rec.Code != http.StatusFound || rec.Header().Get("Location") != "https://example.com/campaign" {
+			t.Errorf("redirect %s: status = %d, Location = %q; want 302 to the primary's URL", id, rec.Code, rec.Header().Get("Location"))
+		}
+	}
+
+	// Clicks on the aliases are counted on the primary, whose info lists its aliases.
+	var info URLInfoResponse
+	for deadline := time.Now().Add(2 * time.Second); ; time.Sleep(10 * time.Millisecond) {
+		rec := serve(mux, http.MethodGet, "/api/v1/r/"+primaryID+"/info", "", nil)
+		if err := json.Unmarshal(rec.Body.Bytes(), &info); err != nil {
+			t.Fatalf("decoding info: %v", err)
+		}
+		if info.ClickCount == 3 || time.Now().After(deadline) {
+			break
+		}
+	}
+	if info.ClickCount != 3 {
+		t.Errorf("primary click count = %d, want 3", info.ClickCount)
+	}
+	if want := []string{"my-brand", "my-brand-2"}; !slices.Equal(info.Aliases, want) {
+		t.Errorf("aliases = %v, want %v", info.Aliases, want)
+	}
+}
+
+func TestCreateAliasRejections(t *testing.T) {
+	svc, _ := newMemoryService()
+	primaryID := mustShorten(t, svc, "https://example.com/campaign", service.CreateOptions{})
+	otherID := mustShorten(t, svc, "https://example.com/other", service.CreateOptions{})
+	mux := newTestMux(t, svc, config.AppConfig{AdminToken: testAdminToken})
+
+	tests := []struct {
+		name       string
+		primary    string
+		body       string
+		wantStatus int
+		wantCode   string
+	}{
+		{name: "code in use", primary: primaryID, body: `{"alias": "` + otherID + `"}`, wantStatus: http.StatusConflict, wantCode: ErrCodeConflict},
+		{name: "reserved code", primary: primaryID, body: `{"alias": "admin"}`, wantStatus: http.StatusUnprocessableEntity, wantCode: ErrCodeValidation},
+		{name: "invalid code", primary: primaryID, body: `{"alias": "no spaces"}`, wantStatus: http.StatusBadRequest},
+		{name: "empty alias", primary: primaryID, body: `{}`, wantStatus: http.StatusBadRequest, wantCode: ErrCodeValidation},
+		{name: "unknown primary", primary: "nope00", body: `{"alias": "my-brand"}`, wantStatus: http.StatusNotFound, wantCode: ErrCodeNotFound},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := serve(mux, http.MethodPost, "/api/v1/r/"+tt.primary+"/aliases", tt.body, adminHeader())
+			if rec.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d; body %s", rec.Code, tt.wantStatus, rec.Body)
+			}
+			if tt.wantCode != "" {
+				if code := errorCode(t, rec); code != tt.wantCode {
+					t.Errorf("code = %q, want %q", code, tt.wantCode)
+				}
+			}
+		})
+	}
+}
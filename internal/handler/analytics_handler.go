@@ -0,0 +1,163 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"time"
+
+	"shawty/internal/domain"
+	"shawty/internal/service"
+	"shawty/internal/store"
+)
+
+// dateLayout is the format of the from and to query parameters of the analytics endpoints.
+const dateLayout = "2006-01-02"
+
+// parseDateRange reads the from and to query parameters as dates or RFC 3339 timestamps and returns
+// the half-open range [from, to) they select. A date in to includes that whole day. Without from the
+// range starts at the Unix epoch; without to it runs to the end of today (UTC), which keeps the range,
+// and so any cached result, the same throughout the day.
+func parseDateRange(query url.Values) (time.Time, time.Time, error) {
+	from := time.Unix(0, 0).UTC()
+	to := time.Now().UTC().Truncate(24*time.Hour).AddDate(0, 0, 1)
+	if raw := query.Get("from"); raw != "" {
+		parsed, _, err := parseDateParam(raw)
+		if err != nil {
+			return time.Time{}, time.Time{}, fmt.Errorf("from: %w", err)
+		}
+		from = parsed
+	}
+	if raw := query.Get("to"); raw != "" {
+		parsed, isDate, err := parseDateParam(raw)
+		if err != nil {
+			return time.Time{}, time.Time{}, fmt.Errorf("to: %w", err)
+		}
+		if isDate {
+			parsed = parsed.AddDate(0, 0, 1)
+		}
+		to = parsed
+	}
+	return from, to, nil
+}
+
+// parseDateParam parses a YYYY-MM-DD date (reporting isDate) or an RFC 3339 timestamp, in UTC.
+func parseDateParam(raw string) (t time.Time, isDate bool, err error) {
+	if t, err := time.Parse(dateLayout, raw); err == nil {
+		return t, true, nil
+	}
+	if t, err := time.Parse(time.RFC3339, raw); err == nil {
+		return t.UTC(), false, nil
+	}
+	return time.Time{}, false, fmt.Errorf("'%s' is not a YYYY-MM-DD date or an RFC 3339 timestamp", raw)
+}
+
+// writeAnalyticsError maps the errors shared by the analytics endpoints to responses.
+func writeAnalyticsError(w http.ResponseWriter, r *http.Request, shortID string, err error) {
+	if errors.Is(err, service.ErrAnalyticsUnavailable) {
+		WriteError(w, http.StatusNotImplemented, ErrCodeNotImplemented, "Click analytics are not enabled on this server")
+		return
+	}
+	if errors.Is(err, service.ErrInvalidDateRange) || errors.Is(err, service.ErrInvalidGranularity) {
+		WriteError(w, http.StatusBadRequest, ErrCodeValidation, err.Error())
+		return
+	}
+	slog.ErrorContext(r.Context(), "Error querying click analytics", slog.String("short_id", shortID), slog.Any("error", err))
+	WriteError(w, http.StatusInternalServerError, ErrCodeInternal, "Error retrieving click analytics")
+}
+
+// refererStatsHandler returns the clicks of a short URL per referer domain, most clicks first.
+// It serves GET /stats/{shortID}/clicks?from=2024-01-01&to=2024-12-31.
+func (h *URLHandler) refererStatsHandler(w http.ResponseWriter, r *http.Request, shortID string) {
+	from, to, err := parseDateRange(r.URL.Query())
+	if err != nil {
+		WriteError(w, http.StatusBadRequest, ErrCodeValidation, err.Error())
+		return
+	}
+
+	counts, err := h.urlService.ListRefererCounts(r.Context(), shortID, from, to)
+	if err != nil {
+		writeAnalyticsError(w, r, shortID, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(counts); err != nil {
+		slog.ErrorContext(r.Context(), "Error encoding referer stats", slog.String("short_id", shortID), slog.Any("error", err))
+	}
+}
+
+// countryStatsHandler returns the clicks of a short URL per country, most clicks first.
+// It serves GET /stats/{shortID}/countries?from=2024-01-01&to=2024-12-31.
+func (h *URLHandler) countryStatsHandler(w http.ResponseWriter, r *http.Request, shortID string) {
+	from, to, err := parseDateRange(r.URL.Query())
+	if err != nil {
+		WriteError(w, http.StatusBadRequest, ErrCodeValidation, err.Error())
+		return
+	}
+
+	counts, err := h.urlService.ListCountryCounts(r.Context(), shortID, from, to)
+	if err != nil {
+		writeAnalyticsError(w, r, shortID, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(counts); err != nil {
+		slog.ErrorContext(r.Context(), "Error encoding country stats", slog.String("short_id", shortID), slog.Any("error", err))
+	}
+}
+
+// variantStatsHandler returns how the clicks of an A/B-tested short URL were split across its
+// destinations, as domain.VariantStats. It serves GET /stats/{shortID}/variants?from=2024-01-01&to=2024-12-31.
+func (h *URLHandler) variantStatsHandler(w http.ResponseWriter, r *http.Request, shortID string) {
+	from, to, err := parseDateRange(r.URL.Query())
+	if err != nil {
+		WriteError(w, http.StatusBadRequest, ErrCodeValidation, err.Error())
+		return
+	}
+
+	stats, err := h.urlService.ListVariantStats(r.Context(), shortID, from, to)
+	if err != nil {
+		if errors.Is(err, store.ErrURLDeleted) || errors.Is(err, store.ErrURLNotFound) {
+			writeLookupError(w, r, shortID, err)
+			return
+		}
+		writeAnalyticsError(w, r, shortID, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(stats); err != nil {
+		slog.ErrorContext(r.Context(), "Error encoding variant stats", slog.String("short_id", shortID), slog.Any("error", err))
+	}
+}
+
+// timeseriesHandler returns the clicks of a short URL per period, oldest first, for charting.
+// It serves GET /stats/{shortID}/timeseries?granularity=day&from=2024-01-01&to=2024-01-31;
+// granularity is hour, day (the default), week or month.
+func (h *URLHandler) timeseriesHandler(w http.ResponseWriter, r *http.Request, shortID string) {
+	from, to, err := parseDateRange(r.URL.Query())
+	if err != nil {
+		WriteError(w, http.StatusBadRequest, ErrCodeValidation, err.Error())
+		return
+	}
+	granularity := r.URL.Query().Get("granularity")
+	if granularity == "" {
+		granularity = domain.GranularityDay
+	}
+
+	buckets, err := h.urlService.ClickTimeseries(r.Context(), shortID, granularity, from, to)
+	if err != nil {
+		writeAnalyticsError(w, r, shortID, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(buckets); err != nil {
+		slog.ErrorContext(r.Context(), "Error encoding click time series", slog.String("short_id", shortID), slog.Any("error", err))
+	}
+}
@@ -0,0 +1,221 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"slices"
+	"sort"
+	"sync"
+	"testing"
+	"time"
+
+	"shawty/internal/config"
+	"shawty/internal/domain"
+	"shawty/internal/service"
+)
+
+// fakeAnalyticsStore keeps click events in memory and aggregates them like the MongoDB store:
+// most clicks first, ties in key order. Only the referer, country and variant counts are implemented.
+type fakeAnalyticsStore struct {
+	mu     sync.Mutex
+	clicks []domain.ClickEvent
+}
+
+func (s *fakeAnalyticsStore) RecordClick(ctx context.Context, evt domain.ClickEvent) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.clicks = append(s.clicks, evt)
+	return nil
+}
+
+func (s *fakeAnalyticsStore) MarkConverted(ctx context.Context, shortID, sessionID string, at time.Time) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for i, click := range s.clicks {
+		if click.ShortID == shortID && click.SessionID == sessionID && click.ConvertedAt == nil {
+			s.clicks[i].ConvertedAt = &at
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// countBy counts the clicks of shortID in [from, to) by the key of each click.
+func (s *fakeAnalyticsStore) countBy(shortID string, from, to time.Time, key func(domain.ClickEvent) string) ([]string, map[string]int64) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	counts := make(map[string]int64)
+	for _, evt := range s.clicks {
+		if evt.ShortID == shortID && !evt.ClickedAt.Before(from) && evt.ClickedAt.Before(to) {
+			counts[key(evt)]++
+		}
+	}
+	keys := make([]string, 0, len(counts))
+	for k := range counts {
+		keys = append(keys, k)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		if counts[keys[i]] != counts[keys[j]] {
+			return counts[keys[i]] > counts[keys[j]]
+		}
+		return keys[i] < keys[j]
+	})
+	return keys, counts
+}
+
+func (s *fakeAnalyticsStore) CountByReferer(ctx context.Context, shortID string, from, to time.Time) ([]domain.RefererCount, error) {
+	keys, counts := s.countBy(shortID, from, to, func(evt domain.ClickEvent) string { return evt.RefererDomain })
+	result := []domain.RefererCount{}
+	for _, k := range keys {
+		result = append(result, domain.RefererCount{Referer: k, Count: counts[k]})
+	}
+	return result, nil
+}
+
+func (s *fakeAnalyticsStore) CountByCountry(ctx context.Context, shortID string, from, to time.Time) ([]domain.CountryCount, error) {
+	keys, counts := s.countBy(shortID, from, to, func(evt domain.ClickEvent) string { return evt.CountryCode })
+	result := []domain.CountryCount{}
+	for _, k := range keys {
+		result = append(result, domain.CountryCount{Country: k, Count: counts[k]})
+	}
+	return result, nil
+}
+
+func (s *fakeAnalyticsStore) CountByVariant(ctx context.Context, shortID string, from, to time.Time) ([]domain.VariantCount, error) {
+	keys, counts := s.countBy(shortID, from, to, func(evt domain.ClickEvent) string { return evt.Variant })
+	result := []domain.VariantCount{}
+	for _, k := range keys {
+		result = append(result, domain.VariantCount{Variant: k, Count: counts[k]})
+	}
+	return result, nil
+}
+
+func (s *fakeAnalyticsStore) ClickTimeseries(ctx context.Context, shortID, granularity string, from, to time.Time) ([]domain.ClickBucket, error) {
+	return nil, nil
+}
+
+// waitForClicks waits until n clicks have been recorded, as redirects record them in the background.
+func (s *fakeAnalyticsStore) waitForClicks(t *testing.T, n int) {
+	t.Helper()
+	deadline := time.Now().Add(2 * time.Second)
+	for {
+		s.mu.Lock()
+		got := len(s.clicks)
+		s.mu.Unlock()
+		if got >= n {
+			return
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("%d clicks recorded, want %d", got, n)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestRefererStatsHandler(t *testing.T) {
+	analytics := &fakeAnalyticsStore{}
+	svc, _ := newMemoryService(service.WithAnalyticsStore(analytics))
+	shortID := mustShorten(t, svc, "https://example.com/campaign", service.CreateOptions{})
+	mux := newTestMux(t, svc, config.AppConfig{})
+
+	referers := []string{
+		"https://www.Twitter.com/someone/status/1?ref=private",
+		"https://twitter.com/other",
+		"https://news.ycombinator.com/item?id=1",
+		"",
+		"not a url",
+	}
+	for _, referer := range referers {
+		header := http.Header{}
+		if referer != "" {
+			header.Set("Referer", referer)
+		}
+		if rec := serve(mux, http.MethodGet, "/api/v1/r/"+shortID, "", header); rec.Code != http.StatusFound {
+			t.Fatalf("redirect: status = %d, want 302", rec.Code)
+		}
+	}
+	analytics.waitForClicks(t, len(referers))
+
+	today := time.Now().UTC().Format(dateLayout)
+	rec := serve(mux, http.MethodGet, "/api/v1/stats/"+shortID+"/clicks?from="+today+"&to="+today, "", nil)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d, want 200; body %s", rec.Code, rec.Body)
+	}
+	var counts []domain.RefererCount
+	if err := json.Unmarshal(rec.Body.Bytes(), &counts); err != nil {
+		t.Fatalf("decoding response: %v", err)
+	}
+	want := []domain.RefererCount{
+		{Referer: domain.RefererDirect, Count: 2},
+		{Referer: "twitter.com", Count: 2},
+		{Referer: "news.ycombinator.com", Count: 1},
+	}
+	if !slices.Equal(counts, want) {
+		t.Errorf("counts = %+v, want %+v", counts, want)
+	}
+
+	// Clicks outside the range are not counted.
+	rec = serve(mux, http.MethodGet, "/api/v1/stats/"+shortID+"/clicks?from=2000-01-01&to=2000-12-31", "", nil)
+	if rec.Code != http.StatusOK || rec.Body.String() != "[]\n" {
+		t.Errorf("past range: status = %d, body %q; want 200 and []", rec.Code, rec.Body)
+	}
+	if rec := serve(mux, http.MethodGet, "/api/v1/stats/"+shortID+"/clicks?from=yesterday", "", nil); rec.Code != http.StatusBadRequest {
+		t.Errorf("invalid date: status = %d, want 400", rec.Code)
+	}
+}
+
+func TestCountryStatsHandler(t *testing.T) {
+	analytics := &fakeAnalyticsStore{}
+	svc, _ := newMemoryService(service.WithAnalyticsStore(analytics))
+	mux := newTestMux(t, svc, config.AppConfig{})
+	clickedAt := time.Date(2024, 6, 1, 12, 0, 0, 0, time.UTC)
+	for country, n := range map[string]int{"GB": 2, "US": 3, "XX": 1, "DE": 2} {
+		for range n {
+			analytics.RecordClick(context.Background(), domain.ClickEvent{ShortID: "abc123", ClickedAt: clickedAt, CountryCode: country})
+		}
+	}
+	analytics.RecordClick(context.Background(), domain.ClickEvent{ShortID: "other1", ClickedAt: clickedAt, CountryCode: "US"})
+
+	rec := serve(mux, http.MethodGet, "/api/v1/stats/abc123/countries?from=2024-01-01&to=2024-12-31", "", nil)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d, want 200; body %s", rec.Code, rec.Body)
+	}
+	var counts []domain.CountryCount
+	if err := json.Unmarshal(rec.Body.Bytes(), &counts); err != nil {
+		t.Fatalf("decoding response: %v", err)
+	}
+	want := []domain.CountryCount{{Country: "US", Count: 3}, {Country: "DE", Count: 2}, {Country: "GB", Count: 2}, {Country: "XX", Count: 1}}
+	if !slices.Equal(counts, want) {
+		t.Errorf("counts = %+v, want %+v", counts, want)
+	}
+}
+
+func TestAnalyticsUnavailable(t *testing.T) {
+	svc, _ := newMemoryService()
+	shortID := mustShorten(t, svc, "https://example.com/no-analytics", service.CreateOptions{})
+	mux := newTestMux(t, svc, config.AppConfig{})
+
+	for _, endpoint := range []string{"clicks", "countries"} {
+		if rec := serve(mux, http.MethodGet, "/api/v1/stats/"+shortID+"/"+endpoint, "", nil); rec.Code != http.StatusNotImplemented {
+			t.Errorf("%s without an analytics store: status = %d, want 501", endpoint, rec.Code)
+		}
+	}
+}
+
+func TestRefererDomain(t *testing.T) {
+	tests := map[string]string{
+		"https://www.twitter.com/user/status/1?s=20": "twitter.com",
+		"https://WWW.Example.COM:8443/path":          "example.com",
+		"http://news.ycombinator.com/":               "news.ycombinator.com",
+		"android-app://com.slack/":                   "com.slack",
+		"":                                           domain.RefererDirect,
+		"/relative/path":                             domain.RefererDirect,
+		"%zz":                                        domain.RefererDirect,
+	}
+	for referer, want := range tests {
+		if got := refererDomain(referer); got != want {
+			t.Errorf("refererDomain(%q) = %q, want %q", referer, got, want)
+		}
+	}
+}
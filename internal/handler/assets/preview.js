@@ -0,0 +1,16 @@
+// Offers to copy the destination of a previewed link, where the browser allows clipboard access.
+(function () {
+  const button = document.getElementById("copy-destination");
+  if (!button || !navigator.clipboard) {
+    return;
+  }
+  button.hidden = false;
+  button.addEventListener("click", async () => {
+    try {
+      await navigator.clipboard.writeText(button.dataset.destination);
+      button.textContent = "Copied";
+    } catch (err) {
+      button.textContent = "Copy failed";
+    }
+  });
+})();
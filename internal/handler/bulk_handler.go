@@ -0,0 +1,83 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+
+	"shawty/internal/service"
+)
+
+// BulkShortenRequest defines the expected JSON body for shortening several URLs at once.
+type BulkShortenRequest struct {
+	URLs []string `json:"urls"`
+}
+
+// BulkShortenResult is one element of the bulk shorten response.
+// Successful entries carry ShortURL and CreationDate; failed entries carry Error.
+type BulkShortenResult struct {
+	OriginalURL  string `json:"original_url"`
+	ShortURL     string `json:"short_url,omitempty"`
+	CreationDate string `json:"creation_date,omitempty"`
+	Error        string `json:"error,omitempty"`
+}
+
+// bulkShortenURLHandler handles requests to shorten up to service.MaxBulkURLs URLs in one call.
+// It expects a POST request with a JSON body like: {"urls": ["https://a.com", "https://b.com"]}
+// Entries that fail are reported individually; the response is 200 as long as the batch was accepted.
+func (h *URLHandler) bulkShortenURLHandler(w http.ResponseWriter, r *http.Request) {
+	ctx, span := tracer.Start(r.Context(), "handler.BulkShortenURL")
+	defer span.End()
+	r = r.WithContext(ctx)
+
+	if r.Method != http.MethodPost {
+		WriteError(w, http.StatusMethodNotAllowed, ErrCodeMethodNotAllowed, "Only POST method is allowed")
+		return
+	}
+
+	// Bulk requests carry no tokens, so they would bypass the check of /shorten.
+	if h.cfg.RequireToken {
+		WriteError(w, http.StatusForbidden, ErrCodeForbidden, "Bulk shortening is disabled while shorten tokens are required")
+		return
+	}
+
+	var req BulkShortenRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeBodyError(w, err)
+		return
+	}
+	defer r.Body.Close()
+
+	results, err := h.urlService.BulkCreateShortURL(r.Context(), req.URLs, h.requestDomain(r))
+	if err != nil {
+		if errors.Is(err, service.ErrTooManyURLs) {
+			WriteError(w, http.StatusBadRequest, ErrCodeValidation, err.Error())
+		} else {
+			slog.ErrorContext(r.Context(), "Error creating short URLs in bulk", slog.Int("count", len(req.URLs)), slog.Any("error", err))
+			WriteError(w, http.StatusInternalServerError, ErrCodeInternal, "Failed to create short URLs")
+		}
+		return
+	}
+
+	response := make([]BulkShortenResult, len(results))
+	for i, result := range results {
+		if result.Err != nil {
+			slog.WarnContext(r.Context(), "Error creating short URL in bulk request", slog.String("original_url", result.OriginalURL), slog.Any("error", result.Err))
+			_, _, message := shortenErrorResponse(result.Err, "")
+			response[i] = BulkShortenResult{OriginalURL: result.OriginalURL, Error: message}
+			continue
+		}
+		created := h.newShortenURLResponse(r, result.URL)
+		response[i] = BulkShortenResult{
+			OriginalURL:  created.OriginalURL,
+			ShortURL:     created.ShortURL,
+			CreationDate: created.CreationDate,
+		}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(response); err != nil {
+		slog.ErrorContext(r.Context(), "Error encoding bulk shorten response", slog.Any("error", err))
+	}
+}
@@ -0,0 +1,149 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+
+	"shawty/internal/config"
+	"shawty/internal/service"
+	"shawty/internal/store"
+	"shawty/internal/urlutil"
+)
+
+// bulkBody returns the JSON body of a bulk shorten request for urls.
+func bulkBody(t *testing.T, urls []string) string {
+	t.Helper()
+	body, err := json.Marshal(BulkShortenRequest{URLs: urls})
+	if err != nil {
+		t.Fatalf("encoding bulk request: %v", err)
+	}
+	return string(body)
+}
+
+// storedCount returns the number of links in memStore.
+func storedCount(t *testing.T, memStore *store.MemoryUrlStore) int {
+	t.Helper()
+	count, err := memStore.EstimatedCount(context.Background())
+	if err != nil {
+		t.Fatalf("EstimatedCount: %v", err)
+	}
+	return int(count)
+}
+
+func TestBulkShortenReportsEachEntry(t *testing.T) {
+	blacklist := urlutil.NewBlacklist()
+	blacklist.Add("93.184.216.35")
+	svc, memStore := newMemoryService(service.WithBlacklist(blacklist))
+	mux := newTestMux(t, svc, config.AppConfig{AdminToken: testAdminToken, BaseURL: "https://sho.rt"})
+
+	// Public IP literals are validated without a DNS lookup.
+	urls := []string{
+		"https://93.184.216.34/first",
+		"not a url",
+		"https://93.184.216.35/blocked",
+		"http://127.0.0.1/internal",
+		"https://93.184.216.34/second",
+	}
+	rec := serve(mux, http.MethodPost, "/api/v1/shorten/bulk", bulkBody(t, urls), nil)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d, want 200 for a batch with failing entries; body %s", rec.Code, rec.Body)
+	}
+	var results []BulkShortenResult
+	if err := json.Unmarshal(rec.Body.Bytes(), &results); err != nil {
+		t.Fatalf("decoding response: %v", err)
+	}
+	if len(results) != len(urls) {
+		t.Fatalf("got %d results, want one per URL (%d)", len(results), len(urls))
+	}
+
+	wantErrors := []string{"", "invalid URL", "Domain is not allowed", "invalid URL", ""}
+	for i, result := range results {
+		if result.OriginalURL != urls[i] {
+			t.Errorf("result %d is for %q, want %q (results keep the request order)", i, result.OriginalURL, urls[i])
+		}
+		if wantErrors[i] == "" {
+			if result.Error != "" || !strings.HasPrefix(result.ShortURL, "https://sho.rt/api/v1/r/") || result.CreationDate == "" {
+				t.Errorf("result %d = %+v, want a short URL and creation date", i, result)
+			}
+			continue
+		}
+		if result.ShortURL != "" || !strings.Contains(result.Error, wantErrors[i]) {
+			t.Errorf("result %d = %+v, want only an error containing %q", i, result, wantErrors[i])
+		}
+	}
+	if got := storedCount(t, memStore); got != 2 {
+		t.Errorf("store holds %d links, want the 2 valid ones", got)
+	}
+}
+
+func TestBulkShortenBatchSize(t *testing.T) {
+	urlsOf := func(n int) []string {
+		urls := make([]string, n)
+		for i := range urls {
+			urls[i] = fmt.Sprintf("https://93.184.216.34/batch/%d", i)
+		}
+		return urls
+	}
+	tests := []struct {
+		name       string
+		urls       []string
+		wantStatus int
+	}{
+		{name: "empty", urls: urlsOf(0), wantStatus: http.StatusBadRequest},
+		{name: "at the limit", urls: urlsOf(service.MaxBulkURLs), wantStatus: http.StatusOK},
+		{name: "over the limit", urls: urlsOf(service.MaxBulkURLs + 1), wantStatus: http.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			svc, memStore := newMemoryService()
+			mux := newTestMux(t, svc, config.AppConfig{AdminToken: testAdminToken})
+
+			rec := serve(mux, http.MethodPost, "/api/v1/shorten/bulk", bulkBody(t, tt.urls), nil)
+			if rec.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d; body %s", rec.Code, tt.wantStatus, rec.Body)
+			}
+			if tt.wantStatus != http.StatusOK {
+				if code := errorCode(t, rec); code != ErrCodeValidation {
+					t.Errorf("error code = %q, want %q", code, ErrCodeValidation)
+				}
+				if got := storedCount(t, memStore); got != 0 {
+					t.Errorf("a rejected batch stored %d links", got)
+				}
+				return
+			}
+			if got := storedCount(t, memStore); got != len(tt.urls) {
+				t.Errorf("store holds %d links, want %d", got, len(tt.urls))
+			}
+		})
+	}
+}
+
+func TestBulkShortenRejectsBadRequests(t *testing.T) {
+	tests := []struct {
+		name       string
+		cfg        config.AppConfig
+		method     string
+		body       string
+		wantStatus int
+	}{
+		{name: "GET", method: http.MethodGet, wantStatus: http.StatusMethodNotAllowed},
+		{name: "malformed JSON", method: http.MethodPost, body: `{"urls": [`, wantStatus: http.StatusBadRequest},
+		{name: "shorten tokens required", cfg: config.AppConfig{RequireToken: true, TokenHMACSecret: "secret"}, method: http.MethodPost,
+			body: `{"urls": ["https://93.184.216.34/"]}`, wantStatus: http.StatusForbidden},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			svc, _ := newMemoryService()
+			mux := newTestMux(t, svc, tt.cfg)
+
+			rec := serve(mux, tt.method, "/api/v1/shorten/bulk", tt.body, nil)
+			if rec.Code != tt.wantStatus {
+				t.Errorf("status = %d, want %d; body %s", rec.Code, tt.wantStatus, rec.Body)
+			}
+		})
+	}
+}
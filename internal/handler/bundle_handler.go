@@ -0,0 +1,51 @@
+package handler
+
+import (
+	_ "embed"
+	"fmt"
+	"html/template"
+	"log/slog"
+	"net/http"
+
+	"shawty/internal/domain"
+)
+
+// defaultBundleTemplate is the page shown for bundles unless BUNDLE_TEMPLATE_PATH names another one.
+//
+//go:embed bundle_template.html
+var defaultBundleTemplate string
+
+// BundlePage is the data a bundle template is executed with.
+type BundlePage struct {
+	Title string
+	Links []domain.Variant // Name and URL of every link, in the bundle's order
+}
+
+// loadBundleTemplate parses the template file at path, or the built-in template when path is empty.
+// html/template escapes every field, so link names and URLs cannot inject markup.
+func loadBundleTemplate(path string) (*template.Template, error) {
+	if path == "" {
+		return template.New("bundle").Parse(defaultBundleTemplate)
+	}
+	tmpl, err := template.ParseFiles(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse bundle template '%s': %w", path, err)
+	}
+	return tmpl, nil
+}
+
+// writeBundlePage answers a visit to a bundle with a page listing its links instead of a redirect.
+// The page is titled after the bundle's title, or its short ID when it has none.
+func (h *URLHandler) writeBundlePage(w http.ResponseWriter, r *http.Request, urlEntry domain.URL) {
+	page := BundlePage{Title: urlEntry.BundleTitle, Links: urlEntry.Variants}
+	if page.Title == "" {
+		page.Title = urlEntry.ShortUrl
+	}
+
+	// Every view is counted as a visit, so the page must not be answered from a cache.
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.Header().Set("Cache-Control", "no-store")
+	if err := h.bundleTemplate.Execute(w, page); err != nil {
+		slog.ErrorContext(r.Context(), "Error rendering bundle page", slog.String("short_id", urlEntry.ID), slog.Any("error", err))
+	}
+}
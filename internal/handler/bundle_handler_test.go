@@ -0,0 +1,127 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"shawty/internal/config"
+
+	"golang.org/x/net/html"
+)
+
+// createBundle shortens a bundle of links through mux and returns its short ID.
+func createBundle(t *testing.T, mux http.Handler) string {
+	t.Helper()
+	body := `{"url": "https://example.com/me", "bundle": true, "bundle_title": "Ada's <links>", "variants": [
+		{"name": "Blog", "url": "https://example.com/blog"},
+		{"name": "<script>alert(1)</script>", "url": "https://example.com/talks?year=2024&lang=en"},
+		{"name": "Shop", "url": "https://shop.example.com/"}
+	]}`
+	rec := serve(mux, http.MethodPost, "/api/v1/shorten", body, nil)
+	if rec.Code != http.StatusCreated {
+		t.Fatalf("shorten bundle: status = %d, want 201; body %s", rec.Code, rec.Body)
+	}
+	var resp ShortenURLResponse
+	if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("decoding response: %v", err)
+	}
+	return resp.ShortURL[strings.LastIndex(resp.ShortURL, "/")+1:]
+}
+
+// bundleLink is an <a> element of a bundle page.
+type bundleLink struct {
+	href, text string
+}
+
+// parseBundlePage returns the title and the links of a bundle page.
+func parseBundlePage(t *testing.T, page string) (string, []bundleLink) {
+	t.Helper()
+	doc, err := html.Parse(strings.NewReader(page))
+	if err != nil {
+		t.Fatalf("parsing page: %v", err)
+	}
+	var title string
+	var links []bundleLink
+	for n := range doc.Descendants() {
+		if n.Type != html.ElementNode || n.FirstChild == nil {
+			continue
+		}
+		switch n.Data {
+		case "title":
+			title = n.FirstChild.Data
+		case "a":
+			link := bundleLink{text: n.FirstChild.Data}
+			for _, attr := range n.Attr {
+				if attr.Key == "href" {
+					link.href = attr.Val
+				}
+			}
+			links = append(links, link)
+		}
+	}
+	return title, links
+}
+
+func TestBundlePageListsEveryLink(t *testing.T) {
+	svc, _ := newMemoryService()
+	mux := newTestMux(t, svc, config.AppConfig{})
+	shortID := createBundle(t, mux)
+
+	rec := serve(mux, http.MethodGet, "/api/v1/r/"+shortID, "", nil)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d, want 200 with the bundle page; body %s", rec.Code, rec.Body)
+	}
+	if ct := rec.Header().Get("Content-Type"); !strings.HasPrefix(ct, "text/html") {
+		t.Errorf("Content-Type = %q, want text/html", ct)
+	}
+	if strings.Contains(rec.Body.String(), "<script>") {
+		t.Error("the page contains an unescaped <script> from a link name")
+	}
+
+	title, links := parseBundlePage(t, rec.Body.String())
+	if title != "Ada's <links>" {
+		t.Errorf("title = %q, want the bundle title", title)
+	}
+	// Link URLs are normalized like any destination.
+	want := []bundleLink{
+		{href: "https://example.com/blog", text: "Blog"},
+		{href: "https://example.com/talks?lang=en&year=2024", text: "<script>alert(1)</script>"},
+		{href: "https://shop.example.com", text: "Shop"},
+	}
+	if len(links) != len(want) {
+		t.Fatalf("links = %v, want %v", links, want)
+	}
+	for i := range want {
+		if links[i] != want[i] {
+			t.Errorf("link %d = %+v, want %+v", i, links[i], want[i])
+		}
+	}
+}
+
+func TestBundleTemplateCanBeOverridden(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "bundle.html")
+	custom := `<html><head><title>custom: {{.Title}}</title></head><body>{{range .Links}}<a href="{{.URL}}">{{.Name}}</a>{{end}}</body></html>`
+	if err := os.WriteFile(path, []byte(custom), 0o600); err != nil {
+		t.Fatalf("writing template: %v", err)
+	}
+	svc, _ := newMemoryService()
+	mux := newTestMux(t, svc, config.AppConfig{BundleTemplatePath: path})
+	shortID := createBundle(t, mux)
+
+	rec := serve(mux, http.MethodGet, "/api/v1/r/"+shortID, "", nil)
+	title, links := parseBundlePage(t, rec.Body.String())
+	if title != "custom: Ada's <links>" || len(links) != 3 {
+		t.Errorf("custom page has title %q and %d links, want the custom title and 3 links", title, len(links))
+	}
+
+	if err := os.WriteFile(path, []byte("{{.Title"), 0o600); err != nil {
+		t.Fatalf("writing template: %v", err)
+	}
+	if _, err := NewURLHandler(svc, config.AppConfig{BundleTemplatePath: path}); err == nil {
+		t.Error("NewURLHandler accepted an unparsable bundle template")
+	}
+}
@@ -0,0 +1,107 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"shawty/internal/cdn"
+	"shawty/internal/config"
+	"shawty/internal/domain"
+	"shawty/internal/service"
+)
+
+// newFakeCloudflare starts a stand-in for the Cloudflare purge API that answers every purge with
+// success, and returns a purger for it with a channel receiving the purged tags.
+func newFakeCloudflare(t *testing.T) (*cdn.CloudflarePurger, <-chan []string) {
+	t.Helper()
+	purged := make(chan []string, 10)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body struct {
+			Tags []string `json:"tags"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			t.Errorf("decoding purge request: %v", err)
+		}
+		purged <- body.Tags
+		w.Write([]byte(`{"success": true, "errors": []}`))
+	}))
+	t.Cleanup(server.Close)
+	return cdn.NewCloudflarePurger(server.Client(), server.URL, "zone1", "cf-token"), purged
+}
+
+func TestRedirectCacheHeaders(t *testing.T) {
+	svc, _ := newMemoryService()
+	mux := newTestMux(t, svc, config.AppConfig{AdminToken: testAdminToken})
+	permanent := mustShorten(t, svc, "https://example.com/permanent", service.CreateOptions{RedirectType: domain.RedirectPermanent})
+	alias, err := svc.CreateAlias(context.Background(), permanent, "perm-alias")
+	if err != nil {
+		t.Fatalf("CreateAlias: %v", err)
+	}
+	expiring := mustShorten(t, svc, "https://example.com/expiring", service.CreateOptions{RedirectType: domain.RedirectPermanent, ExpiresIn: time.Hour})
+	temporary := mustShorten(t, svc, "https://example.com/temporary", service.CreateOptions{RedirectType: domain.RedirectTemporary})
+
+	tests := []struct {
+		name             string
+		shortID          string
+		wantStatus       int
+		wantCacheControl string
+		wantSurrogate    string
+		wantCacheTag     string
+	}{
+		{name: "permanent", shortID: permanent, wantStatus: http.StatusMovedPermanently, wantCacheControl: permanentRedirectCacheControl,
+			wantSurrogate: "shawty-" + permanent, wantCacheTag: "shawty-" + permanent},
+		{name: "alias of a permanent link", shortID: alias.ID, wantStatus: http.StatusMovedPermanently, wantCacheControl: permanentRedirectCacheControl,
+			wantSurrogate: "shawty-perm-alias shawty-" + permanent, wantCacheTag: "shawty-perm-alias,shawty-" + permanent},
+		// A cached redirect would keep answering after the link expires.
+		{name: "permanent with an expiry", shortID: expiring, wantStatus: http.StatusFound, wantCacheControl: "no-store"},
+		{name: "temporary", shortID: temporary, wantStatus: http.StatusFound, wantCacheControl: "no-store"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := serve(mux, http.MethodGet, "/api/v1/r/"+tt.shortID, "", nil)
+			if rec.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d; body %s", rec.Code, tt.wantStatus, rec.Body)
+			}
+			if got := rec.Header().Get("Cache-Control"); got != tt.wantCacheControl {
+				t.Errorf("Cache-Control = %q, want %q", got, tt.wantCacheControl)
+			}
+			if got := rec.Header().Get("Surrogate-Key"); got != tt.wantSurrogate {
+				t.Errorf("Surrogate-Key = %q, want %q", got, tt.wantSurrogate)
+			}
+			if got := rec.Header().Get("Cache-Tag"); got != tt.wantCacheTag {
+				t.Errorf("Cache-Tag = %q, want %q", got, tt.wantCacheTag)
+			}
+		})
+	}
+}
+
+func TestUpdateAndDeletePurgeTheCDN(t *testing.T) {
+	purger, purged := newFakeCloudflare(t)
+	svc, _ := newMemoryService(service.WithCDNPurger(purger))
+	mux := newTestMux(t, svc, config.AppConfig{AdminToken: testAdminToken})
+	shortID := mustShorten(t, svc, "https://example.com/cached", service.CreateOptions{RedirectType: domain.RedirectPermanent})
+
+	expectPurge := func(action string) {
+		t.Helper()
+		select {
+		case tags := <-purged:
+			if len(tags) != 1 || tags[0] != cdn.SurrogateKey(shortID) {
+				t.Errorf("%s purged tags %v, want [%s]", action, tags, cdn.SurrogateKey(shortID))
+			}
+		default:
+			t.Errorf("%s did not purge the CDN", action)
+		}
+	}
+	if rec := serve(mux, http.MethodPatch, "/api/v1/r/"+shortID, `{"url": "https://example.com/moved"}`, adminHeader()); rec.Code != http.StatusOK {
+		t.Fatalf("update: status = %d, want 200; body %s", rec.Code, rec.Body)
+	}
+	expectPurge("update")
+	if rec := serve(mux, http.MethodDelete, "/api/v1/r/"+shortID, "", adminHeader()); rec.Code != http.StatusNoContent {
+		t.Fatalf("delete: status = %d, want 204; body %s", rec.Code, rec.Body)
+	}
+	expectPurge("delete")
+}
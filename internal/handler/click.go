@@ -0,0 +1,51 @@
+package handler
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"shawty/internal/domain"
+	"shawty/internal/service"
+)
+
+// recordClick stores the analytics event of a redirect in the background, like the visit itself.
+// variant is the A/B test variant served, or empty for links without variants. A UUID in the
+// request's session_id query parameter is kept with the click, for matching later conversions.
+func (h *URLHandler) recordClick(r *http.Request, shortID string, mobile bool, variant string) {
+	evt := domain.ClickEvent{
+		ShortID:        shortID,
+		RefererDomain:  refererDomain(r.Referer()),
+		ClickedAt:      time.Now().UTC(),
+		UserAgentClass: domain.UserAgentDesktop,
+		Variant:        variant,
+	}
+	if mobile {
+		evt.UserAgentClass = domain.UserAgentMobile
+	}
+	if sessionID, ok := service.NormalizeSessionID(r.URL.Query().Get("session_id")); ok {
+		evt.SessionID = sessionID
+	}
+
+	go func() {
+		ctx, cancel := context.WithTimeout(context.WithoutCancel(r.Context()), visitRecordTimeout)
+		defer cancel()
+		if err := h.urlService.RecordClick(ctx, evt); err != nil {
+			slog.ErrorContext(ctx, "Error recording click", slog.String("short_id", shortID), slog.Any("error", err))
+		}
+	}()
+}
+
+// refererDomain reduces a Referer header to its lowercase host without a leading "www.",
+// so that no paths or query strings are kept. It returns domain.RefererDirect for a missing
+// or unparseable header.
+func refererDomain(referer string) string {
+	parsed, err := url.Parse(referer)
+	if err != nil || parsed.Hostname() == "" {
+		return domain.RefererDirect
+	}
+	return strings.TrimPrefix(strings.ToLower(parsed.Hostname()), "www.")
+}
@@ -0,0 +1,95 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strings"
+	"time"
+
+	"shawty/internal/service"
+)
+
+// clickStreamHeartbeat is how often a click stream writes a comment line while no clicks arrive,
+// so that proxies do not close it as idle and closed clients are noticed.
+const clickStreamHeartbeat = 15 * time.Second
+
+// clickStreamHandler streams the clicks of a short URL as server-sent events while they happen.
+// It expects a GET request like /api/v1/admin/events/{shortID} with an admin bearer token.
+// Each click is an event named "click" whose data is a JSON object with short_id, click_count and
+// at; a ": heartbeat" comment is sent every 15 seconds in between. The stream runs until the
+// client disconnects or the server shuts down. Slow clients miss clicks rather than delay redirects.
+func (h *URLHandler) clickStreamHandler(w http.ResponseWriter, r *http.Request) {
+	ctx, span := tracer.Start(r.Context(), "handler.StreamClicks")
+	defer span.End()
+	r = r.WithContext(ctx)
+
+	if r.Method != http.MethodGet {
+		WriteError(w, http.StatusMethodNotAllowed, ErrCodeMethodNotAllowed, "Only GET method is allowed")
+		return
+	}
+	if !h.authorizeAdmin(w, r) {
+		return
+	}
+	shortID := pathParam(r)
+	if shortID == "" || strings.Contains(shortID, "/") {
+		WriteError(w, http.StatusBadRequest, ErrCodeValidation, "Short ID is missing in the path")
+		return
+	}
+
+	clicks, unsubscribe, err := h.urlService.SubscribeClicks(r.Context(), shortID)
+	if errors.Is(err, service.ErrClickStreamUnavailable) {
+		WriteError(w, http.StatusNotImplemented, ErrCodeNotImplemented, "Click streams are not available on this deployment")
+		return
+	}
+	if err != nil {
+		writeLookupError(w, r, shortID, err)
+		return
+	}
+	defer unsubscribe()
+
+	// The stream outlives the server's write timeout, so the deadline is lifted for this response.
+	rc := http.NewResponseController(w)
+	if err := rc.SetWriteDeadline(time.Time{}); err != nil {
+		slog.DebugContext(r.Context(), "Could not lift the write deadline for a click stream", slog.Any("error", err))
+	}
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("X-Accel-Buffering", "no") // Keeps nginx from buffering the stream
+	w.WriteHeader(http.StatusOK)
+	if err := rc.Flush(); err != nil {
+		slog.ErrorContext(r.Context(), "Click stream cannot be flushed", slog.String("short_id", shortID), slog.Any("error", err))
+		return
+	}
+
+	heartbeat := time.NewTicker(clickStreamHeartbeat)
+	defer heartbeat.Stop()
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case click, ok := <-clicks:
+			if !ok {
+				return // The server is shutting down
+			}
+			data, err := json.Marshal(click)
+			if err != nil {
+				slog.ErrorContext(r.Context(), "Error encoding click event", slog.String("short_id", shortID), slog.Any("error", err))
+				return
+			}
+			if _, err := fmt.Fprintf(w, "event: click\ndata: %s\n\n", data); err != nil {
+				return
+			}
+		case <-heartbeat.C:
+			if _, err := fmt.Fprint(w, ": heartbeat\n\n"); err != nil {
+				return
+			}
+		}
+		if err := rc.Flush(); err != nil {
+			return
+		}
+	}
+}
@@ -0,0 +1,131 @@
+package handler
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"shawty/internal/config"
+	"shawty/internal/events"
+	"shawty/internal/service"
+)
+
+// newClickStreamServer starts a server whose service feeds its clicks to a broadcaster, and returns
+// it with the service and the broadcaster.
+func newClickStreamServer(t *testing.T) (*httptest.Server, *service.UrlService, *events.ClickBroadcaster) {
+	t.Helper()
+	bus := events.NewBus()
+	t.Cleanup(bus.Close)
+	broadcaster := events.NewClickBroadcaster()
+	bus.Subscribe(events.URLClicked, broadcaster.HandleClick)
+	svc, _ := newMemoryService(service.WithEventBus(bus), service.WithClickBroadcaster(broadcaster))
+	server := httptest.NewServer(newTestMux(t, svc, config.AppConfig{AdminToken: testAdminToken}))
+	// Cleanups run last-registered first: the broadcaster ends the open streams, which
+	// Server.Close would otherwise wait for.
+	t.Cleanup(server.Close)
+	t.Cleanup(broadcaster.Close)
+	return server, svc, broadcaster
+}
+
+// openClickStream opens the click stream of shortID and returns its response once the headers,
+// which are sent after the subscription, have arrived.
+func openClickStream(t *testing.T, ctx context.Context, server *httptest.Server, shortID string) *http.Response {
+	t.Helper()
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, server.URL+"/api/v1/admin/events/"+shortID, nil)
+	if err != nil {
+		t.Fatalf("building request: %v", err)
+	}
+	req.Header = adminHeader()
+	resp, err := server.Client().Do(req)
+	if err != nil {
+		t.Fatalf("GET click stream: %v", err)
+	}
+	t.Cleanup(func() { resp.Body.Close() })
+	if resp.StatusCode != http.StatusOK || resp.Header.Get("Content-Type") != "text/event-stream" {
+		t.Fatalf("status = %d, Content-Type = %q; want 200 with text/event-stream", resp.StatusCode, resp.Header.Get("Content-Type"))
+	}
+	return resp
+}
+
+// readClickEvent reads the next click event from a stream, skipping heartbeat comments.
+func readClickEvent(t *testing.T, r *bufio.Reader) events.ClickEvent {
+	t.Helper()
+	var name, data string
+	for {
+		line, err := r.ReadString('\n')
+		if err != nil {
+			t.Fatalf("reading the stream: %v", err)
+		}
+		line = strings.TrimSuffix(line, "\n")
+		switch {
+		case strings.HasPrefix(line, "event: "):
+			name = strings.TrimPrefix(line, "event: ")
+		case strings.HasPrefix(line, "data: "):
+			data = strings.TrimPrefix(line, "data: ")
+		case line == "" && data != "":
+			if name != "click" {
+				t.Fatalf("event name = %q, want click", name)
+			}
+			var click events.ClickEvent
+			if err := json.Unmarshal([]byte(data), &click); err != nil {
+				t.Fatalf("decoding event data %q: %v", data, err)
+			}
+			return click
+		}
+	}
+}
+
+func TestClickStreamDeliversEveryClick(t *testing.T) {
+	server, svc, _ := newClickStreamServer(t)
+	shortID := mustShorten(t, svc, "https://example.com/live", service.CreateOptions{})
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	stream := bufio.NewReader(openClickStream(t, ctx, server, shortID).Body)
+
+	for range 2 {
+		if err := svc.RecordVisit(ctx, shortID, false); err != nil {
+			t.Fatalf("RecordVisit: %v", err)
+		}
+	}
+	// The bus may deliver the two clicks in either order.
+	var counts []int64
+	for range 2 {
+		click := readClickEvent(t, stream)
+		if click.ShortID != shortID || click.At.IsZero() {
+			t.Errorf("event = %+v, want short ID %s and a time", click, shortID)
+		}
+		counts = append(counts, click.ClickCount)
+	}
+	slices.Sort(counts)
+	if !slices.Equal(counts, []int64{1, 2}) {
+		t.Errorf("click counts = %v, want [1 2]", counts)
+	}
+}
+
+func TestClickStreamEndsWhenTheBroadcasterCloses(t *testing.T) {
+	server, svc, broadcaster := newClickStreamServer(t)
+	shortID := mustShorten(t, svc, "https://example.com/live", service.CreateOptions{})
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	resp := openClickStream(t, ctx, server, shortID)
+
+	broadcaster.Close()
+	rest, err := io.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatalf("reading the stream after Close: %v, want it to end", err)
+	}
+	if len(rest) != 0 {
+		t.Errorf("the stream sent %q before ending, want nothing", rest)
+	}
+	// Later subscribers get a stream that ends at once.
+	if rest, err := io.ReadAll(openClickStream(t, ctx, server, shortID).Body); err != nil || len(rest) != 0 {
+		t.Errorf("stream opened after Close: read %q, %v; want an empty stream", rest, err)
+	}
+}
@@ -0,0 +1,81 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+
+	"shawty/internal/service"
+)
+
+// trackingPixel is a transparent 1x1 GIF.
+var trackingPixel = []byte{
+	0x47, 0x49, 0x46, 0x38, 0x39, 0x61, 0x01, 0x00, 0x01, 0x00, 0x80, 0x00, 0x00, 0x00, 0x00, 0x00,
+	0xff, 0xff, 0xff, 0x21, 0xf9, 0x04, 0x01, 0x00, 0x00, 0x00, 0x00, 0x2c, 0x00, 0x00, 0x00, 0x00,
+	0x01, 0x00, 0x01, 0x00, 0x00, 0x02, 0x02, 0x44, 0x01, 0x00, 0x3b,
+}
+
+// trackConversionHandler counts a conversion of a short URL, reported by the page its visitors end
+// up on, e.g. a thank-you page. It serves POST /track/convert/{shortID}, answered with 204 No Content
+// for navigator.sendBeacon and fetch, and GET /track/convert/{shortID}, answered with a 1x1 GIF for
+// <img> tags. The optional session_id query parameter is the UUID the visitor's click carried in its
+// own session_id parameter; with it only the first conversion after each click is counted.
+// A conversion that is not counted is answered the same way as one that is.
+func (h *URLHandler) trackConversionHandler(w http.ResponseWriter, r *http.Request) {
+	ctx, span := tracer.Start(r.Context(), "handler.TrackConversion")
+	defer span.End()
+	r = r.WithContext(ctx)
+
+	if r.Method != http.MethodGet && r.Method != http.MethodPost {
+		w.Header().Set("Allow", "GET, POST")
+		WriteError(w, http.StatusMethodNotAllowed, ErrCodeMethodNotAllowed, "Only GET and POST methods are allowed")
+		return
+	}
+	shortID := pathParam(r)
+	if shortID == "" {
+		WriteError(w, http.StatusBadRequest, ErrCodeValidation, "Short URL ID is missing in the path")
+		return
+	}
+
+	counted, err := h.urlService.RecordConversion(r.Context(), shortID, r.URL.Query().Get("session_id"))
+	if err != nil {
+		if errors.Is(err, service.ErrInvalidSessionID) {
+			WriteError(w, http.StatusBadRequest, ErrCodeValidation, "session_id must be a UUID")
+		} else if errors.Is(err, service.ErrAnalyticsUnavailable) {
+			WriteError(w, http.StatusNotImplemented, ErrCodeNotImplemented, "Click analytics, which session_id is matched against, are not enabled on this server")
+		} else {
+			writeLookupError(w, r, shortID, err)
+		}
+		return
+	}
+	if !counted {
+		slog.DebugContext(r.Context(), "Conversion matched no unconverted click of its session", slog.String("short_id", shortID))
+	}
+
+	// Every conversion must reach the server, so neither answer may be cached.
+	w.Header().Set("Cache-Control", "no-store")
+	if r.Method == http.MethodPost {
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+	w.Header().Set("Content-Type", "image/gif")
+	if _, err := w.Write(trackingPixel); err != nil {
+		slog.WarnContext(r.Context(), "Error writing tracking pixel", slog.String("short_id", shortID), slog.Any("error", err))
+	}
+}
+
+// conversionRateHandler returns the clicks and conversions of a short URL, as domain.ConversionStats.
+// It serves GET /stats/{shortID}/conversion-rate.
+func (h *URLHandler) conversionRateHandler(w http.ResponseWriter, r *http.Request, shortID string) {
+	stats, err := h.urlService.ConversionRate(r.Context(), shortID)
+	if err != nil {
+		writeLookupError(w, r, shortID, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(stats); err != nil {
+		slog.ErrorContext(r.Context(), "Error encoding conversion rate", slog.String("short_id", shortID), slog.Any("error", err))
+	}
+}
@@ -0,0 +1,120 @@
+package handler
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"testing"
+	"time"
+
+	"shawty/internal/config"
+	"shawty/internal/domain"
+	"shawty/internal/service"
+)
+
+// conversionStats fetches the conversion rate of shortID from mux.
+func conversionStats(t *testing.T, mux http.Handler, shortID string) domain.ConversionStats {
+	t.Helper()
+	rec := serve(mux, http.MethodGet, "/api/v1/stats/"+shortID+"/conversion-rate", "", nil)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("conversion rate: status = %d, want 200; body %s", rec.Code, rec.Body)
+	}
+	var stats domain.ConversionStats
+	if err := json.Unmarshal(rec.Body.Bytes(), &stats); err != nil {
+		t.Fatalf("decoding conversion rate: %v", err)
+	}
+	return stats
+}
+
+// waitForConversionStats waits until the conversion rate of shortID is want, as redirects count
+// their clicks in the background.
+func waitForConversionStats(t *testing.T, mux http.Handler, shortID string, want domain.ConversionStats) {
+	t.Helper()
+	deadline := time.Now().Add(2 * time.Second)
+	for {
+		got := conversionStats(t, mux, shortID)
+		if got == want {
+			return
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("conversion rate = %+v, want %+v", got, want)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestConversionTracking(t *testing.T) {
+	svc, _ := newMemoryService()
+	shortID := mustShorten(t, svc, "https://example.com/signup", service.CreateOptions{})
+	mux := newTestMux(t, svc, config.AppConfig{})
+
+	if got := conversionStats(t, mux, shortID); got != (domain.ConversionStats{}) {
+		t.Errorf("before any click: %+v, want zero clicks, conversions and rate", got)
+	}
+
+	// A conversion without clicks leaves the rate at 0 rather than dividing by zero.
+	rec := serve(mux, http.MethodPost, "/api/v1/track/convert/"+shortID, "", nil)
+	if rec.Code != http.StatusNoContent || rec.Header().Get("Cache-Control") != "no-store" {
+		t.Fatalf("POST convert: status = %d, Cache-Control %q; want an uncached 204", rec.Code, rec.Header().Get("Cache-Control"))
+	}
+	if got, want := conversionStats(t, mux, shortID), (domain.ConversionStats{Conversions: 1}); got != want {
+		t.Errorf("after a conversion without clicks: %+v, want %+v", got, want)
+	}
+
+	for range 4 {
+		if rec := serve(mux, http.MethodGet, "/api/v1/r/"+shortID, "", nil); rec.Code != http.StatusFound {
+			t.Fatalf("redirect: status = %d, want 302", rec.Code)
+		}
+	}
+	// The tracking pixel counts as well.
+	rec = serve(mux, http.MethodGet, "/api/v1/track/convert/"+shortID, "", nil)
+	if rec.Code != http.StatusOK || rec.Header().Get("Content-Type") != "image/gif" || !bytes.Equal(rec.Body.Bytes(), trackingPixel) {
+		t.Fatalf("GET convert: status = %d, Content-Type %q; want the tracking pixel", rec.Code, rec.Header().Get("Content-Type"))
+	}
+	waitForConversionStats(t, mux, shortID, domain.ConversionStats{Clicks: 4, Conversions: 2, Rate: 0.5})
+}
+
+func TestConversionTrackingWithSessionCountsOncePerClick(t *testing.T) {
+	analytics := &fakeAnalyticsStore{}
+	svc, _ := newMemoryService(service.WithAnalyticsStore(analytics))
+	shortID := mustShorten(t, svc, "https://example.com/signup", service.CreateOptions{})
+	mux := newTestMux(t, svc, config.AppConfig{})
+	const session = "0f8fad5b-d9cb-469f-a165-70867728950e"
+
+	if rec := serve(mux, http.MethodGet, "/api/v1/r/"+shortID+"?session_id="+session, "", nil); rec.Code != http.StatusFound {
+		t.Fatalf("redirect: status = %d, want 302", rec.Code)
+	}
+	analytics.waitForClicks(t, 1)
+	for range 3 {
+		if rec := serve(mux, http.MethodPost, "/api/v1/track/convert/"+shortID+"?session_id="+session, "", nil); rec.Code != http.StatusNoContent {
+			t.Fatalf("POST convert: status = %d, want 204; body %s", rec.Code, rec.Body)
+		}
+	}
+	waitForConversionStats(t, mux, shortID, domain.ConversionStats{Clicks: 1, Conversions: 1, Rate: 1})
+}
+
+func TestTrackConversionHandlerErrors(t *testing.T) {
+	svc, _ := newMemoryService()
+	shortID := mustShorten(t, svc, "https://example.com/signup", service.CreateOptions{})
+	mux := newTestMux(t, svc, config.AppConfig{})
+
+	tests := []struct {
+		name       string
+		method     string
+		target     string
+		wantStatus int
+	}{
+		{name: "unknown link", method: http.MethodPost, target: "/api/v1/track/convert/nope00", wantStatus: http.StatusNotFound},
+		{name: "invalid session", method: http.MethodPost, target: "/api/v1/track/convert/" + shortID + "?session_id=not-a-uuid", wantStatus: http.StatusBadRequest},
+		{name: "session without analytics", method: http.MethodPost, target: "/api/v1/track/convert/" + shortID + "?session_id=0f8fad5b-d9cb-469f-a165-70867728950e", wantStatus: http.StatusNotImplemented},
+		{name: "wrong method", method: http.MethodDelete, target: "/api/v1/track/convert/" + shortID, wantStatus: http.StatusMethodNotAllowed},
+	}
+	for _, tt := range tests {
+		if rec := serve(mux, tt.method, tt.target, "", nil); rec.Code != tt.wantStatus {
+			t.Errorf("%s: status = %d, want %d; body %s", tt.name, rec.Code, tt.wantStatus, rec.Body)
+		}
+	}
+	if got := conversionStats(t, mux, shortID); got.Conversions != 0 {
+		t.Errorf("rejected conversions were counted: %+v", got)
+	}
+}
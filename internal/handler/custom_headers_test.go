@@ -0,0 +1,109 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"shawty/internal/config"
+	"shawty/internal/domain"
+	"shawty/internal/service"
+)
+
+func TestRedirectSetsCustomHeaders(t *testing.T) {
+	svc, _ := newMemoryService()
+	mux := newTestMux(t, svc, config.AppConfig{AdminToken: testAdminToken})
+
+	// The mobile URL makes the handler send its own Vary: User-Agent.
+	body := `{"url": "https://example.com/private", "mobile_url": "https://m.example.com/private", "custom_headers": {
+		"cache-control": "no-store, private",
+		"Vary": "Accept",
+		"X-Robots-Tag": "noindex",
+		"Set-Cookie": "session=stolen",
+		"Content-Security-Policy": "default-src *",
+		"Access-Control-Allow-Origin": "*",
+		"Location": "https://evil.example"
+	}}`
+	rec := serve(mux, http.MethodPost, "/api/v1/shorten", body, nil)
+	if rec.Code != http.StatusCreated {
+		t.Fatalf("shorten: status = %d, want 201; body %s", rec.Code, rec.Body)
+	}
+	var created ShortenURLResponse
+	if err := json.Unmarshal(rec.Body.Bytes(), &created); err != nil {
+		t.Fatalf("decoding response: %v", err)
+	}
+	wantStored := map[string]string{"Cache-Control": "no-store, private", "Vary": "Accept", "X-Robots-Tag": "noindex"}
+	if fmt.Sprint(created.CustomHeaders) != fmt.Sprint(wantStored) {
+		t.Errorf("custom_headers = %v, want %v with the denied headers dropped", created.CustomHeaders, wantStored)
+	}
+
+	shortID := created.ShortURL[strings.LastIndex(created.ShortURL, "/")+1:]
+	rec = serve(mux, http.MethodGet, "/api/v1/r/"+shortID, "", nil)
+	if rec.Code != http.StatusFound || rec.Header().Get("Location") != "https://example.com/private" {
+		t.Fatalf("redirect: status = %d to %q, want 302 to the original URL", rec.Code, rec.Header().Get("Location"))
+	}
+	if got := rec.Header().Get("Cache-Control"); got != "no-store, private" {
+		t.Errorf("Cache-Control = %q, want the link's", got)
+	}
+	if got := rec.Header().Get("X-Robots-Tag"); got != "noindex" {
+		t.Errorf("X-Robots-Tag = %q, want noindex", got)
+	}
+	if vary := rec.Header().Values("Vary"); !slices.Contains(vary, "Accept") || !slices.Contains(vary, "User-Agent") {
+		t.Errorf("Vary = %v, want the link's Accept added to User-Agent", vary)
+	}
+	for _, name := range []string{"Set-Cookie", "Content-Security-Policy", "Access-Control-Allow-Origin"} {
+		if got := rec.Header().Get(name); got != "" {
+			t.Errorf("denied header %s = %q reached the redirect", name, got)
+		}
+	}
+}
+
+func TestRedirectSkipsDeniedHeadersStoredEarlier(t *testing.T) {
+	svc, memStore := newMemoryService()
+	entry := domain.URL{
+		ID: "legacy", ShortUrl: "legacy", OriginalUrl: "https://example.com", CreationDate: time.Now().UTC(),
+		CustomHeaders: map[string]string{"Set-Cookie": "a=b", "X-Custom": "kept"},
+	}
+	if err := memStore.Save(context.Background(), entry); err != nil {
+		t.Fatalf("Save: %v", err)
+	}
+	mux := newTestMux(t, svc, config.AppConfig{})
+
+	rec := serve(mux, http.MethodGet, "/api/v1/r/legacy", "", nil)
+	if rec.Header().Get("Set-Cookie") != "" || rec.Header().Get("X-Custom") != "kept" {
+		t.Errorf("headers = %v, want X-Custom without Set-Cookie", rec.Header())
+	}
+}
+
+func TestShortenRejectsInvalidCustomHeaders(t *testing.T) {
+	tooMany := make(map[string]string)
+	for i := range service.MaxCustomHeaders + 1 {
+		tooMany[fmt.Sprintf("X-Header-%d", i)] = "v"
+	}
+	tests := map[string]map[string]string{
+		"too many headers":  tooMany,
+		"name too long":     {"X-" + strings.Repeat("a", service.MaxCustomHeaderNameLength): "v"},
+		"value too long":    {"X-Note": strings.Repeat("a", service.MaxCustomHeaderValueLength+1)},
+		"invalid name":      {"X Note": "v"},
+		"header injection":  {"X-Note": "v\r\nSet-Cookie: a=b"},
+		"same header twice": {"x-note": "a", "X-Note": "b"},
+	}
+	svc, _ := newMemoryService()
+	mux := newTestMux(t, svc, config.AppConfig{})
+	for name, headers := range tests {
+		body, _ := json.Marshal(ShortenURLRequest{URL: "https://example.com/" + strings.ReplaceAll(name, " ", "-"), CustomHeaders: headers})
+		rec := serve(mux, http.MethodPost, "/api/v1/shorten", string(body), nil)
+		if rec.Code != http.StatusBadRequest {
+			t.Errorf("%s: status = %d, want 400; body %s", name, rec.Code, rec.Body)
+			continue
+		}
+		if code := errorCode(t, rec); code != ErrCodeValidation {
+			t.Errorf("%s: code = %q, want %q", name, code, ErrCodeValidation)
+		}
+	}
+}
@@ -0,0 +1,59 @@
+package handler
+
+import (
+	"embed"
+	"encoding/json"
+	"io/fs"
+	"log/slog"
+	"net/http"
+)
+
+// staticFiles holds the self-contained dashboard shipped with the binary.
+//
+//go:embed static/*
+var staticFiles embed.FS
+
+// dashboardFS is the embedded static directory with the "static/" prefix removed.
+var dashboardFS = mustSub(staticFiles, "static")
+
+func mustSub(fsys fs.FS, dir string) fs.FS {
+	sub, err := fs.Sub(fsys, dir)
+	if err != nil {
+		panic(err)
+	}
+	return sub
+}
+
+// DashboardConfigResponse is the runtime configuration served to the dashboard JS app.
+// BaseURL is the URL of the versioned API the dashboard calls.
+type DashboardConfigResponse struct {
+	BaseURL          string `json:"base_url"`
+	AnalyticsEnabled bool   `json:"analytics_enabled"`
+}
+
+// ServeFile serves the embedded dashboard files under /dashboard/.
+func (h *URLHandler) ServeFile(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		WriteError(w, http.StatusMethodNotAllowed, ErrCodeMethodNotAllowed, "Only GET method is allowed")
+		return
+	}
+	http.StripPrefix("/dashboard/", http.FileServerFS(dashboardFS)).ServeHTTP(w, r)
+}
+
+// dashboardConfigHandler returns the settings the dashboard needs so it doesn't hardcode URLs.
+func (h *URLHandler) dashboardConfigHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		WriteError(w, http.StatusMethodNotAllowed, ErrCodeMethodNotAllowed, "Only GET method is allowed")
+		return
+	}
+
+	response := DashboardConfigResponse{
+		BaseURL:          h.apiBaseURL(r),
+		AnalyticsEnabled: h.cfg.AnalyticsEnabled,
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(response); err != nil {
+		slog.ErrorContext(r.Context(), "Error encoding dashboard config", slog.Any("error", err))
+	}
+}
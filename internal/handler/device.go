@@ -0,0 +1,13 @@
+package handler
+
+import "regexp"
+
+// mobileUserAgentPattern matches the User-Agent strings of common phones and tablets.
+// "Mobi" covers most mobile browsers, which include it as recommended by MDN; the rest catch
+// Android tablets and older devices that do not.
+var mobileUserAgentPattern = regexp.MustCompile(`(?i)Mobi|Android|iPhone|iPad|iPod|Windows Phone|BlackBerry|BB10|Opera Mini|IEMobile|webOS|Kindle|Silk`)
+
+// isMobileUserAgent reports whether a User-Agent header comes from a mobile device.
+func isMobileUserAgent(userAgent string) bool {
+	return mobileUserAgentPattern.MatchString(userAgent)
+}
@@ -0,0 +1,157 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"shawty/internal/config"
+	"shawty/internal/domain"
+	"shawty/internal/service"
+	"shawty/internal/store"
+)
+
+// memoryDomainStats implements store.DomainStatsStoreInterface over a MemoryUrlStore.
+type memoryDomainStats struct {
+	memStore *store.MemoryUrlStore
+}
+
+func (m memoryDomainStats) CountByDomain(ctx context.Context) ([]domain.DomainStats, error) {
+	byDomain := make(map[string]*domain.DomainStats)
+	err := m.memStore.ForEach(ctx, func(entry domain.URL) error {
+		if entry.IsDeleted() || entry.AliasOf != nil {
+			return nil
+		}
+		stats, ok := byDomain[entry.Domain]
+		if !ok {
+			stats = &domain.DomainStats{Domain: entry.Domain}
+			byDomain[entry.Domain] = stats
+		}
+		stats.Links++
+		stats.Clicks += entry.ClickCount
+		return nil
+	})
+	result := []domain.DomainStats{}
+	for _, stats := range byDomain {
+		result = append(result, *stats)
+	}
+	slices.SortFunc(result, func(a, b domain.DomainStats) int { return strings.Compare(a.Domain, b.Domain) })
+	return result, err
+}
+
+// serveHost is serve for a request sent to host.
+func serveHost(handler http.Handler, host, method, target, body string, header http.Header) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, target, strings.NewReader(body))
+	req.Host = host
+	for name, values := range header {
+		req.Header[name] = values
+	}
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+	return rec
+}
+
+// waitForClickCount waits until the link shortID has n clicks, as redirects count them in the background.
+func waitForClickCount(t *testing.T, memStore *store.MemoryUrlStore, shortID string, n int64) {
+	t.Helper()
+	deadline := time.Now().Add(2 * time.Second)
+	for {
+		entry, err := memStore.GetByShortID(context.Background(), shortID, store.AnyTenant)
+		if err != nil {
+			t.Fatalf("GetByShortID: %v", err)
+		}
+		if entry.ClickCount >= n {
+			return
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("%s has %d clicks, want %d", shortID, entry.ClickCount, n)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestDomainAliases(t *testing.T) {
+	memStore := store.NewMemoryUrlStore()
+	svc := service.NewUrlService(memStore, service.WithDomainStatsStore(memoryDomainStats{memStore}))
+	mux := newTestMux(t, svc, config.AppConfig{
+		AdminToken:    testAdminToken,
+		BaseURL:       "https://s.example.com",
+		DomainAliases: []string{"go.example.com"},
+	})
+
+	tests := []struct {
+		host         string
+		url          string
+		wantShortURL string
+		wantDomain   string
+	}{
+		{host: "s.example.com", url: "https://example.com/a", wantShortURL: "https://s.example.com/api/v1/r/", wantDomain: "s.example.com"},
+		{host: "GO.example.com", url: "https://example.com/b", wantShortURL: "https://go.example.com/api/v1/r/", wantDomain: "go.example.com"},
+		{host: "internal:8080", url: "https://example.com/c", wantShortURL: "https://s.example.com/api/v1/r/", wantDomain: "s.example.com"},
+	}
+	ids := make(map[string]string)
+	for _, tt := range tests {
+		rec := serveHost(mux, tt.host, http.MethodPost, "/api/v1/shorten", `{"url": "`+tt.url+`"}`, nil)
+		if rec.Code != http.StatusCreated {
+			t.Fatalf("shorten on %s: status = %d, want 201; body %s", tt.host, rec.Code, rec.Body)
+		}
+		var resp ShortenURLResponse
+		if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+			t.Fatalf("decoding response: %v", err)
+		}
+		if !strings.HasPrefix(resp.ShortURL, tt.wantShortURL) {
+			t.Errorf("shorten on %s: short URL = %q, want it under %s", tt.host, resp.ShortURL, tt.wantShortURL)
+		}
+		shortID := resp.ShortURL[strings.LastIndex(resp.ShortURL, "/")+1:]
+		ids[tt.url] = shortID
+		entry, err := memStore.GetByShortID(context.Background(), shortID, store.AnyTenant)
+		if err != nil {
+			t.Fatalf("GetByShortID: %v", err)
+		}
+		if entry.Domain != tt.wantDomain {
+			t.Errorf("shorten on %s: stored domain = %q, want %q", tt.host, entry.Domain, tt.wantDomain)
+		}
+	}
+
+	// Short IDs are global: every link redirects on every domain.
+	for originalURL, shortID := range ids {
+		for _, host := range []string{"s.example.com", "go.example.com"} {
+			rec := serveHost(mux, host, http.MethodGet, "/api/v1/r/"+shortID, "", nil)
+			if rec.Code != http.StatusFound || rec.Header().Get("Location") != originalURL {
+				t.Errorf("redirect of %s on %s: status = %d to %q, want 302 to %s", shortID, host, rec.Code, rec.Header().Get("Location"), originalURL)
+			}
+		}
+	}
+	for _, shortID := range ids {
+		waitForClickCount(t, memStore, shortID, 2)
+	}
+
+	rec := serveHost(mux, "s.example.com", http.MethodGet, "/api/v1/admin/domains/stats", "", adminHeader())
+	if rec.Code != http.StatusOK {
+		t.Fatalf("domain stats: status = %d, want 200; body %s", rec.Code, rec.Body)
+	}
+	var stats []domain.DomainStats
+	if err := json.Unmarshal(rec.Body.Bytes(), &stats); err != nil {
+		t.Fatalf("decoding domain stats: %v", err)
+	}
+	want := []domain.DomainStats{{Domain: "go.example.com", Links: 1, Clicks: 2}, {Domain: "s.example.com", Links: 2, Clicks: 4}}
+	if !slices.Equal(stats, want) {
+		t.Errorf("domain stats = %+v, want %+v", stats, want)
+	}
+}
+
+func TestDomainStatsHandlerWithoutStore(t *testing.T) {
+	svc, _ := newMemoryService()
+	mux := newTestMux(t, svc, config.AppConfig{AdminToken: testAdminToken})
+	if rec := serve(mux, http.MethodGet, "/api/v1/admin/domains/stats", "", nil); rec.Code != http.StatusUnauthorized {
+		t.Errorf("without the admin token: status = %d, want 401", rec.Code)
+	}
+	if rec := serve(mux, http.MethodGet, "/api/v1/admin/domains/stats", "", adminHeader()); rec.Code != http.StatusNotImplemented {
+		t.Errorf("without a domain stats store: status = %d, want 501", rec.Code)
+	}
+}
@@ -0,0 +1,44 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+)
+
+// Error codes sent in the code field of error responses. Clients should branch on these rather
+// than on the human-readable message, which may change.
+const (
+	ErrCodeNotFound           = "NOT_FOUND"
+	ErrCodeValidation         = "VALIDATION_ERROR"
+	ErrCodeHashCollision      = "HASH_COLLISION"
+	ErrCodeRateLimited        = "RATE_LIMITED"
+	ErrCodeUnauthorized       = "UNAUTHORIZED"
+	ErrCodeForbidden          = "FORBIDDEN"
+	ErrCodeInternal           = "INTERNAL_ERROR"
+	ErrCodeMethodNotAllowed   = "METHOD_NOT_ALLOWED"
+	ErrCodeGone               = "GONE"
+	ErrCodeConflict           = "CONFLICT"
+	ErrCodePayloadTooLarge    = "PAYLOAD_TOO_LARGE"
+	ErrCodeQuotaExceeded      = "QUOTA_EXCEEDED"
+	ErrCodeServiceUnavailable = "SERVICE_UNAVAILABLE"
+	ErrCodeNotImplemented     = "NOT_IMPLEMENTED"
+)
+
+// ErrorResponse is the JSON body of every error response, e.g.
+// {"error":"Short URL 'abc' not found","code":"NOT_FOUND"}.
+type ErrorResponse struct {
+	Error string `json:"error"`
+	Code  string `json:"code"`
+}
+
+// WriteError writes an error response with the given status, one of the ErrCode constants and a
+// message for humans. Like http.Error, it expects the caller to write nothing else afterwards.
+func WriteError(w http.ResponseWriter, statusCode int, code, message string) {
+	h := w.Header()
+	// A handler may have prepared headers for a successful response before it failed.
+	h.Del("Content-Length")
+	h.Set("Content-Type", "application/json")
+	h.Set("X-Content-Type-Options", "nosniff")
+	w.WriteHeader(statusCode)
+	_ = json.NewEncoder(w).Encode(ErrorResponse{Error: message, Code: code})
+}
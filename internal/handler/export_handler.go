@@ -0,0 +1,115 @@
+package handler
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"time"
+
+	"shawty/internal/domain"
+)
+
+// Export formats accepted by exportHandler.
+const (
+	exportFormatNDJSON = "ndjson"
+	exportFormatCSV    = "csv"
+)
+
+// exportFlushEvery is how many entries are written between flushes of an export.
+const exportFlushEvery = 100
+
+// exportCSVHeader is the header row of CSV exports. CSV exports carry only these columns;
+// use NDJSON for a complete backup.
+var exportCSVHeader = []string{"id", "original_url", "short_url", "creation_date", "click_count"}
+
+// exportHandler streams every stored URL, deleted ones included, as a file download.
+// It expects a GET request like /api/v1/admin/export?format=ndjson (the default) or ?format=csv
+// with an admin bearer token. NDJSON writes one JSON document per line; CSV writes the
+// exportCSVHeader columns. Entries are flushed as they are read, so the export is never held in memory.
+func (h *URLHandler) exportHandler(w http.ResponseWriter, r *http.Request) {
+	ctx, span := tracer.Start(r.Context(), "handler.ExportURLs")
+	defer span.End()
+	r = r.WithContext(ctx)
+
+	if r.Method != http.MethodGet {
+		WriteError(w, http.StatusMethodNotAllowed, ErrCodeMethodNotAllowed, "Only GET method is allowed")
+		return
+	}
+	if !h.authorizeAdmin(w, r) {
+		return
+	}
+
+	format := r.URL.Query().Get("format")
+	if format == "" {
+		format = exportFormatNDJSON
+	}
+	var (
+		contentType  string
+		write        func(domain.URL) error
+		flushEncoder func() error
+	)
+	switch format {
+	case exportFormatNDJSON:
+		contentType = "application/x-ndjson"
+		encoder := json.NewEncoder(w) // Encode terminates each document with a newline
+		write = func(u domain.URL) error { return encoder.Encode(u) }
+		flushEncoder = func() error { return nil }
+	case exportFormatCSV:
+		contentType = "text/csv; charset=utf-8"
+		writer := csv.NewWriter(w)
+		write = func(u domain.URL) error {
+			return writer.Write([]string{u.ID, u.OriginalUrl, u.ShortUrl, u.CreationDate.Format(time.RFC3339), strconv.FormatInt(u.ClickCount, 10)})
+		}
+		flushEncoder = func() error {
+			writer.Flush()
+			return writer.Error()
+		}
+		// The header is buffered in the csv.Writer until the first flush.
+		if err := writer.Write(exportCSVHeader); err != nil {
+			WriteError(w, http.StatusInternalServerError, ErrCodeInternal, "Failed to start export")
+			return
+		}
+	default:
+		WriteError(w, http.StatusBadRequest, ErrCodeValidation, "format must be ndjson or csv")
+		return
+	}
+
+	// A large export outlives the server's write timeout, so the deadline is lifted for this response.
+	rc := http.NewResponseController(w)
+	if err := rc.SetWriteDeadline(time.Time{}); err != nil {
+		slog.DebugContext(r.Context(), "Could not lift the write deadline for an export", slog.Any("error", err))
+	}
+
+	w.Header().Set("Content-Type", contentType)
+	w.Header().Set("Content-Disposition", `attachment; filename="shawty_export.`+format+`"`)
+	w.WriteHeader(http.StatusOK)
+
+	count := 0
+	err := h.urlService.ExportURLs(r.Context(), func(u domain.URL) error {
+		if err := write(u); err != nil {
+			return err
+		}
+		count++
+		if count%exportFlushEvery == 0 {
+			if err := flushEncoder(); err != nil {
+				return err
+			}
+			if err := rc.Flush(); err != nil && !errors.Is(err, http.ErrNotSupported) {
+				return err
+			}
+		}
+		return nil
+	})
+	if err == nil {
+		err = flushEncoder()
+	}
+	if err != nil {
+		// The status line has been sent, so the client only sees a truncated download.
+		slog.ErrorContext(r.Context(), "Error exporting URLs", slog.String("format", format), slog.Int("exported", count), slog.Any("error", err))
+		return
+	}
+	slog.InfoContext(r.Context(), "Exported URLs", slog.String("format", format), slog.Int("count", count))
+}
@@ -0,0 +1,92 @@
+package handler
+
+import (
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"slices"
+	"strings"
+	"testing"
+
+	"shawty/internal/config"
+	"shawty/internal/domain"
+)
+
+func TestExportHandlerNDJSON(t *testing.T) {
+	svc, memStore := newMemoryService()
+	const total = 1000
+	saveURLs(t, memStore, total)
+	mux := newTestMux(t, svc, config.AppConfig{AdminToken: testAdminToken})
+
+	rec := serve(mux, http.MethodGet, "/api/v1/admin/export?format=ndjson", "", adminHeader())
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d, want 200; body %s", rec.Code, rec.Body)
+	}
+	if got := rec.Header().Get("Content-Type"); got != "application/x-ndjson" {
+		t.Errorf("Content-Type = %q, want application/x-ndjson", got)
+	}
+	if got, want := rec.Header().Get("Content-Disposition"), `attachment; filename="shawty_export.ndjson"`; got != want {
+		t.Errorf("Content-Disposition = %q, want %q", got, want)
+	}
+
+	scanner := bufio.NewScanner(rec.Body)
+	lines := 0
+	for scanner.Scan() {
+		var entry domain.URL
+		if err := json.Unmarshal(scanner.Bytes(), &entry); err != nil {
+			t.Fatalf("line %d is not a JSON document: %v", lines+1, err)
+		}
+		if want := fmt.Sprintf("id%04d", lines); entry.ID != want {
+			t.Fatalf("line %d exports %q, want %q", lines+1, entry.ID, want)
+		}
+		lines++
+	}
+	if err := scanner.Err(); err != nil {
+		t.Fatalf("reading export: %v", err)
+	}
+	if lines != total {
+		t.Errorf("export has %d lines, want %d", lines, total)
+	}
+}
+
+func TestExportHandlerCSV(t *testing.T) {
+	svc, memStore := newMemoryService()
+	const total = 250
+	saveURLs(t, memStore, total)
+	mux := newTestMux(t, svc, config.AppConfig{AdminToken: testAdminToken})
+
+	rec := serve(mux, http.MethodGet, "/api/v1/admin/export?format=csv", "", adminHeader())
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d, want 200; body %s", rec.Code, rec.Body)
+	}
+	if got := rec.Header().Get("Content-Type"); !strings.HasPrefix(got, "text/csv") {
+		t.Errorf("Content-Type = %q, want text/csv", got)
+	}
+	records, err := csv.NewReader(rec.Body).ReadAll()
+	if err != nil {
+		t.Fatalf("parsing CSV: %v", err)
+	}
+	if len(records) != total+1 {
+		t.Fatalf("export has %d rows, want a header and %d entries", len(records), total)
+	}
+	if got := strings.Join(records[0], ","); got != "id,original_url,short_url,creation_date,click_count" {
+		t.Errorf("header = %q", got)
+	}
+	if got, want := records[total], []string{"id0249", "https://example.com/249", "id0249"}; len(got) != 5 || !slices.Equal(got[:3], want) {
+		t.Errorf("last row = %v, want it to start with %v", got, want)
+	}
+}
+
+func TestExportHandlerRejects(t *testing.T) {
+	svc, _ := newMemoryService()
+	mux := newTestMux(t, svc, config.AppConfig{AdminToken: testAdminToken})
+
+	if rec := serve(mux, http.MethodGet, "/api/v1/admin/export", "", nil); rec.Code != http.StatusUnauthorized {
+		t.Errorf("without a token: status = %d, want 401", rec.Code)
+	}
+	if rec := serve(mux, http.MethodGet, "/api/v1/admin/export?format=xml", "", adminHeader()); rec.Code != http.StatusBadRequest {
+		t.Errorf("unknown format: status = %d, want 400", rec.Code)
+	}
+}
@@ -0,0 +1,119 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"maps"
+	"net/http"
+	"slices"
+	"time"
+
+	"shawty/internal/store"
+)
+
+// healthCheckTimeout bounds each health probe's database round-trip.
+const healthCheckTimeout = 2 * time.Second
+
+// BackgroundJob is a job running alongside the server, such as the expired URL cleaner or the
+// event bus. Err returns why it stopped unexpectedly, or nil while it runs.
+type BackgroundJob interface {
+	Err() error
+}
+
+// HealthHandler serves the liveness and readiness endpoints used by
+// load balancers and Kubernetes probes. Probe requests are not logged to keep noise down.
+type HealthHandler struct {
+	checker store.HealthChecker
+	jobs    map[string]BackgroundJob
+}
+
+// NewHealthHandler creates a new HealthHandler. The readiness probe fails when the store is not
+// ready or when one of jobs, keyed by name, has stopped.
+func NewHealthHandler(checker store.HealthChecker, jobs map[string]BackgroundJob) *HealthHandler {
+	return &HealthHandler{checker: checker, jobs: jobs}
+}
+
+// LivenessResponse defines the JSON body returned by the liveness endpoint.
+type LivenessResponse struct {
+	Status string `json:"status"`
+}
+
+// HealthResponse defines the JSON body returned by the readiness endpoint.
+type HealthResponse struct {
+	Status               string `json:"status"`
+	Mongo                string `json:"mongo"`
+	ApproximateTotalURLs *int64 `json:"approximate_total_urls,omitempty"` // Left out when the store cannot estimate it
+	Error                string `json:"error,omitempty"`
+}
+
+// URLCounter is implemented by stores that can estimate how many URLs they hold, such as every
+// store.UrlStoreInterface. The readiness probe reports the estimate of a checker implementing it.
+type URLCounter interface {
+	EstimatedCount(ctx context.Context) (int64, error)
+}
+
+// RegisterRoutes sets up the liveness and readiness probes at the given paths, e.g. /livez and /readyz.
+func (h *HealthHandler) RegisterRoutes(mux *http.ServeMux, livenessPath, readinessPath string) {
+	mux.HandleFunc(livenessPath, h.livezHandler)
+	mux.HandleFunc(readinessPath, h.readyzHandler)
+}
+
+// livezHandler reports that the process is running and serving requests. It checks nothing else,
+// so that a database outage makes Kubernetes stop routing to the pod instead of restarting it.
+func (h *HealthHandler) livezHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		WriteError(w, http.StatusMethodNotAllowed, ErrCodeMethodNotAllowed, "Only GET method is allowed")
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	json.NewEncoder(w).Encode(LivenessResponse{Status: "alive"})
+}
+
+// readyzHandler reports whether the URL collection exists and accepts queries, and whether the
+// background jobs are still running. A ready store also reports its approximate number of URLs,
+// which MongoDB reads from collection metadata without scanning; a failed estimate is left out
+// rather than failing the probe.
+func (h *HealthHandler) readyzHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		WriteError(w, http.StatusMethodNotAllowed, ErrCodeMethodNotAllowed, "Only GET method is allowed")
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), healthCheckTimeout)
+	defer cancel()
+
+	status := http.StatusOK
+	response := HealthResponse{Status: "ok", Mongo: "up"}
+	if err := h.checker.Ready(ctx); err != nil {
+		status = http.StatusServiceUnavailable
+		response = HealthResponse{Status: "degraded", Mongo: "down", Error: err.Error()}
+	} else if err := h.jobsErr(); err != nil {
+		status = http.StatusServiceUnavailable
+		response = HealthResponse{Status: "degraded", Mongo: "up", Error: err.Error()}
+	}
+	if counter, ok := h.checker.(URLCounter); ok && response.Mongo == "up" {
+		if total, err := counter.EstimatedCount(ctx); err == nil {
+			response.ApproximateTotalURLs = &total
+		} else {
+			slog.WarnContext(ctx, "Could not estimate the URL total for the readiness probe", slog.Any("error", err))
+		}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(response)
+}
+
+// jobsErr returns the error of the first stopped background job, or nil if all are running.
+func (h *HealthHandler) jobsErr() error {
+	for _, name := range slices.Sorted(maps.Keys(h.jobs)) {
+		if err := h.jobs[name].Err(); err != nil {
+			return fmt.Errorf("background job %s stopped: %w", name, err)
+		}
+	}
+	return nil
+}
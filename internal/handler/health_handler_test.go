@@ -0,0 +1,140 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+// fakeHealthChecker is a store.HealthChecker failing with err.
+type fakeHealthChecker struct {
+	err error
+}
+
+func (c fakeHealthChecker) Ping(ctx context.Context) error  { return c.err }
+func (c fakeHealthChecker) Ready(ctx context.Context) error { return c.err }
+
+// fakeJob is a BackgroundJob that has stopped with err, or is running if err is nil.
+type fakeJob struct {
+	err error
+}
+
+func (j fakeJob) Err() error { return j.err }
+
+// newHealthMux returns a mux serving the probes of a HealthHandler at /livez and /readyz.
+func newHealthMux(checker fakeHealthChecker, jobs map[string]BackgroundJob) *http.ServeMux {
+	mux := http.NewServeMux()
+	NewHealthHandler(checker, jobs).RegisterRoutes(mux, "/livez", "/readyz")
+	return mux
+}
+
+func TestLivezIgnoresTheStore(t *testing.T) {
+	unhealthy := newHealthMux(fakeHealthChecker{err: errors.New("server selection timeout")}, map[string]BackgroundJob{"cleaner": fakeJob{err: errors.New("panic: boom")}})
+
+	for _, method := range []string{http.MethodGet, http.MethodHead} {
+		rec := serve(unhealthy, method, "/livez", "", nil)
+		if rec.Code != http.StatusOK {
+			t.Fatalf("%s /livez with an unhealthy store: status = %d, want 200", method, rec.Code)
+		}
+		if rec.Header().Get("Cache-Control") != "no-store" {
+			t.Errorf("%s /livez: Cache-Control = %q, want no-store", method, rec.Header().Get("Cache-Control"))
+		}
+	}
+	var body LivenessResponse
+	if err := json.Unmarshal(serve(unhealthy, http.MethodGet, "/livez", "", nil).Body.Bytes(), &body); err != nil || body.Status != "alive" {
+		t.Errorf("/livez body = %+v (%v), want status alive", body, err)
+	}
+	if rec := serve(unhealthy, http.MethodPost, "/livez", "", nil); rec.Code != http.StatusMethodNotAllowed {
+		t.Errorf("POST /livez: status = %d, want 405", rec.Code)
+	}
+}
+
+func TestReadyz(t *testing.T) {
+	tests := []struct {
+		name       string
+		checker    fakeHealthChecker
+		jobs       map[string]BackgroundJob
+		wantStatus int
+		want       HealthResponse
+	}{
+		{
+			name:       "ready",
+			jobs:       map[string]BackgroundJob{"cleaner": fakeJob{}, "event bus": fakeJob{}},
+			wantStatus: http.StatusOK,
+			want:       HealthResponse{Status: "ok", Mongo: "up"},
+		},
+		{
+			name:       "store down",
+			checker:    fakeHealthChecker{err: errors.New("server selection timeout")},
+			wantStatus: http.StatusServiceUnavailable,
+			want:       HealthResponse{Status: "degraded", Mongo: "down", Error: "server selection timeout"},
+		},
+		{
+			name:       "job stopped",
+			jobs:       map[string]BackgroundJob{"cleaner": fakeJob{}, "event bus": fakeJob{err: errors.New("panic: boom")}},
+			wantStatus: http.StatusServiceUnavailable,
+			want:       HealthResponse{Status: "degraded", Mongo: "up", Error: "background job event bus stopped: panic: boom"},
+		},
+	}
+	for _, tt := range tests {
+		rec := serve(newHealthMux(tt.checker, tt.jobs), http.MethodGet, "/readyz", "", nil)
+		if rec.Code != tt.wantStatus {
+			t.Errorf("%s: status = %d, want %d", tt.name, rec.Code, tt.wantStatus)
+		}
+		var got HealthResponse
+		if err := json.Unmarshal(rec.Body.Bytes(), &got); err != nil {
+			t.Fatalf("%s: body %q is not JSON: %v", tt.name, rec.Body, err)
+		}
+		if got != tt.want {
+			t.Errorf("%s: body = %+v, want %+v", tt.name, got, tt.want)
+		}
+	}
+}
+
+// countingHealthChecker is a ready store.HealthChecker that estimates total URLs, or fails with err.
+type countingHealthChecker struct {
+	fakeHealthChecker
+	total int64
+	err   error
+}
+
+func (c countingHealthChecker) EstimatedCount(ctx context.Context) (int64, error) {
+	return c.total, c.err
+}
+
+func TestReadyzReportsApproximateTotal(t *testing.T) {
+	mux := http.NewServeMux()
+	NewHealthHandler(countingHealthChecker{total: 1234}, nil).RegisterRoutes(mux, "/livez", "/readyz")
+	rec := serve(mux, http.MethodGet, "/readyz", "", nil)
+	var got HealthResponse
+	if err := json.Unmarshal(rec.Body.Bytes(), &got); err != nil {
+		t.Fatalf("body %q is not JSON: %v", rec.Body, err)
+	}
+	if rec.Code != http.StatusOK || got.ApproximateTotalURLs == nil || *got.ApproximateTotalURLs != 1234 {
+		t.Errorf("status = %d, body %s; want 200 with approximate_total_urls 1234", rec.Code, rec.Body)
+	}
+	if strings.Contains(serve(mux, http.MethodGet, "/livez", "", nil).Body.String(), "approximate_total_urls") {
+		t.Error("/livez reports the URL total, want it to check nothing")
+	}
+
+	// A failed estimate does not fail the probe.
+	mux = http.NewServeMux()
+	NewHealthHandler(countingHealthChecker{err: errors.New("estimate timed out")}, nil).RegisterRoutes(mux, "/livez", "/readyz")
+	rec = serve(mux, http.MethodGet, "/readyz", "", nil)
+	if rec.Code != http.StatusOK || strings.Contains(rec.Body.String(), "approximate_total_urls") {
+		t.Errorf("with a failing estimate: status = %d, body %s; want 200 without approximate_total_urls", rec.Code, rec.Body)
+	}
+}
+
+func TestProbesAtConfiguredPaths(t *testing.T) {
+	mux := http.NewServeMux()
+	NewHealthHandler(fakeHealthChecker{}, nil).RegisterRoutes(mux, "/health/live", "/health/ready")
+	for _, path := range []string{"/health/live", "/health/ready"} {
+		if rec := serve(mux, http.MethodGet, path, "", nil); rec.Code != http.StatusOK || !strings.Contains(rec.Body.String(), "status") {
+			t.Errorf("GET %s: status = %d, body %s; want a 200 probe response", path, rec.Code, rec.Body)
+		}
+	}
+}
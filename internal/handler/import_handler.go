@@ -0,0 +1,233 @@
+package handler
+
+import (
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"shawty/internal/service"
+	"shawty/internal/store"
+)
+
+// maxImportBytes caps the size of an import upload, including its multipart framing.
+const maxImportBytes = 10 << 20
+
+// ImportError reports a record of an import file that could not be imported.
+type ImportError struct {
+	Line  int    `json:"line"`
+	Error string `json:"error"`
+}
+
+// ImportResponse summarises an import. Processed counts the records read, which are either
+// created, skipped because they already exist, or reported in Errors.
+type ImportResponse struct {
+	Processed         int           `json:"processed"`
+	Created           int           `json:"created"`
+	SkippedDuplicates int           `json:"skipped_duplicates"`
+	Errors            []ImportError `json:"errors"`
+}
+
+// importRecord is one record of an import file. CustomCode, or failing that ID, keeps the short code
+// of the record, so that a file written by exportHandler restores its links unchanged.
+type importRecord struct {
+	OriginalURL string `json:"original_url"`
+	CustomCode  string `json:"custom_code"`
+	ID          string `json:"id"`
+}
+
+// importHandler bulk-creates short URLs from an uploaded file, e.g. one produced by exportHandler.
+// It expects a multipart/form-data POST to /api/v1/admin/import with the file in the "file" field and
+// an admin bearer token. Files named *.ndjson or *.jsonl hold one JSON object per line; *.csv files
+// start with a header row. Each record needs an original_url and may set custom_code (or id).
+// Records whose URL or code already exists are counted as skipped duplicates, except that a deleted
+// link with the record's code and URL is restored and counted as created.
+func (h *URLHandler) importHandler(w http.ResponseWriter, r *http.Request) {
+	ctx, span := tracer.Start(r.Context(), "handler.ImportURLs")
+	defer span.End()
+	r = r.WithContext(ctx)
+
+	if r.Method != http.MethodPost {
+		WriteError(w, http.StatusMethodNotAllowed, ErrCodeMethodNotAllowed, "Only POST method is allowed")
+		return
+	}
+	if !h.authorizeAdmin(w, r) {
+		return
+	}
+	r = r.WithContext(withAdminActor(r.Context()))
+
+	r.Body = http.MaxBytesReader(w, r.Body, maxImportBytes)
+	if err := r.ParseMultipartForm(maxImportBytes); err != nil {
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			WriteError(w, http.StatusRequestEntityTooLarge, ErrCodePayloadTooLarge, "Import file must be at most 10 MB")
+			return
+		}
+		WriteError(w, http.StatusBadRequest, ErrCodeValidation, "Invalid multipart form: "+err.Error())
+		return
+	}
+	defer r.MultipartForm.RemoveAll()
+
+	file, header, err := r.FormFile("file")
+	if err != nil {
+		WriteError(w, http.StatusBadRequest, ErrCodeValidation, "file field is missing from the form")
+		return
+	}
+	defer file.Close()
+
+	var read func(io.Reader, func(line int, rec importRecord, err error)) error
+	switch strings.ToLower(filepath.Ext(header.Filename)) {
+	case ".ndjson", ".jsonl":
+		read = readNDJSONRecords
+	case ".csv":
+		read = readCSVRecords
+	default:
+		WriteError(w, http.StatusBadRequest, ErrCodeValidation, "file must be named *.ndjson, *.jsonl or *.csv")
+		return
+	}
+
+	// Entries that already existed come back from CreateShortURL with an earlier creation date.
+	started := time.Now().UTC().Truncate(time.Second)
+	response := ImportResponse{Errors: []ImportError{}}
+	err = read(file, func(line int, rec importRecord, err error) {
+		response.Processed++
+		if err == nil {
+			err = h.importOne(r, rec, started, &response)
+		}
+		if err != nil {
+			response.Errors = append(response.Errors, ImportError{Line: line, Error: err.Error()})
+		}
+	})
+	if err != nil {
+		WriteError(w, http.StatusBadRequest, ErrCodeValidation, "Failed to read import file: "+err.Error())
+		return
+	}
+
+	slog.InfoContext(r.Context(), "Imported URLs", slog.String("filename", header.Filename), slog.Int("processed", response.Processed),
+		slog.Int("created", response.Created), slog.Int("skipped_duplicates", response.SkippedDuplicates), slog.Int("errors", len(response.Errors)))
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(response); err != nil {
+		slog.ErrorContext(r.Context(), "Error encoding import response", slog.Any("error", err))
+	}
+}
+
+// importOne creates the short URL of one record and counts the outcome in response.
+// It returns the error to report for the record, if any.
+func (h *URLHandler) importOne(r *http.Request, rec importRecord, started time.Time, response *ImportResponse) error {
+	if rec.OriginalURL == "" {
+		return errors.New("original_url is missing or empty")
+	}
+	customCode := rec.CustomCode
+	if customCode == "" {
+		customCode = rec.ID
+	}
+
+	created, err := h.urlService.CreateShortURL(r.Context(), rec.OriginalURL, service.CreateOptions{CustomCode: customCode})
+	if errors.Is(err, service.ErrCustomCodeTaken) && h.restoreDeleted(r, customCode, rec.OriginalURL) {
+		response.Created++
+		return nil
+	}
+	if errors.Is(err, service.ErrCustomCodeTaken) || (err == nil && created.CreationDate.Before(started)) {
+		response.SkippedDuplicates++
+		return nil
+	}
+	if err != nil {
+		_, _, message := shortenErrorResponse(err, customCode)
+		return errors.New(message)
+	}
+	response.Created++
+	return nil
+}
+
+// restoreDeleted undeletes the link under shortID if it is a deleted link to originalURL, so that
+// importing an export restores the links deleted since. It reports whether the link was restored.
+func (h *URLHandler) restoreDeleted(r *http.Request, shortID, originalURL string) bool {
+	if !store.SoftDelete {
+		return false
+	}
+	if err := h.urlService.UndeleteShortURL(r.Context(), shortID); err != nil {
+		// Most often the code is taken by a live link, which is a plain duplicate.
+		return false
+	}
+	restored, err := h.urlService.GetURLDetails(r.Context(), shortID)
+	if err == nil && restored.OriginalUrl == originalURL {
+		return true
+	}
+	// The deleted link under the code pointed elsewhere and must stay deleted.
+	if err := h.urlService.DeleteShortURL(r.Context(), shortID); err != nil {
+		slog.ErrorContext(r.Context(), "Error deleting a link restored by mistake during import", slog.String("short_id", shortID), slog.Any("error", err))
+	}
+	return false
+}
+
+// readNDJSONRecords calls fn for every non-blank line of an NDJSON file.
+func readNDJSONRecords(in io.Reader, fn func(line int, rec importRecord, err error)) error {
+	scanner := bufio.NewScanner(in)
+	scanner.Buffer(make([]byte, 0, 64<<10), maxImportBytes)
+	for line := 1; scanner.Scan(); line++ {
+		raw := strings.TrimSpace(scanner.Text())
+		if raw == "" {
+			continue
+		}
+		var rec importRecord
+		if err := json.Unmarshal([]byte(raw), &rec); err != nil {
+			fn(line, rec, fmt.Errorf("invalid JSON: %v", err))
+			continue
+		}
+		fn(line, rec, nil)
+	}
+	return scanner.Err()
+}
+
+// readCSVRecords calls fn for every row after the header of a CSV file.
+// Columns are found by their header name; original_url is required.
+func readCSVRecords(in io.Reader, fn func(line int, rec importRecord, err error)) error {
+	reader := csv.NewReader(in)
+	reader.FieldsPerRecord = -1
+	header, err := reader.Read()
+	if err != nil {
+		return fmt.Errorf("missing CSV header: %w", err)
+	}
+	columns := make(map[string]int, len(header))
+	for i, name := range header {
+		columns[strings.TrimSpace(name)] = i
+	}
+	if _, ok := columns["original_url"]; !ok {
+		return errors.New("CSV header has no original_url column")
+	}
+	field := func(row []string, name string) string {
+		if i, ok := columns[name]; ok && i < len(row) {
+			return strings.TrimSpace(row[i])
+		}
+		return ""
+	}
+
+	for {
+		row, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil {
+			var parseErr *csv.ParseError
+			if errors.As(err, &parseErr) {
+				fn(parseErr.StartLine, importRecord{}, err)
+				continue
+			}
+			return err
+		}
+		line, _ := reader.FieldPos(0)
+		fn(line, importRecord{
+			OriginalURL: field(row, "original_url"),
+			CustomCode:  field(row, "custom_code"),
+			ID:          field(row, "id"),
+		}, nil)
+	}
+}
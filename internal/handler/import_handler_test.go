@@ -0,0 +1,136 @@
+package handler
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"shawty/internal/config"
+	"shawty/internal/service"
+	"shawty/internal/store"
+)
+
+// importFile posts content to the import endpoint as the multipart file field named filename.
+func importFile(t *testing.T, handler http.Handler, filename string, content []byte) *httptest.ResponseRecorder {
+	t.Helper()
+	var body bytes.Buffer
+	form := multipart.NewWriter(&body)
+	part, err := form.CreateFormFile("file", filename)
+	if err != nil {
+		t.Fatalf("CreateFormFile: %v", err)
+	}
+	part.Write(content)
+	form.Close()
+
+	header := adminHeader()
+	header.Set("Content-Type", form.FormDataContentType())
+	return serve(handler, http.MethodPost, "/api/v1/admin/import", body.String(), header)
+}
+
+// decodeImport decodes the summary of a successful import.
+func decodeImport(t *testing.T, rec *httptest.ResponseRecorder) ImportResponse {
+	t.Helper()
+	if rec.Code != http.StatusOK {
+		t.Fatalf("import: status = %d, want 200; body %s", rec.Code, rec.Body)
+	}
+	var summary ImportResponse
+	if err := json.Unmarshal(rec.Body.Bytes(), &summary); err != nil {
+		t.Fatalf("decoding import summary: %v", err)
+	}
+	return summary
+}
+
+func TestImportRestoresExport(t *testing.T) {
+	svc, _ := newMemoryService()
+	mux := newTestMux(t, svc, config.AppConfig{AdminToken: testAdminToken})
+	const total = 50
+	ids := make(map[string]string, total)
+	for i := range total {
+		// A public IP literal is validated without a DNS lookup for each of the many links.
+		originalURL := fmt.Sprintf("https://93.184.216.34/restore/%d", i)
+		ids[mustShorten(t, svc, originalURL, service.CreateOptions{})] = originalURL
+	}
+
+	rec := serve(mux, http.MethodGet, "/api/v1/admin/export?format=ndjson", "", adminHeader())
+	if rec.Code != http.StatusOK {
+		t.Fatalf("export: status = %d, want 200; body %s", rec.Code, rec.Body)
+	}
+	export := rec.Body.Bytes()
+
+	for shortID := range ids {
+		if rec := serve(mux, http.MethodDelete, "/api/v1/r/"+shortID, "", adminHeader()); rec.Code != http.StatusNoContent {
+			t.Fatalf("delete %s: status = %d, want 204", shortID, rec.Code)
+		}
+		if rec := serve(mux, http.MethodGet, "/api/v1/r/"+shortID, "", nil); rec.Code == http.StatusFound {
+			t.Fatalf("%s still redirects after delete", shortID)
+		}
+	}
+
+	summary := decodeImport(t, importFile(t, mux, "shawty_export.ndjson", export))
+	if summary.Processed != total || summary.Created != total || summary.SkippedDuplicates != 0 || len(summary.Errors) != 0 {
+		t.Fatalf("summary = %+v, want all %d records created", summary, total)
+	}
+	for shortID, originalURL := range ids {
+		rec := serve(mux, http.MethodGet, "/api/v1/r/"+shortID, "", nil)
+		if rec.Code != http.StatusFound || rec.Header().Get("Location") != originalURL {
+			t.Errorf("%s: status = %d, Location = %q; want 302 to %s", shortID, rec.Code, rec.Header().Get("Location"), originalURL)
+		}
+	}
+
+	// Importing the same file again changes nothing.
+	summary = decodeImport(t, importFile(t, mux, "shawty_export.ndjson", export))
+	if summary.Created != 0 || summary.SkippedDuplicates != total {
+		t.Errorf("second import summary = %+v, want all %d records skipped", summary, total)
+	}
+}
+
+func TestImportCSVReportsBadRecords(t *testing.T) {
+	svc, _ := newMemoryService()
+	mux := newTestMux(t, svc, config.AppConfig{AdminToken: testAdminToken})
+	mustShorten(t, svc, "https://example.com/existing", service.CreateOptions{CustomCode: "existing"})
+
+	csvFile := "original_url,custom_code\n" +
+		"https://example.com/new,fresh-code\n" +
+		"https://example.com/other,existing\n" +
+		",missing-url\n" +
+		"ftp://example.com/file,\n"
+	summary := decodeImport(t, importFile(t, mux, "links.csv", []byte(csvFile)))
+	if summary.Processed != 4 || summary.Created != 1 || summary.SkippedDuplicates != 1 {
+		t.Errorf("summary = %+v, want 4 processed, 1 created and 1 skipped", summary)
+	}
+	if len(summary.Errors) != 2 || summary.Errors[0].Line != 4 || summary.Errors[1].Line != 5 {
+		t.Errorf("errors = %+v, want lines 4 and 5", summary.Errors)
+	}
+	if rec := serve(mux, http.MethodGet, "/api/v1/r/fresh-code", "", nil); rec.Code != http.StatusFound {
+		t.Errorf("imported custom code: status = %d, want 302", rec.Code)
+	}
+
+	if rec := importFile(t, mux, "links.txt", []byte(csvFile)); rec.Code != http.StatusBadRequest {
+		t.Errorf("unknown extension: status = %d, want 400", rec.Code)
+	}
+}
+
+func TestImportKeepsDeletedLinkToOtherURL(t *testing.T) {
+	if !store.SoftDelete {
+		t.Skip("deleted links are removed in harddelete builds")
+	}
+	svc, _ := newMemoryService()
+	mux := newTestMux(t, svc, config.AppConfig{AdminToken: testAdminToken})
+	mustShorten(t, svc, "https://example.com/old", service.CreateOptions{CustomCode: "gone-code"})
+	if rec := serve(mux, http.MethodDelete, "/api/v1/r/gone-code", "", adminHeader()); rec.Code != http.StatusNoContent {
+		t.Fatalf("delete: status = %d, want 204", rec.Code)
+	}
+
+	record := `{"original_url": "https://example.com/new", "custom_code": "gone-code"}` + "\n"
+	summary := decodeImport(t, importFile(t, mux, "links.ndjson", []byte(record)))
+	if summary.Created != 0 || summary.SkippedDuplicates != 1 {
+		t.Errorf("summary = %+v, want the record skipped", summary)
+	}
+	if rec := serve(mux, http.MethodGet, "/api/v1/r/gone-code", "", nil); rec.Code != http.StatusGone {
+		t.Errorf("deleted link: status = %d, want 410", rec.Code)
+	}
+}
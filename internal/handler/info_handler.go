@@ -0,0 +1,97 @@
+package handler
+
+import (
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"shawty/internal/domain"
+	"shawty/internal/middleware"
+	"shawty/internal/tracing"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// URLInfoResponse defines the JSON response for GET /r/{shortID}/info: the stored entry
+// plus fields computed from it.
+type URLInfoResponse struct {
+	domain.URL
+	IsExpired         bool     `json:"is_expired"`
+	IsActive          bool     `json:"is_active"`        // Within the link's activation window, if it has one
+	ClicksRemaining   *int64   `json:"clicks_remaining"` // null for links without a click limit
+	PasswordProtected bool     `json:"password_protected"`
+	Aliases           []string `json:"aliases"` // Other short codes redirecting to this link
+}
+
+// infoETag adds an ETag to info responses and answers revalidations whose If-None-Match still
+// matches with 304 Not Modified.
+var infoETag = middleware.NewETagMiddleware()
+
+// infoHandler returns the full details of a short URL without redirecting or counting a click.
+func (h *URLHandler) infoHandler(w http.ResponseWriter, r *http.Request, shortID string) {
+	ctx, span := tracer.Start(r.Context(), "handler.URLInfo", trace.WithAttributes(attribute.String(tracing.AttrShortID, shortID)))
+	defer span.End()
+	r = r.WithContext(ctx)
+
+	if r.Method != http.MethodGet {
+		w.Header().Set("Allow", http.MethodGet)
+		WriteError(w, http.StatusMethodNotAllowed, ErrCodeMethodNotAllowed, "Only GET method is allowed")
+		return
+	}
+	if shortID == "" {
+		WriteError(w, http.StatusBadRequest, ErrCodeValidation, "Short URL ID is missing in the path")
+		return
+	}
+
+	urlEntry, err := h.urlService.GetURLDetails(r.Context(), shortID)
+	if err != nil {
+		writeLookupError(w, r, shortID, err)
+		return
+	}
+
+	now := time.Now().UTC()
+	response := URLInfoResponse{
+		URL:               urlEntry,
+		IsExpired:         urlEntry.IsExpired(now),
+		IsActive:          urlEntry.IsActive(now),
+		ClicksRemaining:   urlEntry.ClicksRemaining(),
+		PasswordProtected: urlEntry.IsPasswordProtected(),
+		Aliases:           []string{},
+	}
+	// The details are still worth returning without the aliases.
+	if aliases, err := h.urlService.ListAliases(r.Context(), urlEntry.ID); err != nil {
+		slog.WarnContext(r.Context(), "Error listing aliases", slog.String("short_id", urlEntry.ID), slog.Any("error", err))
+	} else {
+		response.Aliases = aliases
+	}
+	// The destination of a protected link is only revealed to visitors who know the password.
+	if response.PasswordProtected {
+		response.OriginalUrl = ""
+		response.SubmittedUrl = ""
+		response.MobileURL = nil
+		response.Variants = nil
+		response.PageTitle = ""
+		response.PageDescription = ""
+	}
+	// The webhook belongs to the link's creator, while this endpoint is public.
+	response.WebhookURL = ""
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Last-Modified", lastModified(urlEntry).Format(http.TimeFormat))
+	if err := json.NewEncoder(w).Encode(response); err != nil {
+		slog.ErrorContext(r.Context(), "Error encoding URL info response", slog.String("short_id", shortID), slog.Any("error", err))
+	}
+}
+
+// lastModified returns the latest time at which urlEntry was created, changed or clicked.
+func lastModified(urlEntry domain.URL) time.Time {
+	latest := urlEntry.CreationDate
+	for _, t := range []*time.Time{urlEntry.UpdatedAt, urlEntry.LastAccessedAt} {
+		if t != nil && t.After(latest) {
+			latest = *t
+		}
+	}
+	return latest.UTC()
+}
@@ -0,0 +1,39 @@
+package handler
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+
+	"shawty/internal/config"
+	"shawty/internal/middleware"
+)
+
+// paddedBody returns the JSON object {"url": originalURL} padded with spaces before its closing
+// brace to exactly size bytes, so that a decoder must read every byte to finish it.
+func paddedBody(t *testing.T, originalURL string, size int) string {
+	t.Helper()
+	head := `{"url": "` + originalURL + `"`
+	if len(head)+1 > size {
+		t.Fatalf("size %d is too small for %s", size, head)
+	}
+	return head + strings.Repeat(" ", size-len(head)-1) + "}"
+}
+
+func TestMaxBodyMiddlewareRejectsBodiesOverTheLimit(t *testing.T) {
+	const limit = 4 << 10
+	svc, _ := newMemoryService()
+	handler := middleware.NewMaxBodyMiddleware(limit)(newTestMux(t, svc, config.AppConfig{}))
+
+	if rec := serve(handler, http.MethodPost, "/api/v1/shorten", paddedBody(t, "https://93.184.216.34/fits", limit), nil); rec.Code != http.StatusCreated {
+		t.Errorf("body of exactly %d bytes: status = %d, want 201; body %s", limit, rec.Code, rec.Body)
+	}
+
+	rec := serve(handler, http.MethodPost, "/api/v1/shorten", paddedBody(t, "https://93.184.216.34/too-big", limit+1), nil)
+	if rec.Code != http.StatusRequestEntityTooLarge {
+		t.Fatalf("body of %d bytes: status = %d, want 413; body %s", limit+1, rec.Code, rec.Body)
+	}
+	if code := errorCode(t, rec); code != ErrCodePayloadTooLarge {
+		t.Errorf("code = %q, want %q", code, ErrCodePayloadTooLarge)
+	}
+}
@@ -0,0 +1,104 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"testing"
+
+	"shawty/internal/config"
+	"shawty/internal/store"
+)
+
+func TestPasswordProtectedLinks(t *testing.T) {
+	svc, memStore := newMemoryService()
+	mux := newTestMux(t, svc, config.AppConfig{AdminToken: testAdminToken})
+	const password = "s3cret-pass"
+
+	rec := serve(mux, http.MethodPost, "/api/v1/shorten", `{"url": "https://example.com/private", "password": "`+password+`"}`, nil)
+	if rec.Code != http.StatusCreated {
+		t.Fatalf("shorten: status = %d, want 201; body %s", rec.Code, rec.Body)
+	}
+	var created ShortenURLResponse
+	if err := json.Unmarshal(rec.Body.Bytes(), &created); err != nil {
+		t.Fatalf("decoding shorten response: %v", err)
+	}
+	shortID := created.ShortURL[strings.LastIndex(created.ShortURL, "/")+1:]
+	stored, err := memStore.GetByShortID(context.Background(), shortID, store.AnyTenant)
+	if err != nil {
+		t.Fatalf("GetByShortID: %v", err)
+	}
+	if stored.PasswordHash == "" || stored.PasswordHash == password {
+		t.Fatalf("stored password hash = %q, want a bcrypt hash", stored.PasswordHash)
+	}
+	responses := map[string]string{"shorten": rec.Body.String()}
+
+	t.Run("redirect", func(t *testing.T) {
+		tests := []struct {
+			name       string
+			password   string
+			wantStatus int
+		}{
+			{name: "without a password", wantStatus: http.StatusUnauthorized},
+			{name: "wrong password", password: "guess", wantStatus: http.StatusUnauthorized},
+			{name: "the hash as the password", password: stored.PasswordHash, wantStatus: http.StatusUnauthorized},
+			{name: "correct password", password: password, wantStatus: http.StatusFound},
+		}
+		for _, tt := range tests {
+			t.Run(tt.name, func(t *testing.T) {
+				header := http.Header{}
+				if tt.password != "" {
+					header.Set(passwordHeader, tt.password)
+				}
+				rec := serve(mux, http.MethodGet, "/api/v1/r/"+shortID, "", header)
+				if rec.Code != tt.wantStatus {
+					t.Fatalf("status = %d, want %d; body %s", rec.Code, tt.wantStatus, rec.Body)
+				}
+				if tt.wantStatus == http.StatusUnauthorized {
+					if code := errorCode(t, rec); code != ErrCodeUnauthorized {
+						t.Errorf("error code = %q, want %q", code, ErrCodeUnauthorized)
+					}
+					if location := rec.Header().Get("Location"); location != "" {
+						t.Errorf("Location = %q on a refused redirect", location)
+					}
+					return
+				}
+				if location := rec.Header().Get("Location"); location != "https://example.com/private" {
+					t.Errorf("Location = %q, want https://example.com/private", location)
+				}
+			})
+		}
+	})
+
+	rec = serve(mux, http.MethodGet, "/api/v1/r/"+shortID+"/info", "", nil)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("info: status = %d, want 200; body %s", rec.Code, rec.Body)
+	}
+	var info URLInfoResponse
+	if err := json.Unmarshal(rec.Body.Bytes(), &info); err != nil {
+		t.Fatalf("decoding info response: %v", err)
+	}
+	if !info.PasswordProtected || info.OriginalUrl != "" {
+		t.Errorf("info = %+v, want a protected link without its destination", info)
+	}
+	responses["info"] = rec.Body.String()
+
+	rec = serve(mux, http.MethodGet, "/api/v1/admin/urls", "", adminHeader())
+	if rec.Code != http.StatusOK {
+		t.Fatalf("list: status = %d, want 200; body %s", rec.Code, rec.Body)
+	}
+	if !strings.Contains(rec.Body.String(), shortID) {
+		t.Fatalf("list %s does not include %s", rec.Body, shortID)
+	}
+	responses["list"] = rec.Body.String()
+
+	// Neither the hash nor the password is ever sent back.
+	for name, body := range responses {
+		for _, secret := range []string{stored.PasswordHash, password, "password_hash", "$2a$"} {
+			if strings.Contains(body, secret) {
+				t.Errorf("%s response contains %q: %s", name, secret, body)
+			}
+		}
+	}
+}
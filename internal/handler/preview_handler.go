@@ -0,0 +1,149 @@
+package handler
+
+import (
+	"embed"
+	"encoding/json"
+	"errors"
+	"html/template"
+	"log/slog"
+	"net/http"
+	"strings"
+)
+
+// PreviewResponse defines the JSON response for a link preview.
+type PreviewResponse struct {
+	Destination string `json:"destination"`
+	Title       string `json:"title"`
+	Description string `json:"description"`
+	OGImage     string `json:"og_image"`
+}
+
+// previewTemplate renders a preview for browsers. html/template escapes every field,
+// including the image URL, so page metadata cannot inject markup.
+var previewTemplate = template.Must(template.New("preview").Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<meta name="viewport" content="width=device-width, initial-scale=1">
+<title>Preview: {{if .Title}}{{.Title}}{{else}}{{.Destination}}{{end}}</title>
+<link rel="stylesheet" href="/static/preview.css">
+<script src="/static/preview.js" defer></script>
+</head>
+<body>
+<main class="preview">
+  {{if .OGImage}}<img src="{{.OGImage}}" alt="" class="preview-image">{{end}}
+  <h1>{{if .Title}}{{.Title}}{{else}}{{.Destination}}{{end}}</h1>
+  {{if .Description}}<p>{{.Description}}</p>{{end}}
+  <p>This link goes to <code>{{.Destination}}</code> <button id="copy-destination" type="button" data-destination="{{.Destination}}" hidden>Copy</button></p>
+  <p><a href="{{.Destination}}" rel="noopener noreferrer">Continue to destination</a></p>
+</main>
+</body>
+</html>
+`))
+
+// previewHandler shows where a short URL leads, with the destination page's title,
+// description and image, instead of redirecting. It expects URLs in the format /preview/{shortID}.
+// Browsers asking for text/html get a page; other clients get JSON.
+func (h *URLHandler) previewHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		WriteError(w, http.StatusMethodNotAllowed, ErrCodeMethodNotAllowed, "Only GET method is allowed")
+		return
+	}
+
+	shortID := pathParam(r)
+	if shortID == "" {
+		WriteError(w, http.StatusBadRequest, ErrCodeValidation, "Short URL ID is missing in the path")
+		return
+	}
+
+	urlEntry, err := h.urlService.ResolveShortURL(r.Context(), shortID)
+	if err != nil {
+		writeLookupError(w, r, shortID, err)
+		return
+	}
+	// A preview reveals the destination, so it is protected like the redirect.
+	if !checkLinkPassword(w, r, urlEntry) {
+		return
+	}
+	originalURL := urlEntry.OriginalUrl
+
+	response := PreviewResponse{Destination: originalURL}
+	// A page that cannot be fetched still gets a preview showing where the link goes.
+	meta, err := h.urlService.FetchURLMetadata(r.Context(), originalURL)
+	if err != nil {
+		slog.WarnContext(r.Context(), "Error fetching URL metadata", slog.String("short_id", shortID), slog.String("original_url", originalURL), slog.Any("error", err))
+	} else {
+		response.Title = meta.Title
+		response.Description = meta.Description
+		response.OGImage = meta.Image
+	}
+
+	if strings.Contains(r.Header.Get("Accept"), "text/html") {
+		pushPreviewAssets(w, r)
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		if err := previewTemplate.Execute(w, response); err != nil {
+			slog.ErrorContext(r.Context(), "Error rendering preview page", slog.String("short_id", shortID), slog.Any("error", err))
+		}
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(response); err != nil {
+		slog.ErrorContext(r.Context(), "Error encoding preview response", slog.String("short_id", shortID), slog.Any("error", err))
+	}
+}
+
+// assetFiles holds the stylesheet and script of the preview page.
+//
+//go:embed assets
+var assetFiles embed.FS
+
+// assetsFS is the embedded assets directory with the "assets/" prefix removed.
+var assetsFS = mustSub(assetFiles, "assets")
+
+// serveAsset serves the embedded preview page assets under /static/.
+func (h *URLHandler) serveAsset(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		WriteError(w, http.StatusMethodNotAllowed, ErrCodeMethodNotAllowed, "Only GET method is allowed")
+		return
+	}
+	// Embedded files carry no modification time, so browsers are told how long to keep them instead.
+	w.Header().Set("Cache-Control", "public, max-age=3600")
+	http.StripPrefix("/static/", http.FileServerFS(assetsFS)).ServeHTTP(w, r)
+}
+
+// previewAssets are the files the preview page links to, pushed along with it over HTTP/2.
+var previewAssets = []string{"/static/preview.css", "/static/preview.js"}
+
+// pushPreviewAssets sends the preview page's assets before the page itself when the connection
+// supports HTTP/2 server push, so the browser has them by the time it parses the HTML.
+// Over HTTP/1.1, or when the client has disabled push, it does nothing.
+func pushPreviewAssets(w http.ResponseWriter, r *http.Request) {
+	pusher, ok := findPusher(w)
+	if !ok {
+		return
+	}
+	for _, asset := range previewAssets {
+		if err := pusher.Push(asset, nil); err != nil {
+			if !errors.Is(err, http.ErrNotSupported) {
+				slog.DebugContext(r.Context(), "Error pushing preview asset", slog.String("path", asset), slog.Any("error", err))
+			}
+			return
+		}
+	}
+}
+
+// findPusher returns the http.Pusher of w, looking through the middleware's response writer
+// wrappers via their Unwrap methods, as http.ResponseController does for flushing.
+func findPusher(w http.ResponseWriter) (http.Pusher, bool) {
+	for {
+		if pusher, ok := w.(http.Pusher); ok {
+			return pusher, true
+		}
+		unwrapper, ok := w.(interface{ Unwrap() http.ResponseWriter })
+		if !ok {
+			return nil, false
+		}
+		w = unwrapper.Unwrap()
+	}
+}
@@ -0,0 +1,150 @@
+package handler
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"shawty/internal/config"
+	"shawty/internal/domain"
+	"shawty/internal/service"
+
+	"golang.org/x/net/http2"
+	"golang.org/x/net/http2/hpack"
+)
+
+// previewDestination is a destination whose metadata fetch fails at once: the metadata client
+// refuses loopback addresses, so the preview renders without reaching the network. Shortening
+// refuses them too, so newPreviewService stores the link directly.
+const previewDestination = "http://127.0.0.1:1/landing"
+
+// newPreviewService returns a UrlService holding the link "prev01" to previewDestination.
+func newPreviewService(t *testing.T) *service.UrlService {
+	t.Helper()
+	svc, memStore := newMemoryService()
+	entry := domain.URL{ID: "prev01", ShortUrl: "prev01", OriginalUrl: previewDestination, CreationDate: time.Now().UTC()}
+	if err := memStore.Save(context.Background(), entry); err != nil {
+		t.Fatalf("Save: %v", err)
+	}
+	return svc
+}
+
+// requestPushes sends GET target with Accept: text/html over a raw HTTP/2 connection to server and
+// returns the paths of the PUSH_PROMISE frames received before the response stream ended, with the
+// response body.
+func requestPushes(t *testing.T, server *httptest.Server, target string) ([]string, string) {
+	t.Helper()
+	conn, err := tls.Dial("tcp", server.Listener.Addr().String(), &tls.Config{InsecureSkipVerify: true, NextProtos: []string{http2.NextProtoTLS}})
+	if err != nil {
+		t.Fatalf("dialing the server: %v", err)
+	}
+	defer conn.Close()
+	if proto := conn.ConnectionState().NegotiatedProtocol; proto != http2.NextProtoTLS {
+		t.Fatalf("negotiated protocol %q, want %s", proto, http2.NextProtoTLS)
+	}
+	conn.SetDeadline(time.Now().Add(5 * time.Second))
+
+	if _, err := io.WriteString(conn, http2.ClientPreface); err != nil {
+		t.Fatalf("writing the client preface: %v", err)
+	}
+	framer := http2.NewFramer(conn, conn)
+	if err := framer.WriteSettings(http2.Setting{ID: http2.SettingEnablePush, Val: 1}); err != nil {
+		t.Fatalf("writing settings: %v", err)
+	}
+
+	var headers bytes.Buffer
+	encoder := hpack.NewEncoder(&headers)
+	for _, field := range []hpack.HeaderField{
+		{Name: ":method", Value: http.MethodGet},
+		{Name: ":scheme", Value: "https"},
+		{Name: ":authority", Value: server.Listener.Addr().String()},
+		{Name: ":path", Value: target},
+		{Name: "accept", Value: "text/html"},
+	} {
+		encoder.WriteField(field)
+	}
+	if err := framer.WriteHeaders(http2.HeadersFrameParam{StreamID: 1, BlockFragment: headers.Bytes(), EndStream: true, EndHeaders: true}); err != nil {
+		t.Fatalf("writing the request: %v", err)
+	}
+
+	var pushed []string
+	var body bytes.Buffer
+	decoder := hpack.NewDecoder(4096, nil)
+	for {
+		frame, err := framer.ReadFrame()
+		if err != nil {
+			t.Fatalf("reading frames: %v (pushed %v so far)", err, pushed)
+		}
+		switch f := frame.(type) {
+		case *http2.SettingsFrame:
+			if !f.IsAck() {
+				framer.WriteSettingsAck()
+			}
+		case *http2.PushPromiseFrame:
+			fields, err := decoder.DecodeFull(f.HeaderBlockFragment())
+			if err != nil {
+				t.Fatalf("decoding a push promise: %v", err)
+			}
+			for _, field := range fields {
+				if field.Name == ":path" {
+					pushed = append(pushed, field.Value)
+				}
+			}
+		case *http2.HeadersFrame:
+			if _, err := decoder.DecodeFull(f.HeaderBlockFragment()); err != nil {
+				t.Fatalf("decoding response headers: %v", err)
+			}
+			if f.StreamID == 1 && f.StreamEnded() {
+				return pushed, body.String()
+			}
+		case *http2.DataFrame:
+			if f.StreamID == 1 {
+				body.Write(f.Data())
+				if f.StreamEnded() {
+					return pushed, body.String()
+				}
+			}
+		case *http2.GoAwayFrame:
+			t.Fatalf("server sent GOAWAY: %v", f.ErrCode)
+		}
+	}
+}
+
+func TestPreviewPushesAssetsOverHTTP2(t *testing.T) {
+	server := httptest.NewUnstartedServer(newTestMux(t, newPreviewService(t), config.AppConfig{}))
+	server.EnableHTTP2 = true
+	server.StartTLS()
+	defer server.Close()
+
+	pushed, body := requestPushes(t, server, "/api/v1/preview/prev01")
+	if !slices.Equal(pushed, previewAssets) {
+		t.Errorf("pushed %v, want %v", pushed, previewAssets)
+	}
+	if !strings.Contains(body, previewDestination) {
+		t.Errorf("preview page does not show the destination:\n%s", body)
+	}
+}
+
+func TestPreviewWithoutPush(t *testing.T) {
+	mux := newTestMux(t, newPreviewService(t), config.AppConfig{})
+
+	// httptest.ResponseRecorder, like an HTTP/1.1 connection, is no http.Pusher.
+	rec := serve(mux, http.MethodGet, "/api/v1/preview/prev01", "", http.Header{"Accept": {"text/html"}})
+	if rec.Code != http.StatusOK || !strings.Contains(rec.Body.String(), `href="/static/preview.css"`) {
+		t.Fatalf("preview page: status = %d, want 200 linking the stylesheet; body %s", rec.Code, rec.Body)
+	}
+
+	for _, asset := range previewAssets {
+		rec := serve(mux, http.MethodGet, asset, "", nil)
+		if rec.Code != http.StatusOK || rec.Body.Len() == 0 {
+			t.Errorf("GET %s: status = %d with %d bytes, want the embedded asset", asset, rec.Code, rec.Body.Len())
+		}
+	}
+}
@@ -0,0 +1,62 @@
+package handler
+
+import (
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strconv"
+
+	"github.com/skip2/go-qrcode"
+)
+
+// QR code size bounds, in pixels.
+const (
+	defaultQRSize = 256
+	minQRSize     = 64
+	maxQRSize     = 1024
+)
+
+// qrHandler serves a PNG QR code that encodes the full short URL.
+// It expects URLs in the format /qr/{shortID}?size=256.
+func (h *URLHandler) qrHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		WriteError(w, http.StatusMethodNotAllowed, ErrCodeMethodNotAllowed, "Only GET method is allowed")
+		return
+	}
+
+	shortID := pathParam(r)
+	if shortID == "" {
+		WriteError(w, http.StatusBadRequest, ErrCodeValidation, "Short URL ID is missing in the path")
+		return
+	}
+
+	size := defaultQRSize
+	if raw := r.URL.Query().Get("size"); raw != "" {
+		parsed, err := strconv.Atoi(raw)
+		if err != nil || parsed < minQRSize || parsed > maxQRSize {
+			WriteError(w, http.StatusBadRequest, ErrCodeValidation, fmt.Sprintf("size must be an integer between %d and %d", minQRSize, maxQRSize))
+			return
+		}
+		size = parsed
+	}
+
+	if _, err := h.urlService.GetOriginalURL(r.Context(), shortID); err != nil {
+		writeLookupError(w, r, shortID, err)
+		return
+	}
+
+	shortURL := h.shortLink(r, shortID)
+	png, err := qrcode.Encode(shortURL, qrcode.Medium, size)
+	if err != nil {
+		slog.ErrorContext(r.Context(), "Error generating QR code", slog.String("short_id", shortID), slog.Any("error", err))
+		WriteError(w, http.StatusInternalServerError, ErrCodeInternal, "Failed to generate QR code")
+		return
+	}
+
+	w.Header().Set("Content-Type", "image/png")
+	w.Header().Set("Cache-Control", "public, max-age=86400")
+	w.Header().Set("Content-Length", strconv.Itoa(len(png)))
+	if _, err := w.Write(png); err != nil {
+		slog.ErrorContext(r.Context(), "Error writing QR code", slog.String("short_id", shortID), slog.Any("error", err))
+	}
+}
@@ -0,0 +1,42 @@
+package handler
+
+import (
+	"bytes"
+	"image/png"
+	"net/http"
+	"testing"
+
+	"shawty/internal/config"
+	"shawty/internal/service"
+)
+
+func TestQRHandler(t *testing.T) {
+	svc, _ := newMemoryService()
+	shortID := mustShorten(t, svc, "https://example.com/qr", service.CreateOptions{})
+	mux := newTestMux(t, svc, config.AppConfig{})
+
+	rec := serve(mux, http.MethodGet, "/api/v1/qr/"+shortID+"?size=128", "", nil)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d, want 200; body %s", rec.Code, rec.Body)
+	}
+	if got := rec.Header().Get("Content-Type"); got != "image/png" {
+		t.Errorf("Content-Type = %q, want image/png", got)
+	}
+	if !bytes.HasPrefix(rec.Body.Bytes(), []byte("\x89PNG")) {
+		t.Fatalf("body does not start with the PNG signature: % x", rec.Body.Bytes()[:min(8, rec.Body.Len())])
+	}
+	img, err := png.DecodeConfig(bytes.NewReader(rec.Body.Bytes()))
+	if err != nil {
+		t.Fatalf("decoding PNG: %v", err)
+	}
+	if img.Width != 128 || img.Height != 128 {
+		t.Errorf("image is %dx%d, want 128x128", img.Width, img.Height)
+	}
+
+	if rec := serve(mux, http.MethodGet, "/api/v1/qr/missing", "", nil); rec.Code != http.StatusNotFound {
+		t.Errorf("unknown ID: status = %d, want 404", rec.Code)
+	}
+	if rec := serve(mux, http.MethodGet, "/api/v1/qr/"+shortID+"?size=9999", "", nil); rec.Code != http.StatusBadRequest {
+		t.Errorf("oversized QR code: status = %d, want 400", rec.Code)
+	}
+}
@@ -0,0 +1,83 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+	"strings"
+
+	"shawty/internal/domain"
+	"shawty/internal/service"
+)
+
+// QuotaResponse defines the JSON response for a tenant's quota. Limit is 0 for tenants without one.
+type QuotaResponse struct {
+	Used  int64 `json:"used"`
+	Limit int64 `json:"limit"`
+}
+
+// UpdateQuotaRequest defines the expected JSON body for changing a tenant's quota.
+type UpdateQuotaRequest struct {
+	Limit *int64 `json:"limit"`
+}
+
+// tenantQuotaHandler reads or changes how many links a tenant may create. It expects a GET or a
+// PATCH request like /api/v1/admin/tenants/{ownerID}/quota with an admin bearer token, where
+// ownerID is the owner name of the tenant's API key. PATCH takes a JSON body like {"limit": 1000};
+// a limit of 0 removes the quota.
+func (h *URLHandler) tenantQuotaHandler(w http.ResponseWriter, r *http.Request) {
+	ctx, span := tracer.Start(r.Context(), "handler.TenantQuota")
+	defer span.End()
+	r = r.WithContext(ctx)
+
+	ownerID, ok := strings.CutSuffix(pathParam(r), "/quota")
+	if !ok || ownerID == "" || strings.Contains(ownerID, "/") {
+		http.NotFound(w, r)
+		return
+	}
+	if r.Method != http.MethodGet && r.Method != http.MethodPatch {
+		w.Header().Set("Allow", "GET, PATCH")
+		WriteError(w, http.StatusMethodNotAllowed, ErrCodeMethodNotAllowed, "Only GET and PATCH methods are allowed")
+		return
+	}
+	if !h.authorizeAdmin(w, r) {
+		return
+	}
+
+	var (
+		tenant domain.Tenant
+		err    error
+	)
+	if r.Method == http.MethodGet {
+		tenant, err = h.urlService.GetTenantQuota(r.Context(), ownerID)
+	} else {
+		var req UpdateQuotaRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			writeBodyError(w, err)
+			return
+		}
+		if req.Limit == nil {
+			WriteError(w, http.StatusBadRequest, ErrCodeValidation, "limit field is missing in request body")
+			return
+		}
+		tenant, err = h.urlService.SetTenantQuota(r.Context(), ownerID, *req.Limit)
+	}
+	if err != nil {
+		switch {
+		case errors.Is(err, service.ErrQuotasUnavailable):
+			WriteError(w, http.StatusNotImplemented, ErrCodeNotImplemented, "Tenant quotas are not available with this store")
+		case errors.Is(err, service.ErrInvalidQuota):
+			WriteError(w, http.StatusBadRequest, ErrCodeValidation, "limit must be 0 (unlimited) or a positive number of links")
+		default:
+			slog.ErrorContext(r.Context(), "Error handling tenant quota", slog.String("tenant_id", ownerID), slog.String("method", r.Method), slog.Any("error", err))
+			WriteError(w, http.StatusInternalServerError, ErrCodeInternal, "Failed to handle tenant quota")
+		}
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(QuotaResponse{Used: tenant.URLCount, Limit: tenant.TenantQuota}); err != nil {
+		slog.ErrorContext(r.Context(), "Error encoding quota response", slog.String("tenant_id", ownerID), slog.Any("error", err))
+	}
+}
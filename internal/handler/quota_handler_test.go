@@ -0,0 +1,91 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sync"
+	"testing"
+
+	"shawty/internal/config"
+	"shawty/internal/domain"
+	"shawty/internal/middleware"
+	"shawty/internal/service"
+)
+
+// fakeQuotaStore keeps tenants in a map, as the MongoDB tenants collection does.
+type fakeQuotaStore struct {
+	mu      sync.Mutex
+	tenants map[string]domain.Tenant
+}
+
+func (s *fakeQuotaStore) IncrementTenantURLCount(ctx context.Context, tenantID string, delta int64) (domain.Tenant, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	tenant := s.tenants[tenantID]
+	tenant.ID = tenantID
+	tenant.URLCount += delta
+	s.tenants[tenantID] = tenant
+	return tenant, nil
+}
+
+func (s *fakeQuotaStore) GetTenant(ctx context.Context, tenantID string) (domain.Tenant, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	tenant := s.tenants[tenantID]
+	tenant.ID = tenantID
+	return tenant, nil
+}
+
+func (s *fakeQuotaStore) SetTenantQuota(ctx context.Context, tenantID string, quota int64) (domain.Tenant, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	tenant := s.tenants[tenantID]
+	tenant.ID = tenantID
+	tenant.TenantQuota = quota
+	s.tenants[tenantID] = tenant
+	return tenant, nil
+}
+
+func TestShortenStopsAtTenantQuota(t *testing.T) {
+	quotas := &fakeQuotaStore{tenants: make(map[string]domain.Tenant)}
+	svc, _ := newMemoryService(service.WithQuotaStore(quotas))
+	keys := map[string]string{"key-a": "tenant-a", "key-b": "tenant-b", testAdminToken: AdminOwner}
+	api := middleware.NewAPIKeyMiddleware(keys)(newTestMux(t, svc, config.AppConfig{AdminToken: testAdminToken}))
+	bearer := func(key string) http.Header { return http.Header{"Authorization": {"Bearer " + key}} }
+
+	if rec := serve(api, http.MethodPatch, "/api/v1/admin/tenants/tenant-a/quota", `{"limit": 2}`, adminHeader()); rec.Code != http.StatusOK {
+		t.Fatalf("set quota: status = %d, want 200; body %s", rec.Code, rec.Body)
+	}
+
+	shorten := func(key string, i int) int {
+		body := fmt.Sprintf(`{"url": "https://93.184.216.34/%d"}`, i)
+		return serve(api, http.MethodPost, "/api/v1/shorten", body, bearer(key)).Code
+	}
+	for i := range 2 {
+		if status := shorten("key-a", i); status != http.StatusCreated {
+			t.Fatalf("create %d: status = %d, want 201", i+1, status)
+		}
+	}
+	rec := serve(api, http.MethodPost, "/api/v1/shorten", `{"url": "https://93.184.216.34/2"}`, bearer("key-a"))
+	if rec.Code != http.StatusTooManyRequests {
+		t.Fatalf("create 3: status = %d, want 429; body %s", rec.Code, rec.Body)
+	}
+	if code := errorCode(t, rec); code != ErrCodeQuotaExceeded {
+		t.Errorf("code = %q, want %q", code, ErrCodeQuotaExceeded)
+	}
+	// Another tenant has no quota.
+	if status := shorten("key-b", 3); status != http.StatusCreated {
+		t.Errorf("other tenant: status = %d, want 201", status)
+	}
+
+	rec = serve(api, http.MethodGet, "/api/v1/admin/tenants/tenant-a/quota", "", adminHeader())
+	var quota QuotaResponse
+	if err := json.Unmarshal(rec.Body.Bytes(), &quota); err != nil {
+		t.Fatalf("decoding quota: %v", err)
+	}
+	if quota.Limit != 2 || quota.Used != 2 {
+		t.Errorf("quota = %+v, want limit 2 with 2 used; the refused link must not be counted", quota)
+	}
+}
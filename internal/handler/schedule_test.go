@@ -0,0 +1,93 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"testing"
+	"time"
+
+	"shawty/internal/config"
+	"shawty/internal/domain"
+)
+
+func TestRedirectRespectsActivationWindow(t *testing.T) {
+	svc, memStore := newMemoryService()
+	mux := newTestMux(t, svc, config.AppConfig{})
+	now := time.Now().UTC()
+	hourAgo, inAnHour := now.Add(-time.Hour), now.Add(time.Hour)
+
+	tests := []struct {
+		id           string
+		from, until  *time.Time
+		wantStatus   int
+		wantIsActive bool
+	}{
+		{id: "notyet", from: &inAnHour, wantStatus: http.StatusNotFound},
+		{id: "window", from: &hourAgo, until: &inAnHour, wantStatus: http.StatusFound, wantIsActive: true},
+		{id: "ended0", until: &hourAgo, wantStatus: http.StatusGone},
+		{id: "fromonly", from: &hourAgo, wantStatus: http.StatusFound, wantIsActive: true},
+		{id: "untilonly", until: &inAnHour, wantStatus: http.StatusFound, wantIsActive: true},
+		{id: "unbound", wantStatus: http.StatusFound, wantIsActive: true},
+	}
+	for _, tt := range tests {
+		entry := domain.URL{ID: tt.id, ShortUrl: tt.id, OriginalUrl: "https://example.com/sale", CreationDate: now.Add(-2 * time.Hour), ActiveFrom: tt.from, ActiveUntil: tt.until}
+		if err := memStore.Save(context.Background(), entry); err != nil {
+			t.Fatalf("Save: %v", err)
+		}
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.id, func(t *testing.T) {
+			rec := serve(mux, http.MethodGet, "/api/v1/r/"+tt.id, "", nil)
+			if rec.Code != tt.wantStatus {
+				t.Fatalf("redirect: status = %d, want %d; body %s", rec.Code, tt.wantStatus, rec.Body)
+			}
+			if tt.wantStatus == http.StatusFound && rec.Header().Get("Location") != "https://example.com/sale" {
+				t.Errorf("Location = %q, want https://example.com/sale", rec.Header().Get("Location"))
+			}
+
+			rec = serve(mux, http.MethodGet, "/api/v1/r/"+tt.id+"/info", "", nil)
+			if rec.Code != http.StatusOK {
+				t.Fatalf("info: status = %d, want 200; body %s", rec.Code, rec.Body)
+			}
+			var info URLInfoResponse
+			if err := json.Unmarshal(rec.Body.Bytes(), &info); err != nil {
+				t.Fatalf("decoding info: %v", err)
+			}
+			if info.IsActive != tt.wantIsActive {
+				t.Errorf("is_active = %t, want %t", info.IsActive, tt.wantIsActive)
+			}
+		})
+	}
+}
+
+func TestShortenWithActivationWindow(t *testing.T) {
+	svc, _ := newMemoryService()
+	mux := newTestMux(t, svc, config.AppConfig{})
+	from := time.Now().UTC().Add(time.Hour).Truncate(time.Second)
+	until := from.Add(24 * time.Hour)
+
+	body := `{"url": "https://example.com/flash-sale", "active_from": "` + from.Format(time.RFC3339) + `", "active_until": "` + until.Format(time.RFC3339) + `"}`
+	rec := serve(mux, http.MethodPost, "/api/v1/shorten", body, nil)
+	if rec.Code != http.StatusCreated {
+		t.Fatalf("shorten: status = %d, want 201; body %s", rec.Code, rec.Body)
+	}
+	var resp ShortenURLResponse
+	if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("decoding response: %v", err)
+	}
+	if resp.ActiveFrom != from.Format(time.RFC3339) || resp.ActiveUntil != until.Format(time.RFC3339) {
+		t.Errorf("active_from = %q, active_until = %q; want %s and %s", resp.ActiveFrom, resp.ActiveUntil, from.Format(time.RFC3339), until.Format(time.RFC3339))
+	}
+
+	invalid := map[string]string{
+		"end in the past":     `{"url": "https://example.com/x", "active_until": "2001-01-01T00:00:00Z"}`,
+		"start after the end": `{"url": "https://example.com/x", "active_from": "` + until.Format(time.RFC3339) + `", "active_until": "` + from.Format(time.RFC3339) + `"}`,
+	}
+	for name, body := range invalid {
+		if rec := serve(mux, http.MethodPost, "/api/v1/shorten", body, nil); rec.Code != http.StatusBadRequest {
+			t.Errorf("%s: status = %d, want 400; body %s", name, rec.Code, rec.Body)
+		}
+	}
+}
@@ -0,0 +1,79 @@
+package handler
+
+import (
+	"encoding/xml"
+	"io"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"shawty/internal/service"
+)
+
+// robotsTxt keeps crawlers away from the short links and the API.
+const robotsTxt = "User-agent: *\nDisallow: /r/\nDisallow: /api/\nAllow: /\n"
+
+// sitemapNamespace is the XML namespace of the sitemap protocol.
+const sitemapNamespace = "http://www.sitemaps.org/schemas/sitemap/0.9"
+
+// sitemapURLSet is the root element of a sitemap.
+type sitemapURLSet struct {
+	XMLName xml.Name     `xml:"urlset"`
+	Xmlns   string       `xml:"xmlns,attr"`
+	URLs    []sitemapURL `xml:"url"`
+}
+
+// sitemapURL is a single entry of a sitemap.
+type sitemapURL struct {
+	Loc     string `xml:"loc"`
+	LastMod string `xml:"lastmod"`
+}
+
+// robotsHandler serves /robots.txt, ending with a Sitemap line when SITEMAP_URL is set.
+func (h *URLHandler) robotsHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		WriteError(w, http.StatusMethodNotAllowed, ErrCodeMethodNotAllowed, "Only GET method is allowed")
+		return
+	}
+
+	body := robotsTxt
+	if h.cfg.SitemapURL != "" {
+		body += "Sitemap: " + h.cfg.SitemapURL + "\n"
+	}
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	_, _ = io.WriteString(w, body)
+}
+
+// sitemapHandler serves /sitemap.xml, listing up to service.MaxSitemapURLs links that currently
+// redirect along with their creation dates.
+func (h *URLHandler) sitemapHandler(w http.ResponseWriter, r *http.Request) {
+	ctx, span := tracer.Start(r.Context(), "handler.Sitemap")
+	defer span.End()
+	r = r.WithContext(ctx)
+
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		WriteError(w, http.StatusMethodNotAllowed, ErrCodeMethodNotAllowed, "Only GET method is allowed")
+		return
+	}
+
+	urls, err := h.urlService.ListSitemapURLs(r.Context(), service.MaxSitemapURLs)
+	if err != nil {
+		slog.ErrorContext(r.Context(), "Error listing URLs for the sitemap", slog.Any("error", err))
+		WriteError(w, http.StatusInternalServerError, ErrCodeInternal, "Failed to build sitemap")
+		return
+	}
+
+	sitemap := sitemapURLSet{Xmlns: sitemapNamespace, URLs: make([]sitemapURL, 0, len(urls))}
+	for _, urlEntry := range urls {
+		sitemap.URLs = append(sitemap.URLs, sitemapURL{
+			Loc:     h.shortLink(r, urlEntry.ShortUrl),
+			LastMod: urlEntry.CreationDate.UTC().Format(time.RFC3339),
+		})
+	}
+
+	w.Header().Set("Content-Type", "application/xml; charset=utf-8")
+	_, _ = io.WriteString(w, xml.Header)
+	if err := xml.NewEncoder(w).Encode(sitemap); err != nil {
+		slog.ErrorContext(r.Context(), "Error encoding sitemap", slog.Any("error", err))
+	}
+}
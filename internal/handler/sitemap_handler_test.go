@@ -0,0 +1,90 @@
+package handler
+
+import (
+	"context"
+	"encoding/xml"
+	"net/http"
+	"slices"
+	"testing"
+	"time"
+
+	"shawty/internal/config"
+	"shawty/internal/domain"
+)
+
+func TestRobotsHandler(t *testing.T) {
+	tests := []struct {
+		name       string
+		sitemapURL string
+		want       string
+	}{
+		{name: "without sitemap", want: "User-agent: *\nDisallow: /r/\nDisallow: /api/\nAllow: /\n"},
+		{name: "with sitemap", sitemapURL: "https://sho.rt/sitemap.xml", want: "User-agent: *\nDisallow: /r/\nDisallow: /api/\nAllow: /\nSitemap: https://sho.rt/sitemap.xml\n"},
+	}
+	for _, tt := range tests {
+		svc, _ := newMemoryService()
+		mux := newTestMux(t, svc, config.AppConfig{SitemapURL: tt.sitemapURL})
+		rec := serve(mux, http.MethodGet, "/robots.txt", "", nil)
+		if rec.Code != http.StatusOK || rec.Body.String() != tt.want {
+			t.Errorf("%s: %d %q, want 200 %q", tt.name, rec.Code, rec.Body, tt.want)
+		}
+		if ct := rec.Header().Get("Content-Type"); ct != "text/plain; charset=utf-8" {
+			t.Errorf("%s: Content-Type = %q, want text/plain", tt.name, ct)
+		}
+	}
+}
+
+func TestSitemapHandler(t *testing.T) {
+	svc, memStore := newMemoryService()
+	created := time.Date(2025, 1, 2, 3, 4, 5, 0, time.UTC)
+	past, future := time.Now().Add(-time.Hour), time.Now().Add(time.Hour)
+	primary := "live01"
+	entries := []domain.URL{
+		{ID: "live01", OriginalUrl: "https://example.com/1"},
+		{ID: "live02", OriginalUrl: "https://example.com/2", ExpiresAt: &future},
+		{ID: "expird", OriginalUrl: "https://example.com/3", ExpiresAt: &past},
+		{ID: "deletd", OriginalUrl: "https://example.com/4", DeletedAt: &past},
+		{ID: "later1", OriginalUrl: "https://example.com/5", ActiveFrom: &future},
+		{ID: "secret", OriginalUrl: "https://example.com/6", PasswordHash: "$2a$10$hash"},
+		{ID: "alias1", OriginalUrl: "https://example.com/1", AliasOf: &primary},
+	}
+	for _, entry := range entries {
+		entry.ShortUrl, entry.CreationDate = entry.ID, created
+		if err := memStore.Save(context.Background(), entry); err != nil {
+			t.Fatalf("Save: %v", err)
+		}
+	}
+	mux := newTestMux(t, svc, config.AppConfig{BaseURL: "https://sho.rt"})
+
+	rec := serve(mux, http.MethodGet, "/sitemap.xml", "", nil)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d, want 200; body %s", rec.Code, rec.Body)
+	}
+	if ct := rec.Header().Get("Content-Type"); ct != "application/xml; charset=utf-8" {
+		t.Errorf("Content-Type = %q, want application/xml", ct)
+	}
+	var sitemap struct {
+		XMLName xml.Name
+		URLs    []struct {
+			Loc     string `xml:"loc"`
+			LastMod string `xml:"lastmod"`
+		} `xml:"url"`
+	}
+	if err := xml.Unmarshal(rec.Body.Bytes(), &sitemap); err != nil {
+		t.Fatalf("sitemap is not valid XML: %v\n%s", err, rec.Body)
+	}
+	if sitemap.XMLName.Space != sitemapNamespace || sitemap.XMLName.Local != "urlset" {
+		t.Errorf("root element = %v, want urlset in %s", sitemap.XMLName, sitemapNamespace)
+	}
+	var locs []string
+	for _, entry := range sitemap.URLs {
+		locs = append(locs, entry.Loc)
+		if entry.LastMod != "2025-01-02T03:04:05Z" {
+			t.Errorf("%s: lastmod = %q, want the creation date", entry.Loc, entry.LastMod)
+		}
+	}
+	slices.Sort(locs)
+	if want := []string{"https://sho.rt/api/v1/r/live01", "https://sho.rt/api/v1/r/live02"}; !slices.Equal(locs, want) {
+		t.Errorf("sitemap lists %v, want %v", locs, want)
+	}
+}
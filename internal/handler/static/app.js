@@ -0,0 +1,119 @@
+// Shawty dashboard: a small client for the JSON API.
+// Settings come from /dashboard/config.json so no URLs are hardcoded here.
+// Link creation needs an API key; it is kept in sessionStorage for the lifetime of the tab.
+(function () {
+  "use strict";
+
+  const STORAGE_KEY = "shawty.links";
+  const API_KEY_STORAGE_KEY = "shawty.apiKey";
+  const form = document.getElementById("shorten-form");
+  const input = document.getElementById("url-input");
+  const apiKeyInput = document.getElementById("api-key-input");
+  const errorBox = document.getElementById("error");
+  const linksBody = document.getElementById("links");
+
+  let config = { base_url: window.location.origin, analytics_enabled: false };
+
+  function loadLinks() {
+    try {
+      return JSON.parse(localStorage.getItem(STORAGE_KEY)) || [];
+    } catch (e) {
+      return [];
+    }
+  }
+
+  function saveLinks(links) {
+    localStorage.setItem(STORAGE_KEY, JSON.stringify(links));
+  }
+
+  function showError(message) {
+    errorBox.textContent = message;
+    errorBox.hidden = !message;
+  }
+
+  function renderLinks() {
+    linksBody.replaceChildren();
+    for (const link of loadLinks()) {
+      const row = document.createElement("tr");
+
+      const shortCell = document.createElement("td");
+      const anchor = document.createElement("a");
+      anchor.href = link.short_url;
+      anchor.textContent = link.short_url;
+      anchor.target = "_blank";
+      anchor.rel = "noopener";
+      shortCell.appendChild(anchor);
+
+      const originalCell = document.createElement("td");
+      originalCell.textContent = link.original_url;
+
+      const createdCell = document.createElement("td");
+      createdCell.textContent = new Date(link.creation_date).toLocaleString();
+
+      row.append(shortCell, originalCell, createdCell);
+      linksBody.appendChild(row);
+    }
+  }
+
+  // errorMessage turns an API error response into a readable message. Errors are JSON bodies
+  // like {"error": "...", "code": "..."}; anything else falls back to the status.
+  async function errorMessage(response) {
+    const text = await response.text();
+    try {
+      const body = JSON.parse(text);
+      if (body && body.error) {
+        let message = body.error;
+        if (body.code) {
+          message += " (" + body.code + ")";
+        }
+        if (response.status === 401) {
+          message += ". Check the API key.";
+        }
+        return message;
+      }
+    } catch (e) {
+      // Not JSON, e.g. from a proxy in front of the API.
+    }
+    return text.trim() || "Failed to shorten URL (" + response.status + ")";
+  }
+
+  async function shorten(url, apiKey) {
+    const response = await fetch(config.base_url + "/shorten", {
+      method: "POST",
+      headers: {
+        "Content-Type": "application/json",
+        "Authorization": "Bearer " + apiKey,
+      },
+      body: JSON.stringify({ url: url }),
+    });
+    if (!response.ok) {
+      throw new Error(await errorMessage(response));
+    }
+    return response.json();
+  }
+
+  form.addEventListener("submit", async function (event) {
+    event.preventDefault();
+    showError("");
+    try {
+      const apiKey = apiKeyInput.value.trim();
+      sessionStorage.setItem(API_KEY_STORAGE_KEY, apiKey);
+      const created = await shorten(input.value, apiKey);
+      const links = loadLinks().filter(function (l) { return l.short_url !== created.short_url; });
+      links.unshift(created);
+      saveLinks(links);
+      renderLinks();
+      input.value = "";
+    } catch (err) {
+      showError(err.message);
+    }
+  });
+
+  apiKeyInput.value = sessionStorage.getItem(API_KEY_STORAGE_KEY) || "";
+
+  fetch("config.json")
+    .then(function (response) { return response.ok ? response.json() : config; })
+    .then(function (loaded) { config = loaded; })
+    .catch(function () { /* keep defaults */ })
+    .finally(renderLinks);
+})();
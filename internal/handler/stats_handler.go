@@ -0,0 +1,119 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strings"
+	"time"
+
+	"shawty/internal/store"
+)
+
+// URLStatsResponse defines the JSON response for a single short URL's statistics.
+type URLStatsResponse struct {
+	ShortURL       string  `json:"short_url"`
+	OriginalURL    string  `json:"original_url"`
+	ClickCount     int64   `json:"click_count"`
+	MobileClicks   int64   `json:"mobile_click_count"` // Part of click_count that came from mobile devices
+	LastAccessedAt *string `json:"last_accessed_at"`   // null until the link is first visited
+	CreationDate   string  `json:"creation_date"`
+}
+
+// GlobalStatsResponse defines the JSON response for service-wide statistics.
+type GlobalStatsResponse struct {
+	ApproximateTotalURLs int64 `json:"approximate_total_urls"`
+}
+
+// statsHandler serves GET /stats (service-wide), GET /stats/{shortID} (per link),
+// GET /stats/{shortID}/clicks (per referer), GET /stats/{shortID}/countries (per country),
+// GET /stats/{shortID}/timeseries (per period), GET /stats/{shortID}/variants (per A/B test variant)
+// and GET /stats/{shortID}/conversion-rate.
+func (h *URLHandler) statsHandler(w http.ResponseWriter, r *http.Request) {
+	ctx, span := tracer.Start(r.Context(), "handler.Stats")
+	defer span.End()
+	r = r.WithContext(ctx)
+
+	if r.Method != http.MethodGet {
+		WriteError(w, http.StatusMethodNotAllowed, ErrCodeMethodNotAllowed, "Only GET method is allowed")
+		return
+	}
+
+	shortID := pathParam(r)
+	if shortID == "" {
+		h.globalStatsHandler(w, r)
+		return
+	}
+	if clicksID, ok := strings.CutSuffix(shortID, "/clicks"); ok {
+		h.refererStatsHandler(w, r, clicksID)
+		return
+	}
+	if countriesID, ok := strings.CutSuffix(shortID, "/countries"); ok {
+		h.countryStatsHandler(w, r, countriesID)
+		return
+	}
+	if timeseriesID, ok := strings.CutSuffix(shortID, "/timeseries"); ok {
+		h.timeseriesHandler(w, r, timeseriesID)
+		return
+	}
+	if variantsID, ok := strings.CutSuffix(shortID, "/variants"); ok {
+		h.variantStatsHandler(w, r, variantsID)
+		return
+	}
+	if conversionID, ok := strings.CutSuffix(shortID, "/conversion-rate"); ok {
+		h.conversionRateHandler(w, r, conversionID)
+		return
+	}
+
+	urlEntry, err := h.urlService.GetURLDetails(r.Context(), shortID)
+	if err != nil {
+		if errors.Is(err, store.ErrURLDeleted) {
+			writeDeletedError(w)
+		} else if errors.Is(err, store.ErrURLNotFound) {
+			WriteError(w, http.StatusNotFound, ErrCodeNotFound, fmt.Sprintf("Short URL '%s' not found", shortID))
+		} else {
+			slog.ErrorContext(r.Context(), "Error retrieving stats", slog.String("short_id", shortID), slog.Any("error", err))
+			WriteError(w, http.StatusInternalServerError, ErrCodeInternal, "Error retrieving URL stats")
+		}
+		return
+	}
+
+	response := URLStatsResponse{
+		ShortURL:     h.shortLink(r, urlEntry.ShortUrl),
+		OriginalURL:  urlEntry.OriginalUrl,
+		ClickCount:   urlEntry.ClickCount,
+		MobileClicks: urlEntry.MobileClicks,
+		CreationDate: urlEntry.CreationDate.Format(time.RFC3339),
+	}
+	// The destination of a protected link is only revealed to visitors who know the password.
+	if urlEntry.IsPasswordProtected() {
+		response.OriginalURL = ""
+	}
+	if urlEntry.LastAccessedAt != nil {
+		lastAccessed := urlEntry.LastAccessedAt.Format(time.RFC3339)
+		response.LastAccessedAt = &lastAccessed
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(response); err != nil {
+		slog.ErrorContext(r.Context(), "Error encoding stats response", slog.String("short_id", shortID), slog.Any("error", err))
+	}
+}
+
+// globalStatsHandler returns service-wide statistics.
+// The total is approximate: it comes from collection metadata and may lag after bulk operations.
+func (h *URLHandler) globalStatsHandler(w http.ResponseWriter, r *http.Request) {
+	total, err := h.urlService.GetApproximateTotalURLs(r.Context())
+	if err != nil {
+		slog.ErrorContext(r.Context(), "Error retrieving approximate URL total", slog.Any("error", err))
+		WriteError(w, http.StatusInternalServerError, ErrCodeInternal, "Error retrieving stats")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(GlobalStatsResponse{ApproximateTotalURLs: total}); err != nil {
+		slog.ErrorContext(r.Context(), "Error encoding global stats response", slog.Any("error", err))
+	}
+}
@@ -0,0 +1,74 @@
+package handler
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+
+	"shawty/internal/urlutil"
+)
+
+// ShortenTokenResponse is the JSON response of GET /shorten/token.
+type ShortenTokenResponse struct {
+	Token string `json:"token"`
+}
+
+// shortenTokenHandler issues the token that POST /shorten requires with REQUIRE_TOKEN=true for the
+// URL in the url query parameter, e.g. GET /shorten/token?url=https://example.com. Only the master
+// admin token may ask, so that with REQUIRE_TOKEN API key holders can only shorten the URLs an
+// operator has approved by handing them a token.
+func (h *URLHandler) shortenTokenHandler(w http.ResponseWriter, r *http.Request) {
+	ctx, span := tracer.Start(r.Context(), "handler.ShortenToken")
+	defer span.End()
+	r = r.WithContext(ctx)
+
+	if r.Method != http.MethodGet {
+		WriteError(w, http.StatusMethodNotAllowed, ErrCodeMethodNotAllowed, "Only GET method is allowed")
+		return
+	}
+	if !h.authorizeAdmin(w, r) {
+		return
+	}
+	if h.cfg.TokenHMACSecret == "" {
+		WriteError(w, http.StatusNotImplemented, ErrCodeNotImplemented, "Shorten tokens are not configured on this server")
+		return
+	}
+
+	token, err := signURL(h.cfg.TokenHMACSecret, r.URL.Query().Get("url"))
+	if err != nil {
+		WriteError(w, http.StatusBadRequest, ErrCodeValidation, "url query parameter is missing or not a valid URL")
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	if err := json.NewEncoder(w).Encode(ShortenTokenResponse{Token: token}); err != nil {
+		slog.ErrorContext(r.Context(), "Error encoding shorten token response", slog.Any("error", err))
+	}
+}
+
+// signURL returns the base64url-encoded HMAC-SHA256 of rawURL under secret. The URL is normalized
+// first, so every spelling that shortens to the same link shares a token.
+func signURL(secret, rawURL string) (string, error) {
+	if err := urlutil.ValidateURL(rawURL); err != nil {
+		return "", err
+	}
+	normalized, err := urlutil.NormalizeURL(rawURL)
+	if err != nil {
+		return "", err
+	}
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(normalized))
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil)), nil
+}
+
+// validURLToken reports whether token is the one signURL issues for rawURL, comparing in constant time.
+func validURLToken(secret, rawURL, token string) bool {
+	expected, err := signURL(secret, rawURL)
+	if err != nil {
+		return false
+	}
+	return hmac.Equal([]byte(expected), []byte(token))
+}
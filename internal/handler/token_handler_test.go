@@ -0,0 +1,92 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"testing"
+
+	"shawty/internal/config"
+)
+
+// tokenConfig returns a configuration requiring shorten tokens signed with a test secret.
+func tokenConfig() config.AppConfig {
+	return config.AppConfig{AdminToken: testAdminToken, RequireToken: true, TokenHMACSecret: "test-hmac-secret"}
+}
+
+// issueToken fetches the shorten token for rawURL from mux.
+func issueToken(t *testing.T, mux http.Handler, rawURL string) string {
+	t.Helper()
+	rec := serve(mux, http.MethodGet, "/api/v1/shorten/token?url="+url.QueryEscape(rawURL), "", adminHeader())
+	if rec.Code != http.StatusOK {
+		t.Fatalf("token for %s: status = %d, want 200; body %s", rawURL, rec.Code, rec.Body)
+	}
+	var resp ShortenTokenResponse
+	if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil || resp.Token == "" {
+		t.Fatalf("token for %s: body %s is not a token response: %v", rawURL, rec.Body, err)
+	}
+	return resp.Token
+}
+
+func TestShortenRequiresAValidToken(t *testing.T) {
+	svc, _ := newMemoryService()
+	mux := newTestMux(t, svc, tokenConfig())
+	token := issueToken(t, mux, "https://example.com/approved")
+	otherToken := issueToken(t, mux, "https://example.com/other")
+
+	tests := []struct {
+		name       string
+		body       string
+		wantStatus int
+		wantCode   string
+	}{
+		{name: "valid token", body: `{"url": "https://example.com/approved", "token": "` + token + `"}`, wantStatus: http.StatusCreated},
+		{name: "same URL spelled differently", body: `{"url": "https://EXAMPLE.com/approved/", "token": "` + token + `"}`, wantStatus: http.StatusCreated},
+		{name: "token of another URL", body: `{"url": "https://example.com/approved", "token": "` + otherToken + `"}`, wantStatus: http.StatusForbidden, wantCode: ErrCodeForbidden},
+		{name: "tampered token", body: `{"url": "https://example.com/approved", "token": "` + token[:len(token)-2] + `AA"}`, wantStatus: http.StatusForbidden, wantCode: ErrCodeForbidden},
+		{name: "missing token", body: `{"url": "https://example.com/approved"}`, wantStatus: http.StatusUnauthorized, wantCode: ErrCodeUnauthorized},
+	}
+	for _, tt := range tests {
+		rec := serve(mux, http.MethodPost, "/api/v1/shorten", tt.body, nil)
+		if rec.Code != tt.wantStatus {
+			t.Errorf("%s: status = %d, want %d; body %s", tt.name, rec.Code, tt.wantStatus, rec.Body)
+			continue
+		}
+		if tt.wantCode != "" {
+			if code := errorCode(t, rec); code != tt.wantCode {
+				t.Errorf("%s: code = %q, want %q", tt.name, code, tt.wantCode)
+			}
+		}
+	}
+}
+
+func TestShortenWithoutRequireTokenIgnoresTokens(t *testing.T) {
+	svc, _ := newMemoryService()
+	mux := newTestMux(t, svc, config.AppConfig{AdminToken: testAdminToken})
+	for _, body := range []string{`{"url": "https://example.com/a"}`, `{"url": "https://example.com/b", "token": "bogus"}`} {
+		if rec := serve(mux, http.MethodPost, "/api/v1/shorten", body, nil); rec.Code != http.StatusCreated {
+			t.Errorf("%s: status = %d, want 201; body %s", body, rec.Code, rec.Body)
+		}
+	}
+}
+
+func TestShortenTokenHandler(t *testing.T) {
+	svc, _ := newMemoryService()
+	mux := newTestMux(t, svc, tokenConfig())
+	unconfigured := newTestMux(t, svc, config.AppConfig{AdminToken: testAdminToken})
+	target := "/api/v1/shorten/token?url=" + url.QueryEscape("https://example.com/approved")
+
+	if rec := serve(mux, http.MethodGet, target, "", nil); rec.Code != http.StatusUnauthorized {
+		t.Errorf("without the admin token: status = %d, want 401", rec.Code)
+	}
+	if rec := serve(mux, http.MethodGet, "/api/v1/shorten/token?url=not-a-url", "", adminHeader()); rec.Code != http.StatusBadRequest {
+		t.Errorf("invalid url: status = %d, want 400", rec.Code)
+	}
+	if rec := serve(unconfigured, http.MethodGet, target, "", adminHeader()); rec.Code != http.StatusNotImplemented {
+		t.Errorf("without TOKEN_HMAC_SECRET: status = %d, want 501", rec.Code)
+	}
+	rec := serve(mux, http.MethodGet, target, "", adminHeader())
+	if rec.Header().Get("Cache-Control") != "no-store" {
+		t.Errorf("Cache-Control = %q, want no-store", rec.Header().Get("Cache-Control"))
+	}
+}
@@ -0,0 +1,6 @@
+package handler
+
+import "go.opentelemetry.io/otel"
+
+// tracer creates the handler-layer spans. The HTTP server span itself comes from otelhttp in main.
+var tracer = otel.Tracer("shawty/internal/handler")
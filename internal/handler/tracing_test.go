@@ -0,0 +1,86 @@
+package handler
+
+import (
+	"context"
+	"net/http"
+	"slices"
+	"sync"
+	"testing"
+
+	"shawty/internal/config"
+	"shawty/internal/domain"
+	"shawty/internal/service"
+	"shawty/internal/store"
+
+	"go.opentelemetry.io/contrib/instrumentation/net/http/otelhttp"
+	"go.opentelemetry.io/otel"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+// spanningStore is an in-memory store that opens a span for each lookup the way MongoUrlStore
+// does, so tests can follow a trace down to the store without a database.
+type spanningStore struct {
+	*store.MemoryUrlStore
+}
+
+func (s spanningStore) GetByShortID(ctx context.Context, shortID, tenantID string) (domain.URL, error) {
+	ctx, span := otel.Tracer("shawty/internal/store").Start(ctx, "store.GetByShortID")
+	defer span.End()
+	return s.MemoryUrlStore.GetByShortID(ctx, shortID, tenantID)
+}
+
+// spanRecorder records the spans of every test in the package once installed. The package-level
+// tracers bind to the first global provider set, so it is installed once and never replaced.
+var spanRecorder = sync.OnceValue(func() *tracetest.SpanRecorder {
+	recorder := tracetest.NewSpanRecorder()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)))
+	return recorder
+})
+
+func TestRedirectSpansShareOneTrace(t *testing.T) {
+	recorder := spanRecorder()
+	memStore := store.NewMemoryUrlStore()
+	svc := service.NewUrlService(spanningStore{memStore})
+	shortID := mustShorten(t, svc, "https://example.com/traced", service.CreateOptions{})
+	// The server span is the root in main, so it is here too.
+	root := otelhttp.NewHandler(newTestMux(t, svc, config.AppConfig{AdminToken: testAdminToken}), "shawty")
+
+	before := len(recorder.Ended())
+	if rec := serve(root, http.MethodGet, "/api/v1/r/"+shortID, "", nil); rec.Code != http.StatusFound {
+		t.Fatalf("status = %d, want 302; body %s", rec.Code, rec.Body)
+	}
+
+	// Each span of the chain is a child of the one before it. Shortening and click recording
+	// add spans of their own, so spans are matched by parent as well as by name.
+	spans := recorder.Ended()[before:]
+	var parent sdktrace.ReadOnlySpan
+	parentName := "no parent"
+	for _, name := range []string{"shawty", "handler.RedirectURL", "service.ResolveShortURL", "store.GetByShortID"} {
+		i := slices.IndexFunc(spans, func(span sdktrace.ReadOnlySpan) bool {
+			if span.Name() != name {
+				return false
+			}
+			if parent == nil {
+				return !span.Parent().IsValid()
+			}
+			return span.Parent().SpanID() == parent.SpanContext().SpanID()
+		})
+		if i < 0 {
+			t.Fatalf("no %s span with %s; recorded %v", name, parentName, spanNames(spans))
+		}
+		if parent != nil && spans[i].SpanContext().TraceID() != parent.SpanContext().TraceID() {
+			t.Errorf("%s is in trace %v, want %v", name, spans[i].SpanContext().TraceID(), parent.SpanContext().TraceID())
+		}
+		parent, parentName = spans[i], "parent "+name
+	}
+}
+
+// spanNames returns the names of spans, for failure messages.
+func spanNames(spans []sdktrace.ReadOnlySpan) []string {
+	names := make([]string, len(spans))
+	for i, span := range spans {
+		names[i] = span.Name()
+	}
+	return names
+}
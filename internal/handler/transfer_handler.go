@@ -0,0 +1,69 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strings"
+
+	"shawty/internal/service"
+	"shawty/internal/store"
+)
+
+// TransferRequest defines the expected JSON body for moving a short URL to another tenant.
+type TransferRequest struct {
+	NewOwner string `json:"new_owner"` // Owner name of the receiving tenant's API key
+}
+
+// transferHandler moves a short URL to another tenant, which then lists it and is charged for it
+// in its quota. It expects a POST request like /api/v1/admin/urls/{shortID}/transfer with a JSON
+// body like {"new_owner": "team-b"} and the master admin token, and answers 204 No Content.
+func (h *URLHandler) transferHandler(w http.ResponseWriter, r *http.Request) {
+	ctx, span := tracer.Start(r.Context(), "handler.TransferOwnership")
+	defer span.End()
+	r = r.WithContext(ctx)
+
+	shortID, ok := strings.CutSuffix(pathParam(r), "/transfer")
+	if !ok || shortID == "" || strings.Contains(shortID, "/") {
+		http.NotFound(w, r)
+		return
+	}
+	if r.Method != http.MethodPost {
+		WriteError(w, http.StatusMethodNotAllowed, ErrCodeMethodNotAllowed, "Only POST method is allowed")
+		return
+	}
+	if !h.authorizeAdmin(w, r) {
+		return
+	}
+
+	var req TransferRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeBodyError(w, err)
+		return
+	}
+	defer r.Body.Close()
+	newOwner := strings.TrimSpace(req.NewOwner)
+	if newOwner == "" {
+		WriteError(w, http.StatusBadRequest, ErrCodeValidation, "new_owner field is missing in request body")
+		return
+	}
+	r = r.WithContext(withAdminActor(r.Context()))
+
+	if err := h.urlService.TransferOwnership(r.Context(), shortID, newOwner); err != nil {
+		switch {
+		case errors.Is(err, service.ErrOwnershipUnavailable):
+			WriteError(w, http.StatusNotImplemented, ErrCodeNotImplemented, "Ownership transfer is not available with this store")
+		case errors.Is(err, store.ErrTenantNotFound):
+			WriteError(w, http.StatusUnprocessableEntity, ErrCodeValidation, fmt.Sprintf("No tenant '%s'", newOwner))
+		case errors.Is(err, store.ErrURLNotFound):
+			WriteError(w, http.StatusNotFound, ErrCodeNotFound, fmt.Sprintf("Short URL '%s' not found", shortID))
+		default:
+			slog.ErrorContext(r.Context(), "Error transferring short URL", slog.String("short_id", shortID), slog.String("new_owner", newOwner), slog.Any("error", err))
+			WriteError(w, http.StatusInternalServerError, ErrCodeInternal, "Failed to transfer URL")
+		}
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
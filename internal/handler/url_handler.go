@@ -1,32 +1,118 @@
 package handler
 
 import (
+	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
-	"log"
+	"html/template"
+	"log/slog"
+	"math/rand"
 	"net/http"
+	"net/url"
+	"slices"
+	"strconv"
 	"strings"
 	"time"
 
+	"shawty/internal/cdn"
+	"shawty/internal/circuitbreaker"
+	"shawty/internal/config"
+	"shawty/internal/domain"
+	"shawty/internal/middleware"
 	"shawty/internal/service"
+	"shawty/internal/store"
+	"shawty/internal/tracing"
+	"shawty/internal/urlutil"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+	"golang.org/x/crypto/bcrypt"
 )
 
+// visitRecordTimeout bounds the background write that records a redirect.
+const visitRecordTimeout = 5 * time.Second
+
+// passwordHeader carries the password of a password-protected short URL.
+const passwordHeader = "X-Short-URL-Password"
+
 // URLHandler manages HTTP requests related to URLs.
 type URLHandler struct {
-	urlService service.UrlServiceInterface
+	urlService     service.UrlServiceInterface
+	cfg            config.AppConfig
+	baseURL        string             // Public base URL for short links; detected per request when empty
+	apiPrefix      string             // Path prefix of the versioned API, e.g. "/api/v1"; set by RegisterRoutes
+	bundleTemplate *template.Template // Page shown for bundles, executed with a BundlePage
+}
+
+// NewURLHandler creates a new URLHandler. Short links are built from cfg.BaseURL when it is set.
+// It returns an error if cfg.BundleTemplatePath names a template that cannot be parsed.
+func NewURLHandler(s service.UrlServiceInterface, cfg config.AppConfig) (*URLHandler, error) {
+	bundleTemplate, err := loadBundleTemplate(cfg.BundleTemplatePath)
+	if err != nil {
+		return nil, err
+	}
+	return &URLHandler{urlService: s, cfg: cfg, baseURL: strings.TrimSuffix(cfg.BaseURL, "/"), bundleTemplate: bundleTemplate}, nil
 }
 
-// NewURLHandler creates a new URLHandler.
-func NewURLHandler(s service.UrlServiceInterface) *URLHandler {
-	return &URLHandler{urlService: s}
+// APIPrefix returns the path prefix under which RegisterRoutes serves the given API version.
+func APIPrefix(version string) string {
+	return "/api/" + version
 }
 
-// RegisterRoutes sets up the routes for the URL handler.
-func (h *URLHandler) RegisterRoutes(mux *http.ServeMux) {
+// RegisterRoutes sets up the routes for the URL handler. The API is served under
+// APIPrefix(version), e.g. /api/v1/shorten, and additionally at the unversioned legacy
+// paths while LEGACY_ROUTES_ENABLED is set. The home page, robots.txt, sitemap.xml, the preview
+// page's assets and the dashboard stay at the root.
+// Short URLs handed out to clients use the versioned paths.
+func (h *URLHandler) RegisterRoutes(mux *http.ServeMux, version string) {
+	h.apiPrefix = APIPrefix(version)
+
 	mux.HandleFunc("/", h.homeHandler)
-	mux.HandleFunc("/shorten", h.shortenURLHandler)
-	mux.HandleFunc("/r/", h.redirectURLHandler) // Using /r/ as the prefix for redirection
+	mux.HandleFunc("/robots.txt", h.robotsHandler)
+	mux.HandleFunc("/sitemap.xml", h.sitemapHandler)
+	mux.HandleFunc("/static/", h.serveAsset)
+	h.registerAPIRoutes(mux, h.apiPrefix)
+	if h.cfg.LegacyRoutesEnabled {
+		h.registerAPIRoutes(mux, "")
+	}
+
+	if h.cfg.DashboardEnabled {
+		mux.HandleFunc("/dashboard/config.json", h.dashboardConfigHandler)
+		mux.HandleFunc("/dashboard/", h.ServeFile)
+	}
+}
+
+// registerAPIRoutes registers the API endpoints under prefix.
+// Handlers read their path parameters relative to the matched pattern (see pathParam),
+// so the same handlers serve both the versioned and the legacy routes.
+func (h *URLHandler) registerAPIRoutes(mux *http.ServeMux, prefix string) {
+	mux.HandleFunc(prefix+"/shorten", h.shortenURLHandler)
+	mux.HandleFunc(prefix+"/shorten/bulk", h.bulkShortenURLHandler)
+	mux.HandleFunc(prefix+"/shorten/token", h.shortenTokenHandler)
+	mux.HandleFunc(prefix+"/r/", h.shortURLHandler) // Using /r/ as the prefix for redirection and link management
+	mux.HandleFunc(prefix+"/preview/", h.previewHandler)
+	mux.HandleFunc(prefix+"/qr/", h.qrHandler)
+	mux.HandleFunc(prefix+"/stats", h.statsHandler)
+	mux.HandleFunc(prefix+"/stats/", h.statsHandler)
+	mux.HandleFunc(prefix+"/track/convert/", h.trackConversionHandler)
+	mux.HandleFunc(prefix+"/admin/urls", h.listURLsHandler)
+	mux.HandleFunc(prefix+"/admin/urls/search", h.searchURLsHandler)
+	mux.HandleFunc(prefix+"/admin/urls/", h.transferHandler)
+	mux.HandleFunc(prefix+"/admin/export", h.exportHandler)
+	mux.HandleFunc(prefix+"/admin/import", h.importHandler)
+	mux.HandleFunc(prefix+"/admin/blacklist", h.blacklistHandler)
+	mux.HandleFunc(prefix+"/admin/audit/", h.auditHandler)
+	mux.HandleFunc(prefix+"/admin/undelete/", h.undeleteHandler)
+	mux.HandleFunc(prefix+"/admin/tenants/", h.tenantQuotaHandler)
+	mux.HandleFunc(prefix+"/admin/domains/stats", h.domainStatsHandler)
+	mux.HandleFunc(prefix+"/admin/events/", h.clickStreamHandler)
+}
+
+// pathParam returns the part of the request path after the route pattern it matched,
+// e.g. "abc123" for /api/v1/r/abc123 matched by "/api/v1/r/".
+func pathParam(r *http.Request) string {
+	return strings.TrimPrefix(r.URL.Path, r.Pattern)
 }
 
 // homeHandler provides a simple welcome message.
@@ -40,98 +126,290 @@ func (h *URLHandler) homeHandler(w http.ResponseWriter, r *http.Request) {
 
 // ShortenURLRequest defines the expected JSON body for shortening a URL.
 type ShortenURLRequest struct {
-	URL string `json:"url"`
+	URL              string            `json:"url"` // May be left out for bundles, which then use their first link
+	CustomCode       string            `json:"custom_code,omitempty"`
+	ExpiresInSeconds int64             `json:"expires_in_seconds,omitempty"`
+	RedirectType     string            `json:"redirect_type,omitempty"`  // "permanent" (301) or "temporary" (302); defaults to REDIRECT_TYPE
+	Password         string            `json:"password,omitempty"`       // Visitors must send it in the X-Short-URL-Password header
+	MaxClicks        *int64            `json:"max_clicks,omitempty"`     // The link returns 410 Gone after this many redirects
+	UTM              *domain.UTMParams `json:"utm,omitempty"`            // Added to the destination's query string on redirect
+	Tags             []string          `json:"tags,omitempty"`           // Labels for filtering, e.g. ["campaign-q4", "social"]
+	WebhookURL       string            `json:"webhook_url,omitempty"`    // Receives a POST when the link is followed for the first time
+	MobileURL        string            `json:"mobile_url,omitempty"`     // Destination for visitors on mobile devices
+	Variants         []domain.Variant  `json:"variants,omitempty"`       // A/B test destinations; weights are percentages, url gets the rest
+	ActiveFrom       *time.Time        `json:"active_from,omitempty"`    // RFC 3339; the link answers 404 until then
+	ActiveUntil      *time.Time        `json:"active_until,omitempty"`   // RFC 3339; the link answers 410 from then on
+	Bundle           bool              `json:"bundle,omitempty"`         // Show a page listing variants (name and url only) instead of redirecting
+	BundleTitle      string            `json:"bundle_title,omitempty"`   // Title of the bundle page
+	CustomHeaders    map[string]string `json:"custom_headers,omitempty"` // Set on redirects, e.g. {"Cache-Control": "no-store"}; denied headers are dropped
+	Token            string            `json:"token,omitempty"`          // From GET /shorten/token for url; required with REQUIRE_TOKEN=true
 }
 
 // ShortenURLResponse defines the JSON response for a successful shortening.
 type ShortenURLResponse struct {
-	ShortURL     string `json:"short_url"`
-	OriginalURL  string `json:"original_url"`
-	CreationDate string `json:"creation_date"`
+	ShortURL      string            `json:"short_url"`
+	OriginalURL   string            `json:"original_url"`
+	CreationDate  string            `json:"creation_date"`
+	ExpiresAt     string            `json:"expires_at,omitempty"`
+	ActiveFrom    string            `json:"active_from,omitempty"`
+	ActiveUntil   string            `json:"active_until,omitempty"`
+	RedirectType  string            `json:"redirect_type,omitempty"`
+	MaxClicks     *int64            `json:"max_clicks,omitempty"`
+	UTM           *domain.UTMParams `json:"utm,omitempty"`
+	Tags          []string          `json:"tags,omitempty"`
+	WebhookURL    string            `json:"webhook_url,omitempty"`
+	MobileURL     *string           `json:"mobile_url,omitempty"`
+	Variants      []domain.Variant  `json:"variants,omitempty"`
+	Bundle        bool              `json:"bundle,omitempty"`
+	BundleTitle   string            `json:"bundle_title,omitempty"`
+	CustomHeaders map[string]string `json:"custom_headers,omitempty"`
+	// The page metadata is fetched in the background, so it is usually still empty right after creation.
+	PageTitle       string `json:"page_title"`
+	PageDescription string `json:"page_description"`
 }
 
 // shortenURLHandler handles requests to create a new short URL.
 // It expects a POST request with a JSON body like: {"url": "http://example.com"}
 func (h *URLHandler) shortenURLHandler(w http.ResponseWriter, r *http.Request) {
+	ctx, span := tracer.Start(r.Context(), "handler.ShortenURL")
+	defer span.End()
+	r = r.WithContext(ctx)
+
 	if r.Method != http.MethodPost {
-		http.Error(w, "Only POST method is allowed", http.StatusMethodNotAllowed)
+		WriteError(w, http.StatusMethodNotAllowed, ErrCodeMethodNotAllowed, "Only POST method is allowed")
 		return
 	}
 
 	var req ShortenURLRequest
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		http.Error(w, "Invalid request body: "+err.Error(), http.StatusBadRequest)
+		writeBodyError(w, err)
 		return
 	}
 	defer r.Body.Close()
 
-	if req.URL == "" {
-		http.Error(w, "URL field is missing or empty in request body", http.StatusBadRequest)
+	if req.URL == "" && !req.Bundle {
+		WriteError(w, http.StatusBadRequest, ErrCodeValidation, "URL field is missing or empty in request body")
 		return
 	}
 
-	createdURL, err := h.urlService.CreateShortURL(r.Context(), req.URL)
-	if err != nil {
-		log.Printf("Error creating short URL for '%s': %v", req.URL, err)
-
-		if errors.Is(err, service.ErrHashCollision) {
-			http.Error(w, "Failed to create short URL due to a hash collision. Please try again or modify the URL slightly.", http.StatusConflict)
-		} else if strings.Contains(err.Error(), "duplicate") || strings.Contains(err.Error(), "already exists") {
-			// This case should ideally be less frequent now if service.CreateShortURL handles known duplicates by returning the existing URL.
-			// This might catch other unexpected duplicate errors or if ErrDuplicateShortID from store somehow propagates directly.
-			http.Error(w, "This URL may have already been shortened or a conflict occurred.", http.StatusConflict)
-		} else {
-			http.Error(w, "Failed to create short URL", http.StatusInternalServerError)
+	if h.cfg.RequireToken {
+		// A bundle without a url is shortened under its first link, so that is what the token covers.
+		tokenURL := req.URL
+		if tokenURL == "" && len(req.Variants) > 0 {
+			tokenURL = req.Variants[0].URL
+		}
+		if req.Token == "" {
+			WriteError(w, http.StatusUnauthorized, ErrCodeUnauthorized, "token is required; get one from /shorten/token")
+			return
+		}
+		if !validURLToken(h.cfg.TokenHMACSecret, tokenURL, req.Token) {
+			slog.WarnContext(r.Context(), "Rejected shorten request with an invalid token", slog.String("original_url", tokenURL), slog.String("owner", middleware.OwnerFromContext(r.Context())))
+			WriteError(w, http.StatusForbidden, ErrCodeForbidden, "token does not match the URL")
+			return
 		}
-		return
 	}
 
-	// Construct the full short URL to return to the client
-	// Scheme (http/https) and Host should ideally be configurable or detected
-	scheme := "http"
-	if r.TLS != nil {
-		scheme = "https"
+	if req.ExpiresInSeconds < 0 {
+		WriteError(w, http.StatusBadRequest, ErrCodeValidation, "expires_in_seconds must be a positive number of seconds")
+		return
 	}
-	fullShortURL := fmt.Sprintf("%s://%s/r/%s", scheme, r.Host, createdURL.ShortUrl)
 
-	response := ShortenURLResponse{
-		ShortURL:     fullShortURL,
-		OriginalURL:  createdURL.OriginalUrl,
-		CreationDate: createdURL.CreationDate.Format(time.RFC3339),
+	opts := service.CreateOptions{
+		CustomCode:    req.CustomCode,
+		ExpiresIn:     time.Duration(req.ExpiresInSeconds) * time.Second,
+		RedirectType:  req.RedirectType,
+		Password:      req.Password,
+		MaxClicks:     req.MaxClicks,
+		Tags:          req.Tags,
+		WebhookURL:    req.WebhookURL,
+		MobileURL:     req.MobileURL,
+		Variants:      req.Variants,
+		ActiveFrom:    req.ActiveFrom,
+		ActiveUntil:   req.ActiveUntil,
+		Bundle:        req.Bundle,
+		BundleTitle:   req.BundleTitle,
+		CustomHeaders: req.CustomHeaders,
+		Domain:        h.requestDomain(r),
+	}
+	if req.UTM != nil {
+		opts.UTM = *req.UTM
+	}
+	createdURL, err := h.urlService.CreateShortURL(r.Context(), req.URL, opts)
+	if err != nil {
+		slog.WarnContext(r.Context(), "Error creating short URL", slog.String("original_url", req.URL), slog.String("owner", middleware.OwnerFromContext(r.Context())), slog.Any("error", err))
+		status, code, message := shortenErrorResponse(err, req.CustomCode)
+		WriteError(w, status, code, message)
+		return
 	}
 
+	slog.InfoContext(r.Context(), "Created short URL", slog.String("short_id", createdURL.ShortUrl), slog.String("owner", middleware.OwnerFromContext(r.Context())))
+	response := h.newShortenURLResponse(r, createdURL)
+
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(http.StatusCreated)
 	if err := json.NewEncoder(w).Encode(response); err != nil {
-		log.Printf("Error encoding response for short URL '%s': %v", createdURL.ShortUrl, err)
-		// Cannot send http.Error here as headers might have been written
+		slog.ErrorContext(r.Context(), "Error encoding shorten response", slog.String("short_id", createdURL.ShortUrl), slog.Any("error", err))
+		// Cannot send an error response here as headers might have been written
 	}
 }
 
-// redirectURLHandler handles requests to redirect a short URL to its original URL.
-// It expects URLs in the format /r/{shortID}
-func (h *URLHandler) redirectURLHandler(w http.ResponseWriter, r *http.Request) {
-	if r.Method != http.MethodGet {
-		http.Error(w, "Only GET method is allowed for redirection", http.StatusMethodNotAllowed)
+// writeBodyError answers a request whose JSON body could not be decoded: with 413 if the body
+// is larger than the limit set by middleware.NewMaxBodyMiddleware, and with 400 otherwise.
+func writeBodyError(w http.ResponseWriter, err error) {
+	var tooLarge *http.MaxBytesError
+	if errors.As(err, &tooLarge) {
+		WriteError(w, http.StatusRequestEntityTooLarge, ErrCodePayloadTooLarge, fmt.Sprintf("Request body must be at most %d bytes", tooLarge.Limit))
 		return
 	}
+	WriteError(w, http.StatusBadRequest, ErrCodeValidation, "Invalid request body: "+err.Error())
+}
 
-	shortID := strings.TrimPrefix(r.URL.Path, "/r/")
+// shortenErrorResponse maps an error from CreateShortURL to an HTTP status code, error code and
+// client-facing message.
+func shortenErrorResponse(err error, customCode string) (int, string, string) {
+	if errors.Is(err, circuitbreaker.ErrCircuitOpen) {
+		return http.StatusServiceUnavailable, ErrCodeServiceUnavailable, "The service is temporarily unable to create short URLs. Please try again in a minute."
+	} else if errors.Is(err, service.ErrDomainBlacklisted) {
+		return http.StatusUnprocessableEntity, ErrCodeValidation, "Domain is not allowed"
+	} else if errors.Is(err, service.ErrInvalidURL) {
+		return http.StatusUnprocessableEntity, ErrCodeValidation, err.Error()
+	} else if errors.Is(err, service.ErrInvalidRedirectType) {
+		return http.StatusBadRequest, ErrCodeValidation, "redirect_type must be permanent or temporary"
+	} else if errors.Is(err, service.ErrInvalidMaxClicks) {
+		return http.StatusBadRequest, ErrCodeValidation, "max_clicks must be at least 1"
+	} else if errors.Is(err, service.ErrInvalidPassword) {
+		return http.StatusBadRequest, ErrCodeValidation, "password must be at most 72 bytes long"
+	} else if errors.Is(err, service.ErrInvalidTags) {
+		return http.StatusBadRequest, ErrCodeValidation, err.Error()
+	} else if errors.Is(err, service.ErrInvalidWebhookURL) {
+		return http.StatusBadRequest, ErrCodeValidation, err.Error()
+	} else if errors.Is(err, service.ErrInvalidVariants) {
+		return http.StatusBadRequest, ErrCodeValidation, err.Error()
+	} else if errors.Is(err, service.ErrInvalidSchedule) {
+		return http.StatusBadRequest, ErrCodeValidation, err.Error()
+	} else if errors.Is(err, service.ErrInvalidBundle) {
+		return http.StatusBadRequest, ErrCodeValidation, err.Error()
+	} else if errors.Is(err, service.ErrInvalidCustomHeaders) {
+		return http.StatusBadRequest, ErrCodeValidation, err.Error()
+	} else if errors.Is(err, service.ErrInvalidCustomCode) {
+		return http.StatusBadRequest, ErrCodeValidation, "Custom code must be 3-50 characters long and contain only letters, digits, '_' or '-'."
+	} else if errors.Is(err, service.ErrReservedCode) {
+		return http.StatusUnprocessableEntity, ErrCodeValidation, fmt.Sprintf("Custom code '%s' is reserved. Please choose another one.", customCode)
+	} else if errors.Is(err, service.ErrCustomCodeTaken) {
+		return http.StatusConflict, ErrCodeConflict, fmt.Sprintf("Custom code '%s' is already taken. Please choose another one.", customCode)
+	} else if errors.Is(err, service.ErrQuotaExceeded) {
+		return http.StatusTooManyRequests, ErrCodeQuotaExceeded, "URL quota exceeded: this API key cannot create more short URLs"
+	} else if errors.Is(err, service.ErrHashCollision) {
+		return http.StatusConflict, ErrCodeHashCollision, "Failed to create short URL due to a hash collision. Please try again or modify the URL slightly."
+	} else if strings.Contains(err.Error(), "duplicate") || strings.Contains(err.Error(), "already exists") {
+		// This case should ideally be less frequent now if service.CreateShortURL handles known duplicates by returning the existing URL.
+		// This might catch other unexpected duplicate errors or if ErrDuplicateShortID from store somehow propagates directly.
+		return http.StatusConflict, ErrCodeConflict, "This URL may have already been shortened or a conflict occurred."
+	}
+	return http.StatusInternalServerError, ErrCodeInternal, "Failed to create short URL"
+}
+
+// newShortenURLResponse builds the response body for a created short URL.
+func (h *URLHandler) newShortenURLResponse(r *http.Request, createdURL domain.URL) ShortenURLResponse {
+	// Construct the full short URL to return to the client
+	fullShortURL := h.shortLink(r, createdURL.ShortUrl)
+
+	response := ShortenURLResponse{
+		ShortURL:        fullShortURL,
+		OriginalURL:     createdURL.OriginalUrl,
+		CreationDate:    createdURL.CreationDate.Format(time.RFC3339),
+		RedirectType:    createdURL.RedirectType,
+		MaxClicks:       createdURL.MaxClicks,
+		UTM:             createdURL.UTM,
+		Tags:            createdURL.Tags,
+		WebhookURL:      createdURL.WebhookURL,
+		MobileURL:       createdURL.MobileURL,
+		Variants:        createdURL.Variants,
+		Bundle:          createdURL.Bundle,
+		BundleTitle:     createdURL.BundleTitle,
+		CustomHeaders:   createdURL.CustomHeaders,
+		PageTitle:       createdURL.PageTitle,
+		PageDescription: createdURL.PageDescription,
+	}
+	if createdURL.ExpiresAt != nil {
+		response.ExpiresAt = createdURL.ExpiresAt.Format(time.RFC3339)
+	}
+	if createdURL.ActiveFrom != nil {
+		response.ActiveFrom = createdURL.ActiveFrom.Format(time.RFC3339)
+	}
+	if createdURL.ActiveUntil != nil {
+		response.ActiveUntil = createdURL.ActiveUntil.Format(time.RFC3339)
+	}
+	return response
+}
+
+// shortURLHandler dispatches requests under /r/{shortID} by method:
+// GET redirects to the original URL, PATCH changes its destination and DELETE removes the short link.
+// GET /r/{shortID}/info describes the link without following it and POST /r/{shortID}/aliases adds
+// another short code for it.
+func (h *URLHandler) shortURLHandler(w http.ResponseWriter, r *http.Request) {
+	shortID := pathParam(r)
+	if infoID, ok := strings.CutSuffix(shortID, "/info"); ok {
+		infoETag(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			h.infoHandler(w, r, infoID)
+		})).ServeHTTP(w, r)
+		return
+	}
+	if primaryID, ok := strings.CutSuffix(shortID, "/aliases"); ok {
+		h.createAliasHandler(w, r, primaryID)
+		return
+	}
 	if shortID == "" {
-		http.Error(w, "Short URL ID is missing in the path", http.StatusBadRequest)
+		WriteError(w, http.StatusBadRequest, ErrCodeValidation, "Short URL ID is missing in the path")
 		return
 	}
 
-	originalURL, err := h.urlService.GetOriginalURL(r.Context(), shortID)
+	switch r.Method {
+	case http.MethodGet:
+		h.redirectURLHandler(w, r, shortID)
+	case http.MethodPatch:
+		h.updateURLHandler(w, r, shortID)
+	case http.MethodDelete:
+		h.deleteURLHandler(w, r, shortID)
+	default:
+		w.Header().Set("Allow", "GET, PATCH, DELETE")
+		WriteError(w, http.StatusMethodNotAllowed, ErrCodeMethodNotAllowed, "Only GET, PATCH and DELETE methods are allowed")
+	}
+}
+
+// redirectURLHandler redirects a short URL to its original URL, or to its mobile URL for visitors
+// on mobile devices when it has one. Other visitors of an A/B-tested link are sent to a variant
+// picked at random by weight. Bundles answer with a page listing their links instead.
+func (h *URLHandler) redirectURLHandler(w http.ResponseWriter, r *http.Request, shortID string) {
+	ctx, span := tracer.Start(r.Context(), "handler.RedirectURL", trace.WithAttributes(attribute.String(tracing.AttrShortID, shortID)))
+	defer span.End()
+	r = r.WithContext(ctx)
+
+	urlEntry, err := h.urlService.ResolveShortURL(r.Context(), shortID)
 	if err != nil {
-		if strings.Contains(err.Error(), "not found") {
-			http.Error(w, fmt.Sprintf("Short URL '%s' not found", shortID), http.StatusNotFound)
-		} else {
-			log.Printf("Error retrieving original URL for short ID '%s': %v", shortID, err)
-			http.Error(w, "Error retrieving URL", http.StatusInternalServerError)
-		}
+		writeLookupError(w, r, shortID, err)
 		return
 	}
+	if !checkLinkPassword(w, r, urlEntry) {
+		return
+	}
+	mobile := isMobileUserAgent(r.UserAgent())
+	originalURL := urlEntry.OriginalUrl
+	if urlEntry.MobileURL != nil {
+		// The destination depends on the device, so caches must not share it across user agents.
+		w.Header().Add("Vary", "User-Agent")
+		if mobile {
+			originalURL = *urlEntry.MobileURL
+		}
+	}
+	variant := ""
+	if len(urlEntry.Variants) > 0 && !urlEntry.Bundle && originalURL == urlEntry.OriginalUrl {
+		// The top-level math/rand functions are seeded randomly when the program starts.
+		variant = domain.VariantOriginal
+		if v, ok := urlEntry.PickVariant(rand.Intn(domain.VariantWeightTotal)); ok {
+			originalURL, variant = v.URL, v.Name
+		}
+	}
 
 	// Ensure the original URL has a scheme for proper redirection.
 	// Prepend "http://" if no scheme is present.
@@ -139,6 +417,275 @@ func (h *URLHandler) redirectURLHandler(w http.ResponseWriter, r *http.Request)
 	if !strings.HasPrefix(originalURL, "http://") && !strings.HasPrefix(originalURL, "https://") {
 		originalURL = "http://" + originalURL
 	}
+	if urlEntry.UTM != nil {
+		// A destination that cannot take the parameters is still worth redirecting to.
+		if withUTM, err := urlutil.InjectUTM(originalURL, *urlEntry.UTM); err != nil {
+			slog.WarnContext(r.Context(), "Error adding UTM parameters", slog.String("short_id", shortID), slog.Any("error", err))
+		} else {
+			originalURL = withUTM
+		}
+	}
 
+	// An alias resolves to its primary entry, so its clicks are counted on the primary.
+	clickID := urlEntry.ID
+	if urlEntry.MaxClicks != nil {
+		// A click-limited link may only redirect once its click has been counted, so the
+		// visit is recorded before redirecting. The store refuses clicks over the limit.
+		if err := h.urlService.RecordVisit(r.Context(), clickID, mobile); err != nil {
+			writeLookupError(w, r, shortID, err)
+			return
+		}
+	} else {
+		// Record the visit in the background so the redirect is never slowed down by the write.
+		// The request context is cancelled once the response is sent, so the write is detached from
+		// its cancellation while keeping its values, such as the request ID.
+		go func() {
+			ctx, cancel := context.WithTimeout(context.WithoutCancel(r.Context()), visitRecordTimeout)
+			defer cancel()
+			if err := h.urlService.RecordVisit(ctx, clickID, mobile); err != nil {
+				slog.ErrorContext(ctx, "Error recording visit", slog.String("short_id", clickID), slog.Any("error", err))
+			}
+		}()
+	}
+	h.recordClick(r, clickID, mobile, variant)
+	if urlEntry.Bundle {
+		h.writeBundlePage(w, r, urlEntry)
+		return
+	}
+
+	redirectType := urlEntry.RedirectType
+	if redirectType == "" {
+		redirectType = h.cfg.RedirectType
+	}
+	// A cached redirect would bypass the click limit or the password, outlive the expiry or the
+	// activation window, or keep sending a visitor to one variant uncounted.
+	if urlEntry.MaxClicks != nil || urlEntry.IsPasswordProtected() || urlEntry.ExpiresAt != nil || urlEntry.ActiveUntil != nil || len(urlEntry.Variants) > 0 {
+		redirectType = domain.RedirectTemporary
+	}
+	if redirectType == domain.RedirectPermanent {
+		// Browsers and CDNs may answer repeat visits from cache, so those visits are not counted.
+		// CDNs keep the redirect longer, as updates and deletes purge it by its surrogate keys.
+		w.Header().Set("Cache-Control", permanentRedirectCacheControl)
+		setSurrogateKeys(w, shortID, urlEntry.ID)
+		setCustomHeaders(w, urlEntry.CustomHeaders)
+		http.Redirect(w, r, originalURL, http.StatusMovedPermanently)
+		return
+	}
+	w.Header().Set("Cache-Control", "no-store")
+	setCustomHeaders(w, urlEntry.CustomHeaders)
 	http.Redirect(w, r, originalURL, http.StatusFound)
 }
+
+// permanentRedirectCacheControl lets browsers cache a 301 for an hour and shared caches such as
+// CDNs for a day.
+const permanentRedirectCacheControl = "public, max-age=3600, s-maxage=86400"
+
+// setSurrogateKeys tags a cacheable redirect with the cdn.SurrogateKey of shortID and, for an alias,
+// of its primary link, so that purging either removes it from a CDN. Fastly reads the space-separated
+// Surrogate-Key header and Cloudflare the comma-separated Cache-Tag header.
+func setSurrogateKeys(w http.ResponseWriter, shortID, primaryID string) {
+	keys := []string{cdn.SurrogateKey(shortID)}
+	if primaryID != "" && primaryID != shortID {
+		keys = append(keys, cdn.SurrogateKey(primaryID))
+	}
+	w.Header().Set("Surrogate-Key", strings.Join(keys, " "))
+	w.Header().Set("Cache-Tag", strings.Join(keys, ","))
+}
+
+// setCustomHeaders sets a link's own response headers, replacing those the handler has set, such as
+// Cache-Control. Vary is added to instead, as the handler's Vary: User-Agent must be kept. Headers
+// domain.CustomHeaderAllowed denies are skipped again here, so that entries stored before a header
+// was denied, or edited in the database directly, cannot set it.
+func setCustomHeaders(w http.ResponseWriter, headers map[string]string) {
+	for name, value := range headers {
+		if !domain.CustomHeaderAllowed(name) {
+			continue
+		}
+		if http.CanonicalHeaderKey(name) == "Vary" {
+			w.Header().Add(name, value)
+			continue
+		}
+		w.Header().Set(name, value)
+	}
+}
+
+// UpdateURLRequest defines the expected JSON body for changing a short URL's destination, its tags, or both.
+// Tags is a pointer so that an empty list, which removes all tags, can be told apart from a missing field.
+type UpdateURLRequest struct {
+	URL  string    `json:"url,omitempty"`
+	Tags *[]string `json:"tags,omitempty"`
+}
+
+// updateURLHandler changes the original URL or the tags of a short URL. It requires the admin bearer token.
+// It expects a PATCH request with a JSON body like: {"url": "https://new-destination.com", "tags": ["social"]}
+// where either field may be left out.
+func (h *URLHandler) updateURLHandler(w http.ResponseWriter, r *http.Request, shortID string) {
+	ctx, span := tracer.Start(r.Context(), "handler.UpdateURL", trace.WithAttributes(attribute.String(tracing.AttrShortID, shortID)))
+	defer span.End()
+	r = r.WithContext(ctx)
+
+	if !h.authorizeAdmin(w, r) {
+		return
+	}
+	r = r.WithContext(withAdminActor(r.Context()))
+
+	var req UpdateURLRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeBodyError(w, err)
+		return
+	}
+	defer r.Body.Close()
+
+	if req.URL == "" && req.Tags == nil {
+		WriteError(w, http.StatusBadRequest, ErrCodeValidation, "Request body must set url, tags or both")
+		return
+	}
+
+	var (
+		updatedURL domain.URL
+		err        error
+	)
+	if req.URL != "" {
+		updatedURL, err = h.urlService.UpdateShortURL(r.Context(), shortID, req.URL)
+	}
+	if err == nil && req.Tags != nil {
+		updatedURL, err = h.urlService.UpdateTags(r.Context(), shortID, *req.Tags)
+	}
+	if err != nil {
+		if errors.Is(err, service.ErrDomainBlacklisted) {
+			WriteError(w, http.StatusUnprocessableEntity, ErrCodeValidation, "Domain is not allowed")
+		} else if errors.Is(err, service.ErrInvalidURL) {
+			WriteError(w, http.StatusUnprocessableEntity, ErrCodeValidation, err.Error())
+		} else if errors.Is(err, service.ErrInvalidTags) {
+			WriteError(w, http.StatusBadRequest, ErrCodeValidation, err.Error())
+		} else if errors.Is(err, store.ErrURLNotFound) {
+			WriteError(w, http.StatusNotFound, ErrCodeNotFound, fmt.Sprintf("Short URL '%s' not found", shortID))
+		} else {
+			slog.ErrorContext(r.Context(), "Error updating short URL", slog.String("short_id", shortID), slog.Any("error", err))
+			WriteError(w, http.StatusInternalServerError, ErrCodeInternal, "Failed to update URL")
+		}
+		return
+	}
+
+	slog.InfoContext(r.Context(), "Updated short URL", slog.String("short_id", shortID), slog.String("original_url", updatedURL.OriginalUrl), slog.Any("tags", updatedURL.Tags))
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(h.newShortenURLResponse(r, updatedURL)); err != nil {
+		slog.ErrorContext(r.Context(), "Error encoding update response", slog.String("short_id", shortID), slog.Any("error", err))
+	}
+}
+
+// deleteURLHandler removes a short URL. It requires the admin bearer token.
+func (h *URLHandler) deleteURLHandler(w http.ResponseWriter, r *http.Request, shortID string) {
+	ctx, span := tracer.Start(r.Context(), "handler.DeleteURL", trace.WithAttributes(attribute.String(tracing.AttrShortID, shortID)))
+	defer span.End()
+	r = r.WithContext(ctx)
+
+	if !h.authorizeAdmin(w, r) {
+		return
+	}
+	r = r.WithContext(withAdminActor(r.Context()))
+
+	if err := h.urlService.DeleteShortURL(r.Context(), shortID); err != nil {
+		if errors.Is(err, store.ErrURLNotFound) {
+			WriteError(w, http.StatusNotFound, ErrCodeNotFound, fmt.Sprintf("Short URL '%s' not found", shortID))
+		} else {
+			slog.ErrorContext(r.Context(), "Error deleting short URL", slog.String("short_id", shortID), slog.Any("error", err))
+			WriteError(w, http.StatusInternalServerError, ErrCodeInternal, "Failed to delete URL")
+		}
+		return
+	}
+
+	slog.InfoContext(r.Context(), "Deleted short URL", slog.String("short_id", shortID))
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// writeLookupError writes the response for an error returned while resolving a short ID:
+// 410 for deleted and expired links, 404 for unknown ones and ones that are not active yet,
+// 503 while the store's circuit breaker is open and 500 otherwise.
+func writeLookupError(w http.ResponseWriter, r *http.Request, shortID string, err error) {
+	if errors.Is(err, circuitbreaker.ErrCircuitOpen) {
+		w.Header().Set("Retry-After", strconv.Itoa(int(circuitbreaker.OpenTimeout.Seconds())))
+		WriteError(w, http.StatusServiceUnavailable, ErrCodeServiceUnavailable, "Service temporarily unavailable, please try again shortly")
+	} else if errors.Is(err, store.ErrURLDeleted) {
+		writeDeletedError(w)
+	} else if errors.Is(err, service.ErrURLExpired) {
+		WriteError(w, http.StatusGone, ErrCodeGone, fmt.Sprintf("Short URL '%s' has expired", shortID))
+	} else if errors.Is(err, service.ErrURLNotYetActive) {
+		// Until it goes live, the link is indistinguishable from an unknown one.
+		WriteError(w, http.StatusNotFound, ErrCodeNotFound, fmt.Sprintf("Short URL '%s' not found", shortID))
+	} else if errors.Is(err, store.ErrURLNotFound) {
+		WriteError(w, http.StatusNotFound, ErrCodeNotFound, fmt.Sprintf("Short URL '%s' not found", shortID))
+	} else {
+		slog.ErrorContext(r.Context(), "Error retrieving original URL", slog.String("short_id", shortID), slog.Any("error", err))
+		WriteError(w, http.StatusInternalServerError, ErrCodeInternal, "Error retrieving URL")
+	}
+}
+
+// writeDeletedError tells the client that a link existed but has been deleted,
+// which is less confusing than a 404 for a link that used to be public.
+func writeDeletedError(w http.ResponseWriter) {
+	WriteError(w, http.StatusGone, ErrCodeGone, "This link has been removed")
+}
+
+// checkLinkPassword compares the X-Short-URL-Password header with the password of a protected
+// link and writes a 401 if it does not match. It returns true when the request may proceed.
+func checkLinkPassword(w http.ResponseWriter, r *http.Request, urlEntry domain.URL) bool {
+	if !urlEntry.IsPasswordProtected() {
+		return true
+	}
+	password := r.Header.Get(passwordHeader)
+	if password == "" {
+		WriteError(w, http.StatusUnauthorized, ErrCodeUnauthorized, "This short URL is password protected; send the password in the "+passwordHeader+" header")
+		return false
+	}
+	if bcrypt.CompareHashAndPassword([]byte(urlEntry.PasswordHash), []byte(password)) != nil {
+		WriteError(w, http.StatusUnauthorized, ErrCodeUnauthorized, "Invalid password")
+		return false
+	}
+	return true
+}
+
+// apiBaseURL returns the public URL of the versioned API, e.g. "https://example.com/api/v1".
+func (h *URLHandler) apiBaseURL(r *http.Request) string {
+	return h.requestBaseURL(r) + h.apiPrefix
+}
+
+// shortLink returns the full short URL that redirects to the link with the given short ID.
+func (h *URLHandler) shortLink(r *http.Request, shortID string) string {
+	return fmt.Sprintf("%s/r/%s", h.apiBaseURL(r), shortID)
+}
+
+// requestBaseURL returns the public base URL of short links, e.g. "https://example.com".
+// It is BASE_URL when configured, with its host replaced by the request's Host if that is one of
+// DOMAIN_ALIASES, and otherwise the scheme and host the request was received on, which is wrong
+// behind a reverse proxy that rewrites Host.
+func (h *URLHandler) requestBaseURL(r *http.Request) string {
+	if h.baseURL != "" {
+		host := strings.ToLower(r.Host)
+		if !slices.Contains(h.cfg.DomainAliases, host) {
+			return h.baseURL
+		}
+		// The config has already checked that BASE_URL parses.
+		base, err := url.Parse(h.baseURL)
+		if err != nil {
+			return h.baseURL
+		}
+		base.Host = host
+		return base.String()
+	}
+	scheme := "http"
+	if r.TLS != nil {
+		scheme = "https"
+	}
+	return fmt.Sprintf("%s://%s", scheme, r.Host)
+}
+
+// requestDomain returns the host of the short links handed out for r, e.g. "go.example.com",
+// which is recorded as the domain of the links it creates.
+func (h *URLHandler) requestDomain(r *http.Request) string {
+	base, err := url.Parse(h.requestBaseURL(r))
+	if err != nil {
+		return ""
+	}
+	return base.Host
+}
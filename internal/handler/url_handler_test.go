@@ -0,0 +1,366 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"shawty/internal/circuitbreaker"
+	"shawty/internal/config"
+	"shawty/internal/domain"
+	"shawty/internal/service"
+	"shawty/internal/store"
+)
+
+// mockURLService stubs the service calls of the URL handlers. Calls without a stub panic through
+// the nil embedded interface, except the visit and click recording that every redirect starts.
+type mockURLService struct {
+	service.UrlServiceInterface
+	createShortURL  func(ctx context.Context, originalURL string, opts service.CreateOptions) (domain.URL, error)
+	resolveShortURL func(ctx context.Context, shortID string) (domain.URL, error)
+	getURLDetails   func(ctx context.Context, shortID string) (domain.URL, error)
+}
+
+func (m *mockURLService) CreateShortURL(ctx context.Context, originalURL string, opts service.CreateOptions) (domain.URL, error) {
+	return m.createShortURL(ctx, originalURL, opts)
+}
+
+func (m *mockURLService) ResolveShortURL(ctx context.Context, shortID string) (domain.URL, error) {
+	return m.resolveShortURL(ctx, shortID)
+}
+
+func (m *mockURLService) GetURLDetails(ctx context.Context, shortID string) (domain.URL, error) {
+	return m.getURLDetails(ctx, shortID)
+}
+
+func (m *mockURLService) ListAliases(ctx context.Context, primaryID string) ([]string, error) {
+	return nil, nil
+}
+
+func (m *mockURLService) RecordVisit(ctx context.Context, shortID string, mobile bool) error {
+	return nil
+}
+
+func (m *mockURLService) RecordClick(ctx context.Context, evt domain.ClickEvent) error {
+	return nil
+}
+
+// newTestMux registers the routes of a URLHandler for svc under /api/v1.
+func newTestMux(t *testing.T, svc service.UrlServiceInterface, cfg config.AppConfig) *http.ServeMux {
+	t.Helper()
+	h, err := NewURLHandler(svc, cfg)
+	if err != nil {
+		t.Fatalf("NewURLHandler: %v", err)
+	}
+	mux := http.NewServeMux()
+	h.RegisterRoutes(mux, "v1")
+	return mux
+}
+
+// newMemoryService returns a UrlService on an empty in-memory store, with the store itself.
+func newMemoryService(opts ...service.Option) (*service.UrlService, *store.MemoryUrlStore) {
+	memStore := store.NewMemoryUrlStore()
+	return service.NewUrlService(memStore, opts...), memStore
+}
+
+// mustShorten creates a short URL for originalURL through svc and returns its short ID.
+func mustShorten(t *testing.T, svc service.UrlServiceInterface, originalURL string, opts service.CreateOptions) string {
+	t.Helper()
+	created, err := svc.CreateShortURL(context.Background(), originalURL, opts)
+	if err != nil {
+		t.Fatalf("CreateShortURL(%q): %v", originalURL, err)
+	}
+	return created.ID
+}
+
+// saveURLs stores n entries with the short IDs id0000, id0001, ... directly in memStore.
+func saveURLs(t *testing.T, memStore *store.MemoryUrlStore, n int) {
+	t.Helper()
+	for i := range n {
+		id := fmt.Sprintf("id%04d", i)
+		entry := domain.URL{ID: id, ShortUrl: id, OriginalUrl: fmt.Sprintf("https://example.com/%d", i), CreationDate: time.Now().UTC()}
+		if err := memStore.Save(context.Background(), entry); err != nil {
+			t.Fatalf("Save: %v", err)
+		}
+	}
+}
+
+// serve sends a request through handler and returns the recorded response.
+func serve(handler http.Handler, method, target, body string, header http.Header) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, target, strings.NewReader(body))
+	for name, values := range header {
+		req.Header[name] = values
+	}
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+	return rec
+}
+
+// errorCode decodes the code of an ErrorResponse, failing the test if the body is not one.
+func errorCode(t *testing.T, rec *httptest.ResponseRecorder) string {
+	t.Helper()
+	var body ErrorResponse
+	if err := json.Unmarshal(rec.Body.Bytes(), &body); err != nil {
+		t.Fatalf("error body %q is not JSON: %v", rec.Body.String(), err)
+	}
+	return body.Code
+}
+
+func TestShortenURLHandler(t *testing.T) {
+	created := domain.URL{
+		ID:           "abc123",
+		ShortUrl:     "abc123",
+		OriginalUrl:  "https://example.com",
+		CreationDate: time.Date(2025, 1, 2, 3, 4, 5, 0, time.UTC),
+	}
+	tests := []struct {
+		name       string
+		method     string
+		body       string
+		err        error
+		wantStatus int
+		wantCode   string
+	}{
+		{name: "missing url field", method: http.MethodPost, body: `{}`, wantStatus: http.StatusBadRequest, wantCode: ErrCodeValidation},
+		{name: "empty url", method: http.MethodPost, body: `{"url": ""}`, wantStatus: http.StatusBadRequest, wantCode: ErrCodeValidation},
+		{name: "malformed body", method: http.MethodPost, body: `{"url":`, wantStatus: http.StatusBadRequest, wantCode: ErrCodeValidation},
+		{name: "wrong method", method: http.MethodGet, wantStatus: http.StatusMethodNotAllowed, wantCode: ErrCodeMethodNotAllowed},
+		{name: "hash collision", method: http.MethodPost, body: `{"url": "https://example.com"}`, err: service.ErrHashCollision, wantStatus: http.StatusConflict, wantCode: ErrCodeHashCollision},
+		{name: "custom code taken", method: http.MethodPost, body: `{"url": "https://example.com", "custom_code": "taken"}`, err: service.ErrCustomCodeTaken, wantStatus: http.StatusConflict, wantCode: ErrCodeConflict},
+		{name: "reserved custom code", method: http.MethodPost, body: `{"url": "https://example.com", "custom_code": "admin"}`, err: service.ErrReservedCode, wantStatus: http.StatusUnprocessableEntity, wantCode: ErrCodeValidation},
+		{name: "invalid url", method: http.MethodPost, body: `{"url": "ftp://example.com"}`, err: service.ErrInvalidURL, wantStatus: http.StatusUnprocessableEntity, wantCode: ErrCodeValidation},
+		{name: "store unavailable", method: http.MethodPost, body: `{"url": "https://example.com"}`, err: circuitbreaker.ErrCircuitOpen, wantStatus: http.StatusServiceUnavailable, wantCode: ErrCodeServiceUnavailable},
+		{name: "unexpected error", method: http.MethodPost, body: `{"url": "https://example.com"}`, err: errors.New("connection reset"), wantStatus: http.StatusInternalServerError, wantCode: ErrCodeInternal},
+		{name: "created", method: http.MethodPost, body: `{"url": "https://example.com"}`, wantStatus: http.StatusCreated},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			svc := &mockURLService{
+				createShortURL: func(ctx context.Context, originalURL string, opts service.CreateOptions) (domain.URL, error) {
+					if tt.err != nil {
+						return domain.URL{}, tt.err
+					}
+					return created, nil
+				},
+			}
+			rec := serve(newTestMux(t, svc, config.AppConfig{}), tt.method, "/api/v1/shorten", tt.body, nil)
+
+			if rec.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d; body %s", rec.Code, tt.wantStatus, rec.Body)
+			}
+			if tt.wantCode != "" {
+				if code := errorCode(t, rec); code != tt.wantCode {
+					t.Errorf("code = %q, want %q", code, tt.wantCode)
+				}
+				return
+			}
+			var resp ShortenURLResponse
+			if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+				t.Fatalf("decoding response: %v", err)
+			}
+			if want := "http://example.com/api/v1/r/abc123"; resp.ShortURL != want {
+				t.Errorf("short_url = %q, want %q", resp.ShortURL, want)
+			}
+			if resp.OriginalURL != created.OriginalUrl {
+				t.Errorf("original_url = %q, want %q", resp.OriginalURL, created.OriginalUrl)
+			}
+		})
+	}
+}
+
+func TestRedirectURLHandler(t *testing.T) {
+	tests := []struct {
+		name           string
+		path           string
+		entry          domain.URL
+		err            error
+		wantStatus     int
+		wantCode       string
+		wantLocation   string
+		wantRetryAfter string
+	}{
+		{name: "no short ID", path: "/api/v1/r/", wantStatus: http.StatusBadRequest, wantCode: ErrCodeValidation},
+		{name: "temporary redirect", path: "/api/v1/r/abc123", entry: domain.URL{ID: "abc123", OriginalUrl: "https://example.com/page"}, wantStatus: http.StatusFound, wantLocation: "https://example.com/page"},
+		{name: "permanent redirect", path: "/api/v1/r/abc123", entry: domain.URL{ID: "abc123", OriginalUrl: "https://example.com/page", RedirectType: domain.RedirectPermanent}, wantStatus: http.StatusMovedPermanently, wantLocation: "https://example.com/page"},
+		{name: "destination without scheme", path: "/api/v1/r/abc123", entry: domain.URL{ID: "abc123", OriginalUrl: "example.com"}, wantStatus: http.StatusFound, wantLocation: "http://example.com"},
+		{name: "not found", path: "/api/v1/r/missing", err: store.ErrURLNotFound, wantStatus: http.StatusNotFound, wantCode: ErrCodeNotFound},
+		{name: "expired", path: "/api/v1/r/abc123", err: service.ErrURLExpired, wantStatus: http.StatusGone, wantCode: ErrCodeGone},
+		{name: "deleted", path: "/api/v1/r/abc123", err: store.ErrURLDeleted, wantStatus: http.StatusGone, wantCode: ErrCodeGone},
+		{name: "store unavailable", path: "/api/v1/r/abc123", err: circuitbreaker.ErrCircuitOpen, wantStatus: http.StatusServiceUnavailable, wantCode: ErrCodeServiceUnavailable, wantRetryAfter: "30"},
+		{name: "unexpected error", path: "/api/v1/r/abc123", err: errors.New("connection reset"), wantStatus: http.StatusInternalServerError, wantCode: ErrCodeInternal},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			svc := &mockURLService{
+				resolveShortURL: func(ctx context.Context, shortID string) (domain.URL, error) {
+					return tt.entry, tt.err
+				},
+			}
+			rec := serve(newTestMux(t, svc, config.AppConfig{}), http.MethodGet, tt.path, "", nil)
+
+			if rec.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d; body %s", rec.Code, tt.wantStatus, rec.Body)
+			}
+			if tt.wantCode != "" {
+				if code := errorCode(t, rec); code != tt.wantCode {
+					t.Errorf("code = %q, want %q", code, tt.wantCode)
+				}
+			}
+			if location := rec.Header().Get("Location"); location != tt.wantLocation {
+				t.Errorf("Location = %q, want %q", location, tt.wantLocation)
+			}
+			if retryAfter := rec.Header().Get("Retry-After"); retryAfter != tt.wantRetryAfter {
+				t.Errorf("Retry-After = %q, want %q", retryAfter, tt.wantRetryAfter)
+			}
+		})
+	}
+}
+
+func TestRedirectURLHandlerByDevice(t *testing.T) {
+	svc, _ := newMemoryService()
+	shortID := mustShorten(t, svc, "https://example.com/desktop", service.CreateOptions{MobileURL: "https://m.example.com/app"})
+	mux := newTestMux(t, svc, config.AppConfig{})
+
+	tests := []struct {
+		name         string
+		userAgent    string
+		wantLocation string
+	}{
+		{name: "mobile", userAgent: iPhoneUserAgent, wantLocation: "https://m.example.com/app"},
+		{name: "desktop", userAgent: desktopUserAgent, wantLocation: "https://example.com/desktop"},
+		{name: "no user agent", wantLocation: "https://example.com/desktop"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := serve(mux, http.MethodGet, "/api/v1/r/"+shortID, "", http.Header{"User-Agent": {tt.userAgent}})
+			if rec.Code != http.StatusFound {
+				t.Fatalf("status = %d, want 302; body %s", rec.Code, rec.Body)
+			}
+			if location := rec.Header().Get("Location"); location != tt.wantLocation {
+				t.Errorf("Location = %q, want %q", location, tt.wantLocation)
+			}
+			if vary := rec.Header().Get("Vary"); vary != "User-Agent" {
+				t.Errorf("Vary = %q, want User-Agent", vary)
+			}
+		})
+	}
+
+	// Visits are recorded in the background: every visit counts, mobile ones also separately.
+	deadline := time.Now().Add(2 * time.Second)
+	for {
+		details, err := svc.GetURLDetails(context.Background(), shortID)
+		if err != nil {
+			t.Fatalf("GetURLDetails: %v", err)
+		}
+		if details.ClickCount == 3 && details.MobileClicks == 1 {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("click_count = %d, mobile_click_count = %d; want 3 and 1", details.ClickCount, details.MobileClicks)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestInfoURLHandler(t *testing.T) {
+	past := time.Now().Add(-time.Hour)
+	tests := []struct {
+		name        string
+		method      string
+		entry       domain.URL
+		err         error
+		wantStatus  int
+		wantCode    string
+		wantExpired bool
+	}{
+		{name: "found", method: http.MethodGet, entry: domain.URL{ID: "abc123", OriginalUrl: "https://example.com"}, wantStatus: http.StatusOK},
+		{name: "expired link is described", method: http.MethodGet, entry: domain.URL{ID: "abc123", OriginalUrl: "https://example.com", ExpiresAt: &past}, wantStatus: http.StatusOK, wantExpired: true},
+		{name: "not found", method: http.MethodGet, err: store.ErrURLNotFound, wantStatus: http.StatusNotFound, wantCode: ErrCodeNotFound},
+		{name: "wrong method", method: http.MethodPost, wantStatus: http.StatusMethodNotAllowed, wantCode: ErrCodeMethodNotAllowed},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			svc := &mockURLService{
+				getURLDetails: func(ctx context.Context, shortID string) (domain.URL, error) {
+					return tt.entry, tt.err
+				},
+			}
+			rec := serve(newTestMux(t, svc, config.AppConfig{}), tt.method, "/api/v1/r/abc123/info", "", nil)
+
+			if rec.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d; body %s", rec.Code, tt.wantStatus, rec.Body)
+			}
+			if tt.wantCode != "" {
+				if code := errorCode(t, rec); code != tt.wantCode {
+					t.Errorf("code = %q, want %q", code, tt.wantCode)
+				}
+				return
+			}
+			var resp URLInfoResponse
+			if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+				t.Fatalf("decoding response: %v", err)
+			}
+			if resp.OriginalUrl != tt.entry.OriginalUrl || resp.IsExpired != tt.wantExpired {
+				t.Errorf("got original_url %q, is_expired %v; want %q, %v", resp.OriginalUrl, resp.IsExpired, tt.entry.OriginalUrl, tt.wantExpired)
+			}
+		})
+	}
+}
+
+func TestInfoHandlerRevalidatesWithETag(t *testing.T) {
+	svc, _ := newMemoryService()
+	shortID := mustShorten(t, svc, "https://example.com/page", service.CreateOptions{})
+	mux := newTestMux(t, svc, config.AppConfig{AdminToken: testAdminToken})
+	target := "/api/v1/r/" + shortID + "/info"
+
+	first := serve(mux, http.MethodGet, target, "", nil)
+	etag := first.Header().Get("ETag")
+	if first.Code != http.StatusOK || first.Body.Len() == 0 || etag == "" {
+		t.Fatalf("first GET: status = %d, ETag %q, %d body bytes; want 200 with a body and an ETag", first.Code, etag, first.Body.Len())
+	}
+
+	revalidated := serve(mux, http.MethodGet, target, "", http.Header{"If-None-Match": {etag}})
+	if revalidated.Code != http.StatusNotModified || revalidated.Body.Len() != 0 {
+		t.Errorf("matching If-None-Match: status = %d with %d body bytes, want 304 without a body", revalidated.Code, revalidated.Body.Len())
+	}
+
+	// Changing the link changes its info, so the old ETag no longer matches.
+	if rec := serve(mux, http.MethodPatch, "/api/v1/r/"+shortID, `{"url": "https://example.com/moved"}`, adminHeader()); rec.Code != http.StatusOK {
+		t.Fatalf("update: status = %d, want 200; body %s", rec.Code, rec.Body)
+	}
+	stale := serve(mux, http.MethodGet, target, "", http.Header{"If-None-Match": {etag}})
+	if stale.Code != http.StatusOK || !strings.Contains(stale.Body.String(), "https://example.com/moved") {
+		t.Errorf("stale If-None-Match: status = %d, body %s; want 200 with the updated info", stale.Code, stale.Body)
+	}
+	if stale.Header().Get("ETag") == etag {
+		t.Error("the ETag did not change with the info")
+	}
+}
+
+func TestUnknownShortIDReturnsJSONNotFound(t *testing.T) {
+	svc, _ := newMemoryService()
+	mux := newTestMux(t, svc, config.AppConfig{AdminToken: testAdminToken})
+
+	for _, target := range []string{"/api/v1/r/nope00", "/api/v1/r/nope00/info"} {
+		rec := serve(mux, http.MethodGet, target, "", nil)
+		if rec.Code != http.StatusNotFound {
+			t.Fatalf("GET %s: status = %d, want 404; body %s", target, rec.Code, rec.Body)
+		}
+		if ct := rec.Header().Get("Content-Type"); ct != "application/json" {
+			t.Errorf("GET %s: Content-Type = %q, want application/json", target, ct)
+		}
+		var body map[string]string
+		if err := json.Unmarshal(rec.Body.Bytes(), &body); err != nil {
+			t.Fatalf("GET %s: body %q is not JSON: %v", target, rec.Body, err)
+		}
+		if body["code"] != ErrCodeNotFound || !strings.Contains(body["error"], "nope00") || len(body) != 2 {
+			t.Errorf("GET %s: body = %v, want an error naming nope00 with code %s", target, body, ErrCodeNotFound)
+		}
+	}
+}
@@ -0,0 +1,107 @@
+package handler
+
+import (
+	"encoding/json"
+	"math"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"shawty/internal/config"
+	"shawty/internal/domain"
+	"shawty/internal/service"
+)
+
+func TestVariantRedirectsFollowWeights(t *testing.T) {
+	analytics := &fakeAnalyticsStore{}
+	svc, _ := newMemoryService(service.WithAnalyticsStore(analytics))
+	mux := newTestMux(t, svc, config.AppConfig{})
+
+	body := `{"url": "https://93.184.216.34/original", "variants": [
+		{"name": "blue", "url": "https://93.184.216.34/blue", "weight": 60},
+		{"name": "green", "url": "https://93.184.216.34/green", "weight": 30}
+	]}`
+	rec := serve(mux, http.MethodPost, "/api/v1/shorten", body, nil)
+	if rec.Code != http.StatusCreated {
+		t.Fatalf("shorten: status = %d, want 201; body %s", rec.Code, rec.Body)
+	}
+	var created ShortenURLResponse
+	if err := json.Unmarshal(rec.Body.Bytes(), &created); err != nil {
+		t.Fatalf("decoding response: %v", err)
+	}
+	shortID := created.ShortURL[strings.LastIndex(created.ShortURL, "/")+1:]
+
+	// The original URL receives the 10% the variants leave.
+	wantShare := map[string]float64{
+		"https://93.184.216.34/blue":     0.6,
+		"https://93.184.216.34/green":    0.3,
+		"https://93.184.216.34/original": 0.1,
+	}
+	const calls = 10000
+	served := make(map[string]int)
+	for range calls {
+		rec := serve(mux, http.MethodGet, "/api/v1/r/"+shortID, "", nil)
+		if rec.Code != http.StatusFound {
+			t.Fatalf("redirect: status = %d, want 302", rec.Code)
+		}
+		served[rec.Header().Get("Location")]++
+	}
+	for location, want := range wantShare {
+		if got := float64(served[location]) / calls; math.Abs(got-want) > 0.05 {
+			t.Errorf("%s served %.3f of the redirects, want %.2f ± 0.05", location, got, want)
+		}
+	}
+	if len(served) != len(wantShare) {
+		t.Errorf("redirected to %v, want only the variants and the original URL", served)
+	}
+
+	// Every click records the variant it was sent to.
+	analytics.waitForClicks(t, calls)
+	today := time.Now().UTC().Format(dateLayout)
+	rec = serve(mux, http.MethodGet, "/api/v1/stats/"+shortID+"/variants?from="+today+"&to="+today, "", nil)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("variant stats: status = %d, want 200; body %s", rec.Code, rec.Body)
+	}
+	var stats []domain.VariantStats
+	if err := json.Unmarshal(rec.Body.Bytes(), &stats); err != nil {
+		t.Fatalf("decoding variant stats: %v", err)
+	}
+	wantNames := []string{"blue", "green", domain.VariantOriginal}
+	if len(stats) != len(wantNames) {
+		t.Fatalf("variant stats = %+v, want entries for %v", stats, wantNames)
+	}
+	for i, st := range stats {
+		if st.Name != wantNames[i] || st.Clicks != int64(served[st.URL]) {
+			t.Errorf("stats[%d] = %s with %d clicks, want %s with %d", i, st.Name, st.Clicks, wantNames[i], served[st.URL])
+		}
+		if math.Abs(st.ClickShare-wantShare[st.URL]) > 0.05 {
+			t.Errorf("%s click share = %.3f, want %.2f ± 0.05", st.Name, st.ClickShare, wantShare[st.URL])
+		}
+	}
+}
+
+func TestShortenRejectsInvalidVariants(t *testing.T) {
+	svc, _ := newMemoryService()
+	mux := newTestMux(t, svc, config.AppConfig{})
+	tests := []struct {
+		name       string
+		variants   string
+		wantStatus int
+	}{
+		{name: "weights above 100", variants: `[{"url": "https://93.184.216.34/a", "weight": 60}, {"url": "https://93.184.216.34/b", "weight": 50}]`, wantStatus: http.StatusBadRequest},
+		{name: "zero weight", variants: `[{"url": "https://93.184.216.34/a", "weight": 0}]`, wantStatus: http.StatusBadRequest},
+		{name: "duplicate names", variants: `[{"name": "x", "url": "https://93.184.216.34/a", "weight": 10}, {"name": "x", "url": "https://93.184.216.34/b", "weight": 10}]`, wantStatus: http.StatusBadRequest},
+		{name: "reserved name", variants: `[{"name": "original", "url": "https://93.184.216.34/a", "weight": 10}]`, wantStatus: http.StatusBadRequest},
+		{name: "invalid url", variants: `[{"url": "javascript:alert(1)", "weight": 10}]`, wantStatus: http.StatusUnprocessableEntity},
+	}
+	for _, tt := range tests {
+		rec := serve(mux, http.MethodPost, "/api/v1/shorten", `{"url": "https://93.184.216.34/original", "variants": `+tt.variants+`}`, nil)
+		if rec.Code != tt.wantStatus {
+			t.Errorf("%s: status = %d, want %d; body %s", tt.name, rec.Code, tt.wantStatus, rec.Body)
+		}
+		if code := errorCode(t, rec); code != ErrCodeValidation {
+			t.Errorf("%s: code = %q, want %q", tt.name, code, ErrCodeValidation)
+		}
+	}
+}
@@ -0,0 +1,83 @@
+// Package logger configures the structured logger used across Shawty.
+package logger
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"os"
+	"strings"
+
+	"shawty/internal/middleware"
+)
+
+// Supported values for LOG_FORMAT.
+const (
+	FormatJSON = "json"
+	FormatText = "text"
+)
+
+// Logger is the process-wide structured logger. It starts as a text logger at info level
+// and is replaced by Configure once the configuration has been loaded.
+var Logger = slog.New(slog.NewTextHandler(os.Stderr, nil))
+
+// Configure builds Logger from a level (debug, info, warn, error) and a format (json, text)
+// and installs it as the slog default, so package-level slog calls use it too.
+// Empty values fall back to info and json.
+func Configure(level, format string) error {
+	var lvl slog.Level
+	switch strings.ToLower(level) {
+	case "debug":
+		lvl = slog.LevelDebug
+	case "", "info":
+		lvl = slog.LevelInfo
+	case "warn", "warning":
+		lvl = slog.LevelWarn
+	case "error":
+		lvl = slog.LevelError
+	default:
+		return fmt.Errorf("invalid log level %q: must be debug, info, warn or error", level)
+	}
+
+	opts := &slog.HandlerOptions{Level: lvl}
+	var handler slog.Handler
+	switch strings.ToLower(format) {
+	case "", FormatJSON:
+		handler = slog.NewJSONHandler(os.Stderr, opts)
+	case FormatText:
+		handler = slog.NewTextHandler(os.Stderr, opts)
+	default:
+		return fmt.Errorf("invalid log format %q: must be json or text", format)
+	}
+
+	Logger = slog.New(&contextHandler{Handler: handler})
+	slog.SetDefault(Logger)
+	return nil
+}
+
+// contextHandler adds request-scoped values from the context to every record,
+// so that the *Context logging calls of all layers can be correlated per request.
+type contextHandler struct {
+	slog.Handler
+}
+
+func (h *contextHandler) Handle(ctx context.Context, record slog.Record) error {
+	if id := middleware.GetRequestID(ctx); id != "" {
+		record.AddAttrs(slog.String("request_id", id))
+	}
+	return h.Handler.Handle(ctx, record)
+}
+
+func (h *contextHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return &contextHandler{Handler: h.Handler.WithAttrs(attrs)}
+}
+
+func (h *contextHandler) WithGroup(name string) slog.Handler {
+	return &contextHandler{Handler: h.Handler.WithGroup(name)}
+}
+
+// Fatal logs msg at error level and exits the process, replacing log.Fatalf at startup.
+func Fatal(msg string, args ...any) {
+	Logger.Error(msg, args...)
+	os.Exit(1)
+}
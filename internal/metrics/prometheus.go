@@ -0,0 +1,140 @@
+// Package metrics defines the Prometheus metrics exported by Shawty.
+package metrics
+
+import (
+	"crypto/subtle"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+var (
+	// RequestsTotal counts handled HTTP requests by route pattern, method and status code.
+	RequestsTotal = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "shawty_requests_total",
+		Help: "Total number of HTTP requests handled.",
+	}, []string{"endpoint", "method", "status_code"})
+
+	// RequestDuration observes HTTP request latency by route pattern.
+	RequestDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "shawty_request_duration_seconds",
+		Help:    "HTTP request latency in seconds.",
+		Buckets: prometheus.DefBuckets,
+	}, []string{"endpoint"})
+
+	// ActiveConnections tracks the number of open client connections.
+	ActiveConnections = promauto.NewGauge(prometheus.GaugeOpts{
+		Name: "shawty_active_connections",
+		Help: "Number of currently open client connections.",
+	})
+
+	// URLsShortened counts newly created short URLs.
+	URLsShortened = promauto.NewCounter(prometheus.CounterOpts{
+		Name: "shawty_urls_shortened_total",
+		Help: "Total number of short URLs created.",
+	})
+
+	// CacheHits counts short ID lookups answered by the in-process URL cache.
+	CacheHits = promauto.NewCounter(prometheus.CounterOpts{
+		Name: "shawty_cache_hits_total",
+		Help: "Total number of short ID lookups answered by the in-process cache.",
+	})
+
+	// CacheMisses counts short ID lookups the in-process URL cache had to pass to the store.
+	CacheMisses = promauto.NewCounter(prometheus.CounterOpts{
+		Name: "shawty_cache_misses_total",
+		Help: "Total number of short ID lookups the in-process cache passed to the store.",
+	})
+
+	// CircuitBreakerState is the state of each store circuit breaker: 0 closed, 1 half-open, 2 open.
+	CircuitBreakerState = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "shawty_circuit_breaker_state",
+		Help: "State of the store circuit breaker: 0 closed, 1 half-open, 2 open.",
+	}, []string{"breaker"})
+
+	// CircuitBreakerTransitions counts state changes of the store circuit breakers.
+	CircuitBreakerTransitions = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "shawty_circuit_breaker_transitions_total",
+		Help: "Total number of store circuit breaker state changes.",
+	}, []string{"breaker", "from", "to"})
+
+	// ExpiredURLsCleaned counts expired URLs removed by the cleanup job.
+	ExpiredURLsCleaned = promauto.NewCounter(prometheus.CounterOpts{
+		Name: "shawty_expired_urls_cleaned_total",
+		Help: "Total number of expired short URLs removed by the cleanup job.",
+	})
+
+	// EventsDropped counts events the in-process event bus dropped because its queue was full or it was closed.
+	EventsDropped = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "shawty_events_dropped_total",
+		Help: "Total number of events dropped because the event queue was full or closed.",
+	}, []string{"type"})
+)
+
+// responseWriter captures the status code written by the wrapped handler.
+type responseWriter struct {
+	http.ResponseWriter
+	status int
+}
+
+func (rw *responseWriter) WriteHeader(code int) {
+	rw.status = code
+	rw.ResponseWriter.WriteHeader(code)
+}
+
+// Unwrap lets http.ResponseController reach the underlying ResponseWriter.
+func (rw *responseWriter) Unwrap() http.ResponseWriter {
+	return rw.ResponseWriter
+}
+
+// PrometheusMiddleware returns middleware that records RequestsTotal and RequestDuration
+// for every request. The endpoint label is the pattern routes matches the request to,
+// e.g. "/r/" rather than "/r/abc123", which keeps label cardinality bounded.
+func PrometheusMiddleware(routes *http.ServeMux) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			start := time.Now()
+			rw := &responseWriter{ResponseWriter: w, status: http.StatusOK}
+
+			next.ServeHTTP(rw, r)
+
+			_, endpoint := routes.Handler(r)
+			if endpoint == "" {
+				endpoint = "unmatched"
+			}
+			RequestsTotal.WithLabelValues(endpoint, r.Method, strconv.Itoa(rw.status)).Inc()
+			RequestDuration.WithLabelValues(endpoint).Observe(time.Since(start).Seconds())
+		})
+	}
+}
+
+// TrackConnState keeps ActiveConnections up to date. Assign it to http.Server.ConnState.
+func TrackConnState(_ net.Conn, state http.ConnState) {
+	switch state {
+	case http.StateNew:
+		ActiveConnections.Inc()
+	case http.StateHijacked, http.StateClosed:
+		ActiveConnections.Dec()
+	}
+}
+
+// Handler serves the metrics in the Prometheus exposition format to requests that
+// carry "Authorization: Bearer <token>".
+func Handler(token string) http.Handler {
+	promHandler := promhttp.Handler()
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		provided, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+		if !ok || subtle.ConstantTimeCompare([]byte(provided), []byte(token)) != 1 {
+			w.Header().Set("WWW-Authenticate", `Bearer realm="shawty-metrics"`)
+			http.Error(w, "Missing or invalid metrics token", http.StatusUnauthorized)
+			return
+		}
+		promHandler.ServeHTTP(w, r)
+	})
+}
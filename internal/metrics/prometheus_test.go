@@ -0,0 +1,77 @@
+package metrics
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	dto "github.com/prometheus/client_model/go"
+)
+
+// durationSamples returns the number of observations and their sum in RequestDuration for endpoint.
+func durationSamples(t *testing.T, endpoint string) (uint64, float64) {
+	t.Helper()
+	var m dto.Metric
+	if err := RequestDuration.WithLabelValues(endpoint).(prometheus.Metric).Write(&m); err != nil {
+		t.Fatalf("reading the %s duration histogram: %v", endpoint, err)
+	}
+	return m.GetHistogram().GetSampleCount(), m.GetHistogram().GetSampleSum()
+}
+
+func TestPrometheusMiddlewareRecordsRequests(t *testing.T) {
+	routes := http.NewServeMux()
+	routes.HandleFunc("/r/", func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/r/missing" {
+			http.NotFound(w, r)
+			return
+		}
+		time.Sleep(10 * time.Millisecond)
+		http.Redirect(w, r, "https://example.com", http.StatusFound)
+	})
+	handler := PrometheusMiddleware(routes)(routes)
+
+	counters := []struct {
+		endpoint, method, status string
+		want                     float64
+	}{
+		{endpoint: "/r/", method: http.MethodGet, status: "302", want: 2},
+		{endpoint: "/r/", method: http.MethodGet, status: "404", want: 1},
+		{endpoint: "unmatched", method: http.MethodPost, status: "404", want: 1},
+	}
+	before := make([]float64, len(counters))
+	for i, c := range counters {
+		before[i] = testutil.ToFloat64(RequestsTotal.WithLabelValues(c.endpoint, c.method, c.status))
+	}
+	beforeCount, beforeSum := durationSamples(t, "/r/")
+
+	for _, req := range []struct{ method, target string }{
+		{http.MethodGet, "/r/abc123"},
+		{http.MethodGet, "/r/def456"},
+		{http.MethodGet, "/r/missing"},
+		{http.MethodPost, "/elsewhere"},
+	} {
+		handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(req.method, req.target, nil))
+	}
+
+	// The endpoint label is the route pattern, not the path, and the status is the one written.
+	for i, c := range counters {
+		got := testutil.ToFloat64(RequestsTotal.WithLabelValues(c.endpoint, c.method, c.status)) - before[i]
+		if got != c.want {
+			t.Errorf("requests counted for %s %s %s = %v, want %v", c.method, c.endpoint, c.status, got, c.want)
+		}
+	}
+	count, sum := durationSamples(t, "/r/")
+	if count-beforeCount != 3 {
+		t.Errorf("duration observations for /r/ = %d, want 3", count-beforeCount)
+	}
+	// Two of the requests slept for 10ms each.
+	if sum-beforeSum < 0.02 {
+		t.Errorf("observed duration sum for /r/ = %vs, want at least 0.02s", sum-beforeSum)
+	}
+	if got := testutil.CollectAndCount(RequestDuration, "shawty_request_duration_seconds"); got < 2 {
+		t.Errorf("duration histogram has %d series, want one each for /r/ and unmatched", got)
+	}
+}
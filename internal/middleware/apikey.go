@@ -0,0 +1,50 @@
+package middleware
+
+import (
+	"context"
+	"crypto/subtle"
+	"net/http"
+	"strings"
+)
+
+// contextKey is the type of the request context keys set by this package.
+type contextKey string
+
+// OwnerKey is the request context key under which NewAPIKeyMiddleware stores the owner of the API key.
+const OwnerKey contextKey = "owner"
+
+// NewAPIKeyMiddleware returns middleware that requires an "Authorization: Bearer <key>" header
+// whose key is in validKeys (key -> owner name). Authorized requests carry the owner in their
+// context under OwnerKey; all others get HTTP 401. An empty map rejects every request.
+func NewAPIKeyMiddleware(validKeys map[string]string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			key, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+			owner, found := lookupAPIKey(validKeys, key)
+			if !ok || !found {
+				w.Header().Set("WWW-Authenticate", `Bearer realm="shawty"`)
+				writeError(w, http.StatusUnauthorized, "UNAUTHORIZED", "Missing or invalid API key")
+				return
+			}
+			next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), OwnerKey, owner)))
+		})
+	}
+}
+
+// OwnerFromContext returns the API key owner stored by NewAPIKeyMiddleware, or "" if there is none.
+func OwnerFromContext(ctx context.Context) string {
+	owner, _ := ctx.Value(OwnerKey).(string)
+	return owner
+}
+
+// lookupAPIKey finds the owner of key, comparing every configured key in constant time
+// so the response time does not reveal how much of a key was guessed correctly.
+func lookupAPIKey(validKeys map[string]string, key string) (string, bool) {
+	owner, found := "", false
+	for candidate, candidateOwner := range validKeys {
+		if subtle.ConstantTimeCompare([]byte(candidate), []byte(key)) == 1 {
+			owner, found = candidateOwner, true
+		}
+	}
+	return owner, found && key != ""
+}
@@ -0,0 +1,55 @@
+package middleware
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestAPIKeyMiddleware(t *testing.T) {
+	// The handler echoes the owner the middleware stored in the context.
+	echoOwner := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		io.WriteString(w, OwnerFromContext(r.Context()))
+	})
+	keys := map[string]string{"key-alice": "alice", "key-bob": "bob"}
+
+	tests := []struct {
+		name          string
+		keys          map[string]string
+		authorization string
+		wantStatus    int
+		wantOwner     string
+	}{
+		{name: "missing header", keys: keys, wantStatus: http.StatusUnauthorized},
+		{name: "wrong key", keys: keys, authorization: "Bearer key-mallory", wantStatus: http.StatusUnauthorized},
+		{name: "empty key", keys: keys, authorization: "Bearer ", wantStatus: http.StatusUnauthorized},
+		{name: "key without bearer scheme", keys: keys, authorization: "key-alice", wantStatus: http.StatusUnauthorized},
+		{name: "no keys configured", keys: map[string]string{}, authorization: "Bearer key-alice", wantStatus: http.StatusUnauthorized},
+		{name: "valid key", keys: keys, authorization: "Bearer key-alice", wantStatus: http.StatusOK, wantOwner: "alice"},
+		{name: "another valid key", keys: keys, authorization: "Bearer key-bob", wantStatus: http.StatusOK, wantOwner: "bob"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := newRequest(http.MethodPost, "/shorten", "", "203.0.113.7")
+			if tt.authorization != "" {
+				req.Header.Set("Authorization", tt.authorization)
+			}
+			rec := httptest.NewRecorder()
+			NewAPIKeyMiddleware(tt.keys)(echoOwner).ServeHTTP(rec, req)
+
+			if rec.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d", rec.Code, tt.wantStatus)
+			}
+			if tt.wantStatus == http.StatusUnauthorized {
+				if rec.Header().Get("WWW-Authenticate") == "" {
+					t.Error("401 response has no WWW-Authenticate header")
+				}
+				return
+			}
+			if got := rec.Body.String(); got != tt.wantOwner {
+				t.Errorf("owner in context = %q, want %q", got, tt.wantOwner)
+			}
+		})
+	}
+}
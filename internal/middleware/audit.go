@@ -0,0 +1,23 @@
+package middleware
+
+import (
+	"net/http"
+
+	"shawty/internal/domain"
+)
+
+// NewAuditActorMiddleware returns middleware that records who is making a request, so that
+// stores can attribute the changes it makes in their audit log. The actor is the owner of the
+// request's API key (see NewAPIKeyMiddleware) and the client IP. It must run inside the API key
+// middleware to see the owner.
+func NewAuditActorMiddleware() func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			actor := domain.AuditActor{
+				Key:       OwnerFromContext(r.Context()),
+				IPAddress: clientIP(r),
+			}
+			next.ServeHTTP(w, r.WithContext(domain.WithAuditActor(r.Context(), actor)))
+		})
+	}
+}
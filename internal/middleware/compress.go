@@ -0,0 +1,172 @@
+package middleware
+
+import (
+	"compress/gzip"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// gzipMinSize is the smallest response that gets compressed. Anything shorter fits in a
+// single TCP packet anyway, so compressing it only costs CPU.
+const gzipMinSize = 1400
+
+var gzipWriterPool = sync.Pool{
+	New: func() any { return gzip.NewWriter(nil) },
+}
+
+// gzipResponseWriter buffers the start of a response until it knows whether the body
+// reaches gzipMinSize, then either compresses it or writes it through unchanged.
+type gzipResponseWriter struct {
+	http.ResponseWriter
+	status  int
+	buf     []byte
+	decided bool
+	gz      *gzip.Writer
+}
+
+func (gw *gzipResponseWriter) WriteHeader(code int) {
+	// Informational responses are sent straight away and do not count as the final status.
+	if code >= 100 && code < 200 {
+		gw.ResponseWriter.WriteHeader(code)
+		return
+	}
+	if gw.status == 0 {
+		gw.status = code
+	}
+}
+
+func (gw *gzipResponseWriter) Write(p []byte) (int, error) {
+	if gw.status == 0 {
+		gw.status = http.StatusOK
+	}
+	if gw.decided {
+		if gw.gz != nil {
+			return gw.gz.Write(p)
+		}
+		return gw.ResponseWriter.Write(p)
+	}
+
+	gw.buf = append(gw.buf, p...)
+	if len(gw.buf) >= gzipMinSize {
+		if err := gw.decide(true); err != nil {
+			return 0, err
+		}
+	}
+	return len(p), nil
+}
+
+// Flush sends everything written so far. A flushed response is treated as a stream of
+// unknown length, so it is compressed even if little has been written yet.
+func (gw *gzipResponseWriter) Flush() {
+	if !gw.decided {
+		if gw.status == 0 {
+			gw.status = http.StatusOK
+		}
+		if err := gw.decide(true); err != nil {
+			return
+		}
+	}
+	if gw.gz != nil {
+		gw.gz.Flush()
+	}
+	http.NewResponseController(gw.ResponseWriter).Flush()
+}
+
+// Unwrap lets http.ResponseController reach the underlying ResponseWriter.
+func (gw *gzipResponseWriter) Unwrap() http.ResponseWriter {
+	return gw.ResponseWriter
+}
+
+// decide writes the status line and the buffered body, compressed if compress is true
+// and the response allows it.
+func (gw *gzipResponseWriter) decide(compress bool) error {
+	gw.decided = true
+	header := gw.ResponseWriter.Header()
+	if compress && bodyAllowed(gw.status) && header.Get("Content-Encoding") == "" {
+		// The body would otherwise be sniffed after compression.
+		if header.Get("Content-Type") == "" {
+			header.Set("Content-Type", http.DetectContentType(gw.buf))
+		}
+		header.Set("Content-Encoding", "gzip")
+		header.Del("Content-Length")
+		gw.gz = gzipWriterPool.Get().(*gzip.Writer)
+		gw.gz.Reset(gw.ResponseWriter)
+	}
+
+	gw.ResponseWriter.WriteHeader(gw.status)
+	buf := gw.buf
+	gw.buf = nil
+	if len(buf) == 0 {
+		return nil
+	}
+	var err error
+	if gw.gz != nil {
+		_, err = gw.gz.Write(buf)
+	} else {
+		_, err = gw.ResponseWriter.Write(buf)
+	}
+	return err
+}
+
+// close finishes the response: short bodies are written uncompressed and the gzip stream,
+// if any, is terminated.
+func (gw *gzipResponseWriter) close() {
+	if !gw.decided {
+		if gw.status == 0 {
+			// The handler wrote nothing; let net/http send its implicit 200.
+			return
+		}
+		gw.decide(false)
+	}
+	if gw.gz != nil {
+		gw.gz.Close()
+		gw.gz.Reset(nil)
+		gzipWriterPool.Put(gw.gz)
+		gw.gz = nil
+	}
+}
+
+// bodyAllowed reports whether a response with the given status may carry a body.
+func bodyAllowed(status int) bool {
+	return status != http.StatusNoContent && status != http.StatusNotModified
+}
+
+// acceptsGzip reports whether the request's Accept-Encoding header allows gzip.
+func acceptsGzip(r *http.Request) bool {
+	for _, coding := range strings.Split(r.Header.Get("Accept-Encoding"), ",") {
+		name, params, _ := strings.Cut(coding, ";")
+		if strings.TrimSpace(name) != "gzip" {
+			continue
+		}
+		// "gzip;q=0" explicitly refuses gzip.
+		if q, ok := strings.CutPrefix(strings.TrimSpace(params), "q="); ok {
+			weight, err := strconv.ParseFloat(q, 64)
+			return err == nil && weight > 0
+		}
+		return true
+	}
+	return false
+}
+
+// NewGzipMiddleware returns middleware that gzip-compresses responses of at least 1400 bytes
+// for clients that send Accept-Encoding: gzip. Responses that already have a Content-Encoding
+// are left alone, and every response carries Vary: Accept-Encoding.
+func NewGzipMiddleware() func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Add("Vary", "Accept-Encoding")
+			if r.Method == http.MethodHead || !acceptsGzip(r) {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			// close is deliberately not deferred: if the handler panics, the buffered
+			// response is dropped so that recovery middleware can still send a 500.
+			gw := &gzipResponseWriter{ResponseWriter: w}
+			next.ServeHTTP(gw, r)
+			gw.close()
+		})
+	}
+}
@@ -0,0 +1,169 @@
+package middleware
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/hex"
+	"io"
+	"math/rand/v2"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// gzipped returns s compressed with gzip.
+func gzipped(t *testing.T, s string) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	io.WriteString(gz, s)
+	if err := gz.Close(); err != nil {
+		t.Fatalf("compressing: %v", err)
+	}
+	return buf.Bytes()
+}
+
+// gunzip decompresses body, failing the test if it is not a gzip stream.
+func gunzip(t *testing.T, body []byte) string {
+	t.Helper()
+	gz, err := gzip.NewReader(bytes.NewReader(body))
+	if err != nil {
+		t.Fatalf("response is not gzip: %v", err)
+	}
+	plain, err := io.ReadAll(gz)
+	if err != nil {
+		t.Fatalf("decompressing response: %v", err)
+	}
+	return string(plain)
+}
+
+func TestGzipMiddleware(t *testing.T) {
+	large := strings.Repeat("shawty ", gzipMinSize/7+1)
+	small := strings.Repeat("s", gzipMinSize-1)
+	writeBody := func(body string) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Content-Type", "text/plain")
+			io.WriteString(w, body)
+		})
+	}
+
+	tests := []struct {
+		name           string
+		method         string
+		acceptEncoding string
+		handler        http.Handler
+		wantGzip       bool
+		wantBody       string
+	}{
+		{name: "large body", acceptEncoding: "gzip", handler: writeBody(large), wantGzip: true, wantBody: large},
+		{name: "gzip among other codings", acceptEncoding: "deflate, gzip;q=0.5, br", handler: writeBody(large), wantGzip: true, wantBody: large},
+		{name: "without Accept-Encoding", handler: writeBody(large), wantBody: large},
+		{name: "other codings only", acceptEncoding: "br, deflate", handler: writeBody(large), wantBody: large},
+		{name: "gzip refused with q=0", acceptEncoding: "gzip;q=0", handler: writeBody(large), wantBody: large},
+		{name: "body below the minimum size", acceptEncoding: "gzip", handler: writeBody(small), wantBody: small},
+		{name: "body at the minimum size", acceptEncoding: "gzip", handler: writeBody(small + "s"), wantGzip: true, wantBody: small + "s"},
+		{name: "HEAD", method: http.MethodHead, acceptEncoding: "gzip", handler: writeBody(large), wantBody: large},
+		{name: "written in small pieces", acceptEncoding: "gzip", wantGzip: true, wantBody: large,
+			handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				for _, word := range strings.SplitAfter(large, " ") {
+					io.WriteString(w, word)
+				}
+			})},
+		{name: "no content", acceptEncoding: "gzip",
+			handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) { w.WriteHeader(http.StatusNoContent) })},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			method := tt.method
+			if method == "" {
+				method = http.MethodGet
+			}
+			req := httptest.NewRequest(method, "/", nil)
+			if tt.acceptEncoding != "" {
+				req.Header.Set("Accept-Encoding", tt.acceptEncoding)
+			}
+			rec := httptest.NewRecorder()
+			NewGzipMiddleware()(tt.handler).ServeHTTP(rec, req)
+
+			// Caches must key compressed and plain responses apart, whichever was sent.
+			if got := rec.Header().Values("Vary"); len(got) != 1 || got[0] != "Accept-Encoding" {
+				t.Errorf("Vary = %q, want [Accept-Encoding]", got)
+			}
+			if !tt.wantGzip {
+				if got := rec.Header().Get("Content-Encoding"); got != "" {
+					t.Errorf("Content-Encoding = %q, want none", got)
+				}
+				if rec.Body.String() != tt.wantBody {
+					t.Errorf("body has %d bytes, want the %d bytes written", rec.Body.Len(), len(tt.wantBody))
+				}
+				return
+			}
+			if got := rec.Header().Get("Content-Encoding"); got != "gzip" {
+				t.Fatalf("Content-Encoding = %q, want gzip", got)
+			}
+			if rec.Header().Get("Content-Length") != "" {
+				t.Errorf("Content-Length = %q on a compressed response, want none", rec.Header().Get("Content-Length"))
+			}
+			if rec.Body.Len() >= len(tt.wantBody) {
+				t.Errorf("compressed body has %d bytes, want fewer than the %d written", rec.Body.Len(), len(tt.wantBody))
+			}
+			if got := gunzip(t, rec.Body.Bytes()); got != tt.wantBody {
+				t.Errorf("decompressed body has %d bytes, want the %d bytes written", len(got), len(tt.wantBody))
+			}
+		})
+	}
+}
+
+func TestGzipMiddlewareDoesNotCompressTwice(t *testing.T) {
+	// Random bytes hardly compress, so the compressed body is still past the minimum size and only
+	// its Content-Encoding keeps it from being compressed again.
+	random := make([]byte, 2*gzipMinSize)
+	rand.NewChaCha8([32]byte{}).Read(random)
+	large := hex.EncodeToString(random)
+	precompressed := gzipped(t, large)
+	if len(precompressed) < gzipMinSize {
+		t.Fatalf("compressed body has %d bytes, want at least %d", len(precompressed), gzipMinSize)
+	}
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Encoding", "gzip")
+		w.Write(precompressed)
+	})
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set("Accept-Encoding", "gzip")
+	rec := httptest.NewRecorder()
+	NewGzipMiddleware()(handler).ServeHTTP(rec, req)
+
+	if got := rec.Header().Values("Content-Encoding"); len(got) != 1 || got[0] != "gzip" {
+		t.Errorf("Content-Encoding = %q, want a single gzip", got)
+	}
+	if !bytes.Equal(rec.Body.Bytes(), precompressed) {
+		t.Fatal("the already compressed body was changed")
+	}
+	if got := gunzip(t, rec.Body.Bytes()); got != large {
+		t.Errorf("decompressing once gave %d bytes, want the original %d", len(got), len(large))
+	}
+}
+
+func TestGzipMiddlewareCompressesFlushedStreams(t *testing.T) {
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		io.WriteString(w, "data: first\n\n")
+		http.NewResponseController(w).Flush()
+		io.WriteString(w, "data: second\n\n")
+	})
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set("Accept-Encoding", "gzip")
+	rec := httptest.NewRecorder()
+	NewGzipMiddleware()(handler).ServeHTTP(rec, req)
+
+	if !rec.Flushed {
+		t.Error("the flush did not reach the client")
+	}
+	if got := rec.Header().Get("Content-Encoding"); got != "gzip" {
+		t.Fatalf("Content-Encoding = %q, want gzip for a stream of unknown length", got)
+	}
+	if got := gunzip(t, rec.Body.Bytes()); got != "data: first\n\ndata: second\n\n" {
+		t.Errorf("decompressed body = %q, want both events", got)
+	}
+}
@@ -0,0 +1,34 @@
+package middleware
+
+import "net/http"
+
+// NewCORSMiddleware returns middleware that allows cross-origin browser requests from allowedOrigins.
+// Requests from any other origin get no Access-Control-Allow-Origin header, so browsers block them;
+// an empty list denies every cross-origin request. OPTIONS preflight requests are answered with 204.
+func NewCORSMiddleware(allowedOrigins []string) func(http.Handler) http.Handler {
+	allowed := make(map[string]bool, len(allowedOrigins))
+	for _, origin := range allowedOrigins {
+		allowed[origin] = true
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			// The response depends on the Origin header, so shared caches must key on it.
+			w.Header().Add("Vary", "Origin")
+
+			if origin := r.Header.Get("Origin"); origin != "" && allowed[origin] {
+				w.Header().Set("Access-Control-Allow-Origin", origin)
+				w.Header().Set("Access-Control-Allow-Methods", "POST, GET, DELETE, PATCH, OPTIONS")
+				w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization")
+			}
+
+			// Browsers send an OPTIONS request first to check if the actual request is allowed.
+			if r.Method == http.MethodOptions {
+				w.WriteHeader(http.StatusNoContent)
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
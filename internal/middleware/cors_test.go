@@ -0,0 +1,48 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestCORSMiddleware(t *testing.T) {
+	handler := NewCORSMiddleware([]string{"https://app.example.com"})(okHandler)
+
+	tests := []struct {
+		name       string
+		method     string
+		origin     string
+		wantStatus int
+		wantACAO   string
+	}{
+		{name: "preflight from allowed origin", method: http.MethodOptions, origin: "https://app.example.com", wantStatus: http.StatusNoContent, wantACAO: "https://app.example.com"},
+		{name: "preflight from other origin", method: http.MethodOptions, origin: "https://evil.example", wantStatus: http.StatusNoContent},
+		{name: "request from allowed origin", method: http.MethodPost, origin: "https://app.example.com", wantStatus: http.StatusOK, wantACAO: "https://app.example.com"},
+		{name: "request from other origin", method: http.MethodPost, origin: "https://evil.example", wantStatus: http.StatusOK},
+		{name: "same-origin request", method: http.MethodGet, wantStatus: http.StatusOK},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := newRequest(tt.method, "/shorten", "", "203.0.113.7")
+			if tt.origin != "" {
+				req.Header.Set("Origin", tt.origin)
+			}
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, req)
+
+			if rec.Code != tt.wantStatus {
+				t.Errorf("status = %d, want %d", rec.Code, tt.wantStatus)
+			}
+			if got := rec.Header().Get("Access-Control-Allow-Origin"); got != tt.wantACAO {
+				t.Errorf("Access-Control-Allow-Origin = %q, want %q", got, tt.wantACAO)
+			}
+			if allowMethods := rec.Header().Get("Access-Control-Allow-Methods"); (allowMethods != "") != (tt.wantACAO != "") {
+				t.Errorf("Access-Control-Allow-Methods = %q for an origin that is allowed: %v", allowMethods, tt.wantACAO != "")
+			}
+			if got := rec.Header().Get("Vary"); got != "Origin" {
+				t.Errorf("Vary = %q, want Origin", got)
+			}
+		})
+	}
+}
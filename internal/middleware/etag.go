@@ -0,0 +1,61 @@
+package middleware
+
+import (
+	"crypto/md5"
+	"encoding/hex"
+	"log/slog"
+	"net/http"
+	"strings"
+)
+
+// NewETagMiddleware returns middleware for handlers that answer GET requests with JSON. It buffers
+// each successful (200) JSON response, sets an ETag header to the hex MD5 of its body, and answers
+// 304 Not Modified without a body when the request's If-None-Match names that ETag. Other methods,
+// statuses and content types are passed through unchanged. Handlers may set Last-Modified
+// themselves; it is kept on 304 responses.
+func NewETagMiddleware() func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.Method != http.MethodGet && r.Method != http.MethodHead {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			rec := &responseRecorder{header: make(http.Header), status: http.StatusOK}
+			next.ServeHTTP(rec, r)
+			for name, values := range rec.header {
+				w.Header()[name] = values
+			}
+
+			if rec.status == http.StatusOK && strings.HasPrefix(rec.header.Get("Content-Type"), "application/json") {
+				sum := md5.Sum(rec.body.Bytes())
+				etag := `"` + hex.EncodeToString(sum[:]) + `"`
+				w.Header().Set("ETag", etag)
+				if etagMatches(r.Header.Get("If-None-Match"), etag) {
+					// A 304 carries the validators but no representation headers.
+					w.Header().Del("Content-Type")
+					w.Header().Del("Content-Length")
+					w.WriteHeader(http.StatusNotModified)
+					return
+				}
+			}
+
+			w.WriteHeader(rec.status)
+			if _, err := w.Write(rec.body.Bytes()); err != nil {
+				slog.DebugContext(r.Context(), "Error writing buffered response", slog.Any("error", err))
+			}
+		})
+	}
+}
+
+// etagMatches reports whether an If-None-Match header value names etag. Weak validators match
+// their strong counterparts, as RFC 9110 prescribes for If-None-Match, and "*" matches any ETag.
+func etagMatches(ifNoneMatch, etag string) bool {
+	for _, candidate := range strings.Split(ifNoneMatch, ",") {
+		candidate = strings.TrimPrefix(strings.TrimSpace(candidate), "W/")
+		if candidate == etag || candidate == "*" {
+			return true
+		}
+	}
+	return false
+}
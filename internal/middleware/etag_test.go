@@ -0,0 +1,74 @@
+package middleware
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestETagMiddleware(t *testing.T) {
+	body := `{"id": "abc123"}`
+	handler := NewETagMiddleware()(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("Last-Modified", "Mon, 02 Jan 2006 15:04:05 GMT")
+		io.WriteString(w, body)
+	}))
+	get := func(ifNoneMatch string) *httptest.ResponseRecorder {
+		req := newRequest(http.MethodGet, "/r/abc123/info", "", "203.0.113.7")
+		if ifNoneMatch != "" {
+			req.Header.Set("If-None-Match", ifNoneMatch)
+		}
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		return rec
+	}
+
+	first := get("")
+	etag := first.Header().Get("ETag")
+	if first.Code != http.StatusOK || first.Body.String() != body || etag == "" {
+		t.Fatalf("first GET = %d %q with ETag %q, want 200 with the body and an ETag", first.Code, first.Body, etag)
+	}
+
+	for _, ifNoneMatch := range []string{etag, "W/" + etag, `"other", ` + etag, "*"} {
+		rec := get(ifNoneMatch)
+		if rec.Code != http.StatusNotModified || rec.Body.Len() != 0 {
+			t.Errorf("If-None-Match %s: %d with %d body bytes, want 304 without a body", ifNoneMatch, rec.Code, rec.Body.Len())
+		}
+		if rec.Header().Get("ETag") != etag || rec.Header().Get("Last-Modified") == "" || rec.Header().Get("Content-Type") != "" {
+			t.Errorf("If-None-Match %s: headers %v, want the validators only", ifNoneMatch, rec.Header())
+		}
+	}
+
+	stale := get(`"0123456789abcdef0123456789abcdef"`)
+	if stale.Code != http.StatusOK || stale.Body.String() != body {
+		t.Errorf("stale ETag: %d %q, want 200 with the body", stale.Code, stale.Body)
+	}
+}
+
+func TestETagMiddlewarePassesOtherResponsesThrough(t *testing.T) {
+	tests := []struct {
+		name        string
+		method      string
+		status      int
+		contentType string
+	}{
+		{name: "error status", method: http.MethodGet, status: http.StatusNotFound, contentType: "application/json"},
+		{name: "not JSON", method: http.MethodGet, status: http.StatusOK, contentType: "image/png"},
+		{name: "POST", method: http.MethodPost, status: http.StatusOK, contentType: "application/json"},
+	}
+	for _, tt := range tests {
+		handler := NewETagMiddleware()(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Content-Type", tt.contentType)
+			w.WriteHeader(tt.status)
+			io.WriteString(w, "payload")
+		}))
+		req := newRequest(tt.method, "/r/abc123/info", "", "203.0.113.7")
+		req.Header.Set("If-None-Match", "*")
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		if rec.Code != tt.status || rec.Body.String() != "payload" || rec.Header().Get("ETag") != "" {
+			t.Errorf("%s: %d %q with ETag %q, want the response unchanged", tt.name, rec.Code, rec.Body, rec.Header().Get("ETag"))
+		}
+	}
+}
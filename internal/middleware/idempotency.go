@@ -0,0 +1,157 @@
+package middleware
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"golang.org/x/sync/singleflight"
+)
+
+// IdempotencyKeyHeader names the client-chosen key, typically a UUID, that makes a POST safe to retry.
+const IdempotencyKeyHeader = "Idempotency-Key"
+
+// IdempotencyTTL is how long the response to an idempotent request is replayed.
+const IdempotencyTTL = 24 * time.Hour
+
+// maxIdempotencyKeyLength bounds the keys accepted in IdempotencyKeyHeader.
+const maxIdempotencyKeyLength = 255
+
+// IdempotencyCache stores the responses replayed by NewIdempotencyMiddleware. store.RedisCache and
+// cache.ByteCache satisfy it. Get reports a missing key as an error; any Get error counts as a miss.
+type IdempotencyCache interface {
+	Get(ctx context.Context, key string) ([]byte, error)
+	Set(ctx context.Context, key string, value []byte, ttl time.Duration) error
+}
+
+// idempotentResponse is the cached outcome of a request. Fingerprint identifies the request it
+// answered, so that a key reused for a different request is not answered with this response.
+type idempotentResponse struct {
+	Fingerprint string      `json:"fingerprint"`
+	Status      int         `json:"status"`
+	Header      http.Header `json:"header"`
+	Body        []byte      `json:"body"`
+}
+
+// NewIdempotencyMiddleware returns middleware that makes POST requests carrying an Idempotency-Key
+// header safe to retry. The first successful (2xx) response for a key is cached for ttl under
+// "idempotency:key:{key}" and replayed, with an Idempotent-Replayed: true header, to every later
+// request with that key, without calling the handler again. Concurrent requests with the same key
+// on this instance wait for the first one and receive its response. A key reused with a different
+// API key owner, path or body gets HTTP 422. Requests without the header pass straight through.
+// It must run after NewAPIKeyMiddleware so that the owner is part of the request's fingerprint.
+func NewIdempotencyMiddleware(c IdempotencyCache, ttl time.Duration) func(http.Handler) http.Handler {
+	var inFlight singleflight.Group
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			key := r.Header.Get(IdempotencyKeyHeader)
+			if r.Method != http.MethodPost || key == "" {
+				next.ServeHTTP(w, r)
+				return
+			}
+			if len(key) > maxIdempotencyKeyLength {
+				writeError(w, http.StatusBadRequest, "VALIDATION_ERROR", "Idempotency-Key must be at most 255 characters long")
+				return
+			}
+
+			body, err := io.ReadAll(r.Body)
+			if err != nil {
+				var tooLarge *http.MaxBytesError
+				if errors.As(err, &tooLarge) {
+					writeError(w, http.StatusRequestEntityTooLarge, "PAYLOAD_TOO_LARGE", fmt.Sprintf("Request body must be at most %d bytes", tooLarge.Limit))
+					return
+				}
+				writeError(w, http.StatusBadRequest, "VALIDATION_ERROR", "Failed to read request body")
+				return
+			}
+			r.Body = io.NopCloser(bytes.NewReader(body))
+			fingerprint := requestFingerprint(r, body)
+			cacheKey := "idempotency:key:" + key
+
+			executed := false
+			v, _, _ := inFlight.Do(cacheKey, func() (any, error) {
+				if cached, err := c.Get(r.Context(), cacheKey); err == nil {
+					var resp idempotentResponse
+					if err := json.Unmarshal(cached, &resp); err == nil {
+						return resp, nil
+					}
+					slog.WarnContext(r.Context(), "Ignoring undecodable idempotent response", slog.String("idempotency_key", key))
+				}
+
+				executed = true
+				rec := &responseRecorder{header: make(http.Header), status: http.StatusOK}
+				next.ServeHTTP(rec, r)
+				resp := idempotentResponse{Fingerprint: fingerprint, Status: rec.status, Header: rec.header, Body: rec.body.Bytes()}
+				if resp.Status >= 200 && resp.Status < 300 {
+					if encoded, err := json.Marshal(resp); err != nil {
+						slog.ErrorContext(r.Context(), "Error encoding idempotent response", slog.String("idempotency_key", key), slog.Any("error", err))
+					} else if err := c.Set(r.Context(), cacheKey, encoded, ttl); err != nil {
+						// The response is still sent; only a retry may be processed twice.
+						slog.WarnContext(r.Context(), "Error caching idempotent response", slog.String("idempotency_key", key), slog.Any("error", err))
+					}
+				}
+				return resp, nil
+			})
+			resp := v.(idempotentResponse)
+
+			if resp.Fingerprint != fingerprint {
+				writeError(w, http.StatusUnprocessableEntity, "VALIDATION_ERROR", "Idempotency-Key has already been used for a different request")
+				return
+			}
+			// Waiting requests share resp, so each gets a copy that outer middleware may add to.
+			for name, values := range resp.Header.Clone() {
+				w.Header()[name] = values
+			}
+			if !executed {
+				w.Header().Set("Idempotent-Replayed", "true")
+			}
+			w.WriteHeader(resp.Status)
+			if _, err := w.Write(resp.Body); err != nil {
+				slog.DebugContext(r.Context(), "Error writing idempotent response", slog.String("idempotency_key", key), slog.Any("error", err))
+			}
+		})
+	}
+}
+
+// requestFingerprint identifies a request by its API key owner, method, path and body.
+func requestFingerprint(r *http.Request, body []byte) string {
+	h := sha256.New()
+	for _, part := range []string{OwnerFromContext(r.Context()), r.Method, r.URL.Path} {
+		h.Write([]byte(part))
+		h.Write([]byte{0})
+	}
+	h.Write(body)
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// responseRecorder buffers a complete response so that it can be cached and written to several clients.
+type responseRecorder struct {
+	header      http.Header
+	status      int
+	wroteHeader bool
+	body        bytes.Buffer
+}
+
+func (rec *responseRecorder) Header() http.Header {
+	return rec.header
+}
+
+func (rec *responseRecorder) WriteHeader(code int) {
+	if !rec.wroteHeader {
+		rec.status = code
+		rec.wroteHeader = true
+	}
+}
+
+func (rec *responseRecorder) Write(p []byte) (int, error) {
+	rec.wroteHeader = true
+	return rec.body.Write(p)
+}
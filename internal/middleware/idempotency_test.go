@@ -0,0 +1,143 @@
+package middleware
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// mapCache is an IdempotencyCache in a map, ignoring TTLs.
+type mapCache struct {
+	mu      sync.Mutex
+	entries map[string][]byte
+}
+
+func (c *mapCache) Get(ctx context.Context, key string) ([]byte, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	value, ok := c.entries[key]
+	if !ok {
+		return nil, errors.New("cache miss")
+	}
+	return value, nil
+}
+
+func (c *mapCache) Set(ctx context.Context, key string, value []byte, ttl time.Duration) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.entries[key] = value
+	return nil
+}
+
+// countingShortener answers every request with a new short ID, counting its calls. Calls wait
+// for release to be closed, if it is set.
+type countingShortener struct {
+	calls   atomic.Int32
+	release chan struct{}
+}
+
+func (h *countingShortener) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	n := h.calls.Add(1)
+	if h.release != nil {
+		<-h.release
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	fmt.Fprintf(w, `{"short_url": "https://sho.rt/id%04d"}`, n)
+}
+
+// postShorten sends a POST /shorten with body and, if key is not empty, an Idempotency-Key.
+func postShorten(handler http.Handler, key, body string) *httptest.ResponseRecorder {
+	req := newRequest(http.MethodPost, "/shorten", body, "203.0.113.7")
+	if key != "" {
+		req.Header.Set(IdempotencyKeyHeader, key)
+	}
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+	return rec
+}
+
+func TestIdempotencyConcurrentRequestsShareOneResponse(t *testing.T) {
+	next := &countingShortener{release: make(chan struct{})}
+	handler := NewIdempotencyMiddleware(&mapCache{entries: make(map[string][]byte)}, IdempotencyTTL)(next)
+	const key = "3f1c6c1e-8a53-4d3b-9a4e-6c1f7f0a2b11"
+	body := `{"url": "https://example.com"}`
+
+	var wg sync.WaitGroup
+	recs := make([]*httptest.ResponseRecorder, 2)
+	for i := range recs {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			recs[i] = postShorten(handler, key, body)
+		}()
+	}
+	// Let both requests reach the middleware while the first is still being handled.
+	for next.calls.Load() == 0 {
+		time.Sleep(time.Millisecond)
+	}
+	time.Sleep(50 * time.Millisecond)
+	close(next.release)
+	wg.Wait()
+
+	if got := next.calls.Load(); got != 1 {
+		t.Errorf("handler called %d times, want 1", got)
+	}
+	if recs[0].Code != recs[1].Code || recs[0].Body.String() != recs[1].Body.String() {
+		t.Errorf("responses differ: %d %s and %d %s", recs[0].Code, recs[0].Body, recs[1].Code, recs[1].Body)
+	}
+	if recs[0].Code != http.StatusCreated {
+		t.Errorf("status = %d, want 201", recs[0].Code)
+	}
+
+	// A later retry is answered from the cache.
+	replay := postShorten(handler, key, body)
+	if replay.Code != http.StatusCreated || replay.Body.String() != recs[0].Body.String() || replay.Header().Get("Idempotent-Replayed") != "true" {
+		t.Errorf("retry = %d %s (replayed %q), want the first response replayed", replay.Code, replay.Body, replay.Header().Get("Idempotent-Replayed"))
+	}
+	if got := next.calls.Load(); got != 1 {
+		t.Errorf("handler called %d times after the retry, want 1", got)
+	}
+}
+
+func TestIdempotencyKeyReusedForAnotherRequest(t *testing.T) {
+	next := &countingShortener{}
+	handler := NewIdempotencyMiddleware(&mapCache{entries: make(map[string][]byte)}, IdempotencyTTL)(next)
+
+	if rec := postShorten(handler, "key-1", `{"url": "https://example.com/a"}`); rec.Code != http.StatusCreated {
+		t.Fatalf("first request: status = %d, want 201", rec.Code)
+	}
+	rec := postShorten(handler, "key-1", `{"url": "https://example.com/b"}`)
+	if rec.Code != http.StatusUnprocessableEntity {
+		t.Errorf("reused key: status = %d, want 422; body %s", rec.Code, rec.Body)
+	}
+	if got := next.calls.Load(); got != 1 {
+		t.Errorf("handler called %d times, want 1", got)
+	}
+}
+
+func TestIdempotencyPassesOtherRequestsThrough(t *testing.T) {
+	next := &countingShortener{}
+	handler := NewIdempotencyMiddleware(&mapCache{entries: make(map[string][]byte)}, IdempotencyTTL)(next)
+
+	for range 2 {
+		postShorten(handler, "", `{"url": "https://example.com"}`)
+	}
+	req := newRequest(http.MethodGet, "/r/abc123", "", "203.0.113.7")
+	req.Header.Set(IdempotencyKeyHeader, "key-1")
+	handler.ServeHTTP(httptest.NewRecorder(), req)
+	if got := next.calls.Load(); got != 3 {
+		t.Errorf("handler called %d times, want 3", got)
+	}
+
+	if rec := postShorten(handler, strings.Repeat("k", maxIdempotencyKeyLength+1), `{}`); rec.Code != http.StatusBadRequest {
+		t.Errorf("overlong key: status = %d, want 400", rec.Code)
+	}
+}
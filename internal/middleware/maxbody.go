@@ -0,0 +1,18 @@
+package middleware
+
+import "net/http"
+
+// NewMaxBodyMiddleware returns middleware that limits request bodies to limit bytes, so that no
+// handler reads an arbitrarily large body into memory. Reading past the limit fails with
+// *http.MaxBytesError, which handlers answer with HTTP 413. A limit below 1 disables it.
+func NewMaxBodyMiddleware(limit int64) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		if limit < 1 {
+			return next
+		}
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			r.Body = http.MaxBytesReader(w, r.Body, limit)
+			next.ServeHTTP(w, r)
+		})
+	}
+}
@@ -0,0 +1,47 @@
+package middleware
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestMaxBodyMiddleware(t *testing.T) {
+	tests := []struct {
+		name     string
+		limit    int64
+		size     int
+		wantErr  bool
+		wantRead int
+	}{
+		{name: "at the limit", limit: 10, size: 10, wantRead: 10},
+		{name: "one byte over", limit: 10, size: 11, wantErr: true},
+		{name: "disabled", limit: 0, size: 1000, wantRead: 1000},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var read int
+			var readErr error
+			next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				body, err := io.ReadAll(r.Body)
+				read, readErr = len(body), err
+			})
+			req := newRequest(http.MethodPost, "/shorten", strings.Repeat("x", tt.size), "203.0.113.7")
+			NewMaxBodyMiddleware(tt.limit)(next).ServeHTTP(httptest.NewRecorder(), req)
+
+			var tooLarge *http.MaxBytesError
+			if tt.wantErr {
+				if !errors.As(readErr, &tooLarge) || tooLarge.Limit != tt.limit {
+					t.Errorf("read error = %v, want *http.MaxBytesError with limit %d", readErr, tt.limit)
+				}
+				return
+			}
+			if readErr != nil || read != tt.wantRead {
+				t.Errorf("read %d bytes, %v; want %d", read, readErr, tt.wantRead)
+			}
+		})
+	}
+}
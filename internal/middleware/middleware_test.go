@@ -0,0 +1,20 @@
+package middleware
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+)
+
+// okHandler answers every request with 200 and the body "ok".
+var okHandler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+	io.WriteString(w, "ok")
+})
+
+// newRequest builds a request from remoteIP, the address the connection came from.
+func newRequest(method, target, body, remoteIP string) *http.Request {
+	req := httptest.NewRequest(method, target, strings.NewReader(body))
+	req.RemoteAddr = remoteIP + ":12345"
+	return req
+}
@@ -0,0 +1,65 @@
+package middleware
+
+import (
+	"net/http"
+	"strings"
+)
+
+// ForPaths applies mw only to requests whose path matches one of paths.
+// A path ending in "/" matches every path under it, like http.ServeMux patterns;
+// other paths must match exactly. Other requests go straight to the wrapped handler.
+func ForPaths(mw func(http.Handler) http.Handler, paths ...string) func(http.Handler) http.Handler {
+	matches := pathMatcher(paths)
+	return func(next http.Handler) http.Handler {
+		wrapped := mw(next)
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if matches(r.URL.Path) {
+				wrapped.ServeHTTP(w, r)
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// ExceptPaths applies mw to every request except those whose path matches one of paths,
+// which are matched as in ForPaths. Those go straight to the wrapped handler.
+func ExceptPaths(mw func(http.Handler) http.Handler, paths ...string) func(http.Handler) http.Handler {
+	matches := pathMatcher(paths)
+	return func(next http.Handler) http.Handler {
+		wrapped := mw(next)
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if matches(r.URL.Path) {
+				next.ServeHTTP(w, r)
+				return
+			}
+			wrapped.ServeHTTP(w, r)
+		})
+	}
+}
+
+// pathMatcher returns a function reporting whether a request path matches one of paths.
+func pathMatcher(paths []string) func(string) bool {
+	match := make(map[string]bool, len(paths))
+	var prefixes []string
+	for _, p := range paths {
+		if strings.HasSuffix(p, "/") {
+			prefixes = append(prefixes, p)
+			continue
+		}
+		match[p] = true
+	}
+	return func(path string) bool {
+		return match[path] || hasAnyPrefix(path, prefixes)
+	}
+}
+
+// hasAnyPrefix reports whether path starts with one of prefixes.
+func hasAnyPrefix(path string, prefixes []string) bool {
+	for _, prefix := range prefixes {
+		if strings.HasPrefix(path, prefix) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,74 @@
+// Package middleware contains HTTP middleware shared by the Shawty server.
+package middleware
+
+import (
+	"math"
+	"net/http"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"golang.org/x/time/rate"
+)
+
+// Eviction settings for idle rate-limit buckets.
+const (
+	bucketIdleTimeout   = 10 * time.Minute
+	bucketSweepInterval = time.Minute
+)
+
+// ipBucket is the token bucket of a single client IP.
+type ipBucket struct {
+	limiter  *rate.Limiter
+	lastSeen atomic.Int64 // Unix nanoseconds of the last request
+}
+
+// NewRateLimiter returns middleware that limits each client IP to requestsPerMinute requests,
+// allowing short bursts of up to burst requests. Requests over the limit get HTTP 429 with a
+// Retry-After header. Buckets idle for 10 minutes are evicted by a background goroutine.
+func NewRateLimiter(requestsPerMinute int, burst int) func(http.Handler) http.Handler {
+	if burst < 1 {
+		burst = 1
+	}
+	limit := rate.Limit(float64(requestsPerMinute) / 60)
+
+	var buckets sync.Map // client IP -> *ipBucket
+	go func() {
+		ticker := time.NewTicker(bucketSweepInterval)
+		defer ticker.Stop()
+		for now := range ticker.C {
+			cutoff := now.Add(-bucketIdleTimeout).UnixNano()
+			buckets.Range(func(key, value any) bool {
+				if value.(*ipBucket).lastSeen.Load() < cutoff {
+					buckets.Delete(key)
+				}
+				return true
+			})
+		}
+	}()
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			ip := clientIP(r)
+			value, _ := buckets.LoadOrStore(ip, &ipBucket{limiter: rate.NewLimiter(limit, burst)})
+			bucket := value.(*ipBucket)
+			now := time.Now()
+			bucket.lastSeen.Store(now.UnixNano())
+
+			reservation := bucket.limiter.ReserveN(now, 1)
+			if delay := reservation.DelayFrom(now); !reservation.OK() || delay > 0 {
+				reservation.CancelAt(now)
+				retryAfter := int(math.Ceil(delay.Seconds()))
+				if retryAfter < 1 {
+					retryAfter = 1
+				}
+				w.Header().Set("Retry-After", strconv.Itoa(retryAfter))
+				writeError(w, http.StatusTooManyRequests, "RATE_LIMITED", "Too many requests, please slow down")
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
@@ -0,0 +1,38 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestRateLimiter(t *testing.T) {
+	const burst = 10
+	handler := NewRateLimiter(60, burst)(okHandler)
+
+	var statuses []int
+	for range 20 {
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, newRequest(http.MethodGet, "/shorten", "", "203.0.113.7"))
+		statuses = append(statuses, rec.Code)
+		if rec.Code == http.StatusTooManyRequests && rec.Header().Get("Retry-After") == "" {
+			t.Error("429 response has no Retry-After header")
+		}
+	}
+	for i, status := range statuses {
+		want := http.StatusOK
+		if i >= burst {
+			want = http.StatusTooManyRequests
+		}
+		if status != want {
+			t.Errorf("request %d: status = %d, want %d (all: %v)", i+1, status, want, statuses)
+		}
+	}
+
+	// Every client IP has a bucket of its own.
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, newRequest(http.MethodGet, "/shorten", "", "203.0.113.8"))
+	if rec.Code != http.StatusOK {
+		t.Errorf("request from another IP: status = %d, want 200", rec.Code)
+	}
+}
@@ -0,0 +1,120 @@
+package middleware
+
+import (
+	"context"
+	"log/slog"
+	"net"
+	"net/http"
+	"net/netip"
+	"strings"
+)
+
+// ClientIPKey is the request context key under which NewRealIPMiddleware stores the client IP.
+const ClientIPKey contextKey = "client_ip"
+
+// NewRealIPMiddleware returns middleware that resolves the IP of the client behind any reverse
+// proxies and stores it in the request context, where GetClientIP finds it. trustedProxies lists
+// the CIDR ranges (or single addresses) of those proxies; invalid entries are logged and ignored.
+//
+// The X-Forwarded-For and X-Real-IP headers are only believed when the request comes directly
+// from a trusted proxy, since anyone else can set them. X-Forwarded-For is read from the right,
+// skipping trusted proxies, so the client is the first address a trusted proxy did not add
+// itself; addresses a client prepends cannot take its place. Without X-Forwarded-For, X-Real-IP
+// is used. Requests from anywhere else keep the IP of the connection.
+func NewRealIPMiddleware(trustedProxies []string) func(http.Handler) http.Handler {
+	var trusted []netip.Prefix
+	for _, entry := range trustedProxies {
+		prefix, err := parseProxyPrefix(entry)
+		if err != nil {
+			slog.Warn("Ignoring invalid trusted proxy", slog.String("value", entry), slog.Any("error", err))
+			continue
+		}
+		trusted = append(trusted, prefix)
+	}
+	isTrusted := func(addr netip.Addr) bool {
+		for _, prefix := range trusted {
+			if prefix.Contains(addr) {
+				return true
+			}
+		}
+		return false
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			ip := remoteIP(r)
+			if remote, err := netip.ParseAddr(ip); err == nil && isTrusted(remote.Unmap()) {
+				ip = forwardedIP(r.Header, isTrusted, ip)
+			}
+			next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), ClientIPKey, ip)))
+		})
+	}
+}
+
+// GetClientIP returns the client IP stored by NewRealIPMiddleware, or "" if there is none.
+func GetClientIP(ctx context.Context) string {
+	ip, _ := ctx.Value(ClientIPKey).(string)
+	return ip
+}
+
+// clientIP returns the IP of the client that sent r: the one resolved by NewRealIPMiddleware
+// if it ran, and the IP of the connection otherwise.
+func clientIP(r *http.Request) string {
+	if ip := GetClientIP(r.Context()); ip != "" {
+		return ip
+	}
+	return remoteIP(r)
+}
+
+// remoteIP returns the IP part of the request's remote address.
+func remoteIP(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
+
+// forwardedIP returns the client IP reported by a trusted proxy in X-Forwarded-For or X-Real-IP,
+// or fallback if neither header holds a valid address.
+func forwardedIP(h http.Header, isTrusted func(netip.Addr) bool, fallback string) string {
+	var hops []string
+	for _, value := range h.Values("X-Forwarded-For") {
+		hops = append(hops, strings.Split(value, ",")...)
+	}
+	if len(hops) > 0 {
+		client := ""
+		for i := len(hops) - 1; i >= 0; i-- {
+			addr, err := netip.ParseAddr(strings.TrimSpace(hops[i]))
+			if err != nil {
+				// Whatever precedes a malformed entry cannot be attributed reliably.
+				break
+			}
+			client = addr.Unmap().String()
+			if !isTrusted(addr.Unmap()) {
+				break
+			}
+		}
+		if client != "" {
+			return client
+		}
+	}
+	if addr, err := netip.ParseAddr(strings.TrimSpace(h.Get("X-Real-IP"))); err == nil {
+		return addr.Unmap().String()
+	}
+	return fallback
+}
+
+// parseProxyPrefix parses a trusted proxy given as a CIDR range or a single address.
+func parseProxyPrefix(entry string) (netip.Prefix, error) {
+	if strings.Contains(entry, "/") {
+		prefix, err := netip.ParsePrefix(entry)
+		return prefix.Masked(), err
+	}
+	addr, err := netip.ParseAddr(entry)
+	if err != nil {
+		return netip.Prefix{}, err
+	}
+	addr = addr.Unmap()
+	return netip.PrefixFrom(addr, addr.BitLen()), nil
+}
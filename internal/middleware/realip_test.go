@@ -0,0 +1,62 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestRealIPMiddleware(t *testing.T) {
+	trusted := []string{"10.0.0.0/8", "192.0.2.1", "not-a-cidr"}
+	tests := []struct {
+		name          string
+		remoteIP      string
+		xForwardedFor []string
+		xRealIP       string
+		want          string
+	}{
+		{name: "multi-hop chain", remoteIP: "10.0.0.2", xForwardedFor: []string{"203.0.113.7, 198.51.100.9, 10.0.0.5"}, want: "198.51.100.9"},
+		{name: "chain split across headers", remoteIP: "192.0.2.1", xForwardedFor: []string{"203.0.113.7", "10.1.1.1"}, want: "203.0.113.7"},
+		{name: "spoofed entry is skipped", remoteIP: "10.0.0.2", xForwardedFor: []string{"1.2.3.4, 203.0.113.7"}, want: "203.0.113.7"},
+		{name: "only trusted hops", remoteIP: "10.0.0.2", xForwardedFor: []string{"10.0.0.9, 10.0.0.5"}, want: "10.0.0.9"},
+		{name: "malformed entry stops the walk", remoteIP: "10.0.0.2", xForwardedFor: []string{"203.0.113.7, garbage"}, xRealIP: "198.51.100.9", want: "198.51.100.9"},
+		{name: "X-Real-IP", remoteIP: "10.0.0.2", xRealIP: "203.0.113.7", want: "203.0.113.7"},
+		{name: "IPv4-mapped address", remoteIP: "10.0.0.2", xForwardedFor: []string{"::ffff:203.0.113.7"}, want: "203.0.113.7"},
+		{name: "direct connection ignores headers", remoteIP: "198.51.100.9", xForwardedFor: []string{"203.0.113.7"}, xRealIP: "203.0.113.8", want: "198.51.100.9"},
+		{name: "direct connection without headers", remoteIP: "198.51.100.9", want: "198.51.100.9"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got, limiterKey string
+			handler := NewRealIPMiddleware(trusted)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				got = GetClientIP(r.Context())
+				limiterKey = clientIP(r)
+			}))
+			req := newRequest(http.MethodGet, "/api/v1/r/abc123", "", tt.remoteIP)
+			for _, value := range tt.xForwardedFor {
+				req.Header.Add("X-Forwarded-For", value)
+			}
+			if tt.xRealIP != "" {
+				req.Header.Set("X-Real-IP", tt.xRealIP)
+			}
+			handler.ServeHTTP(httptest.NewRecorder(), req)
+			if got != tt.want {
+				t.Errorf("GetClientIP = %q, want %q", got, tt.want)
+			}
+			if limiterKey != tt.want {
+				t.Errorf("clientIP = %q, want %q", limiterKey, tt.want)
+			}
+		})
+	}
+}
+
+func TestClientIPWithoutRealIPMiddleware(t *testing.T) {
+	req := newRequest(http.MethodGet, "/", "", "198.51.100.9")
+	req.Header.Set("X-Forwarded-For", "203.0.113.7")
+	if got := GetClientIP(req.Context()); got != "" {
+		t.Errorf("GetClientIP = %q, want empty", got)
+	}
+	if got := clientIP(req); got != "198.51.100.9" {
+		t.Errorf("clientIP = %q, want the connection's IP", got)
+	}
+}
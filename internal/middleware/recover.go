@@ -0,0 +1,42 @@
+package middleware
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"runtime/debug"
+)
+
+// NewRecoveryMiddleware returns middleware that turns a panicking handler into a 500 response
+// instead of letting the panic take down the server. The panic value and stack trace are logged
+// at ERROR level. http.ErrAbortHandler is re-panicked so that net/http can abort the response
+// as the handler intended.
+func NewRecoveryMiddleware(logger *slog.Logger) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			defer func() {
+				recovered := recover()
+				if recovered == nil {
+					return
+				}
+				if err, ok := recovered.(error); ok && errors.Is(err, http.ErrAbortHandler) {
+					panic(recovered)
+				}
+
+				logger.ErrorContext(r.Context(), "Recovered from panic in HTTP handler",
+					slog.String("method", r.Method),
+					slog.String("path", r.URL.Path),
+					slog.String("panic", fmt.Sprint(recovered)),
+					slog.String("stack", string(debug.Stack())),
+				)
+
+				// If the handler already started the response, the status can no longer be changed;
+				// the client gets a truncated body either way.
+				writeError(w, http.StatusInternalServerError, "INTERNAL_ERROR", "internal server error")
+			}()
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
@@ -0,0 +1,103 @@
+package middleware
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// panickingRoutes serves /panic with a handler that panics with a string, /abort with one that
+// panics with http.ErrAbortHandler, and okHandler everywhere else.
+func panickingRoutes() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/panic", func(w http.ResponseWriter, r *http.Request) { panic("boom") })
+	mux.HandleFunc("/abort", func(w http.ResponseWriter, r *http.Request) { panic(http.ErrAbortHandler) })
+	mux.Handle("/", okHandler)
+	return mux
+}
+
+func TestRecoveryMiddlewareAnswers500AndKeepsServing(t *testing.T) {
+	var logs bytes.Buffer
+	server := httptest.NewServer(NewRecoveryMiddleware(slog.New(slog.NewTextHandler(&logs, nil)))(panickingRoutes()))
+	defer server.Close()
+
+	resp, err := server.Client().Get(server.URL + "/panic")
+	if err != nil {
+		t.Fatalf("GET /panic: %v", err)
+	}
+	body, _ := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	var errBody struct {
+		Error string `json:"error"`
+		Code  string `json:"code"`
+	}
+	if err := json.Unmarshal(body, &errBody); err != nil {
+		t.Fatalf("error body %q is not JSON: %v", body, err)
+	}
+	if resp.StatusCode != http.StatusInternalServerError || errBody.Code != "INTERNAL_ERROR" || resp.Header.Get("Content-Type") != "application/json" {
+		t.Errorf("status = %d, Content-Type = %q, body %s; want a JSON 500 with code INTERNAL_ERROR", resp.StatusCode, resp.Header.Get("Content-Type"), body)
+	}
+	// The panic value is logged, not sent to the client.
+	if strings.Contains(string(body), "boom") {
+		t.Errorf("response %s reveals the panic value", body)
+	}
+	if !strings.Contains(logs.String(), "panic=boom") || !strings.Contains(logs.String(), "stack=") {
+		t.Errorf("log %q does not record the panic value and stack", logs.String())
+	}
+
+	// The server survives the panic.
+	for range 2 {
+		resp, err := server.Client().Get(server.URL + "/")
+		if err != nil {
+			t.Fatalf("GET / after the panic: %v", err)
+		}
+		body, _ := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		if resp.StatusCode != http.StatusOK || string(body) != "ok" {
+			t.Errorf("GET / after the panic: status = %d, body %q; want 200 ok", resp.StatusCode, body)
+		}
+	}
+}
+
+func TestRecoveryMiddlewareRepanicsAbortHandler(t *testing.T) {
+	var logs bytes.Buffer
+	handler := NewRecoveryMiddleware(slog.New(slog.NewTextHandler(&logs, nil)))(panickingRoutes())
+
+	rec := httptest.NewRecorder()
+	recovered := func() (recovered any) {
+		defer func() { recovered = recover() }()
+		handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/abort", nil))
+		return nil
+	}()
+	if err, ok := recovered.(error); !ok || !errors.Is(err, http.ErrAbortHandler) {
+		t.Fatalf("recovered %v, want http.ErrAbortHandler to be re-panicked", recovered)
+	}
+	if rec.Code != http.StatusOK || rec.Body.Len() != 0 {
+		t.Errorf("status = %d with body %q, want nothing written for an aborted response", rec.Code, rec.Body)
+	}
+	if logs.Len() != 0 {
+		t.Errorf("an aborted response was logged as a panic: %s", logs.String())
+	}
+
+	// net/http aborts the response by closing the connection and goes on serving.
+	server := httptest.NewServer(handler)
+	defer server.Close()
+	if resp, err := server.Client().Get(server.URL + "/abort"); err == nil {
+		resp.Body.Close()
+		t.Errorf("GET /abort: status = %d, want the connection to be closed", resp.StatusCode)
+	}
+	resp, err := server.Client().Get(server.URL + "/")
+	if err != nil {
+		t.Fatalf("GET / after the abort: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Errorf("GET / after the abort: status = %d, want 200", resp.StatusCode)
+	}
+}
@@ -0,0 +1,61 @@
+package middleware
+
+import (
+	"context"
+	"crypto/rand"
+	"fmt"
+	"net/http"
+)
+
+// RequestIDHeader is the header a request ID is read from and echoed back in.
+const RequestIDHeader = "X-Request-ID"
+
+// requestIDKey is the request context key under which NewRequestIDMiddleware stores the request ID.
+const requestIDKey contextKey = "request_id"
+
+// maxRequestIDLength bounds client-supplied request IDs, which end up in every log line.
+const maxRequestIDLength = 128
+
+// NewRequestIDMiddleware returns middleware that gives every request an ID, stored in its context
+// and echoed in the X-Request-ID response header. A client-supplied X-Request-ID is kept if it is
+// a reasonable size and printable; otherwise a random UUID is generated.
+func NewRequestIDMiddleware() func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			id := r.Header.Get(RequestIDHeader)
+			if !validRequestID(id) {
+				id = newUUID()
+			}
+			w.Header().Set(RequestIDHeader, id)
+			next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), requestIDKey, id)))
+		})
+	}
+}
+
+// GetRequestID returns the ID stored by NewRequestIDMiddleware, or "" if there is none.
+func GetRequestID(ctx context.Context) string {
+	id, _ := ctx.Value(requestIDKey).(string)
+	return id
+}
+
+// validRequestID reports whether a client-supplied ID is safe to log and echo.
+func validRequestID(id string) bool {
+	if id == "" || len(id) > maxRequestIDLength {
+		return false
+	}
+	for i := 0; i < len(id); i++ {
+		if id[i] < 0x21 || id[i] > 0x7e {
+			return false
+		}
+	}
+	return true
+}
+
+// newUUID returns a random (version 4) UUID.
+func newUUID() string {
+	var b [16]byte
+	rand.Read(b[:]) // Never returns an error; it crashes the program if the OS has no randomness.
+	b[6] = b[6]&0x0f | 0x40
+	b[8] = b[8]&0x3f | 0x80
+	return fmt.Sprintf("%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:16])
+}
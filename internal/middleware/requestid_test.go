@@ -0,0 +1,77 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"strings"
+	"testing"
+)
+
+var uuidV4Pattern = regexp.MustCompile(`^[0-9a-f]{8}-[0-9a-f]{4}-4[0-9a-f]{3}-[89ab][0-9a-f]{3}-[0-9a-f]{12}$`)
+
+func TestRequestIDMiddleware(t *testing.T) {
+	tests := []struct {
+		name     string
+		incoming string
+		wantKept bool
+	}{
+		{name: "none", incoming: ""},
+		{name: "client ID", incoming: "req-0123456789abcdef", wantKept: true},
+		{name: "UUID", incoming: "3f2504e0-4f89-41d3-9a0c-0305e82c3301", wantKept: true},
+		{name: "at the maximum length", incoming: strings.Repeat("a", maxRequestIDLength), wantKept: true},
+		{name: "over the maximum length", incoming: strings.Repeat("a", maxRequestIDLength+1)},
+		{name: "space", incoming: "two words"},
+		{name: "log injection", incoming: "abc\nlevel=ERROR msg=forged"},
+		{name: "control character", incoming: "abc\x1b[31m"},
+		{name: "non-ASCII", incoming: "idé"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var seen string
+			handler := NewRequestIDMiddleware()(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				seen = GetRequestID(r.Context())
+			}))
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			if tt.incoming != "" {
+				req.Header.Set(RequestIDHeader, tt.incoming)
+			}
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, req)
+
+			echoed := rec.Header().Get(RequestIDHeader)
+			if echoed != seen {
+				t.Errorf("response header %s = %q, want the ID in the context, %q", RequestIDHeader, echoed, seen)
+			}
+			if tt.wantKept {
+				if seen != tt.incoming {
+					t.Errorf("request ID = %q, want the client's %q", seen, tt.incoming)
+				}
+				return
+			}
+			if !uuidV4Pattern.MatchString(seen) {
+				t.Errorf("request ID = %q, want a generated UUID", seen)
+			}
+		})
+	}
+}
+
+func TestRequestIDMiddlewareGeneratesUniqueIDs(t *testing.T) {
+	handler := NewRequestIDMiddleware()(okHandler)
+	seen := make(map[string]bool)
+	for range 100 {
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+		id := rec.Header().Get(RequestIDHeader)
+		if seen[id] {
+			t.Fatalf("request ID %q was generated twice", id)
+		}
+		seen[id] = true
+	}
+}
+
+func TestGetRequestIDWithoutMiddleware(t *testing.T) {
+	if got := GetRequestID(httptest.NewRequest(http.MethodGet, "/", nil).Context()); got != "" {
+		t.Errorf("GetRequestID = %q, want \"\" outside the middleware", got)
+	}
+}
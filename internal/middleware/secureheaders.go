@@ -0,0 +1,66 @@
+package middleware
+
+import (
+	"log/slog"
+	"net/http"
+	"strconv"
+)
+
+// hstsPreloadMinAge is the shortest HSTS max-age accepted for the browsers' preload lists.
+const hstsPreloadMinAge = 365 * 24 * 60 * 60
+
+// HSTSConfig holds the Strict-Transport-Security policy. Browsers only honor it on HTTPS responses.
+type HSTSConfig struct {
+	MaxAge            int // Seconds browsers keep using HTTPS only; 0 leaves the header out
+	IncludeSubDomains bool
+	Preload           bool // Ask to be preloaded into browsers; requires IncludeSubDomains and a MaxAge of at least a year
+}
+
+// NewSecureHeadersMiddleware returns middleware that adds security headers to every response:
+// X-Content-Type-Options: nosniff, Strict-Transport-Security as set by hsts,
+// "Expect-CT: max-age=expectCTMaxAge, enforce" and Permissions-Policy: permissionsPolicy.
+// A header whose setting is zero or empty is left out. A Preload policy that the preload lists
+// would reject is logged but still sent.
+func NewSecureHeadersMiddleware(hsts HSTSConfig, expectCTMaxAge int, permissionsPolicy string) func(http.Handler) http.Handler {
+	if hsts.Preload && hsts.MaxAge > 0 && (!hsts.IncludeSubDomains || hsts.MaxAge < hstsPreloadMinAge) {
+		slog.Warn("HSTS preload requires HSTS_INCLUDE_SUBDOMAINS and an HSTS_MAX_AGE of at least one year; the domain is not eligible for preloading",
+			slog.Int("max_age", hsts.MaxAge), slog.Bool("include_subdomains", hsts.IncludeSubDomains))
+	}
+	hstsValue := hsts.header()
+	expectCT := ""
+	if expectCTMaxAge > 0 {
+		expectCT = "max-age=" + strconv.Itoa(expectCTMaxAge) + ", enforce"
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			h := w.Header()
+			h.Set("X-Content-Type-Options", "nosniff")
+			if hstsValue != "" {
+				h.Set("Strict-Transport-Security", hstsValue)
+			}
+			if expectCT != "" {
+				h.Set("Expect-CT", expectCT)
+			}
+			if permissionsPolicy != "" {
+				h.Set("Permissions-Policy", permissionsPolicy)
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// header returns the Strict-Transport-Security value of the policy, or "" if HSTS is disabled.
+func (c HSTSConfig) header() string {
+	if c.MaxAge <= 0 {
+		return ""
+	}
+	value := "max-age=" + strconv.Itoa(c.MaxAge)
+	if c.IncludeSubDomains {
+		value += "; includeSubDomains"
+	}
+	if c.Preload {
+		value += "; preload"
+	}
+	return value
+}
@@ -0,0 +1,72 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestSecureHeadersMiddleware(t *testing.T) {
+	const year = 365 * 24 * 60 * 60
+	tests := []struct {
+		name              string
+		hsts              HSTSConfig
+		expectCTMaxAge    int
+		permissionsPolicy string
+		want              map[string]string
+	}{
+		{
+			name:              "defaults",
+			hsts:              HSTSConfig{MaxAge: year},
+			expectCTMaxAge:    86400,
+			permissionsPolicy: "geolocation=(), microphone=(), camera=()",
+			want: map[string]string{
+				"Strict-Transport-Security": "max-age=31536000",
+				"Expect-CT":                 "max-age=86400, enforce",
+				"Permissions-Policy":        "geolocation=(), microphone=(), camera=()",
+			},
+		},
+		{
+			name: "subdomains",
+			hsts: HSTSConfig{MaxAge: 600, IncludeSubDomains: true},
+			want: map[string]string{"Strict-Transport-Security": "max-age=600; includeSubDomains"},
+		},
+		{
+			name: "preload",
+			hsts: HSTSConfig{MaxAge: 2 * year, IncludeSubDomains: true, Preload: true},
+			want: map[string]string{"Strict-Transport-Security": "max-age=63072000; includeSubDomains; preload"},
+		},
+		{
+			name: "preload without subdomains is still sent",
+			hsts: HSTSConfig{MaxAge: year, Preload: true},
+			want: map[string]string{"Strict-Transport-Security": "max-age=31536000; preload"},
+		},
+		{
+			name:              "disabled",
+			hsts:              HSTSConfig{MaxAge: 0, IncludeSubDomains: true, Preload: true},
+			permissionsPolicy: "camera=()",
+			want:              map[string]string{"Permissions-Policy": "camera=()"},
+		},
+		{
+			name:           "custom Expect-CT age",
+			expectCTMaxAge: 3600,
+			want:           map[string]string{"Expect-CT": "max-age=3600, enforce"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			handler := NewSecureHeadersMiddleware(tt.hsts, tt.expectCTMaxAge, tt.permissionsPolicy)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, newRequest(http.MethodGet, "/", "", "203.0.113.7"))
+
+			if got := rec.Header().Get("X-Content-Type-Options"); got != "nosniff" {
+				t.Errorf("X-Content-Type-Options = %q, want nosniff", got)
+			}
+			for _, name := range []string{"Strict-Transport-Security", "Expect-CT", "Permissions-Policy"} {
+				if got := rec.Header().Get(name); got != tt.want[name] {
+					t.Errorf("%s = %q, want %q", name, got, tt.want[name])
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,133 @@
+package middleware
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// NewTimeoutMiddleware returns middleware that gives each request d to be answered. The request
+// context is cancelled after d, which aborts the database queries and outgoing requests made with
+// it. If the handler has not started its response by then, the client gets 503 Service Unavailable
+// with {"error":"request timeout"} and whatever the handler writes afterwards is discarded. A
+// response that has already started is left to finish. A d of zero disables the timeout.
+//
+// The handler runs in a goroutine of its own, so a panic must be recovered inside this middleware.
+func NewTimeoutMiddleware(d time.Duration) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		if d <= 0 {
+			return next
+		}
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			ctx, cancel := context.WithTimeout(r.Context(), d)
+			defer cancel()
+
+			tw := &timeoutWriter{w: w, header: make(http.Header)}
+			done := make(chan struct{})
+			panicked := make(chan any, 1)
+			go func() {
+				defer func() {
+					if p := recover(); p != nil {
+						panicked <- p
+					}
+				}()
+				next.ServeHTTP(tw, r.WithContext(ctx))
+				close(done)
+			}()
+
+			select {
+			case <-done:
+			case p := <-panicked:
+				panic(p)
+			case <-ctx.Done():
+				if tw.timeout() {
+					slog.WarnContext(r.Context(), "Request timed out", slog.String("path", r.URL.Path), slog.Duration("timeout", d))
+					return
+				}
+				// The handler is already writing its response; w stays in use until it returns.
+				select {
+				case <-done:
+				case p := <-panicked:
+					panic(p)
+				}
+			}
+		})
+	}
+}
+
+// timeoutWriter passes a handler's response through to w unless the timeout answered first.
+// The handler's headers are kept apart from w's so that a handler still running after the
+// timeout response cannot touch them.
+type timeoutWriter struct {
+	w      http.ResponseWriter
+	header http.Header
+
+	mu       sync.Mutex
+	once     sync.Once // Starts the response, either the handler's or the timeout's
+	timedOut bool
+}
+
+func (tw *timeoutWriter) Header() http.Header {
+	return tw.header
+}
+
+func (tw *timeoutWriter) WriteHeader(statusCode int) {
+	tw.mu.Lock()
+	defer tw.mu.Unlock()
+	if tw.timedOut {
+		return
+	}
+	tw.writeHeaderLocked(statusCode)
+}
+
+func (tw *timeoutWriter) Write(b []byte) (int, error) {
+	tw.mu.Lock()
+	defer tw.mu.Unlock()
+	if tw.timedOut {
+		return 0, http.ErrHandlerTimeout
+	}
+	tw.writeHeaderLocked(http.StatusOK)
+	return tw.w.Write(b)
+}
+
+// Flush sends buffered data to the client, if the underlying writer supports it.
+func (tw *timeoutWriter) Flush() {
+	tw.mu.Lock()
+	defer tw.mu.Unlock()
+	if tw.timedOut {
+		return
+	}
+	tw.writeHeaderLocked(http.StatusOK)
+	if f, ok := tw.w.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+// Unwrap returns the underlying ResponseWriter, for http.ResponseController.
+func (tw *timeoutWriter) Unwrap() http.ResponseWriter {
+	return tw.w
+}
+
+// writeHeaderLocked starts the handler's response unless a response has already started.
+func (tw *timeoutWriter) writeHeaderLocked(statusCode int) {
+	tw.once.Do(func() {
+		dst := tw.w.Header()
+		for name, values := range tw.header {
+			dst[name] = values
+		}
+		tw.w.WriteHeader(statusCode)
+	})
+}
+
+// timeout answers 503 if the handler has not started its response, and reports whether it did.
+func (tw *timeoutWriter) timeout() bool {
+	tw.mu.Lock()
+	defer tw.mu.Unlock()
+	tw.once.Do(func() {
+		tw.timedOut = true
+		writeError(tw.w, http.StatusServiceUnavailable, "SERVICE_UNAVAILABLE", "request timeout")
+	})
+	return tw.timedOut
+}
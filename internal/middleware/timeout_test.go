@@ -0,0 +1,92 @@
+package middleware
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestTimeoutMiddlewareAnswers503ToSlowHandlers(t *testing.T) {
+	answered, finished := make(chan struct{}), make(chan error, 1)
+	handler := NewTimeoutMiddleware(20 * time.Millisecond)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-r.Context().Done()
+		<-answered // a slow query returning only after the client got its answer
+		w.Header().Set("X-Late", "1")
+		_, err := io.WriteString(w, "too late")
+		finished <- err
+	}))
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, newRequest(http.MethodGet, "/api/v1/r/abc123", "", "203.0.113.7"))
+	close(answered)
+	if err := <-finished; !errors.Is(err, http.ErrHandlerTimeout) {
+		t.Errorf("the handler's late Write returned %v, want http.ErrHandlerTimeout", err)
+	}
+
+	if rec.Code != http.StatusServiceUnavailable {
+		t.Fatalf("status = %d, want 503", rec.Code)
+	}
+	var body map[string]string
+	if err := json.Unmarshal(rec.Body.Bytes(), &body); err != nil {
+		t.Fatalf("body %q is not JSON: %v", rec.Body, err)
+	}
+	if body["error"] != "request timeout" {
+		t.Errorf("error = %q, want %q", body["error"], "request timeout")
+	}
+	if rec.Header().Get("X-Late") != "" {
+		t.Error("a header set after the timeout reached the response")
+	}
+}
+
+func TestTimeoutMiddlewareLetsAStartedResponseFinish(t *testing.T) {
+	handler := NewTimeoutMiddleware(20 * time.Millisecond)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/plain")
+		w.WriteHeader(http.StatusOK)
+		io.WriteString(w, "first part, ")
+		<-r.Context().Done()
+		io.WriteString(w, "second part")
+	}))
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, newRequest(http.MethodGet, "/api/v1/export", "", "203.0.113.7"))
+	if rec.Code != http.StatusOK || rec.Body.String() != "first part, second part" {
+		t.Errorf("got %d %q, want the handler's whole 200 response", rec.Code, rec.Body)
+	}
+	if rec.Header().Get("Content-Type") != "text/plain" {
+		t.Errorf("Content-Type = %q, want the handler's", rec.Header().Get("Content-Type"))
+	}
+}
+
+func TestTimeoutMiddlewarePassesFastResponsesThrough(t *testing.T) {
+	for _, d := range []time.Duration{time.Second, 0} {
+		handler := NewTimeoutMiddleware(d)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if _, ok := r.Context().Deadline(); ok != (d > 0) {
+				t.Errorf("timeout %v: request context has a deadline: %v", d, ok)
+			}
+			w.Header().Set("Location", "https://example.com")
+			w.WriteHeader(http.StatusFound)
+		}))
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, newRequest(http.MethodGet, "/api/v1/r/abc123", "", "203.0.113.7"))
+		if rec.Code != http.StatusFound || rec.Header().Get("Location") != "https://example.com" {
+			t.Errorf("timeout %v: got %d to %q, want the handler's 302", d, rec.Code, rec.Header().Get("Location"))
+		}
+	}
+}
+
+func TestTimeoutMiddlewareRepanicsInTheServingGoroutine(t *testing.T) {
+	handler := NewTimeoutMiddleware(time.Second)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		panic("boom")
+	}))
+	defer func() {
+		if p := recover(); p != "boom" {
+			t.Errorf("recovered %v, want the handler's panic", p)
+		}
+	}()
+	handler.ServeHTTP(httptest.NewRecorder(), newRequest(http.MethodGet, "/", "", "203.0.113.7"))
+	t.Error("ServeHTTP returned without panicking")
+}
@@ -0,0 +1,16 @@
+package middleware
+
+import "net/http"
+
+// APIVersionHeader is the response header that reports the API version.
+const APIVersionHeader = "X-API-Version"
+
+// NewAPIVersionMiddleware returns middleware that adds the X-API-Version header to every response.
+func NewAPIVersionMiddleware(version string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set(APIVersionHeader, version)
+			next.ServeHTTP(w, r)
+		})
+	}
+}
@@ -0,0 +1,71 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"shawty/internal/domain"
+	"shawty/internal/store"
+	"shawty/internal/tracing"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// CreateAlias adds aliasCode as another short code of the link primaryShortID. The alias stores
+// only a reference to its primary, so it always redirects to the primary's current destination and
+// its clicks are counted on the primary. Naming an alias as primaryShortID attaches the new alias to
+// that alias's primary, so aliases never chain.
+//
+// The returned entry is the stored alias with the primary's OriginalUrl filled in. It returns
+// ErrInvalidCustomCode or ErrReservedCode for an unusable code, ErrCustomCodeTaken if the code is in
+// use, and the lookup error if primaryShortID does not resolve.
+func (s *UrlService) CreateAlias(ctx context.Context, primaryShortID, aliasCode string) (domain.URL, error) {
+	ctx, span := tracer.Start(ctx, "service.CreateAlias", trace.WithAttributes(attribute.String(tracing.AttrShortID, primaryShortID)))
+	defer span.End()
+
+	if err := validateCustomCode(aliasCode); err != nil {
+		return domain.URL{}, err
+	}
+	primary, err := s.urlStore.GetByShortID(ctx, primaryShortID, store.AnyTenant)
+	if err != nil {
+		return domain.URL{}, err
+	}
+
+	primaryID := primary.ID
+	alias := domain.URL{
+		ID:           aliasCode,
+		ShortUrl:     aliasCode,
+		CreationDate: time.Now().UTC(),
+		TenantID:     primary.TenantID,
+		AliasOf:      &primaryID,
+	}
+	if err := s.saveEntry(ctx, alias); err != nil {
+		if errors.Is(err, store.ErrDuplicateShortID) {
+			return domain.URL{}, fmt.Errorf("%w: '%s'", ErrCustomCodeTaken, aliasCode)
+		}
+		if errors.Is(err, ErrQuotaExceeded) {
+			return domain.URL{}, err
+		}
+		return domain.URL{}, fmt.Errorf("failed to save alias: %w", err)
+	}
+	slog.InfoContext(ctx, "Created alias", slog.String("short_id", aliasCode), slog.String("alias_of", primaryID))
+
+	alias.OriginalUrl = primary.OriginalUrl
+	return alias, nil
+}
+
+// ListAliases returns the short codes of the aliases of the link primaryID, in short ID order.
+func (s *UrlService) ListAliases(ctx context.Context, primaryID string) ([]string, error) {
+	ctx, span := tracer.Start(ctx, "service.ListAliases", trace.WithAttributes(attribute.String(tracing.AttrShortID, primaryID)))
+	defer span.End()
+
+	aliases, err := s.urlStore.ListAliases(ctx, primaryID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list aliases: %w", err)
+	}
+	return aliases, nil
+}
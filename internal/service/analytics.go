@@ -0,0 +1,162 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"shawty/internal/domain"
+	"shawty/internal/geoip"
+	"shawty/internal/store"
+	"shawty/internal/tracing"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// ErrAnalyticsUnavailable is returned by the click analytics queries when no analytics store is configured.
+var ErrAnalyticsUnavailable = errors.New("click analytics are not available")
+
+// ErrInvalidDateRange is returned when an analytics query's range is empty or reversed,
+// or too long for the requested granularity.
+var ErrInvalidDateRange = errors.New("invalid date range")
+
+// ErrInvalidGranularity is returned for a time series granularity other than hour, day, week or month.
+var ErrInvalidGranularity = errors.New("invalid granularity")
+
+// Longest ranges of the fine-grained time series, which would otherwise return thousands of points.
+const (
+	maxHourlyRange = 24 * time.Hour
+	maxDailyRange  = 366 * 24 * time.Hour
+)
+
+// timeseriesCacheTTL is how long a time series is cached. Clicks are recorded continuously,
+// so a series is only reused for repeated requests, e.g. dashboards refreshing a chart.
+const timeseriesCacheTTL = time.Minute
+
+// WithAnalyticsStore sets the store click events are written to and aggregated from.
+// Without it RecordClick does nothing and the analytics queries return ErrAnalyticsUnavailable.
+func WithAnalyticsStore(a store.AnalyticsStoreInterface) Option {
+	return func(s *UrlService) {
+		s.analyticsStore = a
+	}
+}
+
+// WithGeoIP sets the resolver that locates clicks by the requester's IP address.
+// Without it every click is recorded with country geoip.UnknownCountry.
+func WithGeoIP(r *geoip.Resolver) Option {
+	return func(s *UrlService) {
+		s.geo = r
+	}
+}
+
+// RecordClick stores a click event for analytics. It is a no-op without an analytics store.
+// Unless evt already carries one, the country is resolved from the IP address of ctx's audit actor;
+// the address itself is not stored.
+func (s *UrlService) RecordClick(ctx context.Context, evt domain.ClickEvent) error {
+	ctx, span := tracer.Start(ctx, "service.RecordClick", trace.WithAttributes(attribute.String(tracing.AttrShortID, evt.ShortID)))
+	defer span.End()
+
+	if s.analyticsStore == nil {
+		return nil
+	}
+	if evt.CountryCode == "" {
+		evt.CountryCode = s.geo.Country(domain.AuditActorFromContext(ctx).IPAddress)
+	}
+	return s.analyticsStore.RecordClick(ctx, evt)
+}
+
+// ListRefererCounts returns the number of clicks shortID received in [from, to) per referer domain,
+// most clicks first.
+func (s *UrlService) ListRefererCounts(ctx context.Context, shortID string, from, to time.Time) ([]domain.RefererCount, error) {
+	ctx, span := tracer.Start(ctx, "service.ListRefererCounts", trace.WithAttributes(attribute.String(tracing.AttrShortID, shortID)))
+	defer span.End()
+
+	if err := s.checkAnalyticsQuery(shortID, from, to); err != nil {
+		return nil, err
+	}
+	return s.analyticsStore.CountByReferer(ctx, shortID, from, to)
+}
+
+// ListCountryCounts returns the number of clicks shortID received in [from, to) per country code,
+// most clicks first.
+func (s *UrlService) ListCountryCounts(ctx context.Context, shortID string, from, to time.Time) ([]domain.CountryCount, error) {
+	ctx, span := tracer.Start(ctx, "service.ListCountryCounts", trace.WithAttributes(attribute.String(tracing.AttrShortID, shortID)))
+	defer span.End()
+
+	if err := s.checkAnalyticsQuery(shortID, from, to); err != nil {
+		return nil, err
+	}
+	return s.analyticsStore.CountByCountry(ctx, shortID, from, to)
+}
+
+// timeseriesCacheKey returns the cache key of a click time series.
+func timeseriesCacheKey(shortID, granularity string, from, to time.Time) string {
+	return fmt.Sprintf("shawty:timeseries:%s:%s:%d:%d", shortID, granularity, from.Unix(), to.Unix())
+}
+
+// ClickTimeseries returns the number of clicks shortID received in [from, to) per hour, day, week or
+// month, oldest first. Hourly series may span at most a day and daily series at most 366 days.
+// Results are cached for a minute when a cache is configured.
+func (s *UrlService) ClickTimeseries(ctx context.Context, shortID, granularity string, from, to time.Time) ([]domain.ClickBucket, error) {
+	ctx, span := tracer.Start(ctx, "service.ClickTimeseries", trace.WithAttributes(attribute.String(tracing.AttrShortID, shortID)))
+	defer span.End()
+
+	if err := s.checkAnalyticsQuery(shortID, from, to); err != nil {
+		return nil, err
+	}
+	switch granularity {
+	case domain.GranularityHour:
+		if to.Sub(from) > maxHourlyRange {
+			return nil, fmt.Errorf("%w: hourly series may span at most %s", ErrInvalidDateRange, maxHourlyRange)
+		}
+	case domain.GranularityDay:
+		if to.Sub(from) > maxDailyRange {
+			return nil, fmt.Errorf("%w: daily series may span at most 366 days", ErrInvalidDateRange)
+		}
+	case domain.GranularityWeek, domain.GranularityMonth:
+	default:
+		return nil, fmt.Errorf("%w: '%s' is not one of hour, day, week or month", ErrInvalidGranularity, granularity)
+	}
+
+	key := timeseriesCacheKey(shortID, granularity, from, to)
+	if s.cache != nil {
+		if cached, err := s.cache.Get(ctx, key); err == nil {
+			var buckets []domain.ClickBucket
+			if jsonErr := json.Unmarshal(cached, &buckets); jsonErr == nil {
+				return buckets, nil
+			}
+		}
+	}
+
+	buckets, err := s.analyticsStore.ClickTimeseries(ctx, shortID, granularity, from, to)
+	if err != nil {
+		return nil, err
+	}
+
+	if s.cache != nil {
+		if encoded, jsonErr := json.Marshal(buckets); jsonErr == nil {
+			if setErr := s.cache.Set(ctx, key, encoded, timeseriesCacheTTL); setErr != nil {
+				slog.WarnContext(ctx, "Failed to cache click time series", slog.String("short_id", shortID), slog.Any("error", setErr))
+			}
+		}
+	}
+	return buckets, nil
+}
+
+// checkAnalyticsQuery validates the arguments shared by the analytics queries.
+func (s *UrlService) checkAnalyticsQuery(shortID string, from, to time.Time) error {
+	if s.analyticsStore == nil {
+		return ErrAnalyticsUnavailable
+	}
+	if shortID == "" {
+		return fmt.Errorf("short ID cannot be empty")
+	}
+	if !from.Before(to) {
+		return fmt.Errorf("%w: from (%s) must be before to (%s)", ErrInvalidDateRange, from.Format(time.RFC3339), to.Format(time.RFC3339))
+	}
+	return nil
+}
@@ -0,0 +1,138 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"testing"
+	"time"
+
+	"shawty/internal/cache"
+	"shawty/internal/domain"
+	"shawty/internal/geoip"
+	"shawty/internal/store"
+)
+
+// recordingAnalyticsStore keeps the clicks passed to RecordClick. Its other methods are not implemented.
+type recordingAnalyticsStore struct {
+	store.AnalyticsStoreInterface
+	clicks []domain.ClickEvent
+}
+
+func (s *recordingAnalyticsStore) RecordClick(ctx context.Context, evt domain.ClickEvent) error {
+	s.clicks = append(s.clicks, evt)
+	return nil
+}
+
+func TestRecordClickResolvesCountry(t *testing.T) {
+	resolver, err := geoip.Open("../geoip/testdata/country-test.mmdb")
+	if err != nil {
+		t.Fatalf("geoip.Open: %v", err)
+	}
+	defer resolver.Close()
+
+	tests := []struct {
+		name    string
+		geo     *geoip.Resolver
+		ip      string
+		country string
+		want    string
+	}{
+		{name: "listed address", geo: resolver, ip: "81.2.69.160", want: "GB"},
+		{name: "other listed address", geo: resolver, ip: "1.2.3.4", want: "US"},
+		{name: "private address", geo: resolver, ip: "10.0.0.1", want: geoip.UnknownCountry},
+		{name: "no client address", geo: resolver, want: geoip.UnknownCountry},
+		{name: "country already set", geo: resolver, ip: "1.2.3.4", country: "FR", want: "FR"},
+		{name: "no database", ip: "81.2.69.160", want: geoip.UnknownCountry},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			analytics := &recordingAnalyticsStore{}
+			svc := NewUrlService(store.NewMemoryUrlStore(), WithAnalyticsStore(analytics), WithGeoIP(tt.geo))
+			ctx := domain.WithAuditActor(context.Background(), domain.AuditActor{IPAddress: tt.ip})
+
+			evt := domain.ClickEvent{ShortID: "abc123", ClickedAt: time.Now().UTC(), CountryCode: tt.country}
+			if err := svc.RecordClick(ctx, evt); err != nil {
+				t.Fatalf("RecordClick: %v", err)
+			}
+			if len(analytics.clicks) != 1 {
+				t.Fatalf("recorded %d clicks, want 1", len(analytics.clicks))
+			}
+			if got := analytics.clicks[0].CountryCode; got != tt.want {
+				t.Errorf("country = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+// timeseriesStore answers every ClickTimeseries with one bucket and counts the calls.
+type timeseriesStore struct {
+	store.AnalyticsStoreInterface
+	calls int
+}
+
+func (s *timeseriesStore) ClickTimeseries(ctx context.Context, shortID, granularity string, from, to time.Time) ([]domain.ClickBucket, error) {
+	s.calls++
+	return []domain.ClickBucket{{Date: from.Format("2006-01-02"), Count: int64(s.calls)}}, nil
+}
+
+func TestClickTimeseriesValidatesRange(t *testing.T) {
+	ctx := context.Background()
+	svc := NewUrlService(store.NewMemoryUrlStore(), WithAnalyticsStore(&timeseriesStore{}))
+	from := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name        string
+		granularity string
+		to          time.Time
+		wantErr     error
+	}{
+		{name: "a day of hours", granularity: domain.GranularityHour, to: from.Add(24 * time.Hour)},
+		{name: "more than a day of hours", granularity: domain.GranularityHour, to: from.Add(25 * time.Hour), wantErr: ErrInvalidDateRange},
+		{name: "366 days", granularity: domain.GranularityDay, to: from.AddDate(0, 0, 366)},
+		{name: "more than 366 days", granularity: domain.GranularityDay, to: from.AddDate(0, 0, 367), wantErr: ErrInvalidDateRange},
+		{name: "years of weeks", granularity: domain.GranularityWeek, to: from.AddDate(5, 0, 0)},
+		{name: "years of months", granularity: domain.GranularityMonth, to: from.AddDate(5, 0, 0)},
+		{name: "reversed range", granularity: domain.GranularityDay, to: from.AddDate(0, 0, -1), wantErr: ErrInvalidDateRange},
+		{name: "unknown granularity", granularity: "minute", to: from.Add(time.Hour), wantErr: ErrInvalidGranularity},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := svc.ClickTimeseries(ctx, "abc123", tt.granularity, from, tt.to)
+			if tt.wantErr == nil && err != nil {
+				t.Errorf("ClickTimeseries: %v", err)
+			}
+			if tt.wantErr != nil && !errors.Is(err, tt.wantErr) {
+				t.Errorf("ClickTimeseries error = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestClickTimeseriesIsCached(t *testing.T) {
+	ctx := context.Background()
+	analytics := &timeseriesStore{}
+	svc := NewUrlService(store.NewMemoryUrlStore(), WithAnalyticsStore(analytics), WithCache(cache.NewByteCache(10)))
+	from := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	to := from.AddDate(0, 1, 0)
+
+	first, err := svc.ClickTimeseries(ctx, "abc123", domain.GranularityDay, from, to)
+	if err != nil {
+		t.Fatalf("ClickTimeseries: %v", err)
+	}
+	second, err := svc.ClickTimeseries(ctx, "abc123", domain.GranularityDay, from, to)
+	if err != nil {
+		t.Fatalf("repeated ClickTimeseries: %v", err)
+	}
+	if analytics.calls != 1 || !slices.Equal(first, second) {
+		t.Errorf("store queried %d times, results %+v and %+v; want one query and equal results", analytics.calls, first, second)
+	}
+
+	// Another granularity is another series.
+	if _, err := svc.ClickTimeseries(ctx, "abc123", domain.GranularityWeek, from, to); err != nil {
+		t.Fatalf("ClickTimeseries: %v", err)
+	}
+	if analytics.calls != 2 {
+		t.Errorf("store queried %d times, want 2", analytics.calls)
+	}
+}
@@ -0,0 +1,43 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"shawty/internal/domain"
+	"shawty/internal/store"
+	"shawty/internal/tracing"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// MaxAuditEvents caps the number of audit events ListAuditEvents returns.
+const MaxAuditEvents = 100
+
+// ErrAuditUnavailable is returned by ListAuditEvents when the store keeps no audit log.
+var ErrAuditUnavailable = errors.New("audit log is not available for this store")
+
+// WithAuditStore sets the store audit events are read from. Without it ListAuditEvents
+// returns ErrAuditUnavailable.
+func WithAuditStore(a store.AuditStoreInterface) Option {
+	return func(s *UrlService) {
+		s.auditStore = a
+	}
+}
+
+// ListAuditEvents returns the most recent audit events (at most MaxAuditEvents) for a short ID,
+// newest first. Events outlive the short URL, so a deleted ID still has its history.
+func (s *UrlService) ListAuditEvents(ctx context.Context, shortID string) ([]domain.AuditEvent, error) {
+	ctx, span := tracer.Start(ctx, "service.ListAuditEvents", trace.WithAttributes(attribute.String(tracing.AttrShortID, shortID)))
+	defer span.End()
+
+	if s.auditStore == nil {
+		return nil, ErrAuditUnavailable
+	}
+	if shortID == "" {
+		return nil, fmt.Errorf("short ID cannot be empty")
+	}
+	return s.auditStore.ListAuditEvents(ctx, shortID, MaxAuditEvents)
+}
@@ -0,0 +1,52 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"unicode/utf8"
+
+	"shawty/internal/domain"
+	"shawty/internal/urlutil"
+)
+
+// Limits of a bundle, a short URL that shows a page of links instead of redirecting.
+const (
+	MaxBundleLinks       = 50
+	MaxBundleTitleLength = 200
+	MaxBundleNameLength  = 200
+)
+
+// ErrInvalidBundle is returned when the links or the title of a new bundle fail validation.
+var ErrInvalidBundle = errors.New("invalid bundle")
+
+// normalizeBundleLinks validates the links of a new bundle and returns them with normalized URLs.
+// Unlike A/B test variants, names are free-form labels shown on the bundle page and default to
+// the link's URL; weights must be left at zero because every visitor sees every link.
+func (s *UrlService) normalizeBundleLinks(links []domain.Variant) ([]domain.Variant, error) {
+	if len(links) == 0 || len(links) > MaxBundleLinks {
+		return nil, fmt.Errorf("%w: got %d links, a bundle needs between 1 and %d", ErrInvalidBundle, len(links), MaxBundleLinks)
+	}
+
+	normalized := make([]domain.Variant, 0, len(links))
+	for i, link := range links {
+		if link.Weight != 0 {
+			return nil, fmt.Errorf("%w: link %d has a weight, but bundle links are all shown", ErrInvalidBundle, i+1)
+		}
+		if err := s.validateDestination(link.URL); err != nil {
+			return nil, fmt.Errorf("bundle link %d: %w", i+1, err)
+		}
+		url, err := urlutil.NormalizeURL(link.URL)
+		if err != nil {
+			return nil, fmt.Errorf("bundle link %d: %w: %v", i+1, ErrInvalidURL, err)
+		}
+		link.URL = url
+		if link.Name == "" {
+			link.Name = url
+		}
+		if utf8.RuneCountInString(link.Name) > MaxBundleNameLength {
+			return nil, fmt.Errorf("%w: name of link %d is longer than %d characters", ErrInvalidBundle, i+1, MaxBundleNameLength)
+		}
+		normalized = append(normalized, link)
+	}
+	return normalized, nil
+}
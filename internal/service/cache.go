@@ -0,0 +1,21 @@
+package service
+
+import (
+	"context"
+	"time"
+)
+
+// Cache stores derived data, such as page metadata, that is expensive to recompute.
+// Implementations must be safe for concurrent use. Get reports a missing key as an error;
+// callers treat any Get error as a miss.
+type Cache interface {
+	Get(ctx context.Context, key string) ([]byte, error)
+	Set(ctx context.Context, key string, value []byte, ttl time.Duration) error
+}
+
+// WithCache sets the cache used for derived data. Without it nothing is cached.
+func WithCache(c Cache) Option {
+	return func(s *UrlService) {
+		s.cache = c
+	}
+}
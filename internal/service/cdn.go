@@ -0,0 +1,29 @@
+package service
+
+import (
+	"context"
+	"log/slog"
+
+	"shawty/internal/cdn"
+)
+
+// WithCDNPurger sets the CDN that updated and deleted links are purged from, so that cached
+// permanent redirects do not outlive the change. Without it nothing is purged.
+func WithCDNPurger(p cdn.Purger) Option {
+	return func(s *UrlService) {
+		s.cdnPurger = p
+	}
+}
+
+// purgeCDN purges the cached redirects of shortID. The change is already stored, so a failed
+// purge is only logged; the CDN then serves the old redirect until it expires.
+func (s *UrlService) purgeCDN(ctx context.Context, shortID string) {
+	if s.cdnPurger == nil {
+		return
+	}
+	if err := s.cdnPurger.PurgeShortURL(ctx, shortID); err != nil {
+		slog.ErrorContext(ctx, "Error purging short URL from the CDN", slog.String("short_id", shortID), slog.Any("error", err))
+		return
+	}
+	slog.DebugContext(ctx, "Purged short URL from the CDN", slog.String("short_id", shortID))
+}
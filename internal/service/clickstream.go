@@ -0,0 +1,43 @@
+package service
+
+import (
+	"context"
+	"errors"
+
+	"shawty/internal/events"
+	"shawty/internal/tracing"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// ErrClickStreamUnavailable is returned by SubscribeClicks when the service has no click broadcaster.
+var ErrClickStreamUnavailable = errors.New("click streams are not available")
+
+// WithClickBroadcaster sets the broadcaster SubscribeClicks subscribes to. It only receives clicks
+// once it is subscribed to the URLClicked events of the service's bus. Without it SubscribeClicks
+// returns ErrClickStreamUnavailable.
+func WithClickBroadcaster(b *events.ClickBroadcaster) Option {
+	return func(s *UrlService) {
+		s.clicks = b
+	}
+}
+
+// SubscribeClicks returns a channel that receives the clicks of shortID as they are recorded, and
+// a function that ends the subscription. Clicks on an alias are counted on its primary link, so
+// subscribing to an alias subscribes to the primary. Links that cannot be looked up return the
+// errors of GetURLDetails.
+func (s *UrlService) SubscribeClicks(ctx context.Context, shortID string) (<-chan events.ClickEvent, func(), error) {
+	ctx, span := tracer.Start(ctx, "service.SubscribeClicks", trace.WithAttributes(attribute.String(tracing.AttrShortID, shortID)))
+	defer span.End()
+
+	if s.clicks == nil {
+		return nil, nil, ErrClickStreamUnavailable
+	}
+	urlEntry, err := s.GetURLDetails(ctx, shortID)
+	if err != nil {
+		return nil, nil, err
+	}
+	clicks, unsubscribe := s.clicks.Subscribe(urlEntry.ID)
+	return clicks, unsubscribe, nil
+}
@@ -0,0 +1,90 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	"shawty/internal/domain"
+	"shawty/internal/tracing"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// ErrInvalidSessionID is returned when a conversion's session ID is not a UUID.
+var ErrInvalidSessionID = errors.New("invalid session ID")
+
+// sessionIDPattern matches a UUID in its canonical 8-4-4-4-12 hex form.
+var sessionIDPattern = regexp.MustCompile(`^[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}$`)
+
+// NormalizeSessionID returns a session ID in lowercase and reports whether it is a UUID.
+func NormalizeSessionID(sessionID string) (string, bool) {
+	if !sessionIDPattern.MatchString(sessionID) {
+		return "", false
+	}
+	return strings.ToLower(sessionID), true
+}
+
+// RecordConversion counts a conversion of a visit to shortID, e.g. a purchase on the page the link
+// led to, and reports whether it was counted. Conversions of an alias count towards its primary
+// entry, like its clicks.
+//
+// With a sessionID, the conversion only counts if it matches a click recorded with that session ID
+// which has not been converted yet, so that a thank-you page loaded twice counts once. Matching
+// needs the click log: without an analytics store it returns ErrAnalyticsUnavailable, and with a
+// session ID that is not a UUID it returns ErrInvalidSessionID.
+func (s *UrlService) RecordConversion(ctx context.Context, shortID, sessionID string) (bool, error) {
+	ctx, span := tracer.Start(ctx, "service.RecordConversion", trace.WithAttributes(attribute.String(tracing.AttrShortID, shortID)))
+	defer span.End()
+
+	if sessionID != "" {
+		normalized, ok := NormalizeSessionID(sessionID)
+		if !ok {
+			return false, fmt.Errorf("%w: '%s' is not a UUID", ErrInvalidSessionID, sessionID)
+		}
+		sessionID = normalized
+		if s.analyticsStore == nil {
+			return false, ErrAnalyticsUnavailable
+		}
+	}
+	urlEntry, err := s.GetURLDetails(ctx, shortID)
+	if err != nil {
+		return false, err
+	}
+
+	if sessionID != "" {
+		matched, err := s.analyticsStore.MarkConverted(ctx, urlEntry.ID, sessionID, time.Now().UTC())
+		if err != nil {
+			return false, err
+		}
+		if !matched {
+			return false, nil
+		}
+	}
+	if err := s.urlStore.IncrementConversionCount(ctx, urlEntry.ID); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// ConversionRate returns the clicks and conversions counted for shortID and their ratio, which is
+// 0 for a link without clicks. Conversions reported without a session ID are not matched to
+// clicks, so the rate can exceed 1.
+func (s *UrlService) ConversionRate(ctx context.Context, shortID string) (domain.ConversionStats, error) {
+	ctx, span := tracer.Start(ctx, "service.ConversionRate", trace.WithAttributes(attribute.String(tracing.AttrShortID, shortID)))
+	defer span.End()
+
+	urlEntry, err := s.GetURLDetails(ctx, shortID)
+	if err != nil {
+		return domain.ConversionStats{}, err
+	}
+	stats := domain.ConversionStats{Clicks: urlEntry.ClickCount, Conversions: urlEntry.ConversionCount}
+	if stats.Clicks > 0 {
+		stats.Rate = float64(stats.Conversions) / float64(stats.Clicks)
+	}
+	return stats, nil
+}
@@ -0,0 +1,32 @@
+package service
+
+import (
+	"context"
+	"errors"
+
+	"shawty/internal/domain"
+	"shawty/internal/store"
+)
+
+// ErrDomainStatsUnavailable is returned by DomainStats when the store cannot group links by domain.
+var ErrDomainStatsUnavailable = errors.New("per-domain stats are not available for this store")
+
+// WithDomainStatsStore sets the store per-domain stats are read from. Without it DomainStats
+// returns ErrDomainStatsUnavailable.
+func WithDomainStatsStore(d store.DomainStatsStoreInterface) Option {
+	return func(s *UrlService) {
+		s.domainStatsStore = d
+	}
+}
+
+// DomainStats returns the links created on each domain and the clicks they received, most links
+// first. Links created before domains were recorded are counted under the empty domain.
+func (s *UrlService) DomainStats(ctx context.Context) ([]domain.DomainStats, error) {
+	ctx, span := tracer.Start(ctx, "service.DomainStats")
+	defer span.End()
+
+	if s.domainStatsStore == nil {
+		return nil, ErrDomainStatsUnavailable
+	}
+	return s.domainStatsStore.CountByDomain(ctx)
+}
@@ -0,0 +1,28 @@
+package service
+
+import (
+	"context"
+	"time"
+
+	"shawty/internal/domain"
+	"shawty/internal/events"
+)
+
+// WithEventBus publishes the service's events on bus and subscribes the service's own post-processing
+// hooks to it: storing the page metadata of new links and sending first-click webhooks.
+// Without a bus, events are not published and those hooks do not run.
+func WithEventBus(bus *events.Bus) Option {
+	return func(s *UrlService) {
+		s.events = bus
+		bus.Subscribe(events.URLCreated, s.storePageMetadata)
+		bus.Subscribe(events.URLClicked, s.notifyFirstClick)
+	}
+}
+
+// publish stamps e with the time, the client IP and the context of the request, and publishes it.
+func (s *UrlService) publish(ctx context.Context, e events.Event) {
+	e.At = time.Now().UTC()
+	e.IP = domain.AuditActorFromContext(ctx).IPAddress
+	e.Context = ctx
+	s.events.Publish(e)
+}
@@ -0,0 +1,144 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"shawty/internal/domain"
+	"shawty/internal/store"
+)
+
+// ErrHashMigrationUnavailable is returned by MigrateHashes when the store cannot list entries by hash algorithm.
+var ErrHashMigrationUnavailable = errors.New("hash migration is not available for this store")
+
+// HashMigrationResult counts what MigrateHashes did with the entries it read.
+type HashMigrationResult struct {
+	Migrated int // Entries that got an sha256 alias
+	Skipped  int // Entries whose short ID is not an MD5 hash, e.g. custom codes, or whose sha256 ID is taken
+}
+
+// WithHashAlgoStore sets the store MigrateHashes finds MD5-derived entries in.
+func WithHashAlgoStore(h store.HashAlgoStoreInterface) Option {
+	return func(s *UrlService) {
+		s.hashAlgoStore = h
+	}
+}
+
+// MigrateHashes gives every entry whose short ID was derived with MD5 an alias under the ID SHA-256
+// derives from the same input, and marks the entry as HashAlgoMigrated. The MD5 ID keeps resolving,
+// so links already handed out keep working, while the alias is what CreateShortURL would now
+// generate. Entries without a hash_algo, which predate it, are included, and only those whose ID
+// really is the MD5 hash of their URL are migrated.
+//
+// Entries are read batchSize at a time with a pause of delay after each batch, to keep the load on
+// the database down. An interrupted run can simply be started again: entries already marked are
+// not read, and an alias left over from a previous attempt is reused.
+func (s *UrlService) MigrateHashes(ctx context.Context, batchSize int, delay time.Duration) (HashMigrationResult, error) {
+	ctx, span := tracer.Start(ctx, "service.MigrateHashes")
+	defer span.End()
+
+	var result HashMigrationResult
+	if s.hashAlgoStore == nil {
+		return result, ErrHashMigrationUnavailable
+	}
+
+	afterID := ""
+	for {
+		batch, err := s.hashAlgoStore.ListByHashAlgo(ctx, []string{HashAlgoMD5, ""}, afterID, int64(batchSize))
+		if err != nil {
+			return result, fmt.Errorf("failed to list MD5 entries: %w", err)
+		}
+		for _, entry := range batch {
+			migrated, err := s.migrateHash(ctx, entry)
+			if err != nil {
+				return result, err
+			}
+			if migrated {
+				result.Migrated++
+			} else {
+				result.Skipped++
+			}
+		}
+		if len(batch) < batchSize {
+			return result, nil
+		}
+		slog.InfoContext(ctx, "Migrated batch of short IDs", slog.Int("migrated", result.Migrated), slog.Int("skipped", result.Skipped))
+		afterID = batch[len(batch)-1].ID
+
+		select {
+		case <-ctx.Done():
+			return result, ctx.Err()
+		case <-time.After(delay):
+		}
+	}
+}
+
+// migrateHash gives one entry its sha256 alias and reports whether it did.
+func (s *UrlService) migrateHash(ctx context.Context, entry domain.URL) (bool, error) {
+	hashInput, ok := s.md5HashInput(entry)
+	if !ok {
+		if entry.HashAlgo == HashAlgoMD5 {
+			slog.WarnContext(ctx, "Short ID does not match the MD5 hash of its URL, skipping", slog.String("short_id", entry.ID))
+		}
+		return false, nil
+	}
+
+	aliasID := generateShortID(hashInput, HashAlgoSHA256, s.shortIDLength)
+	primaryID := entry.ID
+	alias := domain.URL{
+		ID:           aliasID,
+		ShortUrl:     aliasID,
+		CreationDate: time.Now().UTC(),
+		TenantID:     entry.TenantID,
+		AliasOf:      &primaryID,
+		HashAlgo:     HashAlgoSHA256,
+	}
+	// The alias is not the tenant's doing, so it does not count against their quota.
+	if err := s.urlStore.Save(ctx, alias); err != nil {
+		if !errors.Is(err, store.ErrDuplicateShortID) {
+			return false, fmt.Errorf("failed to save sha256 alias of '%s': %w", entry.ID, err)
+		}
+		existing, getErr := s.urlStore.GetByShortID(ctx, aliasID, store.AnyTenant)
+		if getErr != nil && !errors.Is(getErr, store.ErrURLDeleted) {
+			return false, fmt.Errorf("failed to look up sha256 ID '%s': %w", aliasID, getErr)
+		}
+		// GetByShortID follows aliases, so an alias of this entry from an earlier run comes back as the entry itself.
+		if existing.ID != entry.ID {
+			slog.WarnContext(ctx, "SHA-256 short ID is taken, skipping", slog.String("short_id", entry.ID), slog.String("sha256_id", aliasID))
+			return false, nil
+		}
+	}
+
+	if err := s.hashAlgoStore.SetHashAlgo(ctx, entry.ID, HashAlgoMigrated); err != nil {
+		return false, fmt.Errorf("failed to mark '%s' as migrated: %w", entry.ID, err)
+	}
+	slog.DebugContext(ctx, "Migrated short ID", slog.String("short_id", entry.ID), slog.String("sha256_id", aliasID))
+	return true, nil
+}
+
+// md5HashInput finds the input hashWithRetry hashed into entry's ID with MD5: the URL, prefixed
+// with the tenant if there is one, and suffixed with the retry attempt after a collision. It
+// reports false if the ID is not such a hash, as with custom codes.
+func (s *UrlService) md5HashInput(entry domain.URL) (string, bool) {
+	hashBase := entry.OriginalUrl
+	if entry.TenantID != "" {
+		hashBase = entry.TenantID + "|" + entry.OriginalUrl
+	}
+	// MD5 IDs are hex prefixes of a 32-character digest.
+	if len(entry.ID) > 32 {
+		return "", false
+	}
+	for attempt := 0; attempt <= s.maxCollisionRetries; attempt++ {
+		hashInput := hashBase
+		if attempt > 0 {
+			hashInput = fmt.Sprintf("%s_%d", hashBase, attempt)
+		}
+		if generateShortID(hashInput, HashAlgoMD5, len(entry.ID)) == entry.ID {
+			return hashInput, true
+		}
+	}
+	return "", false
+}
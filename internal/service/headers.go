@@ -0,0 +1,52 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"net/textproto"
+	"unicode/utf8"
+
+	"shawty/internal/domain"
+
+	"golang.org/x/net/http/httpguts"
+)
+
+// Limits on the response headers of a short URL.
+const (
+	MaxCustomHeaders           = 10
+	MaxCustomHeaderNameLength  = 64
+	MaxCustomHeaderValueLength = 256
+)
+
+// ErrInvalidCustomHeaders is returned when the custom response headers of a new short URL fail validation.
+var ErrInvalidCustomHeaders = errors.New("invalid custom headers")
+
+// normalizeCustomHeaders validates the response headers a new short URL sets on its redirects and
+// returns them under their canonical names, e.g. "Cache-Control" for "cache-control". Headers that
+// domain.CustomHeaderAllowed denies are dropped without an error. It returns nil when no header is left.
+func normalizeCustomHeaders(headers map[string]string) (map[string]string, error) {
+	if len(headers) > MaxCustomHeaders {
+		return nil, fmt.Errorf("%w: got %d headers, at most %d are allowed", ErrInvalidCustomHeaders, len(headers), MaxCustomHeaders)
+	}
+	var normalized map[string]string
+	for name, value := range headers {
+		if name == "" || len(name) > MaxCustomHeaderNameLength || !httpguts.ValidHeaderFieldName(name) {
+			return nil, fmt.Errorf("%w: '%s' is not a header name of at most %d characters", ErrInvalidCustomHeaders, name, MaxCustomHeaderNameLength)
+		}
+		if utf8.RuneCountInString(value) > MaxCustomHeaderValueLength || !httpguts.ValidHeaderFieldValue(value) {
+			return nil, fmt.Errorf("%w: value of '%s' must be at most %d characters without control characters", ErrInvalidCustomHeaders, name, MaxCustomHeaderValueLength)
+		}
+		if !domain.CustomHeaderAllowed(name) {
+			continue
+		}
+		name = textproto.CanonicalMIMEHeaderKey(name)
+		if _, ok := normalized[name]; ok {
+			return nil, fmt.Errorf("%w: '%s' is given twice", ErrInvalidCustomHeaders, name)
+		}
+		if normalized == nil {
+			normalized = make(map[string]string, len(headers))
+		}
+		normalized[name] = value
+	}
+	return normalized, nil
+}
@@ -0,0 +1,207 @@
+package service
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"shawty/internal/domain"
+	"shawty/internal/events"
+	"shawty/internal/urlutil"
+
+	"golang.org/x/net/html"
+)
+
+// Limits for fetching destination pages.
+const (
+	metadataFetchTimeout = 5 * time.Second
+	metadataMaxBodyBytes = 64 << 10
+	metadataMaxRedirects = 3
+	metadataCacheTTL     = time.Hour
+	// pageMetadataTimeout bounds the background fetch that stores a new link's page title and
+	// description, retry included.
+	pageMetadataTimeout = 10 * time.Second
+)
+
+// metadataClient fetches destination pages. It refuses to connect to private addresses.
+var metadataClient = urlutil.NewSafeHTTPClient(metadataFetchTimeout, metadataMaxRedirects)
+
+// metadataCacheKey returns the cache key for a destination URL's metadata.
+func metadataCacheKey(originalURL string) string {
+	sum := sha256.Sum256([]byte(originalURL))
+	return "shawty:meta:" + hex.EncodeToString(sum[:])
+}
+
+// FetchURLMetadata returns the title, description and Open Graph image of the page at originalURL.
+// Results are cached for an hour when a cache is configured.
+func (s *UrlService) FetchURLMetadata(ctx context.Context, originalURL string) (domain.URLMetadata, error) {
+	ctx, span := tracer.Start(ctx, "service.FetchURLMetadata")
+	defer span.End()
+
+	key := metadataCacheKey(originalURL)
+	if s.cache != nil {
+		if cached, err := s.cache.Get(ctx, key); err == nil {
+			var meta domain.URLMetadata
+			if jsonErr := json.Unmarshal(cached, &meta); jsonErr == nil {
+				return meta, nil
+			}
+		}
+	}
+
+	meta, err := fetchMetadata(ctx, originalURL)
+	if err != nil {
+		return domain.URLMetadata{}, err
+	}
+
+	if s.cache != nil {
+		if encoded, jsonErr := json.Marshal(meta); jsonErr == nil {
+			if setErr := s.cache.Set(ctx, key, encoded, metadataCacheTTL); setErr != nil {
+				slog.WarnContext(ctx, "Failed to cache URL metadata", slog.String("original_url", originalURL), slog.Any("error", setErr))
+			}
+		}
+	}
+	return meta, nil
+}
+
+// storePageMetadata handles URLCreated events: it fetches the title and description of the new link's
+// destination and stores them with the entry. A network error is retried once; failures are only
+// logged, since the link works without its metadata.
+func (s *UrlService) storePageMetadata(e events.Event) {
+	ctx, cancel := context.WithTimeout(e.Context, pageMetadataTimeout)
+	defer cancel()
+	shortID, originalURL := e.ShortID, e.OriginalURL
+
+	meta, err := fetchMetadata(ctx, originalURL)
+	var netErr *url.Error
+	if errors.As(err, &netErr) && ctx.Err() == nil {
+		slog.DebugContext(ctx, "Retrying page metadata fetch", slog.String("short_id", shortID), slog.Any("error", err))
+		meta, err = fetchMetadata(ctx, originalURL)
+	}
+	if err != nil {
+		slog.WarnContext(ctx, "Could not fetch page metadata", slog.String("short_id", shortID), slog.String("original_url", originalURL), slog.Any("error", err))
+		return
+	}
+	if meta.Title == "" && meta.Description == "" {
+		return
+	}
+	if err := s.urlStore.UpdateMetadata(ctx, shortID, meta.Title, meta.Description); err != nil {
+		slog.WarnContext(ctx, "Could not store page metadata", slog.String("short_id", shortID), slog.Any("error", err))
+		return
+	}
+	slog.DebugContext(ctx, "Stored page metadata", slog.String("short_id", shortID), slog.String("page_title", meta.Title))
+}
+
+// fetchMetadata downloads the start of the page at rawURL and extracts its metadata.
+func fetchMetadata(ctx context.Context, rawURL string) (domain.URLMetadata, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, rawURL, nil)
+	if err != nil {
+		return domain.URLMetadata{}, fmt.Errorf("failed to build metadata request: %w", err)
+	}
+	req.Header.Set("Accept", "text/html")
+	req.Header.Set("User-Agent", "ShawtyPreview/1.0")
+
+	resp, err := metadataClient.Do(req)
+	if err != nil {
+		return domain.URLMetadata{}, fmt.Errorf("failed to fetch '%s': %w", rawURL, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return domain.URLMetadata{}, fmt.Errorf("fetching '%s' returned status %d", rawURL, resp.StatusCode)
+	}
+	return parseMetadata(io.LimitReader(resp.Body, metadataMaxBodyBytes)), nil
+}
+
+// parseMetadata scans an HTML document for <title> and the og:title, og:description and og:image
+// meta tags, falling back to <meta name="description">. It stops at the end of <head>.
+// Pages with a robots nosnippet directive get no description.
+func parseMetadata(r io.Reader) domain.URLMetadata {
+	var meta domain.URLMetadata
+	var ogTitle, plainDescription string
+	inTitle, noSnippet := false, false
+
+	tokenizer := html.NewTokenizer(r)
+	for {
+		switch tokenizer.Next() {
+		case html.ErrorToken:
+			return finishMetadata(meta, ogTitle, plainDescription, noSnippet)
+		case html.StartTagToken, html.SelfClosingTagToken:
+			token := tokenizer.Token()
+			switch token.Data {
+			case "title":
+				inTitle = true
+			case "meta":
+				var property, name, content string
+				for _, attr := range token.Attr {
+					switch strings.ToLower(attr.Key) {
+					case "property":
+						property = strings.ToLower(attr.Val)
+					case "name":
+						name = strings.ToLower(attr.Val)
+					case "content":
+						content = strings.TrimSpace(attr.Val)
+					}
+				}
+				switch {
+				case property == "og:title":
+					ogTitle = content
+				case property == "og:description":
+					meta.Description = content
+				case property == "og:image":
+					meta.Image = content
+				case name == "description":
+					plainDescription = content
+				case name == "robots":
+					noSnippet = noSnippet || hasRobotsDirective(content, "nosnippet")
+				}
+			case "body":
+				return finishMetadata(meta, ogTitle, plainDescription, noSnippet)
+			}
+		case html.TextToken:
+			if inTitle && meta.Title == "" {
+				meta.Title = strings.TrimSpace(string(tokenizer.Text()))
+			}
+		case html.EndTagToken:
+			switch tokenizer.Token().Data {
+			case "title":
+				inTitle = false
+			case "head":
+				return finishMetadata(meta, ogTitle, plainDescription, noSnippet)
+			}
+		}
+	}
+}
+
+// finishMetadata fills empty fields from the fallback tags and drops the description of nosnippet pages.
+func finishMetadata(meta domain.URLMetadata, ogTitle, plainDescription string, noSnippet bool) domain.URLMetadata {
+	if meta.Title == "" {
+		meta.Title = ogTitle
+	}
+	if meta.Description == "" {
+		meta.Description = plainDescription
+	}
+	if noSnippet {
+		meta.Description = ""
+	}
+	return meta
+}
+
+// hasRobotsDirective reports whether the content of a robots meta tag, e.g. "noindex, nosnippet",
+// contains directive.
+func hasRobotsDirective(content, directive string) bool {
+	for _, d := range strings.Split(content, ",") {
+		if strings.EqualFold(strings.TrimSpace(d), directive) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,62 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+
+	"shawty/internal/store"
+	"shawty/internal/tracing"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// ErrOwnershipUnavailable is returned by TransferOwnership when the store cannot move links between tenants.
+var ErrOwnershipUnavailable = errors.New("ownership transfer is not available for this store")
+
+// ErrInvalidOwner is returned when a short URL is transferred to an empty tenant ID.
+var ErrInvalidOwner = errors.New("invalid new owner")
+
+// WithOwnershipStore sets the store that moves links between tenants. Without it
+// TransferOwnership returns ErrOwnershipUnavailable.
+func WithOwnershipStore(o store.OwnershipStoreInterface) Option {
+	return func(s *UrlService) {
+		s.ownershipStore = o
+	}
+}
+
+// TransferOwnership makes newOwnerID the tenant of shortID, so that the link is listed and
+// looked up as newOwnerID's only. newOwnerID must be a tenant the store knows, i.e. one that has
+// created a link or been given a quota; otherwise store.ErrTenantNotFound is returned.
+//
+// The link is moved from the quota of its previous tenant to that of newOwnerID. The new owner's
+// quota is not enforced: an admin may hand a tenant more links than it could create itself.
+func (s *UrlService) TransferOwnership(ctx context.Context, shortID, newOwnerID string) error {
+	ctx, span := tracer.Start(ctx, "service.TransferOwnership", trace.WithAttributes(attribute.String(tracing.AttrShortID, shortID)))
+	defer span.End()
+
+	if s.ownershipStore == nil {
+		return ErrOwnershipUnavailable
+	}
+	if newOwnerID == "" {
+		return fmt.Errorf("%w: the new owner must not be empty", ErrInvalidOwner)
+	}
+	previousOwner, err := s.ownershipStore.TransferOwnership(ctx, shortID, newOwnerID)
+	if err != nil {
+		return err
+	}
+	if previousOwner == newOwnerID {
+		return nil
+	}
+
+	if s.quotaStore != nil {
+		if _, err := s.quotaStore.IncrementTenantURLCount(ctx, newOwnerID, 1); err != nil {
+			slog.ErrorContext(ctx, "Error charging transferred URL to its new owner's quota", slog.String("short_id", shortID), slog.String("tenant_id", newOwnerID), slog.Any("error", err))
+		}
+		s.releaseQuota(ctx, previousOwner)
+	}
+	slog.InfoContext(ctx, "Transferred short URL", slog.String("short_id", shortID), slog.String("previous_owner", previousOwner), slog.String("new_owner", newOwnerID))
+	return nil
+}
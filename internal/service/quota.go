@@ -0,0 +1,101 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+
+	"shawty/internal/domain"
+	"shawty/internal/store"
+)
+
+// ErrQuotaExceeded is returned when a tenant has created as many links as its quota allows.
+var ErrQuotaExceeded = errors.New("URL quota exceeded")
+
+// ErrQuotasUnavailable is returned by the tenant quota methods when the store keeps no quotas.
+var ErrQuotasUnavailable = errors.New("tenant quotas are not available for this store")
+
+// ErrInvalidQuota is returned when a negative quota is requested.
+var ErrInvalidQuota = errors.New("quota must be zero (unlimited) or positive")
+
+// WithQuotaStore sets the store that counts the links of each tenant. Without it no quotas are
+// enforced and the tenant quota methods return ErrQuotasUnavailable.
+func WithQuotaStore(q store.QuotaStoreInterface) Option {
+	return func(s *UrlService) {
+		s.quotaStore = q
+	}
+}
+
+// saveEntry saves a new entry, counting it against the quota of its tenant. The reserved quota
+// is given back when the save fails, e.g. because the short ID is taken.
+func (s *UrlService) saveEntry(ctx context.Context, entry domain.URL) error {
+	if err := s.reserveQuota(ctx, entry.TenantID); err != nil {
+		return err
+	}
+	if err := s.urlStore.Save(ctx, entry); err != nil {
+		s.releaseQuota(ctx, entry.TenantID)
+		return err
+	}
+	return nil
+}
+
+// reserveQuota counts one more link for tenantID and returns ErrQuotaExceeded if that takes the
+// tenant over its quota. The counter is incremented before it is checked, so concurrent requests
+// cannot both take the last free link. Links without a tenant are not counted.
+func (s *UrlService) reserveQuota(ctx context.Context, tenantID string) error {
+	if s.quotaStore == nil || tenantID == "" {
+		return nil
+	}
+	tenant, err := s.quotaStore.IncrementTenantURLCount(ctx, tenantID, 1)
+	if err != nil {
+		return fmt.Errorf("failed to reserve URL quota: %w", err)
+	}
+	if tenant.QuotaExceeded() {
+		s.releaseQuota(ctx, tenantID)
+		return fmt.Errorf("%w: tenant '%s' may create at most %d links", ErrQuotaExceeded, tenantID, tenant.TenantQuota)
+	}
+	return nil
+}
+
+// releaseQuota gives back a link reserved with reserveQuota. Failures are only logged; they leave
+// the tenant with one link fewer to create.
+func (s *UrlService) releaseQuota(ctx context.Context, tenantID string) {
+	if s.quotaStore == nil || tenantID == "" {
+		return
+	}
+	if _, err := s.quotaStore.IncrementTenantURLCount(ctx, tenantID, -1); err != nil {
+		slog.ErrorContext(ctx, "Error releasing URL quota", slog.String("tenant_id", tenantID), slog.Any("error", err))
+	}
+}
+
+// GetTenantQuota returns the quota and the number of links created by tenantID.
+func (s *UrlService) GetTenantQuota(ctx context.Context, tenantID string) (domain.Tenant, error) {
+	ctx, span := tracer.Start(ctx, "service.GetTenantQuota")
+	defer span.End()
+
+	if s.quotaStore == nil {
+		return domain.Tenant{}, ErrQuotasUnavailable
+	}
+	return s.quotaStore.GetTenant(ctx, tenantID)
+}
+
+// SetTenantQuota limits tenantID to quota links; 0 removes the limit. Lowering the quota below the
+// links already created only stops new ones from being created.
+func (s *UrlService) SetTenantQuota(ctx context.Context, tenantID string, quota int64) (domain.Tenant, error) {
+	ctx, span := tracer.Start(ctx, "service.SetTenantQuota")
+	defer span.End()
+
+	if s.quotaStore == nil {
+		return domain.Tenant{}, ErrQuotasUnavailable
+	}
+	if quota < 0 {
+		return domain.Tenant{}, fmt.Errorf("%w: got %d", ErrInvalidQuota, quota)
+	}
+	tenant, err := s.quotaStore.SetTenantQuota(ctx, tenantID, quota)
+	if err != nil {
+		return domain.Tenant{}, err
+	}
+	slog.InfoContext(ctx, "Set tenant quota", slog.String("tenant_id", tenantID), slog.Int64("quota", quota))
+	return tenant, nil
+}
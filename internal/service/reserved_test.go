@@ -0,0 +1,80 @@
+package service
+
+import (
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"io/fs"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+// repoRoot is the module root relative to this package.
+const repoRoot = "../.."
+
+// TestNoReservedWordIsAValidShortCode checks that no path segment of a registered route can be taken
+// as a custom short code. Routes are found in the source, as the string literals passed to Handle and
+// HandleFunc, so a new route whose segment is missing from urlutil.ReservedCodes fails the build.
+// The probe paths come from configuration; their defaults are checked explicitly.
+func TestNoReservedWordIsAValidShortCode(t *testing.T) {
+	segments := map[string]string{"livez": "LIVENESS_PATH default", "readyz": "READINESS_PATH default"}
+	fset := token.NewFileSet()
+	err := filepath.WalkDir(repoRoot, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() && (d.Name() == "node_modules" || d.Name() == "testdata" || strings.HasPrefix(d.Name(), ".")) && path != repoRoot {
+			return filepath.SkipDir
+		}
+		if d.IsDir() || !strings.HasSuffix(path, ".go") || strings.HasSuffix(path, "_test.go") {
+			return nil
+		}
+		file, err := parser.ParseFile(fset, path, nil, parser.SkipObjectResolution)
+		if err != nil {
+			return err
+		}
+		ast.Inspect(file, func(n ast.Node) bool {
+			call, ok := n.(*ast.CallExpr)
+			if !ok || len(call.Args) != 2 {
+				return true
+			}
+			sel, ok := call.Fun.(*ast.SelectorExpr)
+			if !ok || (sel.Sel.Name != "Handle" && sel.Sel.Name != "HandleFunc") {
+				return true
+			}
+			// Patterns are literals or built from them, e.g. prefix+"/admin/urls".
+			ast.Inspect(call.Args[0], func(n ast.Node) bool {
+				lit, ok := n.(*ast.BasicLit)
+				if !ok || lit.Kind != token.STRING {
+					return true
+				}
+				pattern, err := strconv.Unquote(lit.Value)
+				if err != nil {
+					return true
+				}
+				for segment := range strings.SplitSeq(pattern, "/") {
+					if segment != "" {
+						segments[segment] = fset.Position(lit.Pos()).String()
+					}
+				}
+				return true
+			})
+			return true
+		})
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("scanning the source for routes: %v", err)
+	}
+	if len(segments) < 10 {
+		t.Fatalf("found only %d route segments; is the source scan still matching the route registrations?", len(segments))
+	}
+
+	for segment, source := range segments {
+		if validateCustomCode(segment) == nil {
+			t.Errorf("custom code %q is accepted but is a route segment (%s); add it to urlutil.ReservedCodes", segment, source)
+		}
+	}
+}
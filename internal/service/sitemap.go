@@ -0,0 +1,48 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"shawty/internal/domain"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// MaxSitemapURLs is the largest number of links listed in a sitemap, the limit search engines
+// accept in a single sitemap file.
+const MaxSitemapURLs = 50000
+
+// errSitemapFull stops the store iteration of ListSitemapURLs once limit links were collected.
+var errSitemapFull = errors.New("sitemap is full")
+
+// ListSitemapURLs returns up to limit links that currently redirect, in short ID order: deleted,
+// expired, used-up and not yet active links are left out, and so are password-protected links and
+// aliases, which would only repeat their primary.
+func (s *UrlService) ListSitemapURLs(ctx context.Context, limit int) ([]domain.URL, error) {
+	ctx, span := tracer.Start(ctx, "service.ListSitemapURLs", trace.WithAttributes(attribute.Int("limit", limit)))
+	defer span.End()
+
+	now := time.Now().UTC()
+	var urls []domain.URL
+	err := s.urlStore.ForEach(ctx, func(urlEntry domain.URL) error {
+		if urlEntry.IsDeleted() || urlEntry.IsExpired(now) || !urlEntry.IsActive(now) ||
+			urlEntry.IsPasswordProtected() || urlEntry.AliasOf != nil {
+			return nil
+		}
+		if remaining := urlEntry.ClicksRemaining(); remaining != nil && *remaining == 0 {
+			return nil
+		}
+		if len(urls) == limit {
+			return errSitemapFull
+		}
+		urls = append(urls, urlEntry)
+		return nil
+	})
+	if err != nil && !errors.Is(err, errSitemapFull) {
+		return nil, err
+	}
+	return urls, nil
+}
@@ -0,0 +1,80 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+
+	"shawty/internal/domain"
+	"shawty/internal/store"
+	"shawty/internal/tracing"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// MaxTags is the largest number of tags a short URL may carry.
+const MaxTags = 10
+
+// ErrInvalidTags is returned when a tag fails validation or a URL is given too many tags.
+var ErrInvalidTags = errors.New("invalid tags")
+
+// tagPattern restricts tags to short lowercase slugs such as "campaign-q4".
+var tagPattern = regexp.MustCompile(`^[a-z0-9][a-z0-9_-]{0,49}$`)
+
+// normalizeTags lowercases and trims tags, drops duplicates and validates the result.
+// It returns nil for an empty list so that entries without tags store none.
+func normalizeTags(tags []string) ([]string, error) {
+	if len(tags) == 0 {
+		return nil, nil
+	}
+	normalized := make([]string, 0, len(tags))
+	for _, tag := range tags {
+		tag = strings.ToLower(strings.TrimSpace(tag))
+		if !tagPattern.MatchString(tag) {
+			return nil, fmt.Errorf("%w: '%s' must be 1-50 lowercase letters, digits, '_' or '-'", ErrInvalidTags, tag)
+		}
+		if !slices.Contains(normalized, tag) {
+			normalized = append(normalized, tag)
+		}
+	}
+	if len(normalized) > MaxTags {
+		return nil, fmt.Errorf("%w: got %d tags, at most %d are allowed", ErrInvalidTags, len(normalized), MaxTags)
+	}
+	return normalized, nil
+}
+
+// ListURLsByTag returns up to limit stored URLs carrying tag, in short ID order, skipping the first
+// offset, along with the number of URLs carrying it. Callers are responsible for bounding limit.
+func (s *UrlService) ListURLsByTag(ctx context.Context, tag string, limit, offset int64) ([]domain.URL, int64, error) {
+	ctx, span := tracer.Start(ctx, "service.ListURLsByTag", trace.WithAttributes(attribute.String("tag", tag), attribute.Int64("limit", limit), attribute.Int64("offset", offset)))
+	defer span.End()
+
+	if limit < 1 || offset < 0 {
+		return nil, 0, fmt.Errorf("limit must be positive and offset non-negative (limit=%d, offset=%d)", limit, offset)
+	}
+	return s.urlStore.ListByTag(ctx, strings.ToLower(strings.TrimSpace(tag)), limit, offset)
+}
+
+// UpdateTags replaces the tags of a short URL, leaving its destination unchanged, and returns the
+// updated entry. An empty list removes all tags. It returns store.ErrURLNotFound if the short ID
+// does not exist and ErrInvalidTags if a tag fails validation.
+func (s *UrlService) UpdateTags(ctx context.Context, shortID string, tags []string) (domain.URL, error) {
+	ctx, span := tracer.Start(ctx, "service.UpdateTags", trace.WithAttributes(attribute.String(tracing.AttrShortID, shortID)))
+	defer span.End()
+
+	if shortID == "" {
+		return domain.URL{}, fmt.Errorf("short ID cannot be empty")
+	}
+	normalized, err := normalizeTags(tags)
+	if err != nil {
+		return domain.URL{}, err
+	}
+	if err := s.urlStore.UpdateTags(ctx, shortID, normalized); err != nil {
+		return domain.URL{}, err
+	}
+	return s.urlStore.GetByShortID(ctx, shortID, store.AnyTenant)
+}
@@ -3,96 +3,824 @@ package service
 import (
 	"context"
 	"crypto/md5"
+	"crypto/sha256"
+	"encoding/binary"
 	"encoding/hex"
 	"errors"
 	"fmt"
+	"log/slog"
+	"net/url"
+	"regexp"
+	"strings"
+	"sync"
 	"time"
+	"unicode/utf8"
 
+	"shawty/internal/cdn"
 	"shawty/internal/domain"
+	"shawty/internal/events"
+	"shawty/internal/geoip"
+	"shawty/internal/metrics"
+	"shawty/internal/middleware"
 	"shawty/internal/store"
+	"shawty/internal/tracing"
+	"shawty/internal/urlutil"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+	"golang.org/x/crypto/bcrypt"
 )
 
+// tracer creates the service-layer spans.
+var tracer = otel.Tracer("shawty/internal/service")
+
 // ErrHashCollision is returned when two different original URLs generate the same short ID.
 var ErrHashCollision = errors.New("hash collision detected")
 
+// ErrCustomCodeTaken is returned when a requested custom short code is already in use.
+var ErrCustomCodeTaken = errors.New("custom code is already taken")
+
+// ErrInvalidCustomCode is returned when a requested custom short code fails validation.
+var ErrInvalidCustomCode = errors.New("invalid custom code")
+
+// ErrReservedCode is returned when a requested custom short code is one of urlutil.ReservedCodes.
+var ErrReservedCode = errors.New("custom code is reserved")
+
+// ErrURLExpired is returned when a short URL exists but its expiration time has passed.
+var ErrURLExpired = errors.New("short URL has expired")
+
+// ErrURLNotYetActive is returned when a short URL exists but its activation time has not come yet.
+var ErrURLNotYetActive = errors.New("short URL is not active yet")
+
+// ErrInvalidSchedule is returned when a link's activation window is empty or has already ended.
+var ErrInvalidSchedule = errors.New("invalid activation window")
+
+// ErrInvalidURL is returned when the submitted URL is malformed, uses an unsupported scheme,
+// or points at a private network address.
+var ErrInvalidURL = errors.New("invalid URL")
+
+// ErrTooManyURLs is returned when a bulk request is empty or exceeds MaxBulkURLs entries.
+var ErrTooManyURLs = errors.New("bulk request must contain between 1 and 100 URLs")
+
+// ErrDomainBlacklisted is returned when the submitted URL's host is on the blacklist.
+var ErrDomainBlacklisted = errors.New("domain is blacklisted")
+
+// ErrInvalidRedirectType is returned when a redirect type other than domain.RedirectPermanent
+// or domain.RedirectTemporary is requested.
+var ErrInvalidRedirectType = errors.New("redirect type must be permanent or temporary")
+
+// ErrInvalidMaxClicks is returned when a click limit below 1 is requested.
+var ErrInvalidMaxClicks = errors.New("max clicks must be at least 1")
+
+// ErrInvalidWebhookURL is returned when a webhook URL is malformed or points at a private network address.
+var ErrInvalidWebhookURL = errors.New("invalid webhook URL")
+
+// ErrInvalidPassword is returned when a link password cannot be used, e.g. because it is too long for bcrypt.
+var ErrInvalidPassword = errors.New("invalid password")
+
+// PasswordHashCost is the bcrypt cost used to hash link passwords.
+const PasswordHashCost = 12
+
+// MaxBulkURLs caps the number of URLs accepted by BulkCreateShortURL.
+const MaxBulkURLs = 100
+
+// DefaultBulkConcurrency is how many URLs BulkCreateShortURL shortens at once when no limit is configured.
+const DefaultBulkConcurrency = 10
+
+// customCodePattern restricts custom short codes to URL-safe characters and a sensible length.
+var customCodePattern = regexp.MustCompile(`^[a-zA-Z0-9_-]{3,50}$`)
+
+// CreateOptions holds optional settings for a new short URL.
+type CreateOptions struct {
+	// CustomCode, when set, is used as the short ID instead of a hash of the URL.
+	CustomCode string
+	// ExpiresIn, when positive, makes the link stop resolving after this duration.
+	ExpiresIn time.Duration
+	// RedirectType is domain.RedirectPermanent or domain.RedirectTemporary; empty uses the server default.
+	RedirectType string
+	// Password, when set, must be presented to follow the link. Only its bcrypt hash is stored.
+	Password string
+	// MaxClicks, when set, makes the link stop resolving after this many redirects.
+	MaxClicks *int64
+	// UTM parameters are added to the destination on every redirect.
+	UTM domain.UTMParams
+	// Tags label the link for filtering; see normalizeTags for the accepted form.
+	Tags []string
+	// MobileURL, when set, is the destination for visitors on mobile devices.
+	MobileURL string
+	// WebhookURL, when set, receives a webhook.FirstClickEvent when the link is followed for the first time.
+	WebhookURL string
+	// Variants, when set, split the link's traffic by weight for A/B tests; see normalizeVariants.
+	Variants []domain.Variant
+	// ActiveFrom and ActiveUntil, when set, limit the time the link redirects to [ActiveFrom, ActiveUntil).
+	ActiveFrom  *time.Time
+	ActiveUntil *time.Time
+	// Bundle, when set, makes the link show a page listing Variants instead of redirecting;
+	// see normalizeBundleLinks. BundleTitle is the title of that page.
+	Bundle      bool
+	BundleTitle string
+	// CustomHeaders are set on the link's redirect responses; see normalizeCustomHeaders.
+	CustomHeaders map[string]string
+	// Domain is the host the link is created on, e.g. go.example.com, recorded for per-domain stats.
+	Domain string
+}
+
+// validate checks the options that do not depend on the store.
+func (o CreateOptions) validate() error {
+	switch o.RedirectType {
+	case "", domain.RedirectPermanent, domain.RedirectTemporary:
+	default:
+		return fmt.Errorf("%w: got '%s'", ErrInvalidRedirectType, o.RedirectType)
+	}
+	if o.MaxClicks != nil && *o.MaxClicks < 1 {
+		return fmt.Errorf("%w: got %d", ErrInvalidMaxClicks, *o.MaxClicks)
+	}
+	if _, err := normalizeTags(o.Tags); err != nil {
+		return err
+	}
+	if _, err := normalizeCustomHeaders(o.CustomHeaders); err != nil {
+		return err
+	}
+	if o.WebhookURL != "" {
+		if err := urlutil.ValidateURL(o.WebhookURL); err != nil {
+			return fmt.Errorf("%w: %v", ErrInvalidWebhookURL, err)
+		}
+	}
+	if o.ActiveUntil != nil {
+		if !o.ActiveUntil.After(time.Now()) {
+			return fmt.Errorf("%w: active_until (%s) has already passed", ErrInvalidSchedule, o.ActiveUntil.Format(time.RFC3339))
+		}
+		if o.ActiveFrom != nil && !o.ActiveFrom.Before(*o.ActiveUntil) {
+			return fmt.Errorf("%w: active_from (%s) must be before active_until (%s)", ErrInvalidSchedule, o.ActiveFrom.Format(time.RFC3339), o.ActiveUntil.Format(time.RFC3339))
+		}
+	}
+	if !o.Bundle && o.BundleTitle != "" {
+		return fmt.Errorf("%w: bundle_title is only allowed on bundles", ErrInvalidBundle)
+	}
+	if o.Bundle && o.MobileURL != "" {
+		return fmt.Errorf("%w: a bundle shows the same page on every device and cannot have a mobile_url", ErrInvalidBundle)
+	}
+	if utf8.RuneCountInString(o.BundleTitle) > MaxBundleTitleLength {
+		return fmt.Errorf("%w: bundle_title is longer than %d characters", ErrInvalidBundle, MaxBundleTitleLength)
+	}
+	return nil
+}
+
+// newEntry builds the entry to save for originalURL, without its short ID.
+func (o CreateOptions) newEntry(originalURL, submittedURL string, now time.Time) domain.URL {
+	entry := domain.URL{
+		OriginalUrl:  originalURL,
+		SubmittedUrl: submittedURL,
+		CreationDate: now,
+		ExpiresAt:    o.expiresAt(now),
+		RedirectType: o.RedirectType,
+		MaxClicks:    o.MaxClicks,
+		WebhookURL:   o.WebhookURL,
+		ActiveFrom:   utcTime(o.ActiveFrom),
+		ActiveUntil:  utcTime(o.ActiveUntil),
+		Bundle:       o.Bundle,
+		BundleTitle:  o.BundleTitle,
+		Domain:       o.Domain,
+	}
+	if o.MobileURL != "" {
+		mobileURL := o.MobileURL
+		entry.MobileURL = &mobileURL
+	}
+	// validate has already rejected invalid tags and headers.
+	entry.Tags, _ = normalizeTags(o.Tags)
+	entry.CustomHeaders, _ = normalizeCustomHeaders(o.CustomHeaders)
+	if !o.UTM.IsZero() {
+		utm := o.UTM
+		entry.UTM = &utm
+	}
+	return entry
+}
+
+// utcTime returns a copy of t in UTC, or nil for nil.
+func utcTime(t *time.Time) *time.Time {
+	if t == nil {
+		return nil
+	}
+	utc := t.UTC()
+	return &utc
+}
+
+// expiresAt returns the absolute expiration time for a link created at now, or nil if it never expires.
+func (o CreateOptions) expiresAt(now time.Time) *time.Time {
+	if o.ExpiresIn <= 0 {
+		return nil
+	}
+	t := now.Add(o.ExpiresIn)
+	return &t
+}
+
+// BulkResult is the outcome of shortening one entry of a bulk request.
+// Exactly one of URL and Err is meaningful: Err is nil when the entry succeeded.
+type BulkResult struct {
+	OriginalURL string
+	URL         domain.URL
+	Err         error
+}
+
 // UrlServiceInterface defines operations for URL management.
 type UrlServiceInterface interface {
-	CreateShortURL(ctx context.Context, originalURL string) (domain.URL, error)
+	CreateShortURL(ctx context.Context, originalURL string, opts CreateOptions) (domain.URL, error)
 	GetOriginalURL(ctx context.Context, shortID string) (string, error)
+	ResolveShortURL(ctx context.Context, shortID string) (domain.URL, error)
+	GetApproximateTotalURLs(ctx context.Context) (int64, error)
+	RecordVisit(ctx context.Context, shortID string, mobile bool) error
+	GetURLDetails(ctx context.Context, shortID string) (domain.URL, error)
+	BulkCreateShortURL(ctx context.Context, urls []string, linkDomain string) ([]BulkResult, error)
+	ListURLs(ctx context.Context, tenantID, cursor string, limit int64, includeDeleted bool) ([]domain.URL, string, error)
+	ExportURLs(ctx context.Context, fn func(domain.URL) error) error
+	ListSitemapURLs(ctx context.Context, limit int) ([]domain.URL, error)
+	DeleteShortURL(ctx context.Context, shortID string) error
+	UndeleteShortURL(ctx context.Context, shortID string) error
+	ListURLsByTag(ctx context.Context, tag string, limit, offset int64) ([]domain.URL, int64, error)
+	SearchURLs(ctx context.Context, query string, limit int64) ([]domain.URL, error)
+	UpdateTags(ctx context.Context, shortID string, tags []string) (domain.URL, error)
+	UpdateShortURL(ctx context.Context, shortID, newURL string) (domain.URL, error)
+	CreateAlias(ctx context.Context, primaryShortID, aliasCode string) (domain.URL, error)
+	ListAliases(ctx context.Context, primaryID string) ([]string, error)
+	GetTenantQuota(ctx context.Context, tenantID string) (domain.Tenant, error)
+	SetTenantQuota(ctx context.Context, tenantID string, quota int64) (domain.Tenant, error)
+	FetchURLMetadata(ctx context.Context, originalURL string) (domain.URLMetadata, error)
+	BlacklistDomain(ctx context.Context, host string) error
+	ListAuditEvents(ctx context.Context, shortID string) ([]domain.AuditEvent, error)
+	RecordClick(ctx context.Context, evt domain.ClickEvent) error
+	ListRefererCounts(ctx context.Context, shortID string, from, to time.Time) ([]domain.RefererCount, error)
+	ListCountryCounts(ctx context.Context, shortID string, from, to time.Time) ([]domain.CountryCount, error)
+	ListVariantStats(ctx context.Context, shortID string, from, to time.Time) ([]domain.VariantStats, error)
+	ClickTimeseries(ctx context.Context, shortID, granularity string, from, to time.Time) ([]domain.ClickBucket, error)
+	RecordConversion(ctx context.Context, shortID, sessionID string) (bool, error)
+	ConversionRate(ctx context.Context, shortID string) (domain.ConversionStats, error)
+	DomainStats(ctx context.Context) ([]domain.DomainStats, error)
+	TransferOwnership(ctx context.Context, shortID, newOwnerID string) error
+	SubscribeClicks(ctx context.Context, shortID string) (<-chan events.ClickEvent, func(), error)
 }
 
+// Supported hash algorithms for short ID generation.
+const (
+	HashAlgoSHA256 = "sha256"
+	HashAlgoMD5    = "md5" // Legacy algorithm, kept so existing deployments can keep generating the same IDs
+	// HashAlgoMigrated marks an MD5 entry that MigrateHashes has given an alias under its SHA-256 ID.
+	HashAlgoMigrated = "migrated"
+)
+
 // UrlService implements UrlServiceInterface.
 type UrlService struct {
-	urlStore store.UrlStoreInterface
+	urlStore            store.UrlStoreInterface
+	hashAlgo            string
+	shortIDLength       int
+	maxCollisionRetries int
+	bulkConcurrency     int
+	cache               Cache
+	auditStore          store.AuditStoreInterface
+	analyticsStore      store.AnalyticsStoreInterface
+	quotaStore          store.QuotaStoreInterface
+	hashAlgoStore       store.HashAlgoStoreInterface
+	domainStatsStore    store.DomainStatsStoreInterface
+	ownershipStore      store.OwnershipStoreInterface
+	geo                 *geoip.Resolver
+	blacklist           *urlutil.Blacklist
+	events              *events.Bus
+	clicks              *events.ClickBroadcaster
+	cdnPurger           cdn.Purger
+}
+
+// Option configures optional UrlService settings.
+type Option func(*UrlService)
+
+// WithHashAlgo sets the hash algorithm used to derive short IDs (HashAlgoSHA256 or HashAlgoMD5).
+func WithHashAlgo(algo string) Option {
+	return func(s *UrlService) {
+		s.hashAlgo = algo
+	}
+}
+
+// Bounds and default for the length of generated short IDs.
+const (
+	MinShortIDLength     = 4
+	MaxShortIDLength     = 32
+	DefaultShortIDLength = 8
+)
+
+// WithShortIDLength sets the number of characters in generated short IDs.
+// Values outside MinShortIDLength..MaxShortIDLength keep DefaultShortIDLength.
+// Changing the length for an existing database orphans previously shortened URLs,
+// because resubmitting them no longer produces their stored ID.
+func WithShortIDLength(n int) Option {
+	return func(s *UrlService) {
+		if n >= MinShortIDLength && n <= MaxShortIDLength {
+			s.shortIDLength = n
+		}
+	}
+}
+
+// DefaultMaxCollisionRetries is how many suffixed re-hashes CreateShortURL tries after a hash collision.
+const DefaultMaxCollisionRetries = 5
+
+// WithMaxCollisionRetries sets how many suffixed re-hashes CreateShortURL tries after a hash collision.
+// Zero disables retrying; negative values keep DefaultMaxCollisionRetries.
+func WithMaxCollisionRetries(n int) Option {
+	return func(s *UrlService) {
+		if n >= 0 {
+			s.maxCollisionRetries = n
+		}
+	}
+}
+
+// WithBulkConcurrency sets how many URLs BulkCreateShortURL shortens in parallel.
+// Values below 1 keep DefaultBulkConcurrency.
+func WithBulkConcurrency(n int) Option {
+	return func(s *UrlService) {
+		if n > 0 {
+			s.bulkConcurrency = n
+		}
+	}
+}
+
+// WithBlacklist sets the list of hosts that may not be shortened.
+func WithBlacklist(bl *urlutil.Blacklist) Option {
+	return func(s *UrlService) {
+		s.blacklist = bl
+	}
 }
 
 // NewUrlService creates a new UrlService.
-func NewUrlService(s store.UrlStoreInterface) *UrlService {
-	return &UrlService{urlStore: s}
+func NewUrlService(s store.UrlStoreInterface, opts ...Option) *UrlService {
+	svc := &UrlService{
+		urlStore:            s,
+		hashAlgo:            HashAlgoSHA256,
+		shortIDLength:       DefaultShortIDLength,
+		maxCollisionRetries: DefaultMaxCollisionRetries,
+		bulkConcurrency:     DefaultBulkConcurrency,
+	}
+	for _, opt := range opts {
+		opt(svc)
+	}
+	return svc
 }
 
-// generateShortID creates a short identifier from the original URL.
-// This implementation uses MD5 and takes the first 8 characters.
-func generateShortID(originalURL string) string {
-	hasher := md5.New()
-	hasher.Write([]byte(originalURL))
-	data := hasher.Sum(nil)
-	hash := hex.EncodeToString(data)
-	return hash[:8]
+// generateShortID creates a short identifier of the given length from the original URL.
+// With SHA-256 the first 8 bytes of the digest are read as a big-endian uint64 and Base62-encoded,
+// which packs about 6 bits into each character. The encoding is zero-padded on the left when it is
+// shorter than length and keeps its last length characters when it is longer; a uint64 needs at most
+// 11 Base62 digits, so lengths above 11 only add padding.
+// MD5 keeps the legacy scheme of taking the first length hex characters of the digest, so existing
+// deployments that pinned HASH_ALGO=md5 keep generating the same IDs.
+func generateShortID(originalURL string, hashAlgo string, length int) string {
+	if hashAlgo == HashAlgoMD5 {
+		sum := md5.Sum([]byte(originalURL))
+		return hex.EncodeToString(sum[:])[:length]
+	}
+
+	sum := sha256.Sum256([]byte(originalURL))
+	encoded := urlutil.Encode(binary.BigEndian.Uint64(sum[:8]))
+	if len(encoded) >= length {
+		return encoded[len(encoded)-length:]
+	}
+	return strings.Repeat("0", length-len(encoded)) + encoded
 }
 
 // CreateShortURL generates a short URL for the given original URL and saves it.
 // If the original URL has already been shortened, it returns the existing short URL.
-// It returns ErrHashCollision if a different original URL generates the same short ID.
-func (s *UrlService) CreateShortURL(ctx context.Context, originalURL string) (domain.URL, error) {
-	if originalURL == "" {
-		return domain.URL{}, fmt.Errorf("original URL cannot be empty")
+// If a different original URL already holds the generated short ID, it retries with suffixed hashes
+// and returns ErrHashCollision only when every retry collides.
+// When opts.CustomCode is set, that code is stored as-is and ErrCustomCodeTaken is returned if it is in use.
+// A bundle may leave originalURL empty, in which case its first link is used.
+// The entry belongs to the tenant named by the API key owner in ctx; tenants never share entries.
+// A new entry counts against the tenant's quota, and ErrQuotaExceeded is returned once it is used up.
+func (s *UrlService) CreateShortURL(ctx context.Context, originalURL string, opts CreateOptions) (domain.URL, error) {
+	ctx, span := tracer.Start(ctx, "service.CreateShortURL", trace.WithAttributes(attribute.String(tracing.AttrOriginalURL, originalURL)))
+	defer span.End()
+
+	if opts.Bundle && originalURL == "" {
+		if len(opts.Variants) == 0 {
+			return domain.URL{}, fmt.Errorf("%w: a bundle needs at least one link", ErrInvalidBundle)
+		}
+		originalURL = opts.Variants[0].URL
+	}
+	if err := s.validateDestination(originalURL); err != nil {
+		return domain.URL{}, err
+	}
+	if err := opts.validate(); err != nil {
+		return domain.URL{}, err
+	}
+	// The mobile destination is held to the same rules as the main one, blacklist included.
+	if opts.MobileURL != "" {
+		if err := s.validateDestination(opts.MobileURL); err != nil {
+			return domain.URL{}, err
+		}
+	}
+	normalizeLinks := s.normalizeVariants
+	if opts.Bundle {
+		normalizeLinks = s.normalizeBundleLinks
+	}
+	variants, err := normalizeLinks(opts.Variants)
+	if err != nil {
+		return domain.URL{}, err
 	}
 
-	shortID := generateShortID(originalURL)
+	// Equivalent spellings of a URL share one entry; the submitted form is kept for auditing.
+	submittedURL := originalURL
+	originalURL, err = urlutil.NormalizeURL(submittedURL)
+	if err != nil {
+		return domain.URL{}, fmt.Errorf("%w: %v", ErrInvalidURL, err)
+	}
 
-	urlToSave := domain.URL{
-		ID:           shortID,
-		OriginalUrl:  originalURL,
-		ShortUrl:     shortID,
-		CreationDate: time.Now().UTC(),
+	now := time.Now().UTC()
+	entry := opts.newEntry(originalURL, submittedURL, now)
+	entry.TenantID = middleware.OwnerFromContext(ctx)
+	entry.Variants = variants
+	if opts.Password != "" {
+		hash, err := bcrypt.GenerateFromPassword([]byte(opts.Password), PasswordHashCost)
+		if err != nil {
+			return domain.URL{}, fmt.Errorf("%w: %v", ErrInvalidPassword, err)
+		}
+		entry.PasswordHash = string(hash)
+	}
+	if opts.CustomCode != "" {
+		return s.createWithCustomCode(ctx, entry, opts.CustomCode)
+	}
+
+	// A resubmitted URL is answered from the existing entry, which avoids a failed insert.
+	// Expired entries are skipped so that the insert path below decides what to do with them.
+	// Links with a password, click limit, UTM parameters, tags, a webhook, a mobile destination, variants,
+	// an activation window or a bundle page are never shared, so they always get an entry of their own.
+	if shareable(entry) {
+		existingURL, err := s.urlStore.GetByOriginalURL(ctx, originalURL)
+		if err == nil && !existingURL.IsExpired(now) && reusable(existingURL, entry) {
+			slog.DebugContext(ctx, "Returning existing short URL", slog.String("short_id", existingURL.ID), slog.String("original_url", originalURL))
+			return existingURL, nil
+		}
+		if err != nil && !errors.Is(err, store.ErrURLNotFound) {
+			return domain.URL{}, fmt.Errorf("failed to look up existing URL: %w", err)
+		}
+	}
+
+	return s.hashWithRetry(ctx, entry, s.maxCollisionRetries)
+}
+
+// hashWithRetry saves entry under a short ID derived from the hash of its original URL, prefixed
+// with its tenant when it has one so that every tenant gets an ID of its own.
+// When the ID already belongs to a different URL, it re-hashes originalURL+"_1", then "_2" and so
+// on, up to maxRetries times, and returns ErrHashCollision only once every attempt has collided.
+// If an attempt finds an entry for the same original URL, that entry is returned.
+func (s *UrlService) hashWithRetry(ctx context.Context, entry domain.URL, maxRetries int) (domain.URL, error) {
+	originalURL := entry.OriginalUrl
+	hashBase := originalURL
+	if entry.TenantID != "" {
+		hashBase = entry.TenantID + "|" + originalURL
+	}
+	var lastExisting domain.URL
+	for attempt := 0; attempt <= maxRetries; attempt++ {
+		hashInput := hashBase
+		if attempt > 0 {
+			hashInput = fmt.Sprintf("%s_%d", hashBase, attempt)
+		}
+		shortID := generateShortID(hashInput, s.hashAlgo, s.shortIDLength)
+
+		urlToSave := entry
+		urlToSave.ID = shortID
+		urlToSave.ShortUrl = shortID
+		urlToSave.HashAlgo = s.hashAlgo
+
+		err := s.saveEntry(ctx, urlToSave)
+		if err == nil {
+			// Successfully saved a new entry
+			metrics.URLsShortened.Inc()
+			slog.DebugContext(ctx, "Created short URL", slog.String("short_id", shortID), slog.String("original_url", originalURL), slog.Int("attempt", attempt))
+			s.publish(ctx, events.Event{Type: events.URLCreated, ShortID: shortID, OriginalURL: originalURL})
+			return urlToSave, nil
+		}
+		if errors.Is(err, ErrQuotaExceeded) {
+			return domain.URL{}, err
+		}
+		if !errors.Is(err, store.ErrDuplicateShortID) {
+			// Some other error occurred during save
+			return domain.URL{}, fmt.Errorf("failed to save URL: %w", err)
+		}
+
+		// The shortID already exists, fetch the existing entry. A deleted entry keeps its ID
+		// so that the link answers 410 and can be restored; it counts as a collision.
+		existingURL, getErr := s.urlStore.GetByShortID(ctx, shortID, store.AnyTenant)
+		if errors.Is(getErr, store.ErrURLDeleted) {
+			slog.DebugContext(ctx, "Short ID held by a deleted URL", slog.String("short_id", shortID), slog.Int("attempt", attempt))
+			continue
+		}
+		if getErr != nil {
+			return domain.URL{}, fmt.Errorf("error retrieving existing URL for short ID '%s' after duplicate detection: %w", shortID, getErr)
+		}
+		if reusable(existingURL, entry) {
+			// The original URLs match, so this is the same URL being submitted again (e.g. concurrently)
+			slog.DebugContext(ctx, "Returning existing short URL", slog.String("short_id", shortID), slog.String("original_url", originalURL))
+			return existingURL, nil
+		}
+
+		// A different or unshareable URL holds this ID: try the next suffix
+		slog.WarnContext(ctx, "Hash collision", slog.String("short_id", shortID), slog.String("original_url", originalURL), slog.String("existing_url", existingURL.OriginalUrl), slog.Int("attempt", attempt))
+		lastExisting = existingURL
+	}
+	return domain.URL{}, fmt.Errorf("%w: no free short ID for '%s' after %d retries (last conflict with '%s')", ErrHashCollision, originalURL, maxRetries, lastExisting.OriginalUrl)
+}
+
+// reusable reports whether existing may be returned instead of saving entry: both point at the
+// same URL for the same tenant, existing has not been deleted, and both may be shared.
+func reusable(existing, entry domain.URL) bool {
+	return existing.OriginalUrl == entry.OriginalUrl && existing.TenantID == entry.TenantID && !existing.IsDeleted() &&
+		shareable(existing) && shareable(entry)
+}
+
+// shareable reports whether an entry may be handed to everyone who shortens its URL.
+// Entries with a password, click limit, UTM parameters, tags, a webhook, a mobile destination,
+// variants, an activation window, a bundle page or custom headers belong to whoever created them.
+func shareable(u domain.URL) bool {
+	return !u.IsPasswordProtected() && u.MaxClicks == nil && u.UTM == nil && len(u.Tags) == 0 &&
+		u.WebhookURL == "" && u.MobileURL == nil && len(u.Variants) == 0 && u.ActiveFrom == nil && u.ActiveUntil == nil &&
+		!u.Bundle && len(u.CustomHeaders) == 0
+}
+
+// validateDestination checks that rawURL may be used as a short link destination.
+// It returns ErrInvalidURL for malformed or internal URLs and ErrDomainBlacklisted for blacklisted hosts.
+func (s *UrlService) validateDestination(rawURL string) error {
+	if err := urlutil.ValidateURL(rawURL); err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidURL, err)
 	}
+	if s.blacklist != nil {
+		parsed, err := url.Parse(rawURL)
+		if err != nil {
+			return fmt.Errorf("%w: %v", ErrInvalidURL, err)
+		}
+		if s.blacklist.Contains(parsed.Hostname()) {
+			return fmt.Errorf("%w: %s", ErrDomainBlacklisted, parsed.Hostname())
+		}
+	}
+	return nil
+}
+
+// BulkCreateShortURL shortens every URL in urls, running at most bulkConcurrency creations at once.
+// Results are returned in the same order as urls. A failing entry records its error in its
+// BulkResult and does not stop the rest of the batch; the returned error is only set when
+// the batch itself is rejected (ErrTooManyURLs). Every link is recorded under linkDomain, as
+// CreateOptions.Domain.
+func (s *UrlService) BulkCreateShortURL(ctx context.Context, urls []string, linkDomain string) ([]BulkResult, error) {
+	ctx, span := tracer.Start(ctx, "service.BulkCreateShortURL", trace.WithAttributes(attribute.Int("url.count", len(urls))))
+	defer span.End()
 
-	err := s.urlStore.Save(ctx, urlToSave)
-	if err == nil {
-		// Successfully saved a new entry
-		return urlToSave, nil
+	if len(urls) == 0 || len(urls) > MaxBulkURLs {
+		return nil, fmt.Errorf("%w: got %d", ErrTooManyURLs, len(urls))
 	}
 
-	// Handle error from Save
-	if errors.Is(err, store.ErrDuplicateShortID) {
-		// The shortID already exists, fetch the existing entry
-		existingURL, getErr := s.urlStore.GetByShortID(ctx, shortID)
-		if getErr == nil {
-			// Successfully fetched the existing URL
-			if existingURL.OriginalUrl == originalURL {
-				// The original URLs match, so this is the same URL being submitted again
-				return existingURL, nil
-			}
-			// Original URLs do not match: this is a hash collision
-			return domain.URL{}, fmt.Errorf("%w: short ID '%s' generated for a different original URL (submitted: '%s', existing: '%s')", ErrHashCollision, shortID, originalURL, existingURL.OriginalUrl)
+	results := make([]BulkResult, len(urls))
+	sem := make(chan struct{}, s.bulkConcurrency)
+	var wg sync.WaitGroup
+
+	for i, originalURL := range urls {
+		wg.Add(1)
+		sem <- struct{}{}
+		go func(i int, originalURL string) {
+			defer wg.Done()
+			defer func() { <-sem }()
+
+			created, err := s.CreateShortURL(ctx, originalURL, CreateOptions{Domain: linkDomain})
+			results[i] = BulkResult{OriginalURL: originalURL, URL: created, Err: err}
+		}(i, originalURL)
+	}
+	wg.Wait()
+
+	return results, nil
+}
+
+// createWithCustomCode saves entry under a caller-supplied short code.
+func (s *UrlService) createWithCustomCode(ctx context.Context, entry domain.URL, customCode string) (domain.URL, error) {
+	if err := validateCustomCode(customCode); err != nil {
+		return domain.URL{}, err
+	}
+
+	urlToSave := entry
+	urlToSave.ID = customCode
+	urlToSave.ShortUrl = customCode
+
+	if err := s.saveEntry(ctx, urlToSave); err != nil {
+		if errors.Is(err, store.ErrDuplicateShortID) {
+			return domain.URL{}, fmt.Errorf("%w: '%s'", ErrCustomCodeTaken, customCode)
+		}
+		if errors.Is(err, ErrQuotaExceeded) {
+			return domain.URL{}, err
 		}
-		// Error fetching the existing URL after duplicate detection
-		return domain.URL{}, fmt.Errorf("error retrieving existing URL for short ID '%s' after duplicate detection: %w", shortID, getErr)
+		return domain.URL{}, fmt.Errorf("failed to save URL: %w", err)
 	}
+	metrics.URLsShortened.Inc()
+	slog.DebugContext(ctx, "Created short URL with custom code", slog.String("short_id", customCode), slog.String("original_url", entry.OriginalUrl))
+	s.publish(ctx, events.Event{Type: events.URLCreated, ShortID: customCode, OriginalURL: entry.OriginalUrl})
+	return urlToSave, nil
+}
 
-	// Some other error occurred during save
-	return domain.URL{}, fmt.Errorf("failed to save URL: %w", err)
+// validateCustomCode returns ErrInvalidCustomCode or ErrReservedCode if code cannot be used as a short ID.
+func validateCustomCode(code string) error {
+	if !customCodePattern.MatchString(code) {
+		return fmt.Errorf("%w: '%s' must be 3-50 characters of letters, digits, '_' or '-'", ErrInvalidCustomCode, code)
+	}
+	if urlutil.IsReservedCode(code) {
+		return fmt.Errorf("%w: '%s' is used by an application route", ErrReservedCode, code)
+	}
+	return nil
 }
 
 // GetOriginalURL retrieves the original URL for a given short ID.
+// It is ResolveShortURL for callers that only need the destination.
+// It returns ErrURLExpired if the link has an expiration time that has passed.
 func (s *UrlService) GetOriginalURL(ctx context.Context, shortID string) (string, error) {
-	if shortID == "" {
-		return "", fmt.Errorf("short ID cannot be empty")
-	}
-	url, err := s.urlStore.GetByShortID(ctx, shortID)
+	url, err := s.ResolveShortURL(ctx, shortID)
 	if err != nil {
 		return "", err
 	}
 	return url.OriginalUrl, nil
 }
+
+// ResolveShortURL retrieves the entry a short ID redirects to. It returns store.ErrURLNotFound
+// for unknown IDs, store.ErrURLDeleted for deleted ones, ErrURLNotYetActive for ones whose
+// activation window has not started and ErrURLExpired for expired ones, including ones whose
+// activation window has ended.
+func (s *UrlService) ResolveShortURL(ctx context.Context, shortID string) (domain.URL, error) {
+	ctx, span := tracer.Start(ctx, "service.ResolveShortURL", trace.WithAttributes(attribute.String(tracing.AttrShortID, shortID)))
+	defer span.End()
+
+	if shortID == "" {
+		return domain.URL{}, fmt.Errorf("short ID cannot be empty")
+	}
+	url, err := s.urlStore.GetByShortID(ctx, shortID, store.AnyTenant)
+	if err != nil {
+		return domain.URL{}, err
+	}
+	now := time.Now().UTC()
+	// MongoDB's TTL reaper runs periodically, so expired documents can still be found for a short while.
+	if url.IsExpired(now) {
+		return domain.URL{}, fmt.Errorf("%w: short ID '%s' expired at %s", ErrURLExpired, shortID, url.ExpiresAt.Format(time.RFC3339))
+	}
+	if url.ActiveFrom != nil && now.Before(*url.ActiveFrom) {
+		return domain.URL{}, fmt.Errorf("%w: short ID '%s' becomes active at %s", ErrURLNotYetActive, shortID, url.ActiveFrom.Format(time.RFC3339))
+	}
+	if url.ActiveUntil != nil && !now.Before(*url.ActiveUntil) {
+		return domain.URL{}, fmt.Errorf("%w: short ID '%s' was active until %s", ErrURLExpired, shortID, url.ActiveUntil.Format(time.RFC3339))
+	}
+	if remaining := url.ClicksRemaining(); remaining != nil && *remaining == 0 {
+		return domain.URL{}, fmt.Errorf("%w: short ID '%s' used up its %d clicks", ErrURLExpired, shortID, *url.MaxClicks)
+	}
+	return url, nil
+}
+
+// GetApproximateTotalURLs returns an approximate count of stored URLs.
+// The value comes from collection metadata and may lag by a few seconds after bulk operations,
+// which is fine for dashboards but should not be used where an exact total matters.
+func (s *UrlService) GetApproximateTotalURLs(ctx context.Context) (int64, error) {
+	ctx, span := tracer.Start(ctx, "service.GetApproximateTotalURLs")
+	defer span.End()
+
+	return s.urlStore.EstimatedCount(ctx)
+}
+
+// RecordVisit increments the click count and last access time for a short ID.
+// For a click-limited link that has used up its clicks it returns ErrURLExpired and records nothing.
+// Visits from mobile devices are also counted in MobileClicks.
+// Every recorded visit publishes a URLClicked event; the one that takes the count from 0 to 1 sends the
+// link's first-click webhook, if it has one.
+func (s *UrlService) RecordVisit(ctx context.Context, shortID string, mobile bool) error {
+	ctx, span := tracer.Start(ctx, "service.RecordVisit", trace.WithAttributes(attribute.String(tracing.AttrShortID, shortID)))
+	defer span.End()
+
+	if shortID == "" {
+		return fmt.Errorf("short ID cannot be empty")
+	}
+	clickCount, err := s.urlStore.IncrementClickCount(ctx, shortID, mobile)
+	if err != nil {
+		if errors.Is(err, store.ErrClickLimitReached) {
+			return fmt.Errorf("%w: %w", ErrURLExpired, err)
+		}
+		return err
+	}
+	s.publish(ctx, events.Event{Type: events.URLClicked, ShortID: shortID, ClickCount: clickCount})
+	return nil
+}
+
+// GetURLDetails retrieves the full stored entry, including click statistics, for a short ID.
+// Expired entries are returned as they are; deleted ones return store.ErrURLDeleted.
+func (s *UrlService) GetURLDetails(ctx context.Context, shortID string) (domain.URL, error) {
+	ctx, span := tracer.Start(ctx, "service.GetURLDetails", trace.WithAttributes(attribute.String(tracing.AttrShortID, shortID)))
+	defer span.End()
+
+	if shortID == "" {
+		return domain.URL{}, fmt.Errorf("short ID cannot be empty")
+	}
+	return s.urlStore.GetByShortID(ctx, shortID, store.AnyTenant)
+}
+
+// ListURLs returns one page of the URLs of tenantID (of every tenant for store.AnyTenant) in short ID
+// order and the opaque cursor of the next page, which is empty on the last page. An empty cursor starts
+// from the first page; an undecodable one returns store.ErrInvalidCursor. Deleted URLs are only listed
+// with includeDeleted. Callers are responsible for bounding limit.
+func (s *UrlService) ListURLs(ctx context.Context, tenantID, cursor string, limit int64, includeDeleted bool) ([]domain.URL, string, error) {
+	ctx, span := tracer.Start(ctx, "service.ListURLs", trace.WithAttributes(attribute.Int64("limit", limit)))
+	defer span.End()
+
+	if limit < 1 {
+		return nil, "", fmt.Errorf("limit must be positive (limit=%d)", limit)
+	}
+	return s.urlStore.List(ctx, tenantID, cursor, limit, includeDeleted)
+}
+
+// ExportURLs calls fn for every stored URL, deleted ones included, in short ID order.
+// Entries are streamed from the store, so exports of any size use constant memory.
+// It stops at the first error fn returns and returns that error.
+func (s *UrlService) ExportURLs(ctx context.Context, fn func(domain.URL) error) error {
+	ctx, span := tracer.Start(ctx, "service.ExportURLs")
+	defer span.End()
+
+	return s.urlStore.ForEach(ctx, fn)
+}
+
+// SearchURLs returns up to limit undeleted URLs whose original URL contains query, ignoring case,
+// in short ID order. The query is matched literally, never as a pattern.
+// Callers are responsible for bounding limit.
+func (s *UrlService) SearchURLs(ctx context.Context, query string, limit int64) ([]domain.URL, error) {
+	ctx, span := tracer.Start(ctx, "service.SearchURLs", trace.WithAttributes(attribute.Int64("limit", limit)))
+	defer span.End()
+
+	if query == "" {
+		return nil, fmt.Errorf("search query cannot be empty")
+	}
+	if limit < 1 {
+		return nil, fmt.Errorf("limit must be positive (limit=%d)", limit)
+	}
+	return s.urlStore.Search(ctx, query, limit)
+}
+
+// DeleteShortURL removes a short URL so that it no longer redirects. Unless the binary is built
+// with the harddelete tag, the entry is kept and answers store.ErrURLDeleted until it is undeleted.
+// It returns store.ErrURLNotFound if the short ID does not exist.
+func (s *UrlService) DeleteShortURL(ctx context.Context, shortID string) error {
+	ctx, span := tracer.Start(ctx, "service.DeleteShortURL", trace.WithAttributes(attribute.String(tracing.AttrShortID, shortID)))
+	defer span.End()
+
+	if shortID == "" {
+		return fmt.Errorf("short ID cannot be empty")
+	}
+	if err := s.urlStore.Delete(ctx, shortID); err != nil {
+		return err
+	}
+	s.purgeCDN(ctx, shortID)
+	s.publish(ctx, events.Event{Type: events.URLDeleted, ShortID: shortID})
+	return nil
+}
+
+// UndeleteShortURL restores a deleted short URL so that it redirects again.
+// It returns store.ErrURLNotFound if no deleted entry has the short ID.
+func (s *UrlService) UndeleteShortURL(ctx context.Context, shortID string) error {
+	ctx, span := tracer.Start(ctx, "service.UndeleteShortURL", trace.WithAttributes(attribute.String(tracing.AttrShortID, shortID)))
+	defer span.End()
+
+	if shortID == "" {
+		return fmt.Errorf("short ID cannot be empty")
+	}
+	return s.urlStore.Undelete(ctx, shortID)
+}
+
+// UpdateShortURL points an existing short URL at a new destination and returns the updated entry.
+// The new URL goes through the same validation and normalization as CreateShortURL. It returns store.ErrURLNotFound
+// if the short ID does not exist. The short ID is kept even if it was derived from the old URL's hash.
+func (s *UrlService) UpdateShortURL(ctx context.Context, shortID, newURL string) (domain.URL, error) {
+	ctx, span := tracer.Start(ctx, "service.UpdateShortURL", trace.WithAttributes(attribute.String(tracing.AttrShortID, shortID), attribute.String(tracing.AttrOriginalURL, newURL)))
+	defer span.End()
+
+	if shortID == "" {
+		return domain.URL{}, fmt.Errorf("short ID cannot be empty")
+	}
+	if err := s.validateDestination(newURL); err != nil {
+		return domain.URL{}, err
+	}
+	newURL, err := urlutil.NormalizeURL(newURL)
+	if err != nil {
+		return domain.URL{}, fmt.Errorf("%w: %v", ErrInvalidURL, err)
+	}
+	if err := s.urlStore.Update(ctx, shortID, newURL); err != nil {
+		return domain.URL{}, err
+	}
+	s.purgeCDN(ctx, shortID)
+	return s.urlStore.GetByShortID(ctx, shortID, store.AnyTenant)
+}
+
+// BlacklistDomain adds host to the blacklist and persists the list.
+// Existing short links to the host keep working; only new links are refused.
+func (s *UrlService) BlacklistDomain(ctx context.Context, host string) error {
+	if s.blacklist == nil {
+		return fmt.Errorf("blacklisting is not enabled")
+	}
+	s.blacklist.Add(host)
+	if err := s.blacklist.Save(); err != nil {
+		return fmt.Errorf("failed to persist blacklist: %w", err)
+	}
+	slog.InfoContext(ctx, "Blacklisted domain", slog.String("host", host))
+	return nil
+}
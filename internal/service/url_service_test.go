@@ -0,0 +1,317 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"shawty/internal/domain"
+	"shawty/internal/middleware"
+	"shawty/internal/store"
+)
+
+func TestCreateShortURL(t *testing.T) {
+	ctx := context.Background()
+	svc := NewUrlService(store.NewMemoryUrlStore())
+
+	created, err := svc.CreateShortURL(ctx, "https://example.com/page", CreateOptions{})
+	if err != nil {
+		t.Fatalf("CreateShortURL: %v", err)
+	}
+	if len(created.ID) != DefaultShortIDLength || created.ShortUrl != created.ID {
+		t.Errorf("short ID = %q, short URL = %q; want one ID of %d characters", created.ID, created.ShortUrl, DefaultShortIDLength)
+	}
+	if created.OriginalUrl != "https://example.com/page" {
+		t.Errorf("original URL = %q", created.OriginalUrl)
+	}
+
+	got, err := svc.GetOriginalURL(ctx, created.ID)
+	if err != nil {
+		t.Fatalf("GetOriginalURL: %v", err)
+	}
+	if got != "https://example.com/page" {
+		t.Errorf("GetOriginalURL = %q, want https://example.com/page", got)
+	}
+}
+
+func TestCreateShortURLReturnsExistingEntry(t *testing.T) {
+	ctx := context.Background()
+	svc := NewUrlService(store.NewMemoryUrlStore())
+
+	first, err := svc.CreateShortURL(ctx, "https://example.com/page", CreateOptions{})
+	if err != nil {
+		t.Fatalf("first CreateShortURL: %v", err)
+	}
+	second, err := svc.CreateShortURL(ctx, "https://example.com/page", CreateOptions{})
+	if err != nil {
+		t.Fatalf("second CreateShortURL: %v", err)
+	}
+	if second.ID != first.ID || !second.CreationDate.Equal(first.CreationDate) {
+		t.Errorf("resubmission created %q at %v, want the existing %q at %v", second.ID, second.CreationDate, first.ID, first.CreationDate)
+	}
+	if count, _ := svc.GetApproximateTotalURLs(ctx); count != 1 {
+		t.Errorf("stored %d entries, want 1", count)
+	}
+}
+
+func TestCreateShortURLHashCollision(t *testing.T) {
+	ctx := context.Background()
+	const originalURL = "https://example.com/page"
+	const maxRetries = 2
+
+	// Every ID the URL could get is already held by a different URL.
+	memStore := store.NewMemoryUrlStore()
+	for attempt := 0; attempt <= maxRetries; attempt++ {
+		hashInput := originalURL
+		if attempt > 0 {
+			hashInput = fmt.Sprintf("%s_%d", originalURL, attempt)
+		}
+		id := generateShortID(hashInput, HashAlgoSHA256, DefaultShortIDLength)
+		other := domain.URL{ID: id, ShortUrl: id, OriginalUrl: fmt.Sprintf("https://other.example/%d", attempt), CreationDate: time.Now().UTC()}
+		if err := memStore.Save(ctx, other); err != nil {
+			t.Fatalf("Save: %v", err)
+		}
+	}
+
+	svc := NewUrlService(memStore, WithMaxCollisionRetries(maxRetries))
+	if _, err := svc.CreateShortURL(ctx, originalURL, CreateOptions{}); !errors.Is(err, ErrHashCollision) {
+		t.Fatalf("CreateShortURL error = %v, want ErrHashCollision", err)
+	}
+
+	// One more retry reaches a free ID.
+	svc = NewUrlService(memStore, WithMaxCollisionRetries(maxRetries+1))
+	created, err := svc.CreateShortURL(ctx, originalURL, CreateOptions{})
+	if err != nil {
+		t.Fatalf("CreateShortURL with a free ID: %v", err)
+	}
+	if want := generateShortID(fmt.Sprintf("%s_%d", originalURL, maxRetries+1), HashAlgoSHA256, DefaultShortIDLength); created.ID != want {
+		t.Errorf("short ID = %q, want %q", created.ID, want)
+	}
+}
+
+func TestGenerateShortIDIsDeterministic(t *testing.T) {
+	// The expected IDs were computed independently of this package; a change to any of them
+	// orphans the links already stored under it.
+	tests := []struct {
+		hashInput string
+		hashAlgo  string
+		want      string
+	}{
+		{hashInput: "https://example.com/page", hashAlgo: HashAlgoSHA256, want: "7hQeKZIB"},
+		{hashInput: "https://example.com/page_1", hashAlgo: HashAlgoSHA256, want: "uyfqQBBR"},
+		{hashInput: "acme|https://example.com/page", hashAlgo: HashAlgoSHA256, want: "4xLZ2rQG"},
+		{hashInput: "https://example.com/page", hashAlgo: HashAlgoMD5, want: "fb37c0eb"},
+		{hashInput: "https://example.com/page_1", hashAlgo: HashAlgoMD5, want: "4968933d"},
+		{hashInput: "acme|https://example.com/page", hashAlgo: HashAlgoMD5, want: "7c27f57c"},
+	}
+	for _, tt := range tests {
+		for range 2 {
+			if got := generateShortID(tt.hashInput, tt.hashAlgo, DefaultShortIDLength); got != tt.want {
+				t.Errorf("generateShortID(%q, %s) = %q, want %q", tt.hashInput, tt.hashAlgo, got, tt.want)
+			}
+		}
+	}
+
+	// A retry attempt ("_1") and a tenant prefix each change the ID.
+	if plain, retry := generateShortID("https://example.com/page", HashAlgoSHA256, 8), generateShortID("https://example.com/page_1", HashAlgoSHA256, 8); plain == retry {
+		t.Errorf("the first retry repeats the ID %q", plain)
+	}
+
+	// The service derives the same ID for the same URL on any store.
+	ctx := context.Background()
+	svc := NewUrlService(store.NewMemoryUrlStore())
+	first, err := svc.CreateShortURL(ctx, "https://example.com/page", CreateOptions{})
+	if err != nil {
+		t.Fatalf("CreateShortURL: %v", err)
+	}
+	again, err := NewUrlService(store.NewMemoryUrlStore()).CreateShortURL(ctx, "https://example.com/page", CreateOptions{})
+	if err != nil {
+		t.Fatalf("CreateShortURL on a second store: %v", err)
+	}
+	if first.ID != "7hQeKZIB" || again.ID != first.ID {
+		t.Errorf("the same URL got IDs %q and %q, want 7hQeKZIB on both stores", first.ID, again.ID)
+	}
+}
+
+func TestShortIDLength(t *testing.T) {
+	tests := []struct {
+		name       string
+		configured int
+		want       int
+	}{
+		{name: "minimum", configured: MinShortIDLength, want: MinShortIDLength},
+		{name: "default", configured: DefaultShortIDLength, want: DefaultShortIDLength},
+		{name: "custom", configured: 12, want: 12},
+		{name: "maximum", configured: MaxShortIDLength, want: MaxShortIDLength},
+		{name: "below the minimum", configured: MinShortIDLength - 1, want: DefaultShortIDLength},
+		{name: "above the maximum", configured: MaxShortIDLength + 1, want: DefaultShortIDLength},
+		{name: "zero", configured: 0, want: DefaultShortIDLength},
+		{name: "negative", configured: -8, want: DefaultShortIDLength},
+	}
+	for _, algo := range []string{HashAlgoSHA256, HashAlgoMD5} {
+		for _, tt := range tests {
+			t.Run(algo+"/"+tt.name, func(t *testing.T) {
+				ctx := context.Background()
+				svc := NewUrlService(store.NewMemoryUrlStore(), WithHashAlgo(algo), WithShortIDLength(tt.configured))
+
+				created, err := svc.CreateShortURL(ctx, "https://example.com/length", CreateOptions{})
+				if err != nil {
+					t.Fatalf("CreateShortURL: %v", err)
+				}
+				if len(created.ID) != tt.want {
+					t.Errorf("short ID %q has %d characters, want %d", created.ID, len(created.ID), tt.want)
+				}
+				if want := generateShortID("https://example.com/length", algo, tt.want); created.ID != want {
+					t.Errorf("short ID = %q, want %q", created.ID, want)
+				}
+			})
+		}
+	}
+}
+
+func TestUpdateShortURLNormalizesDestination(t *testing.T) {
+	ctx := context.Background()
+	svc := NewUrlService(store.NewMemoryUrlStore())
+	created, err := svc.CreateShortURL(ctx, "https://example.com/old", CreateOptions{})
+	if err != nil {
+		t.Fatalf("CreateShortURL: %v", err)
+	}
+
+	updated, err := svc.UpdateShortURL(ctx, created.ID, "HTTPS://Example.COM:443/New/?utm_source=mail&b=2&a=1")
+	if err != nil {
+		t.Fatalf("UpdateShortURL: %v", err)
+	}
+	const want = "https://example.com/New?a=1&b=2"
+	if updated.OriginalUrl != want {
+		t.Errorf("updated original URL = %q, want the normalized %q", updated.OriginalUrl, want)
+	}
+	// The normalized form is what creation would have stored, so the link is found again by it.
+	again, err := svc.CreateShortURL(ctx, "https://example.com/New?b=2&a=1", CreateOptions{})
+	if err != nil {
+		t.Fatalf("CreateShortURL of an equivalent URL: %v", err)
+	}
+	if again.ID != created.ID {
+		t.Errorf("shortening an equivalent URL created %q, want the updated %q", again.ID, created.ID)
+	}
+
+	if _, err := svc.UpdateShortURL(ctx, created.ID, "ftp://example.com/file"); !errors.Is(err, ErrInvalidURL) {
+		t.Errorf("UpdateShortURL to an invalid URL error = %v, want ErrInvalidURL", err)
+	}
+}
+
+func TestResolveShortURLRejectsExpired(t *testing.T) {
+	ctx := context.Background()
+	memStore := store.NewMemoryUrlStore()
+	expiredAt := time.Now().UTC().Add(-time.Minute)
+	expired := domain.URL{ID: "expired", ShortUrl: "expired", OriginalUrl: "https://example.com", CreationDate: expiredAt.Add(-time.Hour), ExpiresAt: &expiredAt}
+	if err := memStore.Save(ctx, expired); err != nil {
+		t.Fatalf("Save: %v", err)
+	}
+	svc := NewUrlService(memStore)
+
+	if _, err := svc.ResolveShortURL(ctx, "expired"); !errors.Is(err, ErrURLExpired) {
+		t.Errorf("ResolveShortURL error = %v, want ErrURLExpired", err)
+	}
+	// The details stay readable, so that clients can tell why the link stopped working.
+	details, err := svc.GetURLDetails(ctx, "expired")
+	if err != nil {
+		t.Fatalf("GetURLDetails: %v", err)
+	}
+	if !details.IsExpired(time.Now()) {
+		t.Error("GetURLDetails returned an entry that is not expired")
+	}
+}
+
+func TestCreateShortURLWithExpiry(t *testing.T) {
+	ctx := context.Background()
+	svc := NewUrlService(store.NewMemoryUrlStore())
+
+	created, err := svc.CreateShortURL(ctx, "https://example.com/soon", CreateOptions{ExpiresIn: time.Hour})
+	if err != nil {
+		t.Fatalf("CreateShortURL: %v", err)
+	}
+	if created.ExpiresAt == nil || created.ExpiresAt.Sub(created.CreationDate) != time.Hour {
+		t.Fatalf("ExpiresAt = %v, want an hour after %v", created.ExpiresAt, created.CreationDate)
+	}
+	if _, err := svc.ResolveShortURL(ctx, created.ID); err != nil {
+		t.Errorf("ResolveShortURL before expiry: %v", err)
+	}
+}
+
+func TestResolveShortURLNotFound(t *testing.T) {
+	svc := NewUrlService(store.NewMemoryUrlStore())
+	if _, err := svc.ResolveShortURL(context.Background(), "missing"); !errors.Is(err, store.ErrURLNotFound) {
+		t.Errorf("ResolveShortURL error = %v, want store.ErrURLNotFound", err)
+	}
+}
+
+func TestTenantsGetSeparateShortIDs(t *testing.T) {
+	memStore := store.NewMemoryUrlStore()
+	svc := NewUrlService(memStore)
+	const originalURL = "https://example.com/shared"
+	ctxA := context.WithValue(context.Background(), middleware.OwnerKey, "tenant-a")
+	ctxB := context.WithValue(context.Background(), middleware.OwnerKey, "tenant-b")
+
+	createdA, err := svc.CreateShortURL(ctxA, originalURL, CreateOptions{})
+	if err != nil {
+		t.Fatalf("CreateShortURL for tenant-a: %v", err)
+	}
+	createdB, err := svc.CreateShortURL(ctxB, originalURL, CreateOptions{})
+	if err != nil {
+		t.Fatalf("CreateShortURL for tenant-b: %v", err)
+	}
+	if createdA.ID == createdB.ID {
+		t.Fatalf("both tenants got short ID %q, want separate IDs", createdA.ID)
+	}
+	if createdA.TenantID != "tenant-a" || createdB.TenantID != "tenant-b" {
+		t.Errorf("tenant IDs = %q and %q, want tenant-a and tenant-b", createdA.TenantID, createdB.TenantID)
+	}
+	if again, err := svc.CreateShortURL(ctxA, originalURL, CreateOptions{}); err != nil || again.ID != createdA.ID {
+		t.Errorf("resubmission by tenant-a = %q, %v; want the existing %q", again.ID, err, createdA.ID)
+	}
+
+	// Each tenant sees only its own link; the unfiltered lookup behind redirects sees both.
+	if _, err := memStore.GetByShortID(ctxA, createdB.ID, "tenant-a"); !errors.Is(err, store.ErrURLNotFound) {
+		t.Errorf("tenant-a looking up tenant-b's link: error = %v, want ErrURLNotFound", err)
+	}
+	if got, err := memStore.GetByShortID(ctxA, createdA.ID, "tenant-a"); err != nil || got.ID != createdA.ID {
+		t.Errorf("tenant-a looking up its own link = %q, %v", got.ID, err)
+	}
+	for _, id := range []string{createdA.ID, createdB.ID} {
+		if got, err := svc.GetOriginalURL(context.Background(), id); err != nil || got != originalURL {
+			t.Errorf("GetOriginalURL(%q) = %q, %v; want %s", id, got, err, originalURL)
+		}
+	}
+	listed, _, err := svc.ListURLs(ctxA, "tenant-a", "", 10, false)
+	if err != nil {
+		t.Fatalf("ListURLs: %v", err)
+	}
+	if len(listed) != 1 || listed[0].ID != createdA.ID {
+		t.Errorf("tenant-a lists %v, want only %q", listed, createdA.ID)
+	}
+	if all, _, _ := svc.ListURLs(ctxA, store.AnyTenant, "", 10, false); len(all) != 2 {
+		t.Errorf("listing every tenant returned %d links, want 2", len(all))
+	}
+}
+
+func TestListSitemapURLsStopsAtLimit(t *testing.T) {
+	ctx := context.Background()
+	memStore := store.NewMemoryUrlStore()
+	for i := range 5 {
+		id := fmt.Sprintf("id%04d", i)
+		if err := memStore.Save(ctx, domain.URL{ID: id, ShortUrl: id, OriginalUrl: fmt.Sprintf("https://example.com/%d", i), CreationDate: time.Now().UTC()}); err != nil {
+			t.Fatalf("Save: %v", err)
+		}
+	}
+	svc := NewUrlService(memStore)
+
+	urls, err := svc.ListSitemapURLs(ctx, 3)
+	if err != nil {
+		t.Fatalf("ListSitemapURLs: %v", err)
+	}
+	if len(urls) != 3 {
+		t.Errorf("ListSitemapURLs returned %d links, want 3", len(urls))
+	}
+}
@@ -0,0 +1,125 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"time"
+
+	"shawty/internal/domain"
+	"shawty/internal/store"
+	"shawty/internal/tracing"
+	"shawty/internal/urlutil"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// MaxVariants is the largest number of A/B test variants a short URL may have.
+const MaxVariants = 10
+
+// ErrInvalidVariants is returned when the A/B test variants of a new short URL fail validation.
+var ErrInvalidVariants = errors.New("invalid variants")
+
+// variantNamePattern restricts variant names to short URL-safe labels such as "blue-button".
+var variantNamePattern = regexp.MustCompile(`^[a-zA-Z0-9_-]{1,50}$`)
+
+// normalizeVariants validates the variants of a new short URL and returns them with normalized
+// destinations, naming unnamed ones "variant-1", "variant-2" and so on after their position.
+// Weights are percentages of the traffic; whatever they leave below domain.VariantWeightTotal
+// goes to the link's original URL. It returns nil for an empty list.
+func (s *UrlService) normalizeVariants(variants []domain.Variant) ([]domain.Variant, error) {
+	if len(variants) == 0 {
+		return nil, nil
+	}
+	if len(variants) > MaxVariants {
+		return nil, fmt.Errorf("%w: got %d variants, at most %d are allowed", ErrInvalidVariants, len(variants), MaxVariants)
+	}
+
+	normalized := make([]domain.Variant, 0, len(variants))
+	names := make(map[string]bool, len(variants))
+	total := 0
+	for i, v := range variants {
+		if v.Name == "" {
+			v.Name = fmt.Sprintf("variant-%d", i+1)
+		}
+		if !variantNamePattern.MatchString(v.Name) || v.Name == domain.VariantOriginal {
+			return nil, fmt.Errorf("%w: name '%s' must be 1-50 letters, digits, '_' or '-' and not '%s'", ErrInvalidVariants, v.Name, domain.VariantOriginal)
+		}
+		if names[v.Name] {
+			return nil, fmt.Errorf("%w: name '%s' is used twice", ErrInvalidVariants, v.Name)
+		}
+		names[v.Name] = true
+
+		if v.Weight < 1 || v.Weight > domain.VariantWeightTotal {
+			return nil, fmt.Errorf("%w: weight of '%s' must be between 1 and %d, got %d", ErrInvalidVariants, v.Name, domain.VariantWeightTotal, v.Weight)
+		}
+		total += v.Weight
+
+		// Variant destinations are held to the same rules as the main one, blacklist included.
+		if err := s.validateDestination(v.URL); err != nil {
+			return nil, fmt.Errorf("variant '%s': %w", v.Name, err)
+		}
+		url, err := urlutil.NormalizeURL(v.URL)
+		if err != nil {
+			return nil, fmt.Errorf("variant '%s': %w: %v", v.Name, ErrInvalidURL, err)
+		}
+		v.URL = url
+		normalized = append(normalized, v)
+	}
+	if total > domain.VariantWeightTotal {
+		return nil, fmt.Errorf("%w: weights add up to %d, at most %d is allowed", ErrInvalidVariants, total, domain.VariantWeightTotal)
+	}
+	return normalized, nil
+}
+
+// ListVariantStats returns how the clicks the A/B-tested link shortID received in [from, to) were
+// split across its destinations: one entry per variant in the link's order, followed by one for
+// domain.VariantOriginal if the variants leave part of the traffic to the original URL. The list is
+// empty for links without variants and for bundles, whose links are not picked by weight.
+func (s *UrlService) ListVariantStats(ctx context.Context, shortID string, from, to time.Time) ([]domain.VariantStats, error) {
+	ctx, span := tracer.Start(ctx, "service.ListVariantStats", trace.WithAttributes(attribute.String(tracing.AttrShortID, shortID)))
+	defer span.End()
+
+	if err := s.checkAnalyticsQuery(shortID, from, to); err != nil {
+		return nil, err
+	}
+	urlEntry, err := s.urlStore.GetByShortID(ctx, shortID, store.AnyTenant)
+	if err != nil {
+		return nil, err
+	}
+	if len(urlEntry.Variants) == 0 || urlEntry.Bundle {
+		return []domain.VariantStats{}, nil
+	}
+
+	counts, err := s.analyticsStore.CountByVariant(ctx, urlEntry.ID, from, to)
+	if err != nil {
+		return nil, err
+	}
+	clicks := make(map[string]int64, len(counts))
+	for _, c := range counts {
+		clicks[c.Variant] = c.Count
+	}
+
+	stats := make([]domain.VariantStats, 0, len(urlEntry.Variants)+1)
+	remaining := domain.VariantWeightTotal
+	for _, v := range urlEntry.Variants {
+		stats = append(stats, domain.VariantStats{Name: v.Name, URL: v.URL, Weight: v.Weight, Clicks: clicks[v.Name]})
+		remaining -= v.Weight
+	}
+	if remaining > 0 {
+		stats = append(stats, domain.VariantStats{Name: domain.VariantOriginal, URL: urlEntry.OriginalUrl, Weight: remaining, Clicks: clicks[domain.VariantOriginal]})
+	}
+
+	var total int64
+	for _, st := range stats {
+		total += st.Clicks
+	}
+	if total > 0 {
+		for i := range stats {
+			stats[i].ClickShare = float64(stats[i].Clicks) / float64(total)
+		}
+	}
+	return stats, nil
+}
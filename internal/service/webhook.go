@@ -0,0 +1,39 @@
+package service
+
+import (
+	"log/slog"
+
+	"shawty/internal/events"
+	"shawty/internal/store"
+	"shawty/internal/webhook"
+)
+
+// notifyFirstClick handles URLClicked events: for the click that took the count to 1 it sends the
+// link's first-click webhook, if the link has one. Failures are only logged.
+func (s *UrlService) notifyFirstClick(e events.Event) {
+	if e.ClickCount != 1 {
+		return
+	}
+	ctx, shortID := e.Context, e.ShortID
+	urlEntry, err := s.urlStore.GetByShortID(ctx, shortID, store.AnyTenant)
+	if err != nil {
+		slog.WarnContext(ctx, "Error looking up URL for first-click webhook", slog.String("short_id", shortID), slog.Any("error", err))
+		return
+	}
+	if urlEntry.WebhookURL == "" {
+		return
+	}
+
+	event := webhook.FirstClickEvent{
+		Event:       webhook.EventFirstClick,
+		ShortID:     shortID,
+		OriginalURL: urlEntry.OriginalUrl,
+		ClickedAt:   e.At,
+		IP:          e.IP,
+	}
+	if err := webhook.Dispatch(ctx, urlEntry.WebhookURL, event); err != nil {
+		slog.ErrorContext(ctx, "Error sending first-click webhook", slog.String("short_id", shortID), slog.Any("error", err))
+		return
+	}
+	slog.DebugContext(ctx, "Sent first-click webhook", slog.String("short_id", shortID))
+}
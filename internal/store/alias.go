@@ -0,0 +1,31 @@
+package store
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"shawty/internal/domain"
+)
+
+// followAlias looks up shortID with get and, if the entry is an alias, returns its primary entry
+// instead. Aliases are followed one hop only: an alias of an alias is reported as not found, and
+// so is an alias whose primary is gone. An alias of a deleted primary reports ErrURLDeleted.
+func followAlias(ctx context.Context, shortID, tenantID string, get func(ctx context.Context, shortID, tenantID string) (domain.URL, error)) (domain.URL, error) {
+	urlEntry, err := get(ctx, shortID, tenantID)
+	if err != nil || urlEntry.AliasOf == nil {
+		return urlEntry, err
+	}
+
+	primary, err := get(ctx, *urlEntry.AliasOf, tenantID)
+	if err != nil {
+		if errors.Is(err, ErrURLDeleted) {
+			return domain.URL{}, fmt.Errorf("%w: '%s'", ErrURLDeleted, shortID)
+		}
+		return domain.URL{}, fmt.Errorf("alias '%s' of '%s' could not be resolved: %w", shortID, *urlEntry.AliasOf, err)
+	}
+	if primary.AliasOf != nil {
+		return domain.URL{}, errNotFound(shortID)
+	}
+	return primary, nil
+}
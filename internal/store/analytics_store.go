@@ -0,0 +1,255 @@
+package store
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"shawty/internal/domain"
+	"shawty/internal/geoip"
+	"shawty/internal/tracing"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// ClicksCollectionName is the MongoDB collection click events are written to.
+const ClicksCollectionName = "clicks"
+
+// AnalyticsStoreInterface is implemented by stores that keep a log of individual clicks.
+type AnalyticsStoreInterface interface {
+	// RecordClick stores one click event.
+	RecordClick(ctx context.Context, evt domain.ClickEvent) error
+	// MarkConverted sets ConvertedAt to at on the latest click of shortID with sessionID that has not
+	// been converted yet, and reports whether there was such a click.
+	MarkConverted(ctx context.Context, shortID, sessionID string, at time.Time) (bool, error)
+	// CountByReferer returns the number of clicks shortID received in [from, to) per referer domain,
+	// most clicks first.
+	CountByReferer(ctx context.Context, shortID string, from, to time.Time) ([]domain.RefererCount, error)
+	// CountByCountry returns the number of clicks shortID received in [from, to) per country code,
+	// most clicks first.
+	CountByCountry(ctx context.Context, shortID string, from, to time.Time) ([]domain.CountryCount, error)
+	// CountByVariant returns the number of clicks shortID received in [from, to) per A/B test variant.
+	// Clicks recorded without a variant are not counted.
+	CountByVariant(ctx context.Context, shortID string, from, to time.Time) ([]domain.VariantCount, error)
+	// ClickTimeseries returns the number of clicks shortID received in [from, to) per period of
+	// granularity (one of the domain.Granularity constants), oldest first. Periods without clicks are omitted.
+	ClickTimeseries(ctx context.Context, shortID, granularity string, from, to time.Time) ([]domain.ClickBucket, error)
+}
+
+// bucketFormats are the $dateToString formats of the ClickBucket dates of each granularity.
+var bucketFormats = map[string]string{
+	domain.GranularityHour:  "%Y-%m-%dT%H:00:00Z",
+	domain.GranularityDay:   "%Y-%m-%d",
+	domain.GranularityWeek:  "%Y-%m-%d",
+	domain.GranularityMonth: "%Y-%m",
+}
+
+// clickIndexModels returns the indexes of the clicks collection.
+// Every aggregation selects one short ID and a range of clicked_at.
+func clickIndexModels() []mongo.IndexModel {
+	return []mongo.IndexModel{
+		{Keys: bson.D{{Key: "short_id", Value: 1}, {Key: "clicked_at", Value: 1}}},
+	}
+}
+
+// clickSessionIndexModels returns the index MarkConverted finds a session's clicks with. Only
+// clicks that carry a session ID are indexed.
+func clickSessionIndexModels() []mongo.IndexModel {
+	return []mongo.IndexModel{{
+		Keys:    bson.D{{Key: "short_id", Value: 1}, {Key: "session_id", Value: 1}},
+		Options: options.Index().SetPartialFilterExpression(bson.M{"session_id": bson.M{"$exists": true}}),
+	}}
+}
+
+// ensureAnalyticsIndexes creates the indexes the click aggregations and conversions rely on.
+func (s *MongoUrlStore) ensureAnalyticsIndexes(ctx context.Context) error {
+	if err := createIndexes(ctx, s.clicksCollection, clickIndexModels()); err != nil {
+		return err
+	}
+	return createIndexes(ctx, s.clicksCollection, clickSessionIndexModels())
+}
+
+// RecordClick inserts a click event into the clicks collection.
+func (s *MongoUrlStore) RecordClick(ctx context.Context, evt domain.ClickEvent) error {
+	ctx, span := tracer.Start(ctx, "store.RecordClick", trace.WithAttributes(attribute.String(tracing.AttrShortID, evt.ShortID)))
+	defer span.End()
+
+	if _, err := s.clicksCollection.InsertOne(ctx, evt); err != nil {
+		return fmt.Errorf("failed to record click in MongoDB: %w", err)
+	}
+	return nil
+}
+
+// MarkConverted sets converted_at on the session's latest unconverted click with FindOneAndUpdate,
+// so that concurrent conversions of one click cannot both match it.
+func (s *MongoUrlStore) MarkConverted(ctx context.Context, shortID, sessionID string, at time.Time) (bool, error) {
+	ctx, span := tracer.Start(ctx, "store.MarkConverted", trace.WithAttributes(attribute.String(tracing.AttrShortID, shortID)))
+	defer span.End()
+
+	filter := bson.M{"short_id": shortID, "session_id": sessionID, "converted_at": bson.M{"$exists": false}}
+	update := bson.M{"$set": bson.M{"converted_at": at}}
+	opts := options.FindOneAndUpdate().
+		SetSort(bson.D{{Key: "clicked_at", Value: -1}}).
+		SetProjection(bson.M{"_id": 1})
+	err := s.clicksCollection.FindOneAndUpdate(ctx, filter, update, opts).Err()
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("failed to mark click as converted in MongoDB: %w", err)
+	}
+	return true, nil
+}
+
+// clicksInRange matches the click events of shortID in [from, to).
+func clicksInRange(shortID string, from, to time.Time) bson.M {
+	return bson.M{"short_id": shortID, "clicked_at": bson.M{"$gte": from, "$lt": to}}
+}
+
+// CountByReferer groups the click events of shortID in [from, to) by referer domain.
+func (s *MongoUrlStore) CountByReferer(ctx context.Context, shortID string, from, to time.Time) ([]domain.RefererCount, error) {
+	ctx, span := tracer.Start(ctx, "store.CountByReferer", trace.WithAttributes(attribute.String(tracing.AttrShortID, shortID)))
+	defer span.End()
+
+	pipeline := mongo.Pipeline{
+		{{Key: "$match", Value: clicksInRange(shortID, from, to)}},
+		{{Key: "$group", Value: bson.M{"_id": "$referer_domain", "count": bson.M{"$sum": 1}}}},
+		{{Key: "$sort", Value: bson.D{{Key: "count", Value: -1}, {Key: "_id", Value: 1}}}},
+	}
+	cursor, err := s.clicksCollection.Aggregate(ctx, pipeline)
+	if err != nil {
+		return nil, fmt.Errorf("failed to aggregate clicks by referer in MongoDB: %w", err)
+	}
+	defer cursor.Close(ctx)
+
+	counts := []domain.RefererCount{}
+	if err := cursor.All(ctx, &counts); err != nil {
+		return nil, fmt.Errorf("failed to decode referer counts: %w", err)
+	}
+	return counts, nil
+}
+
+// CountByCountry groups the click events of shortID in [from, to) by country code.
+// Events recorded before countries were resolved are counted as geoip.UnknownCountry.
+func (s *MongoUrlStore) CountByCountry(ctx context.Context, shortID string, from, to time.Time) ([]domain.CountryCount, error) {
+	ctx, span := tracer.Start(ctx, "store.CountByCountry", trace.WithAttributes(attribute.String(tracing.AttrShortID, shortID)))
+	defer span.End()
+
+	pipeline := mongo.Pipeline{
+		{{Key: "$match", Value: clicksInRange(shortID, from, to)}},
+		{{Key: "$group", Value: bson.M{
+			"_id":   bson.M{"$ifNull": bson.A{"$country_code", geoip.UnknownCountry}},
+			"count": bson.M{"$sum": 1},
+		}}},
+		{{Key: "$sort", Value: bson.D{{Key: "count", Value: -1}, {Key: "_id", Value: 1}}}},
+	}
+	cursor, err := s.clicksCollection.Aggregate(ctx, pipeline)
+	if err != nil {
+		return nil, fmt.Errorf("failed to aggregate clicks by country in MongoDB: %w", err)
+	}
+	defer cursor.Close(ctx)
+
+	counts := []domain.CountryCount{}
+	if err := cursor.All(ctx, &counts); err != nil {
+		return nil, fmt.Errorf("failed to decode country counts: %w", err)
+	}
+	return counts, nil
+}
+
+// CountByVariant groups the click events of shortID in [from, to) that record a variant by variant.
+func (s *MongoUrlStore) CountByVariant(ctx context.Context, shortID string, from, to time.Time) ([]domain.VariantCount, error) {
+	ctx, span := tracer.Start(ctx, "store.CountByVariant", trace.WithAttributes(attribute.String(tracing.AttrShortID, shortID)))
+	defer span.End()
+
+	match := clicksInRange(shortID, from, to)
+	match["variant"] = bson.M{"$exists": true}
+	pipeline := mongo.Pipeline{
+		{{Key: "$match", Value: match}},
+		{{Key: "$group", Value: bson.M{"_id": "$variant", "count": bson.M{"$sum": 1}}}},
+		{{Key: "$sort", Value: bson.D{{Key: "_id", Value: 1}}}},
+	}
+	cursor, err := s.clicksCollection.Aggregate(ctx, pipeline)
+	if err != nil {
+		return nil, fmt.Errorf("failed to aggregate clicks by variant in MongoDB: %w", err)
+	}
+	defer cursor.Close(ctx)
+
+	counts := []domain.VariantCount{}
+	if err := cursor.All(ctx, &counts); err != nil {
+		return nil, fmt.Errorf("failed to decode variant counts: %w", err)
+	}
+	return counts, nil
+}
+
+// DetectDateTrunc checks whether the MongoDB server supports $dateTrunc (5.0 and later), which
+// ClickTimeseries then uses to bucket clicks. If the version cannot be read, the older
+// $dateToString pipeline is used, which works on every version.
+func (s *MongoUrlStore) DetectDateTrunc(ctx context.Context) {
+	var info struct {
+		Version      string  `bson:"version"`
+		VersionArray []int32 `bson:"versionArray"`
+	}
+	err := s.collection.Database().RunCommand(ctx, bson.D{{Key: "buildInfo", Value: 1}}).Decode(&info)
+	if err != nil || len(info.VersionArray) == 0 {
+		slog.WarnContext(ctx, "Could not read the MongoDB version, click time series will not use $dateTrunc", slog.Any("error", err))
+		return
+	}
+	s.hasDateTrunc = info.VersionArray[0] >= 5
+	slog.InfoContext(ctx, "Detected MongoDB version", slog.String("version", info.Version), slog.Bool("date_trunc", s.hasDateTrunc))
+}
+
+// bucketStart returns the expression for the start of the granularity period of clicked_at.
+// Without $dateTrunc only weeks need computing, by stepping back to Monday; hours, days and
+// months are truncated by the $dateToString format itself.
+func (s *MongoUrlStore) bucketStart(granularity string) any {
+	if s.hasDateTrunc {
+		trunc := bson.M{"date": "$clicked_at", "unit": granularity}
+		if granularity == domain.GranularityWeek {
+			trunc["startOfWeek"] = "monday"
+		}
+		return bson.M{"$dateTrunc": trunc}
+	}
+	if granularity != domain.GranularityWeek {
+		return "$clicked_at"
+	}
+	// $dayOfWeek is 1 for Sunday through 7 for Saturday, so (dayOfWeek+5)%7 is the number of days since Monday.
+	daysSinceMonday := bson.M{"$mod": bson.A{bson.M{"$add": bson.A{bson.M{"$dayOfWeek": "$clicked_at"}, 5}}, 7}}
+	return bson.M{"$subtract": bson.A{"$clicked_at", bson.M{"$multiply": bson.A{daysSinceMonday, int64(24 * time.Hour / time.Millisecond)}}}}
+}
+
+// ClickTimeseries groups the click events of shortID in [from, to) by period of granularity.
+func (s *MongoUrlStore) ClickTimeseries(ctx context.Context, shortID, granularity string, from, to time.Time) ([]domain.ClickBucket, error) {
+	ctx, span := tracer.Start(ctx, "store.ClickTimeseries", trace.WithAttributes(attribute.String(tracing.AttrShortID, shortID)))
+	defer span.End()
+
+	format, ok := bucketFormats[granularity]
+	if !ok {
+		return nil, fmt.Errorf("unknown time series granularity '%s'", granularity)
+	}
+	pipeline := mongo.Pipeline{
+		{{Key: "$match", Value: clicksInRange(shortID, from, to)}},
+		{{Key: "$group", Value: bson.M{
+			"_id":   bson.M{"$dateToString": bson.M{"format": format, "date": s.bucketStart(granularity)}},
+			"count": bson.M{"$sum": 1},
+		}}},
+		// The date formats sort chronologically as strings.
+		{{Key: "$sort", Value: bson.D{{Key: "_id", Value: 1}}}},
+	}
+	cursor, err := s.clicksCollection.Aggregate(ctx, pipeline)
+	if err != nil {
+		return nil, fmt.Errorf("failed to aggregate click time series in MongoDB: %w", err)
+	}
+	defer cursor.Close(ctx)
+
+	buckets := []domain.ClickBucket{}
+	if err := cursor.All(ctx, &buckets); err != nil {
+		return nil, fmt.Errorf("failed to decode click time series: %w", err)
+	}
+	return buckets, nil
+}
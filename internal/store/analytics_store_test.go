@@ -0,0 +1,84 @@
+package store
+
+import (
+	"context"
+	"slices"
+	"testing"
+	"time"
+
+	"shawty/internal/domain"
+)
+
+func TestMongoClickTimeseries(t *testing.T) {
+	ctx := context.Background()
+	s := newTestMongoStore(t)
+
+	// 2024-01-15 is a Monday.
+	clicks := []string{
+		"2024-01-15T13:05:00Z",
+		"2024-01-15T13:59:59Z",
+		"2024-01-15T14:00:00Z",
+		"2024-01-16T00:00:00Z",
+		"2024-01-21T23:59:59Z", // Sunday, the last day of the first week
+		"2024-01-22T00:00:00Z",
+		"2024-02-01T10:00:00Z",
+		"2024-03-01T00:00:00Z", // Outside the queried range
+	}
+	for _, raw := range clicks {
+		clickedAt, _ := time.Parse(time.RFC3339, raw)
+		if err := s.RecordClick(ctx, domain.ClickEvent{ShortID: "abc123", ClickedAt: clickedAt}); err != nil {
+			t.Fatalf("RecordClick: %v", err)
+		}
+	}
+	other := domain.ClickEvent{ShortID: "other1", ClickedAt: time.Date(2024, 1, 15, 13, 0, 0, 0, time.UTC)}
+	if err := s.RecordClick(ctx, other); err != nil {
+		t.Fatalf("RecordClick: %v", err)
+	}
+
+	january := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	march := time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)
+	tests := []struct {
+		granularity string
+		from, to    time.Time
+		want        []domain.ClickBucket
+	}{
+		{
+			granularity: domain.GranularityHour,
+			from:        time.Date(2024, 1, 15, 13, 0, 0, 0, time.UTC),
+			to:          time.Date(2024, 1, 15, 15, 0, 0, 0, time.UTC),
+			want:        []domain.ClickBucket{{Date: "2024-01-15T13:00:00Z", Count: 2}, {Date: "2024-01-15T14:00:00Z", Count: 1}},
+		},
+		{
+			granularity: domain.GranularityDay, from: january, to: march,
+			want: []domain.ClickBucket{{Date: "2024-01-15", Count: 3}, {Date: "2024-01-16", Count: 1}, {Date: "2024-01-21", Count: 1}, {Date: "2024-01-22", Count: 1}, {Date: "2024-02-01", Count: 1}},
+		},
+		{
+			granularity: domain.GranularityWeek, from: january, to: march,
+			want: []domain.ClickBucket{{Date: "2024-01-15", Count: 5}, {Date: "2024-01-22", Count: 1}, {Date: "2024-01-29", Count: 1}},
+		},
+		{
+			granularity: domain.GranularityMonth, from: january, to: march,
+			want: []domain.ClickBucket{{Date: "2024-01", Count: 6}, {Date: "2024-02", Count: 1}},
+		},
+	}
+	run := func(t *testing.T) {
+		for _, tt := range tests {
+			got, err := s.ClickTimeseries(ctx, "abc123", tt.granularity, tt.from, tt.to)
+			if err != nil {
+				t.Fatalf("ClickTimeseries(%s): %v", tt.granularity, err)
+			}
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("ClickTimeseries(%s) = %+v, want %+v", tt.granularity, got, tt.want)
+			}
+		}
+	}
+
+	// Both pipelines must bucket the same way; $dateTrunc needs MongoDB 5.0.
+	t.Run("dateToString", run)
+	s.DetectDateTrunc(ctx)
+	if !s.hasDateTrunc {
+		t.Log("the server does not support $dateTrunc")
+		return
+	}
+	t.Run("dateTrunc", run)
+}
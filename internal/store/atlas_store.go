@@ -0,0 +1,495 @@
+package store
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"strings"
+	"time"
+
+	"shawty/internal/domain"
+
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+// AtlasDataAPIStore implements UrlStoreInterface on top of the MongoDB Atlas Data API.
+// It talks to Atlas over plain HTTPS, which suits environments that cannot keep a
+// persistent driver connection open (serverless functions, edge runtimes).
+type AtlasDataAPIStore struct {
+	httpClient *http.Client
+	baseURL    string
+	apiKey     string
+	dataSource string
+	database   string
+	collection string
+}
+
+// NewAtlasDataAPIStore creates a new AtlasDataAPIStore.
+// baseURL is the Data API endpoint, e.g. https://data.mongodb-api.com/app/<app-id>/endpoint/data/v1.
+func NewAtlasDataAPIStore(httpClient *http.Client, baseURL, apiKey, dataSource, dbName, collectionName string) *AtlasDataAPIStore {
+	return &AtlasDataAPIStore{
+		httpClient: httpClient,
+		baseURL:    strings.TrimRight(baseURL, "/"),
+		apiKey:     apiKey,
+		dataSource: dataSource,
+		database:   dbName,
+		collection: collectionName,
+	}
+}
+
+// EnsureIndexes is a no-op: the Data API cannot manage indexes.
+// The _id index that enforces short ID uniqueness always exists.
+func (s *AtlasDataAPIStore) EnsureIndexes(ctx context.Context) error {
+	slog.InfoContext(ctx, "Atlas Data API store does not manage indexes; relying on the default unique index on _id")
+	return nil
+}
+
+// Save inserts a new URL entry through the insertOne action.
+func (s *AtlasDataAPIStore) Save(ctx context.Context, urlEntry domain.URL) error {
+	payload := s.payload(bson.M{"document": urlEntry})
+	if err := s.do(ctx, "insertOne", payload, nil); err != nil {
+		if isAtlasDuplicateKeyError(err) {
+			return ErrDuplicateShortID
+		}
+		return fmt.Errorf("failed to insert URL through Atlas Data API: %w", err)
+	}
+	return nil
+}
+
+// GetByShortID retrieves a URL entry by its short ID through the findOne action, following an
+// alias to its primary.
+func (s *AtlasDataAPIStore) GetByShortID(ctx context.Context, shortID, tenantID string) (domain.URL, error) {
+	return followAlias(ctx, shortID, tenantID, s.getByShortID)
+}
+
+// getByShortID retrieves the document with the short ID as stored, aliases included.
+func (s *AtlasDataAPIStore) getByShortID(ctx context.Context, shortID, tenantID string) (domain.URL, error) {
+	var result struct {
+		Document *domain.URL `bson:"document"`
+	}
+	payload := s.payload(bson.M{"filter": withTenant(bson.M{"_id": shortID}, tenantID)})
+	if err := s.do(ctx, "findOne", payload, &result); err != nil {
+		return domain.URL{}, fmt.Errorf("error retrieving URL from Atlas Data API: %w", err)
+	}
+	if result.Document == nil {
+		return domain.URL{}, errNotFound(shortID)
+	}
+	if result.Document.IsDeleted() {
+		return domain.URL{}, fmt.Errorf("%w: '%s'", ErrURLDeleted, shortID)
+	}
+	return *result.Document, nil
+}
+
+// GetByOriginalURL retrieves an (undeleted) URL entry by its original URL through the findOne action.
+// It returns ErrURLNotFound if the URL has not been shortened.
+func (s *AtlasDataAPIStore) GetByOriginalURL(ctx context.Context, originalURL string) (domain.URL, error) {
+	var result struct {
+		Document *domain.URL `bson:"document"`
+	}
+	payload := s.payload(bson.M{"filter": bson.M{"original_url": originalURL, "deleted_at": bson.M{"$exists": false}}})
+	if err := s.do(ctx, "findOne", payload, &result); err != nil {
+		return domain.URL{}, fmt.Errorf("error retrieving URL by original URL from Atlas Data API: %w", err)
+	}
+	if result.Document == nil {
+		return domain.URL{}, fmt.Errorf("%w: no entry for '%s'", ErrURLNotFound, originalURL)
+	}
+	return *result.Document, nil
+}
+
+// EstimatedCount returns the number of URL entries in the collection.
+// The Data API has no metadata-based count, so this runs a $count aggregation.
+func (s *AtlasDataAPIStore) EstimatedCount(ctx context.Context) (int64, error) {
+	return s.count(ctx, notDeletedFilter)
+}
+
+// count returns the number of entries matching filter using a $count aggregation.
+func (s *AtlasDataAPIStore) count(ctx context.Context, filter bson.M) (int64, error) {
+	var result struct {
+		Documents []struct {
+			Total int64 `bson:"total"`
+		} `bson:"documents"`
+	}
+	payload := s.payload(bson.M{"pipeline": bson.A{
+		bson.M{"$match": filter},
+		bson.M{"$count": "total"},
+	}})
+	if err := s.do(ctx, "aggregate", payload, &result); err != nil {
+		return 0, fmt.Errorf("failed to count URLs through Atlas Data API: %w", err)
+	}
+	if len(result.Documents) == 0 {
+		return 0, nil // $count emits no document when nothing matches
+	}
+	return result.Documents[0].Total, nil
+}
+
+// IncrementClickCount atomically increments the click counter through the updateOne action.
+// Click-limited entries that have used up their clicks are not incremented and return ErrClickLimitReached.
+// The Data API has no findOneAndUpdate action, so the new count is read back with a separate findOne;
+// concurrent clicks may therefore see the same count.
+func (s *AtlasDataAPIStore) IncrementClickCount(ctx context.Context, shortID string, mobile bool) (int64, error) {
+	var result struct {
+		MatchedCount int64 `bson:"matchedCount"`
+	}
+	payload := s.payload(bson.M{
+		"filter": bson.M{"_id": shortID, "$or": underClickLimitFilter},
+		"update": bson.M{
+			"$inc": clickIncrement(mobile),
+			"$set": bson.M{"last_accessed_at": time.Now().UTC()},
+		},
+	})
+	if err := s.do(ctx, "updateOne", payload, &result); err != nil {
+		return 0, fmt.Errorf("failed to increment click count through Atlas Data API: %w", err)
+	}
+	// Either way the entry is read: to tell a missing entry from a used-up limit, or for its new count.
+	urlEntry, err := s.GetByShortID(ctx, shortID, AnyTenant)
+	if err != nil {
+		return 0, err
+	}
+	if result.MatchedCount == 0 {
+		return 0, fmt.Errorf("%w: '%s'", ErrClickLimitReached, shortID)
+	}
+	return urlEntry.ClickCount, nil
+}
+
+// List returns a page of URL entries in _id order, starting after cursor, through the find action.
+func (s *AtlasDataAPIStore) List(ctx context.Context, tenantID, cursor string, limit int64, includeDeleted bool) ([]domain.URL, string, error) {
+	after, err := decodeCursor(cursor)
+	if err != nil {
+		return nil, "", err
+	}
+	filter := withTenant(bson.M{}, tenantID)
+	if !includeDeleted {
+		filter["deleted_at"] = bson.M{"$exists": false}
+	}
+	if after != "" {
+		filter["_id"] = bson.M{"$gt": after}
+	}
+
+	var result struct {
+		Documents []domain.URL `bson:"documents"`
+	}
+	payload := s.payload(bson.M{
+		"filter": filter,
+		"sort":   bson.M{"_id": 1},
+		"limit":  limit + 1, // One extra entry tells whether another page follows
+	})
+	if err := s.do(ctx, "find", payload, &result); err != nil {
+		return nil, "", fmt.Errorf("failed to list URLs through Atlas Data API: %w", err)
+	}
+	if result.Documents == nil {
+		result.Documents = []domain.URL{}
+	}
+	urls, next := nextPage(result.Documents, limit)
+	return urls, next, nil
+}
+
+// Delete removes a URL entry through the deleteOne action, or sets deleted_at through
+// updateOne in soft-delete builds. It returns ErrURLNotFound if nothing matched.
+func (s *AtlasDataAPIStore) Delete(ctx context.Context, shortID string) error {
+	if SoftDelete {
+		var result struct {
+			MatchedCount int64 `bson:"matchedCount"`
+		}
+		payload := s.payload(bson.M{
+			"filter": bson.M{"_id": shortID, "deleted_at": bson.M{"$exists": false}},
+			"update": bson.M{"$set": bson.M{"deleted_at": time.Now().UTC()}},
+		})
+		if err := s.do(ctx, "updateOne", payload, &result); err != nil {
+			return fmt.Errorf("failed to soft-delete URL through Atlas Data API: %w", err)
+		}
+		if result.MatchedCount == 0 {
+			return fmt.Errorf("%w: '%s'", ErrURLNotFound, shortID)
+		}
+		return nil
+	}
+
+	var result struct {
+		DeletedCount int64 `bson:"deletedCount"`
+	}
+	payload := s.payload(bson.M{"filter": bson.M{"_id": shortID}})
+	if err := s.do(ctx, "deleteOne", payload, &result); err != nil {
+		return fmt.Errorf("failed to delete URL through Atlas Data API: %w", err)
+	}
+	if result.DeletedCount == 0 {
+		return fmt.Errorf("%w: '%s'", ErrURLNotFound, shortID)
+	}
+	return nil
+}
+
+// Update changes the original URL of an entry through the updateOne action.
+// It returns ErrURLNotFound if no (undeleted) entry has the short ID.
+func (s *AtlasDataAPIStore) Update(ctx context.Context, shortID, newOriginalURL string) error {
+	var result struct {
+		MatchedCount int64 `bson:"matchedCount"`
+	}
+	payload := s.payload(bson.M{
+		"filter": bson.M{"_id": shortID, "deleted_at": bson.M{"$exists": false}},
+		"update": bson.M{"$set": bson.M{
+			"original_url": newOriginalURL,
+			"updated_at":   time.Now().UTC(),
+		}},
+	})
+	if err := s.do(ctx, "updateOne", payload, &result); err != nil {
+		return fmt.Errorf("failed to update URL through Atlas Data API: %w", err)
+	}
+	if result.MatchedCount == 0 {
+		return fmt.Errorf("%w: '%s'", ErrURLNotFound, shortID)
+	}
+	return nil
+}
+
+// atlasForEachPageSize is how many entries ForEach fetches per find action.
+const atlasForEachPageSize = 1000
+
+// ForEach calls fn for every entry in _id order. The Data API has no cursors,
+// so entries are fetched in pages through List.
+func (s *AtlasDataAPIStore) ForEach(ctx context.Context, fn func(domain.URL) error) error {
+	cursor := ""
+	for {
+		urls, next, err := s.List(ctx, AnyTenant, cursor, atlasForEachPageSize, true)
+		if err != nil {
+			return err
+		}
+		for _, urlEntry := range urls {
+			if err := fn(urlEntry); err != nil {
+				return err
+			}
+		}
+		if next == "" {
+			return nil
+		}
+		cursor = next
+	}
+}
+
+// ListByTag returns a page of undeleted entries carrying tag in _id order through the find action.
+// The total comes from a separate $count aggregation.
+func (s *AtlasDataAPIStore) ListByTag(ctx context.Context, tag string, limit, offset int64) ([]domain.URL, int64, error) {
+	filter := bson.M{"tags": tag, "deleted_at": bson.M{"$exists": false}}
+
+	var result struct {
+		Documents []domain.URL `bson:"documents"`
+	}
+	payload := s.payload(bson.M{
+		"filter": filter,
+		"sort":   bson.M{"_id": 1},
+		"skip":   offset,
+		"limit":  limit,
+	})
+	if err := s.do(ctx, "find", payload, &result); err != nil {
+		return nil, 0, fmt.Errorf("failed to list URLs by tag through Atlas Data API: %w", err)
+	}
+	if result.Documents == nil {
+		result.Documents = []domain.URL{}
+	}
+	total, err := s.count(ctx, filter)
+	if err != nil {
+		return nil, 0, err
+	}
+	return result.Documents, total, nil
+}
+
+// Search returns up to limit undeleted entries whose original URL contains query, through the find action.
+func (s *AtlasDataAPIStore) Search(ctx context.Context, query string, limit int64) ([]domain.URL, error) {
+	var result struct {
+		Documents []domain.URL `bson:"documents"`
+	}
+	payload := s.payload(bson.M{
+		"filter": searchFilter(query),
+		"sort":   bson.M{"_id": 1},
+		"limit":  limit,
+	})
+	if err := s.do(ctx, "find", payload, &result); err != nil {
+		return nil, fmt.Errorf("failed to search URLs through Atlas Data API: %w", err)
+	}
+	if result.Documents == nil {
+		result.Documents = []domain.URL{}
+	}
+	return result.Documents, nil
+}
+
+// ListAliases returns the short IDs of the (undeleted) aliases of primaryID through the find action.
+func (s *AtlasDataAPIStore) ListAliases(ctx context.Context, primaryID string) ([]string, error) {
+	var result struct {
+		Documents []struct {
+			ID string `bson:"_id"`
+		} `bson:"documents"`
+	}
+	payload := s.payload(bson.M{
+		"filter":     bson.M{"alias_of": primaryID, "deleted_at": bson.M{"$exists": false}},
+		"projection": bson.M{"_id": 1},
+		"sort":       bson.M{"_id": 1},
+	})
+	if err := s.do(ctx, "find", payload, &result); err != nil {
+		return nil, fmt.Errorf("failed to list aliases through Atlas Data API: %w", err)
+	}
+	aliases := make([]string, 0, len(result.Documents))
+	for _, doc := range result.Documents {
+		aliases = append(aliases, doc.ID)
+	}
+	return aliases, nil
+}
+
+// UpdateTags replaces the tags of an entry through the updateOne action.
+// It returns ErrURLNotFound if no (undeleted) entry has the short ID.
+func (s *AtlasDataAPIStore) UpdateTags(ctx context.Context, shortID string, tags []string) error {
+	update := bson.M{"$set": bson.M{"tags": tags, "updated_at": time.Now().UTC()}}
+	if len(tags) == 0 {
+		update = bson.M{"$set": bson.M{"updated_at": time.Now().UTC()}, "$unset": bson.M{"tags": ""}}
+	}
+
+	var result struct {
+		MatchedCount int64 `bson:"matchedCount"`
+	}
+	payload := s.payload(bson.M{
+		"filter": bson.M{"_id": shortID, "deleted_at": bson.M{"$exists": false}},
+		"update": update,
+	})
+	if err := s.do(ctx, "updateOne", payload, &result); err != nil {
+		return fmt.Errorf("failed to update tags through Atlas Data API: %w", err)
+	}
+	if result.MatchedCount == 0 {
+		return fmt.Errorf("%w: '%s'", ErrURLNotFound, shortID)
+	}
+	return nil
+}
+
+// IncrementConversionCount atomically increments the conversion counter through the updateOne action.
+func (s *AtlasDataAPIStore) IncrementConversionCount(ctx context.Context, shortID string) error {
+	var result struct {
+		MatchedCount int64 `bson:"matchedCount"`
+	}
+	payload := s.payload(bson.M{
+		"filter": bson.M{"_id": shortID},
+		"update": bson.M{"$inc": bson.M{"conversion_count": 1}},
+	})
+	if err := s.do(ctx, "updateOne", payload, &result); err != nil {
+		return fmt.Errorf("failed to increment conversion count through Atlas Data API: %w", err)
+	}
+	if result.MatchedCount == 0 {
+		return fmt.Errorf("%w: '%s'", ErrURLNotFound, shortID)
+	}
+	return nil
+}
+
+// UpdateMetadata sets the page title and description of an entry through the updateOne action.
+// It returns ErrURLNotFound if no (undeleted) entry has the short ID.
+func (s *AtlasDataAPIStore) UpdateMetadata(ctx context.Context, shortID, title, description string) error {
+	var result struct {
+		MatchedCount int64 `bson:"matchedCount"`
+	}
+	payload := s.payload(bson.M{
+		"filter": bson.M{"_id": shortID, "deleted_at": bson.M{"$exists": false}},
+		"update": bson.M{"$set": bson.M{"page_title": title, "page_description": description}},
+	})
+	if err := s.do(ctx, "updateOne", payload, &result); err != nil {
+		return fmt.Errorf("failed to update page metadata through Atlas Data API: %w", err)
+	}
+	if result.MatchedCount == 0 {
+		return fmt.Errorf("%w: '%s'", ErrURLNotFound, shortID)
+	}
+	return nil
+}
+
+// Undelete clears deleted_at on a soft-deleted entry through the updateOne action.
+// It returns ErrURLNotFound if no deleted entry has the short ID.
+func (s *AtlasDataAPIStore) Undelete(ctx context.Context, shortID string) error {
+	var result struct {
+		MatchedCount int64 `bson:"matchedCount"`
+	}
+	payload := s.payload(bson.M{
+		"filter": bson.M{"_id": shortID, "deleted_at": bson.M{"$exists": true}},
+		"update": bson.M{"$unset": bson.M{"deleted_at": ""}},
+	})
+	if err := s.do(ctx, "updateOne", payload, &result); err != nil {
+		return fmt.Errorf("failed to undelete URL through Atlas Data API: %w", err)
+	}
+	if result.MatchedCount == 0 {
+		return fmt.Errorf("%w: no deleted entry '%s'", ErrURLNotFound, shortID)
+	}
+	return nil
+}
+
+// Ping checks that the Data API endpoint accepts requests with the configured key.
+func (s *AtlasDataAPIStore) Ping(ctx context.Context) error {
+	payload := s.payload(bson.M{"filter": bson.M{"_id": ""}})
+	if err := s.do(ctx, "findOne", payload, nil); err != nil {
+		return fmt.Errorf("atlas data api is not reachable: %w", err)
+	}
+	return nil
+}
+
+// Ready checks that the URL collection can answer queries.
+// The Data API creates collections lazily, so reachability is the only meaningful check.
+func (s *AtlasDataAPIStore) Ready(ctx context.Context) error {
+	return s.Ping(ctx)
+}
+
+// payload builds the request body shared by every Data API action.
+func (s *AtlasDataAPIStore) payload(fields bson.M) bson.M {
+	fields["dataSource"] = s.dataSource
+	fields["database"] = s.database
+	fields["collection"] = s.collection
+	return fields
+}
+
+// atlasAPIError is returned when the Data API answers with a non-2xx status.
+type atlasAPIError struct {
+	StatusCode int
+	Body       string
+}
+
+func (e *atlasAPIError) Error() string {
+	return fmt.Sprintf("atlas data api returned status %d: %s", e.StatusCode, e.Body)
+}
+
+// isAtlasDuplicateKeyError reports whether the Data API rejected an insert because of a duplicate _id.
+func isAtlasDuplicateKeyError(err error) bool {
+	apiErr, ok := err.(*atlasAPIError)
+	if !ok {
+		return false
+	}
+	return strings.Contains(apiErr.Body, "E11000") || strings.Contains(apiErr.Body, "DuplicateKey")
+}
+
+// do executes a Data API action. Requests and responses are encoded as canonical
+// extended JSON so that dates and other BSON types round-trip through domain.URL.
+func (s *AtlasDataAPIStore) do(ctx context.Context, action string, payload bson.M, out interface{}) error {
+	body, err := bson.MarshalExtJSON(payload, true, false)
+	if err != nil {
+		return fmt.Errorf("failed to encode %s payload: %w", action, err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.baseURL+"/action/"+action, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("failed to build %s request: %w", action, err)
+	}
+	req.Header.Set("Content-Type", "application/ejson")
+	req.Header.Set("Accept", "application/ejson")
+	req.Header.Set("api-key", s.apiKey)
+
+	resp, err := s.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("%s request failed: %w", action, err)
+	}
+	defer resp.Body.Close()
+
+	respBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return fmt.Errorf("failed to read %s response: %w", action, err)
+	}
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return &atlasAPIError{StatusCode: resp.StatusCode, Body: string(respBody)}
+	}
+
+	if out == nil {
+		return nil
+	}
+	if err := bson.UnmarshalExtJSON(respBody, true, out); err != nil {
+		return fmt.Errorf("failed to decode %s response: %w", action, err)
+	}
+	return nil
+}
@@ -0,0 +1,220 @@
+package store
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"shawty/internal/domain"
+
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+const testAtlasAPIKey = "atlas-key"
+
+// fakeAtlas simulates the findOne, insertOne, updateOne and deleteOne actions of the Data API
+// on an in-memory collection. Filters support equality and {"$exists": false}; updates support $set.
+type fakeAtlas struct {
+	t       *testing.T
+	mu      sync.Mutex
+	docs    map[string]bson.M
+	actions []string
+}
+
+func (f *fakeAtlas) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if r.Method != http.MethodPost || r.Header.Get("api-key") != testAtlasAPIKey || r.Header.Get("Content-Type") != "application/ejson" {
+		f.t.Errorf("got %s with api-key %q and Content-Type %q, want an EJSON POST with the API key", r.Method, r.Header.Get("api-key"), r.Header.Get("Content-Type"))
+		http.Error(w, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+	body, _ := io.ReadAll(r.Body)
+	var payload bson.M
+	if err := bson.UnmarshalExtJSON(body, true, &payload); err != nil {
+		f.t.Errorf("request body is not canonical extended JSON: %v", err)
+		http.Error(w, "bad request", http.StatusBadRequest)
+		return
+	}
+	if payload["dataSource"] != "Cluster0" || payload["database"] != "shawtydb" || payload["collection"] != "urls" {
+		f.t.Errorf("payload targets %v/%v/%v, want Cluster0/shawtydb/urls", payload["dataSource"], payload["database"], payload["collection"])
+	}
+	filter, _ := payload["filter"].(bson.M)
+	action := strings.TrimPrefix(r.URL.Path, "/app/test-app/endpoint/data/v1/action/")
+	f.actions = append(f.actions, action)
+
+	var result bson.M
+	switch action {
+	case "findOne":
+		result = bson.M{"document": nil}
+		if doc := f.find(filter); doc != nil {
+			result["document"] = doc
+		}
+	case "insertOne":
+		doc := payload["document"].(bson.M)
+		id := doc["_id"].(string)
+		if _, exists := f.docs[id]; exists {
+			http.Error(w, `{"error":"Failed to insert document: FunctionError: E11000 duplicate key error collection: shawtydb.urls index: _id_ dup key"}`, http.StatusBadRequest)
+			return
+		}
+		f.docs[id] = doc
+		result = bson.M{"insertedId": id}
+	case "updateOne":
+		matched := 0
+		if doc := f.find(filter); doc != nil {
+			matched = 1
+			set, _ := payload["update"].(bson.M)["$set"].(bson.M)
+			for key, value := range set {
+				doc[key] = value
+			}
+		}
+		result = bson.M{"matchedCount": matched, "modifiedCount": matched}
+	case "deleteOne":
+		deleted := 0
+		if doc := f.find(filter); doc != nil {
+			deleted = 1
+			delete(f.docs, doc["_id"].(string))
+		}
+		result = bson.M{"deletedCount": deleted}
+	default:
+		f.t.Errorf("unexpected action %q", action)
+		http.NotFound(w, r)
+		return
+	}
+	out, err := bson.MarshalExtJSON(result, true, false)
+	if err != nil {
+		f.t.Errorf("encoding response: %v", err)
+	}
+	w.Header().Set("Content-Type", "application/ejson")
+	w.Write(out)
+}
+
+// find returns the first document matching filter, or nil.
+func (f *fakeAtlas) find(filter bson.M) bson.M {
+	for _, doc := range f.docs {
+		if matchesFilter(doc, filter) {
+			return doc
+		}
+	}
+	return nil
+}
+
+// matchesFilter reports whether doc satisfies filter.
+func matchesFilter(doc, filter bson.M) bool {
+	for key, want := range filter {
+		value, present := doc[key]
+		if cond, ok := want.(bson.M); ok {
+			if exists, ok := cond["$exists"].(bool); ok && exists != present {
+				return false
+			}
+			continue
+		}
+		if !present || value != want {
+			return false
+		}
+	}
+	return true
+}
+
+// newTestAtlasStore returns an AtlasDataAPIStore talking to a fakeAtlas, with the fake.
+func newTestAtlasStore(t *testing.T) (*AtlasDataAPIStore, *fakeAtlas) {
+	t.Helper()
+	fake := &fakeAtlas{t: t, docs: make(map[string]bson.M)}
+	server := httptest.NewServer(fake)
+	t.Cleanup(server.Close)
+	baseURL := server.URL + "/app/test-app/endpoint/data/v1/"
+	return NewAtlasDataAPIStore(&http.Client{Timeout: 5 * time.Second}, baseURL, testAtlasAPIKey, "Cluster0", "shawtydb", "urls"), fake
+}
+
+func TestAtlasDataAPIStore(t *testing.T) {
+	ctx := context.Background()
+	atlasStore, fake := newTestAtlasStore(t)
+	created := time.Date(2025, 1, 2, 3, 4, 5, 0, time.UTC)
+	entry := domain.URL{ID: "abc123", ShortUrl: "abc123", OriginalUrl: "https://example.com/page", CreationDate: created}
+
+	// insertOne
+	if err := atlasStore.Save(ctx, entry); err != nil {
+		t.Fatalf("Save: %v", err)
+	}
+	if err := atlasStore.Save(ctx, entry); !errors.Is(err, ErrDuplicateShortID) {
+		t.Errorf("saving the short ID again: error = %v, want ErrDuplicateShortID", err)
+	}
+
+	// findOne
+	got, err := atlasStore.GetByShortID(ctx, "abc123", AnyTenant)
+	if err != nil {
+		t.Fatalf("GetByShortID: %v", err)
+	}
+	if got.OriginalUrl != entry.OriginalUrl || !got.CreationDate.Equal(created) {
+		t.Errorf("GetByShortID = %+v, want %+v", got, entry)
+	}
+	if got, err := atlasStore.GetByOriginalURL(ctx, "https://example.com/page"); err != nil || got.ID != "abc123" {
+		t.Errorf("GetByOriginalURL = %q, %v; want abc123", got.ID, err)
+	}
+	if _, err := atlasStore.GetByShortID(ctx, "nope00", AnyTenant); !errors.Is(err, ErrURLNotFound) {
+		t.Errorf("GetByShortID of a missing ID: error = %v, want ErrURLNotFound", err)
+	}
+	if _, err := atlasStore.GetByOriginalURL(ctx, "https://example.com/other"); !errors.Is(err, ErrURLNotFound) {
+		t.Errorf("GetByOriginalURL of an unknown URL: error = %v, want ErrURLNotFound", err)
+	}
+
+	// updateOne
+	if err := atlasStore.Update(ctx, "abc123", "https://example.com/moved"); err != nil {
+		t.Fatalf("Update: %v", err)
+	}
+	if got, _ := atlasStore.GetByShortID(ctx, "abc123", AnyTenant); got.OriginalUrl != "https://example.com/moved" || got.UpdatedAt == nil {
+		t.Errorf("after Update: original URL %q, updated at %v; want the new URL and a timestamp", got.OriginalUrl, got.UpdatedAt)
+	}
+	if err := atlasStore.Update(ctx, "nope00", "https://example.com/x"); !errors.Is(err, ErrURLNotFound) {
+		t.Errorf("Update of a missing ID: error = %v, want ErrURLNotFound", err)
+	}
+
+	// deleteOne, or updateOne setting deleted_at in soft-delete builds
+	if err := atlasStore.Delete(ctx, "abc123"); err != nil {
+		t.Fatalf("Delete: %v", err)
+	}
+	_, err = atlasStore.GetByShortID(ctx, "abc123", AnyTenant)
+	if SoftDelete && !errors.Is(err, ErrURLDeleted) {
+		t.Errorf("GetByShortID after a soft delete: error = %v, want ErrURLDeleted", err)
+	}
+	if !SoftDelete && !errors.Is(err, ErrURLNotFound) {
+		t.Errorf("GetByShortID after Delete: error = %v, want ErrURLNotFound", err)
+	}
+	if err := atlasStore.Delete(ctx, "abc123"); !errors.Is(err, ErrURLNotFound) {
+		t.Errorf("deleting twice: error = %v, want ErrURLNotFound", err)
+	}
+
+	used := make(map[string]bool)
+	for _, action := range fake.actions {
+		used[action] = true
+	}
+	for _, action := range []string{"findOne", "insertOne", "updateOne"} {
+		if !used[action] {
+			t.Errorf("the %s action was never called", action)
+		}
+	}
+	if !SoftDelete && !used["deleteOne"] {
+		t.Error("the deleteOne action was never called")
+	}
+}
+
+func TestAtlasDataAPIStoreReportsAPIErrors(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, `{"error":"invalid session"}`, http.StatusUnauthorized)
+	}))
+	defer server.Close()
+	atlasStore := NewAtlasDataAPIStore(server.Client(), server.URL, "wrong-key", "Cluster0", "shawtydb", "urls")
+
+	err := atlasStore.Ping(context.Background())
+	var apiErr *atlasAPIError
+	if !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusUnauthorized {
+		t.Errorf("Ping error = %v, want an atlasAPIError with status 401", err)
+	}
+}
@@ -0,0 +1,85 @@
+package store
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"shawty/internal/domain"
+	"shawty/internal/tracing"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// AuditCollectionName is the MongoDB collection audit events are written to.
+const AuditCollectionName = "audit_events"
+
+// auditWriteTimeout bounds the background insert of an audit event.
+const auditWriteTimeout = 5 * time.Second
+
+// AuditStoreInterface is implemented by stores that keep an audit log of changes to short URLs.
+type AuditStoreInterface interface {
+	// ListAuditEvents returns up to limit events for shortID, newest first.
+	ListAuditEvents(ctx context.Context, shortID string, limit int) ([]domain.AuditEvent, error)
+}
+
+// recordAudit writes an audit event for a successful change in the background.
+// The actor comes from ctx; a failed write is logged and does not affect the change itself.
+func (s *MongoUrlStore) recordAudit(ctx context.Context, eventType, shortID, originalURL string) {
+	s.writeAudit(ctx, domain.AuditEvent{EventType: eventType, ShortID: shortID, OriginalURL: originalURL})
+}
+
+// writeAudit fills in the actor and timestamp of event and writes it like recordAudit.
+func (s *MongoUrlStore) writeAudit(ctx context.Context, event domain.AuditEvent) {
+	actor := domain.AuditActorFromContext(ctx)
+	event.ActorKey = actor.Key
+	event.IPAddress = actor.IPAddress
+	event.Timestamp = time.Now().UTC()
+
+	// The caller's context may be cancelled as soon as its response is sent.
+	go func() {
+		ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), auditWriteTimeout)
+		defer cancel()
+		if _, err := s.auditCollection.InsertOne(ctx, event); err != nil {
+			slog.ErrorContext(ctx, "Failed to write audit event", slog.String("event_type", event.EventType), slog.String("short_id", event.ShortID), slog.Any("error", err))
+		}
+	}()
+}
+
+// auditIndexModels returns the indexes of the audit_events collection.
+func auditIndexModels() []mongo.IndexModel {
+	return []mongo.IndexModel{
+		{Keys: bson.D{{Key: "short_id", Value: 1}, {Key: "timestamp", Value: -1}}},
+	}
+}
+
+// ensureAuditIndexes creates the index ListAuditEvents relies on.
+func (s *MongoUrlStore) ensureAuditIndexes(ctx context.Context) error {
+	return createIndexes(ctx, s.auditCollection, auditIndexModels())
+}
+
+// ListAuditEvents returns up to limit audit events for shortID, newest first.
+func (s *MongoUrlStore) ListAuditEvents(ctx context.Context, shortID string, limit int) ([]domain.AuditEvent, error) {
+	ctx, span := tracer.Start(ctx, "store.ListAuditEvents", trace.WithAttributes(attribute.String(tracing.AttrShortID, shortID)))
+	defer span.End()
+
+	findOptions := options.Find().
+		SetSort(bson.D{{Key: "timestamp", Value: -1}}).
+		SetLimit(int64(limit))
+	cursor, err := s.auditCollection.Find(ctx, bson.M{"short_id": shortID}, findOptions)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list audit events from MongoDB: %w", err)
+	}
+	defer cursor.Close(ctx)
+
+	events := []domain.AuditEvent{}
+	if err := cursor.All(ctx, &events); err != nil {
+		return nil, fmt.Errorf("failed to decode audit events: %w", err)
+	}
+	return events, nil
+}
@@ -0,0 +1,39 @@
+package store
+
+import (
+	"encoding/base64"
+	"errors"
+	"fmt"
+
+	"shawty/internal/domain"
+)
+
+// ErrInvalidCursor is returned by List when the pagination cursor cannot be decoded.
+var ErrInvalidCursor = errors.New("invalid pagination cursor")
+
+// encodeCursor returns the opaque cursor that continues a listing after the entry with shortID.
+func encodeCursor(shortID string) string {
+	return base64.RawURLEncoding.EncodeToString([]byte(shortID))
+}
+
+// decodeCursor returns the short ID a cursor continues after; the empty cursor starts from the beginning.
+func decodeCursor(cursor string) (string, error) {
+	if cursor == "" {
+		return "", nil
+	}
+	shortID, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil || len(shortID) == 0 {
+		return "", fmt.Errorf("%w: '%s'", ErrInvalidCursor, cursor)
+	}
+	return string(shortID), nil
+}
+
+// nextPage trims a listing fetched with limit+1 entries down to limit and returns the cursor of the
+// following page, or "" when the listing fetched no more than limit entries and so is the last page.
+func nextPage(urls []domain.URL, limit int64) ([]domain.URL, string) {
+	if int64(len(urls)) <= limit {
+		return urls, ""
+	}
+	urls = urls[:limit]
+	return urls, encodeCursor(urls[len(urls)-1].ID)
+}
@@ -0,0 +1,45 @@
+package store
+
+import (
+	"context"
+	"fmt"
+
+	"shawty/internal/domain"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// DomainStatsStoreInterface is implemented by stores that can group short URLs by the domain they were created on.
+type DomainStatsStoreInterface interface {
+	// CountByDomain returns the links and clicks of each domain, most links first. Deleted entries
+	// and aliases are not counted.
+	CountByDomain(ctx context.Context) ([]domain.DomainStats, error)
+}
+
+// CountByDomain groups the entries of the urls collection by domain.
+func (s *MongoUrlStore) CountByDomain(ctx context.Context) ([]domain.DomainStats, error) {
+	ctx, span := tracer.Start(ctx, "store.CountByDomain")
+	defer span.End()
+
+	pipeline := mongo.Pipeline{
+		{{Key: "$match", Value: bson.M{"deleted_at": bson.M{"$exists": false}, "alias_of": bson.M{"$exists": false}}}},
+		{{Key: "$group", Value: bson.M{
+			"_id":    bson.M{"$ifNull": bson.A{"$domain", ""}},
+			"links":  bson.M{"$sum": 1},
+			"clicks": bson.M{"$sum": "$click_count"},
+		}}},
+		{{Key: "$sort", Value: bson.D{{Key: "links", Value: -1}, {Key: "_id", Value: 1}}}},
+	}
+	cursor, err := s.collection.Aggregate(ctx, pipeline)
+	if err != nil {
+		return nil, fmt.Errorf("failed to aggregate URLs by domain in MongoDB: %w", err)
+	}
+	defer cursor.Close(ctx)
+
+	stats := []domain.DomainStats{}
+	if err := cursor.All(ctx, &stats); err != nil {
+		return nil, fmt.Errorf("failed to decode domain stats: %w", err)
+	}
+	return stats, nil
+}
@@ -0,0 +1,800 @@
+package store
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"shawty/internal/domain"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/attributevalue"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+// dynamoOriginalURLIndex is the global secondary index GetByOriginalURL queries.
+const dynamoOriginalURLIndex = "original_url-index"
+
+// dynamoBatchGetSize is the most keys a BatchGetItem request may ask for.
+const dynamoBatchGetSize = 100
+
+// DynamoAPI is the part of the DynamoDB client that DynamoUrlStore uses. *dynamodb.Client
+// implements it; tests can substitute a fake.
+type DynamoAPI interface {
+	PutItem(ctx context.Context, params *dynamodb.PutItemInput, optFns ...func(*dynamodb.Options)) (*dynamodb.PutItemOutput, error)
+	GetItem(ctx context.Context, params *dynamodb.GetItemInput, optFns ...func(*dynamodb.Options)) (*dynamodb.GetItemOutput, error)
+	BatchGetItem(ctx context.Context, params *dynamodb.BatchGetItemInput, optFns ...func(*dynamodb.Options)) (*dynamodb.BatchGetItemOutput, error)
+	UpdateItem(ctx context.Context, params *dynamodb.UpdateItemInput, optFns ...func(*dynamodb.Options)) (*dynamodb.UpdateItemOutput, error)
+	DeleteItem(ctx context.Context, params *dynamodb.DeleteItemInput, optFns ...func(*dynamodb.Options)) (*dynamodb.DeleteItemOutput, error)
+	Query(ctx context.Context, params *dynamodb.QueryInput, optFns ...func(*dynamodb.Options)) (*dynamodb.QueryOutput, error)
+	Scan(ctx context.Context, params *dynamodb.ScanInput, optFns ...func(*dynamodb.Options)) (*dynamodb.ScanOutput, error)
+	DescribeTable(ctx context.Context, params *dynamodb.DescribeTableInput, optFns ...func(*dynamodb.Options)) (*dynamodb.DescribeTableOutput, error)
+	CreateTable(ctx context.Context, params *dynamodb.CreateTableInput, optFns ...func(*dynamodb.Options)) (*dynamodb.CreateTableOutput, error)
+	UpdateTable(ctx context.Context, params *dynamodb.UpdateTableInput, optFns ...func(*dynamodb.Options)) (*dynamodb.UpdateTableOutput, error)
+}
+
+// DynamoUrlStore implements UrlStoreInterface using a DynamoDB table keyed by the short ID in
+// its id attribute. Items use the attribute names of the SQL columns; unset fields are left out,
+// since an empty original_url cannot be stored in the original_url-index.
+//
+// DynamoDB cannot sort a table by its partition key, so listings, tag lookups and searches scan
+// the whole table and sort in memory. They suit tables of up to some hundred thousand links.
+type DynamoUrlStore struct {
+	client DynamoAPI
+	table  string
+}
+
+// NewDynamoUrlStore creates a new DynamoUrlStore on table.
+func NewDynamoUrlStore(client DynamoAPI, table string) *DynamoUrlStore {
+	return &DynamoUrlStore{client: client, table: table}
+}
+
+// EnsureIndexes creates the table if it does not exist, with on-demand capacity, and the
+// original_url-index global secondary index if the table lacks it. A new table is waited for;
+// an index added to an existing table is backfilled in the background.
+func (s *DynamoUrlStore) EnsureIndexes(ctx context.Context) error {
+	desc, err := s.client.DescribeTable(ctx, &dynamodb.DescribeTableInput{TableName: aws.String(s.table)})
+	var notFound *types.ResourceNotFoundException
+	if errors.As(err, &notFound) {
+		return s.createTable(ctx)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to describe DynamoDB table '%s': %w", s.table, err)
+	}
+
+	for _, index := range desc.Table.GlobalSecondaryIndexes {
+		if aws.ToString(index.IndexName) == dynamoOriginalURLIndex {
+			slog.InfoContext(ctx, "Ensured DynamoDB indexes", slog.String("table", s.table))
+			return nil
+		}
+	}
+	gsi := &types.CreateGlobalSecondaryIndexAction{
+		IndexName:  aws.String(dynamoOriginalURLIndex),
+		KeySchema:  []types.KeySchemaElement{{AttributeName: aws.String("original_url"), KeyType: types.KeyTypeHash}},
+		Projection: &types.Projection{ProjectionType: types.ProjectionTypeAll},
+	}
+	// A provisioned table needs the index's capacity spelled out; it gets the table's own.
+	if desc.Table.BillingModeSummary == nil || desc.Table.BillingModeSummary.BillingMode != types.BillingModePayPerRequest {
+		if throughput := desc.Table.ProvisionedThroughput; throughput != nil && aws.ToInt64(throughput.ReadCapacityUnits) > 0 {
+			gsi.ProvisionedThroughput = &types.ProvisionedThroughput{
+				ReadCapacityUnits:  throughput.ReadCapacityUnits,
+				WriteCapacityUnits: throughput.WriteCapacityUnits,
+			}
+		}
+	}
+	_, err = s.client.UpdateTable(ctx, &dynamodb.UpdateTableInput{
+		TableName:                   aws.String(s.table),
+		AttributeDefinitions:        []types.AttributeDefinition{{AttributeName: aws.String("original_url"), AttributeType: types.ScalarAttributeTypeS}},
+		GlobalSecondaryIndexUpdates: []types.GlobalSecondaryIndexUpdate{{Create: gsi}},
+	})
+	if err != nil {
+		return fmt.Errorf("failed to create index on original_url: %w", err)
+	}
+	slog.InfoContext(ctx, "Creating DynamoDB index on original_url; it is backfilled in the background", slog.String("table", s.table))
+	return nil
+}
+
+// createTable creates the URL table with its index and waits until it is active.
+func (s *DynamoUrlStore) createTable(ctx context.Context) error {
+	_, err := s.client.CreateTable(ctx, &dynamodb.CreateTableInput{
+		TableName:   aws.String(s.table),
+		BillingMode: types.BillingModePayPerRequest,
+		AttributeDefinitions: []types.AttributeDefinition{
+			{AttributeName: aws.String("id"), AttributeType: types.ScalarAttributeTypeS},
+			{AttributeName: aws.String("original_url"), AttributeType: types.ScalarAttributeTypeS},
+		},
+		KeySchema: []types.KeySchemaElement{{AttributeName: aws.String("id"), KeyType: types.KeyTypeHash}},
+		GlobalSecondaryIndexes: []types.GlobalSecondaryIndex{{
+			IndexName:  aws.String(dynamoOriginalURLIndex),
+			KeySchema:  []types.KeySchemaElement{{AttributeName: aws.String("original_url"), KeyType: types.KeyTypeHash}},
+			Projection: &types.Projection{ProjectionType: types.ProjectionTypeAll},
+		}},
+	})
+	if err != nil {
+		return fmt.Errorf("failed to create DynamoDB table '%s': %w", s.table, err)
+	}
+	waiter := dynamodb.NewTableExistsWaiter(s.client)
+	if err := waiter.Wait(ctx, &dynamodb.DescribeTableInput{TableName: aws.String(s.table)}, 2*time.Minute); err != nil {
+		return fmt.Errorf("DynamoDB table '%s' did not become active: %w", s.table, err)
+	}
+	slog.InfoContext(ctx, "Created DynamoDB table", slog.String("table", s.table))
+	return nil
+}
+
+// Save inserts a new URL entry with a conditional put, returning ErrDuplicateShortID if the ID is already present.
+func (s *DynamoUrlStore) Save(ctx context.Context, urlEntry domain.URL) error {
+	item, err := urlToItem(urlEntry)
+	if err != nil {
+		return err
+	}
+	_, err = s.client.PutItem(ctx, &dynamodb.PutItemInput{
+		TableName:                aws.String(s.table),
+		Item:                     item,
+		ConditionExpression:      aws.String("attribute_not_exists(#id)"),
+		ExpressionAttributeNames: map[string]string{"#id": "id"},
+	})
+	if isConditionFailed(err) {
+		return ErrDuplicateShortID
+	}
+	if err != nil {
+		return fmt.Errorf("failed to insert URL into DynamoDB: %w", err)
+	}
+	return nil
+}
+
+// GetByShortID retrieves a URL entry by its short ID, following an alias to its primary.
+func (s *DynamoUrlStore) GetByShortID(ctx context.Context, shortID, tenantID string) (domain.URL, error) {
+	return followAlias(ctx, shortID, tenantID, s.getByShortID)
+}
+
+// getByShortID retrieves the item with the short ID as stored, aliases included. The read is
+// strongly consistent, so a link can be followed as soon as it is created.
+func (s *DynamoUrlStore) getByShortID(ctx context.Context, shortID, tenantID string) (domain.URL, error) {
+	out, err := s.client.GetItem(ctx, &dynamodb.GetItemInput{
+		TableName:      aws.String(s.table),
+		Key:            dynamoKey(shortID),
+		ConsistentRead: aws.Bool(true),
+	})
+	if err != nil {
+		return domain.URL{}, fmt.Errorf("error retrieving URL from DynamoDB: %w", err)
+	}
+	if out.Item == nil {
+		return domain.URL{}, errNotFound(shortID)
+	}
+	urlEntry, err := itemToURL(out.Item)
+	if err != nil {
+		return domain.URL{}, err
+	}
+	if !belongsTo(urlEntry, tenantID) {
+		return domain.URL{}, errNotFound(shortID)
+	}
+	if urlEntry.IsDeleted() {
+		return domain.URL{}, fmt.Errorf("%w: '%s'", ErrURLDeleted, shortID)
+	}
+	return urlEntry, nil
+}
+
+// GetByOriginalURL retrieves an (undeleted) URL entry by its original URL through the
+// original_url-index. Index reads are eventually consistent, so a URL shortened a moment ago may
+// not be found yet. It returns ErrURLNotFound if the URL has not been shortened.
+func (s *DynamoUrlStore) GetByOriginalURL(ctx context.Context, originalURL string) (domain.URL, error) {
+	input := &dynamodb.QueryInput{
+		TableName:                 aws.String(s.table),
+		IndexName:                 aws.String(dynamoOriginalURLIndex),
+		KeyConditionExpression:    aws.String("#original_url = :url"),
+		FilterExpression:          aws.String("attribute_not_exists(#deleted_at)"),
+		ExpressionAttributeNames:  map[string]string{"#original_url": "original_url", "#deleted_at": "deleted_at"},
+		ExpressionAttributeValues: map[string]types.AttributeValue{":url": &types.AttributeValueMemberS{Value: originalURL}},
+	}
+	// The filter applies after each page is read, so a page can come back empty with more to follow.
+	for {
+		out, err := s.client.Query(ctx, input)
+		if err != nil {
+			return domain.URL{}, fmt.Errorf("error retrieving URL by original URL from DynamoDB: %w", err)
+		}
+		if len(out.Items) > 0 {
+			return itemToURL(out.Items[0])
+		}
+		if out.LastEvaluatedKey == nil {
+			return domain.URL{}, fmt.Errorf("%w: no entry for '%s'", ErrURLNotFound, originalURL)
+		}
+		input.ExclusiveStartKey = out.LastEvaluatedKey
+	}
+}
+
+// EstimatedCount returns the item count DynamoDB reports for the table, which it refreshes about
+// every six hours. Soft-deleted entries are included.
+func (s *DynamoUrlStore) EstimatedCount(ctx context.Context) (int64, error) {
+	desc, err := s.client.DescribeTable(ctx, &dynamodb.DescribeTableInput{TableName: aws.String(s.table)})
+	if err != nil {
+		return 0, fmt.Errorf("failed to describe DynamoDB table '%s': %w", s.table, err)
+	}
+	return aws.ToInt64(desc.Table.ItemCount), nil
+}
+
+// IncrementClickCount atomically increments the click counter of a URL entry and records the access time.
+// Click-limited entries are only incremented while they have clicks left; once they are used up it
+// returns ErrClickLimitReached.
+func (s *DynamoUrlStore) IncrementClickCount(ctx context.Context, shortID string, mobile bool) (int64, error) {
+	update := "ADD #click_count :one SET #last_accessed_at = :now"
+	names := map[string]string{"#id": "id", "#click_count": "click_count", "#max_clicks": "max_clicks", "#last_accessed_at": "last_accessed_at"}
+	if mobile {
+		update = "ADD #click_count :one, #mobile_click_count :one SET #last_accessed_at = :now"
+		names["#mobile_click_count"] = "mobile_click_count"
+	}
+	out, err := s.client.UpdateItem(ctx, &dynamodb.UpdateItemInput{
+		TableName:                aws.String(s.table),
+		Key:                      dynamoKey(shortID),
+		UpdateExpression:         aws.String(update),
+		ConditionExpression:      aws.String("attribute_exists(#id) AND (attribute_not_exists(#max_clicks) OR #click_count < #max_clicks)"),
+		ExpressionAttributeNames: names,
+		ExpressionAttributeValues: map[string]types.AttributeValue{
+			":one": dynamoNumber(1),
+			":now": dynamoTime(time.Now().UTC()),
+		},
+		ReturnValues: types.ReturnValueUpdatedNew,
+	})
+	if isConditionFailed(err) {
+		// Either the entry does not exist or its limit filtered it out.
+		got, err := s.client.GetItem(ctx, &dynamodb.GetItemInput{
+			TableName:                aws.String(s.table),
+			Key:                      dynamoKey(shortID),
+			ProjectionExpression:     aws.String("#id"),
+			ExpressionAttributeNames: map[string]string{"#id": "id"},
+		})
+		if err != nil {
+			return 0, fmt.Errorf("failed to check click-limited URL in DynamoDB: %w", err)
+		}
+		if got.Item != nil {
+			return 0, fmt.Errorf("%w: '%s'", ErrClickLimitReached, shortID)
+		}
+		return 0, fmt.Errorf("%w: '%s'", ErrURLNotFound, shortID)
+	}
+	if err != nil {
+		return 0, fmt.Errorf("failed to increment click count in DynamoDB: %w", err)
+	}
+	var updated dynamoItem
+	if err := attributevalue.UnmarshalMap(out.Attributes, &updated); err != nil {
+		return 0, fmt.Errorf("failed to decode click count from DynamoDB: %w", err)
+	}
+	return updated.ClickCount, nil
+}
+
+// IncrementConversionCount atomically increments the conversion counter of a URL entry.
+func (s *DynamoUrlStore) IncrementConversionCount(ctx context.Context, shortID string) error {
+	_, err := s.client.UpdateItem(ctx, &dynamodb.UpdateItemInput{
+		TableName:                 aws.String(s.table),
+		Key:                       dynamoKey(shortID),
+		UpdateExpression:          aws.String("ADD #conversion_count :one"),
+		ConditionExpression:       aws.String("attribute_exists(#id)"),
+		ExpressionAttributeNames:  map[string]string{"#id": "id", "#conversion_count": "conversion_count"},
+		ExpressionAttributeValues: map[string]types.AttributeValue{":one": dynamoNumber(1)},
+	})
+	return s.updateResult(err, shortID, "increment conversion count")
+}
+
+// List returns a page of URL entries in id order, starting after cursor, and the cursor of the next page.
+func (s *DynamoUrlStore) List(ctx context.Context, tenantID, cursor string, limit int64, includeDeleted bool) ([]domain.URL, string, error) {
+	after, err := decodeCursor(cursor)
+	if err != nil {
+		return nil, "", err
+	}
+	var (
+		conditions []string
+		names      = map[string]string{}
+		values     = map[string]types.AttributeValue{}
+	)
+	if !includeDeleted {
+		conditions = append(conditions, "attribute_not_exists(#deleted_at)")
+		names["#deleted_at"] = "deleted_at"
+	}
+	if tenantID != AnyTenant {
+		conditions = append(conditions, "#tenant_id = :tenant")
+		names["#tenant_id"] = "tenant_id"
+		values[":tenant"] = &types.AttributeValueMemberS{Value: tenantID}
+	}
+	if after != "" {
+		conditions = append(conditions, "#id > :after")
+		names["#id"] = "id"
+		values[":after"] = &types.AttributeValueMemberS{Value: after}
+	}
+
+	urls, err := s.scanURLs(ctx, strings.Join(conditions, " AND "), names, values)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to list URLs from DynamoDB: %w", err)
+	}
+	// One extra entry tells whether another page follows.
+	urls, next := nextPage(urls[:min(int64(len(urls)), limit+1)], limit)
+	return urls, next, nil
+}
+
+// ForEach calls fn for every entry in id order. Only the short IDs are held in memory: they are
+// scanned and sorted first, then the entries are fetched in batches.
+func (s *DynamoUrlStore) ForEach(ctx context.Context, fn func(domain.URL) error) error {
+	var ids []string
+	err := s.scan(ctx, &dynamodb.ScanInput{
+		TableName:                aws.String(s.table),
+		ProjectionExpression:     aws.String("#id"),
+		ExpressionAttributeNames: map[string]string{"#id": "id"},
+	}, func(item map[string]types.AttributeValue) error {
+		var key dynamoItem
+		err := attributevalue.UnmarshalMap(item, &key)
+		ids = append(ids, key.ID)
+		return err
+	})
+	if err != nil {
+		return fmt.Errorf("failed to read URLs from DynamoDB: %w", err)
+	}
+	slices.Sort(ids)
+
+	for batch := range slices.Chunk(ids, dynamoBatchGetSize) {
+		urls, err := s.batchGet(ctx, batch)
+		if err != nil {
+			return fmt.Errorf("failed to read URLs from DynamoDB: %w", err)
+		}
+		for _, urlEntry := range urls {
+			if err := fn(urlEntry); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// batchGet returns the entries with the short IDs in id order, retrying keys DynamoDB leaves unprocessed.
+// Entries deleted since the IDs were read are skipped.
+func (s *DynamoUrlStore) batchGet(ctx context.Context, ids []string) ([]domain.URL, error) {
+	keys := make([]map[string]types.AttributeValue, 0, len(ids))
+	for _, id := range ids {
+		keys = append(keys, dynamoKey(id))
+	}
+	request := map[string]types.KeysAndAttributes{s.table: {Keys: keys, ConsistentRead: aws.Bool(true)}}
+
+	urls := make([]domain.URL, 0, len(ids))
+	for len(request) > 0 {
+		out, err := s.client.BatchGetItem(ctx, &dynamodb.BatchGetItemInput{RequestItems: request})
+		if err != nil {
+			return nil, err
+		}
+		for _, item := range out.Responses[s.table] {
+			urlEntry, err := itemToURL(item)
+			if err != nil {
+				return nil, err
+			}
+			urls = append(urls, urlEntry)
+		}
+		request = out.UnprocessedKeys
+	}
+	sortByID(urls)
+	return urls, nil
+}
+
+// Delete removes a URL entry, or marks it with deleted_at in soft-delete builds.
+// It returns ErrURLNotFound if no (undeleted) entry has the short ID.
+func (s *DynamoUrlStore) Delete(ctx context.Context, shortID string) error {
+	if SoftDelete {
+		_, err := s.client.UpdateItem(ctx, &dynamodb.UpdateItemInput{
+			TableName:                 aws.String(s.table),
+			Key:                       dynamoKey(shortID),
+			UpdateExpression:          aws.String("SET #deleted_at = :now"),
+			ConditionExpression:       aws.String("attribute_exists(#id) AND attribute_not_exists(#deleted_at)"),
+			ExpressionAttributeNames:  map[string]string{"#id": "id", "#deleted_at": "deleted_at"},
+			ExpressionAttributeValues: map[string]types.AttributeValue{":now": dynamoTime(time.Now().UTC())},
+		})
+		return s.updateResult(err, shortID, "soft-delete URL")
+	}
+
+	_, err := s.client.DeleteItem(ctx, &dynamodb.DeleteItemInput{
+		TableName:                aws.String(s.table),
+		Key:                      dynamoKey(shortID),
+		ConditionExpression:      aws.String("attribute_exists(#id)"),
+		ExpressionAttributeNames: map[string]string{"#id": "id"},
+	})
+	return s.updateResult(err, shortID, "delete URL")
+}
+
+// Undelete removes deleted_at from a soft-deleted entry.
+// It returns ErrURLNotFound if no deleted entry has the short ID.
+func (s *DynamoUrlStore) Undelete(ctx context.Context, shortID string) error {
+	_, err := s.client.UpdateItem(ctx, &dynamodb.UpdateItemInput{
+		TableName:                aws.String(s.table),
+		Key:                      dynamoKey(shortID),
+		UpdateExpression:         aws.String("REMOVE #deleted_at"),
+		ConditionExpression:      aws.String("attribute_exists(#deleted_at)"),
+		ExpressionAttributeNames: map[string]string{"#deleted_at": "deleted_at"},
+	})
+	return s.updateResult(err, shortID, "undelete URL")
+}
+
+// Update changes the original URL of an entry and records the modification time.
+// It returns ErrURLNotFound if no (undeleted) entry has the short ID.
+func (s *DynamoUrlStore) Update(ctx context.Context, shortID, newOriginalURL string) error {
+	return s.updateLive(ctx, shortID, "update URL", "SET #original_url = :url, #updated_at = :now",
+		map[string]string{"#original_url": "original_url", "#updated_at": "updated_at"},
+		map[string]types.AttributeValue{
+			":url": &types.AttributeValueMemberS{Value: newOriginalURL},
+			":now": dynamoTime(time.Now().UTC()),
+		})
+}
+
+// UpdateTags replaces the tags of an entry and records the modification time.
+// It returns ErrURLNotFound if no (undeleted) entry has the short ID.
+func (s *DynamoUrlStore) UpdateTags(ctx context.Context, shortID string, tags []string) error {
+	names := map[string]string{"#tags": "tags", "#updated_at": "updated_at"}
+	values := map[string]types.AttributeValue{":now": dynamoTime(time.Now().UTC())}
+	if len(tags) == 0 {
+		return s.updateLive(ctx, shortID, "update tags", "SET #updated_at = :now REMOVE #tags", names, values)
+	}
+	values[":tags"] = dynamoStringList(tags)
+	return s.updateLive(ctx, shortID, "update tags", "SET #tags = :tags, #updated_at = :now", names, values)
+}
+
+// UpdateMetadata sets the page title and description of an entry.
+// It returns ErrURLNotFound if no (undeleted) entry has the short ID.
+func (s *DynamoUrlStore) UpdateMetadata(ctx context.Context, shortID, title, description string) error {
+	return s.updateLive(ctx, shortID, "update page metadata", "SET #page_title = :title, #page_description = :description",
+		map[string]string{"#page_title": "page_title", "#page_description": "page_description"},
+		map[string]types.AttributeValue{
+			":title":       &types.AttributeValueMemberS{Value: title},
+			":description": &types.AttributeValueMemberS{Value: description},
+		})
+}
+
+// updateLive applies update to an existing, undeleted entry. names and values are extended with
+// the attributes of the condition.
+func (s *DynamoUrlStore) updateLive(ctx context.Context, shortID, action, update string, names map[string]string, values map[string]types.AttributeValue) error {
+	names["#id"] = "id"
+	names["#deleted_at"] = "deleted_at"
+	_, err := s.client.UpdateItem(ctx, &dynamodb.UpdateItemInput{
+		TableName:                 aws.String(s.table),
+		Key:                       dynamoKey(shortID),
+		UpdateExpression:          aws.String(update),
+		ConditionExpression:       aws.String("attribute_exists(#id) AND attribute_not_exists(#deleted_at)"),
+		ExpressionAttributeNames:  names,
+		ExpressionAttributeValues: values,
+	})
+	return s.updateResult(err, shortID, action)
+}
+
+// updateResult maps the error of a conditional write to ErrURLNotFound when its condition failed.
+func (s *DynamoUrlStore) updateResult(err error, shortID, action string) error {
+	if isConditionFailed(err) {
+		return fmt.Errorf("%w: '%s'", ErrURLNotFound, shortID)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to %s in DynamoDB: %w", action, err)
+	}
+	return nil
+}
+
+// ListByTag returns a page of undeleted entries carrying tag in id order, along with their total.
+func (s *DynamoUrlStore) ListByTag(ctx context.Context, tag string, limit, offset int64) ([]domain.URL, int64, error) {
+	urls, err := s.scanURLs(ctx, "attribute_not_exists(#deleted_at) AND contains(#tags, :tag)",
+		map[string]string{"#deleted_at": "deleted_at", "#tags": "tags"},
+		map[string]types.AttributeValue{":tag": &types.AttributeValueMemberS{Value: tag}})
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to list URLs by tag from DynamoDB: %w", err)
+	}
+	total := int64(len(urls))
+	start := min(offset, total)
+	return urls[start:min(start+limit, total)], total, nil
+}
+
+// Search returns up to limit undeleted entries whose original URL contains query, ignoring case, in id order.
+// DynamoDB's contains is case-sensitive, so the match is made after the scan.
+func (s *DynamoUrlStore) Search(ctx context.Context, query string, limit int64) ([]domain.URL, error) {
+	urls, err := s.scanURLs(ctx, "attribute_not_exists(#deleted_at)", map[string]string{"#deleted_at": "deleted_at"}, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to search URLs in DynamoDB: %w", err)
+	}
+	query = strings.ToLower(query)
+	matches := []domain.URL{}
+	for _, urlEntry := range urls {
+		if int64(len(matches)) == limit {
+			break
+		}
+		if strings.Contains(strings.ToLower(urlEntry.OriginalUrl), query) {
+			matches = append(matches, urlEntry)
+		}
+	}
+	return matches, nil
+}
+
+// ListAliases returns the short IDs of the (undeleted) aliases of primaryID.
+func (s *DynamoUrlStore) ListAliases(ctx context.Context, primaryID string) ([]string, error) {
+	urls, err := s.scanURLs(ctx, "#alias_of = :primary AND attribute_not_exists(#deleted_at)",
+		map[string]string{"#alias_of": "alias_of", "#deleted_at": "deleted_at"},
+		map[string]types.AttributeValue{":primary": &types.AttributeValueMemberS{Value: primaryID}})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list aliases in DynamoDB: %w", err)
+	}
+	aliases := make([]string, 0, len(urls))
+	for _, urlEntry := range urls {
+		aliases = append(aliases, urlEntry.ID)
+	}
+	return aliases, nil
+}
+
+// Ping checks that the table can be described with the configured credentials.
+func (s *DynamoUrlStore) Ping(ctx context.Context) error {
+	if _, err := s.client.DescribeTable(ctx, &dynamodb.DescribeTableInput{TableName: aws.String(s.table)}); err != nil {
+		return fmt.Errorf("dynamodb table '%s' is not reachable: %w", s.table, err)
+	}
+	return nil
+}
+
+// Ready checks that the table exists and can serve requests, which it can while active or
+// while an update such as an index backfill is in progress.
+func (s *DynamoUrlStore) Ready(ctx context.Context) error {
+	desc, err := s.client.DescribeTable(ctx, &dynamodb.DescribeTableInput{TableName: aws.String(s.table)})
+	if err != nil {
+		return fmt.Errorf("failed to describe DynamoDB table '%s': %w", s.table, err)
+	}
+	if status := desc.Table.TableStatus; status != types.TableStatusActive && status != types.TableStatusUpdating {
+		return fmt.Errorf("dynamodb table '%s' is %s", s.table, status)
+	}
+	return nil
+}
+
+// scanURLs scans the table for the entries matching filter, a filter expression that may be
+// empty, and returns them in id order.
+func (s *DynamoUrlStore) scanURLs(ctx context.Context, filter string, names map[string]string, values map[string]types.AttributeValue) ([]domain.URL, error) {
+	input := &dynamodb.ScanInput{TableName: aws.String(s.table)}
+	if filter != "" {
+		input.FilterExpression = aws.String(filter)
+		input.ExpressionAttributeNames = names
+	}
+	if len(values) > 0 {
+		input.ExpressionAttributeValues = values
+	}
+	urls := []domain.URL{}
+	err := s.scan(ctx, input, func(item map[string]types.AttributeValue) error {
+		urlEntry, err := itemToURL(item)
+		if err != nil {
+			return err
+		}
+		urls = append(urls, urlEntry)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	sortByID(urls)
+	return urls, nil
+}
+
+// scan calls fn for every item input matches, reading the table page by page.
+func (s *DynamoUrlStore) scan(ctx context.Context, input *dynamodb.ScanInput, fn func(map[string]types.AttributeValue) error) error {
+	for {
+		out, err := s.client.Scan(ctx, input)
+		if err != nil {
+			return err
+		}
+		for _, item := range out.Items {
+			if err := fn(item); err != nil {
+				return err
+			}
+		}
+		if out.LastEvaluatedKey == nil {
+			return nil
+		}
+		input.ExclusiveStartKey = out.LastEvaluatedKey
+	}
+}
+
+// sortByID sorts entries by short ID, the order every listing returns.
+func sortByID(urls []domain.URL) {
+	slices.SortFunc(urls, func(a, b domain.URL) int { return strings.Compare(a.ID, b.ID) })
+}
+
+// isConditionFailed reports whether a write was rejected because its condition expression failed.
+func isConditionFailed(err error) bool {
+	var condErr *types.ConditionalCheckFailedException
+	return errors.As(err, &condErr)
+}
+
+// dynamoKey returns the primary key of the item with the short ID.
+func dynamoKey(shortID string) map[string]types.AttributeValue {
+	return map[string]types.AttributeValue{"id": &types.AttributeValueMemberS{Value: shortID}}
+}
+
+// dynamoNumber encodes an integer as a DynamoDB number.
+func dynamoNumber(n int64) types.AttributeValue {
+	return &types.AttributeValueMemberN{Value: strconv.FormatInt(n, 10)}
+}
+
+// dynamoTime encodes a time as an RFC 3339 string, which sorts in time order for times in UTC.
+func dynamoTime(t time.Time) types.AttributeValue {
+	return &types.AttributeValueMemberS{Value: t.UTC().Format(time.RFC3339Nano)}
+}
+
+// dynamoStringList encodes strings as a DynamoDB list, which keeps their order unlike a string set.
+func dynamoStringList(values []string) types.AttributeValue {
+	list := make([]types.AttributeValue, 0, len(values))
+	for _, v := range values {
+		list = append(list, &types.AttributeValueMemberS{Value: v})
+	}
+	return &types.AttributeValueMemberL{Value: list}
+}
+
+// dynamoItem is the layout of a URL entry in the table, encoded and decoded with the attributevalue
+// package. Empty strings, nil pointers and empty collections are left out; times are RFC 3339
+// strings in UTC, and UTM parameters, variants and custom headers are stored as JSON strings,
+// like the JSON columns of the SQL stores.
+type dynamoItem struct {
+	ID              string     `dynamodbav:"id"`
+	OriginalURL     string     `dynamodbav:"original_url,omitempty"`
+	SubmittedURL    string     `dynamodbav:"submitted_url,omitempty"`
+	ShortURL        string     `dynamodbav:"short_url,omitempty"`
+	RedirectType    string     `dynamodbav:"redirect_type,omitempty"`
+	PasswordHash    string     `dynamodbav:"password_hash,omitempty"`
+	WebhookURL      string     `dynamodbav:"webhook_url,omitempty"`
+	PageTitle       string     `dynamodbav:"page_title,omitempty"`
+	PageDescription string     `dynamodbav:"page_description,omitempty"`
+	TenantID        string     `dynamodbav:"tenant_id,omitempty"`
+	BundleTitle     string     `dynamodbav:"bundle_title,omitempty"`
+	Domain          string     `dynamodbav:"domain,omitempty"`
+	HashAlgo        string     `dynamodbav:"hash_algo,omitempty"`
+	CreationDate    time.Time  `dynamodbav:"creation_date"`
+	ClickCount      int64      `dynamodbav:"click_count"`
+	MobileClicks    int64      `dynamodbav:"mobile_click_count"`
+	ConversionCount int64      `dynamodbav:"conversion_count"`
+	ExpiresAt       *time.Time `dynamodbav:"expires_at,omitempty"`
+	LastAccessedAt  *time.Time `dynamodbav:"last_accessed_at,omitempty"`
+	UpdatedAt       *time.Time `dynamodbav:"updated_at,omitempty"`
+	DeletedAt       *time.Time `dynamodbav:"deleted_at,omitempty"`
+	ActiveFrom      *time.Time `dynamodbav:"active_from,omitempty"`
+	ActiveUntil     *time.Time `dynamodbav:"active_until,omitempty"`
+	MaxClicks       *int64     `dynamodbav:"max_clicks,omitempty"`
+	MobileURL       *string    `dynamodbav:"mobile_url,omitempty"`
+	AliasOf         *string    `dynamodbav:"alias_of,omitempty"`
+	Bundle          bool       `dynamodbav:"bundle,omitempty"`
+	Tags            []string   `dynamodbav:"tags,omitempty"`
+	UTM             string     `dynamodbav:"utm,omitempty"`
+	Variants        string     `dynamodbav:"variants,omitempty"`
+	CustomHeaders   string     `dynamodbav:"custom_headers,omitempty"`
+}
+
+// urlToItem encodes a URL entry as a DynamoDB item.
+func urlToItem(urlEntry domain.URL) (map[string]types.AttributeValue, error) {
+	item := dynamoItem{
+		ID:              urlEntry.ID,
+		OriginalURL:     urlEntry.OriginalUrl,
+		SubmittedURL:    urlEntry.SubmittedUrl,
+		ShortURL:        urlEntry.ShortUrl,
+		RedirectType:    urlEntry.RedirectType,
+		PasswordHash:    urlEntry.PasswordHash,
+		WebhookURL:      urlEntry.WebhookURL,
+		PageTitle:       urlEntry.PageTitle,
+		PageDescription: urlEntry.PageDescription,
+		TenantID:        urlEntry.TenantID,
+		BundleTitle:     urlEntry.BundleTitle,
+		Domain:          urlEntry.Domain,
+		HashAlgo:        urlEntry.HashAlgo,
+		CreationDate:    urlEntry.CreationDate.UTC(),
+		ClickCount:      urlEntry.ClickCount,
+		MobileClicks:    urlEntry.MobileClicks,
+		ConversionCount: urlEntry.ConversionCount,
+		ExpiresAt:       utcTime(urlEntry.ExpiresAt),
+		LastAccessedAt:  utcTime(urlEntry.LastAccessedAt),
+		UpdatedAt:       utcTime(urlEntry.UpdatedAt),
+		DeletedAt:       utcTime(urlEntry.DeletedAt),
+		ActiveFrom:      utcTime(urlEntry.ActiveFrom),
+		ActiveUntil:     utcTime(urlEntry.ActiveUntil),
+		MaxClicks:       urlEntry.MaxClicks,
+		MobileURL:       urlEntry.MobileURL,
+		AliasOf:         urlEntry.AliasOf,
+		Bundle:          urlEntry.Bundle,
+	}
+	if len(urlEntry.Tags) > 0 {
+		item.Tags = urlEntry.Tags
+	}
+	var err error
+	if urlEntry.UTM != nil {
+		if item.UTM, err = encodeJSON("utm", urlEntry.UTM); err != nil {
+			return nil, err
+		}
+	}
+	if len(urlEntry.Variants) > 0 {
+		if item.Variants, err = encodeJSON("variants", urlEntry.Variants); err != nil {
+			return nil, err
+		}
+	}
+	if len(urlEntry.CustomHeaders) > 0 {
+		if item.CustomHeaders, err = encodeJSON("custom_headers", urlEntry.CustomHeaders); err != nil {
+			return nil, err
+		}
+	}
+	encoded, err := attributevalue.MarshalMap(item)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode URL item: %w", err)
+	}
+	return encoded, nil
+}
+
+// itemToURL decodes a DynamoDB item written by urlToItem.
+func itemToURL(encoded map[string]types.AttributeValue) (domain.URL, error) {
+	var item dynamoItem
+	if err := attributevalue.UnmarshalMap(encoded, &item); err != nil {
+		return domain.URL{}, fmt.Errorf("failed to decode URL item: %w", err)
+	}
+	urlEntry := domain.URL{
+		ID:              item.ID,
+		OriginalUrl:     item.OriginalURL,
+		SubmittedUrl:    item.SubmittedURL,
+		ShortUrl:        item.ShortURL,
+		RedirectType:    item.RedirectType,
+		PasswordHash:    item.PasswordHash,
+		WebhookURL:      item.WebhookURL,
+		PageTitle:       item.PageTitle,
+		PageDescription: item.PageDescription,
+		TenantID:        item.TenantID,
+		BundleTitle:     item.BundleTitle,
+		Domain:          item.Domain,
+		HashAlgo:        item.HashAlgo,
+		CreationDate:    item.CreationDate.UTC(),
+		ClickCount:      item.ClickCount,
+		MobileClicks:    item.MobileClicks,
+		ConversionCount: item.ConversionCount,
+		ExpiresAt:       utcTime(item.ExpiresAt),
+		LastAccessedAt:  utcTime(item.LastAccessedAt),
+		UpdatedAt:       utcTime(item.UpdatedAt),
+		DeletedAt:       utcTime(item.DeletedAt),
+		ActiveFrom:      utcTime(item.ActiveFrom),
+		ActiveUntil:     utcTime(item.ActiveUntil),
+		MaxClicks:       item.MaxClicks,
+		MobileURL:       item.MobileURL,
+		AliasOf:         item.AliasOf,
+		Bundle:          item.Bundle,
+		Tags:            item.Tags,
+	}
+	if item.UTM != "" {
+		urlEntry.UTM = &domain.UTMParams{}
+		if err := decodeJSON("utm", item.UTM, urlEntry.UTM); err != nil {
+			return domain.URL{}, err
+		}
+	}
+	if err := decodeJSON("variants", item.Variants, &urlEntry.Variants); err != nil {
+		return domain.URL{}, err
+	}
+	if err := decodeJSON("custom_headers", item.CustomHeaders, &urlEntry.CustomHeaders); err != nil {
+		return domain.URL{}, err
+	}
+	return urlEntry, nil
+}
+
+// utcTime returns a copy of t in UTC, so stored times sort in time order, or nil if t is nil.
+func utcTime(t *time.Time) *time.Time {
+	if t == nil {
+		return nil
+	}
+	utc := t.UTC()
+	return &utc
+}
+
+// encodeJSON encodes value as the JSON string stored in the attribute name.
+func encodeJSON(name string, value any) (string, error) {
+	encoded, err := json.Marshal(value)
+	if err != nil {
+		return "", fmt.Errorf("failed to encode %s: %w", name, err)
+	}
+	return string(encoded), nil
+}
+
+// decodeJSON decodes the JSON string of the attribute name into out, leaving out untouched if it is empty.
+func decodeJSON(name, encoded string, out any) error {
+	if encoded == "" {
+		return nil
+	}
+	if err := json.Unmarshal([]byte(encoded), out); err != nil {
+		return fmt.Errorf("failed to decode %s attribute: %w", name, err)
+	}
+	return nil
+}
@@ -0,0 +1,134 @@
+package store
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+
+	"shawty/internal/domain"
+
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+// fakeDynamo keeps items in memory by id and implements the PutItem and GetItem calls of
+// DynamoAPI. PutItem honours the attribute_not_exists condition of Save; the other methods of
+// the embedded interface are nil and panic if called.
+type fakeDynamo struct {
+	DynamoAPI
+	mu    sync.Mutex
+	items map[string]map[string]types.AttributeValue
+}
+
+func newFakeDynamo() *fakeDynamo {
+	return &fakeDynamo{items: make(map[string]map[string]types.AttributeValue)}
+}
+
+func (f *fakeDynamo) PutItem(ctx context.Context, params *dynamodb.PutItemInput, optFns ...func(*dynamodb.Options)) (*dynamodb.PutItemOutput, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	id := params.Item["id"].(*types.AttributeValueMemberS).Value
+	if _, exists := f.items[id]; exists && params.ConditionExpression != nil {
+		return nil, &types.ConditionalCheckFailedException{Message: new(string)}
+	}
+	f.items[id] = params.Item
+	return &dynamodb.PutItemOutput{}, nil
+}
+
+func (f *fakeDynamo) GetItem(ctx context.Context, params *dynamodb.GetItemInput, optFns ...func(*dynamodb.Options)) (*dynamodb.GetItemOutput, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	id := params.Key["id"].(*types.AttributeValueMemberS).Value
+	return &dynamodb.GetItemOutput{Item: f.items[id]}, nil
+}
+
+func TestDynamoSaveRejectsDuplicateShortID(t *testing.T) {
+	ctx := context.Background()
+	dynamoStore := NewDynamoUrlStore(newFakeDynamo(), "urls")
+	first := domain.URL{ID: "dup001", ShortUrl: "dup001", OriginalUrl: "https://example.com/first", CreationDate: time.Now().UTC()}
+	if err := dynamoStore.Save(ctx, first); err != nil {
+		t.Fatalf("Save: %v", err)
+	}
+
+	second := first
+	second.OriginalUrl = "https://example.com/second"
+	if err := dynamoStore.Save(ctx, second); !errors.Is(err, ErrDuplicateShortID) {
+		t.Fatalf("saving a taken short ID: error = %v, want ErrDuplicateShortID", err)
+	}
+	got, err := dynamoStore.GetByShortID(ctx, "dup001", AnyTenant)
+	if err != nil {
+		t.Fatalf("GetByShortID: %v", err)
+	}
+	if got.OriginalUrl != first.OriginalUrl {
+		t.Errorf("original URL = %q after the rejected save, want %q", got.OriginalUrl, first.OriginalUrl)
+	}
+}
+
+func TestDynamoItemRoundTrip(t *testing.T) {
+	ctx := context.Background()
+	fake := newFakeDynamo()
+	dynamoStore := NewDynamoUrlStore(fake, "urls")
+	at := func(s string) *time.Time {
+		parsed, _ := time.Parse(time.RFC3339Nano, s)
+		return &parsed
+	}
+	maxClicks := int64(10)
+	mobileURL := "https://m.example.com/full"
+	want := domain.URL{
+		ID:              "full01",
+		OriginalUrl:     "https://example.com/full",
+		SubmittedUrl:    "example.com/full",
+		ShortUrl:        "full01",
+		CreationDate:    *at("2025-03-04T05:06:07.123456789Z"),
+		ExpiresAt:       at("2026-01-01T00:00:00Z"),
+		ClickCount:      7,
+		LastAccessedAt:  at("2025-03-05T00:00:00.5Z"),
+		RedirectType:    domain.RedirectTemporary,
+		PasswordHash:    "$2a$10$hash",
+		MaxClicks:       &maxClicks,
+		UTM:             &domain.UTMParams{Source: "newsletter", Campaign: "spring"},
+		Tags:            []string{"campaign-q4", "social"},
+		WebhookURL:      "https://hooks.example.com/first-click",
+		MobileURL:       &mobileURL,
+		MobileClicks:    3,
+		ConversionCount: 2,
+		PageTitle:       "Full",
+		TenantID:        "tenant-a",
+		Variants:        []domain.Variant{{Name: "b", URL: "https://example.com/b", Weight: 50}},
+		ActiveFrom:      at("2025-03-04T00:00:00Z"),
+		ActiveUntil:     at("2025-12-31T23:59:59Z"),
+		CustomHeaders:   map[string]string{"X-Robots-Tag": "noindex"},
+		Domain:          "go.example.com",
+		HashAlgo:        "sha256",
+	}
+	if err := dynamoStore.Save(ctx, want); err != nil {
+		t.Fatalf("Save: %v", err)
+	}
+	got, err := dynamoStore.GetByShortID(ctx, "full01", AnyTenant)
+	if err != nil {
+		t.Fatalf("GetByShortID: %v", err)
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("round trip changed the entry:\n got %+v\nwant %+v", got, want)
+	}
+
+	// The stored layout is the one existing tables hold.
+	item := fake.items["full01"]
+	if s, ok := item["creation_date"].(*types.AttributeValueMemberS); !ok || s.Value != "2025-03-04T05:06:07.123456789Z" {
+		t.Errorf("creation_date = %#v, want an RFC 3339 string", item["creation_date"])
+	}
+	if tags, ok := item["tags"].(*types.AttributeValueMemberL); !ok || len(tags.Value) != 2 {
+		t.Errorf("tags = %#v, want a list of two strings", item["tags"])
+	}
+	if s, ok := item["utm"].(*types.AttributeValueMemberS); !ok || s.Value != `{"source":"newsletter","campaign":"spring"}` {
+		t.Errorf("utm = %#v, want a JSON string", item["utm"])
+	}
+	for _, name := range []string{"page_description", "deleted_at", "alias_of", "bundle", "bundle_title"} {
+		if _, ok := item[name]; ok {
+			t.Errorf("the unset attribute %s is stored", name)
+		}
+	}
+}
@@ -0,0 +1,35 @@
+package store
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+// ExpiredURLStoreInterface is implemented by stores that can remove expired entries in bulk.
+type ExpiredURLStoreInterface interface {
+	// DeleteExpired removes every entry whose expiry is before now and returns how many were removed.
+	DeleteExpired(ctx context.Context, now time.Time) (int64, error)
+}
+
+// DeleteExpired removes every entry that expired, or whose activation window ended, before now. The
+// TTL indexes remove them as well, but only when MongoDB's reaper next runs, which may be a minute later. Expired entries are removed
+// outright, also in soft-delete builds, and are not recorded in the audit log.
+func (s *MongoUrlStore) DeleteExpired(ctx context.Context, now time.Time) (int64, error) {
+	ctx, span := tracer.Start(ctx, "store.DeleteExpired")
+	defer span.End()
+
+	filter := bson.M{"$or": bson.A{
+		bson.M{"expires_at": bson.M{"$lt": now, "$ne": nil}},
+		bson.M{"active_until": bson.M{"$lt": now, "$ne": nil}},
+	}}
+	result, err := s.collection.DeleteMany(ctx, filter)
+	if err != nil {
+		return 0, fmt.Errorf("failed to delete expired URLs from MongoDB: %w", err)
+	}
+	span.SetAttributes(attribute.Int64("deleted", result.DeletedCount))
+	return result.DeletedCount, nil
+}
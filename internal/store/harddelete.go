@@ -0,0 +1,8 @@
+//go:build harddelete
+
+package store
+
+// SoftDelete reports whether Delete marks entries with deleted_at instead of removing them.
+// Deleted links are kept so that they answer 410 Gone and can be restored with Undelete;
+// build with -tags harddelete to remove them from the database instead.
+const SoftDelete = false
@@ -0,0 +1,73 @@
+package store
+
+import (
+	"context"
+	"fmt"
+
+	"shawty/internal/domain"
+	"shawty/internal/tracing"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// HashAlgoStoreInterface is implemented by stores that can find entries by the hash their short ID
+// was derived with, for moving links from one hash algorithm to another.
+type HashAlgoStoreInterface interface {
+	// ListByHashAlgo returns up to limit entries whose hash_algo is one of algos, in short ID order,
+	// starting after afterID ("" for the first batch). An empty string in algos matches entries
+	// without a hash_algo. Aliases are never returned, deleted entries are.
+	ListByHashAlgo(ctx context.Context, algos []string, afterID string, limit int64) ([]domain.URL, error)
+	// SetHashAlgo sets the hash_algo of an entry. It returns ErrURLNotFound if no entry has the short ID.
+	SetHashAlgo(ctx context.Context, shortID, algo string) error
+}
+
+// ListByHashAlgo returns a batch of entries whose hash_algo is one of algos.
+func (s *MongoUrlStore) ListByHashAlgo(ctx context.Context, algos []string, afterID string, limit int64) ([]domain.URL, error) {
+	ctx, span := tracer.Start(ctx, "store.ListByHashAlgo", trace.WithAttributes(attribute.Int64("limit", limit)))
+	defer span.End()
+
+	values := make(bson.A, 0, len(algos))
+	for _, algo := range algos {
+		if algo == "" {
+			// A null in $in also matches documents that lack the field.
+			values = append(values, nil)
+			continue
+		}
+		values = append(values, algo)
+	}
+	filter := bson.M{"hash_algo": bson.M{"$in": values}, "alias_of": bson.M{"$exists": false}}
+	if afterID != "" {
+		filter["_id"] = bson.M{"$gt": afterID}
+	}
+
+	findOptions := options.Find().
+		SetSort(bson.D{{Key: "_id", Value: 1}}).
+		SetLimit(limit)
+	cursor, err := s.collection.Find(ctx, filter, findOptions)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list URLs by hash algorithm from MongoDB: %w", err)
+	}
+	urls := []domain.URL{}
+	if err := cursor.All(ctx, &urls); err != nil {
+		return nil, fmt.Errorf("failed to decode URLs listed by hash algorithm: %w", err)
+	}
+	return urls, nil
+}
+
+// SetHashAlgo sets the hash_algo of an entry with $set.
+func (s *MongoUrlStore) SetHashAlgo(ctx context.Context, shortID, algo string) error {
+	ctx, span := tracer.Start(ctx, "store.SetHashAlgo", trace.WithAttributes(attribute.String(tracing.AttrShortID, shortID)))
+	defer span.End()
+
+	result, err := s.collection.UpdateOne(ctx, bson.M{"_id": shortID}, bson.M{"$set": bson.M{"hash_algo": algo}})
+	if err != nil {
+		return fmt.Errorf("failed to update hash algorithm in MongoDB: %w", err)
+	}
+	if result.MatchedCount == 0 {
+		return fmt.Errorf("%w: '%s'", ErrURLNotFound, shortID)
+	}
+	return nil
+}
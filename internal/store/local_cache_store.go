@@ -0,0 +1,152 @@
+package store
+
+import (
+	"context"
+	"time"
+
+	"shawty/internal/cache"
+	"shawty/internal/domain"
+)
+
+// LocalCachedUrlStore decorates another UrlStoreInterface with an in-process LRU cache for
+// GetByShortID. It is the fallback for deployments without Redis: every instance keeps its own
+// cache, so changes made through another instance are only seen once the entry expires.
+// Like CachedUrlStore, IncrementClickCount does not invalidate cached entries.
+type LocalCachedUrlStore struct {
+	inner UrlStoreInterface
+	cache *cache.URLCache
+}
+
+// NewLocalCachedStore wraps inner with an in-process cache of at most size entries,
+// each kept for DefaultCacheTTL.
+func NewLocalCachedStore(inner UrlStoreInterface, size int) UrlStoreInterface {
+	return NewLocalCachedStoreWithTTL(inner, size, DefaultCacheTTL)
+}
+
+// NewLocalCachedStoreWithTTL wraps inner with an in-process cache of at most size entries, each kept for ttl.
+// A ttl of zero or less uses DefaultCacheTTL.
+func NewLocalCachedStoreWithTTL(inner UrlStoreInterface, size int, ttl time.Duration) UrlStoreInterface {
+	if ttl <= 0 {
+		ttl = DefaultCacheTTL
+	}
+	return &LocalCachedUrlStore{inner: inner, cache: cache.NewURLCache(size, ttl)}
+}
+
+// EnsureIndexes delegates to the inner store.
+func (s *LocalCachedUrlStore) EnsureIndexes(ctx context.Context) error {
+	return s.inner.EnsureIndexes(ctx)
+}
+
+// Save delegates to the inner store and evicts any cached entry for the short ID.
+func (s *LocalCachedUrlStore) Save(ctx context.Context, urlEntry domain.URL) error {
+	err := s.inner.Save(ctx, urlEntry)
+	s.cache.Remove(urlEntry.ID)
+	return err
+}
+
+// GetByShortID returns the cached entry if present, otherwise loads it from the inner store and caches it.
+// Lookups that fail, including those of deleted entries, are not cached. Entries are cached
+// regardless of tenant and checked against tenantID on the way out.
+func (s *LocalCachedUrlStore) GetByShortID(ctx context.Context, shortID, tenantID string) (domain.URL, error) {
+	urlEntry, ok := s.cache.Get(shortID)
+	if !ok {
+		var err error
+		urlEntry, err = s.inner.GetByShortID(ctx, shortID, AnyTenant)
+		if err != nil {
+			return domain.URL{}, err
+		}
+		s.cache.Add(urlEntry)
+	}
+	if !belongsTo(urlEntry, tenantID) {
+		return domain.URL{}, errNotFound(shortID)
+	}
+	return urlEntry, nil
+}
+
+// GetByOriginalURL delegates to the inner store; lookups by original URL are not cached.
+func (s *LocalCachedUrlStore) GetByOriginalURL(ctx context.Context, originalURL string) (domain.URL, error) {
+	return s.inner.GetByOriginalURL(ctx, originalURL)
+}
+
+// EstimatedCount delegates to the inner store.
+func (s *LocalCachedUrlStore) EstimatedCount(ctx context.Context) (int64, error) {
+	return s.inner.EstimatedCount(ctx)
+}
+
+// IncrementClickCount delegates to the inner store. The cached entry is left in place.
+func (s *LocalCachedUrlStore) IncrementClickCount(ctx context.Context, shortID string, mobile bool) (int64, error) {
+	return s.inner.IncrementClickCount(ctx, shortID, mobile)
+}
+
+// List delegates to the inner store; listings are not cached.
+func (s *LocalCachedUrlStore) List(ctx context.Context, tenantID, cursor string, limit int64, includeDeleted bool) ([]domain.URL, string, error) {
+	return s.inner.List(ctx, tenantID, cursor, limit, includeDeleted)
+}
+
+// ForEach delegates to the inner store.
+func (s *LocalCachedUrlStore) ForEach(ctx context.Context, fn func(domain.URL) error) error {
+	return s.inner.ForEach(ctx, fn)
+}
+
+// Delete delegates to the inner store and evicts any cached entry so the link stops resolving immediately.
+func (s *LocalCachedUrlStore) Delete(ctx context.Context, shortID string) error {
+	err := s.inner.Delete(ctx, shortID)
+	s.cache.Remove(shortID)
+	return err
+}
+
+// Undelete delegates to the inner store and evicts any cached entry.
+func (s *LocalCachedUrlStore) Undelete(ctx context.Context, shortID string) error {
+	err := s.inner.Undelete(ctx, shortID)
+	s.cache.Remove(shortID)
+	return err
+}
+
+// ListByTag delegates to the inner store; listings are not cached.
+func (s *LocalCachedUrlStore) ListByTag(ctx context.Context, tag string, limit, offset int64) ([]domain.URL, int64, error) {
+	return s.inner.ListByTag(ctx, tag, limit, offset)
+}
+
+// Search delegates to the inner store.
+func (s *LocalCachedUrlStore) Search(ctx context.Context, query string, limit int64) ([]domain.URL, error) {
+	return s.inner.Search(ctx, query, limit)
+}
+
+// ListAliases delegates to the inner store.
+func (s *LocalCachedUrlStore) ListAliases(ctx context.Context, primaryID string) ([]string, error) {
+	return s.inner.ListAliases(ctx, primaryID)
+}
+
+// UpdateTags delegates to the inner store and evicts any cached entry.
+func (s *LocalCachedUrlStore) UpdateTags(ctx context.Context, shortID string, tags []string) error {
+	err := s.inner.UpdateTags(ctx, shortID, tags)
+	s.cache.Remove(shortID)
+	return err
+}
+
+// IncrementConversionCount delegates to the inner store. Like the click count, the conversion count
+// of a cached entry may lag behind.
+func (s *LocalCachedUrlStore) IncrementConversionCount(ctx context.Context, shortID string) error {
+	return s.inner.IncrementConversionCount(ctx, shortID)
+}
+
+// UpdateMetadata delegates to the inner store and evicts any cached entry.
+func (s *LocalCachedUrlStore) UpdateMetadata(ctx context.Context, shortID, title, description string) error {
+	err := s.inner.UpdateMetadata(ctx, shortID, title, description)
+	s.cache.Remove(shortID)
+	return err
+}
+
+// Update delegates to the inner store and evicts any cached entry so redirects use the new destination.
+func (s *LocalCachedUrlStore) Update(ctx context.Context, shortID, newOriginalURL string) error {
+	err := s.inner.Update(ctx, shortID, newOriginalURL)
+	s.cache.Remove(shortID)
+	return err
+}
+
+// TransferOwnership delegates to the inner store and evicts any cached entry, which still names the previous tenant.
+func (s *LocalCachedUrlStore) TransferOwnership(ctx context.Context, shortID, newOwnerID string) (string, error) {
+	previousOwner, err := transferOwnership(ctx, s.inner, shortID, newOwnerID)
+	s.cache.Remove(shortID)
+	return previousOwner, err
+}
@@ -0,0 +1,170 @@
+package store
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"shawty/internal/domain"
+	"shawty/internal/metrics"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+// countingStore is a MemoryUrlStore that counts the GetByShortID calls reaching it.
+type countingStore struct {
+	*MemoryUrlStore
+	gets atomic.Int32
+}
+
+func (s *countingStore) GetByShortID(ctx context.Context, shortID, tenantID string) (domain.URL, error) {
+	s.gets.Add(1)
+	return s.MemoryUrlStore.GetByShortID(ctx, shortID, tenantID)
+}
+
+// newCountingStore returns a countingStore holding one entry, abc123 for https://example.com/a.
+func newCountingStore(t *testing.T) *countingStore {
+	t.Helper()
+	inner := &countingStore{MemoryUrlStore: NewMemoryUrlStore()}
+	entry := domain.URL{ID: "abc123", ShortUrl: "abc123", OriginalUrl: "https://example.com/a", CreationDate: time.Now().UTC()}
+	if err := inner.Save(context.Background(), entry); err != nil {
+		t.Fatalf("Save: %v", err)
+	}
+	return inner
+}
+
+func TestLocalCachedStoreServesRepeatedLookupsFromCache(t *testing.T) {
+	ctx := context.Background()
+	inner := newCountingStore(t)
+	cached := NewLocalCachedStore(inner, 10)
+	hits, misses := testutil.ToFloat64(metrics.CacheHits), testutil.ToFloat64(metrics.CacheMisses)
+
+	for range 5 {
+		urlEntry, err := cached.GetByShortID(ctx, "abc123", AnyTenant)
+		if err != nil {
+			t.Fatalf("GetByShortID: %v", err)
+		}
+		if urlEntry.OriginalUrl != "https://example.com/a" {
+			t.Fatalf("original URL = %q, want https://example.com/a", urlEntry.OriginalUrl)
+		}
+	}
+	if got := inner.gets.Load(); got != 1 {
+		t.Errorf("inner store was read %d times, want 1", got)
+	}
+	if got := testutil.ToFloat64(metrics.CacheHits) - hits; got != 4 {
+		t.Errorf("cache_hits_total grew by %v, want 4", got)
+	}
+	if got := testutil.ToFloat64(metrics.CacheMisses) - misses; got != 1 {
+		t.Errorf("cache_misses_total grew by %v, want 1", got)
+	}
+}
+
+func TestLocalCachedStoreEvictsOnWrite(t *testing.T) {
+	ctx := context.Background()
+	inner := newCountingStore(t)
+	cached := NewLocalCachedStore(inner, 10)
+	if _, err := cached.GetByShortID(ctx, "abc123", AnyTenant); err != nil {
+		t.Fatalf("GetByShortID: %v", err)
+	}
+
+	if err := cached.Update(ctx, "abc123", "https://example.com/b"); err != nil {
+		t.Fatalf("Update: %v", err)
+	}
+	urlEntry, err := cached.GetByShortID(ctx, "abc123", AnyTenant)
+	if err != nil {
+		t.Fatalf("GetByShortID after Update: %v", err)
+	}
+	if urlEntry.OriginalUrl != "https://example.com/b" {
+		t.Errorf("after Update, original URL = %q, want https://example.com/b", urlEntry.OriginalUrl)
+	}
+
+	if err := cached.Delete(ctx, "abc123"); err != nil {
+		t.Fatalf("Delete: %v", err)
+	}
+	if _, err := cached.GetByShortID(ctx, "abc123", AnyTenant); err == nil {
+		t.Error("the deleted entry is still served from the cache")
+	}
+	if got := inner.gets.Load(); got != 3 {
+		t.Errorf("inner store was read %d times, want 3 (once after each write)", got)
+	}
+}
+
+func TestLocalCachedStoreChecksTenant(t *testing.T) {
+	ctx := context.Background()
+	inner := &countingStore{MemoryUrlStore: NewMemoryUrlStore()}
+	entry := domain.URL{ID: "owned1", ShortUrl: "owned1", OriginalUrl: "https://example.com", CreationDate: time.Now().UTC(), TenantID: "alice"}
+	if err := inner.Save(ctx, entry); err != nil {
+		t.Fatalf("Save: %v", err)
+	}
+	cached := NewLocalCachedStore(inner, 10)
+
+	if _, err := cached.GetByShortID(ctx, "owned1", "alice"); err != nil {
+		t.Fatalf("GetByShortID for the owner: %v", err)
+	}
+	// The entry is cached now, but must not be served to another tenant.
+	if _, err := cached.GetByShortID(ctx, "owned1", "bob"); !errors.Is(err, ErrURLNotFound) {
+		t.Errorf("GetByShortID for another tenant error = %v, want ErrURLNotFound", err)
+	}
+}
+
+// transferringStore is a MemoryUrlStore that can move entries between tenants.
+type transferringStore struct {
+	*MemoryUrlStore
+}
+
+func (s transferringStore) TransferOwnership(ctx context.Context, shortID, newOwnerID string) (string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	urlEntry, ok := s.urls[shortID]
+	if !ok {
+		return "", errNotFound(shortID)
+	}
+	previousOwner := urlEntry.TenantID
+	urlEntry.TenantID = newOwnerID
+	s.urls[shortID] = urlEntry
+	return previousOwner, nil
+}
+
+func TestLocalCachedStoreTransfersOwnership(t *testing.T) {
+	ctx := context.Background()
+	inner := transferringStore{MemoryUrlStore: NewMemoryUrlStore()}
+	entry := domain.URL{ID: "owned1", ShortUrl: "owned1", OriginalUrl: "https://example.com", CreationDate: time.Now().UTC(), TenantID: "alice"}
+	if err := inner.Save(ctx, entry); err != nil {
+		t.Fatalf("Save: %v", err)
+	}
+	// The transfer has to pass through every decorator between the cache and the store.
+	cached := NewLocalCachedStore(NewRetryStore(inner, 1, time.Millisecond), 10)
+	if _, err := cached.GetByShortID(ctx, "owned1", "alice"); err != nil {
+		t.Fatalf("GetByShortID for the owner: %v", err)
+	}
+
+	previousOwner, err := cached.(OwnershipStoreInterface).TransferOwnership(ctx, "owned1", "bob")
+	if err != nil {
+		t.Fatalf("TransferOwnership: %v", err)
+	}
+	if previousOwner != "alice" {
+		t.Errorf("previous owner = %q, want alice", previousOwner)
+	}
+	if _, err := cached.GetByShortID(ctx, "owned1", "bob"); err != nil {
+		t.Errorf("GetByShortID for the new owner: %v", err)
+	}
+	if _, err := cached.GetByShortID(ctx, "owned1", "alice"); !errors.Is(err, ErrURLNotFound) {
+		t.Errorf("GetByShortID for the previous owner error = %v, want ErrURLNotFound", err)
+	}
+	for owner, want := range map[string]int{"bob": 1, "alice": 0} {
+		urls, _, err := cached.List(ctx, owner, "", 10, false)
+		if err != nil {
+			t.Fatalf("List(%s): %v", owner, err)
+		}
+		if len(urls) != want {
+			t.Errorf("%s lists %d URLs, want %d", owner, len(urls), want)
+		}
+	}
+
+	plain := NewLocalCachedStore(NewMemoryUrlStore(), 10)
+	if _, err := plain.(OwnershipStoreInterface).TransferOwnership(ctx, "owned1", "bob"); !errors.Is(err, ErrOwnershipUnsupported) {
+		t.Errorf("TransferOwnership on a store without tenants error = %v, want ErrOwnershipUnsupported", err)
+	}
+}
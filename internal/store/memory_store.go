@@ -0,0 +1,317 @@
+package store
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"shawty/internal/domain"
+)
+
+// MemoryUrlStore implements UrlStoreInterface with an in-process map.
+// It performs no I/O, which makes it suitable for unit tests and local experiments.
+// Data is lost when the process exits.
+type MemoryUrlStore struct {
+	mu   sync.RWMutex
+	urls map[string]domain.URL
+}
+
+// NewMemoryUrlStore creates a new, empty MemoryUrlStore.
+func NewMemoryUrlStore() *MemoryUrlStore {
+	return &MemoryUrlStore{urls: make(map[string]domain.URL)}
+}
+
+// EnsureIndexes is a no-op for the in-memory store.
+func (s *MemoryUrlStore) EnsureIndexes(ctx context.Context) error {
+	return nil
+}
+
+// Save stores a new URL entry, returning ErrDuplicateShortID if the ID is already present.
+func (s *MemoryUrlStore) Save(ctx context.Context, urlEntry domain.URL) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, exists := s.urls[urlEntry.ID]; exists {
+		return ErrDuplicateShortID
+	}
+	s.urls[urlEntry.ID] = urlEntry
+	return nil
+}
+
+// GetByShortID retrieves a URL entry by its short ID, following an alias to its primary.
+func (s *MemoryUrlStore) GetByShortID(ctx context.Context, shortID, tenantID string) (domain.URL, error) {
+	return followAlias(ctx, shortID, tenantID, s.getByShortID)
+}
+
+// getByShortID retrieves the entry with the short ID as stored, aliases included.
+func (s *MemoryUrlStore) getByShortID(ctx context.Context, shortID, tenantID string) (domain.URL, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	urlEntry, ok := s.urls[shortID]
+	if !ok || !belongsTo(urlEntry, tenantID) {
+		return domain.URL{}, errNotFound(shortID)
+	}
+	if urlEntry.IsDeleted() {
+		return domain.URL{}, fmt.Errorf("%w: '%s'", ErrURLDeleted, shortID)
+	}
+	return urlEntry, nil
+}
+
+// GetByOriginalURL retrieves an (undeleted) URL entry by its original URL.
+// It returns ErrURLNotFound if the URL has not been shortened.
+func (s *MemoryUrlStore) GetByOriginalURL(ctx context.Context, originalURL string) (domain.URL, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	for _, urlEntry := range s.urls {
+		if urlEntry.OriginalUrl == originalURL && !urlEntry.IsDeleted() {
+			return urlEntry, nil
+		}
+	}
+	return domain.URL{}, fmt.Errorf("%w: no entry for '%s'", ErrURLNotFound, originalURL)
+}
+
+// EstimatedCount returns the number of stored URL entries.
+func (s *MemoryUrlStore) EstimatedCount(ctx context.Context) (int64, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return int64(len(s.urls)), nil
+}
+
+// IncrementClickCount increments the click counter of a URL entry and records the access time.
+// It returns ErrClickLimitReached for click-limited entries that have used up their clicks.
+func (s *MemoryUrlStore) IncrementClickCount(ctx context.Context, shortID string, mobile bool) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	urlEntry, ok := s.urls[shortID]
+	if !ok {
+		return 0, fmt.Errorf("%w: '%s'", ErrURLNotFound, shortID)
+	}
+	if urlEntry.MaxClicks != nil && urlEntry.ClickCount >= *urlEntry.MaxClicks {
+		return 0, fmt.Errorf("%w: '%s'", ErrClickLimitReached, shortID)
+	}
+	now := time.Now().UTC()
+	urlEntry.ClickCount++
+	if mobile {
+		urlEntry.MobileClicks++
+	}
+	urlEntry.LastAccessedAt = &now
+	s.urls[shortID] = urlEntry
+	return urlEntry.ClickCount, nil
+}
+
+// IncrementConversionCount increments the conversion counter of a URL entry.
+func (s *MemoryUrlStore) IncrementConversionCount(ctx context.Context, shortID string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	urlEntry, ok := s.urls[shortID]
+	if !ok {
+		return fmt.Errorf("%w: '%s'", ErrURLNotFound, shortID)
+	}
+	urlEntry.ConversionCount++
+	s.urls[shortID] = urlEntry
+	return nil
+}
+
+// List returns a page of URL entries in short ID order, starting after cursor, and the cursor of the next page.
+func (s *MemoryUrlStore) List(ctx context.Context, tenantID, cursor string, limit int64, includeDeleted bool) ([]domain.URL, string, error) {
+	after, err := decodeCursor(cursor)
+	if err != nil {
+		return nil, "", err
+	}
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	all := make([]domain.URL, 0, len(s.urls))
+	for _, urlEntry := range s.urls {
+		if (urlEntry.IsDeleted() && !includeDeleted) || urlEntry.ID <= after || !belongsTo(urlEntry, tenantID) {
+			continue
+		}
+		all = append(all, urlEntry)
+	}
+	sort.Slice(all, func(i, j int) bool {
+		return all[i].ID < all[j].ID
+	})
+	urls, next := nextPage(all, limit)
+	return urls, next, nil
+}
+
+// Delete removes a URL entry, or marks it with deleted_at in soft-delete builds.
+// It returns ErrURLNotFound if no (undeleted) entry has the short ID.
+func (s *MemoryUrlStore) Delete(ctx context.Context, shortID string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	urlEntry, ok := s.urls[shortID]
+	if !ok || urlEntry.IsDeleted() {
+		return fmt.Errorf("%w: '%s'", ErrURLNotFound, shortID)
+	}
+	if SoftDelete {
+		now := time.Now().UTC()
+		urlEntry.DeletedAt = &now
+		s.urls[shortID] = urlEntry
+		return nil
+	}
+	delete(s.urls, shortID)
+	return nil
+}
+
+// ForEach calls fn for every entry in short ID order. It works on a snapshot, so fn may use the store.
+func (s *MemoryUrlStore) ForEach(ctx context.Context, fn func(domain.URL) error) error {
+	s.mu.RLock()
+	all := make([]domain.URL, 0, len(s.urls))
+	for _, urlEntry := range s.urls {
+		all = append(all, urlEntry)
+	}
+	s.mu.RUnlock()
+
+	sort.Slice(all, func(i, j int) bool {
+		return all[i].ID < all[j].ID
+	})
+	for _, urlEntry := range all {
+		if err := fn(urlEntry); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ListByTag returns a page of undeleted entries carrying tag in short ID order, along with their total.
+func (s *MemoryUrlStore) ListByTag(ctx context.Context, tag string, limit, offset int64) ([]domain.URL, int64, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	matching := []domain.URL{}
+	for _, urlEntry := range s.urls {
+		if !urlEntry.IsDeleted() && slices.Contains(urlEntry.Tags, tag) {
+			matching = append(matching, urlEntry)
+		}
+	}
+	sort.Slice(matching, func(i, j int) bool {
+		return matching[i].ID < matching[j].ID
+	})
+
+	total := int64(len(matching))
+	if offset >= total {
+		return []domain.URL{}, total, nil
+	}
+	end := min(offset+limit, total)
+	return matching[offset:end], total, nil
+}
+
+// Search returns up to limit undeleted entries whose original URL contains query, ignoring case, in short ID order.
+func (s *MemoryUrlStore) Search(ctx context.Context, query string, limit int64) ([]domain.URL, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	query = strings.ToLower(query)
+	matching := []domain.URL{}
+	for _, urlEntry := range s.urls {
+		if !urlEntry.IsDeleted() && strings.Contains(strings.ToLower(urlEntry.OriginalUrl), query) {
+			matching = append(matching, urlEntry)
+		}
+	}
+	sort.Slice(matching, func(i, j int) bool {
+		return matching[i].ID < matching[j].ID
+	})
+	return matching[:min(int64(len(matching)), limit)], nil
+}
+
+// ListAliases returns the short IDs of the (undeleted) aliases of primaryID.
+func (s *MemoryUrlStore) ListAliases(ctx context.Context, primaryID string) ([]string, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	aliases := []string{}
+	for _, urlEntry := range s.urls {
+		if !urlEntry.IsDeleted() && urlEntry.AliasOf != nil && *urlEntry.AliasOf == primaryID {
+			aliases = append(aliases, urlEntry.ID)
+		}
+	}
+	sort.Strings(aliases)
+	return aliases, nil
+}
+
+// UpdateTags replaces the tags of an entry and records the modification time.
+// It returns ErrURLNotFound if no (undeleted) entry has the short ID.
+func (s *MemoryUrlStore) UpdateTags(ctx context.Context, shortID string, tags []string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	urlEntry, ok := s.urls[shortID]
+	if !ok || urlEntry.IsDeleted() {
+		return fmt.Errorf("%w: '%s'", ErrURLNotFound, shortID)
+	}
+	now := time.Now().UTC()
+	urlEntry.Tags = tags
+	urlEntry.UpdatedAt = &now
+	s.urls[shortID] = urlEntry
+	return nil
+}
+
+// UpdateMetadata sets the page title and description of an entry.
+// It returns ErrURLNotFound if no (undeleted) entry has the short ID.
+func (s *MemoryUrlStore) UpdateMetadata(ctx context.Context, shortID, title, description string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	urlEntry, ok := s.urls[shortID]
+	if !ok || urlEntry.IsDeleted() {
+		return fmt.Errorf("%w: '%s'", ErrURLNotFound, shortID)
+	}
+	urlEntry.PageTitle = title
+	urlEntry.PageDescription = description
+	s.urls[shortID] = urlEntry
+	return nil
+}
+
+// Undelete clears DeletedAt on a soft-deleted entry.
+// It returns ErrURLNotFound if no deleted entry has the short ID.
+func (s *MemoryUrlStore) Undelete(ctx context.Context, shortID string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	urlEntry, ok := s.urls[shortID]
+	if !ok || !urlEntry.IsDeleted() {
+		return fmt.Errorf("%w: no deleted entry '%s'", ErrURLNotFound, shortID)
+	}
+	urlEntry.DeletedAt = nil
+	s.urls[shortID] = urlEntry
+	return nil
+}
+
+// Update changes the original URL of an entry and records the modification time.
+// It returns ErrURLNotFound if no (undeleted) entry has the short ID.
+func (s *MemoryUrlStore) Update(ctx context.Context, shortID, newOriginalURL string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	urlEntry, ok := s.urls[shortID]
+	if !ok || urlEntry.IsDeleted() {
+		return fmt.Errorf("%w: '%s'", ErrURLNotFound, shortID)
+	}
+	now := time.Now().UTC()
+	urlEntry.OriginalUrl = newOriginalURL
+	urlEntry.UpdatedAt = &now
+	s.urls[shortID] = urlEntry
+	return nil
+}
+
+// Ping always succeeds for the in-memory store.
+func (s *MemoryUrlStore) Ping(ctx context.Context) error {
+	return nil
+}
+
+// Ready always succeeds for the in-memory store.
+func (s *MemoryUrlStore) Ready(ctx context.Context) error {
+	return nil
+}
@@ -0,0 +1,274 @@
+package store
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// MigrationsCollectionName is the MongoDB collection that records the applied migrations.
+const MigrationsCollectionName = "_migrations"
+
+// ErrNoAppliedMigrations is returned by MongoMigrator.Down when there is nothing to reverse.
+var ErrNoAppliedMigrations = errors.New("no migrations have been applied")
+
+// MongoDB server error codes the migrations tolerate.
+const (
+	mongoCodeIndexNotFound   = 27
+	mongoCodeNamespaceExists = 48
+)
+
+// migration is a reversible change to the MongoDB schema. up must be safe to run again after a
+// partial failure, since it is only recorded as applied once it has succeeded.
+type migration struct {
+	name string
+	up   func(ctx context.Context, m *MongoMigrator) error
+	down func(ctx context.Context, m *MongoMigrator) error
+}
+
+// migrations are applied in order. Append new migrations at the end and never rename or reorder
+// the existing ones: their names are what _migrations records.
+var migrations = []migration{
+	{name: "0001_urls_indexes", up: upURLIndexes, down: downURLIndexes},
+	{name: "0002_clicks_ttl_index", up: upClicksTTLIndex, down: downClicksTTLIndex},
+	{name: "0003_tenants_collection", up: upTenantsCollection, down: downTenantsCollection},
+	{name: "0004_audit_events_indexes", up: upAuditIndexes, down: downAuditIndexes},
+	{name: "0005_clicks_session_index", up: upClicksSessionIndex, down: downClicksSessionIndex},
+}
+
+// MigrationRecord is a document of the _migrations collection.
+type MigrationRecord struct {
+	Name      string    `bson:"name"`
+	AppliedAt time.Time `bson:"applied_at"`
+}
+
+// MigrationStatus reports whether a migration has been applied, and when.
+type MigrationStatus struct {
+	Name      string
+	AppliedAt *time.Time // nil if the migration is pending
+}
+
+// MongoMigrator applies the registered migrations to a MongoDB database and records them in its
+// _migrations collection.
+type MongoMigrator struct {
+	db             *mongo.Database
+	urlsCollection string
+	clickRetention time.Duration // How long click events are kept; 0 keeps them forever
+	records        *mongo.Collection
+}
+
+// NewMongoMigrator creates a MongoMigrator for db, whose URLs live in urlsCollection. Click events
+// older than clickRetention are removed by the TTL index of the clicks migration; with 0 the index
+// is not created.
+func NewMongoMigrator(db *mongo.Database, urlsCollection string, clickRetention time.Duration) *MongoMigrator {
+	return &MongoMigrator{
+		db:             db,
+		urlsCollection: urlsCollection,
+		clickRetention: clickRetention,
+		records:        db.Collection(MigrationsCollectionName),
+	}
+}
+
+// Up applies the pending migrations in order and returns the names of those it applied. Running it
+// again once everything is applied does nothing. A unique index on name keeps two concurrent runs
+// from recording a migration twice.
+func (m *MongoMigrator) Up(ctx context.Context) ([]string, error) {
+	if _, err := m.records.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys:    bson.D{{Key: "name", Value: 1}},
+		Options: options.Index().SetUnique(true),
+	}); err != nil {
+		return nil, fmt.Errorf("failed to create index on %s: %w", MigrationsCollectionName, err)
+	}
+
+	applied, err := m.applied(ctx)
+	if err != nil {
+		return nil, err
+	}
+	var ran []string
+	for _, mig := range migrations {
+		if _, ok := applied[mig.name]; ok {
+			continue
+		}
+		slog.InfoContext(ctx, "Applying migration", slog.String("name", mig.name))
+		if err := mig.up(ctx, m); err != nil {
+			return ran, fmt.Errorf("migration %s failed: %w", mig.name, err)
+		}
+		record := MigrationRecord{Name: mig.name, AppliedAt: time.Now().UTC()}
+		if _, err := m.records.InsertOne(ctx, record); err != nil && !mongo.IsDuplicateKeyError(err) {
+			return ran, fmt.Errorf("failed to record migration %s: %w", mig.name, err)
+		}
+		ran = append(ran, mig.name)
+	}
+	return ran, nil
+}
+
+// Status lists every registered migration in order along with when it was applied.
+func (m *MongoMigrator) Status(ctx context.Context) ([]MigrationStatus, error) {
+	applied, err := m.applied(ctx)
+	if err != nil {
+		return nil, err
+	}
+	statuses := make([]MigrationStatus, 0, len(migrations))
+	for _, mig := range migrations {
+		status := MigrationStatus{Name: mig.name}
+		if appliedAt, ok := applied[mig.name]; ok {
+			status.AppliedAt = &appliedAt
+		}
+		statuses = append(statuses, status)
+	}
+	return statuses, nil
+}
+
+// Down reverses the last applied migration and returns its name. It returns ErrNoAppliedMigrations
+// if none has been applied.
+func (m *MongoMigrator) Down(ctx context.Context) (string, error) {
+	applied, err := m.applied(ctx)
+	if err != nil {
+		return "", err
+	}
+	for i := len(migrations) - 1; i >= 0; i-- {
+		mig := migrations[i]
+		if _, ok := applied[mig.name]; !ok {
+			continue
+		}
+		slog.InfoContext(ctx, "Reversing migration", slog.String("name", mig.name))
+		if err := mig.down(ctx, m); err != nil {
+			return "", fmt.Errorf("reversing migration %s failed: %w", mig.name, err)
+		}
+		if _, err := m.records.DeleteOne(ctx, bson.M{"name": mig.name}); err != nil {
+			return "", fmt.Errorf("failed to unrecord migration %s: %w", mig.name, err)
+		}
+		return mig.name, nil
+	}
+	return "", ErrNoAppliedMigrations
+}
+
+// applied returns the application time of every recorded migration by name.
+func (m *MongoMigrator) applied(ctx context.Context) (map[string]time.Time, error) {
+	cursor, err := m.records.Find(ctx, bson.M{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", MigrationsCollectionName, err)
+	}
+	defer cursor.Close(ctx)
+
+	var records []MigrationRecord
+	if err := cursor.All(ctx, &records); err != nil {
+		return nil, fmt.Errorf("failed to decode %s: %w", MigrationsCollectionName, err)
+	}
+	applied := make(map[string]time.Time, len(records))
+	for _, record := range records {
+		applied[record.Name] = record.AppliedAt
+	}
+	return applied, nil
+}
+
+// createCollection creates the named collection unless it already exists. The readiness probe
+// requires the urls collection, which would otherwise only appear with the first write.
+func (m *MongoMigrator) createCollection(ctx context.Context, name string) error {
+	err := m.db.CreateCollection(ctx, name)
+	var cmdErr mongo.CommandError
+	if err != nil && !(errors.As(err, &cmdErr) && cmdErr.Code == mongoCodeNamespaceExists) {
+		return fmt.Errorf("failed to create collection %s: %w", name, err)
+	}
+	return nil
+}
+
+// dropIndexes drops models from coll, ignoring those that do not exist.
+func dropIndexes(ctx context.Context, coll *mongo.Collection, models []mongo.IndexModel) error {
+	for _, model := range models {
+		name := indexName(model.Keys.(bson.D))
+		if _, err := coll.Indexes().DropOne(ctx, name); err != nil {
+			var cmdErr mongo.CommandError
+			if errors.As(err, &cmdErr) && cmdErr.Code == mongoCodeIndexNotFound {
+				continue
+			}
+			return fmt.Errorf("failed to drop index %s on %s: %w", name, coll.Name(), err)
+		}
+		slog.InfoContext(ctx, "Dropped index", slog.String("collection", coll.Name()), slog.String("index", name))
+	}
+	return nil
+}
+
+// clicksTTLIndexModels returns the TTL index that expires click events after the retention period,
+// or no index without one.
+func (m *MongoMigrator) clicksTTLIndexModels() []mongo.IndexModel {
+	if m.clickRetention <= 0 {
+		return nil
+	}
+	return []mongo.IndexModel{{
+		Keys:    bson.D{{Key: "clicked_at", Value: 1}},
+		Options: options.Index().SetExpireAfterSeconds(int32(m.clickRetention / time.Second)),
+	}}
+}
+
+func upURLIndexes(ctx context.Context, m *MongoMigrator) error {
+	if err := m.createCollection(ctx, m.urlsCollection); err != nil {
+		return err
+	}
+	return createIndexes(ctx, m.db.Collection(m.urlsCollection), urlIndexModels())
+}
+
+func downURLIndexes(ctx context.Context, m *MongoMigrator) error {
+	return dropIndexes(ctx, m.db.Collection(m.urlsCollection), urlIndexModels())
+}
+
+func upClicksTTLIndex(ctx context.Context, m *MongoMigrator) error {
+	clicks := m.db.Collection(ClicksCollectionName)
+	if err := createIndexes(ctx, clicks, clickIndexModels()); err != nil {
+		return err
+	}
+	return createIndexes(ctx, clicks, m.clicksTTLIndexModels())
+}
+
+func downClicksTTLIndex(ctx context.Context, m *MongoMigrator) error {
+	clicks := m.db.Collection(ClicksCollectionName)
+	// The TTL index is dropped whatever the retention, since it may have been created with another.
+	ttl := []mongo.IndexModel{{Keys: bson.D{{Key: "clicked_at", Value: 1}}}}
+	if err := dropIndexes(ctx, clicks, ttl); err != nil {
+		return err
+	}
+	return dropIndexes(ctx, clicks, clickIndexModels())
+}
+
+func upTenantsCollection(ctx context.Context, m *MongoMigrator) error {
+	return m.createCollection(ctx, TenantsCollectionName)
+}
+
+// downTenantsCollection drops the tenants collection, but only while it is empty: it holds the
+// tenants' quotas and link counters, which cannot be recreated.
+func downTenantsCollection(ctx context.Context, m *MongoMigrator) error {
+	tenants := m.db.Collection(TenantsCollectionName)
+	count, err := tenants.CountDocuments(ctx, bson.M{}, options.Count().SetLimit(1))
+	if err != nil {
+		return fmt.Errorf("failed to count tenants: %w", err)
+	}
+	if count > 0 {
+		return fmt.Errorf("collection %s is not empty; remove its documents first to reverse this migration", TenantsCollectionName)
+	}
+	if err := tenants.Drop(ctx); err != nil {
+		return fmt.Errorf("failed to drop collection %s: %w", TenantsCollectionName, err)
+	}
+	return nil
+}
+
+func upAuditIndexes(ctx context.Context, m *MongoMigrator) error {
+	return createIndexes(ctx, m.db.Collection(AuditCollectionName), auditIndexModels())
+}
+
+func downAuditIndexes(ctx context.Context, m *MongoMigrator) error {
+	return dropIndexes(ctx, m.db.Collection(AuditCollectionName), auditIndexModels())
+}
+
+func upClicksSessionIndex(ctx context.Context, m *MongoMigrator) error {
+	return createIndexes(ctx, m.db.Collection(ClicksCollectionName), clickSessionIndexModels())
+}
+
+func downClicksSessionIndex(ctx context.Context, m *MongoMigrator) error {
+	return dropIndexes(ctx, m.db.Collection(ClicksCollectionName), clickSessionIndexModels())
+}
@@ -0,0 +1,111 @@
+package store
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"testing"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// migrationNames returns the names of the registered migrations in order.
+func migrationNames() []string {
+	names := make([]string, 0, len(migrations))
+	for _, mig := range migrations {
+		names = append(names, mig.name)
+	}
+	return names
+}
+
+// indexNames returns the sorted names of the indexes of coll.
+func indexNames(t *testing.T, coll *mongo.Collection) []string {
+	t.Helper()
+	specs, err := coll.Indexes().ListSpecifications(context.Background())
+	if err != nil {
+		t.Fatalf("listing indexes of %s: %v", coll.Name(), err)
+	}
+	var names []string
+	for _, spec := range specs {
+		names = append(names, spec.Name)
+	}
+	slices.Sort(names)
+	return names
+}
+
+func TestMongoMigratorUpIsIdempotent(t *testing.T) {
+	db := newTestMongoDatabase(t)
+	ctx := context.Background()
+	migrator := NewMongoMigrator(db, "urls", 90*24*time.Hour)
+
+	applied, err := migrator.Up(ctx)
+	if err != nil {
+		t.Fatalf("first Up: %v", err)
+	}
+	if !slices.Equal(applied, migrationNames()) {
+		t.Errorf("first Up applied %v, want %v", applied, migrationNames())
+	}
+	indexesAfterFirst := indexNames(t, db.Collection("urls"))
+
+	applied, err = migrator.Up(ctx)
+	if err != nil {
+		t.Fatalf("second Up: %v", err)
+	}
+	if len(applied) != 0 {
+		t.Errorf("second Up applied %v, want nothing", applied)
+	}
+	if got := indexNames(t, db.Collection("urls")); !slices.Equal(got, indexesAfterFirst) {
+		t.Errorf("indexes after the second Up = %v, want %v", got, indexesAfterFirst)
+	}
+	count, err := db.Collection(MigrationsCollectionName).CountDocuments(ctx, bson.M{})
+	if err != nil {
+		t.Fatalf("counting %s: %v", MigrationsCollectionName, err)
+	}
+	if count != int64(len(migrations)) {
+		t.Errorf("%s holds %d records, want %d", MigrationsCollectionName, count, len(migrations))
+	}
+
+	statuses, err := migrator.Status(ctx)
+	if err != nil {
+		t.Fatalf("Status: %v", err)
+	}
+	for _, status := range statuses {
+		if status.AppliedAt == nil {
+			t.Errorf("Status reports %s as pending after Up", status.Name)
+		}
+	}
+}
+
+func TestMongoMigratorDownReversesTheLastMigration(t *testing.T) {
+	db := newTestMongoDatabase(t)
+	ctx := context.Background()
+	migrator := NewMongoMigrator(db, "urls", 90*24*time.Hour)
+	if _, err := migrator.Up(ctx); err != nil {
+		t.Fatalf("Up: %v", err)
+	}
+
+	names := migrationNames()
+	for i := len(names) - 1; i >= 0; i-- {
+		reversed, err := migrator.Down(ctx)
+		if err != nil {
+			t.Fatalf("Down: %v", err)
+		}
+		if reversed != names[i] {
+			t.Fatalf("Down reversed %s, want %s", reversed, names[i])
+		}
+	}
+	if _, err := migrator.Down(ctx); !errors.Is(err, ErrNoAppliedMigrations) {
+		t.Errorf("Down with nothing applied: error = %v, want ErrNoAppliedMigrations", err)
+	}
+
+	// Everything reversed can be applied again.
+	applied, err := migrator.Up(ctx)
+	if err != nil {
+		t.Fatalf("Up after Down: %v", err)
+	}
+	if !slices.Equal(applied, names) {
+		t.Errorf("Up after Down applied %v, want %v", applied, names)
+	}
+}
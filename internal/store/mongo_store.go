@@ -4,70 +4,190 @@ import (
 	"context"
 	"errors"
 	"fmt"
-	"log"
+	"log/slog"
+	"strings"
+	"time"
 
 	"shawty/internal/domain"
+	"shawty/internal/tracing"
 
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
 )
 
+// tracer creates the store-layer spans.
+var tracer = otel.Tracer("shawty/internal/store")
+
 // ErrDuplicateShortID is returned when trying to save a URL with a short ID that already exists.
 var ErrDuplicateShortID = errors.New("short ID already exists in store")
 
+// ErrURLNotFound is returned when an operation targets a short ID that does not exist.
+var ErrURLNotFound = errors.New("URL not found")
+
+// ErrURLDeleted is returned by GetByShortID when the entry has been soft-deleted.
+var ErrURLDeleted = errors.New("URL has been deleted")
+
+// ErrClickLimitReached is returned by IncrementClickCount when a click-limited entry has used up its clicks.
+var ErrClickLimitReached = errors.New("click limit reached")
+
+// underClickLimitFilter matches entries without a click limit and entries that have clicks left.
+// A null max_clicks also matches entries where the field is missing.
+var underClickLimitFilter = bson.A{
+	bson.M{"max_clicks": nil},
+	bson.M{"$expr": bson.M{"$lt": bson.A{"$click_count", "$max_clicks"}}},
+}
+
+// clickIncrement returns the $inc document that counts one redirect, from a mobile device or not.
+func clickIncrement(mobile bool) bson.M {
+	if mobile {
+		return bson.M{"click_count": 1, "mobile_click_count": 1}
+	}
+	return bson.M{"click_count": 1}
+}
+
+// notDeletedFilter matches entries that have not been soft-deleted.
+var notDeletedFilter = bson.M{"deleted_at": bson.M{"$exists": false}}
+
 // UrlStoreInterface defines the operations for URL persistence.
 type UrlStoreInterface interface {
 	Save(ctx context.Context, urlEntry domain.URL) error
-	GetByShortID(ctx context.Context, shortID string) (domain.URL, error)
+	// GetByShortID returns the entry with the short ID. Unless tenantID is AnyTenant, entries of
+	// other tenants are reported as not found.
+	// An alias is resolved to its primary entry, see domain.URL.AliasOf.
+	GetByShortID(ctx context.Context, shortID, tenantID string) (domain.URL, error)
+	// ListAliases returns the short IDs of the aliases of primaryID in short ID order.
+	ListAliases(ctx context.Context, primaryID string) ([]string, error)
 	EnsureIndexes(ctx context.Context) error
+	EstimatedCount(ctx context.Context) (int64, error)
+	// IncrementClickCount counts a redirect and returns the entry's click count after it.
+	// Redirects of mobile visitors also count towards the mobile click count.
+	IncrementClickCount(ctx context.Context, shortID string, mobile bool) (int64, error)
+	// IncrementConversionCount counts a conversion of a visit. It returns ErrURLNotFound if no entry has the short ID.
+	IncrementConversionCount(ctx context.Context, shortID string) error
+	// List returns up to limit entries of tenantID (every tenant's for AnyTenant) in short ID order,
+	// starting after cursor ("" for the first page), and the cursor of the next page, which is ""
+	// on the last page. Soft-deleted entries are only included when includeDeleted is set.
+	List(ctx context.Context, tenantID, cursor string, limit int64, includeDeleted bool) ([]domain.URL, string, error)
+	// ForEach calls fn for every entry, deleted ones included, in short ID order, without holding
+	// them all in memory. It stops at the first error fn returns and returns that error.
+	ForEach(ctx context.Context, fn func(domain.URL) error) error
+	Delete(ctx context.Context, shortID string) error
+	// Undelete restores a soft-deleted entry. It returns ErrURLNotFound if no deleted entry has the short ID.
+	Undelete(ctx context.Context, shortID string) error
+	// ListByTag returns up to limit undeleted entries carrying tag in short ID order, skipping the
+	// first offset, along with the total number of matching entries.
+	ListByTag(ctx context.Context, tag string, limit, offset int64) ([]domain.URL, int64, error)
+	// UpdateTags replaces the tags of an entry; nil or empty removes them.
+	// It returns ErrURLNotFound if no (undeleted) entry has the short ID.
+	UpdateTags(ctx context.Context, shortID string, tags []string) error
+	// Search returns up to limit undeleted entries whose original URL contains query, ignoring case,
+	// in short ID order. The query is matched literally.
+	Search(ctx context.Context, query string, limit int64) ([]domain.URL, error)
+	Update(ctx context.Context, shortID, newOriginalURL string) error
+	// UpdateMetadata stores the page title and description fetched from an entry's destination.
+	// It returns ErrURLNotFound if no (undeleted) entry has the short ID.
+	UpdateMetadata(ctx context.Context, shortID, title, description string) error
+	GetByOriginalURL(ctx context.Context, originalURL string) (domain.URL, error)
+}
+
+// HealthChecker is implemented by stores that can report their connectivity.
+type HealthChecker interface {
+	// Ping checks that the database server is reachable.
+	Ping(ctx context.Context) error
+	// Ready checks that the URL collection exists and can serve queries.
+	Ready(ctx context.Context) error
 }
 
 // MongoUrlStore implements UrlStoreInterface using MongoDB.
+// It also implements AuditStoreInterface, recording every create, update and delete
+// in the audit_events collection of the same database, AnalyticsStoreInterface,
+// keeping click events in the clicks collection, and QuotaStoreInterface, keeping
+// tenant quotas in the tenants collection.
 type MongoUrlStore struct {
-	collection *mongo.Collection
+	collection        *mongo.Collection
+	auditCollection   *mongo.Collection
+	clicksCollection  *mongo.Collection
+	tenantsCollection *mongo.Collection
+	hasDateTrunc      bool // The server supports $dateTrunc; set by DetectDateTrunc
 }
 
 // NewMongoUrlStore creates a new MongoUrlStore.
 func NewMongoUrlStore(dbClient *mongo.Client, dbName string, collectionName string) *MongoUrlStore {
-	collection := dbClient.Database(dbName).Collection(collectionName)
-	return &MongoUrlStore{collection: collection}
+	db := dbClient.Database(dbName)
+	return &MongoUrlStore{
+		collection:        db.Collection(collectionName),
+		auditCollection:   db.Collection(AuditCollectionName),
+		clicksCollection:  db.Collection(ClicksCollectionName),
+		tenantsCollection: db.Collection(TenantsCollectionName),
+	}
+}
+
+// urlIndexModels returns the indexes of the urls collection.
+func urlIndexModels() []mongo.IndexModel {
+	return []mongo.IndexModel{
+		// TTL index: MongoDB's background reaper deletes documents once expires_at has passed.
+		// Documents without expires_at are never removed.
+		{Keys: bson.D{{Key: "expires_at", Value: 1}}, Options: options.Index().SetExpireAfterSeconds(0)},
+		// A second TTL index removes links once their activation window has ended.
+		{Keys: bson.D{{Key: "active_until", Value: 1}}, Options: options.Index().SetExpireAfterSeconds(0)},
+		// Lets CreateShortURL find an existing entry for a resubmitted URL without a collection scan.
+		{Keys: bson.D{{Key: "original_url", Value: 1}}},
+		// Multikey index: every tag of a document is indexed, so ListByTag does not scan the collection.
+		{Keys: bson.D{{Key: "tags", Value: 1}}},
+		// Serves the pages of a tenant's listing in short ID order.
+		{Keys: bson.D{{Key: "tenant_id", Value: 1}, {Key: "_id", Value: 1}}},
+		// Sparse, since only aliases carry alias_of.
+		{Keys: bson.D{{Key: "alias_of", Value: 1}}, Options: options.Index().SetSparse(true)},
+	}
 }
 
-// EnsureIndexes creates necessary indexes for the urls collection.
-// For example, a unique index on the short_url field (or _id if it\'s the same).
+// EnsureIndexes creates necessary indexes for the urls, audit_events and clicks collections.
+// The server only calls it with AUTO_MIGRATE=true; otherwise the indexes are created by the
+// migrations of cmd/migrate.
 func (s *MongoUrlStore) EnsureIndexes(ctx context.Context) error {
-	// The _id field is automatically indexed by MongoDB and is always unique.
-	// Explicitly trying to create it again with SetUnique(true) causes the reported error.
-	// If other fields needed indexing (e.g., a non-unique index on 'original_url' for searching,
-	// or a unique index on a 'short_url' field if it were different from _id),
-	// those definitions would go here.
-
-	// Example of creating an index on another field, if needed:
-	/*
-		otherFieldIndexModel := mongo.IndexModel{
-			Keys:    bson.D{{Key: "some_other_field", Value: 1}},
-			Options: options.Index().SetUnique(false), // Or true if it needs to be unique
-		}
-		_, err := s.collection.Indexes().CreateOne(ctx, otherFieldIndexModel)
+	ctx, span := tracer.Start(ctx, "store.EnsureIndexes")
+	defer span.End()
+
+	if err := createIndexes(ctx, s.collection, urlIndexModels()); err != nil {
+		return err
+	}
+	if err := s.ensureAuditIndexes(ctx); err != nil {
+		return err
+	}
+	return s.ensureAnalyticsIndexes(ctx)
+}
+
+// createIndexes creates models on coll one by one. Creating an index that already exists with the
+// same options is a no-op.
+func createIndexes(ctx context.Context, coll *mongo.Collection, models []mongo.IndexModel) error {
+	for _, model := range models {
+		name, err := coll.Indexes().CreateOne(ctx, model)
 		if err != nil {
-			// It's good practice to check if the error is because the index already exists.
-			// For example, by checking the error code or message.
-			// if mongo.IsDuplicateKeyError(err) || strings.Contains(err.Error(), "index already exists") {
-			// log.Println("Index on 'some_other_field' already exists.")
-			// } else {
-			return fmt.Errorf("failed to create index on some_other_field: %w", err)
-			// }
-		} else {
-			log.Println("Successfully ensured index on some_other_field.")
+			return fmt.Errorf("failed to create index %s on %s: %w", indexName(model.Keys.(bson.D)), coll.Name(), err)
 		}
-	*/
-
-	log.Println("MongoDB automatically handles the unique index on _id. EnsureIndexes will ensure other custom indexes if defined above.")
+		slog.InfoContext(ctx, "Ensured index", slog.String("collection", coll.Name()), slog.String("index", name))
+	}
 	return nil
 }
 
+// indexName returns the name MongoDB gives an index on keys when none is set, e.g. "tenant_id_1__id_1".
+func indexName(keys bson.D) string {
+	parts := make([]string, 0, len(keys))
+	for _, key := range keys {
+		parts = append(parts, fmt.Sprintf("%s_%v", key.Key, key.Value))
+	}
+	return strings.Join(parts, "_")
+}
+
 // Save inserts a new URL entry into the database.
 func (s *MongoUrlStore) Save(ctx context.Context, urlEntry domain.URL) error {
+	ctx, span := tracer.Start(ctx, "store.Save", trace.WithAttributes(attribute.String(tracing.AttrShortID, urlEntry.ID), attribute.String(tracing.AttrOriginalURL, urlEntry.OriginalUrl)))
+	defer span.End()
+
 	_, err := s.collection.InsertOne(ctx, urlEntry)
 	if err != nil {
 		if mongo.IsDuplicateKeyError(err) {
@@ -75,20 +195,388 @@ func (s *MongoUrlStore) Save(ctx context.Context, urlEntry domain.URL) error {
 		}
 		return fmt.Errorf("failed to insert URL into MongoDB: %w", err)
 	}
+	s.recordAudit(ctx, domain.AuditEventCreate, urlEntry.ID, urlEntry.OriginalUrl)
 	return nil
 }
 
-// GetByShortID retrieves a URL entry by its short ID (_id field).
-func (s *MongoUrlStore) GetByShortID(ctx context.Context, shortID string) (domain.URL, error) {
+// GetByShortID retrieves a URL entry by its short ID (_id field), following an alias to its primary.
+func (s *MongoUrlStore) GetByShortID(ctx context.Context, shortID, tenantID string) (domain.URL, error) {
+	return followAlias(ctx, shortID, tenantID, s.getByShortID)
+}
+
+// getByShortID retrieves the document with the short ID as stored, aliases included.
+func (s *MongoUrlStore) getByShortID(ctx context.Context, shortID, tenantID string) (domain.URL, error) {
+	ctx, span := tracer.Start(ctx, "store.GetByShortID", trace.WithAttributes(attribute.String(tracing.AttrShortID, shortID)))
+	defer span.End()
+
 	var url domain.URL
-	filter := bson.M{"_id": shortID}
+	filter := withTenant(bson.M{"_id": shortID}, tenantID)
 	err := s.collection.FindOne(ctx, filter).Decode(&url)
 
 	if err != nil {
 		if errors.Is(err, mongo.ErrNoDocuments) {
-			return domain.URL{}, fmt.Errorf("URL with ID '%s' not found: %w", shortID, err)
+			return domain.URL{}, errNotFound(shortID)
 		}
 		return domain.URL{}, fmt.Errorf("error retrieving URL from MongoDB: %w", err)
 	}
+	if url.IsDeleted() {
+		return domain.URL{}, fmt.Errorf("%w: '%s'", ErrURLDeleted, shortID)
+	}
 	return url, nil
 }
+
+// GetByOriginalURL retrieves an (undeleted) URL entry by its original URL.
+// It returns ErrURLNotFound if the URL has not been shortened.
+func (s *MongoUrlStore) GetByOriginalURL(ctx context.Context, originalURL string) (domain.URL, error) {
+	ctx, span := tracer.Start(ctx, "store.GetByOriginalURL", trace.WithAttributes(attribute.String(tracing.AttrOriginalURL, originalURL)))
+	defer span.End()
+
+	var url domain.URL
+	filter := bson.M{"original_url": originalURL, "deleted_at": bson.M{"$exists": false}}
+	err := s.collection.FindOne(ctx, filter).Decode(&url)
+	if err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return domain.URL{}, fmt.Errorf("%w: no entry for '%s'", ErrURLNotFound, originalURL)
+		}
+		return domain.URL{}, fmt.Errorf("error retrieving URL by original URL from MongoDB: %w", err)
+	}
+	return url, nil
+}
+
+// EstimatedCount returns an approximate number of URL entries in the collection.
+// It reads MongoDB's collection metadata instead of scanning documents, so it is cheap
+// but may lag by a few seconds after bulk inserts or deletes.
+func (s *MongoUrlStore) EstimatedCount(ctx context.Context) (int64, error) {
+	ctx, span := tracer.Start(ctx, "store.EstimatedCount")
+	defer span.End()
+
+	count, err := s.collection.EstimatedDocumentCount(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("failed to estimate URL count: %w", err)
+	}
+	return count, nil
+}
+
+// IncrementClickCount atomically increments the click counter of a URL entry and records the access time.
+// Click-limited entries are only incremented while they have clicks left; once they are used up it
+// returns ErrClickLimitReached.
+func (s *MongoUrlStore) IncrementClickCount(ctx context.Context, shortID string, mobile bool) (int64, error) {
+	ctx, span := tracer.Start(ctx, "store.IncrementClickCount", trace.WithAttributes(attribute.String(tracing.AttrShortID, shortID)))
+	defer span.End()
+
+	filter := bson.M{"_id": shortID, "$or": underClickLimitFilter}
+	update := bson.M{
+		"$inc": clickIncrement(mobile),
+		"$set": bson.M{"last_accessed_at": time.Now().UTC()},
+	}
+	opts := options.FindOneAndUpdate().
+		SetReturnDocument(options.After).
+		SetProjection(bson.M{"click_count": 1})
+	var updated domain.URL
+	err := s.collection.FindOneAndUpdate(ctx, filter, update, opts).Decode(&updated)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		// Either the entry does not exist or its limit filtered it out.
+		count, err := s.collection.CountDocuments(ctx, bson.M{"_id": shortID})
+		if err != nil {
+			return 0, fmt.Errorf("failed to check click-limited URL in MongoDB: %w", err)
+		}
+		if count > 0 {
+			return 0, fmt.Errorf("%w: '%s'", ErrClickLimitReached, shortID)
+		}
+		return 0, fmt.Errorf("%w: '%s'", ErrURLNotFound, shortID)
+	}
+	if err != nil {
+		return 0, fmt.Errorf("failed to increment click count in MongoDB: %w", err)
+	}
+	return updated.ClickCount, nil
+}
+
+// IncrementConversionCount atomically increments the conversion counter of a URL entry with $inc.
+func (s *MongoUrlStore) IncrementConversionCount(ctx context.Context, shortID string) error {
+	ctx, span := tracer.Start(ctx, "store.IncrementConversionCount", trace.WithAttributes(attribute.String(tracing.AttrShortID, shortID)))
+	defer span.End()
+
+	result, err := s.collection.UpdateOne(ctx, bson.M{"_id": shortID}, bson.M{"$inc": bson.M{"conversion_count": 1}})
+	if err != nil {
+		return fmt.Errorf("failed to increment conversion count in MongoDB: %w", err)
+	}
+	if result.MatchedCount == 0 {
+		return fmt.Errorf("%w: '%s'", ErrURLNotFound, shortID)
+	}
+	return nil
+}
+
+// List returns a page of URL entries in _id order, starting after cursor, and the cursor of the next page.
+// Paging on the _id index keeps every page equally cheap, unlike skipping over earlier pages.
+func (s *MongoUrlStore) List(ctx context.Context, tenantID, cursor string, limit int64, includeDeleted bool) ([]domain.URL, string, error) {
+	ctx, span := tracer.Start(ctx, "store.List", trace.WithAttributes(attribute.Int64("limit", limit)))
+	defer span.End()
+
+	after, err := decodeCursor(cursor)
+	if err != nil {
+		return nil, "", err
+	}
+	filter := withTenant(bson.M{}, tenantID)
+	if !includeDeleted {
+		filter["deleted_at"] = bson.M{"$exists": false}
+	}
+	if after != "" {
+		filter["_id"] = bson.M{"$gt": after}
+	}
+
+	// One extra entry tells whether another page follows.
+	findOptions := options.Find().
+		SetSort(bson.D{{Key: "_id", Value: 1}}).
+		SetLimit(limit + 1)
+	results, err := s.collection.Find(ctx, filter, findOptions)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to list URLs from MongoDB: %w", err)
+	}
+	urls := []domain.URL{}
+	if err := results.All(ctx, &urls); err != nil {
+		return nil, "", fmt.Errorf("failed to decode listed URLs: %w", err)
+	}
+	urls, next := nextPage(urls, limit)
+	return urls, next, nil
+}
+
+// Delete removes a URL entry, or marks it with deleted_at in soft-delete builds.
+// It returns ErrURLNotFound if no (undeleted) entry has the short ID.
+func (s *MongoUrlStore) Delete(ctx context.Context, shortID string) error {
+	ctx, span := tracer.Start(ctx, "store.Delete", trace.WithAttributes(attribute.String(tracing.AttrShortID, shortID)))
+	defer span.End()
+
+	// The deleted entry is read back so that the audit log can record its destination.
+	var deleted domain.URL
+	if SoftDelete {
+		filter := bson.M{"_id": shortID, "deleted_at": bson.M{"$exists": false}}
+		update := bson.M{"$set": bson.M{"deleted_at": time.Now().UTC()}}
+		err := s.collection.FindOneAndUpdate(ctx, filter, update).Decode(&deleted)
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return fmt.Errorf("%w: '%s'", ErrURLNotFound, shortID)
+		}
+		if err != nil {
+			return fmt.Errorf("failed to soft-delete URL in MongoDB: %w", err)
+		}
+	} else {
+		err := s.collection.FindOneAndDelete(ctx, bson.M{"_id": shortID}).Decode(&deleted)
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return fmt.Errorf("%w: '%s'", ErrURLNotFound, shortID)
+		}
+		if err != nil {
+			return fmt.Errorf("failed to delete URL from MongoDB: %w", err)
+		}
+	}
+	s.recordAudit(ctx, domain.AuditEventDelete, shortID, deleted.OriginalUrl)
+	return nil
+}
+
+// Update changes the original URL of an entry and records the modification time.
+// It returns ErrURLNotFound if no (undeleted) entry has the short ID.
+func (s *MongoUrlStore) Update(ctx context.Context, shortID, newOriginalURL string) error {
+	ctx, span := tracer.Start(ctx, "store.Update", trace.WithAttributes(attribute.String(tracing.AttrShortID, shortID), attribute.String(tracing.AttrOriginalURL, newOriginalURL)))
+	defer span.End()
+
+	filter := bson.M{"_id": shortID, "deleted_at": bson.M{"$exists": false}}
+	update := bson.M{"$set": bson.M{
+		"original_url": newOriginalURL,
+		"updated_at":   time.Now().UTC(),
+	}}
+	result, err := s.collection.UpdateOne(ctx, filter, update)
+	if err != nil {
+		return fmt.Errorf("failed to update URL in MongoDB: %w", err)
+	}
+	if result.MatchedCount == 0 {
+		return fmt.Errorf("%w: '%s'", ErrURLNotFound, shortID)
+	}
+	s.recordAudit(ctx, domain.AuditEventUpdate, shortID, newOriginalURL)
+	return nil
+}
+
+// ForEach streams every entry through a cursor in _id order, calling fn for each.
+func (s *MongoUrlStore) ForEach(ctx context.Context, fn func(domain.URL) error) error {
+	ctx, span := tracer.Start(ctx, "store.ForEach")
+	defer span.End()
+
+	results, err := s.collection.Find(ctx, bson.M{}, options.Find().SetSort(bson.D{{Key: "_id", Value: 1}}))
+	if err != nil {
+		return fmt.Errorf("failed to read URLs from MongoDB: %w", err)
+	}
+	defer results.Close(ctx)
+
+	for results.Next(ctx) {
+		var urlEntry domain.URL
+		if err := results.Decode(&urlEntry); err != nil {
+			return fmt.Errorf("failed to decode URL: %w", err)
+		}
+		if err := fn(urlEntry); err != nil {
+			return err
+		}
+	}
+	if err := results.Err(); err != nil {
+		return fmt.Errorf("failed to read URLs from MongoDB: %w", err)
+	}
+	return nil
+}
+
+// ListByTag returns a page of undeleted entries carrying tag in _id order, along with their total.
+func (s *MongoUrlStore) ListByTag(ctx context.Context, tag string, limit, offset int64) ([]domain.URL, int64, error) {
+	ctx, span := tracer.Start(ctx, "store.ListByTag", trace.WithAttributes(attribute.String("tag", tag), attribute.Int64("offset", offset), attribute.Int64("limit", limit)))
+	defer span.End()
+
+	// Matching a scalar against an array field matches documents whose array contains it.
+	filter := bson.M{"tags": tag, "deleted_at": bson.M{"$exists": false}}
+	findOptions := options.Find().
+		SetSort(bson.D{{Key: "_id", Value: 1}}).
+		SetSkip(offset).
+		SetLimit(limit)
+	results, err := s.collection.Find(ctx, filter, findOptions)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to list URLs by tag from MongoDB: %w", err)
+	}
+	urls := []domain.URL{}
+	if err := results.All(ctx, &urls); err != nil {
+		return nil, 0, fmt.Errorf("failed to decode URLs listed by tag: %w", err)
+	}
+
+	total, err := s.collection.CountDocuments(ctx, filter)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to count URLs by tag in MongoDB: %w", err)
+	}
+	return urls, total, nil
+}
+
+// Search returns up to limit undeleted entries whose original URL contains query, in _id order.
+// The escaped query is matched with a case-insensitive regex, which cannot use an index;
+// it is meant for occasional admin lookups rather than hot paths.
+func (s *MongoUrlStore) Search(ctx context.Context, query string, limit int64) ([]domain.URL, error) {
+	ctx, span := tracer.Start(ctx, "store.Search", trace.WithAttributes(attribute.Int64("limit", limit)))
+	defer span.End()
+
+	findOptions := options.Find().
+		SetSort(bson.D{{Key: "_id", Value: 1}}).
+		SetLimit(limit)
+	results, err := s.collection.Find(ctx, searchFilter(query), findOptions)
+	if err != nil {
+		return nil, fmt.Errorf("failed to search URLs in MongoDB: %w", err)
+	}
+	urls := []domain.URL{}
+	if err := results.All(ctx, &urls); err != nil {
+		return nil, fmt.Errorf("failed to decode searched URLs: %w", err)
+	}
+	return urls, nil
+}
+
+// ListAliases returns the short IDs of the (undeleted) aliases of primaryID.
+func (s *MongoUrlStore) ListAliases(ctx context.Context, primaryID string) ([]string, error) {
+	ctx, span := tracer.Start(ctx, "store.ListAliases", trace.WithAttributes(attribute.String(tracing.AttrShortID, primaryID)))
+	defer span.End()
+
+	findOptions := options.Find().
+		SetSort(bson.D{{Key: "_id", Value: 1}}).
+		SetProjection(bson.M{"_id": 1})
+	filter := bson.M{"alias_of": primaryID, "deleted_at": bson.M{"$exists": false}}
+	results, err := s.collection.Find(ctx, filter, findOptions)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list aliases in MongoDB: %w", err)
+	}
+	var docs []struct {
+		ID string `bson:"_id"`
+	}
+	if err := results.All(ctx, &docs); err != nil {
+		return nil, fmt.Errorf("failed to decode aliases: %w", err)
+	}
+	aliases := make([]string, 0, len(docs))
+	for _, doc := range docs {
+		aliases = append(aliases, doc.ID)
+	}
+	return aliases, nil
+}
+
+// UpdateTags replaces the tags of an entry and records the modification time.
+// It returns ErrURLNotFound if no (undeleted) entry has the short ID.
+func (s *MongoUrlStore) UpdateTags(ctx context.Context, shortID string, tags []string) error {
+	ctx, span := tracer.Start(ctx, "store.UpdateTags", trace.WithAttributes(attribute.String(tracing.AttrShortID, shortID)))
+	defer span.End()
+
+	filter := bson.M{"_id": shortID, "deleted_at": bson.M{"$exists": false}}
+	update := bson.M{"$set": bson.M{"tags": tags, "updated_at": time.Now().UTC()}}
+	if len(tags) == 0 {
+		update = bson.M{"$set": bson.M{"updated_at": time.Now().UTC()}, "$unset": bson.M{"tags": ""}}
+	}
+	var updated domain.URL
+	err := s.collection.FindOneAndUpdate(ctx, filter, update).Decode(&updated)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return fmt.Errorf("%w: '%s'", ErrURLNotFound, shortID)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to update tags in MongoDB: %w", err)
+	}
+	s.recordAudit(ctx, domain.AuditEventUpdate, shortID, updated.OriginalUrl)
+	return nil
+}
+
+// UpdateMetadata sets the page title and description of an entry. The metadata is derived from
+// the destination rather than changed by a user, so updated_at and the audit log are left alone.
+// It returns ErrURLNotFound if no (undeleted) entry has the short ID.
+func (s *MongoUrlStore) UpdateMetadata(ctx context.Context, shortID, title, description string) error {
+	ctx, span := tracer.Start(ctx, "store.UpdateMetadata", trace.WithAttributes(attribute.String(tracing.AttrShortID, shortID)))
+	defer span.End()
+
+	filter := bson.M{"_id": shortID, "deleted_at": bson.M{"$exists": false}}
+	update := bson.M{"$set": bson.M{"page_title": title, "page_description": description}}
+	result, err := s.collection.UpdateOne(ctx, filter, update)
+	if err != nil {
+		return fmt.Errorf("failed to update page metadata in MongoDB: %w", err)
+	}
+	if result.MatchedCount == 0 {
+		return fmt.Errorf("%w: '%s'", ErrURLNotFound, shortID)
+	}
+	return nil
+}
+
+// Undelete clears deleted_at on a soft-deleted entry.
+// It returns ErrURLNotFound if no deleted entry has the short ID.
+func (s *MongoUrlStore) Undelete(ctx context.Context, shortID string) error {
+	ctx, span := tracer.Start(ctx, "store.Undelete", trace.WithAttributes(attribute.String(tracing.AttrShortID, shortID)))
+	defer span.End()
+
+	var restored domain.URL
+	filter := bson.M{"_id": shortID, "deleted_at": bson.M{"$exists": true}}
+	update := bson.M{"$unset": bson.M{"deleted_at": ""}}
+	err := s.collection.FindOneAndUpdate(ctx, filter, update).Decode(&restored)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return fmt.Errorf("%w: no deleted entry '%s'", ErrURLNotFound, shortID)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to undelete URL in MongoDB: %w", err)
+	}
+	s.recordAudit(ctx, domain.AuditEventUndelete, shortID, restored.OriginalUrl)
+	return nil
+}
+
+// Ping checks that the MongoDB server is reachable.
+func (s *MongoUrlStore) Ping(ctx context.Context) error {
+	ctx, span := tracer.Start(ctx, "store.Ping")
+	defer span.End()
+
+	return s.collection.Database().Client().Ping(ctx, nil)
+}
+
+// Ready checks that the URL collection exists and can answer a lightweight count query.
+func (s *MongoUrlStore) Ready(ctx context.Context) error {
+	ctx, span := tracer.Start(ctx, "store.Ready")
+	defer span.End()
+
+	names, err := s.collection.Database().ListCollectionNames(ctx, bson.M{"name": s.collection.Name()})
+	if err != nil {
+		return fmt.Errorf("failed to list collections: %w", err)
+	}
+	if len(names) == 0 {
+		return fmt.Errorf("collection '%s' does not exist", s.collection.Name())
+	}
+	if _, err := s.collection.CountDocuments(ctx, bson.M{}, options.Count().SetLimit(1)); err != nil {
+		return fmt.Errorf("failed to query collection '%s': %w", s.collection.Name(), err)
+	}
+	return nil
+}
@@ -0,0 +1,109 @@
+package store
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"testing"
+	"time"
+
+	"shawty/internal/domain"
+
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+func TestMongoEnsureIndexesCreatesTTLIndexes(t *testing.T) {
+	ctx := context.Background()
+	s := newTestMongoStore(t)
+
+	cursor, err := s.collection.Indexes().List(ctx)
+	if err != nil {
+		t.Fatalf("listing indexes: %v", err)
+	}
+	var indexes []bson.M
+	if err := cursor.All(ctx, &indexes); err != nil {
+		t.Fatalf("decoding indexes: %v", err)
+	}
+	ttl := make(map[string]bool)
+	for _, index := range indexes {
+		keys, _ := index["key"].(bson.M)
+		if _, ok := index["expireAfterSeconds"]; ok && len(keys) == 1 {
+			for field := range keys {
+				ttl[field] = true
+			}
+		}
+	}
+	for _, field := range []string{"expires_at", "active_until"} {
+		if !ttl[field] {
+			t.Errorf("no TTL index on %s; indexes: %v", field, indexes)
+		}
+	}
+}
+
+func TestMongoCountByDomain(t *testing.T) {
+	ctx := context.Background()
+	s := newTestMongoStore(t)
+	primary := "go0001"
+	entries := []domain.URL{
+		{ID: "go0001", OriginalUrl: "https://example.com/1", Domain: "go.example.com", ClickCount: 3},
+		{ID: "go0002", OriginalUrl: "https://example.com/2", Domain: "go.example.com", ClickCount: 4},
+		{ID: "s00001", OriginalUrl: "https://example.com/3", Domain: "s.example.com", ClickCount: 1},
+		{ID: "old001", OriginalUrl: "https://example.com/4"},
+		{ID: "alias1", OriginalUrl: "https://example.com/1", Domain: "s.example.com", AliasOf: &primary},
+	}
+	for _, entry := range entries {
+		entry.ShortUrl, entry.CreationDate = entry.ID, time.Now().UTC()
+		if err := s.Save(ctx, entry); err != nil {
+			t.Fatalf("Save: %v", err)
+		}
+	}
+
+	stats, err := s.CountByDomain(ctx)
+	if err != nil {
+		t.Fatalf("CountByDomain: %v", err)
+	}
+	want := []domain.DomainStats{
+		{Domain: "go.example.com", Links: 2, Clicks: 7},
+		{Domain: "", Links: 1},
+		{Domain: "s.example.com", Links: 1, Clicks: 1},
+	}
+	if !slices.Equal(stats, want) {
+		t.Errorf("CountByDomain = %+v, want %+v", stats, want)
+	}
+}
+
+// benchmarkURLCount is the size of the collection the count benchmarks run on.
+const benchmarkURLCount = 1_000_000
+
+// BenchmarkMongoCount compares EstimatedCount, which reads collection metadata, with
+// CountDocuments, which scans the collection, on a collection of benchmarkURLCount entries.
+func BenchmarkMongoCount(b *testing.B) {
+	ctx := context.Background()
+	s := newTestMongoStore(b)
+	batch := make([]any, 0, 10_000)
+	for i := range benchmarkURLCount {
+		id := fmt.Sprintf("bench%07d", i)
+		batch = append(batch, domain.URL{ID: id, ShortUrl: id, OriginalUrl: "https://example.com/" + id, CreationDate: time.Now().UTC()})
+		if len(batch) == cap(batch) || i == benchmarkURLCount-1 {
+			if _, err := s.collection.InsertMany(ctx, batch); err != nil {
+				b.Fatalf("seeding the collection: %v", err)
+			}
+			batch = batch[:0]
+		}
+	}
+
+	b.Run("EstimatedCount", func(b *testing.B) {
+		for b.Loop() {
+			if _, err := s.EstimatedCount(ctx); err != nil {
+				b.Fatalf("EstimatedCount: %v", err)
+			}
+		}
+	})
+	b.Run("CountDocuments", func(b *testing.B) {
+		for b.Loop() {
+			if _, err := s.collection.CountDocuments(ctx, bson.M{}); err != nil {
+				b.Fatalf("CountDocuments: %v", err)
+			}
+		}
+	})
+}
@@ -0,0 +1,60 @@
+package store
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"testing"
+	"time"
+
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// testMongoURIEnv names the environment variable holding the URI of a MongoDB server for the
+// integration tests, e.g. mongodb://localhost:27017. The tests are skipped when it is unset.
+const testMongoURIEnv = "SHAWTY_TEST_MONGO_URI"
+
+// newTestMongoStore returns a MongoUrlStore with its indexes on a database of its own, which is
+// dropped when the test ends.
+func newTestMongoStore(t testing.TB) *MongoUrlStore {
+	t.Helper()
+	db := newTestMongoDatabase(t)
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	s := NewMongoUrlStore(db.Client(), db.Name(), "urls")
+	if err := s.EnsureIndexes(ctx); err != nil {
+		t.Fatalf("EnsureIndexes: %v", err)
+	}
+	return s
+}
+
+// newTestMongoDatabase returns an empty database of its own on the test server, which is dropped
+// when the test ends.
+func newTestMongoDatabase(t testing.TB) *mongo.Database {
+	t.Helper()
+	uri := os.Getenv(testMongoURIEnv)
+	if uri == "" {
+		t.Skipf("%s is not set", testMongoURIEnv)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	client, err := mongo.Connect(ctx, options.Client().ApplyURI(uri))
+	if err != nil {
+		t.Fatalf("connecting to MongoDB: %v", err)
+	}
+	if err := client.Ping(ctx, nil); err != nil {
+		t.Fatalf("pinging MongoDB: %v", err)
+	}
+	dbName := fmt.Sprintf("shawty_test_%d", time.Now().UnixNano())
+	t.Cleanup(func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+		if err := client.Database(dbName).Drop(ctx); err != nil {
+			t.Logf("dropping test database %s: %v", dbName, err)
+		}
+		client.Disconnect(ctx)
+	})
+	return client.Database(dbName)
+}
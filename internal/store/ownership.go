@@ -0,0 +1,78 @@
+package store
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"shawty/internal/domain"
+	"shawty/internal/tracing"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// ErrTenantNotFound is returned when a short URL is transferred to a tenant that has no document
+// in the tenants collection.
+var ErrTenantNotFound = errors.New("tenant not found")
+
+// ErrOwnershipUnsupported is returned by decorating stores whose inner store cannot transfer links.
+var ErrOwnershipUnsupported = errors.New("store cannot transfer URLs between tenants")
+
+// OwnershipStoreInterface is implemented by stores that can move a short URL to another tenant.
+type OwnershipStoreInterface interface {
+	// TransferOwnership sets the tenant of shortID to newOwnerID and returns the tenant it had
+	// before, "" for a link without one. It returns ErrTenantNotFound if newOwnerID is not in the
+	// tenants collection and ErrURLNotFound if no (undeleted) entry has the short ID. Aliases of
+	// the entry keep their own tenant.
+	TransferOwnership(ctx context.Context, shortID, newOwnerID string) (string, error)
+}
+
+// transferOwnership passes a transfer on to inner, for the decorating stores.
+func transferOwnership(ctx context.Context, inner UrlStoreInterface, shortID, newOwnerID string) (string, error) {
+	ownershipStore, ok := inner.(OwnershipStoreInterface)
+	if !ok {
+		return "", ErrOwnershipUnsupported
+	}
+	return ownershipStore.TransferOwnership(ctx, shortID, newOwnerID)
+}
+
+// TransferOwnership sets tenant_id of an entry with $set and records a transfer audit event.
+// The caching stores evict the entry on the way back, so transfers should go through them.
+func (s *MongoUrlStore) TransferOwnership(ctx context.Context, shortID, newOwnerID string) (string, error) {
+	ctx, span := tracer.Start(ctx, "store.TransferOwnership", trace.WithAttributes(attribute.String(tracing.AttrShortID, shortID)))
+	defer span.End()
+
+	err := s.tenantsCollection.FindOne(ctx, bson.M{"_id": newOwnerID}, options.FindOne().SetProjection(bson.M{"_id": 1})).Err()
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return "", fmt.Errorf("%w: '%s'", ErrTenantNotFound, newOwnerID)
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to look up tenant '%s' in MongoDB: %w", newOwnerID, err)
+	}
+
+	// The entry is read as it was before the update, for its previous tenant.
+	var previous domain.URL
+	filter := bson.M{"_id": shortID, "deleted_at": bson.M{"$exists": false}}
+	update := bson.M{"$set": bson.M{"tenant_id": newOwnerID}}
+	err = s.collection.FindOneAndUpdate(ctx, filter, update).Decode(&previous)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return "", fmt.Errorf("%w: '%s'", ErrURLNotFound, shortID)
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to transfer URL in MongoDB: %w", err)
+	}
+	if previous.TenantID != newOwnerID {
+		s.writeAudit(ctx, domain.AuditEvent{
+			EventType:     domain.AuditEventTransfer,
+			ShortID:       shortID,
+			OriginalURL:   previous.OriginalUrl,
+			PreviousOwner: previous.TenantID,
+			NewOwner:      newOwnerID,
+		})
+	}
+	return previous.TenantID, nil
+}
@@ -0,0 +1,553 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	_ "embed"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"shawty/internal/domain"
+)
+
+// postgresSchema creates the urls table. It is applied by EnsureIndexes.
+//
+//go:embed schema.sql
+var postgresSchema string
+
+// urlColumns lists the columns scanned by scanURL, in order.
+const urlColumns = "id, original_url, short_url, creation_date, expires_at, click_count, last_accessed_at, updated_at, deleted_at, submitted_url, redirect_type, password_hash, max_clicks, utm, tags, webhook_url, mobile_url, mobile_click_count, page_title, page_description, tenant_id, alias_of, variants, active_from, active_until, bundle, bundle_title, hash_algo, custom_headers, conversion_count, domain"
+
+// PostgresUrlStore implements UrlStoreInterface using PostgreSQL through database/sql.
+// The caller opens the *sql.DB with the pgx driver ("pgx") and owns its lifecycle.
+type PostgresUrlStore struct {
+	db *sql.DB
+}
+
+// NewPostgresUrlStore creates a new PostgresUrlStore.
+func NewPostgresUrlStore(db *sql.DB) *PostgresUrlStore {
+	return &PostgresUrlStore{db: db}
+}
+
+// EnsureIndexes creates the urls table if needed and indexes on original_url and tags.
+// The index is built CONCURRENTLY so that starting a new instance never locks the table for writes.
+func (s *PostgresUrlStore) EnsureIndexes(ctx context.Context) error {
+	if _, err := s.db.ExecContext(ctx, postgresSchema); err != nil {
+		return fmt.Errorf("failed to create urls table: %w", err)
+	}
+	if _, err := s.db.ExecContext(ctx, "CREATE INDEX CONCURRENTLY IF NOT EXISTS urls_original_url_idx ON urls (original_url)"); err != nil {
+		return fmt.Errorf("failed to create index on original_url: %w", err)
+	}
+	// A GIN index answers the tags @> containment queries of ListByTag.
+	if _, err := s.db.ExecContext(ctx, "CREATE INDEX CONCURRENTLY IF NOT EXISTS urls_tags_idx ON urls USING GIN (tags)"); err != nil {
+		return fmt.Errorf("failed to create index on tags: %w", err)
+	}
+	slog.InfoContext(ctx, "Ensured PostgreSQL schema and indexes")
+	return nil
+}
+
+// Save inserts a new URL entry, returning ErrDuplicateShortID if the ID is already present.
+func (s *PostgresUrlStore) Save(ctx context.Context, urlEntry domain.URL) error {
+	utm, err := utmColumn(urlEntry.UTM)
+	if err != nil {
+		return err
+	}
+	tags, err := tagsColumn(urlEntry.Tags)
+	if err != nil {
+		return err
+	}
+	variants, err := variantsColumn(urlEntry.Variants)
+	if err != nil {
+		return err
+	}
+	customHeaders, err := customHeadersColumn(urlEntry.CustomHeaders)
+	if err != nil {
+		return err
+	}
+
+	var insertedID string
+	err = s.db.QueryRowContext(ctx,
+		`INSERT INTO urls (id, original_url, short_url, creation_date, expires_at, click_count, submitted_url, redirect_type, password_hash, max_clicks, utm, tags, webhook_url, mobile_url, mobile_click_count, page_title, page_description, tenant_id, alias_of, variants, active_from, active_until, bundle, bundle_title, hash_algo, custom_headers, conversion_count, domain)
+		 VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17, $18, $19, $20, $21, $22, $23, $24, $25, $26, $27, $28)
+		 ON CONFLICT (id) DO NOTHING
+		 RETURNING id`,
+		urlEntry.ID, urlEntry.OriginalUrl, urlEntry.ShortUrl, urlEntry.CreationDate, urlEntry.ExpiresAt, urlEntry.ClickCount, nullString(urlEntry.SubmittedUrl), nullString(urlEntry.RedirectType), nullString(urlEntry.PasswordHash), urlEntry.MaxClicks, utm, tags, nullString(urlEntry.WebhookURL), urlEntry.MobileURL, urlEntry.MobileClicks, nullString(urlEntry.PageTitle), nullString(urlEntry.PageDescription), nullString(urlEntry.TenantID), urlEntry.AliasOf, variants, urlEntry.ActiveFrom, urlEntry.ActiveUntil, urlEntry.Bundle, nullString(urlEntry.BundleTitle), nullString(urlEntry.HashAlgo), customHeaders, urlEntry.ConversionCount, nullString(urlEntry.Domain),
+	).Scan(&insertedID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			// ON CONFLICT DO NOTHING returns no row when the ID already exists
+			return ErrDuplicateShortID
+		}
+		return fmt.Errorf("failed to insert URL into PostgreSQL: %w", err)
+	}
+	return nil
+}
+
+// GetByShortID retrieves a URL entry by its short ID, following an alias to its primary.
+func (s *PostgresUrlStore) GetByShortID(ctx context.Context, shortID, tenantID string) (domain.URL, error) {
+	return followAlias(ctx, shortID, tenantID, s.getByShortID)
+}
+
+// getByShortID retrieves the row with the short ID as stored, aliases included.
+func (s *PostgresUrlStore) getByShortID(ctx context.Context, shortID, tenantID string) (domain.URL, error) {
+	row := s.db.QueryRowContext(ctx, "SELECT "+urlColumns+" FROM urls WHERE id = $1 AND ($2 = '' OR tenant_id = $2)", shortID, tenantID)
+	urlEntry, err := scanURL(row)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return domain.URL{}, errNotFound(shortID)
+		}
+		return domain.URL{}, fmt.Errorf("error retrieving URL from PostgreSQL: %w", err)
+	}
+	if urlEntry.IsDeleted() {
+		return domain.URL{}, fmt.Errorf("%w: '%s'", ErrURLDeleted, shortID)
+	}
+	return urlEntry, nil
+}
+
+// GetByOriginalURL retrieves an (undeleted) URL entry by its original URL.
+// It returns ErrURLNotFound if the URL has not been shortened.
+func (s *PostgresUrlStore) GetByOriginalURL(ctx context.Context, originalURL string) (domain.URL, error) {
+	row := s.db.QueryRowContext(ctx, "SELECT "+urlColumns+" FROM urls WHERE original_url = $1 AND deleted_at IS NULL LIMIT 1", originalURL)
+	urlEntry, err := scanURL(row)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return domain.URL{}, fmt.Errorf("%w: no entry for '%s'", ErrURLNotFound, originalURL)
+		}
+		return domain.URL{}, fmt.Errorf("error retrieving URL by original URL from PostgreSQL: %w", err)
+	}
+	return urlEntry, nil
+}
+
+// EstimatedCount returns an approximate number of URL entries from the planner statistics.
+// Before the table has been analyzed the statistics are empty, so it falls back to an exact count.
+func (s *PostgresUrlStore) EstimatedCount(ctx context.Context) (int64, error) {
+	var estimate int64
+	err := s.db.QueryRowContext(ctx, "SELECT reltuples::BIGINT FROM pg_class WHERE relname = 'urls'").Scan(&estimate)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return 0, fmt.Errorf("failed to estimate URL count: %w", err)
+	}
+	if err == nil && estimate >= 0 {
+		return estimate, nil
+	}
+
+	var count int64
+	if err := s.db.QueryRowContext(ctx, "SELECT COUNT(*) FROM urls").Scan(&count); err != nil {
+		return 0, fmt.Errorf("failed to count URLs: %w", err)
+	}
+	return count, nil
+}
+
+// IncrementClickCount atomically increments the click counter of a URL entry and records the access time.
+// Click-limited entries are only incremented while they have clicks left; once they are used up it
+// returns ErrClickLimitReached.
+func (s *PostgresUrlStore) IncrementClickCount(ctx context.Context, shortID string, mobile bool) (int64, error) {
+	var clickCount int64
+	err := s.db.QueryRowContext(ctx,
+		`UPDATE urls SET click_count = click_count + 1, last_accessed_at = $2,
+		   mobile_click_count = mobile_click_count + CASE WHEN $3 THEN 1 ELSE 0 END
+		 WHERE id = $1 AND (max_clicks IS NULL OR click_count < max_clicks)
+		 RETURNING click_count`,
+		shortID, time.Now().UTC(), mobile,
+	).Scan(&clickCount)
+	if errors.Is(err, sql.ErrNoRows) {
+		// Either the entry does not exist or its limit filtered it out.
+		var exists bool
+		if err := s.db.QueryRowContext(ctx, "SELECT EXISTS (SELECT 1 FROM urls WHERE id = $1)", shortID).Scan(&exists); err != nil {
+			return 0, fmt.Errorf("failed to check click-limited URL in PostgreSQL: %w", err)
+		}
+		if exists {
+			return 0, fmt.Errorf("%w: '%s'", ErrClickLimitReached, shortID)
+		}
+		return 0, fmt.Errorf("%w: '%s'", ErrURLNotFound, shortID)
+	}
+	if err != nil {
+		return 0, fmt.Errorf("failed to increment click count in PostgreSQL: %w", err)
+	}
+	return clickCount, nil
+}
+
+// List returns a page of URL entries in id order, starting after cursor, and the cursor of the next page.
+func (s *PostgresUrlStore) List(ctx context.Context, tenantID, cursor string, limit int64, includeDeleted bool) ([]domain.URL, string, error) {
+	after, err := decodeCursor(cursor)
+	if err != nil {
+		return nil, "", err
+	}
+	// One extra row tells whether another page follows.
+	rows, err := s.db.QueryContext(ctx,
+		"SELECT "+urlColumns+" FROM urls WHERE ($3 OR deleted_at IS NULL) AND ($4 = '' OR tenant_id = $4) AND id > $1 ORDER BY id LIMIT $2",
+		after, limit+1, includeDeleted, tenantID,
+	)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to list URLs from PostgreSQL: %w", err)
+	}
+	defer rows.Close()
+
+	urls := []domain.URL{}
+	for rows.Next() {
+		urlEntry, err := scanURL(rows)
+		if err != nil {
+			return nil, "", fmt.Errorf("failed to decode listed URL: %w", err)
+		}
+		urls = append(urls, urlEntry)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, "", fmt.Errorf("failed to list URLs from PostgreSQL: %w", err)
+	}
+	urls, next := nextPage(urls, limit)
+	return urls, next, nil
+}
+
+// Delete removes a URL entry, or marks it with deleted_at in soft-delete builds.
+// It returns ErrURLNotFound if no (undeleted) entry has the short ID.
+func (s *PostgresUrlStore) Delete(ctx context.Context, shortID string) error {
+	var (
+		result sql.Result
+		err    error
+	)
+	if SoftDelete {
+		result, err = s.db.ExecContext(ctx, "UPDATE urls SET deleted_at = $2 WHERE id = $1 AND deleted_at IS NULL", shortID, time.Now().UTC())
+	} else {
+		result, err = s.db.ExecContext(ctx, "DELETE FROM urls WHERE id = $1", shortID)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to delete URL from PostgreSQL: %w", err)
+	}
+	return requireAffected(result, shortID)
+}
+
+// Update changes the original URL of an entry and records the modification time.
+// It returns ErrURLNotFound if no (undeleted) entry has the short ID.
+func (s *PostgresUrlStore) Update(ctx context.Context, shortID, newOriginalURL string) error {
+	result, err := s.db.ExecContext(ctx,
+		"UPDATE urls SET original_url = $2, updated_at = $3 WHERE id = $1 AND deleted_at IS NULL",
+		shortID, newOriginalURL, time.Now().UTC(),
+	)
+	if err != nil {
+		return fmt.Errorf("failed to update URL in PostgreSQL: %w", err)
+	}
+	return requireAffected(result, shortID)
+}
+
+// ForEach streams every entry in id order, calling fn for each.
+func (s *PostgresUrlStore) ForEach(ctx context.Context, fn func(domain.URL) error) error {
+	rows, err := s.db.QueryContext(ctx, "SELECT "+urlColumns+" FROM urls ORDER BY id")
+	if err != nil {
+		return fmt.Errorf("failed to read URLs from PostgreSQL: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		urlEntry, err := scanURL(rows)
+		if err != nil {
+			return fmt.Errorf("failed to decode URL: %w", err)
+		}
+		if err := fn(urlEntry); err != nil {
+			return err
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("failed to read URLs from PostgreSQL: %w", err)
+	}
+	return nil
+}
+
+// ListByTag returns a page of undeleted entries carrying tag in id order, along with their total.
+func (s *PostgresUrlStore) ListByTag(ctx context.Context, tag string, limit, offset int64) ([]domain.URL, int64, error) {
+	rows, err := s.db.QueryContext(ctx,
+		"SELECT "+urlColumns+" FROM urls WHERE deleted_at IS NULL AND tags @> jsonb_build_array($1::text) ORDER BY id OFFSET $2 LIMIT $3",
+		tag, offset, limit,
+	)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to list URLs by tag from PostgreSQL: %w", err)
+	}
+	defer rows.Close()
+
+	urls := []domain.URL{}
+	for rows.Next() {
+		urlEntry, err := scanURL(rows)
+		if err != nil {
+			return nil, 0, fmt.Errorf("failed to decode URL listed by tag: %w", err)
+		}
+		urls = append(urls, urlEntry)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, 0, fmt.Errorf("failed to list URLs by tag from PostgreSQL: %w", err)
+	}
+
+	var total int64
+	err = s.db.QueryRowContext(ctx,
+		"SELECT COUNT(*) FROM urls WHERE deleted_at IS NULL AND tags @> jsonb_build_array($1::text)", tag,
+	).Scan(&total)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to count URLs by tag in PostgreSQL: %w", err)
+	}
+	return urls, total, nil
+}
+
+// Search returns up to limit undeleted entries whose original URL contains query, ignoring case, in id order.
+// LIKE wildcards in query are escaped, so it is matched literally.
+func (s *PostgresUrlStore) Search(ctx context.Context, query string, limit int64) ([]domain.URL, error) {
+	rows, err := s.db.QueryContext(ctx,
+		`SELECT `+urlColumns+` FROM urls WHERE deleted_at IS NULL AND original_url ILIKE $1 ESCAPE '\' ORDER BY id LIMIT $2`,
+		likePattern(query), limit,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to search URLs in PostgreSQL: %w", err)
+	}
+	defer rows.Close()
+
+	urls := []domain.URL{}
+	for rows.Next() {
+		urlEntry, err := scanURL(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to decode searched URL: %w", err)
+		}
+		urls = append(urls, urlEntry)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to search URLs in PostgreSQL: %w", err)
+	}
+	return urls, nil
+}
+
+// ListAliases returns the short IDs of the (undeleted) aliases of primaryID.
+func (s *PostgresUrlStore) ListAliases(ctx context.Context, primaryID string) ([]string, error) {
+	rows, err := s.db.QueryContext(ctx, "SELECT id FROM urls WHERE alias_of = $1 AND deleted_at IS NULL ORDER BY id", primaryID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list aliases in PostgreSQL: %w", err)
+	}
+	defer rows.Close()
+
+	aliases := []string{}
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			return nil, fmt.Errorf("failed to decode alias: %w", err)
+		}
+		aliases = append(aliases, id)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to list aliases in PostgreSQL: %w", err)
+	}
+	return aliases, nil
+}
+
+// UpdateTags replaces the tags of an entry and records the modification time.
+// It returns ErrURLNotFound if no (undeleted) entry has the short ID.
+func (s *PostgresUrlStore) UpdateTags(ctx context.Context, shortID string, tags []string) error {
+	encoded, err := tagsColumn(tags)
+	if err != nil {
+		return err
+	}
+	result, err := s.db.ExecContext(ctx,
+		"UPDATE urls SET tags = $2, updated_at = $3 WHERE id = $1 AND deleted_at IS NULL",
+		shortID, encoded, time.Now().UTC(),
+	)
+	if err != nil {
+		return fmt.Errorf("failed to update tags in PostgreSQL: %w", err)
+	}
+	return requireAffected(result, shortID)
+}
+
+// IncrementConversionCount atomically increments the conversion counter of a URL entry.
+func (s *PostgresUrlStore) IncrementConversionCount(ctx context.Context, shortID string) error {
+	result, err := s.db.ExecContext(ctx, "UPDATE urls SET conversion_count = conversion_count + 1 WHERE id = $1", shortID)
+	if err != nil {
+		return fmt.Errorf("failed to increment conversion count in PostgreSQL: %w", err)
+	}
+	return requireAffected(result, shortID)
+}
+
+// UpdateMetadata sets the page title and description of an entry.
+// It returns ErrURLNotFound if no (undeleted) entry has the short ID.
+func (s *PostgresUrlStore) UpdateMetadata(ctx context.Context, shortID, title, description string) error {
+	result, err := s.db.ExecContext(ctx,
+		"UPDATE urls SET page_title = $2, page_description = $3 WHERE id = $1 AND deleted_at IS NULL",
+		shortID, nullString(title), nullString(description),
+	)
+	if err != nil {
+		return fmt.Errorf("failed to update page metadata in PostgreSQL: %w", err)
+	}
+	return requireAffected(result, shortID)
+}
+
+// Undelete clears deleted_at on a soft-deleted entry.
+// It returns ErrURLNotFound if no deleted entry has the short ID.
+func (s *PostgresUrlStore) Undelete(ctx context.Context, shortID string) error {
+	result, err := s.db.ExecContext(ctx, "UPDATE urls SET deleted_at = NULL WHERE id = $1 AND deleted_at IS NOT NULL", shortID)
+	if err != nil {
+		return fmt.Errorf("failed to undelete URL in PostgreSQL: %w", err)
+	}
+	return requireAffected(result, shortID)
+}
+
+// Ping checks that the PostgreSQL server is reachable.
+func (s *PostgresUrlStore) Ping(ctx context.Context) error {
+	return s.db.PingContext(ctx)
+}
+
+// Ready checks that the urls table exists and can answer a query.
+func (s *PostgresUrlStore) Ready(ctx context.Context) error {
+	var one int
+	err := s.db.QueryRowContext(ctx, "SELECT 1 FROM urls LIMIT 1").Scan(&one)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return fmt.Errorf("failed to query urls table: %w", err)
+	}
+	return nil
+}
+
+// rowScanner is satisfied by *sql.Row and *sql.Rows.
+type rowScanner interface {
+	Scan(dest ...any) error
+}
+
+// scanURL reads one row selected with urlColumns into a domain.URL.
+func scanURL(row rowScanner) (domain.URL, error) {
+	var (
+		urlEntry                                      domain.URL
+		expiresAt, lastAccessed, updatedAt, deletedAt sql.NullTime
+		activeFrom, activeUntil                       sql.NullTime
+		clickCount, maxClicks, mobileClicks           sql.NullInt64
+		conversionCount                               sql.NullInt64
+		submittedURL, redirectType, passwordHash, utm sql.NullString
+		tags, webhookURL, mobileURL                   sql.NullString
+		pageTitle, pageDescription, tenantID          sql.NullString
+		aliasOf, variants, bundleTitle, hashAlgo      sql.NullString
+		customHeaders, linkDomain                     sql.NullString
+		bundle                                        sql.NullBool
+	)
+	err := row.Scan(&urlEntry.ID, &urlEntry.OriginalUrl, &urlEntry.ShortUrl, &urlEntry.CreationDate,
+		&expiresAt, &clickCount, &lastAccessed, &updatedAt, &deletedAt, &submittedURL, &redirectType, &passwordHash, &maxClicks, &utm, &tags, &webhookURL, &mobileURL, &mobileClicks, &pageTitle, &pageDescription, &tenantID, &aliasOf, &variants, &activeFrom, &activeUntil, &bundle, &bundleTitle, &hashAlgo, &customHeaders, &conversionCount, &linkDomain)
+	if err != nil {
+		return domain.URL{}, err
+	}
+	urlEntry.CreationDate = urlEntry.CreationDate.UTC()
+	urlEntry.ExpiresAt = nullTimePtr(expiresAt)
+	urlEntry.ClickCount = clickCount.Int64
+	urlEntry.LastAccessedAt = nullTimePtr(lastAccessed)
+	urlEntry.UpdatedAt = nullTimePtr(updatedAt)
+	urlEntry.DeletedAt = nullTimePtr(deletedAt)
+	urlEntry.ActiveFrom = nullTimePtr(activeFrom)
+	urlEntry.ActiveUntil = nullTimePtr(activeUntil)
+	urlEntry.SubmittedUrl = submittedURL.String
+	urlEntry.RedirectType = redirectType.String
+	urlEntry.PasswordHash = passwordHash.String
+	urlEntry.WebhookURL = webhookURL.String
+	urlEntry.MobileClicks = mobileClicks.Int64
+	urlEntry.ConversionCount = conversionCount.Int64
+	urlEntry.PageTitle = pageTitle.String
+	urlEntry.PageDescription = pageDescription.String
+	urlEntry.TenantID = tenantID.String
+	urlEntry.Bundle = bundle.Bool
+	urlEntry.BundleTitle = bundleTitle.String
+	urlEntry.HashAlgo = hashAlgo.String
+	urlEntry.Domain = linkDomain.String
+	if mobileURL.Valid {
+		urlEntry.MobileURL = &mobileURL.String
+	}
+	if aliasOf.Valid {
+		urlEntry.AliasOf = &aliasOf.String
+	}
+	if maxClicks.Valid {
+		urlEntry.MaxClicks = &maxClicks.Int64
+	}
+	if utm.Valid {
+		urlEntry.UTM = &domain.UTMParams{}
+		if err := json.Unmarshal([]byte(utm.String), urlEntry.UTM); err != nil {
+			return domain.URL{}, fmt.Errorf("failed to decode utm column: %w", err)
+		}
+	}
+	if tags.Valid {
+		if err := json.Unmarshal([]byte(tags.String), &urlEntry.Tags); err != nil {
+			return domain.URL{}, fmt.Errorf("failed to decode tags column: %w", err)
+		}
+	}
+	if variants.Valid {
+		if err := json.Unmarshal([]byte(variants.String), &urlEntry.Variants); err != nil {
+			return domain.URL{}, fmt.Errorf("failed to decode variants column: %w", err)
+		}
+	}
+	if customHeaders.Valid {
+		if err := json.Unmarshal([]byte(customHeaders.String), &urlEntry.CustomHeaders); err != nil {
+			return domain.URL{}, fmt.Errorf("failed to decode custom_headers column: %w", err)
+		}
+	}
+	return urlEntry, nil
+}
+
+// nullTimePtr converts a nullable column to the optional-time convention used by domain.URL.
+func nullTimePtr(t sql.NullTime) *time.Time {
+	if !t.Valid {
+		return nil
+	}
+	utc := t.Time.UTC()
+	return &utc
+}
+
+// utmColumn encodes UTM parameters for the JSONB utm column, storing nil as NULL.
+func utmColumn(utm *domain.UTMParams) (sql.NullString, error) {
+	if utm == nil {
+		return sql.NullString{}, nil
+	}
+	encoded, err := json.Marshal(utm)
+	if err != nil {
+		return sql.NullString{}, fmt.Errorf("failed to encode UTM parameters: %w", err)
+	}
+	return sql.NullString{String: string(encoded), Valid: true}, nil
+}
+
+// tagsColumn encodes tags as a JSONB array for the tags column, storing no tags as NULL.
+func tagsColumn(tags []string) (sql.NullString, error) {
+	if len(tags) == 0 {
+		return sql.NullString{}, nil
+	}
+	encoded, err := json.Marshal(tags)
+	if err != nil {
+		return sql.NullString{}, fmt.Errorf("failed to encode tags: %w", err)
+	}
+	return sql.NullString{String: string(encoded), Valid: true}, nil
+}
+
+// variantsColumn encodes A/B test variants as a JSONB array for the variants column, storing none as NULL.
+func variantsColumn(variants []domain.Variant) (sql.NullString, error) {
+	if len(variants) == 0 {
+		return sql.NullString{}, nil
+	}
+	encoded, err := json.Marshal(variants)
+	if err != nil {
+		return sql.NullString{}, fmt.Errorf("failed to encode variants: %w", err)
+	}
+	return sql.NullString{String: string(encoded), Valid: true}, nil
+}
+
+// customHeadersColumn encodes custom response headers as a JSONB object for the custom_headers column, storing none as NULL.
+func customHeadersColumn(headers map[string]string) (sql.NullString, error) {
+	if len(headers) == 0 {
+		return sql.NullString{}, nil
+	}
+	encoded, err := json.Marshal(headers)
+	if err != nil {
+		return sql.NullString{}, fmt.Errorf("failed to encode custom headers: %w", err)
+	}
+	return sql.NullString{String: string(encoded), Valid: true}, nil
+}
+
+// nullString stores empty strings as NULL.
+func nullString(s string) sql.NullString {
+	return sql.NullString{String: s, Valid: s != ""}
+}
+
+// requireAffected returns ErrURLNotFound when a write statement matched no row.
+func requireAffected(result sql.Result, shortID string) error {
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to read affected rows: %w", err)
+	}
+	if affected == 0 {
+		return fmt.Errorf("%w: '%s'", ErrURLNotFound, shortID)
+	}
+	return nil
+}
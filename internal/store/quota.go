@@ -0,0 +1,69 @@
+package store
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"shawty/internal/domain"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// TenantsCollectionName is the MongoDB collection tenant quotas and link counters are kept in.
+const TenantsCollectionName = "tenants"
+
+// QuotaStoreInterface is implemented by stores that count the links each tenant creates.
+type QuotaStoreInterface interface {
+	// IncrementTenantURLCount atomically adds delta to the link counter of tenantID, creating the
+	// tenant if needed, and returns the tenant as it is after the change.
+	IncrementTenantURLCount(ctx context.Context, tenantID string, delta int64) (domain.Tenant, error)
+	// GetTenant returns tenantID. A tenant that has never been stored is returned with no quota and no links.
+	GetTenant(ctx context.Context, tenantID string) (domain.Tenant, error)
+	// SetTenantQuota sets the quota of tenantID, creating the tenant if needed, and returns the tenant.
+	SetTenantQuota(ctx context.Context, tenantID string, quota int64) (domain.Tenant, error)
+}
+
+// IncrementTenantURLCount atomically adds delta to the link counter of tenantID with $inc.
+func (s *MongoUrlStore) IncrementTenantURLCount(ctx context.Context, tenantID string, delta int64) (domain.Tenant, error) {
+	ctx, span := tracer.Start(ctx, "store.IncrementTenantURLCount")
+	defer span.End()
+
+	return s.upsertTenant(ctx, tenantID, bson.M{"$inc": bson.M{"url_count": delta}})
+}
+
+// GetTenant returns tenantID from the tenants collection.
+func (s *MongoUrlStore) GetTenant(ctx context.Context, tenantID string) (domain.Tenant, error) {
+	ctx, span := tracer.Start(ctx, "store.GetTenant")
+	defer span.End()
+
+	var tenant domain.Tenant
+	err := s.tenantsCollection.FindOne(ctx, bson.M{"_id": tenantID}).Decode(&tenant)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return domain.Tenant{ID: tenantID}, nil
+	}
+	if err != nil {
+		return domain.Tenant{}, fmt.Errorf("failed to get tenant '%s' from MongoDB: %w", tenantID, err)
+	}
+	return tenant, nil
+}
+
+// SetTenantQuota sets the quota of tenantID in the tenants collection.
+func (s *MongoUrlStore) SetTenantQuota(ctx context.Context, tenantID string, quota int64) (domain.Tenant, error) {
+	ctx, span := tracer.Start(ctx, "store.SetTenantQuota")
+	defer span.End()
+
+	return s.upsertTenant(ctx, tenantID, bson.M{"$set": bson.M{"quota": quota}})
+}
+
+// upsertTenant applies update to tenantID, creating it first if needed, and returns the updated tenant.
+func (s *MongoUrlStore) upsertTenant(ctx context.Context, tenantID string, update bson.M) (domain.Tenant, error) {
+	opts := options.FindOneAndUpdate().SetUpsert(true).SetReturnDocument(options.After)
+	var tenant domain.Tenant
+	if err := s.tenantsCollection.FindOneAndUpdate(ctx, bson.M{"_id": tenantID}, update, opts).Decode(&tenant); err != nil {
+		return domain.Tenant{}, fmt.Errorf("failed to update tenant '%s' in MongoDB: %w", tenantID, err)
+	}
+	return tenant, nil
+}
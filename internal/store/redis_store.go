@@ -0,0 +1,206 @@
+package store
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"time"
+
+	"shawty/internal/domain"
+
+	"github.com/redis/go-redis/v9"
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+// DefaultCacheTTL is how long a cached URL entry stays in Redis when no TTL is configured.
+const DefaultCacheTTL = 5 * time.Minute
+
+// CachedUrlStore decorates another UrlStoreInterface with a Redis read-through cache
+// for GetByShortID, so hot redirects don't hit the underlying database on every request.
+//
+// Redis failures never fail a request: the cache is bypassed and the inner store is used.
+// Click counts in cached entries may be stale for up to the cache TTL, since
+// IncrementClickCount does not invalidate the cached document.
+type CachedUrlStore struct {
+	inner UrlStoreInterface
+	rdb   *redis.Client
+	ttl   time.Duration
+}
+
+// NewCachedUrlStore wraps inner with a Redis cache whose entries expire after ttl.
+func NewCachedUrlStore(inner UrlStoreInterface, rdb *redis.Client, ttl time.Duration) UrlStoreInterface {
+	if ttl <= 0 {
+		ttl = DefaultCacheTTL
+	}
+	return &CachedUrlStore{inner: inner, rdb: rdb, ttl: ttl}
+}
+
+// urlCacheKey returns the Redis key under which a short ID's entry is cached.
+func urlCacheKey(shortID string) string {
+	return "shawty:url:" + shortID
+}
+
+// EnsureIndexes delegates to the inner store.
+func (s *CachedUrlStore) EnsureIndexes(ctx context.Context) error {
+	return s.inner.EnsureIndexes(ctx)
+}
+
+// Save delegates to the inner store and invalidates any cached entry for the short ID.
+func (s *CachedUrlStore) Save(ctx context.Context, urlEntry domain.URL) error {
+	err := s.inner.Save(ctx, urlEntry)
+	s.invalidate(ctx, urlEntry.ID)
+	return err
+}
+
+// GetByShortID returns the cached entry if present, otherwise loads it from the inner store and caches it.
+// Entries are cached regardless of tenant and checked against tenantID on the way out.
+func (s *CachedUrlStore) GetByShortID(ctx context.Context, shortID, tenantID string) (domain.URL, error) {
+	key := urlCacheKey(shortID)
+
+	cached, err := s.rdb.Get(ctx, key).Bytes()
+	if err == nil {
+		var urlEntry domain.URL
+		if bsonErr := bson.Unmarshal(cached, &urlEntry); bsonErr == nil {
+			if !belongsTo(urlEntry, tenantID) {
+				return domain.URL{}, errNotFound(shortID)
+			}
+			return urlEntry, nil
+		}
+		slog.WarnContext(ctx, "Discarding undecodable cache entry", slog.String("short_id", shortID))
+	} else if !errors.Is(err, redis.Nil) {
+		slog.WarnContext(ctx, "Redis GET failed, falling back to store", slog.String("short_id", shortID), slog.Any("error", err))
+	}
+
+	urlEntry, err := s.inner.GetByShortID(ctx, shortID, AnyTenant)
+	if err != nil {
+		return domain.URL{}, err
+	}
+
+	// Entries are cached as BSON rather than JSON because some fields, such as the password hash,
+	// are deliberately left out of the JSON form.
+	if encoded, bsonErr := bson.Marshal(urlEntry); bsonErr == nil {
+		if setErr := s.rdb.Set(ctx, key, encoded, s.ttl).Err(); setErr != nil {
+			slog.WarnContext(ctx, "Redis SET failed", slog.String("short_id", shortID), slog.Any("error", setErr))
+		}
+	}
+	if !belongsTo(urlEntry, tenantID) {
+		return domain.URL{}, errNotFound(shortID)
+	}
+	return urlEntry, nil
+}
+
+// GetByOriginalURL delegates to the inner store; lookups by original URL are not cached.
+func (s *CachedUrlStore) GetByOriginalURL(ctx context.Context, originalURL string) (domain.URL, error) {
+	return s.inner.GetByOriginalURL(ctx, originalURL)
+}
+
+// EstimatedCount delegates to the inner store.
+func (s *CachedUrlStore) EstimatedCount(ctx context.Context) (int64, error) {
+	return s.inner.EstimatedCount(ctx)
+}
+
+// IncrementClickCount delegates to the inner store. The cached entry is left in place
+// so that popular links stay cached; its click count catches up when the entry expires.
+func (s *CachedUrlStore) IncrementClickCount(ctx context.Context, shortID string, mobile bool) (int64, error) {
+	return s.inner.IncrementClickCount(ctx, shortID, mobile)
+}
+
+// List delegates to the inner store; listings are not cached.
+func (s *CachedUrlStore) List(ctx context.Context, tenantID, cursor string, limit int64, includeDeleted bool) ([]domain.URL, string, error) {
+	return s.inner.List(ctx, tenantID, cursor, limit, includeDeleted)
+}
+
+// Delete delegates to the inner store and invalidates any cached entry so the link stops resolving immediately.
+func (s *CachedUrlStore) Delete(ctx context.Context, shortID string) error {
+	err := s.inner.Delete(ctx, shortID)
+	s.invalidate(ctx, shortID)
+	return err
+}
+
+// Update delegates to the inner store and invalidates any cached entry so redirects use the new destination.
+func (s *CachedUrlStore) Update(ctx context.Context, shortID, newOriginalURL string) error {
+	err := s.inner.Update(ctx, shortID, newOriginalURL)
+	s.invalidate(ctx, shortID)
+	return err
+}
+
+// Undelete delegates to the inner store and invalidates any cached entry.
+func (s *CachedUrlStore) Undelete(ctx context.Context, shortID string) error {
+	err := s.inner.Undelete(ctx, shortID)
+	s.invalidate(ctx, shortID)
+	return err
+}
+
+// ForEach delegates to the inner store.
+func (s *CachedUrlStore) ForEach(ctx context.Context, fn func(domain.URL) error) error {
+	return s.inner.ForEach(ctx, fn)
+}
+
+// ListByTag delegates to the inner store; listings are not cached.
+func (s *CachedUrlStore) ListByTag(ctx context.Context, tag string, limit, offset int64) ([]domain.URL, int64, error) {
+	return s.inner.ListByTag(ctx, tag, limit, offset)
+}
+
+// Search delegates to the inner store.
+func (s *CachedUrlStore) Search(ctx context.Context, query string, limit int64) ([]domain.URL, error) {
+	return s.inner.Search(ctx, query, limit)
+}
+
+// ListAliases delegates to the inner store.
+func (s *CachedUrlStore) ListAliases(ctx context.Context, primaryID string) ([]string, error) {
+	return s.inner.ListAliases(ctx, primaryID)
+}
+
+// UpdateTags delegates to the inner store and invalidates any cached entry.
+func (s *CachedUrlStore) UpdateTags(ctx context.Context, shortID string, tags []string) error {
+	err := s.inner.UpdateTags(ctx, shortID, tags)
+	s.invalidate(ctx, shortID)
+	return err
+}
+
+// IncrementConversionCount delegates to the inner store. Like the click count, the conversion count
+// of a cached entry may lag behind.
+func (s *CachedUrlStore) IncrementConversionCount(ctx context.Context, shortID string) error {
+	return s.inner.IncrementConversionCount(ctx, shortID)
+}
+
+// UpdateMetadata delegates to the inner store and invalidates any cached entry.
+func (s *CachedUrlStore) UpdateMetadata(ctx context.Context, shortID, title, description string) error {
+	err := s.inner.UpdateMetadata(ctx, shortID, title, description)
+	s.invalidate(ctx, shortID)
+	return err
+}
+
+// TransferOwnership delegates to the inner store and invalidates any cached entry, which still names the previous tenant.
+func (s *CachedUrlStore) TransferOwnership(ctx context.Context, shortID, newOwnerID string) (string, error) {
+	previousOwner, err := transferOwnership(ctx, s.inner, shortID, newOwnerID)
+	s.invalidate(ctx, shortID)
+	return previousOwner, err
+}
+
+// invalidate removes a short ID's cached entry.
+func (s *CachedUrlStore) invalidate(ctx context.Context, shortID string) {
+	if err := s.rdb.Del(ctx, urlCacheKey(shortID)).Err(); err != nil {
+		slog.WarnContext(ctx, "Redis DEL failed", slog.String("short_id", shortID), slog.Any("error", err))
+	}
+}
+
+// RedisCache is a generic byte cache on top of Redis. It satisfies service.Cache.
+type RedisCache struct {
+	rdb *redis.Client
+}
+
+// NewRedisCache creates a RedisCache using rdb.
+func NewRedisCache(rdb *redis.Client) *RedisCache {
+	return &RedisCache{rdb: rdb}
+}
+
+// Get returns the cached value for key. A missing key is reported as redis.Nil.
+func (c *RedisCache) Get(ctx context.Context, key string) ([]byte, error) {
+	return c.rdb.Get(ctx, key).Bytes()
+}
+
+// Set caches value under key for ttl.
+func (c *RedisCache) Set(ctx context.Context, key string, value []byte, ttl time.Duration) error {
+	return c.rdb.Set(ctx, key, value, ttl).Err()
+}
@@ -0,0 +1,177 @@
+package store
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"shawty/internal/domain"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+)
+
+const testCacheTTL = time.Minute
+
+// newTestRedisStore returns a CachedUrlStore over a countingStore holding abc123, backed by a
+// miniredis server, with the inner store and the server.
+func newTestRedisStore(t *testing.T) (UrlStoreInterface, *countingStore, *miniredis.Miniredis) {
+	t.Helper()
+	mr := miniredis.RunT(t)
+	// Retries would only slow down the tests that stop the server.
+	rdb := redis.NewClient(&redis.Options{Addr: mr.Addr(), MaxRetries: -1})
+	t.Cleanup(func() { rdb.Close() })
+	inner := newCountingStore(t)
+	return NewCachedUrlStore(inner, rdb, testCacheTTL), inner, mr
+}
+
+func TestCachedStoreReadsThroughRedis(t *testing.T) {
+	ctx := context.Background()
+	cached, inner, mr := newTestRedisStore(t)
+
+	for range 3 {
+		urlEntry, err := cached.GetByShortID(ctx, "abc123", AnyTenant)
+		if err != nil {
+			t.Fatalf("GetByShortID: %v", err)
+		}
+		if urlEntry.OriginalUrl != "https://example.com/a" {
+			t.Fatalf("original URL = %q, want https://example.com/a", urlEntry.OriginalUrl)
+		}
+	}
+	if got := inner.gets.Load(); got != 1 {
+		t.Errorf("inner store was read %d times, want 1 (a miss, then hits)", got)
+	}
+	if !mr.Exists(urlCacheKey("abc123")) {
+		t.Fatalf("no Redis key %s after a miss", urlCacheKey("abc123"))
+	}
+	if ttl := mr.TTL(urlCacheKey("abc123")); ttl != testCacheTTL {
+		t.Errorf("cache entry TTL = %v, want %v", ttl, testCacheTTL)
+	}
+
+	// An expired entry is read from the store again.
+	mr.FastForward(testCacheTTL + time.Second)
+	if _, err := cached.GetByShortID(ctx, "abc123", AnyTenant); err != nil {
+		t.Fatalf("GetByShortID after expiry: %v", err)
+	}
+	if got := inner.gets.Load(); got != 2 {
+		t.Errorf("inner store was read %d times after the entry expired, want 2", got)
+	}
+
+	// Unknown IDs are not cached.
+	if _, err := cached.GetByShortID(ctx, "nope00", AnyTenant); !errors.Is(err, ErrURLNotFound) {
+		t.Errorf("GetByShortID of an unknown ID error = %v, want ErrURLNotFound", err)
+	}
+	if mr.Exists(urlCacheKey("nope00")) {
+		t.Error("a failed lookup was cached")
+	}
+}
+
+func TestCachedStoreInvalidatesOnWrite(t *testing.T) {
+	ctx := context.Background()
+	cached, inner, mr := newTestRedisStore(t)
+	load := func() domain.URL {
+		t.Helper()
+		urlEntry, err := cached.GetByShortID(ctx, "abc123", AnyTenant)
+		if err != nil {
+			t.Fatalf("GetByShortID: %v", err)
+		}
+		return urlEntry
+	}
+
+	writes := []struct {
+		name  string
+		write func() error
+		check func(domain.URL) bool
+	}{
+		{name: "Update", write: func() error { return cached.Update(ctx, "abc123", "https://example.com/b") },
+			check: func(u domain.URL) bool { return u.OriginalUrl == "https://example.com/b" }},
+		{name: "UpdateTags", write: func() error { return cached.UpdateTags(ctx, "abc123", []string{"fresh"}) },
+			check: func(u domain.URL) bool { return len(u.Tags) == 1 && u.Tags[0] == "fresh" }},
+		{name: "UpdateMetadata", write: func() error { return cached.UpdateMetadata(ctx, "abc123", "Title", "") },
+			check: func(u domain.URL) bool { return u.PageTitle == "Title" }},
+	}
+	for _, w := range writes {
+		load()
+		if err := w.write(); err != nil {
+			t.Fatalf("%s: %v", w.name, err)
+		}
+		if mr.Exists(urlCacheKey("abc123")) {
+			t.Errorf("%s left the cached entry in Redis", w.name)
+		}
+		if got := load(); !w.check(got) {
+			t.Errorf("after %s the entry read is %+v, want the change", w.name, got)
+		}
+	}
+
+	load()
+	if err := cached.Delete(ctx, "abc123"); err != nil {
+		t.Fatalf("Delete: %v", err)
+	}
+	if _, err := cached.GetByShortID(ctx, "abc123", AnyTenant); err == nil {
+		t.Error("the deleted entry is still served from Redis")
+	}
+
+	// Save drops a cached entry for the ID, e.g. one written by another instance's miss.
+	mr.Set(urlCacheKey("new001"), "stale")
+	entry := domain.URL{ID: "new001", ShortUrl: "new001", OriginalUrl: "https://example.com/new", CreationDate: time.Now().UTC()}
+	if err := cached.Save(ctx, entry); err != nil {
+		t.Fatalf("Save: %v", err)
+	}
+	if mr.Exists(urlCacheKey("new001")) {
+		t.Error("Save left the cached entry in Redis")
+	}
+	if inner.gets.Load() == 0 {
+		t.Error("the inner store was never read")
+	}
+}
+
+func TestCachedStoreChecksTenant(t *testing.T) {
+	ctx := context.Background()
+	mr := miniredis.RunT(t)
+	rdb := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	t.Cleanup(func() { rdb.Close() })
+	inner := NewMemoryUrlStore()
+	entry := domain.URL{ID: "owned1", ShortUrl: "owned1", OriginalUrl: "https://example.com", CreationDate: time.Now().UTC(), TenantID: "alice"}
+	if err := inner.Save(ctx, entry); err != nil {
+		t.Fatalf("Save: %v", err)
+	}
+	cached := NewCachedUrlStore(inner, rdb, testCacheTTL)
+
+	if _, err := cached.GetByShortID(ctx, "owned1", "alice"); err != nil {
+		t.Fatalf("GetByShortID for the owner: %v", err)
+	}
+	// The entry is cached now, but must not be served to another tenant.
+	if _, err := cached.GetByShortID(ctx, "owned1", "bob"); !errors.Is(err, ErrURLNotFound) {
+		t.Errorf("GetByShortID for another tenant error = %v, want ErrURLNotFound", err)
+	}
+}
+
+func TestCachedStoreFallsBackWhenRedisIsDown(t *testing.T) {
+	ctx := context.Background()
+	cached, inner, mr := newTestRedisStore(t)
+	if _, err := cached.GetByShortID(ctx, "abc123", AnyTenant); err != nil {
+		t.Fatalf("GetByShortID: %v", err)
+	}
+
+	mr.Close()
+	for range 2 {
+		urlEntry, err := cached.GetByShortID(ctx, "abc123", AnyTenant)
+		if err != nil {
+			t.Fatalf("GetByShortID with Redis down: %v", err)
+		}
+		if urlEntry.OriginalUrl != "https://example.com/a" {
+			t.Errorf("original URL = %q, want https://example.com/a", urlEntry.OriginalUrl)
+		}
+	}
+	if got := inner.gets.Load(); got != 3 {
+		t.Errorf("inner store was read %d times, want 3 (every lookup once Redis is down)", got)
+	}
+	if err := cached.Update(ctx, "abc123", "https://example.com/b"); err != nil {
+		t.Errorf("Update with Redis down: %v", err)
+	}
+	entry := domain.URL{ID: "new001", ShortUrl: "new001", OriginalUrl: "https://example.com/new", CreationDate: time.Now().UTC()}
+	if err := cached.Save(ctx, entry); err != nil {
+		t.Errorf("Save with Redis down: %v", err)
+	}
+}
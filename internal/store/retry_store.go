@@ -0,0 +1,158 @@
+package store
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"math/rand"
+	"net"
+	"time"
+
+	"shawty/internal/domain"
+
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// DefaultRetryBaseDelay is the delay before the first retry when none is configured.
+const DefaultRetryBaseDelay = 50 * time.Millisecond
+
+// RetryUrlStore decorates another UrlStoreInterface by retrying GetByShortID, the lookup behind
+// every redirect, when it fails with a transient error such as a timeout or a dropped connection.
+// Other methods are passed through unchanged: Save in particular must not be retried, because an
+// insert that reached the database before the error would make the retry fail as a duplicate.
+type RetryUrlStore struct {
+	inner      UrlStoreInterface
+	maxRetries int
+	baseDelay  time.Duration
+}
+
+// NewRetryStore wraps inner so that GetByShortID is retried up to maxRetries times. The n-th retry
+// waits baseDelay*2^(n-1) plus up to half as much again of random jitter, so that instances
+// recovering from the same blip do not retry in lockstep.
+func NewRetryStore(inner UrlStoreInterface, maxRetries int, baseDelay time.Duration) UrlStoreInterface {
+	if baseDelay <= 0 {
+		baseDelay = DefaultRetryBaseDelay
+	}
+	return &RetryUrlStore{inner: inner, maxRetries: maxRetries, baseDelay: baseDelay}
+}
+
+// isTransient reports whether err is likely to go away if the operation is simply repeated.
+func isTransient(err error) bool {
+	if mongo.IsNetworkError(err) || mongo.IsTimeout(err) || errors.Is(err, context.DeadlineExceeded) {
+		return true
+	}
+	var netErr net.Error
+	return errors.As(err, &netErr)
+}
+
+// retryDelay returns the wait before retry number attempt (starting at 1).
+func (s *RetryUrlStore) retryDelay(attempt int) time.Duration {
+	backoff := s.baseDelay << (attempt - 1)
+	return backoff + time.Duration(rand.Int63n(int64(backoff/2)+1))
+}
+
+// EnsureIndexes delegates to the inner store.
+func (s *RetryUrlStore) EnsureIndexes(ctx context.Context) error {
+	return s.inner.EnsureIndexes(ctx)
+}
+
+// Save delegates to the inner store without retrying.
+func (s *RetryUrlStore) Save(ctx context.Context, urlEntry domain.URL) error {
+	return s.inner.Save(ctx, urlEntry)
+}
+
+// GetByShortID delegates to the inner store, retrying transient errors with exponential backoff.
+// It gives up early when ctx is done, returning the last error.
+func (s *RetryUrlStore) GetByShortID(ctx context.Context, shortID, tenantID string) (domain.URL, error) {
+	urlEntry, err := s.inner.GetByShortID(ctx, shortID, tenantID)
+	for attempt := 1; attempt <= s.maxRetries && err != nil && isTransient(err) && ctx.Err() == nil; attempt++ {
+		delay := s.retryDelay(attempt)
+		slog.WarnContext(ctx, "Retrying short ID lookup after a transient error", slog.String("short_id", shortID),
+			slog.Int("attempt", attempt), slog.Duration("delay", delay), slog.Any("error", err))
+
+		timer := time.NewTimer(delay)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return domain.URL{}, err
+		case <-timer.C:
+		}
+		urlEntry, err = s.inner.GetByShortID(ctx, shortID, tenantID)
+	}
+	return urlEntry, err
+}
+
+// GetByOriginalURL delegates to the inner store.
+func (s *RetryUrlStore) GetByOriginalURL(ctx context.Context, originalURL string) (domain.URL, error) {
+	return s.inner.GetByOriginalURL(ctx, originalURL)
+}
+
+// EstimatedCount delegates to the inner store.
+func (s *RetryUrlStore) EstimatedCount(ctx context.Context) (int64, error) {
+	return s.inner.EstimatedCount(ctx)
+}
+
+// IncrementClickCount delegates to the inner store without retrying, so a click is never counted twice.
+func (s *RetryUrlStore) IncrementClickCount(ctx context.Context, shortID string, mobile bool) (int64, error) {
+	return s.inner.IncrementClickCount(ctx, shortID, mobile)
+}
+
+// List delegates to the inner store.
+func (s *RetryUrlStore) List(ctx context.Context, tenantID, cursor string, limit int64, includeDeleted bool) ([]domain.URL, string, error) {
+	return s.inner.List(ctx, tenantID, cursor, limit, includeDeleted)
+}
+
+// ForEach delegates to the inner store.
+func (s *RetryUrlStore) ForEach(ctx context.Context, fn func(domain.URL) error) error {
+	return s.inner.ForEach(ctx, fn)
+}
+
+// Delete delegates to the inner store.
+func (s *RetryUrlStore) Delete(ctx context.Context, shortID string) error {
+	return s.inner.Delete(ctx, shortID)
+}
+
+// Undelete delegates to the inner store.
+func (s *RetryUrlStore) Undelete(ctx context.Context, shortID string) error {
+	return s.inner.Undelete(ctx, shortID)
+}
+
+// ListByTag delegates to the inner store.
+func (s *RetryUrlStore) ListByTag(ctx context.Context, tag string, limit, offset int64) ([]domain.URL, int64, error) {
+	return s.inner.ListByTag(ctx, tag, limit, offset)
+}
+
+// Search delegates to the inner store.
+func (s *RetryUrlStore) Search(ctx context.Context, query string, limit int64) ([]domain.URL, error) {
+	return s.inner.Search(ctx, query, limit)
+}
+
+// ListAliases delegates to the inner store.
+func (s *RetryUrlStore) ListAliases(ctx context.Context, primaryID string) ([]string, error) {
+	return s.inner.ListAliases(ctx, primaryID)
+}
+
+// UpdateTags delegates to the inner store.
+func (s *RetryUrlStore) UpdateTags(ctx context.Context, shortID string, tags []string) error {
+	return s.inner.UpdateTags(ctx, shortID, tags)
+}
+
+// IncrementConversionCount delegates to the inner store without retrying, so a conversion is never counted twice.
+func (s *RetryUrlStore) IncrementConversionCount(ctx context.Context, shortID string) error {
+	return s.inner.IncrementConversionCount(ctx, shortID)
+}
+
+// UpdateMetadata delegates to the inner store.
+func (s *RetryUrlStore) UpdateMetadata(ctx context.Context, shortID, title, description string) error {
+	return s.inner.UpdateMetadata(ctx, shortID, title, description)
+}
+
+// Update delegates to the inner store.
+func (s *RetryUrlStore) Update(ctx context.Context, shortID, newOriginalURL string) error {
+	return s.inner.Update(ctx, shortID, newOriginalURL)
+}
+
+// TransferOwnership delegates to the inner store without retrying.
+func (s *RetryUrlStore) TransferOwnership(ctx context.Context, shortID, newOwnerID string) (string, error) {
+	return transferOwnership(ctx, s.inner, shortID, newOwnerID)
+}
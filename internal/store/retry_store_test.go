@@ -0,0 +1,119 @@
+package store
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"shawty/internal/domain"
+)
+
+// timeoutError is a net.Error reporting a timeout, as a dropped database connection would.
+type timeoutError struct{}
+
+func (timeoutError) Error() string   { return "i/o timeout" }
+func (timeoutError) Timeout() bool   { return true }
+func (timeoutError) Temporary() bool { return true }
+
+// failingStore is a MemoryUrlStore whose GetByShortID and Save fail with err for their first
+// failures calls. It counts the calls that reach it.
+type failingStore struct {
+	*MemoryUrlStore
+	err      error
+	failures int
+	gets     int
+	saves    int
+}
+
+func (s *failingStore) GetByShortID(ctx context.Context, shortID, tenantID string) (domain.URL, error) {
+	s.gets++
+	if s.gets <= s.failures {
+		return domain.URL{}, s.err
+	}
+	return s.MemoryUrlStore.GetByShortID(ctx, shortID, tenantID)
+}
+
+func (s *failingStore) Save(ctx context.Context, urlEntry domain.URL) error {
+	s.saves++
+	if s.saves <= s.failures {
+		return s.err
+	}
+	return s.MemoryUrlStore.Save(ctx, urlEntry)
+}
+
+// newFailingStore returns a failingStore holding abc123 for https://example.com/a.
+func newFailingStore(t *testing.T, err error, failures int) *failingStore {
+	t.Helper()
+	inner := &failingStore{MemoryUrlStore: NewMemoryUrlStore()}
+	entry := domain.URL{ID: "abc123", ShortUrl: "abc123", OriginalUrl: "https://example.com/a", CreationDate: time.Now().UTC()}
+	if err := inner.MemoryUrlStore.Save(context.Background(), entry); err != nil {
+		t.Fatalf("Save: %v", err)
+	}
+	inner.err, inner.failures = err, failures
+	return inner
+}
+
+func TestRetryStoreGetByShortID(t *testing.T) {
+	tests := []struct {
+		name      string
+		err       error
+		failures  int
+		wantCalls int
+		wantErr   error
+	}{
+		{name: "succeeds first time", err: timeoutError{}, failures: 0, wantCalls: 1},
+		{name: "recovers from transient errors", err: timeoutError{}, failures: 2, wantCalls: 3},
+		{name: "recovers from a deadline", err: context.DeadlineExceeded, failures: 1, wantCalls: 2},
+		{name: "gives up after the last retry", err: timeoutError{}, failures: 10, wantCalls: 4, wantErr: timeoutError{}},
+		{name: "does not retry a missing link", err: ErrURLNotFound, failures: 10, wantCalls: 1, wantErr: ErrURLNotFound},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			inner := newFailingStore(t, tt.err, tt.failures)
+			retrying := NewRetryStore(inner, 3, time.Millisecond)
+
+			urlEntry, err := retrying.GetByShortID(context.Background(), "abc123", AnyTenant)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("error = %v, want %v", err, tt.wantErr)
+			}
+			if err == nil && urlEntry.OriginalUrl != "https://example.com/a" {
+				t.Errorf("OriginalUrl = %q, want https://example.com/a", urlEntry.OriginalUrl)
+			}
+			if inner.gets != tt.wantCalls {
+				t.Errorf("inner store called %d times, want %d", inner.gets, tt.wantCalls)
+			}
+		})
+	}
+}
+
+func TestRetryStoreStopsWhenContextIsDone(t *testing.T) {
+	inner := newFailingStore(t, timeoutError{}, 10)
+	retrying := NewRetryStore(inner, 3, time.Hour)
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+
+	start := time.Now()
+	if _, err := retrying.GetByShortID(ctx, "abc123", AnyTenant); !errors.Is(err, timeoutError{}) {
+		t.Errorf("error = %v, want the store's last error", err)
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("GetByShortID returned after %v, want it to stop with the context", elapsed)
+	}
+	if inner.gets != 1 {
+		t.Errorf("inner store called %d times, want 1", inner.gets)
+	}
+}
+
+func TestRetryStoreDoesNotRetrySave(t *testing.T) {
+	inner := newFailingStore(t, timeoutError{}, 1)
+	retrying := NewRetryStore(inner, 3, time.Millisecond)
+
+	entry := domain.URL{ID: "def456", ShortUrl: "def456", OriginalUrl: "https://example.com/b", CreationDate: time.Now().UTC()}
+	if err := retrying.Save(context.Background(), entry); !errors.Is(err, timeoutError{}) {
+		t.Errorf("Save error = %v, want the store's error", err)
+	}
+	if inner.saves != 1 {
+		t.Errorf("Save reached the inner store %d times, want 1", inner.saves)
+	}
+}
@@ -0,0 +1,37 @@
+CREATE TABLE IF NOT EXISTS urls (
+    id               TEXT PRIMARY KEY,
+    original_url     TEXT NOT NULL,
+    short_url        TEXT NOT NULL,
+    creation_date    TIMESTAMPTZ NOT NULL,
+    expires_at       TIMESTAMPTZ,
+    click_count      BIGINT DEFAULT 0,
+    last_accessed_at TIMESTAMPTZ,
+    updated_at       TIMESTAMPTZ,
+    deleted_at       TIMESTAMPTZ
+);
+
+-- Columns added after the initial release. ADD COLUMN IF NOT EXISTS upgrades existing tables in place.
+ALTER TABLE urls ADD COLUMN IF NOT EXISTS submitted_url TEXT;
+ALTER TABLE urls ADD COLUMN IF NOT EXISTS redirect_type TEXT;
+ALTER TABLE urls ADD COLUMN IF NOT EXISTS password_hash TEXT;
+ALTER TABLE urls ADD COLUMN IF NOT EXISTS max_clicks BIGINT;
+ALTER TABLE urls ADD COLUMN IF NOT EXISTS utm JSONB;
+ALTER TABLE urls ADD COLUMN IF NOT EXISTS tags JSONB;
+ALTER TABLE urls ADD COLUMN IF NOT EXISTS webhook_url TEXT;
+ALTER TABLE urls ADD COLUMN IF NOT EXISTS mobile_url TEXT;
+ALTER TABLE urls ADD COLUMN IF NOT EXISTS mobile_click_count BIGINT DEFAULT 0;
+ALTER TABLE urls ADD COLUMN IF NOT EXISTS page_title TEXT;
+ALTER TABLE urls ADD COLUMN IF NOT EXISTS page_description TEXT;
+ALTER TABLE urls ADD COLUMN IF NOT EXISTS tenant_id TEXT;
+CREATE INDEX IF NOT EXISTS urls_tenant_id_idx ON urls (tenant_id, id);
+ALTER TABLE urls ADD COLUMN IF NOT EXISTS alias_of TEXT;
+CREATE INDEX IF NOT EXISTS urls_alias_of_idx ON urls (alias_of) WHERE alias_of IS NOT NULL;
+ALTER TABLE urls ADD COLUMN IF NOT EXISTS variants JSONB;
+ALTER TABLE urls ADD COLUMN IF NOT EXISTS active_from TIMESTAMPTZ;
+ALTER TABLE urls ADD COLUMN IF NOT EXISTS active_until TIMESTAMPTZ;
+ALTER TABLE urls ADD COLUMN IF NOT EXISTS bundle BOOLEAN NOT NULL DEFAULT FALSE;
+ALTER TABLE urls ADD COLUMN IF NOT EXISTS bundle_title TEXT;
+ALTER TABLE urls ADD COLUMN IF NOT EXISTS hash_algo TEXT;
+ALTER TABLE urls ADD COLUMN IF NOT EXISTS custom_headers JSONB;
+ALTER TABLE urls ADD COLUMN IF NOT EXISTS conversion_count BIGINT NOT NULL DEFAULT 0;
+ALTER TABLE urls ADD COLUMN IF NOT EXISTS domain TEXT;
@@ -0,0 +1,25 @@
+package store
+
+import (
+	"regexp"
+	"strings"
+
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+// searchFilter matches undeleted entries whose original URL contains query, ignoring case.
+// Regex metacharacters in query are escaped, so it is always matched literally.
+func searchFilter(query string) bson.M {
+	return bson.M{
+		"original_url": bson.M{"$regex": regexp.QuoteMeta(query), "$options": "i"},
+		"deleted_at":   bson.M{"$exists": false},
+	}
+}
+
+// likeEscaper escapes the LIKE wildcards and the escape character itself.
+var likeEscaper = strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`)
+
+// likePattern returns a LIKE pattern matching values that contain query literally.
+func likePattern(query string) string {
+	return "%" + likeEscaper.Replace(query) + "%"
+}
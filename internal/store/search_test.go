@@ -0,0 +1,105 @@
+package store
+
+import (
+	"context"
+	"regexp"
+	"slices"
+	"testing"
+	"time"
+
+	"shawty/internal/domain"
+
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+// searchFixture lists the original URLs stored for the search tests.
+var searchFixture = []string{
+	"https://example.com/pricing",
+	"https://EXAMPLE.com/About",
+	"https://blog.example.com/post",
+	"https://other.org/example.com-review",
+	"https://other.org/a.b(c/page",
+	"https://other.org/axb(c/page",
+	"https://unrelated.net/",
+}
+
+func TestMemoryUrlStoreSearch(t *testing.T) {
+	ctx := context.Background()
+	memStore := NewMemoryUrlStore()
+	for i, originalURL := range searchFixture {
+		id := string(rune('a'+i)) + "00000"
+		if err := memStore.Save(ctx, domain.URL{ID: id, ShortUrl: id, OriginalUrl: originalURL, CreationDate: time.Now().UTC()}); err != nil {
+			t.Fatalf("Save: %v", err)
+		}
+	}
+
+	tests := []struct {
+		query string
+		limit int64
+		want  []string
+	}{
+		{query: "example.com", limit: 20, want: []string{"https://example.com/pricing", "https://EXAMPLE.com/About", "https://blog.example.com/post", "https://other.org/example.com-review"}},
+		{query: "Example.COM/about", limit: 20, want: []string{"https://EXAMPLE.com/About"}},
+		{query: "example.com", limit: 2, want: []string{"https://example.com/pricing", "https://EXAMPLE.com/About"}},
+		{query: "a.b(c", limit: 20, want: []string{"https://other.org/a.b(c/page"}},
+		{query: "nothing-matches", limit: 20, want: nil},
+	}
+	for _, tt := range tests {
+		results, err := memStore.Search(ctx, tt.query, tt.limit)
+		if err != nil {
+			t.Fatalf("Search(%q): %v", tt.query, err)
+		}
+		var got []string
+		for _, entry := range results {
+			got = append(got, entry.OriginalUrl)
+		}
+		if !slices.Equal(got, tt.want) {
+			t.Errorf("Search(%q, %d) = %v, want %v", tt.query, tt.limit, got, tt.want)
+		}
+	}
+}
+
+func TestSearchFilterMatchesLiterally(t *testing.T) {
+	tests := []struct {
+		query string
+		match []string
+		miss  []string
+	}{
+		{query: "example.com", match: []string{"https://EXAMPLE.COM/x"}, miss: []string{"https://examplexcom/x"}},
+		{query: "a.b(c", match: []string{"https://other.org/a.b(c/page"}, miss: []string{"https://other.org/axb(c/page"}},
+		{query: ".*", match: []string{"https://x.org/.*"}, miss: []string{"https://x.org/"}},
+		{query: "[a-z]+$", match: []string{"https://x.org/[a-z]+$"}, miss: []string{"https://x.org/abc"}},
+	}
+	for _, tt := range tests {
+		regex := searchFilter(tt.query)["original_url"].(bson.M)["$regex"].(string)
+		// MongoDB applies the "i" option; the escaped pattern is the same in Go's syntax.
+		re, err := regexp.Compile("(?i)" + regex)
+		if err != nil {
+			t.Fatalf("query %q gives an invalid pattern %q: %v", tt.query, regex, err)
+		}
+		for _, s := range tt.match {
+			if !re.MatchString(s) {
+				t.Errorf("query %q (pattern %q) does not match %q", tt.query, regex, s)
+			}
+		}
+		for _, s := range tt.miss {
+			if re.MatchString(s) {
+				t.Errorf("query %q (pattern %q) matches %q", tt.query, regex, s)
+			}
+		}
+	}
+}
+
+func TestLikePatternEscapesWildcards(t *testing.T) {
+	tests := map[string]string{
+		"example.com": "%example.com%",
+		"100%":        `%100\%%`,
+		"snake_case":  `%snake\_case%`,
+		`back\slash`:  `%back\\slash%`,
+	}
+	for query, want := range tests {
+		if got := likePattern(query); got != want {
+			t.Errorf("likePattern(%q) = %q, want %q", query, got, want)
+		}
+	}
+}
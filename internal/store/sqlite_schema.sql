@@ -0,0 +1,33 @@
+CREATE TABLE IF NOT EXISTS urls (
+    id                 TEXT PRIMARY KEY,
+    original_url       TEXT NOT NULL,
+    short_url          TEXT NOT NULL,
+    creation_date      TIMESTAMP NOT NULL,
+    expires_at         TIMESTAMP,
+    click_count        INTEGER DEFAULT 0,
+    last_accessed_at   TIMESTAMP,
+    updated_at         TIMESTAMP,
+    deleted_at         TIMESTAMP,
+    submitted_url      TEXT,
+    redirect_type      TEXT,
+    password_hash      TEXT,
+    max_clicks         INTEGER,
+    utm                TEXT,
+    tags               TEXT,
+    webhook_url        TEXT,
+    mobile_url         TEXT,
+    mobile_click_count INTEGER DEFAULT 0,
+    page_title         TEXT,
+    page_description   TEXT,
+    tenant_id          TEXT,
+    alias_of           TEXT,
+    variants           TEXT,
+    active_from        TIMESTAMP,
+    active_until       TIMESTAMP,
+    bundle             INTEGER NOT NULL DEFAULT 0,
+    bundle_title       TEXT,
+    hash_algo          TEXT,
+    custom_headers     TEXT,
+    conversion_count   INTEGER NOT NULL DEFAULT 0,
+    domain             TEXT
+);
@@ -0,0 +1,376 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	_ "embed"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"shawty/internal/domain"
+)
+
+// sqliteSchema creates the urls table. It is applied when the store is created.
+// utm and tags hold JSON text, as in the PostgreSQL schema, so scanURL reads both databases.
+//
+//go:embed sqlite_schema.sql
+var sqliteSchema string
+
+// SQLiteUrlStore implements UrlStoreInterface using a local SQLite database file, for running
+// shawty without any external services. The caller opens the *sql.DB with the pure-Go "sqlite"
+// driver and owns its lifecycle; Close releases the store's prepared statement.
+type SQLiteUrlStore struct {
+	db      *sql.DB
+	getByID *sql.Stmt
+}
+
+// NewSQLiteUrlStore creates the urls table if needed and returns a store on db.
+func NewSQLiteUrlStore(ctx context.Context, db *sql.DB) (*SQLiteUrlStore, error) {
+	if _, err := db.ExecContext(ctx, sqliteSchema); err != nil {
+		return nil, fmt.Errorf("failed to create urls table: %w", err)
+	}
+	// Short ID lookups serve every redirect, so their statement is prepared once.
+	getByID, err := db.PrepareContext(ctx, "SELECT "+urlColumns+" FROM urls WHERE id = ?1 AND (?2 = '' OR tenant_id = ?2)")
+	if err != nil {
+		return nil, fmt.Errorf("failed to prepare short ID lookup: %w", err)
+	}
+	return &SQLiteUrlStore{db: db, getByID: getByID}, nil
+}
+
+// Close releases the prepared statements of the store. The database itself is closed by its owner.
+func (s *SQLiteUrlStore) Close() error {
+	return s.getByID.Close()
+}
+
+// EnsureIndexes creates indexes on original_url, tenant_id and alias_of.
+func (s *SQLiteUrlStore) EnsureIndexes(ctx context.Context) error {
+	if _, err := s.db.ExecContext(ctx, "CREATE INDEX IF NOT EXISTS urls_original_url_idx ON urls (original_url)"); err != nil {
+		return fmt.Errorf("failed to create index on original_url: %w", err)
+	}
+	if _, err := s.db.ExecContext(ctx, "CREATE INDEX IF NOT EXISTS urls_tenant_id_idx ON urls (tenant_id, id)"); err != nil {
+		return fmt.Errorf("failed to create index on tenant_id: %w", err)
+	}
+	if _, err := s.db.ExecContext(ctx, "CREATE INDEX IF NOT EXISTS urls_alias_of_idx ON urls (alias_of) WHERE alias_of IS NOT NULL"); err != nil {
+		return fmt.Errorf("failed to create index on alias_of: %w", err)
+	}
+	slog.InfoContext(ctx, "Ensured SQLite indexes")
+	return nil
+}
+
+// Save inserts a new URL entry, returning ErrDuplicateShortID if the ID is already present.
+func (s *SQLiteUrlStore) Save(ctx context.Context, urlEntry domain.URL) error {
+	utm, err := utmColumn(urlEntry.UTM)
+	if err != nil {
+		return err
+	}
+	tags, err := tagsColumn(urlEntry.Tags)
+	if err != nil {
+		return err
+	}
+	variants, err := variantsColumn(urlEntry.Variants)
+	if err != nil {
+		return err
+	}
+	customHeaders, err := customHeadersColumn(urlEntry.CustomHeaders)
+	if err != nil {
+		return err
+	}
+
+	result, err := s.db.ExecContext(ctx,
+		`INSERT OR IGNORE INTO urls (id, original_url, short_url, creation_date, expires_at, click_count, submitted_url, redirect_type, password_hash, max_clicks, utm, tags, webhook_url, mobile_url, mobile_click_count, page_title, page_description, tenant_id, alias_of, variants, active_from, active_until, bundle, bundle_title, hash_algo, custom_headers, conversion_count, domain)
+		 VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		urlEntry.ID, urlEntry.OriginalUrl, urlEntry.ShortUrl, urlEntry.CreationDate, urlEntry.ExpiresAt, urlEntry.ClickCount, nullString(urlEntry.SubmittedUrl), nullString(urlEntry.RedirectType), nullString(urlEntry.PasswordHash), urlEntry.MaxClicks, utm, tags, nullString(urlEntry.WebhookURL), urlEntry.MobileURL, urlEntry.MobileClicks, nullString(urlEntry.PageTitle), nullString(urlEntry.PageDescription), nullString(urlEntry.TenantID), urlEntry.AliasOf, variants, urlEntry.ActiveFrom, urlEntry.ActiveUntil, urlEntry.Bundle, nullString(urlEntry.BundleTitle), nullString(urlEntry.HashAlgo), customHeaders, urlEntry.ConversionCount, nullString(urlEntry.Domain),
+	)
+	if err != nil {
+		return fmt.Errorf("failed to insert URL into SQLite: %w", err)
+	}
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to read affected rows: %w", err)
+	}
+	if affected == 0 {
+		// INSERT OR IGNORE inserts nothing when the ID already exists
+		return ErrDuplicateShortID
+	}
+	return nil
+}
+
+// GetByShortID retrieves a URL entry by its short ID, following an alias to its primary.
+func (s *SQLiteUrlStore) GetByShortID(ctx context.Context, shortID, tenantID string) (domain.URL, error) {
+	return followAlias(ctx, shortID, tenantID, s.getByShortID)
+}
+
+// getByShortID retrieves the row with the short ID as stored, aliases included.
+func (s *SQLiteUrlStore) getByShortID(ctx context.Context, shortID, tenantID string) (domain.URL, error) {
+	urlEntry, err := scanURL(s.getByID.QueryRowContext(ctx, shortID, tenantID))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return domain.URL{}, errNotFound(shortID)
+		}
+		return domain.URL{}, fmt.Errorf("error retrieving URL from SQLite: %w", err)
+	}
+	if urlEntry.IsDeleted() {
+		return domain.URL{}, fmt.Errorf("%w: '%s'", ErrURLDeleted, shortID)
+	}
+	return urlEntry, nil
+}
+
+// GetByOriginalURL retrieves an (undeleted) URL entry by its original URL.
+// It returns ErrURLNotFound if the URL has not been shortened.
+func (s *SQLiteUrlStore) GetByOriginalURL(ctx context.Context, originalURL string) (domain.URL, error) {
+	row := s.db.QueryRowContext(ctx, "SELECT "+urlColumns+" FROM urls WHERE original_url = ? AND deleted_at IS NULL LIMIT 1", originalURL)
+	urlEntry, err := scanURL(row)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return domain.URL{}, fmt.Errorf("%w: no entry for '%s'", ErrURLNotFound, originalURL)
+		}
+		return domain.URL{}, fmt.Errorf("error retrieving URL by original URL from SQLite: %w", err)
+	}
+	return urlEntry, nil
+}
+
+// EstimatedCount returns the number of URL entries. SQLite keeps no statistics to estimate from,
+// and a local database is small enough to count.
+func (s *SQLiteUrlStore) EstimatedCount(ctx context.Context) (int64, error) {
+	var count int64
+	if err := s.db.QueryRowContext(ctx, "SELECT COUNT(*) FROM urls").Scan(&count); err != nil {
+		return 0, fmt.Errorf("failed to count URLs: %w", err)
+	}
+	return count, nil
+}
+
+// IncrementClickCount atomically increments the click counter of a URL entry and records the access time.
+// Click-limited entries are only incremented while they have clicks left; once they are used up it
+// returns ErrClickLimitReached.
+func (s *SQLiteUrlStore) IncrementClickCount(ctx context.Context, shortID string, mobile bool) (int64, error) {
+	var clickCount int64
+	err := s.db.QueryRowContext(ctx,
+		`UPDATE urls SET click_count = click_count + 1, last_accessed_at = ?,
+		   mobile_click_count = mobile_click_count + CASE WHEN ? THEN 1 ELSE 0 END
+		 WHERE id = ? AND (max_clicks IS NULL OR click_count < max_clicks)
+		 RETURNING click_count`,
+		time.Now().UTC(), mobile, shortID,
+	).Scan(&clickCount)
+	if errors.Is(err, sql.ErrNoRows) {
+		// Either the entry does not exist or its limit filtered it out.
+		var exists bool
+		if err := s.db.QueryRowContext(ctx, "SELECT EXISTS (SELECT 1 FROM urls WHERE id = ?)", shortID).Scan(&exists); err != nil {
+			return 0, fmt.Errorf("failed to check click-limited URL in SQLite: %w", err)
+		}
+		if exists {
+			return 0, fmt.Errorf("%w: '%s'", ErrClickLimitReached, shortID)
+		}
+		return 0, fmt.Errorf("%w: '%s'", ErrURLNotFound, shortID)
+	}
+	if err != nil {
+		return 0, fmt.Errorf("failed to increment click count in SQLite: %w", err)
+	}
+	return clickCount, nil
+}
+
+// List returns a page of URL entries in id order, starting after cursor, and the cursor of the next page.
+func (s *SQLiteUrlStore) List(ctx context.Context, tenantID, cursor string, limit int64, includeDeleted bool) ([]domain.URL, string, error) {
+	after, err := decodeCursor(cursor)
+	if err != nil {
+		return nil, "", err
+	}
+	// One extra row tells whether another page follows.
+	urls, err := s.query(ctx,
+		"SELECT "+urlColumns+" FROM urls WHERE (?3 OR deleted_at IS NULL) AND (?4 = '' OR tenant_id = ?4) AND id > ?1 ORDER BY id LIMIT ?2",
+		after, limit+1, includeDeleted, tenantID,
+	)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to list URLs from SQLite: %w", err)
+	}
+	urls, next := nextPage(urls, limit)
+	return urls, next, nil
+}
+
+// Delete removes a URL entry, or marks it with deleted_at in soft-delete builds.
+// It returns ErrURLNotFound if no (undeleted) entry has the short ID.
+func (s *SQLiteUrlStore) Delete(ctx context.Context, shortID string) error {
+	var (
+		result sql.Result
+		err    error
+	)
+	if SoftDelete {
+		result, err = s.db.ExecContext(ctx, "UPDATE urls SET deleted_at = ? WHERE id = ? AND deleted_at IS NULL", time.Now().UTC(), shortID)
+	} else {
+		result, err = s.db.ExecContext(ctx, "DELETE FROM urls WHERE id = ?", shortID)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to delete URL from SQLite: %w", err)
+	}
+	return requireAffected(result, shortID)
+}
+
+// Update changes the original URL of an entry and records the modification time.
+// It returns ErrURLNotFound if no (undeleted) entry has the short ID.
+func (s *SQLiteUrlStore) Update(ctx context.Context, shortID, newOriginalURL string) error {
+	result, err := s.db.ExecContext(ctx,
+		"UPDATE urls SET original_url = ?, updated_at = ? WHERE id = ? AND deleted_at IS NULL",
+		newOriginalURL, time.Now().UTC(), shortID,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to update URL in SQLite: %w", err)
+	}
+	return requireAffected(result, shortID)
+}
+
+// ForEach streams every entry in id order, calling fn for each.
+func (s *SQLiteUrlStore) ForEach(ctx context.Context, fn func(domain.URL) error) error {
+	rows, err := s.db.QueryContext(ctx, "SELECT "+urlColumns+" FROM urls ORDER BY id")
+	if err != nil {
+		return fmt.Errorf("failed to read URLs from SQLite: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		urlEntry, err := scanURL(rows)
+		if err != nil {
+			return fmt.Errorf("failed to decode URL: %w", err)
+		}
+		if err := fn(urlEntry); err != nil {
+			return err
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("failed to read URLs from SQLite: %w", err)
+	}
+	return nil
+}
+
+// ListByTag returns a page of undeleted entries carrying tag in id order, along with their total.
+func (s *SQLiteUrlStore) ListByTag(ctx context.Context, tag string, limit, offset int64) ([]domain.URL, int64, error) {
+	const hasTag = "deleted_at IS NULL AND EXISTS (SELECT 1 FROM json_each(urls.tags) WHERE value = ?)"
+	urls, err := s.query(ctx, "SELECT "+urlColumns+" FROM urls WHERE "+hasTag+" ORDER BY id LIMIT ? OFFSET ?", tag, limit, offset)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to list URLs by tag from SQLite: %w", err)
+	}
+
+	var total int64
+	if err := s.db.QueryRowContext(ctx, "SELECT COUNT(*) FROM urls WHERE "+hasTag, tag).Scan(&total); err != nil {
+		return nil, 0, fmt.Errorf("failed to count URLs by tag in SQLite: %w", err)
+	}
+	return urls, total, nil
+}
+
+// Search returns up to limit undeleted entries whose original URL contains query, ignoring case, in id order.
+// LIKE wildcards in query are escaped, so it is matched literally. SQLite's LIKE ignores the case of ASCII letters only.
+func (s *SQLiteUrlStore) Search(ctx context.Context, query string, limit int64) ([]domain.URL, error) {
+	urls, err := s.query(ctx,
+		`SELECT `+urlColumns+` FROM urls WHERE deleted_at IS NULL AND original_url LIKE ? ESCAPE '\' ORDER BY id LIMIT ?`,
+		likePattern(query), limit,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to search URLs in SQLite: %w", err)
+	}
+	return urls, nil
+}
+
+// ListAliases returns the short IDs of the (undeleted) aliases of primaryID.
+func (s *SQLiteUrlStore) ListAliases(ctx context.Context, primaryID string) ([]string, error) {
+	rows, err := s.db.QueryContext(ctx, "SELECT id FROM urls WHERE alias_of = ? AND deleted_at IS NULL ORDER BY id", primaryID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list aliases in SQLite: %w", err)
+	}
+	defer rows.Close()
+
+	aliases := []string{}
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			return nil, fmt.Errorf("failed to decode alias: %w", err)
+		}
+		aliases = append(aliases, id)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to list aliases in SQLite: %w", err)
+	}
+	return aliases, nil
+}
+
+// UpdateTags replaces the tags of an entry and records the modification time.
+// It returns ErrURLNotFound if no (undeleted) entry has the short ID.
+func (s *SQLiteUrlStore) UpdateTags(ctx context.Context, shortID string, tags []string) error {
+	encoded, err := tagsColumn(tags)
+	if err != nil {
+		return err
+	}
+	result, err := s.db.ExecContext(ctx,
+		"UPDATE urls SET tags = ?, updated_at = ? WHERE id = ? AND deleted_at IS NULL",
+		encoded, time.Now().UTC(), shortID,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to update tags in SQLite: %w", err)
+	}
+	return requireAffected(result, shortID)
+}
+
+// IncrementConversionCount atomically increments the conversion counter of a URL entry.
+func (s *SQLiteUrlStore) IncrementConversionCount(ctx context.Context, shortID string) error {
+	result, err := s.db.ExecContext(ctx, "UPDATE urls SET conversion_count = conversion_count + 1 WHERE id = ?", shortID)
+	if err != nil {
+		return fmt.Errorf("failed to increment conversion count in SQLite: %w", err)
+	}
+	return requireAffected(result, shortID)
+}
+
+// UpdateMetadata sets the page title and description of an entry.
+// It returns ErrURLNotFound if no (undeleted) entry has the short ID.
+func (s *SQLiteUrlStore) UpdateMetadata(ctx context.Context, shortID, title, description string) error {
+	result, err := s.db.ExecContext(ctx,
+		"UPDATE urls SET page_title = ?, page_description = ? WHERE id = ? AND deleted_at IS NULL",
+		nullString(title), nullString(description), shortID,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to update page metadata in SQLite: %w", err)
+	}
+	return requireAffected(result, shortID)
+}
+
+// Undelete clears deleted_at on a soft-deleted entry.
+// It returns ErrURLNotFound if no deleted entry has the short ID.
+func (s *SQLiteUrlStore) Undelete(ctx context.Context, shortID string) error {
+	result, err := s.db.ExecContext(ctx, "UPDATE urls SET deleted_at = NULL WHERE id = ? AND deleted_at IS NOT NULL", shortID)
+	if err != nil {
+		return fmt.Errorf("failed to undelete URL in SQLite: %w", err)
+	}
+	return requireAffected(result, shortID)
+}
+
+// Ping checks that the database file can be reached.
+func (s *SQLiteUrlStore) Ping(ctx context.Context) error {
+	return s.db.PingContext(ctx)
+}
+
+// Ready checks that the urls table exists and can answer a query.
+func (s *SQLiteUrlStore) Ready(ctx context.Context) error {
+	var one int
+	err := s.db.QueryRowContext(ctx, "SELECT 1 FROM urls LIMIT 1").Scan(&one)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return fmt.Errorf("failed to query urls table: %w", err)
+	}
+	return nil
+}
+
+// query runs a SELECT of urlColumns and decodes every row.
+func (s *SQLiteUrlStore) query(ctx context.Context, query string, args ...any) ([]domain.URL, error) {
+	rows, err := s.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	urls := []domain.URL{}
+	for rows.Next() {
+		urlEntry, err := scanURL(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to decode URL: %w", err)
+		}
+		urls = append(urls, urlEntry)
+	}
+	return urls, rows.Err()
+}
@@ -0,0 +1,185 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"shawty/internal/domain"
+
+	_ "modernc.org/sqlite"
+)
+
+// newTestSQLiteStore returns a SQLiteUrlStore on a new database file in a temporary directory.
+func newTestSQLiteStore(t *testing.T) *SQLiteUrlStore {
+	t.Helper()
+	db, err := sql.Open("sqlite", "file:"+filepath.Join(t.TempDir(), "shawty.db")+"?_pragma=busy_timeout(5000)")
+	if err != nil {
+		t.Fatalf("opening SQLite database: %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+	ctx := context.Background()
+	sqliteStore, err := NewSQLiteUrlStore(ctx, db)
+	if err != nil {
+		t.Fatalf("NewSQLiteUrlStore: %v", err)
+	}
+	t.Cleanup(func() { sqliteStore.Close() })
+	if err := sqliteStore.EnsureIndexes(ctx); err != nil {
+		t.Fatalf("EnsureIndexes: %v", err)
+	}
+	return sqliteStore
+}
+
+func TestSQLiteUrlStoreCRUD(t *testing.T) {
+	ctx := context.Background()
+	sqliteStore := newTestSQLiteStore(t)
+	created := time.Date(2025, 3, 4, 5, 6, 7, 0, time.UTC)
+	expires := created.Add(24 * time.Hour)
+	maxClicks := int64(2)
+	entry := domain.URL{
+		ID:           "abc123",
+		ShortUrl:     "abc123",
+		OriginalUrl:  "https://example.com/page",
+		CreationDate: created,
+		ExpiresAt:    &expires,
+		MaxClicks:    &maxClicks,
+		Tags:         []string{"campaign-q4", "social"},
+		TenantID:     "tenant-a",
+	}
+
+	if err := sqliteStore.Save(ctx, entry); err != nil {
+		t.Fatalf("Save: %v", err)
+	}
+	if err := sqliteStore.Save(ctx, entry); !errors.Is(err, ErrDuplicateShortID) {
+		t.Fatalf("second Save error = %v, want ErrDuplicateShortID", err)
+	}
+
+	got, err := sqliteStore.GetByShortID(ctx, "abc123", AnyTenant)
+	if err != nil {
+		t.Fatalf("GetByShortID: %v", err)
+	}
+	if got.OriginalUrl != entry.OriginalUrl || got.TenantID != "tenant-a" || !got.CreationDate.Equal(created) ||
+		got.ExpiresAt == nil || !got.ExpiresAt.Equal(expires) || got.MaxClicks == nil || *got.MaxClicks != 2 ||
+		strings.Join(got.Tags, ",") != "campaign-q4,social" {
+		t.Errorf("GetByShortID = %+v, want the saved entry %+v", got, entry)
+	}
+	if _, err := sqliteStore.GetByShortID(ctx, "abc123", "tenant-b"); !errors.Is(err, ErrURLNotFound) {
+		t.Errorf("GetByShortID for another tenant: error = %v, want ErrURLNotFound", err)
+	}
+	if _, err := sqliteStore.GetByShortID(ctx, "nope00", AnyTenant); !errors.Is(err, ErrURLNotFound) {
+		t.Errorf("GetByShortID of a missing ID: error = %v, want ErrURLNotFound", err)
+	}
+	if got, err := sqliteStore.GetByOriginalURL(ctx, entry.OriginalUrl); err != nil || got.ID != "abc123" {
+		t.Errorf("GetByOriginalURL = %q, %v; want abc123", got.ID, err)
+	}
+	if count, err := sqliteStore.EstimatedCount(ctx); err != nil || count != 1 {
+		t.Errorf("EstimatedCount = %d, %v; want 1", count, err)
+	}
+
+	for want := int64(1); want <= maxClicks; want++ {
+		if clicks, err := sqliteStore.IncrementClickCount(ctx, "abc123", want == 1); err != nil || clicks != want {
+			t.Fatalf("IncrementClickCount = %d, %v; want %d", clicks, err, want)
+		}
+	}
+	if _, err := sqliteStore.IncrementClickCount(ctx, "abc123", false); !errors.Is(err, ErrClickLimitReached) {
+		t.Errorf("IncrementClickCount past the limit: error = %v, want ErrClickLimitReached", err)
+	}
+	if _, err := sqliteStore.IncrementClickCount(ctx, "nope00", false); !errors.Is(err, ErrURLNotFound) {
+		t.Errorf("IncrementClickCount of a missing ID: error = %v, want ErrURLNotFound", err)
+	}
+	if got, _ := sqliteStore.GetByShortID(ctx, "abc123", AnyTenant); got.ClickCount != 2 || got.MobileClicks != 1 {
+		t.Errorf("clicks = %d (mobile %d), want 2 (mobile 1)", got.ClickCount, got.MobileClicks)
+	}
+
+	if err := sqliteStore.Update(ctx, "abc123", "https://example.com/moved"); err != nil {
+		t.Fatalf("Update: %v", err)
+	}
+	if got, _ := sqliteStore.GetByShortID(ctx, "abc123", AnyTenant); got.OriginalUrl != "https://example.com/moved" {
+		t.Errorf("after Update, original URL = %q", got.OriginalUrl)
+	}
+	if err := sqliteStore.Update(ctx, "nope00", "https://example.com/x"); !errors.Is(err, ErrURLNotFound) {
+		t.Errorf("Update of a missing ID: error = %v, want ErrURLNotFound", err)
+	}
+	if err := sqliteStore.UpdateTags(ctx, "abc123", []string{"email"}); err != nil {
+		t.Fatalf("UpdateTags: %v", err)
+	}
+	if tagged, total, err := sqliteStore.ListByTag(ctx, "email", 10, 0); err != nil || total != 1 || len(tagged) != 1 {
+		t.Errorf("ListByTag(email) = %d entries of %d, %v; want 1 of 1", len(tagged), total, err)
+	}
+
+	if err := sqliteStore.Delete(ctx, "abc123"); err != nil {
+		t.Fatalf("Delete: %v", err)
+	}
+	if err := sqliteStore.Delete(ctx, "abc123"); !errors.Is(err, ErrURLNotFound) {
+		t.Errorf("second Delete error = %v, want ErrURLNotFound", err)
+	}
+	_, err = sqliteStore.GetByShortID(ctx, "abc123", AnyTenant)
+	if SoftDelete {
+		if !errors.Is(err, ErrURLDeleted) {
+			t.Errorf("GetByShortID after Delete: error = %v, want ErrURLDeleted", err)
+		}
+		if err := sqliteStore.Undelete(ctx, "abc123"); err != nil {
+			t.Fatalf("Undelete: %v", err)
+		}
+		if _, err := sqliteStore.GetByShortID(ctx, "abc123", AnyTenant); err != nil {
+			t.Errorf("GetByShortID after Undelete: %v", err)
+		}
+	} else if !errors.Is(err, ErrURLNotFound) {
+		t.Errorf("GetByShortID after Delete: error = %v, want ErrURLNotFound", err)
+	}
+}
+
+func TestSQLiteUrlStoreListsByCursorAndTenant(t *testing.T) {
+	ctx := context.Background()
+	sqliteStore := newTestSQLiteStore(t)
+	for i, tenantID := range []string{"tenant-a", "tenant-b", "tenant-a", "tenant-a", "tenant-b"} {
+		id := string(rune('a'+i)) + "00000"
+		entry := domain.URL{ID: id, ShortUrl: id, OriginalUrl: "https://example.com/" + id, CreationDate: time.Now().UTC(), TenantID: tenantID}
+		if err := sqliteStore.Save(ctx, entry); err != nil {
+			t.Fatalf("Save: %v", err)
+		}
+	}
+
+	list := func(tenantID string) []string {
+		t.Helper()
+		var ids []string
+		cursor := ""
+		for {
+			page, next, err := sqliteStore.List(ctx, tenantID, cursor, 2, false)
+			if err != nil {
+				t.Fatalf("List: %v", err)
+			}
+			for _, entry := range page {
+				ids = append(ids, entry.ID)
+			}
+			if next == "" {
+				return ids
+			}
+			cursor = next
+		}
+	}
+	tests := map[string]string{
+		AnyTenant:  "a00000,b00000,c00000,d00000,e00000",
+		"tenant-a": "a00000,c00000,d00000",
+		"tenant-b": "b00000,e00000",
+		"tenant-c": "",
+	}
+	for tenantID, want := range tests {
+		if got := strings.Join(list(tenantID), ","); got != want {
+			t.Errorf("tenant %q lists %s, want %s", tenantID, got, want)
+		}
+	}
+
+	var streamed []string
+	err := sqliteStore.ForEach(ctx, func(entry domain.URL) error {
+		streamed = append(streamed, entry.ID)
+		return nil
+	})
+	if err != nil || len(streamed) != 5 {
+		t.Errorf("ForEach streamed %v, %v; want all 5 entries", streamed, err)
+	}
+}
@@ -0,0 +1,32 @@
+package store
+
+import (
+	"fmt"
+
+	"shawty/internal/domain"
+
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+// AnyTenant is the tenant ID that lifts the tenant filter of GetByShortID and List. Public
+// lookups such as redirects and admin requests made with the master token use it.
+const AnyTenant = ""
+
+// belongsTo reports whether urlEntry is visible to tenantID.
+func belongsTo(urlEntry domain.URL, tenantID string) bool {
+	return tenantID == AnyTenant || urlEntry.TenantID == tenantID
+}
+
+// errNotFound is the error of a GetByShortID that found no entry. Another tenant's entry is
+// reported the same way, so that tenants cannot probe for each other's short IDs.
+func errNotFound(shortID string) error {
+	return fmt.Errorf("%w: '%s'", ErrURLNotFound, shortID)
+}
+
+// withTenant restricts a MongoDB filter to the entries of tenantID.
+func withTenant(filter bson.M, tenantID string) bson.M {
+	if tenantID != AnyTenant {
+		filter["tenant_id"] = tenantID
+	}
+	return filter
+}
@@ -0,0 +1,45 @@
+package urlutil
+
+import (
+	"fmt"
+	"math"
+	"strings"
+)
+
+// base62Alphabet orders digits, then lowercase, then uppercase letters.
+const base62Alphabet = "0123456789abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ"
+
+// Encode returns the Base62 representation of n, without padding. Encode(0) is "0".
+func Encode(n uint64) string {
+	if n == 0 {
+		return "0"
+	}
+	var buf [11]byte // 62^11 > 2^64, so 11 digits always suffice
+	i := len(buf)
+	for n > 0 {
+		i--
+		buf[i] = base62Alphabet[n%62]
+		n /= 62
+	}
+	return string(buf[i:])
+}
+
+// Decode parses a Base62 string produced by Encode. Leading zeros are allowed.
+// It returns an error for empty input, characters outside the alphabet, or values that overflow uint64.
+func Decode(s string) (uint64, error) {
+	if s == "" {
+		return 0, fmt.Errorf("base62: empty string")
+	}
+	var n uint64
+	for _, r := range s {
+		digit := strings.IndexRune(base62Alphabet, r)
+		if digit < 0 {
+			return 0, fmt.Errorf("base62: invalid character %q", r)
+		}
+		if n > (math.MaxUint64-uint64(digit))/62 {
+			return 0, fmt.Errorf("base62: %q overflows uint64", s)
+		}
+		n = n*62 + uint64(digit)
+	}
+	return n, nil
+}
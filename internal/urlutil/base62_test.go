@@ -0,0 +1,50 @@
+package urlutil
+
+import (
+	"math"
+	"testing"
+	"testing/quick"
+)
+
+func TestBase62RoundTrip(t *testing.T) {
+	roundTrips := func(n uint64) bool {
+		decoded, err := Decode(Encode(n))
+		return err == nil && decoded == n
+	}
+	if err := quick.Check(roundTrips, &quick.Config{MaxCount: 10000}); err != nil {
+		t.Error(err)
+	}
+	for _, n := range []uint64{0, 1, 61, 62, math.MaxUint64} {
+		if !roundTrips(n) {
+			t.Errorf("Decode(Encode(%d)) != %d", n, n)
+		}
+	}
+}
+
+func TestBase62Encode(t *testing.T) {
+	tests := []struct {
+		n    uint64
+		want string
+	}{
+		{0, "0"},
+		{61, "Z"},
+		{62, "10"},
+		{math.MaxUint64, "lYGhA16ahyf"},
+	}
+	for _, tt := range tests {
+		if got := Encode(tt.n); got != tt.want {
+			t.Errorf("Encode(%d) = %q, want %q", tt.n, got, tt.want)
+		}
+	}
+}
+
+func TestBase62DecodeErrors(t *testing.T) {
+	for _, s := range []string{"", "abc-1", "lYGhA16ahyg", "100000000000"} {
+		if n, err := Decode(s); err == nil {
+			t.Errorf("Decode(%q) = %d, want an error", s, n)
+		}
+	}
+	if n, err := Decode("007"); err != nil || n != 7 {
+		t.Errorf("Decode(\"007\") = %d, %v; want 7 with leading zeros allowed", n, err)
+	}
+}
@@ -0,0 +1,136 @@
+package urlutil
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// Blacklist is a set of hostnames that may not be shortened. A listed host also blocks
+// all of its subdomains, so listing "evil.com" blocks "www.evil.com" too.
+// It is safe for concurrent use.
+type Blacklist struct {
+	mu    sync.RWMutex
+	hosts map[string]struct{}
+	path  string // File the list is persisted to; empty keeps it in memory only
+}
+
+// NewBlacklist creates an empty, in-memory Blacklist.
+func NewBlacklist() *Blacklist {
+	return &Blacklist{hosts: make(map[string]struct{})}
+}
+
+// LoadBlacklist reads a newline-separated list of hostnames from path. Blank lines and
+// lines starting with '#' are ignored. A missing file yields an empty list that Save will create.
+func LoadBlacklist(path string) (*Blacklist, error) {
+	bl := NewBlacklist()
+	bl.path = path
+
+	file, err := os.Open(path)
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return bl, nil
+		}
+		return nil, fmt.Errorf("failed to open blacklist file: %w", err)
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		bl.Add(line)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read blacklist file: %w", err)
+	}
+	return bl, nil
+}
+
+// normalizeHost lowercases a hostname and strips a trailing dot.
+func normalizeHost(host string) string {
+	return strings.TrimSuffix(strings.ToLower(strings.TrimSpace(host)), ".")
+}
+
+// Add puts host on the list. It does not persist the change; call Save for that.
+func (b *Blacklist) Add(host string) {
+	host = normalizeHost(host)
+	if host == "" {
+		return
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.hosts[host] = struct{}{}
+}
+
+// Contains reports whether host, or any domain it is a subdomain of, is on the list.
+func (b *Blacklist) Contains(host string) bool {
+	host = normalizeHost(host)
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+
+	for host != "" {
+		if _, ok := b.hosts[host]; ok {
+			return true
+		}
+		dot := strings.IndexByte(host, '.')
+		if dot < 0 {
+			break
+		}
+		host = host[dot+1:]
+	}
+	return false
+}
+
+// Len returns the number of listed hosts.
+func (b *Blacklist) Len() int {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	return len(b.hosts)
+}
+
+// Save writes the list, one host per line, to the file it was loaded from.
+// The file is replaced atomically. Save is a no-op for in-memory lists.
+func (b *Blacklist) Save() error {
+	if b.path == "" {
+		return nil
+	}
+
+	b.mu.RLock()
+	hosts := make([]string, 0, len(b.hosts))
+	for host := range b.hosts {
+		hosts = append(hosts, host)
+	}
+	b.mu.RUnlock()
+	sort.Strings(hosts)
+
+	tmp, err := os.CreateTemp(filepath.Dir(b.path), ".blacklist-*")
+	if err != nil {
+		return fmt.Errorf("failed to create temporary blacklist file: %w", err)
+	}
+	defer os.Remove(tmp.Name()) // No-op once the rename has succeeded
+
+	w := bufio.NewWriter(tmp)
+	for _, host := range hosts {
+		fmt.Fprintln(w, host)
+	}
+	if err := w.Flush(); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to write blacklist file: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to write blacklist file: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), b.path); err != nil {
+		return fmt.Errorf("failed to replace blacklist file: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,78 @@
+package urlutil
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestBlacklistContains(t *testing.T) {
+	bl := NewBlacklist()
+	bl.Add("evil.com")
+	bl.Add("Tracker.Example.")
+	bl.Add("  ")
+
+	tests := []struct {
+		host string
+		want bool
+	}{
+		{host: "evil.com", want: true},
+		{host: "www.evil.com", want: true},
+		{host: "a.b.evil.com", want: true},
+		{host: "EVIL.Com", want: true},
+		{host: "evil.com.", want: true},
+		{host: "WWW.EVIL.COM.", want: true},
+		// Entries are normalized when added, too.
+		{host: "tracker.example", want: true},
+		{host: "cdn.tracker.example", want: true},
+		// Only whole labels match.
+		{host: "notevil.com", want: false},
+		{host: "evil.com.au", want: false},
+		{host: "com", want: false},
+		{host: "example", want: false},
+		{host: "", want: false},
+	}
+	for _, tt := range tests {
+		if got := bl.Contains(tt.host); got != tt.want {
+			t.Errorf("Contains(%q) = %v, want %v", tt.host, got, tt.want)
+		}
+	}
+	if got := bl.Len(); got != 2 {
+		t.Errorf("Len() = %d, want 2 (blank hosts are not added)", got)
+	}
+}
+
+func TestBlacklistSaveAndLoad(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "blacklist.txt")
+	if err := os.WriteFile(path, []byte("# blocked hosts\n\nEvil.com\nspam.example.\n"), 0o644); err != nil {
+		t.Fatalf("writing blacklist: %v", err)
+	}
+	bl, err := LoadBlacklist(path)
+	if err != nil {
+		t.Fatalf("LoadBlacklist: %v", err)
+	}
+	if !bl.Contains("www.evil.com") || !bl.Contains("spam.example") || bl.Len() != 2 {
+		t.Fatalf("loaded list holds %d hosts, want evil.com and spam.example", bl.Len())
+	}
+
+	bl.Add("new.example")
+	if err := bl.Save(); err != nil {
+		t.Fatalf("Save: %v", err)
+	}
+	saved, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("reading saved blacklist: %v", err)
+	}
+	if want := "evil.com\nnew.example\nspam.example\n"; string(saved) != want {
+		t.Errorf("saved list = %q, want %q", saved, want)
+	}
+
+	// A missing file is an empty list that Save creates.
+	missing, err := LoadBlacklist(filepath.Join(t.TempDir(), "missing.txt"))
+	if err != nil {
+		t.Fatalf("LoadBlacklist of a missing file: %v", err)
+	}
+	if missing.Len() != 0 {
+		t.Errorf("list loaded from a missing file holds %d hosts, want none", missing.Len())
+	}
+}
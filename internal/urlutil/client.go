@@ -0,0 +1,51 @@
+package urlutil
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"syscall"
+	"time"
+)
+
+// ErrPrivateAddress is returned when an outbound connection would reach a private network address.
+var ErrPrivateAddress = errors.New("connection to private address refused")
+
+// NewSafeHTTPClient returns an HTTP client for fetching user-supplied URLs.
+// Every connection, including those made for redirects, is refused if the resolved IP is
+// loopback, private or link-local. Checking at dial time rather than before the request also
+// covers hostnames whose DNS answer changes between validation and use. At most maxRedirects
+// redirects are followed, and only to http(s) URLs.
+func NewSafeHTTPClient(timeout time.Duration, maxRedirects int) *http.Client {
+	dialer := &net.Dialer{
+		Timeout: timeout,
+		Control: func(network, address string, _ syscall.RawConn) error {
+			host, _, err := net.SplitHostPort(address)
+			if err != nil {
+				return err
+			}
+			if ip := net.ParseIP(host); ip == nil || IsPrivateIP(ip) {
+				return fmt.Errorf("%w: %s", ErrPrivateAddress, host)
+			}
+			return nil
+		},
+	}
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.Proxy = nil // A proxy would make the dialer see the proxy's address instead of the target's
+	transport.DialContext = dialer.DialContext
+
+	return &http.Client{
+		Timeout:   timeout,
+		Transport: transport,
+		CheckRedirect: func(req *http.Request, via []*http.Request) error {
+			if len(via) > maxRedirects {
+				return fmt.Errorf("stopped after %d redirects", maxRedirects)
+			}
+			if req.URL.Scheme != "http" && req.URL.Scheme != "https" {
+				return fmt.Errorf("refusing to follow redirect to %q", req.URL.Scheme)
+			}
+			return nil
+		},
+	}
+}
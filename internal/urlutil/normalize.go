@@ -0,0 +1,46 @@
+package urlutil
+
+import (
+	"fmt"
+	"net"
+	"net/url"
+	"strings"
+)
+
+// trackingParams are query parameters that identify a campaign or click, not the resource.
+var trackingParams = []string{"utm_source", "utm_medium", "utm_campaign", "fbclid", "gclid"}
+
+// NormalizeURL returns a canonical form of rawURL so that equivalent URLs hash to the same short ID.
+// It lowercases the scheme and host, drops the default port (:80 for http, :443 for https),
+// removes common tracking parameters, sorts the remaining query parameters, and strips trailing
+// slashes from the path. The path keeps its case, since servers may treat it case-sensitively.
+func NormalizeURL(rawURL string) (string, error) {
+	parsed, err := url.Parse(rawURL)
+	if err != nil {
+		return "", fmt.Errorf("URL is malformed: %w", err)
+	}
+
+	parsed.Scheme = strings.ToLower(parsed.Scheme)
+	parsed.Host = strings.ToLower(parsed.Host)
+	if host, port, err := net.SplitHostPort(parsed.Host); err == nil {
+		if (parsed.Scheme == "http" && port == "80") || (parsed.Scheme == "https" && port == "443") {
+			parsed.Host = host
+			if strings.Contains(host, ":") {
+				parsed.Host = "[" + host + "]" // IPv6 literals keep their brackets
+			}
+		}
+	}
+
+	if parsed.RawQuery != "" {
+		query := parsed.Query()
+		for _, param := range trackingParams {
+			query.Del(param)
+		}
+		parsed.RawQuery = query.Encode() // Encode sorts by key
+	}
+
+	parsed.Path = strings.TrimRight(parsed.Path, "/")
+	parsed.RawPath = strings.TrimRight(parsed.RawPath, "/")
+
+	return parsed.String(), nil
+}
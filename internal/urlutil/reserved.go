@@ -0,0 +1,46 @@
+package urlutil
+
+import "strings"
+
+// ReservedCodes are the path segments used by the application's routes. They may not be
+// registered as custom short codes, so that a short link can never be mistaken for, or shadow,
+// a real endpoint. Add the segment here whenever a new route is registered.
+var ReservedCodes = map[string]struct{}{
+	"api":          {}, // Versioned API prefix, /api/v1
+	"shorten":      {},
+	"bulk":         {},
+	"token":        {},
+	"r":            {},
+	"info":         {},
+	"preview":      {},
+	"qr":           {},
+	"stats":        {},
+	"track":        {},
+	"convert":      {},
+	"admin":        {},
+	"urls":         {},
+	"search":       {},
+	"export":       {},
+	"import":       {},
+	"blacklist":    {},
+	"audit":        {},
+	"undelete":     {},
+	"tenants":      {},
+	"domains":      {},
+	"events":       {},
+	"dashboard":    {},
+	"healthz":      {},
+	"livez":        {},
+	"readyz":       {},
+	"metrics":      {},
+	"docs":         {},
+	"static":       {},
+	"openapi.json": {},
+}
+
+// IsReservedCode reports whether code is a reserved route segment. The comparison ignores case,
+// since "Admin" would be just as confusing as "admin".
+func IsReservedCode(code string) bool {
+	_, reserved := ReservedCodes[strings.ToLower(code)]
+	return reserved
+}
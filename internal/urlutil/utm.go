@@ -0,0 +1,42 @@
+package urlutil
+
+import (
+	"fmt"
+	"net/url"
+
+	"shawty/internal/domain"
+)
+
+// InjectUTM adds the non-empty fields of utm to rawURL as utm_* query parameters.
+// Parameters already present in rawURL are kept as they are, and the existing query string
+// and fragment are left untouched apart from the appended parameters.
+func InjectUTM(rawURL string, utm domain.UTMParams) (string, error) {
+	parsed, err := url.Parse(rawURL)
+	if err != nil {
+		return "", fmt.Errorf("URL is malformed: %w", err)
+	}
+
+	existing := parsed.Query()
+	extra := url.Values{}
+	for _, param := range []struct{ key, value string }{
+		{"utm_source", utm.Source},
+		{"utm_medium", utm.Medium},
+		{"utm_campaign", utm.Campaign},
+		{"utm_term", utm.Term},
+		{"utm_content", utm.Content},
+	} {
+		if param.value != "" && !existing.Has(param.key) {
+			extra.Set(param.key, param.value)
+		}
+	}
+	if len(extra) == 0 {
+		return rawURL, nil
+	}
+
+	if parsed.RawQuery == "" {
+		parsed.RawQuery = extra.Encode()
+	} else {
+		parsed.RawQuery += "&" + extra.Encode()
+	}
+	return parsed.String(), nil
+}
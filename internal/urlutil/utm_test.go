@@ -0,0 +1,54 @@
+package urlutil
+
+import (
+	"testing"
+
+	"shawty/internal/domain"
+)
+
+func TestInjectUTM(t *testing.T) {
+	campaign := domain.UTMParams{Source: "newsletter", Medium: "email", Campaign: "spring"}
+
+	tests := []struct {
+		name string
+		url  string
+		utm  domain.UTMParams
+		want string
+	}{
+		{name: "no query", url: "https://example.com/page", utm: campaign,
+			want: "https://example.com/page?utm_campaign=spring&utm_medium=email&utm_source=newsletter"},
+		{name: "every field", url: "https://example.com/", utm: domain.UTMParams{Source: "s", Medium: "m", Campaign: "c", Term: "t", Content: "x"},
+			want: "https://example.com/?utm_campaign=c&utm_content=x&utm_medium=m&utm_source=s&utm_term=t"},
+		{name: "existing query kept in its order", url: "https://example.com/search?q=go&a=1", utm: domain.UTMParams{Source: "ads"},
+			want: "https://example.com/search?q=go&a=1&utm_source=ads"},
+		{name: "existing query encoding kept", url: "https://example.com/?q=a%20b&tag=x+y", utm: domain.UTMParams{Source: "ads"},
+			want: "https://example.com/?q=a%20b&tag=x+y&utm_source=ads"},
+		{name: "existing utm values not overwritten", url: "https://example.com/?utm_source=twitter&utm_medium=", utm: campaign,
+			want: "https://example.com/?utm_source=twitter&utm_medium=&utm_campaign=spring"},
+		{name: "every utm value present", url: "https://example.com/?utm_source=a&utm_medium=b&utm_campaign=c", utm: campaign,
+			want: "https://example.com/?utm_source=a&utm_medium=b&utm_campaign=c"},
+		{name: "fragment", url: "https://example.com/docs#install", utm: domain.UTMParams{Source: "ads"},
+			want: "https://example.com/docs?utm_source=ads#install"},
+		{name: "query and fragment", url: "https://example.com/docs?v=2#install", utm: domain.UTMParams{Source: "ads"},
+			want: "https://example.com/docs?v=2&utm_source=ads#install"},
+		{name: "values escaped", url: "https://example.com/", utm: domain.UTMParams{Campaign: "summer sale & more"},
+			want: "https://example.com/?utm_campaign=summer+sale+%26+more"},
+		{name: "no fields", url: "https://example.com/page?x=1#top", utm: domain.UTMParams{},
+			want: "https://example.com/page?x=1#top"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := InjectUTM(tt.url, tt.utm)
+			if err != nil {
+				t.Fatalf("InjectUTM(%q): %v", tt.url, err)
+			}
+			if got != tt.want {
+				t.Errorf("InjectUTM(%q) = %q, want %q", tt.url, got, tt.want)
+			}
+		})
+	}
+
+	if _, err := InjectUTM("https://exa mple.com/%zz", campaign); err == nil {
+		t.Error("InjectUTM of a malformed URL succeeded, want an error")
+	}
+}
@@ -0,0 +1,79 @@
+// Package urlutil contains helpers for validating and manipulating the URLs users submit.
+package urlutil
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"net/url"
+	"strings"
+)
+
+// MaxURLLength is the longest URL accepted for shortening.
+const MaxURLLength = 2048
+
+// lookupIP resolves hostnames; it is a variable so the resolver can be swapped out.
+var lookupIP = net.LookupIP
+
+// ValidateURL checks that rawURL is an absolute http(s) URL with a host that does not point
+// at a loopback, private, or link-local address. Hostnames are resolved so that names
+// pointing at internal addresses are rejected too (SSRF prevention). A hostname that
+// cannot be resolved is accepted, since it cannot be used to reach an internal service.
+func ValidateURL(rawURL string) error {
+	if rawURL == "" {
+		return errors.New("URL is empty")
+	}
+	if len(rawURL) > MaxURLLength {
+		return fmt.Errorf("URL is longer than %d characters", MaxURLLength)
+	}
+
+	parsed, err := url.Parse(rawURL)
+	if err != nil {
+		return fmt.Errorf("URL is malformed: %w", err)
+	}
+	scheme := strings.ToLower(parsed.Scheme)
+	if scheme != "http" && scheme != "https" {
+		return fmt.Errorf("URL scheme must be http or https, got %q", parsed.Scheme)
+	}
+	host := parsed.Hostname()
+	if host == "" {
+		return errors.New("URL has no host")
+	}
+
+	return checkPublicHost(host)
+}
+
+// checkPublicHost rejects hosts that are, or resolve to, non-public IP addresses.
+func checkPublicHost(host string) error {
+	if strings.EqualFold(host, "localhost") || strings.HasSuffix(strings.ToLower(host), ".localhost") {
+		return fmt.Errorf("host %q is not allowed", host)
+	}
+
+	if ip := net.ParseIP(host); ip != nil {
+		if IsPrivateIP(ip) {
+			return fmt.Errorf("host %q is a private or loopback address", host)
+		}
+		return nil
+	}
+
+	ips, err := lookupIP(host)
+	if err != nil {
+		return nil
+	}
+	for _, ip := range ips {
+		if IsPrivateIP(ip) {
+			return fmt.Errorf("host %q resolves to private address %s", host, ip)
+		}
+	}
+	return nil
+}
+
+// IsPrivateIP reports whether ip is a loopback, private (10/8, 172.16/12, 192.168/16, fc00::/7),
+// link-local, or unspecified address.
+func IsPrivateIP(ip net.IP) bool {
+	return ip.IsLoopback() ||
+		ip.IsPrivate() ||
+		ip.IsLinkLocalUnicast() ||
+		ip.IsLinkLocalMulticast() ||
+		ip.IsUnspecified()
+}
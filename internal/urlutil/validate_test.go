@@ -0,0 +1,66 @@
+package urlutil
+
+import (
+	"errors"
+	"net"
+	"strings"
+	"testing"
+)
+
+func TestValidateURL(t *testing.T) {
+	// Names resolve from this table instead of DNS, so the test runs offline.
+	hosts := map[string][]net.IP{
+		"example.com":        {net.ParseIP("93.184.216.34")},
+		"internal.example":   {net.ParseIP("10.0.0.5")},
+		"rebind.example.com": {net.ParseIP("93.184.216.34"), net.ParseIP("127.0.0.1")},
+	}
+	lookupIP = func(host string) ([]net.IP, error) {
+		if ips, ok := hosts[host]; ok {
+			return ips, nil
+		}
+		return nil, errors.New("no such host")
+	}
+	t.Cleanup(func() { lookupIP = net.LookupIP })
+
+	tests := []struct {
+		name    string
+		url     string
+		wantErr bool
+	}{
+		{name: "https", url: "https://example.com/path?q=1"},
+		{name: "http", url: "http://example.com"},
+		{name: "uppercase scheme", url: "HTTPS://example.com"},
+		{name: "public IP", url: "https://93.184.216.34/"},
+		{name: "unresolvable host", url: "https://does-not-resolve.example"},
+		{name: "longest allowed", url: "https://example.com/" + strings.Repeat("a", MaxURLLength-len("https://example.com/"))},
+		{name: "empty", url: "", wantErr: true},
+		{name: "bare hostname", url: "example.com", wantErr: true},
+		{name: "bare hostname with path", url: "example.com/path", wantErr: true},
+		{name: "file scheme", url: "file:///etc/passwd", wantErr: true},
+		{name: "javascript scheme", url: "javascript:alert(1)", wantErr: true},
+		{name: "ftp scheme", url: "ftp://example.com/file", wantErr: true},
+		{name: "no host", url: "https:///path", wantErr: true},
+		{name: "malformed", url: "https://exa mple.com/%zz", wantErr: true},
+		{name: "too long", url: "https://example.com/" + strings.Repeat("a", MaxURLLength), wantErr: true},
+		{name: "localhost", url: "http://localhost:8080", wantErr: true},
+		{name: "localhost subdomain", url: "http://api.localhost", wantErr: true},
+		{name: "loopback", url: "http://127.0.0.1/admin", wantErr: true},
+		{name: "private 10/8", url: "http://10.1.2.3", wantErr: true},
+		{name: "private 172.16/12", url: "http://172.16.0.1", wantErr: true},
+		{name: "private 192.168/16", url: "http://192.168.1.1", wantErr: true},
+		{name: "link-local metadata", url: "http://169.254.169.254/latest/meta-data", wantErr: true},
+		{name: "unspecified", url: "http://0.0.0.0", wantErr: true},
+		{name: "IPv6 loopback", url: "http://[::1]/", wantErr: true},
+		{name: "IPv6 unique local", url: "http://[fd00::1]/", wantErr: true},
+		{name: "name resolving to a private address", url: "https://internal.example", wantErr: true},
+		{name: "name resolving to a private address among public ones", url: "https://rebind.example.com", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ValidateURL(tt.url)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("ValidateURL(%.60q) error = %v, want error %v", tt.url, err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,93 @@
+// Package webhook delivers event notifications to user-supplied URLs.
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"shawty/internal/urlutil"
+)
+
+// Delivery settings. Each attempt may take up to Timeout; failed attempts are retried after
+// a backoff that starts at InitialBackoff and doubles, up to MaxAttempts attempts in total.
+const (
+	Timeout        = 5 * time.Second
+	MaxAttempts    = 3
+	InitialBackoff = 500 * time.Millisecond
+)
+
+// EventFirstClick is the event sent when a short URL is followed for the first time.
+const EventFirstClick = "first_click"
+
+// FirstClickEvent is the payload of an EventFirstClick webhook.
+type FirstClickEvent struct {
+	Event       string    `json:"event"`
+	ShortID     string    `json:"short_id"`
+	OriginalURL string    `json:"original_url"`
+	ClickedAt   time.Time `json:"clicked_at"`
+	IP          string    `json:"ip"`
+}
+
+// httpClient delivers webhooks. Webhook URLs are validated when a link is created, and the
+// client re-checks every connection so that a DNS change cannot point it at a private address.
+// Redirects are not followed.
+var httpClient = urlutil.NewSafeHTTPClient(Timeout, 0)
+
+// Dispatch POSTs payload as JSON to url. Network errors, 429 and 5xx responses are retried
+// with exponential backoff; other non-2xx responses fail immediately. It returns the error of
+// the last attempt, or ctx's error if ctx is done while waiting to retry.
+func Dispatch(ctx context.Context, url string, payload any) error {
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return fmt.Errorf("failed to encode webhook payload: %w", err)
+	}
+
+	backoff := InitialBackoff
+	for attempt := 1; ; attempt++ {
+		retry, err := post(ctx, url, body)
+		if err == nil {
+			return nil
+		}
+		if !retry || attempt == MaxAttempts {
+			return fmt.Errorf("webhook to '%s' failed after %d attempt(s): %w", url, attempt, err)
+		}
+
+		timer := time.NewTimer(backoff)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return fmt.Errorf("webhook to '%s' abandoned after %d attempt(s): %w", url, attempt, ctx.Err())
+		case <-timer.C:
+		}
+		backoff *= 2
+	}
+}
+
+// post makes one delivery attempt and reports whether a failure is worth retrying.
+func post(ctx context.Context, url string, body []byte) (bool, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return false, fmt.Errorf("failed to build webhook request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "ShawtyWebhook/1.0")
+
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return true, err
+	}
+	defer resp.Body.Close()
+	// Drain a little of the body so that the connection can be reused.
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 4<<10))
+
+	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+		return false, nil
+	}
+	retry := resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= 500
+	return retry, fmt.Errorf("unexpected status %d", resp.StatusCode)
+}
@@ -0,0 +1,117 @@
+package webhook
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"shawty/internal/urlutil"
+)
+
+// useTestClient lets Dispatch reach httptest servers, which listen on loopback addresses that
+// the production client refuses.
+func useTestClient(t *testing.T) {
+	t.Helper()
+	httpClient = &http.Client{Timeout: Timeout}
+	t.Cleanup(func() { httpClient = urlutil.NewSafeHTTPClient(Timeout, 0) })
+}
+
+// newTarget starts a webhook target answering with the given statuses in turn, repeating the
+// last one, and returns it with the counter of requests it received.
+func newTarget(t *testing.T, statuses ...int) (*httptest.Server, *atomic.Int32) {
+	t.Helper()
+	var calls atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := int(calls.Add(1))
+		w.WriteHeader(statuses[min(n, len(statuses))-1])
+	}))
+	t.Cleanup(server.Close)
+	return server, &calls
+}
+
+func TestDispatchPostsPayload(t *testing.T) {
+	useTestClient(t)
+	received := make(chan FirstClickEvent, 1)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost || r.Header.Get("Content-Type") != "application/json" {
+			t.Errorf("got %s with Content-Type %q, want a JSON POST", r.Method, r.Header.Get("Content-Type"))
+		}
+		var event FirstClickEvent
+		if err := json.NewDecoder(r.Body).Decode(&event); err != nil {
+			t.Errorf("decoding payload: %v", err)
+		}
+		received <- event
+	}))
+	defer server.Close()
+
+	sent := FirstClickEvent{
+		Event:       EventFirstClick,
+		ShortID:     "abc123",
+		OriginalURL: "https://example.com",
+		ClickedAt:   time.Date(2025, 1, 2, 3, 4, 5, 0, time.UTC),
+		IP:          "203.0.113.7",
+	}
+	if err := Dispatch(context.Background(), server.URL, sent); err != nil {
+		t.Fatalf("Dispatch: %v", err)
+	}
+	if got := <-received; got != sent {
+		t.Errorf("target received %+v, want %+v", got, sent)
+	}
+}
+
+func TestDispatchRetries(t *testing.T) {
+	useTestClient(t)
+	tests := []struct {
+		name      string
+		statuses  []int
+		wantCalls int32
+		wantErr   bool
+	}{
+		{name: "recovers from server errors", statuses: []int{500, 503, 204}, wantCalls: 3},
+		{name: "retries rate limiting", statuses: []int{429, 200}, wantCalls: 2},
+		{name: "gives up after the last attempt", statuses: []int{502}, wantCalls: MaxAttempts, wantErr: true},
+		{name: "does not retry client errors", statuses: []int{400}, wantCalls: 1, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server, calls := newTarget(t, tt.statuses...)
+			err := Dispatch(context.Background(), server.URL, map[string]string{"event": "test"})
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Dispatch error = %v, want error %v", err, tt.wantErr)
+			}
+			if got := calls.Load(); got != tt.wantCalls {
+				t.Errorf("target received %d requests, want %d", got, tt.wantCalls)
+			}
+		})
+	}
+}
+
+func TestDispatchStopsWhenContextIsDone(t *testing.T) {
+	useTestClient(t)
+	server, calls := newTarget(t, http.StatusServiceUnavailable)
+	ctx, cancel := context.WithTimeout(context.Background(), InitialBackoff/5)
+	defer cancel()
+
+	if err := Dispatch(ctx, server.URL, "payload"); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Dispatch error = %v, want context.DeadlineExceeded", err)
+	}
+	if got := calls.Load(); got != 1 {
+		t.Errorf("target received %d requests, want 1", got)
+	}
+}
+
+func TestDispatchRefusesPrivateAddresses(t *testing.T) {
+	server, calls := newTarget(t, http.StatusOK)
+
+	if err := Dispatch(context.Background(), server.URL, "payload"); !errors.Is(err, urlutil.ErrPrivateAddress) {
+		t.Errorf("Dispatch to %s error = %v, want urlutil.ErrPrivateAddress", server.URL, err)
+	}
+	if got := calls.Load(); got != 0 {
+		t.Errorf("target received %d requests, want 0", got)
+	}
+}
@@ -3,123 +3,263 @@ package main
 import (
 	"context"
 	"fmt"
-	"log"
+	"log/slog"
 	"net/http"
-	"os"
 	"os/signal"
+	"shawty/internal/cache"
+	"shawty/internal/cdn"
+	"shawty/internal/circuitbreaker"
+	"shawty/internal/cleanup"
 	"shawty/internal/config"
+	"shawty/internal/docs"
+	"shawty/internal/events"
+	"shawty/internal/geoip"
 	"shawty/internal/handler"
+	"shawty/internal/logger"
+	"shawty/internal/metrics"
+	"shawty/internal/middleware"
 	"shawty/internal/service"
 	"shawty/internal/store"
+	"shawty/internal/tracing"
+	"shawty/internal/urlutil"
 	"syscall"
 	"time"
+
+	"github.com/redis/go-redis/v9"
 )
 
+// apiVersion is the current version of the HTTP API, served under /api/v{apiVersion}.
+const apiVersion = "1"
+
 func main() {
 	// Load application configuration
-	dbCfg := config.LoadConfig()
+	cfg := config.LoadConfig()
 
-	// Connect to MongoDB
-	dbClient, err := config.ConnectDB(dbCfg)
+	shutdownTracing, err := tracing.Setup(context.Background(), cfg.OTelEndpoint)
 	if err != nil {
-		log.Fatalf("Failed to connect to database: %v", err)
+		logger.Fatal("Failed to set up tracing", slog.Any("error", err))
 	}
 	defer func() {
-		if err = dbClient.Disconnect(context.Background()); err != nil {
-			log.Printf("Failed to disconnect MongoDB client: %v", err)
-		} else {
-			fmt.Println("Disconnected from MongoDB.")
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		if err := shutdownTracing(ctx); err != nil {
+			slog.Error("Failed to flush traces", slog.Any("error", err))
 		}
 	}()
 
 	// Initialize store
-	urlStore := store.NewMongoUrlStore(dbClient, dbCfg.DBName, dbCfg.CollectionName)
+	urlStore, closeStore, err := openURLStore(cfg)
+	if err != nil {
+		logger.Fatal("Failed to connect to database", slog.Any("error", err))
+	}
+
+	// Keep a handle on the underlying store for health probes and the audit log, before any decorators are applied
+	baseStore := urlStore
+	healthChecker, ok := urlStore.(store.HealthChecker)
+	if !ok {
+		logger.Fatal("URL store does not support health checks", slog.String("store", fmt.Sprintf("%T", urlStore)))
+	}
 
-	// This is a good practice to do on startup.
-	ctx, cancelIdx := context.WithTimeout(context.Background(), 10*time.Second)
-	defer cancelIdx()
-	if err := urlStore.EnsureIndexes(ctx); err != nil {
-		log.Fatalf("Failed to ensure database indexes: %v", err)
+	// Ride out short blips by retrying lookups; the breaker counts a retried lookup once.
+	if cfg.StoreMaxRetries > 0 {
+		urlStore = store.NewRetryStore(urlStore, cfg.StoreMaxRetries, cfg.StoreRetryBase)
+	}
+	// Fail fast while the database is down; caches in front of the breaker keep serving hot links.
+	urlStore = circuitbreaker.NewBreakerStore(urlStore, cfg.StoreBackend)
+
+	// Put the Redis cache in front of the store when configured
+	var redisCache service.Cache
+	if cfg.Redis.Addr != "" {
+		rdb := redis.NewClient(&redis.Options{Addr: cfg.Redis.Addr, Password: cfg.Redis.Password})
+		defer rdb.Close()
+
+		pingCtx, cancelPing := context.WithTimeout(context.Background(), 5*time.Second)
+		if err := rdb.Ping(pingCtx).Err(); err != nil {
+			// The cache bypasses Redis on errors, so a missing Redis only costs performance.
+			slog.Warn("Redis is not reachable, cache will be bypassed until it is", slog.String("addr", cfg.Redis.Addr), slog.Any("error", err))
+		}
+		cancelPing()
+
+		urlStore = store.NewCachedUrlStore(urlStore, rdb, cfg.Redis.CacheTTL)
+		redisCache = store.NewRedisCache(rdb)
+		slog.Info("Redis cache enabled", slog.String("addr", cfg.Redis.Addr), slog.Duration("ttl", cfg.Redis.CacheTTL))
+	} else if cfg.CacheSize > 0 {
+		// Without Redis, hot links are still kept out of the database by a per-instance cache.
+		urlStore = store.NewLocalCachedStoreWithTTL(urlStore, cfg.CacheSize, cfg.Redis.CacheTTL)
+		slog.Info("In-process cache enabled", slog.Int("size", cfg.CacheSize), slog.Duration("ttl", cfg.Redis.CacheTTL))
+	}
+
+	// MongoDB indexes are left to cmd/migrate unless AUTO_MIGRATE is set, so that replicas
+	// starting together do not all build them at once. The SQL backends keep creating their
+	// schema here, since nothing else does.
+	if cfg.AutoMigrate || cfg.StoreBackend != config.StoreBackendMongo {
+		ctx, cancelIdx := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancelIdx()
+		if err := urlStore.EnsureIndexes(ctx); err != nil {
+			logger.Fatal("Failed to ensure database indexes", slog.Any("error", err))
+		}
+	} else {
+		slog.Info("AUTO_MIGRATE not set, leaving MongoDB indexes to the migrate command")
 	}
 
 	// Initialize service
-	urlSvc := service.NewUrlService(urlStore)
+	blacklist := urlutil.NewBlacklist()
+	if cfg.BlacklistFile != "" {
+		blacklist, err = urlutil.LoadBlacklist(cfg.BlacklistFile)
+		if err != nil {
+			logger.Fatal("Failed to load blacklist", slog.String("path", cfg.BlacklistFile), slog.Any("error", err))
+		}
+		slog.Info("Loaded domain blacklist", slog.String("path", cfg.BlacklistFile), slog.Int("hosts", blacklist.Len()))
+	}
+
+	// The audit log is read from the undecorated store; only MongoDB keeps one.
+	auditStore, hasAudit := baseStore.(store.AuditStoreInterface)
+	// Click events are likewise only kept by MongoDB, and only when analytics are enabled.
+	analyticsStore, hasAnalytics := baseStore.(store.AnalyticsStoreInterface)
+	// Tenant quotas are likewise only kept by MongoDB; other stores create links without limits.
+	quotaStore, hasQuotas := baseStore.(store.QuotaStoreInterface)
+	// Per-domain stats are aggregated by MongoDB as well.
+	domainStatsStore, hasDomainStats := baseStore.(store.DomainStatsStoreInterface)
+	// Only MongoDB keeps the tenants a link can be transferred to. Transfers go through the
+	// decorators, so that the caches drop the entry with its previous tenant.
+	_, hasOwnership := baseStore.(store.OwnershipStoreInterface)
+	ownershipStore, _ := urlStore.(store.OwnershipStoreInterface)
+
+	// Background jobs run until appCtx is cancelled at shutdown.
+	appCtx, stopApp := context.WithCancel(context.Background())
+	defer stopApp()
+
+	// Expired links are deleted on a schedule instead of waiting for MongoDB's TTL reaper.
+	var cleaner *cleanup.Cleaner
+	if expiredStore, ok := baseStore.(store.ExpiredURLStoreInterface); ok && cfg.CleanupInterval > 0 {
+		cleaner = cleanup.NewCleaner(expiredStore, cfg.CleanupInterval)
+		cleaner.Start(appCtx)
+	}
+
+	// Post-processing hooks such as webhooks and page metadata run off the event bus, after the response.
+	eventBus := events.NewBus()
+	// Dashboards follow the clicks of a link over server-sent events fed from the bus.
+	clickBroadcaster := events.NewClickBroadcaster()
+	eventBus.Subscribe(events.URLClicked, clickBroadcaster.HandleClick)
+
+	svcOpts := []service.Option{
+		service.WithEventBus(eventBus),
+		service.WithClickBroadcaster(clickBroadcaster),
+		service.WithHashAlgo(cfg.HashAlgo),
+		service.WithShortIDLength(cfg.ShortIDLength),
+		service.WithMaxCollisionRetries(cfg.MaxCollisionRetries),
+		service.WithBulkConcurrency(cfg.BulkConcurrency),
+		service.WithBlacklist(blacklist),
+	}
+	if redisCache != nil {
+		svcOpts = append(svcOpts, service.WithCache(redisCache))
+	}
+	if hasAudit {
+		svcOpts = append(svcOpts, service.WithAuditStore(auditStore))
+	}
+	if hasQuotas {
+		svcOpts = append(svcOpts, service.WithQuotaStore(quotaStore))
+	}
+	if hasDomainStats {
+		svcOpts = append(svcOpts, service.WithDomainStatsStore(domainStatsStore))
+	}
+	if hasOwnership {
+		svcOpts = append(svcOpts, service.WithOwnershipStore(ownershipStore))
+	}
+	if cfg.AnalyticsEnabled {
+		if hasAnalytics {
+			svcOpts = append(svcOpts, service.WithAnalyticsStore(analyticsStore))
+			if cfg.GeoIPDBPath != "" {
+				geo, err := geoip.Open(cfg.GeoIPDBPath)
+				if err != nil {
+					logger.Fatal("Failed to load GeoIP database", slog.String("path", cfg.GeoIPDBPath), slog.Any("error", err))
+				}
+				defer geo.Close()
+				svcOpts = append(svcOpts, service.WithGeoIP(geo))
+			}
+		} else {
+			slog.Warn("ANALYTICS_ENABLED is set, but the store does not keep click events", slog.String("store", cfg.StoreBackend))
+		}
+	}
+	if cfg.Cloudflare.Enabled() {
+		purger := cdn.NewCloudflarePurger(&http.Client{Timeout: cdn.CloudflareTimeout}, cdn.CloudflareAPIURL, cfg.Cloudflare.ZoneID, cfg.Cloudflare.APIToken)
+		svcOpts = append(svcOpts, service.WithCDNPurger(purger))
+	}
+	urlSvc := service.NewUrlService(urlStore, svcOpts...)
 
 	// Initialize HTTP handler
-	urlHandler := handler.NewURLHandler(urlSvc)
+	urlHandler, err := handler.NewURLHandler(urlSvc, cfg)
+	if err != nil {
+		logger.Fatal("Failed to create the URL handler", slog.Any("error", err))
+	}
 
 	// Setup HTTP server and routes
 	mux := http.NewServeMux()
-	urlHandler.RegisterRoutes(mux)
+	urlHandler.RegisterRoutes(mux, "v"+apiVersion)
+	if cfg.LegacyRoutesEnabled {
+		slog.Info("LEGACY_ROUTES_ENABLED is set, the API is also served at its unversioned paths")
+	}
+	// Readiness fails when a background job dies, so that the instance is taken out of rotation.
+	jobs := map[string]handler.BackgroundJob{"event_bus": eventBus}
+	if cleaner != nil {
+		jobs["cleanup"] = cleaner
+	}
+	handler.NewHealthHandler(healthChecker, jobs).RegisterRoutes(mux, cfg.LivenessPath, cfg.ReadinessPath)
+	apiDocs, err := docs.NewHandler()
+	if err != nil {
+		logger.Fatal("Failed to build the OpenAPI document", slog.Any("error", err))
+	}
+	apiDocs.RegisterRoutes(mux)
+	if cfg.MetricsToken != "" {
+		mux.Handle("/metrics", metrics.Handler(cfg.MetricsToken))
+	} else {
+		slog.Info("METRICS_TOKEN not set, /metrics is disabled")
+	}
 
-	port := os.Getenv("PORT")
-	if port == "" {
-		port = "8080" // Default port
-		log.Printf("PORT environment variable not set, using default %s", port)
+	// Idempotent responses are shared through Redis when it is configured, so a retry may reach any instance.
+	var idempotencyCache middleware.IdempotencyCache = cache.NewByteCache(cfg.CacheSize)
+	if redisCache != nil {
+		idempotencyCache = redisCache
 	}
 
 	server := &http.Server{
-		Addr:    ":" + port,
-		Handler: corsMiddleware(mux),
+		Addr:      cfg.Listen(),
+		Handler:   newRootHandler(cfg, mux, idempotencyCache),
+		ConnState: metrics.TrackConnState,
 		// Good practice: add timeouts to avoid resource exhaustion.
 		ReadTimeout:  5 * time.Second,
 		WriteTimeout: 10 * time.Second,
 		IdleTimeout:  120 * time.Second,
 	}
 
-	// Graceful shutdown
-	go func() {
-		log.Printf("Server starting on port %s", port)
-		if err := server.ListenAndServe(); err != nil && err != http.ErrServerClosed {
-			log.Fatalf("ListenAndServe error: %v", err)
-		}
-	}()
+	// Shutdown waits for open click streams, so they are ended as it starts.
+	server.RegisterOnShutdown(clickBroadcaster.Close)
 
-	// Wait for interrupt signal to gracefully shut down the server
-	quit := make(chan os.Signal, 1)
-	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
-	<-quit
-	log.Println("Shutting down server...")
-
-	// The context is used to inform the server it has 5 seconds to finish
-	// the request it is currently handling
-	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
-	defer cancel()
-	if err := server.Shutdown(ctx); err != nil {
-		log.Fatalf("Server forced to shutdown: %v", err)
+	// With TLS, the API moves to TLS_PORT and PORT only redirects to it.
+	serve := server.ListenAndServe
+	var redirectServer *http.Server
+	if cfg.TLS.Enabled() {
+		serve, redirectServer = enableTLS(cfg, server)
 	}
 
-	log.Println("Server exiting")
-}
-
-// corsMiddleware adds necessary CORS headers to each request.
-func corsMiddleware(next http.Handler) http.Handler {
-	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		allowedOrigins := []string{"http://localhost:3000", "https://shawty-jet.vercel.app"}
-		requestOrigin := r.Header.Get("Origin")
-
-		for _, origin := range allowedOrigins {
-			if requestOrigin == origin {
-				w.Header().Set("Access-Control-Allow-Origin", origin)
-				break
-			}
-		}
-
-		// Set allowed methods
-		w.Header().Set("Access-Control-Allow-Methods", "POST, GET, OPTIONS, PUT, DELETE")
-
-		// Set allowed headers
-		// "Content-Type" is important for your frontend's POST request.
-		w.Header().Set("Access-Control-Allow-Headers", "Accept, Content-Type, Content-Length, Accept-Encoding, X-CSRF-Token, Authorization")
+	// Serve until an interrupt, then drain gracefully. The store is closed only after the server
+	// has drained, so no request loses its database mid-flight.
+	signalCtx, stopSignals := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stopSignals()
+	if err := runServer(signalCtx, server, serve, redirectServer, cfg.ShutdownTimeout); err != nil {
+		logger.Fatal("ListenAndServe error", slog.Any("error", err))
+	}
+	// Hooks and background jobs still use the store, so they finish before it is closed.
+	eventBus.Close()
+	stopApp()
+	if cleaner != nil {
+		cleaner.Stop()
+	}
 
-		// Handle preflight requests (OPTIONS method)
-		// Browsers send an OPTIONS request first to check if the actual request is safe to send.
-		if r.Method == "OPTIONS" {
-			w.WriteHeader(http.StatusOK)
-			return
-		}
+	// Disconnecting gets its own deadline, since the drain may have used up the shutdown timeout.
+	closeCtx, cancelClose := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancelClose()
+	closeStore(closeCtx)
 
-		// Pass control to the next handler in the chain
-		next.ServeHTTP(w, r)
-	})
+	slog.Info("Server exiting")
 }
@@ -0,0 +1,504 @@
+// Package client is a Go client for the shawty HTTP API.
+//
+//	c := client.NewClient("https://s.example.com", apiKey, client.WithTimeout(5*time.Second))
+//	res, err := c.Shorten(ctx, "https://example.com/a/long/path", client.WithTags("docs"), client.WithTTL(24*time.Hour))
+//	if err != nil {
+//		return err
+//	}
+//	fmt.Println(res.ShortURL)
+//
+// Requests answered with 429 Too Many Requests or 503 Service Unavailable are retried up to
+// MaxRetries times, waiting as long as the server's Retry-After header asks.
+package client
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Retry settings. Without a Retry-After header, retries wait DefaultRetryDelay, doubling each time.
+const (
+	MaxRetries        = 3
+	DefaultRetryDelay = 500 * time.Millisecond
+	// MaxRetryDelay caps the wait a Retry-After header can ask for.
+	MaxRetryDelay = 30 * time.Second
+)
+
+// apiPrefix is the path prefix of the version of the API this client speaks.
+const apiPrefix = "/api/v1"
+
+// Client calls the API of one shawty server. It is safe for concurrent use.
+type Client struct {
+	baseURL    string
+	apiKey     string
+	httpClient *http.Client
+	userAgent  string
+}
+
+// ClientOption configures a Client.
+type ClientOption func(*Client)
+
+// WithHTTPClient makes the client send its requests with hc.
+func WithHTTPClient(hc *http.Client) ClientOption {
+	return func(c *Client) {
+		c.httpClient = hc
+	}
+}
+
+// WithTimeout bounds each request, retries excluded, to d.
+// It replaces the timeout of a client set with WithHTTPClient, so pass it after that option.
+func WithTimeout(d time.Duration) ClientOption {
+	return func(c *Client) {
+		hc := *c.httpClient
+		hc.Timeout = d
+		c.httpClient = &hc
+	}
+}
+
+// WithUserAgent sets the User-Agent header of the client's requests.
+func WithUserAgent(ua string) ClientOption {
+	return func(c *Client) {
+		c.userAgent = ua
+	}
+}
+
+// NewClient creates a client for the server at baseURL, e.g. "https://s.example.com".
+// apiKey is sent as a bearer token; it may be empty for calls that need no key.
+func NewClient(baseURL, apiKey string, opts ...ClientOption) *Client {
+	c := &Client{
+		baseURL:    strings.TrimRight(baseURL, "/"),
+		apiKey:     apiKey,
+		httpClient: &http.Client{Timeout: 10 * time.Second},
+		userAgent:  "shawty-go-client",
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c
+}
+
+// APIError is returned for responses with a non-2xx status.
+type APIError struct {
+	StatusCode int
+	Code       string // Machine-readable error code such as "NOT_FOUND"; empty if the server sent none
+	Message    string // Human-readable explanation, or the raw body if it was not a JSON error
+}
+
+func (e *APIError) Error() string {
+	return fmt.Sprintf("shawty: %d %s: %s", e.StatusCode, http.StatusText(e.StatusCode), e.Message)
+}
+
+// newAPIError builds the error for a non-2xx response from its JSON error body,
+// {"error": "...", "code": "..."}, falling back to the raw body for other responses.
+func newAPIError(statusCode int, body []byte) *APIError {
+	var payload struct {
+		Error string `json:"error"`
+		Code  string `json:"code"`
+	}
+	if err := json.Unmarshal(body, &payload); err == nil && payload.Error != "" {
+		return &APIError{StatusCode: statusCode, Code: payload.Code, Message: payload.Error}
+	}
+	return &APIError{StatusCode: statusCode, Message: strings.TrimSpace(string(body))}
+}
+
+// UTMParams are campaign parameters added to the destination's query string on redirect.
+type UTMParams struct {
+	Source   string `json:"source,omitempty"`
+	Medium   string `json:"medium,omitempty"`
+	Campaign string `json:"campaign,omitempty"`
+	Term     string `json:"term,omitempty"`
+	Content  string `json:"content,omitempty"`
+}
+
+// Variant is one destination of an A/B-tested link. Weight is the percentage of visitors sent to
+// URL; the link's own URL receives whatever the weights of its variants leave.
+// In a bundle, a Variant is one entry of the page: Name is its label and Weight stays zero.
+type Variant struct {
+	Name   string `json:"name,omitempty"`
+	URL    string `json:"url"`
+	Weight int    `json:"weight,omitempty"`
+}
+
+// transferRequest is the body of POST /admin/urls/{shortID}/transfer.
+type transferRequest struct {
+	NewOwner string `json:"new_owner"`
+}
+
+// shortenRequest is the body of POST /shorten.
+type shortenRequest struct {
+	URL              string            `json:"url"`
+	CustomCode       string            `json:"custom_code,omitempty"`
+	ExpiresInSeconds int64             `json:"expires_in_seconds,omitempty"`
+	RedirectType     string            `json:"redirect_type,omitempty"`
+	Password         string            `json:"password,omitempty"`
+	MaxClicks        *int64            `json:"max_clicks,omitempty"`
+	UTM              *UTMParams        `json:"utm,omitempty"`
+	Tags             []string          `json:"tags,omitempty"`
+	WebhookURL       string            `json:"webhook_url,omitempty"`
+	MobileURL        string            `json:"mobile_url,omitempty"`
+	Variants         []Variant         `json:"variants,omitempty"`
+	ActiveFrom       *time.Time        `json:"active_from,omitempty"`
+	ActiveUntil      *time.Time        `json:"active_until,omitempty"`
+	Bundle           bool              `json:"bundle,omitempty"`
+	BundleTitle      string            `json:"bundle_title,omitempty"`
+	CustomHeaders    map[string]string `json:"custom_headers,omitempty"`
+	Token            string            `json:"token,omitempty"`
+}
+
+// ShortenOption sets an optional property of a link created with Shorten.
+type ShortenOption func(*shortenRequest)
+
+// WithCustomCode asks for code as the short ID instead of a generated one.
+func WithCustomCode(code string) ShortenOption {
+	return func(r *shortenRequest) { r.CustomCode = code }
+}
+
+// WithTTL makes the link expire after ttl, rounded down to whole seconds.
+func WithTTL(ttl time.Duration) ShortenOption {
+	return func(r *shortenRequest) { r.ExpiresInSeconds = int64(ttl / time.Second) }
+}
+
+// WithTags labels the link with tags.
+func WithTags(tags ...string) ShortenOption {
+	return func(r *shortenRequest) { r.Tags = append(r.Tags, tags...) }
+}
+
+// WithRedirectType sets the redirect of the link: "permanent" (301) or "temporary" (302).
+func WithRedirectType(redirectType string) ShortenOption {
+	return func(r *shortenRequest) { r.RedirectType = redirectType }
+}
+
+// WithPassword protects the link; visitors must send the password to be redirected.
+func WithPassword(password string) ShortenOption {
+	return func(r *shortenRequest) { r.Password = password }
+}
+
+// WithMaxClicks makes the link stop redirecting after n clicks.
+func WithMaxClicks(n int64) ShortenOption {
+	return func(r *shortenRequest) { r.MaxClicks = &n }
+}
+
+// WithUTM adds campaign parameters to the destination on redirect.
+func WithUTM(utm UTMParams) ShortenOption {
+	return func(r *shortenRequest) { r.UTM = &utm }
+}
+
+// WithWebhook has the server POST to webhookURL when the link is followed for the first time.
+func WithWebhook(webhookURL string) ShortenOption {
+	return func(r *shortenRequest) { r.WebhookURL = webhookURL }
+}
+
+// WithMobileURL sends visitors on mobile devices to mobileURL instead.
+func WithMobileURL(mobileURL string) ShortenOption {
+	return func(r *shortenRequest) { r.MobileURL = mobileURL }
+}
+
+// WithVariants splits the link's visitors across variants by weight, for A/B tests.
+func WithVariants(variants ...Variant) ShortenOption {
+	return func(r *shortenRequest) { r.Variants = variants }
+}
+
+// WithBundle makes the link a bundle: instead of redirecting, it shows a page titled title that
+// lists links. The URL passed to Shorten may then be empty, in which case the first link is used.
+func WithBundle(title string, links ...Variant) ShortenOption {
+	return func(r *shortenRequest) {
+		r.Bundle = true
+		r.BundleTitle = title
+		r.Variants = links
+	}
+}
+
+// WithActivePeriod makes the link redirect only from from until until. A zero time leaves
+// that end of the period open.
+func WithActivePeriod(from, until time.Time) ShortenOption {
+	return func(r *shortenRequest) {
+		if !from.IsZero() {
+			r.ActiveFrom = &from
+		}
+		if !until.IsZero() {
+			r.ActiveUntil = &until
+		}
+	}
+}
+
+// WithCustomHeaders sets response headers, such as Cache-Control, on the link's redirects. At most
+// 10 headers are allowed; the server drops those it does not let links set, such as Set-Cookie.
+func WithCustomHeaders(headers map[string]string) ShortenOption {
+	return func(r *shortenRequest) { r.CustomHeaders = headers }
+}
+
+// WithToken sends the token ShortenToken issued for the URL, which servers running with
+// REQUIRE_TOKEN=true need.
+func WithToken(token string) ShortenOption {
+	return func(r *shortenRequest) {
+		r.Token = token
+	}
+}
+
+// ShortenResult is the link created, or returned again, by Shorten.
+type ShortenResult struct {
+	ShortURL        string            `json:"short_url"`
+	OriginalURL     string            `json:"original_url"`
+	CreationDate    time.Time         `json:"creation_date"`
+	ExpiresAt       *time.Time        `json:"expires_at,omitempty"`
+	RedirectType    string            `json:"redirect_type,omitempty"`
+	MaxClicks       *int64            `json:"max_clicks,omitempty"`
+	UTM             *UTMParams        `json:"utm,omitempty"`
+	Tags            []string          `json:"tags,omitempty"`
+	WebhookURL      string            `json:"webhook_url,omitempty"`
+	MobileURL       *string           `json:"mobile_url,omitempty"`
+	Variants        []Variant         `json:"variants,omitempty"`
+	ActiveFrom      *time.Time        `json:"active_from,omitempty"`
+	ActiveUntil     *time.Time        `json:"active_until,omitempty"`
+	Bundle          bool              `json:"bundle,omitempty"`
+	BundleTitle     string            `json:"bundle_title,omitempty"`
+	CustomHeaders   map[string]string `json:"custom_headers,omitempty"`
+	PageTitle       string            `json:"page_title"`
+	PageDescription string            `json:"page_description"`
+}
+
+// URLInfo is the full description of a link returned by GetInfo.
+type URLInfo struct {
+	ID                string            `json:"id"`
+	OriginalURL       string            `json:"original_url"`
+	ShortURL          string            `json:"short_url"`
+	CreationDate      time.Time         `json:"creation_date"`
+	ExpiresAt         *time.Time        `json:"expires_at,omitempty"`
+	ClickCount        int64             `json:"click_count"`
+	MobileClicks      int64             `json:"mobile_click_count"`
+	ConversionCount   int64             `json:"conversion_count"`
+	LastAccessedAt    *time.Time        `json:"last_accessed_at,omitempty"`
+	UpdatedAt         *time.Time        `json:"updated_at,omitempty"`
+	RedirectType      string            `json:"redirect_type,omitempty"`
+	MaxClicks         *int64            `json:"max_clicks,omitempty"`
+	UTM               *UTMParams        `json:"utm,omitempty"`
+	Tags              []string          `json:"tags,omitempty"`
+	MobileURL         *string           `json:"mobile_url,omitempty"`
+	Variants          []Variant         `json:"variants,omitempty"`
+	ActiveFrom        *time.Time        `json:"active_from,omitempty"`
+	ActiveUntil       *time.Time        `json:"active_until,omitempty"`
+	Bundle            bool              `json:"bundle,omitempty"`
+	BundleTitle       string            `json:"bundle_title,omitempty"`
+	CustomHeaders     map[string]string `json:"custom_headers,omitempty"`
+	Domain            string            `json:"domain,omitempty"`
+	PageTitle         string            `json:"page_title"`
+	PageDescription   string            `json:"page_description"`
+	IsExpired         bool              `json:"is_expired"`
+	IsActive          bool              `json:"is_active"`
+	ClicksRemaining   *int64            `json:"clicks_remaining"`
+	PasswordProtected bool              `json:"password_protected"`
+}
+
+// Stats are the click statistics of a link returned by Stats.
+type Stats struct {
+	ShortURL       string     `json:"short_url"`
+	OriginalURL    string     `json:"original_url"`
+	ClickCount     int64      `json:"click_count"`
+	MobileClicks   int64      `json:"mobile_click_count"`
+	LastAccessedAt *time.Time `json:"last_accessed_at"`
+	CreationDate   time.Time  `json:"creation_date"`
+}
+
+// ConversionStats compares the conversions of a link with its clicks, as returned by ConversionRate.
+type ConversionStats struct {
+	Clicks      int64   `json:"clicks"`
+	Conversions int64   `json:"conversions"`
+	Rate        float64 `json:"rate"` // Conversions / Clicks; 0 for links without clicks
+}
+
+// DomainStats counts the links created on one short domain and their clicks, as returned by DomainStats.
+type DomainStats struct {
+	Domain string `json:"domain"` // Empty for links created before the server recorded domains
+	Links  int64  `json:"links"`
+	Clicks int64  `json:"clicks"`
+}
+
+// Shorten creates a short link for longURL. Shortening a URL that was shortened before, without
+// options that make the link private, returns the existing link.
+func (c *Client) Shorten(ctx context.Context, longURL string, opts ...ShortenOption) (*ShortenResult, error) {
+	req := shortenRequest{URL: longURL}
+	for _, opt := range opts {
+		opt(&req)
+	}
+	payload, err := json.Marshal(req)
+	if err != nil {
+		return nil, fmt.Errorf("shawty: failed to encode request: %w", err)
+	}
+	var result ShortenResult
+	if err := c.do(ctx, http.MethodPost, apiPrefix+"/shorten", payload, &result); err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+// ShortenToken returns the token that approves longURL for Shorten with WithToken on servers that
+// require tokens. It needs the server's master admin token as API key.
+func (c *Client) ShortenToken(ctx context.Context, longURL string) (string, error) {
+	var result struct {
+		Token string `json:"token"`
+	}
+	if err := c.do(ctx, http.MethodGet, apiPrefix+"/shorten/token?url="+url.QueryEscape(longURL), nil, &result); err != nil {
+		return "", err
+	}
+	return result.Token, nil
+}
+
+// GetInfo returns the details of the link with shortID without following it.
+func (c *Client) GetInfo(ctx context.Context, shortID string) (*URLInfo, error) {
+	var info URLInfo
+	if err := c.do(ctx, http.MethodGet, apiPrefix+"/r/"+url.PathEscape(shortID)+"/info", nil, &info); err != nil {
+		return nil, err
+	}
+	return &info, nil
+}
+
+// Delete deletes the link with shortID. It needs the server's master admin token as API key.
+func (c *Client) Delete(ctx context.Context, shortID string) error {
+	return c.do(ctx, http.MethodDelete, apiPrefix+"/r/"+url.PathEscape(shortID), nil, nil)
+}
+
+// Stats returns the click statistics of the link with shortID.
+func (c *Client) Stats(ctx context.Context, shortID string) (*Stats, error) {
+	var stats Stats
+	if err := c.do(ctx, http.MethodGet, apiPrefix+"/stats/"+url.PathEscape(shortID), nil, &stats); err != nil {
+		return nil, err
+	}
+	return &stats, nil
+}
+
+// TrackConversion reports a conversion of a visit to the link with shortID. A non-empty sessionID
+// must be the UUID the visitor's click carried in its session_id query parameter; the conversion then
+// only counts if that click has not been converted yet.
+func (c *Client) TrackConversion(ctx context.Context, shortID, sessionID string) error {
+	path := apiPrefix + "/track/convert/" + url.PathEscape(shortID)
+	if sessionID != "" {
+		path += "?" + url.Values{"session_id": {sessionID}}.Encode()
+	}
+	return c.do(ctx, http.MethodPost, path, nil, nil)
+}
+
+// ConversionRate returns the clicks and conversions of the link with shortID.
+func (c *Client) ConversionRate(ctx context.Context, shortID string) (*ConversionStats, error) {
+	var stats ConversionStats
+	if err := c.do(ctx, http.MethodGet, apiPrefix+"/stats/"+url.PathEscape(shortID)+"/conversion-rate", nil, &stats); err != nil {
+		return nil, err
+	}
+	return &stats, nil
+}
+
+// DomainStats returns the links and clicks of each short domain the server answers on, most links
+// first. It needs the server's master admin token as API key.
+func (c *Client) DomainStats(ctx context.Context) ([]DomainStats, error) {
+	var stats []DomainStats
+	if err := c.do(ctx, http.MethodGet, apiPrefix+"/admin/domains/stats", nil, &stats); err != nil {
+		return nil, err
+	}
+	return stats, nil
+}
+
+// Transfer moves the link with shortID to the tenant newOwner, the owner name of its API key. The
+// tenant must already be known to the server, by having created a link or been given a quota. It
+// needs the server's master admin token as API key.
+func (c *Client) Transfer(ctx context.Context, shortID, newOwner string) error {
+	payload, err := json.Marshal(transferRequest{NewOwner: newOwner})
+	if err != nil {
+		return fmt.Errorf("shawty: failed to encode request: %w", err)
+	}
+	return c.do(ctx, http.MethodPost, apiPrefix+"/admin/urls/"+url.PathEscape(shortID)+"/transfer", payload, nil)
+}
+
+// do sends a request, retrying 429 and 503 responses, and decodes a 2xx response into out
+// unless out is nil.
+func (c *Client) do(ctx context.Context, method, path string, payload []byte, out any) error {
+	delay := DefaultRetryDelay
+	for attempt := 0; ; attempt++ {
+		resp, err := c.send(ctx, method, path, payload)
+		if err != nil {
+			return err
+		}
+		body, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+		resp.Body.Close()
+		if err != nil {
+			return fmt.Errorf("shawty: failed to read response: %w", err)
+		}
+
+		if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+			if out == nil {
+				return nil
+			}
+			if err := json.Unmarshal(body, out); err != nil {
+				return fmt.Errorf("shawty: failed to decode response: %w", err)
+			}
+			return nil
+		}
+		apiErr := newAPIError(resp.StatusCode, body)
+		// An exhausted quota also answers 429, but retrying cannot help with it.
+		retryable := (resp.StatusCode == http.StatusTooManyRequests && apiErr.Code != "QUOTA_EXCEEDED") || resp.StatusCode == http.StatusServiceUnavailable
+		if !retryable || attempt == MaxRetries {
+			return apiErr
+		}
+
+		wait := delay
+		if after, ok := retryAfter(resp.Header.Get("Retry-After")); ok {
+			wait = after
+		}
+		timer := time.NewTimer(wait)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return fmt.Errorf("%w (gave up retrying: %w)", apiErr, ctx.Err())
+		case <-timer.C:
+		}
+		delay *= 2
+	}
+}
+
+// send makes one attempt of a request.
+func (c *Client) send(ctx context.Context, method, path string, payload []byte) (*http.Response, error) {
+	var body io.Reader
+	if payload != nil {
+		body = bytes.NewReader(payload)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, c.baseURL+path, body)
+	if err != nil {
+		return nil, fmt.Errorf("shawty: failed to build request: %w", err)
+	}
+	if payload != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if c.apiKey != "" {
+		req.Header.Set("Authorization", "Bearer "+c.apiKey)
+	}
+	req.Header.Set("Accept", "application/json")
+	req.Header.Set("User-Agent", c.userAgent)
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("shawty: %s %s: %w", method, path, err)
+	}
+	return resp, nil
+}
+
+// retryAfter parses a Retry-After header given in seconds or as an HTTP date, capped at MaxRetryDelay.
+func retryAfter(header string) (time.Duration, bool) {
+	if header == "" {
+		return 0, false
+	}
+	var wait time.Duration
+	if seconds, err := strconv.Atoi(header); err == nil {
+		wait = time.Duration(seconds) * time.Second
+	} else if at, err := http.ParseTime(header); err == nil {
+		wait = time.Until(at)
+	} else {
+		return 0, false
+	}
+	return min(max(wait, 0), MaxRetryDelay), true
+}
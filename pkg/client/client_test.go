@@ -0,0 +1,160 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestShortenSendsOptionsAndCredentials(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost || r.URL.Path != "/api/v1/shorten" {
+			t.Errorf("got %s %s, want POST /api/v1/shorten", r.Method, r.URL.Path)
+		}
+		if got := r.Header.Get("Authorization"); got != "Bearer secret" {
+			t.Errorf("Authorization = %q, want Bearer secret", got)
+		}
+		if got := r.Header.Get("User-Agent"); got != "my-app/1.0" {
+			t.Errorf("User-Agent = %q, want my-app/1.0", got)
+		}
+		var req shortenRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			t.Errorf("decoding request: %v", err)
+		}
+		if req.URL != "https://example.com/page" || req.CustomCode != "my-brand" || req.ExpiresInSeconds != 3600 || len(req.Tags) != 2 {
+			t.Errorf("request = %+v, want the URL with custom code, TTL and tags", req)
+		}
+		w.WriteHeader(http.StatusCreated)
+		json.NewEncoder(w).Encode(ShortenResult{ShortURL: "https://s.example.com/my-brand", OriginalURL: req.URL, Tags: req.Tags})
+	}))
+	defer server.Close()
+
+	c := NewClient(server.URL+"/", "secret", WithUserAgent("my-app/1.0"))
+	res, err := c.Shorten(context.Background(), "https://example.com/page", WithCustomCode("my-brand"), WithTTL(time.Hour), WithTags("docs", "q4"))
+	if err != nil {
+		t.Fatalf("Shorten: %v", err)
+	}
+	if res.ShortURL != "https://s.example.com/my-brand" || len(res.Tags) != 2 {
+		t.Errorf("Shorten = %+v", res)
+	}
+}
+
+// statusServer answers with the given statuses in turn, repeating the last one, each with the
+// JSON error body {"error": "...", "code": code} and Retry-After: 0. It counts the requests.
+func statusServer(t *testing.T, code string, statuses ...int) (*httptest.Server, *atomic.Int32) {
+	t.Helper()
+	var calls atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := int(calls.Add(1))
+		status := statuses[min(n, len(statuses))-1]
+		w.Header().Set("Retry-After", "0")
+		w.WriteHeader(status)
+		if status >= 300 {
+			json.NewEncoder(w).Encode(map[string]string{"error": http.StatusText(status), "code": code})
+			return
+		}
+		w.Write([]byte(`{"short_url": "https://s.example.com/abc123", "click_count": 7}`))
+	}))
+	t.Cleanup(server.Close)
+	return server, &calls
+}
+
+func TestClientRetries(t *testing.T) {
+	tests := []struct {
+		name       string
+		code       string
+		statuses   []int
+		wantCalls  int32
+		wantStatus int // 0 for success
+	}{
+		{name: "rate limited then served", code: "RATE_LIMITED", statuses: []int{429, 429, 200}, wantCalls: 3},
+		{name: "unavailable then served", code: "SERVICE_UNAVAILABLE", statuses: []int{503, 200}, wantCalls: 2},
+		{name: "gives up after MaxRetries", code: "SERVICE_UNAVAILABLE", statuses: []int{503}, wantCalls: MaxRetries + 1, wantStatus: 503},
+		{name: "exhausted quota is not retried", code: "QUOTA_EXCEEDED", statuses: []int{429}, wantCalls: 1, wantStatus: 429},
+		{name: "client errors are not retried", code: "NOT_FOUND", statuses: []int{404}, wantCalls: 1, wantStatus: 404},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server, calls := statusServer(t, tt.code, tt.statuses...)
+			stats, err := NewClient(server.URL, "").Stats(context.Background(), "abc123")
+			if got := calls.Load(); got != tt.wantCalls {
+				t.Errorf("server received %d requests, want %d", got, tt.wantCalls)
+			}
+			if tt.wantStatus == 0 {
+				if err != nil || stats.ClickCount != 7 {
+					t.Errorf("Stats = %+v, %v; want 7 clicks", stats, err)
+				}
+				return
+			}
+			var apiErr *APIError
+			if !errors.As(err, &apiErr) || apiErr.StatusCode != tt.wantStatus || apiErr.Code != tt.code {
+				t.Errorf("Stats error = %v, want an APIError with status %d and code %s", err, tt.wantStatus, tt.code)
+			}
+		})
+	}
+}
+
+func TestClientStopsRetryingWhenContextIsDone(t *testing.T) {
+	var calls atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		w.Header().Set("Retry-After", "10")
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+
+	err := NewClient(server.URL, "").Delete(ctx, "abc123")
+	var apiErr *APIError
+	if !errors.Is(err, context.DeadlineExceeded) || !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusServiceUnavailable {
+		t.Errorf("Delete error = %v, want the 503 and context.DeadlineExceeded", err)
+	}
+	if got := calls.Load(); got != 1 {
+		t.Errorf("server received %d requests, want 1", got)
+	}
+}
+
+func TestWithTimeoutBoundsEachRequest(t *testing.T) {
+	release := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-release
+	}))
+	defer server.Close()
+	defer close(release)
+
+	custom := &http.Client{Timeout: time.Hour}
+	c := NewClient(server.URL, "", WithHTTPClient(custom), WithTimeout(20*time.Millisecond))
+	if _, err := c.GetInfo(context.Background(), "abc123"); err == nil {
+		t.Fatal("GetInfo succeeded, want a timeout")
+	}
+	if custom.Timeout != time.Hour {
+		t.Errorf("WithTimeout changed the caller's client to %v", custom.Timeout)
+	}
+}
+
+func TestRetryAfter(t *testing.T) {
+	tests := []struct {
+		header string
+		want   time.Duration
+		ok     bool
+	}{
+		{header: "", ok: false},
+		{header: "soon", ok: false},
+		{header: "2", want: 2 * time.Second, ok: true},
+		{header: "-5", want: 0, ok: true},
+		{header: "3600", want: MaxRetryDelay, ok: true},
+		{header: "Mon, 02 Jan 2006 15:04:05 GMT", want: 0, ok: true},
+	}
+	for _, tt := range tests {
+		got, ok := retryAfter(tt.header)
+		if got != tt.want || ok != tt.ok {
+			t.Errorf("retryAfter(%q) = %v, %t; want %v, %t", tt.header, got, ok, tt.want, tt.ok)
+		}
+	}
+}
@@ -0,0 +1,45 @@
+package client_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+
+	"shawty/pkg/client"
+)
+
+func ExampleClient_Shorten() {
+	// A stand-in for a shawty server.
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusCreated)
+		json.NewEncoder(w).Encode(map[string]string{"short_url": "https://s.example.com/my-brand", "original_url": "https://example.com/docs"})
+	}))
+	defer server.Close()
+
+	c := client.NewClient(server.URL, "api-key")
+	res, err := c.Shorten(context.Background(), "https://example.com/docs", client.WithCustomCode("my-brand"))
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	fmt.Println(res.ShortURL)
+	// Output: https://s.example.com/my-brand
+}
+
+func ExampleAPIError() {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+		w.Write([]byte(`{"error": "Short URL not found", "code": "NOT_FOUND"}`))
+	}))
+	defer server.Close()
+
+	_, err := client.NewClient(server.URL, "").GetInfo(context.Background(), "missing")
+	var apiErr *client.APIError
+	if errors.As(err, &apiErr) {
+		fmt.Println(apiErr.StatusCode, apiErr.Code)
+	}
+	// Output: 404 NOT_FOUND
+}
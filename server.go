@@ -0,0 +1,48 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"time"
+)
+
+// runServer serves server with serve, and redirectServer with ListenAndServe when it is not nil,
+// until ctx is done. It then stops both from accepting connections and gives in-flight requests
+// up to shutdownTimeout to finish before returning. If a server fails to start or stops serving
+// first, runServer returns its error at once and leaves the other one running.
+func runServer(ctx context.Context, server *http.Server, serve func() error, redirectServer *http.Server, shutdownTimeout time.Duration) error {
+	errs := make(chan error, 2)
+	listen := func(name string, serve func() error) {
+		if err := serve(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			errs <- fmt.Errorf("%s: %w", name, err)
+		}
+	}
+	if redirectServer != nil {
+		slog.Info("HTTP to HTTPS redirect starting", slog.String("addr", redirectServer.Addr))
+		go listen("HTTP redirect server", redirectServer.ListenAndServe)
+	}
+	slog.Info("Server starting", slog.String("addr", server.Addr), slog.Bool("tls", server.TLSConfig != nil))
+	go listen("server", serve)
+
+	select {
+	case err := <-errs:
+		return err
+	case <-ctx.Done():
+	}
+	slog.Info("Shutting down server")
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+	defer cancel()
+	if redirectServer != nil {
+		if err := redirectServer.Shutdown(shutdownCtx); err != nil {
+			slog.Error("HTTP redirect server forced to shutdown", slog.Any("error", err))
+		}
+	}
+	if err := server.Shutdown(shutdownCtx); err != nil {
+		slog.Error("Server forced to shutdown before in-flight requests finished", slog.Duration("timeout", shutdownTimeout), slog.Any("error", err))
+	}
+	return nil
+}
@@ -0,0 +1,97 @@
+package main
+
+import (
+	"context"
+	"io"
+	"net"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRunServerDrainsInFlightRequests(t *testing.T) {
+	started := make(chan struct{})
+	release := make(chan struct{})
+	server := &http.Server{
+		Addr: net.JoinHostPort("127.0.0.1", freePort(t)),
+		Handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			close(started)
+			<-release
+			io.WriteString(w, "finished")
+		}),
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	stopped := make(chan error, 1)
+	go func() { stopped <- runServer(ctx, server, server.ListenAndServe, nil, 5*time.Second) }()
+
+	type result struct {
+		body string
+		err  error
+	}
+	responses := make(chan result, 1)
+	go func() {
+		target := "http://" + server.Addr + "/slow"
+		for deadline := time.Now().Add(2 * time.Second); ; time.Sleep(10 * time.Millisecond) {
+			resp, err := http.Get(target)
+			if err != nil && time.Now().Before(deadline) {
+				continue
+			}
+			if err != nil {
+				responses <- result{err: err}
+				return
+			}
+			body, err := io.ReadAll(resp.Body)
+			resp.Body.Close()
+			responses <- result{body: string(body), err: err}
+			return
+		}
+	}()
+
+	select {
+	case <-started:
+	case res := <-responses:
+		t.Fatalf("the request ended before reaching the handler: %+v", res)
+	}
+	cancel()
+	// Shutdown waits for the request, so runServer must not return while it is in flight.
+	select {
+	case err := <-stopped:
+		t.Fatalf("runServer returned %v while a request was in flight", err)
+	case <-time.After(100 * time.Millisecond):
+	}
+	// New connections are refused once the shutdown has started.
+	if resp, err := http.Get("http://" + server.Addr + "/"); err == nil {
+		resp.Body.Close()
+		t.Error("the server accepted a new request after the shutdown started")
+	}
+
+	close(release)
+	if res := <-responses; res.err != nil || res.body != "finished" {
+		t.Errorf("in-flight request got %q, %v; want the handler's full response", res.body, res.err)
+	}
+	select {
+	case err := <-stopped:
+		if err != nil {
+			t.Errorf("runServer: %v", err)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("runServer did not return after the last request finished")
+	}
+}
+
+func TestRunServerReturnsListenErrors(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listening: %v", err)
+	}
+	defer ln.Close()
+	// The address is taken, so the server fails to start.
+	server := &http.Server{Addr: ln.Addr().String(), Handler: http.NotFoundHandler()}
+
+	err = runServer(context.Background(), server, server.ListenAndServe, nil, time.Second)
+	if err == nil || !strings.Contains(err.Error(), "address already in use") {
+		t.Errorf("runServer error = %v, want the listen error", err)
+	}
+}
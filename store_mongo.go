@@ -0,0 +1,113 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"shawty/internal/config"
+	"shawty/internal/store"
+)
+
+// openURLStore opens the store selected by STORE_BACKEND and returns it
+// together with a function that releases its connections.
+func openURLStore(cfg config.AppConfig) (store.UrlStoreInterface, func(context.Context), error) {
+	switch cfg.StoreBackend {
+	case config.StoreBackendPostgres:
+		return openPostgresStore(cfg.Postgres)
+	case config.StoreBackendSQLite:
+		return openSQLiteStore(cfg.SQLite)
+	case config.StoreBackendAtlas:
+		return openAtlasStore(cfg)
+	case config.StoreBackendDynamo:
+		return openDynamoStore(cfg.Dynamo)
+	default:
+		return openMongoStore(cfg.DB)
+	}
+}
+
+// openMongoStore connects to MongoDB and returns the driver-backed URL store
+// together with a function that disconnects the client.
+func openMongoStore(dbCfg config.DBConfig) (store.UrlStoreInterface, func(context.Context), error) {
+	// Connect to MongoDB
+	dbClient, err := config.ConnectDB(dbCfg)
+	if err != nil {
+		return nil, nil, err
+	}
+	closeFn := func(ctx context.Context) {
+		if err := dbClient.Disconnect(ctx); err != nil {
+			slog.Error("Failed to disconnect MongoDB client", slog.Any("error", err))
+		} else {
+			slog.Info("Disconnected from MongoDB")
+		}
+	}
+
+	mongoStore := store.NewMongoUrlStore(dbClient, dbCfg.DBName, dbCfg.CollectionName)
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	mongoStore.DetectDateTrunc(ctx)
+	return mongoStore, closeFn, nil
+}
+
+// openAtlasStore returns a URL store backed by the MongoDB Atlas Data API. No persistent
+// connection is held, so the returned close function is a no-op.
+func openAtlasStore(cfg config.AppConfig) (store.UrlStoreInterface, func(context.Context), error) {
+	httpClient := &http.Client{Timeout: cfg.Atlas.Timeout}
+	atlasStore := store.NewAtlasDataAPIStore(httpClient, cfg.Atlas.URL, cfg.Atlas.APIKey, cfg.Atlas.DataSource, cfg.DB.DBName, cfg.DB.CollectionName)
+	return atlasStore, func(context.Context) {}, nil
+}
+
+// openDynamoStore returns a URL store backed by a DynamoDB table. The AWS client holds no
+// connections that need closing, so the returned close function is a no-op.
+func openDynamoStore(dynamoCfg config.DynamoConfig) (store.UrlStoreInterface, func(context.Context), error) {
+	client, err := config.ConnectDynamo(dynamoCfg)
+	if err != nil {
+		return nil, nil, err
+	}
+	return store.NewDynamoUrlStore(client, dynamoCfg.TableName), func(context.Context) {}, nil
+}
+
+// openPostgresStore connects to PostgreSQL and returns the SQL-backed URL store
+// together with a function that closes the connection pool.
+func openPostgresStore(pgCfg config.PostgresConfig) (store.UrlStoreInterface, func(context.Context), error) {
+	db, err := config.ConnectPostgres(pgCfg)
+	if err != nil {
+		return nil, nil, err
+	}
+	closeFn := func(context.Context) {
+		if err := db.Close(); err != nil {
+			slog.Error("Failed to close PostgreSQL connection pool", slog.Any("error", err))
+		} else {
+			slog.Info("Disconnected from PostgreSQL")
+		}
+	}
+
+	return store.NewPostgresUrlStore(db), closeFn, nil
+}
+
+// openSQLiteStore opens the SQLite database file and returns the SQL-backed URL store
+// together with a function that closes the store and the database.
+func openSQLiteStore(sqliteCfg config.SQLiteConfig) (store.UrlStoreInterface, func(context.Context), error) {
+	db, err := config.ConnectSQLite(sqliteCfg)
+	if err != nil {
+		return nil, nil, err
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	sqliteStore, err := store.NewSQLiteUrlStore(ctx, db)
+	if err != nil {
+		db.Close()
+		return nil, nil, err
+	}
+	closeFn := func(context.Context) {
+		if err := errors.Join(sqliteStore.Close(), db.Close()); err != nil {
+			slog.Error("Failed to close SQLite database", slog.Any("error", err))
+		} else {
+			slog.Info("Closed SQLite database")
+		}
+	}
+
+	return sqliteStore, closeFn, nil
+}
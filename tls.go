@@ -0,0 +1,71 @@
+package main
+
+import (
+	"crypto/tls"
+	"log/slog"
+	"net"
+	"net/http"
+
+	"shawty/internal/config"
+
+	"golang.org/x/crypto/acme/autocert"
+)
+
+// enableTLS moves server to TLS_PORT and returns the function that serves it, together with a plain
+// HTTP server on HOST:PORT that answers every request with a 301 to the HTTPS URL.
+//
+// Certificates come either from TLS_CERT_FILE/TLS_KEY_FILE or, with AUTO_TLS_DOMAIN, from Let's Encrypt
+// through autocert. The trade-offs of serving TLS here rather than behind a reverse proxy:
+//   - Files are simplest, but the process must be restarted to pick up a renewed certificate.
+//   - autocert renews by itself, but Let's Encrypt must reach this process from the internet:
+//     the HTTP-01 challenge is answered on PORT and TLS-ALPN-01 on the HTTPS port, so they have
+//     to be, or be forwarded from, ports 80 and 443. Certificates are cached in AUTOCERT_DIR on local
+//     disk; replicas that do not share that directory each request their own certificates and soon
+//     hit Let's Encrypt's rate limits, so run a single instance or terminate TLS in front of them.
+//   - Either way, a proxy or load balancer is still the better choice for several instances,
+//     HTTP/3 or certificate management across services.
+func enableTLS(cfg config.AppConfig, server *http.Server) (func() error, *http.Server) {
+	tlsCfg := cfg.TLS
+	server.Addr = net.JoinHostPort(cfg.Host, tlsCfg.Port)
+	server.TLSConfig = &tls.Config{MinVersion: tls.VersionTLS12}
+
+	var redirect http.Handler = httpsRedirectHandler(tlsCfg.Port)
+	serve := func() error { return server.ListenAndServeTLS(tlsCfg.CertFile, tlsCfg.KeyFile) }
+	if tlsCfg.AutoDomain != "" {
+		manager := &autocert.Manager{
+			Prompt:     autocert.AcceptTOS,
+			HostPolicy: autocert.HostWhitelist(tlsCfg.AutoDomain),
+			Cache:      autocert.DirCache(tlsCfg.AutocertDir),
+		}
+		server.TLSConfig = manager.TLSConfig()
+		// The challenge handler passes every other request on to the redirect.
+		redirect = manager.HTTPHandler(redirect)
+		serve = func() error { return server.ListenAndServeTLS("", "") }
+		slog.Info("Let's Encrypt certificates enabled", slog.String("domain", tlsCfg.AutoDomain), slog.String("cache_dir", tlsCfg.AutocertDir))
+	}
+
+	redirectServer := &http.Server{
+		Addr:         cfg.Listen(),
+		Handler:      redirect,
+		ReadTimeout:  server.ReadTimeout,
+		WriteTimeout: server.WriteTimeout,
+		IdleTimeout:  server.IdleTimeout,
+	}
+	return serve, redirectServer
+}
+
+// httpsRedirectHandler redirects every request permanently to the same host and path over HTTPS
+// on tlsPort. The port is left out of the URL when it is the default 443.
+func httpsRedirectHandler(tlsPort string) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		host := r.Host
+		if h, _, err := net.SplitHostPort(host); err == nil {
+			host = h
+		}
+		if tlsPort != "443" {
+			host = net.JoinHostPort(host, tlsPort)
+		}
+		target := "https://" + host + r.URL.RequestURI()
+		http.Redirect(w, r, target, http.StatusMovedPermanently)
+	})
+}